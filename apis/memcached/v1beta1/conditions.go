@@ -0,0 +1,59 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+)
+
+// Condition Types used by API objects.
+const (
+	// DegradedCondition indicates that the instance works, but not as expected,
+	// e.g. because only a subset of the replicas is ready or because of an
+	// excessive eviction rate. It is only set while degraded
+	// and does not affect the Ready condition.
+	DegradedCondition condition.Type = "Degraded"
+
+	// PausedCondition indicates that the reconciliation is paused via the
+	// memcached.openstack.org/paused annotation. It is only set while paused
+	// and does not affect the Ready condition.
+	PausedCondition condition.Type = "Paused"
+)
+
+// Common Reasons used by API objects.
+const (
+	// ReplicasUnavailableReason
+	ReplicasUnavailableReason condition.Reason = "ReplicasUnavailable"
+
+	// EvictionRateReason
+	EvictionRateReason condition.Reason = "EvictionRate"
+
+	// PausedReason
+	PausedReason condition.Reason = "Paused"
+)
+
+// Common Messages used by API objects.
+const (
+	// DegradedReplicasMessage
+	DegradedReplicasMessage = "%d of %d replicas ready"
+
+	// DegradedEvictionRateMessage
+	DegradedEvictionRateMessage = "Eviction rate of %s is %d/min, exceeds %d/min, consider increasing the cache size"
+
+	// PausedMessage
+	PausedMessage = "Reconciliation paused, the owned resources are not updated"
+)
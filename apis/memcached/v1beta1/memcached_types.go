@@ -18,8 +18,11 @@ package v1beta1
 
 import (
 	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/tls"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 const (
@@ -27,6 +30,21 @@ const (
 
 	// MemcachedContainerImage is the fall-back container image for Memcached
 	MemcachedContainerImage = "quay.io/podified-antelope-centos9/openstack-memcached:current-podified"
+
+	// MemcachedProxyServiceSuffix - name suffix of the load balanced proxy Service
+	MemcachedProxyServiceSuffix = "proxy"
+
+	// MemcachedDeploymentModeStatefulSet - replicas run in a StatefulSet, each one addressed individually
+	MemcachedDeploymentModeStatefulSet = "StatefulSet"
+	// MemcachedDeploymentModeDaemonSet - a replica runs on every selected node and is addressed node-locally
+	MemcachedDeploymentModeDaemonSet = "DaemonSet"
+
+	// MemcachedDefaultCacheSizeMB - cache size used when neither the cache size nor a memory limit is set
+	MemcachedDefaultCacheSizeMB int64 = 9932
+
+	// MemcachedDefaultCacheSizeMemoryLimitPercent - share of the memory limit used as cache size,
+	// used if spec.cacheSizeMemoryLimitPercent is not set
+	MemcachedDefaultCacheSizeMemoryLimitPercent int32 = 80
 )
 
 // MemcachedSpec defines the desired state of Memcached
@@ -39,13 +57,356 @@ type MemcachedSpec struct {
 	// +kubebuilder:default=1
 	// Size of the memcached cluster
 	Replicas *int32 `json:"replicas"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=StatefulSet
+	// +kubebuilder:validation:Enum=StatefulSet;DaemonSet
+	// DeploymentMode - StatefulSet runs spec.replicas memcached pods which are all published in
+	// the server lists. DaemonSet runs a memcached pod on every node matching spec.nodeSelector,
+	// published via a single Service which routes to the pod on the node of the caller, for
+	// latency critical callers colocated with the nodes. spec.replicas is ignored in DaemonSet
+	// mode and the mode can not be changed after creation.
+	DeploymentMode string `json:"deploymentMode,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// NodeSelector - to target the nodes to run the memcached pods on
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// RestartSchedule - optional cron expression (minute hour day-of-month month day-of-week, UTC)
+	// to periodically perform a rolling restart of the memcached pods, one pod at a time,
+	// e.g. to get rid of slab fragmentation. Leave empty to disable scheduled restarts.
+	RestartSchedule string `json:"restartSchedule,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// TLS settings for memcached service
+	TLS tls.SimpleService `json:"tls,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=false
+	// KeepPlaintextPort - when TLS is enabled, additionally listen without TLS on a
+	// separate port, so that clients can be migrated to TLS one at a time
+	KeepPlaintextPort bool `json:"keepPlaintextPort,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=11211
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65534
+	// Port - port memcached listens on, e.g. if the default port conflicts with host network
+	// components. The plaintext port kept open during a TLS migration is the next one.
+	Port int32 `json:"port,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=false
+	// UDPEnabled - additionally listen on the UDP port for legacy clients still using the
+	// UDP protocol. Can not be combined with TLS, as the UDP traffic is not encrypted.
+	UDPEnabled bool `json:"udpEnabled,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=false
+	// PerPodServices - create a ClusterIP Service <name>-<ordinal> per memcached pod and publish
+	// these stable virtual IPs in the server lists instead of the pod addresses, so that client
+	// side consistent hashing is not disturbed by pod IP changes on restarts. Does not apply to
+	// the pools. With TLS the certificate has to be valid for <name>-<ordinal>.<namespace>.svc.
+	PerPodServices bool `json:"perPodServices,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=2
+	// IPFamilies - IP families of the memcached service, e.g. [IPv6] on IPv6-only clusters or
+	// [IPv4, IPv6] for dual-stack. The first one is the primary family. If not set, the cluster
+	// default is used.
+	IPFamilies []corev1.IPFamily `json:"ipFamilies,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=name
+	// Pools - additional named memcached pools, e.g. to isolate the caches of different
+	// consumers. Each pool gets its own StatefulSet and headless Service named <name>-<pool>.
+	Pools []MemcachedPool `json:"pools,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// NetworkPolicy - when set, a NetworkPolicy is created which limits the ingress
+	// to the memcached ports to the pods matching the selectors
+	NetworkPolicy *MemcachedNetworkPolicy `json:"networkPolicy,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// ExtraOptions - additional memcached command line options, one option with its
+	// optional value per entry, e.g. "-o modern,track_sizes" or "-t 8". Options managed
+	// by the operator, like the port, listen address, user, memory and connection
+	// limit or the TLS settings, are rejected.
+	ExtraOptions []string `json:"extraOptions,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// ServiceAffinity - when set, a load balanced Service <name>-proxy is created in addition
+	// to the headless Service, which keeps sending the requests of a client IP to the same
+	// memcached pod. To be used by proxying layers in front of the cache which need stable
+	// backend selection.
+	ServiceAffinity *MemcachedServiceAffinity `json:"serviceAffinity,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Cluster;Local
+	// ExternalTrafficPolicy - when set, the <name>-proxy Service is created with type
+	// LoadBalancer and this external traffic policy, e.g. Local to preserve the client
+	// source IP of external proxies.
+	ExternalTrafficPolicy corev1.ServiceExternalTrafficPolicyType `json:"externalTrafficPolicy,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=0
+	// +kubebuilder:validation:Minimum=0
+	// ScaleInDrainSeconds - on scale-in the departing replicas are removed from the published
+	// server lists and taken out of the endpoints of the memcached Services right away, but
+	// their pods are only deleted after this drain period, so that clients can rehash to the
+	// remaining replicas first. 0 deletes them immediately.
+	ScaleInDrainSeconds int32 `json:"scaleInDrainSeconds,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Resources - compute resources of the memcached container
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// CacheSizeMB - memory used for the cache in megabytes (memcached -m). If not set, it is
+	// derived from the memory limit of spec.resources, or a built-in default if no limit is set.
+	CacheSizeMB *int64 `json:"cacheSizeMB,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=80
+	// +kubebuilder:validation:Minimum=10
+	// +kubebuilder:validation:Maximum=95
+	// CacheSizeMemoryLimitPercent - share of the memory limit of spec.resources used as cache
+	// size if cacheSizeMB is not set. The rest is kept for the connection buffers and the
+	// memcached process itself.
+	CacheSizeMemoryLimitPercent int32 `json:"cacheSizeMemoryLimitPercent,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=1048576
+	// MaxItemSizeKB - maximum size of an item in kilobytes (memcached -I), at most half of the
+	// cache size. If not set, the memcached default of 1MB is used.
+	MaxItemSizeKB *int32 `json:"maxItemSizeKB,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// IdleTimeoutSeconds - close client connections idle for longer than the given seconds
+	// (memcached -o idle_timeout). If not set, idle connections are never closed.
+	IdleTimeoutSeconds *int32 `json:"idleTimeoutSeconds,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// MaxRequestsPerEvent - maximum number of requests processed per event for a single
+	// connection, to prevent starving the other connections (memcached -R). If not set, the
+	// memcached default of 20 is used.
+	MaxRequestsPerEvent *int32 `json:"maxRequestsPerEvent,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	// GrowthFactor - chunk size growth factor of the slab classes (memcached -f), must be
+	// greater than 1, e.g. "1.08". If not set, the memcached default of 1.25 is used.
+	GrowthFactor string `json:"growthFactor,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Stats - when set, the operator periodically collects the stats of each replica and
+	// reports them in the status. The operator connects to the memcached port of the pods,
+	// so a configured spec.networkPolicy has to allow the operator pod.
+	Stats *MemcachedStats `json:"stats,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// UpdateStrategy - rolling update settings of the StatefulSets of the instance and its
+	// pools, e.g. to roll large cache tiers one pod at a time or canary style on image bumps.
+	// If not set, the StatefulSet defaults are used.
+	UpdateStrategy *MemcachedUpdateStrategy `json:"updateStrategy,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Extstore - when set, memcached extstore spills the values of cold items from memory to a
+	// data file on a PersistentVolume per pod, e.g. a local NVMe, so that large caches do not
+	// require huge RAM allocations. The volume settings can not be changed after creation.
+	Extstore *MemcachedExtstore `json:"extstore,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// ExtraMounts - additional volumes mounted into the memcached containers, e.g. custom
+	// CA bundles, SASL databases or tuning files
+	ExtraMounts []MemcachedExtraVolMounts `json:"extraMounts,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Proxy - when set, memcached runs with the built-in proxy (memcached 1.6 proto-proxy)
+	// configured by the given Lua route config, e.g. for read-through replication across the
+	// replicas. Requires a memcached container image built with proxy support.
+	Proxy *MemcachedProxy `json:"proxy,omitempty"`
+}
+
+// MemcachedProxy defines the configuration of the built-in memcached proxy
+type MemcachedProxy struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// RouteConfig - Lua route config of the proxy. The backends of the instance are available
+	// as Lua table of {name, host, port, tls} entries via
+	// dofile("/etc/memcached/proxy/backends.lua"). As the backends are part of the config, the
+	// pods get rolled when the instance gets scaled.
+	RouteConfig string `json:"routeConfig"`
+}
+
+// MemcachedExtraVolMounts defines a set of additional volumes and their mounts
+type MemcachedExtraVolMounts struct {
+	// +kubebuilder:validation:Optional
+	// Name - optional name of the set
+	Name string `json:"name,omitempty"`
+
+	// +kubebuilder:validation:Required
+	// Volumes - the volumes of the set
+	Volumes []MemcachedVolume `json:"volumes"`
+
+	// +kubebuilder:validation:Required
+	// Mounts - the mounts of the volumes of the set into the memcached container
+	Mounts []corev1.VolumeMount `json:"mounts"`
+}
+
+// MemcachedVolume defines an additional volume, limited to the volume sources
+// useful to provide files to memcached
+type MemcachedVolume struct {
+	// +kubebuilder:validation:Required
+	// Name of the volume
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:Optional
+	// Secret - secret providing the files
+	Secret *corev1.SecretVolumeSource `json:"secret,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// ConfigMap - config map providing the files
+	ConfigMap *corev1.ConfigMapVolumeSource `json:"configMap,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Projected - combination of secrets and config maps providing the files
+	Projected *corev1.ProjectedVolumeSource `json:"projected,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// EmptyDir - empty directory sharing the lifetime of the pod
+	EmptyDir *corev1.EmptyDirVolumeSource `json:"emptyDir,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// PersistentVolumeClaim - existing claim providing the files
+	PersistentVolumeClaim *corev1.PersistentVolumeClaimVolumeSource `json:"persistentVolumeClaim,omitempty"`
+}
+
+// MemcachedExtstore defines the flash-backed storage of the memcached items
+type MemcachedExtstore struct {
+	// +kubebuilder:validation:Optional
+	// StorageClass - storage class of the PersistentVolumeClaims holding the data file
+	StorageClass string `json:"storageClass,omitempty"`
+
+	// +kubebuilder:validation:Required
+	// StorageRequest - size of the PersistentVolumeClaims holding the data file, e.g. 100G
+	StorageRequest string `json:"storageRequest"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// FileSizeMB - size of the data file in megabytes. If not set, 90% of the storage request
+	// is used.
+	FileSizeMB *int64 `json:"fileSizeMB,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// ItemSize - minimum size in bytes of the items stored to flash (ext_item_size)
+	ItemSize *int32 `json:"itemSize,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// ItemAge - minimum seconds since the last access of the items stored to flash (ext_item_age)
+	ItemAge *int32 `json:"itemAge,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// WriteBufferSizeMB - size in megabytes of the write buffers of the data file (ext_wbuf_size)
+	WriteBufferSizeMB *int32 `json:"writeBufferSizeMB,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// Threads - number of IO threads of the data file (ext_threads)
+	Threads *int32 `json:"threads,omitempty"`
+}
+
+// MemcachedUpdateStrategy defines the rolling update of the memcached StatefulSets
+type MemcachedUpdateStrategy struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// Partition - only the pods with an ordinal greater than or equal to the partition get
+	// updated, the others keep running the previous revision. Lower it step by step for a
+	// canary rollout.
+	Partition *int32 `json:"partition,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// MaxUnavailable - maximum number or percentage of pods which can be unavailable during
+	// the update. Only honored with the MaxUnavailableStatefulSet feature gate enabled,
+	// otherwise the pods are updated one at a time.
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// MemcachedStats defines the stats collection of the memcached replicas
+type MemcachedStats struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=60
+	// +kubebuilder:validation:Minimum=10
+	// IntervalSeconds - seconds between two stats collections
+	IntervalSeconds int32 `json:"intervalSeconds,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=1000
+	// +kubebuilder:validation:Minimum=0
+	// MaxEvictionsPerMinute - eviction rate of a replica above which the instance is
+	// reported as Degraded, as the cache is likely too small
+	MaxEvictionsPerMinute int64 `json:"maxEvictionsPerMinute,omitempty"`
+}
+
+// MemcachedServiceAffinity defines the client IP based session affinity of the proxy Service
+type MemcachedServiceAffinity struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=10800
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=86400
+	// TimeoutSeconds - seconds the affinity of a client IP is kept without requests
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// MemcachedPool defines an additional memcached pool
+type MemcachedPool struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=32
+	// Name of the pool
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=0
+	// Size of the pool
+	Replicas *int32 `json:"replicas"`
+}
+
+// MemcachedNetworkPolicy defines the clients allowed to connect to memcached
+type MemcachedNetworkPolicy struct {
+	// +kubebuilder:validation:Optional
+	// NamespaceSelector - selects the namespaces of the allowed clients. If not set,
+	// only clients in the namespace of the Memcached instance are allowed.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// PodSelector - selects the allowed client pods within the selected namespaces.
+	// If not set, all pods of the selected namespaces are allowed.
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
 }
 
 // MemcachedStatus defines the observed state of Memcached
 type MemcachedStatus struct {
+	// Map of hashes to track input changes
+	Hash map[string]string `json:"hash,omitempty"`
+
 	// ReadyCount of Memcached instances
 	ReadyCount int32 `json:"readyCount,omitempty"`
 
+	// Selector - label selector of the memcached pods, used by the scale subresource
+	Selector string `json:"selector,omitempty" optional:"true"`
+
 	// Conditions
 	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
 
@@ -54,12 +415,100 @@ type MemcachedStatus struct {
 
 	// ServerListWithInet - List of memcached endpoints with inet(6) prefix
 	ServerListWithInet []string `json:"serverListWithInet,omitempty" optional:"true"`
+
+	// PlaintextServerList - List of memcached plaintext endpoints without inet(6) prefix,
+	// only set when TLS is enabled and the plaintext port is kept open
+	PlaintextServerList []string `json:"plaintextServerList,omitempty" optional:"true"`
+
+	// PlaintextServerListWithInet - List of memcached plaintext endpoints with inet(6) prefix,
+	// only set when TLS is enabled and the plaintext port is kept open
+	PlaintextServerListWithInet []string `json:"plaintextServerListWithInet,omitempty" optional:"true"`
+
+	// Pools - status of the additional memcached pools
+	Pools []MemcachedPoolStatus `json:"pools,omitempty" optional:"true"`
+
+	// ServerListSecret - name of the Secret publishing the memcached server list
+	ServerListSecret string `json:"serverListSecret,omitempty" optional:"true"`
+
+	// LastScheduledRestart - time the last scheduled rolling restart was triggered
+	LastScheduledRestart *metav1.Time `json:"lastScheduledRestart,omitempty" optional:"true"`
+
+	// NextScheduledRestart - time the next scheduled rolling restart is due
+	NextScheduledRestart *metav1.Time `json:"nextScheduledRestart,omitempty" optional:"true"`
+
+	// DrainingSince - time the drain of the departing replicas of an ongoing scale-in started
+	DrainingSince *metav1.Time `json:"drainingSince,omitempty" optional:"true"`
+
+	// SkippedResources - owned resources not managed by the operator, because they
+	// are opted out via a <group>/manage-<resource>: "false" annotation on the CR
+	SkippedResources []string `json:"skippedResources,omitempty" optional:"true"`
+
+	// Stats - stats of the memcached replicas, only collected when spec.stats is set
+	Stats []MemcachedPodStats `json:"stats,omitempty" optional:"true"`
+
+	// StatsCollectedAt - time the stats got collected
+	StatsCollectedAt *metav1.Time `json:"statsCollectedAt,omitempty" optional:"true"`
+
+	// TLSCertHash - hash of the TLS certificate all memcached pods run with, only updated
+	// once the pods got rolled after a certificate rotation
+	TLSCertHash string `json:"tlsCertHash,omitempty" optional:"true"`
+
+	// TLSCertRotationStartedAt - time the roll of the pods after a certificate rotation
+	// started, pods created before got not yet rolled
+	TLSCertRotationStartedAt *metav1.Time `json:"tlsCertRotationStartedAt,omitempty" optional:"true"`
+}
+
+// MemcachedPodStats defines the collected stats of a memcached replica
+type MemcachedPodStats struct {
+	// Pod - name of the memcached pod
+	Pod string `json:"pod"`
+
+	// HitRatio - percentage of get requests which hit the cache
+	HitRatio string `json:"hitRatio,omitempty"`
+
+	// Evictions - number of items evicted from the cache to free memory
+	Evictions int64 `json:"evictions,omitempty"`
+
+	// EvictionsPerMinute - eviction rate since the previous collection
+	EvictionsPerMinute int64 `json:"evictionsPerMinute,omitempty"`
+
+	// UsedMemoryBytes - bytes used to store the items
+	UsedMemoryBytes int64 `json:"usedMemoryBytes,omitempty"`
+
+	// LimitMaxBytes - bytes the cache is allowed to use
+	LimitMaxBytes int64 `json:"limitMaxBytes,omitempty"`
+
+	// TotalMalloced - bytes allocated for the slab pages
+	TotalMalloced int64 `json:"totalMalloced,omitempty"`
+
+	// ActiveSlabs - number of slab classes in use
+	ActiveSlabs int32 `json:"activeSlabs,omitempty"`
+}
+
+// MemcachedPoolStatus defines the observed state of a memcached pool
+type MemcachedPoolStatus struct {
+	// Name of the pool
+	Name string `json:"name"`
+
+	// ReadyCount of memcached instances of the pool
+	ReadyCount int32 `json:"readyCount,omitempty"`
+
+	// ServerList - List of memcached endpoints of the pool without inet(6) prefix
+	ServerList []string `json:"serverList,omitempty"`
+
+	// ServerListWithInet - List of memcached endpoints of the pool with inet(6) prefix
+	ServerListWithInet []string `json:"serverListWithInet,omitempty"`
+
+	// DrainingSince - time the drain of the departing replicas of an ongoing scale-in of the pool started
+	DrainingSince *metav1.Time `json:"drainingSince,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.readyCount,selectorpath=.status.selector
 // +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[0].status",description="Ready"
 // +kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[0].message",description="Message"
+// +kubebuilder:printcolumn:name="ReadyCount",type="integer",JSONPath=".status.readyCount",description="Ready replicas"
 
 // Memcached is the Schema for the memcacheds API
 type Memcached struct {
@@ -103,6 +552,79 @@ func (instance Memcached) RbacResourceName() string {
 	return "memcached-" + instance.Name
 }
 
+// ToCoreVolume - returns the corev1.Volume of the MemcachedVolume
+func (v MemcachedVolume) ToCoreVolume() corev1.Volume {
+	return corev1.Volume{
+		Name: v.Name,
+		VolumeSource: corev1.VolumeSource{
+			Secret:                v.Secret,
+			ConfigMap:             v.ConfigMap,
+			Projected:             v.Projected,
+			EmptyDir:              v.EmptyDir,
+			PersistentVolumeClaim: v.PersistentVolumeClaim,
+		},
+	}
+}
+
+// DaemonSetMode - returns true if memcached runs node-local in a DaemonSet
+func (instance Memcached) DaemonSetMode() bool {
+	return instance.Spec.DeploymentMode == MemcachedDeploymentModeDaemonSet
+}
+
+// PlaintextPortEnabled - returns true if a plaintext port is kept open next to the TLS one
+func (instance Memcached) PlaintextPortEnabled() bool {
+	return instance.Spec.TLS.Enabled() && instance.Spec.KeepPlaintextPort
+}
+
+// PoolName - return the name of the StatefulSet and Service of a pool
+func (instance Memcached) PoolName(pool string) string {
+	return instance.Name + "-" + pool
+}
+
+// ProxyServiceEnabled - returns true if the load balanced proxy Service is requested
+func (instance Memcached) ProxyServiceEnabled() bool {
+	return instance.Spec.ServiceAffinity != nil || instance.Spec.ExternalTrafficPolicy != ""
+}
+
+// ProxyServiceName - returns the name of the load balanced proxy Service
+func (instance Memcached) ProxyServiceName() string {
+	return instance.Name + "-" + MemcachedProxyServiceSuffix
+}
+
+// ServerListSecretName - return the name of the Secret publishing the memcached server list
+func (instance Memcached) ServerListSecretName() string {
+	return "memcached-servers-" + instance.Name
+}
+
+// GetCacheSizeMB - returns the cache size in megabytes passed to memcached -m. If
+// not set explicitly it is derived from the memory limit of the container,
+// instead of falling back to the memcached default of 64MB.
+func (spec MemcachedSpec) GetCacheSizeMB() int64 {
+	if spec.CacheSizeMB != nil {
+		return *spec.CacheSizeMB
+	}
+
+	limit := spec.Resources.Limits.Memory()
+	if limit.IsZero() {
+		return MemcachedDefaultCacheSizeMB
+	}
+
+	size := limit.Value() * int64(spec.GetCacheSizeMemoryLimitPercent()) / 100 / (1024 * 1024)
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// GetCacheSizeMemoryLimitPercent - returns the share of the memory limit used as
+// cache size if the cache size is not set explicitly
+func (spec MemcachedSpec) GetCacheSizeMemoryLimitPercent() int32 {
+	if spec.CacheSizeMemoryLimitPercent == 0 {
+		return MemcachedDefaultCacheSizeMemoryLimitPercent
+	}
+	return spec.CacheSizeMemoryLimitPercent
+}
+
 // SetupDefaults - initializes any CRD field defaults based on environment variables (the defaulting mechanism itself is implemented via webhooks)
 func SetupDefaults() {
 	// Acquire environmental defaults and initialize Memcached defaults with them
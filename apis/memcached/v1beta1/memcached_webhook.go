@@ -23,12 +23,47 @@ limitations under the License.
 package v1beta1
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/openstack-k8s-operators/lib-common/modules/common/tls"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
+// deniedExtraOptions - memcached command line options which are managed by the
+// operator and therefore can not be passed via spec.extraOptions
+var deniedExtraOptions = map[string]bool{
+	"-p": true, "--port": true,
+	"-U": true, "--udp-port": true,
+	"-l": true, "--listen": true,
+	"-u": true, "--user": true,
+	"-m": true, "--memory-limit": true,
+	"-c": true, "--conn-limit": true,
+	"-Z": true, "--enable-ssl": true,
+	"-d": true, "--daemon": true,
+	"-P": true, "--pidfile": true,
+	"-s": true, "--unix-socket": true,
+	"-I": true, "--max-item-size": true,
+	"-R": true, "--max-reqs-per-event": true,
+	"-f": true, "--slab-growth-factor": true,
+}
+
 // MemcachedDefaults -
 type MemcachedDefaults struct {
 	ContainerImageURL string
@@ -45,8 +80,28 @@ func SetupMemcachedDefaults(defaults MemcachedDefaults) {
 	memcachedlog.Info("Memcached defaults initialized", "defaults", defaults)
 }
 
+// reservedVolumeNames - names of the volumes the operator adds to the memcached
+// pods, which can not be used by spec.extraMounts
+var reservedVolumeNames = map[string]bool{
+	"kolla-config":        true,
+	"config-data":         true,
+	"extstore":            true,
+	"memcached-tls-certs": true,
+	tls.CABundleLabel:     true,
+}
+
+// Client needed for API calls (manager's client, set by SetupWebhookWithManager())
+var webhookClient client.Client
+
 // SetupWebhookWithManager sets up the webhook with the Manager
 func (r *Memcached) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	if webhookClient == nil {
+		webhookClient = mgr.GetClient()
+	}
+
+	mgr.GetWebhookServer().Register("/validate-memcached-openstack-org-v1beta1-memcached-scale",
+		&webhook.Admission{Handler: &memcachedScaleValidator{}})
+
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
 		Complete()
@@ -79,16 +134,467 @@ var _ webhook.Validator = &Memcached{}
 func (r *Memcached) ValidateCreate() error {
 	memcachedlog.Info("validate create", "name", r.Name)
 
-	// TODO(user): fill in your validation logic upon object creation.
-	return nil
+	allErrs := r.Spec.ValidateExtraOptions(field.NewPath("spec"))
+	allErrs = append(allErrs, r.Spec.ValidatePools(field.NewPath("spec"))...)
+	allErrs = append(allErrs, r.Spec.ValidateUpdateStrategy(field.NewPath("spec"))...)
+	allErrs = append(allErrs, r.Spec.ValidateExtstore(field.NewPath("spec"))...)
+	allErrs = append(allErrs, r.Spec.ValidateUDP(field.NewPath("spec"))...)
+	allErrs = append(allErrs, r.Spec.ValidateCacheSize(field.NewPath("spec"))...)
+	allErrs = append(allErrs, r.Spec.ValidateMaxItemSize(field.NewPath("spec"))...)
+	allErrs = append(allErrs, r.Spec.ValidateGrowthFactor(field.NewPath("spec"))...)
+	allErrs = append(allErrs, r.Spec.ValidateExtraMounts(field.NewPath("spec"))...)
+	allErrs = append(allErrs, r.Spec.ValidateDeploymentMode(field.NewPath("spec"))...)
+	allErrs = append(allErrs, r.validateReferences(nil, field.NewPath("spec"))...)
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("Memcached").GroupKind(), r.Name, allErrs)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
 func (r *Memcached) ValidateUpdate(old runtime.Object) error {
 	memcachedlog.Info("validate update", "name", r.Name)
 
-	// TODO(user): fill in your validation logic upon object update.
-	return nil
+	oldMemcached, ok := old.(*Memcached)
+	if !ok || oldMemcached == nil {
+		return apierrors.NewInternalError(fmt.Errorf("unable to convert existing object"))
+	}
+
+	allErrs := r.Spec.ValidateExtraOptions(field.NewPath("spec"))
+	allErrs = append(allErrs, r.Spec.ValidatePools(field.NewPath("spec"))...)
+	allErrs = append(allErrs, r.Spec.ValidateUpdateStrategy(field.NewPath("spec"))...)
+	allErrs = append(allErrs, r.Spec.ValidateExtstore(field.NewPath("spec"))...)
+	allErrs = append(allErrs, r.Spec.ValidateUDP(field.NewPath("spec"))...)
+	allErrs = append(allErrs, r.Spec.ValidateCacheSize(field.NewPath("spec"))...)
+	allErrs = append(allErrs, r.Spec.ValidateMaxItemSize(field.NewPath("spec"))...)
+	allErrs = append(allErrs, r.Spec.ValidateGrowthFactor(field.NewPath("spec"))...)
+	allErrs = append(allErrs, r.Spec.ValidateExtraMounts(field.NewPath("spec"))...)
+	allErrs = append(allErrs, r.Spec.ValidateDeploymentMode(field.NewPath("spec"))...)
+	allErrs = append(allErrs, r.Spec.ValidateExtstoreUpdate(oldMemcached.Spec, field.NewPath("spec"))...)
+	if r.Spec.DeploymentMode != oldMemcached.Spec.DeploymentMode {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "deploymentMode"), "field is immutable"))
+	}
+	allErrs = append(allErrs, r.validateReferences(oldMemcached, field.NewPath("spec"))...)
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("Memcached").GroupKind(), r.Name, allErrs)
+}
+
+// ValidateExtraOptions - validates the extra command line options against the
+// options managed by the operator
+func (spec *MemcachedSpec) ValidateExtraOptions(basePath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	for i, opt := range spec.ExtraOptions {
+		path := basePath.Child("extraOptions").Index(i)
+
+		// the options get rendered into a shell sourced config file
+		if strings.ContainsAny(opt, "\"$`\\;&|<>\n") {
+			allErrs = append(allErrs, field.Invalid(path, opt, "option contains unsupported characters"))
+			continue
+		}
+
+		fields := strings.Fields(opt)
+		if len(fields) == 0 || !strings.HasPrefix(fields[0], "-") {
+			allErrs = append(allErrs, field.Invalid(path, opt, "option must start with -"))
+			continue
+		}
+
+		flag := fields[0]
+		if strings.HasPrefix(flag, "--") {
+			// --flag=value
+			flag, _, _ = strings.Cut(flag, "=")
+		} else if len(flag) > 2 {
+			// -fvalue
+			flag = flag[:2]
+		}
+		if deniedExtraOptions[flag] {
+			allErrs = append(allErrs, field.Forbidden(path,
+				fmt.Sprintf("option %s is managed by the operator", flag)))
+			continue
+		}
+
+		// the TLS, extstore and proxy settings are passed via -o ssl_*/ext_*/proxy_*
+		if flag == "-o" || flag == "--extended" {
+			if strings.Contains(opt, "ssl_") {
+				allErrs = append(allErrs, field.Forbidden(path,
+					"the TLS extended options are managed by the operator"))
+			}
+			if strings.Contains(opt, "ext_") {
+				allErrs = append(allErrs, field.Forbidden(path,
+					"the extstore extended options are managed via spec.extstore"))
+			}
+			if strings.Contains(opt, "proxy_") {
+				allErrs = append(allErrs, field.Forbidden(path,
+					"the proxy extended options are managed via spec.proxy"))
+			}
+			if strings.Contains(opt, "idle_timeout") {
+				allErrs = append(allErrs, field.Forbidden(path,
+					"the idle timeout is managed via spec.idleTimeoutSeconds"))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// ValidatePools - validates the pool names do not collide with the names of
+// the other resources created for the Memcached instance
+func (spec *MemcachedSpec) ValidatePools(basePath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	for i, pool := range spec.Pools {
+		if pool.Name == MemcachedProxyServiceSuffix {
+			allErrs = append(allErrs, field.Invalid(
+				basePath.Child("pools").Index(i).Child("name"),
+				pool.Name,
+				fmt.Sprintf("pool name %s is reserved", pool.Name)))
+		}
+		// <name>-<ordinal> is used by the per pod services
+		if _, err := strconv.Atoi(pool.Name); err == nil && spec.PerPodServices {
+			allErrs = append(allErrs, field.Invalid(
+				basePath.Child("pools").Index(i).Child("name"),
+				pool.Name,
+				"numeric pool names collide with the per pod services"))
+		}
+	}
+
+	return allErrs
+}
+
+// ValidateUpdateStrategy - validates maxUnavailable is a positive number or percentage
+func (spec *MemcachedSpec) ValidateUpdateStrategy(basePath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if spec.UpdateStrategy == nil || spec.UpdateStrategy.MaxUnavailable == nil {
+		return allErrs
+	}
+
+	path := basePath.Child("updateStrategy", "maxUnavailable")
+	maxUnavailable := spec.UpdateStrategy.MaxUnavailable
+	value, err := intstr.GetScaledValueFromIntOrPercent(maxUnavailable, 100, true)
+	if err != nil {
+		allErrs = append(allErrs, field.Invalid(path, maxUnavailable.String(), err.Error()))
+	} else if value < 1 {
+		allErrs = append(allErrs, field.Invalid(path, maxUnavailable.String(), "must be greater than 0"))
+	} else if maxUnavailable.Type == intstr.String && value > 100 {
+		allErrs = append(allErrs, field.Invalid(path, maxUnavailable.String(), "must not be greater than 100%"))
+	}
+
+	return allErrs
+}
+
+// ValidateExtstore - validates the storage request and that the data file fits into it
+func (spec *MemcachedSpec) ValidateExtstore(basePath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if spec.Extstore == nil {
+		return allErrs
+	}
+
+	path := basePath.Child("extstore")
+	request, err := resource.ParseQuantity(spec.Extstore.StorageRequest)
+	if err != nil {
+		allErrs = append(allErrs, field.Invalid(path.Child("storageRequest"), spec.Extstore.StorageRequest, err.Error()))
+		return allErrs
+	}
+	if spec.Extstore.FileSizeMB != nil && *spec.Extstore.FileSizeMB*1024*1024 > request.Value() {
+		allErrs = append(allErrs, field.Invalid(path.Child("fileSizeMB"), *spec.Extstore.FileSizeMB,
+			fmt.Sprintf("data file does not fit into the storage request %s", spec.Extstore.StorageRequest)))
+	}
+
+	return allErrs
+}
+
+// ValidateExtstoreUpdate - validates the extstore volume settings did not change,
+// as the volume claim templates of the StatefulSets are immutable
+func (spec *MemcachedSpec) ValidateExtstoreUpdate(old MemcachedSpec, basePath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	path := basePath.Child("extstore")
+	switch {
+	case (spec.Extstore == nil) != (old.Extstore == nil):
+		allErrs = append(allErrs, field.Forbidden(path, "extstore can not be enabled or disabled after creation"))
+	case spec.Extstore == nil:
+	case spec.Extstore.StorageClass != old.Extstore.StorageClass:
+		allErrs = append(allErrs, field.Forbidden(path.Child("storageClass"), "field is immutable"))
+	case spec.Extstore.StorageRequest != old.Extstore.StorageRequest:
+		allErrs = append(allErrs, field.Forbidden(path.Child("storageRequest"), "field is immutable"))
+	}
+
+	return allErrs
+}
+
+// ValidateUDP - validates UDP is not enabled together with TLS
+func (spec *MemcachedSpec) ValidateUDP(basePath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if spec.UDPEnabled && spec.TLS.Enabled() {
+		allErrs = append(allErrs, field.Forbidden(basePath.Child("udpEnabled"),
+			"UDP can not be enabled together with TLS, the UDP traffic would not be encrypted"))
+	}
+
+	return allErrs
+}
+
+// ValidateCacheSize - validates the effective cache size fits into the memory
+// limit of the container. Without cacheSizeMB the cache size derived from the
+// limit is at least 1MB, which does not fit into a smaller limit.
+func (spec *MemcachedSpec) ValidateCacheSize(basePath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	limit := spec.Resources.Limits.Memory()
+	if limit.IsZero() {
+		return allErrs
+	}
+
+	cacheSize := spec.GetCacheSizeMB()
+	if cacheSize*1024*1024 <= limit.Value() {
+		return allErrs
+	}
+	msg := fmt.Sprintf("cache size of %dMB exceeds the memory limit %s", cacheSize, limit.String())
+	if spec.CacheSizeMB != nil {
+		allErrs = append(allErrs, field.Invalid(basePath.Child("cacheSizeMB"), cacheSize, msg))
+	} else {
+		allErrs = append(allErrs, field.Invalid(
+			basePath.Child("resources", "limits", string(corev1.ResourceMemory)), limit.String(), msg))
+	}
+
+	return allErrs
+}
+
+// ValidateMaxItemSize - validates the max item size is at most half of the
+// effective cache size, memcached refuses to start otherwise
+func (spec *MemcachedSpec) ValidateMaxItemSize(basePath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if spec.MaxItemSizeKB == nil {
+		return allErrs
+	}
+
+	if int64(*spec.MaxItemSizeKB) > spec.GetCacheSizeMB()*1024/2 {
+		allErrs = append(allErrs, field.Invalid(basePath.Child("maxItemSizeKB"), *spec.MaxItemSizeKB,
+			"max item size exceeds half of the cache size"))
+	}
+
+	return allErrs
+}
+
+// ValidateGrowthFactor - validates the slab growth factor is greater than 1
+func (spec *MemcachedSpec) ValidateGrowthFactor(basePath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if spec.GrowthFactor == "" {
+		return allErrs
+	}
+
+	factor, err := strconv.ParseFloat(spec.GrowthFactor, 64)
+	if err != nil || factor <= 1 {
+		allErrs = append(allErrs, field.Invalid(basePath.Child("growthFactor"), spec.GrowthFactor,
+			"growth factor must be a number greater than 1"))
+	}
+
+	return allErrs
+}
+
+// ValidateExtraMounts - validates the extra volumes do not collide with the
+// volumes of the operator and each mount references a volume of its set
+func (spec *MemcachedSpec) ValidateExtraMounts(basePath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	names := map[string]bool{}
+	for i, extraMount := range spec.ExtraMounts {
+		path := basePath.Child("extraMounts").Index(i)
+
+		setNames := map[string]bool{}
+		for j, vol := range extraMount.Volumes {
+			volPath := path.Child("volumes").Index(j).Child("name")
+			switch {
+			case reservedVolumeNames[vol.Name]:
+				allErrs = append(allErrs, field.Invalid(volPath, vol.Name,
+					fmt.Sprintf("volume name %s is reserved", vol.Name)))
+			case names[vol.Name]:
+				allErrs = append(allErrs, field.Duplicate(volPath, vol.Name))
+			}
+			names[vol.Name] = true
+			setNames[vol.Name] = true
+		}
+
+		for j, mount := range extraMount.Mounts {
+			if !setNames[mount.Name] {
+				allErrs = append(allErrs, field.NotFound(
+					path.Child("mounts").Index(j).Child("name"), mount.Name))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// ValidateDeploymentMode - validates the features requiring individually
+// addressed replicas are not used in DaemonSet mode
+func (spec *MemcachedSpec) ValidateDeploymentMode(basePath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if spec.DeploymentMode != MemcachedDeploymentModeDaemonSet {
+		return allErrs
+	}
+
+	msg := "not supported in DaemonSet mode"
+	if len(spec.Pools) > 0 {
+		allErrs = append(allErrs, field.Forbidden(basePath.Child("pools"), msg))
+	}
+	if spec.Extstore != nil {
+		allErrs = append(allErrs, field.Forbidden(basePath.Child("extstore"), msg))
+	}
+	if spec.PerPodServices {
+		allErrs = append(allErrs, field.Forbidden(basePath.Child("perPodServices"), msg))
+	}
+	if spec.ScaleInDrainSeconds > 0 {
+		allErrs = append(allErrs, field.Forbidden(basePath.Child("scaleInDrainSeconds"), msg))
+	}
+	if spec.UpdateStrategy != nil {
+		allErrs = append(allErrs, field.Forbidden(basePath.Child("updateStrategy"), msg))
+	}
+
+	return allErrs
+}
+
+// validateReferences - validates the objects the Memcached instance interacts
+// with. The TLS cert secret has to exist and the instance can not be scaled to
+// zero replicas while pods consume its server list secret.
+func (r *Memcached) validateReferences(old *Memcached, basePath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if webhookClient == nil {
+		return allErrs
+	}
+
+	if r.Spec.TLS.Enabled() {
+		secret := &corev1.Secret{}
+		err := webhookClient.Get(context.TODO(),
+			types.NamespacedName{Name: *r.Spec.TLS.SecretName, Namespace: r.Namespace}, secret)
+		if err != nil {
+			path := basePath.Child("tls", "secretName")
+			if apierrors.IsNotFound(err) {
+				allErrs = append(allErrs, field.NotFound(path, *r.Spec.TLS.SecretName))
+			} else {
+				allErrs = append(allErrs, field.InternalError(path, err))
+			}
+		}
+	}
+
+	allErrs = append(allErrs, r.validateScaleToZero(old, basePath)...)
+
+	return allErrs
+}
+
+// validateScaleToZero - validates the instance does not get scaled to zero
+// replicas while pods consume its server list secret
+func (r *Memcached) validateScaleToZero(old *Memcached, basePath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	scaledToZero := r.Spec.Replicas != nil && *r.Spec.Replicas == 0 &&
+		(old == nil || old.Spec.Replicas == nil || *old.Spec.Replicas > 0)
+	if !scaledToZero {
+		return allErrs
+	}
+
+	pods := &corev1.PodList{}
+	err := webhookClient.List(context.TODO(), pods, client.InNamespace(r.Namespace))
+	path := basePath.Child("replicas")
+	if err != nil {
+		allErrs = append(allErrs, field.InternalError(path, err))
+	} else if consumers := SecretConsumers(pods.Items, r.ServerListSecretName()); len(consumers) > 0 {
+		allErrs = append(allErrs, field.Forbidden(path,
+			fmt.Sprintf("can not scale to zero replicas, the server list is consumed by pods %s",
+				strings.Join(consumers, ", "))))
+	}
+
+	return allErrs
+}
+
+//+kubebuilder:webhook:path=/validate-memcached-openstack-org-v1beta1-memcached-scale,mutating=false,failurePolicy=fail,sideEffects=None,groups=memcached.openstack.org,resources=memcacheds/scale,verbs=update,versions=v1beta1,name=vmemcachedscale.kb.io,admissionReviewVersions=v1
+
+// memcachedScaleValidator - validates the updates of the scale subresource,
+// which do not go through the validation of the Memcached itself, e.g. on
+// kubectl scale or from an autoscaler
+type memcachedScaleValidator struct{}
+
+// Handle implements admission.Handler, it denies scaling to zero replicas
+// while pods consume the server list secret
+func (v *memcachedScaleValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	scale := &autoscalingv1.Scale{}
+	if err := json.Unmarshal(req.Object.Raw, scale); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	memcachedlog.Info("validate scale", "name", req.Name, "replicas", scale.Spec.Replicas)
+
+	old := &Memcached{}
+	err := webhookClient.Get(ctx, types.NamespacedName{Name: req.Name, Namespace: req.Namespace}, old)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	r := old.DeepCopy()
+	r.Spec.Replicas = &scale.Spec.Replicas
+
+	allErrs := r.validateScaleToZero(old, field.NewPath("spec"))
+	if len(allErrs) > 0 {
+		return admission.Denied(
+			apierrors.NewInvalid(GroupVersion.WithKind("Memcached").GroupKind(), r.Name, allErrs).Error())
+	}
+
+	return admission.Allowed("")
+}
+
+// SecretConsumers returns the names of the pods which reference the secret via
+// a volume or an environment variable
+func SecretConsumers(pods []corev1.Pod, secretName string) []string {
+	consumers := []string{}
+
+	for _, pod := range pods {
+		if podReferencesSecret(pod, secretName) {
+			consumers = append(consumers, pod.Name)
+		}
+	}
+
+	return consumers
+}
+
+func podReferencesSecret(pod corev1.Pod, secretName string) bool {
+	for _, vol := range pod.Spec.Volumes {
+		if vol.Secret != nil && vol.Secret.SecretName == secretName {
+			return true
+		}
+		if vol.Projected != nil {
+			for _, src := range vol.Projected.Sources {
+				if src.Secret != nil && src.Secret.Name == secretName {
+					return true
+				}
+			}
+		}
+	}
+
+	containers := append([]corev1.Container{}, pod.Spec.InitContainers...)
+	containers = append(containers, pod.Spec.Containers...)
+	for _, c := range containers {
+		for _, envFrom := range c.EnvFrom {
+			if envFrom.SecretRef != nil && envFrom.SecretRef.Name == secretName {
+				return true
+			}
+		}
+		for _, env := range c.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil &&
+				env.ValueFrom.SecretKeyRef.Name == secretName {
+				return true
+			}
+		}
+	}
+
+	return false
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
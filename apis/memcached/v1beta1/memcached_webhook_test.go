@@ -0,0 +1,350 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestMemcachedValidateExtraOptions(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectErr    bool
+		extraOptions []string
+	}{
+		{
+			name:         "should succeed without extra options",
+			expectErr:    false,
+			extraOptions: nil,
+		},
+		{
+			name:         "should succeed with tunables",
+			expectErr:    false,
+			extraOptions: []string{"-o modern,track_sizes", "-t 8", "--listen-backlog=2048"},
+		},
+		{
+			name:         "should fail with the port",
+			expectErr:    true,
+			extraOptions: []string{"-p 11222"},
+		},
+		{
+			name:         "should fail with an attached short option value",
+			expectErr:    true,
+			extraOptions: []string{"-m2048"},
+		},
+		{
+			name:         "should fail with a long option",
+			expectErr:    true,
+			extraOptions: []string{"--listen=127.0.0.1"},
+		},
+		{
+			name:         "should fail with TLS extended options",
+			expectErr:    true,
+			extraOptions: []string{"-o ssl_verify_mode=0"},
+		},
+		{
+			name:         "should fail with proxy extended options",
+			expectErr:    true,
+			extraOptions: []string{"-o proxy_config=/tmp/proxy.lua"},
+		},
+		{
+			name:         "should fail with the idle timeout extended option",
+			expectErr:    true,
+			extraOptions: []string{"-o idle_timeout=600"},
+		},
+		{
+			name:         "should fail with max requests per event",
+			expectErr:    true,
+			extraOptions: []string{"-R 40"},
+		},
+		{
+			name:         "should fail without a leading dash",
+			expectErr:    true,
+			extraOptions: []string{"modern"},
+		},
+		{
+			name:         "should fail with shell characters",
+			expectErr:    true,
+			extraOptions: []string{"-t $(nproc)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			spec := MemcachedSpec{ExtraOptions: tt.extraOptions}
+			errs := spec.ValidateExtraOptions(field.NewPath("spec"))
+			if tt.expectErr {
+				g.Expect(errs).NotTo(BeEmpty())
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestMemcachedValidatePools(t *testing.T) {
+	g := NewWithT(t)
+
+	spec := MemcachedSpec{Pools: []MemcachedPool{{Name: "sessions"}}}
+	g.Expect(spec.ValidatePools(field.NewPath("spec"))).To(BeEmpty())
+
+	spec.Pools = append(spec.Pools, MemcachedPool{Name: MemcachedProxyServiceSuffix})
+	g.Expect(spec.ValidatePools(field.NewPath("spec"))).To(HaveLen(1))
+
+	spec.Pools = append(spec.Pools, MemcachedPool{Name: "0"})
+	g.Expect(spec.ValidatePools(field.NewPath("spec"))).To(HaveLen(1))
+	spec.PerPodServices = true
+	g.Expect(spec.ValidatePools(field.NewPath("spec"))).To(HaveLen(2))
+}
+
+func TestMemcachedValidateUpdateStrategy(t *testing.T) {
+	tests := []struct {
+		name           string
+		expectErr      bool
+		maxUnavailable intstr.IntOrString
+	}{
+		{
+			name:           "should succeed with a number",
+			maxUnavailable: intstr.FromInt(2),
+		},
+		{
+			name:           "should succeed with a percentage",
+			maxUnavailable: intstr.FromString("25%"),
+		},
+		{
+			name:           "should fail with zero",
+			expectErr:      true,
+			maxUnavailable: intstr.FromInt(0),
+		},
+		{
+			name:           "should fail with zero percent",
+			expectErr:      true,
+			maxUnavailable: intstr.FromString("0%"),
+		},
+		{
+			name:           "should fail with more than 100 percent",
+			expectErr:      true,
+			maxUnavailable: intstr.FromString("150%"),
+		},
+		{
+			name:           "should fail with an invalid string",
+			expectErr:      true,
+			maxUnavailable: intstr.FromString("two"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			spec := MemcachedSpec{UpdateStrategy: &MemcachedUpdateStrategy{MaxUnavailable: &tt.maxUnavailable}}
+			errs := spec.ValidateUpdateStrategy(field.NewPath("spec"))
+			if tt.expectErr {
+				g.Expect(errs).NotTo(BeEmpty())
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestMemcachedValidateExtstore(t *testing.T) {
+	g := NewWithT(t)
+
+	fileSize := int64(900)
+	spec := MemcachedSpec{Extstore: &MemcachedExtstore{StorageRequest: "1G", FileSizeMB: &fileSize}}
+	g.Expect(spec.ValidateExtstore(field.NewPath("spec"))).To(BeEmpty())
+
+	fileSize = 2000
+	g.Expect(spec.ValidateExtstore(field.NewPath("spec"))).To(HaveLen(1))
+
+	spec.Extstore.StorageRequest = "lots"
+	g.Expect(spec.ValidateExtstore(field.NewPath("spec"))).To(HaveLen(1))
+}
+
+func TestMemcachedValidateExtstoreUpdate(t *testing.T) {
+	g := NewWithT(t)
+
+	old := MemcachedSpec{Extstore: &MemcachedExtstore{StorageRequest: "1G"}}
+	threads := int32(4)
+	spec := MemcachedSpec{Extstore: &MemcachedExtstore{StorageRequest: "1G", Threads: &threads}}
+	g.Expect(spec.ValidateExtstoreUpdate(old, field.NewPath("spec"))).To(BeEmpty())
+
+	spec.Extstore.StorageRequest = "2G"
+	g.Expect(spec.ValidateExtstoreUpdate(old, field.NewPath("spec"))).To(HaveLen(1))
+
+	spec.Extstore = nil
+	g.Expect(spec.ValidateExtstoreUpdate(old, field.NewPath("spec"))).To(HaveLen(1))
+}
+
+func TestMemcachedValidateUDP(t *testing.T) {
+	g := NewWithT(t)
+
+	spec := MemcachedSpec{UDPEnabled: true}
+	g.Expect(spec.ValidateUDP(field.NewPath("spec"))).To(BeEmpty())
+
+	secretName := "cert-memcached-svc"
+	spec.TLS.SecretName = &secretName
+	g.Expect(spec.ValidateUDP(field.NewPath("spec"))).To(HaveLen(1))
+}
+
+func TestMemcachedValidateCacheSize(t *testing.T) {
+	g := NewWithT(t)
+
+	cacheSize := int64(512)
+	spec := MemcachedSpec{CacheSizeMB: &cacheSize}
+	g.Expect(spec.ValidateCacheSize(field.NewPath("spec"))).To(BeEmpty())
+
+	spec.Resources.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}
+	g.Expect(spec.ValidateCacheSize(field.NewPath("spec"))).To(BeEmpty())
+
+	cacheSize = 2048
+	g.Expect(spec.ValidateCacheSize(field.NewPath("spec"))).To(HaveLen(1))
+
+	// the cache size derived from the limit is at least 1MB
+	spec = MemcachedSpec{}
+	spec.Resources.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")}
+	g.Expect(spec.ValidateCacheSize(field.NewPath("spec"))).To(BeEmpty())
+	spec.Resources.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Ki")}
+	g.Expect(spec.ValidateCacheSize(field.NewPath("spec"))).To(ConsistOf(
+		HaveField("Field", "spec.resources.limits.memory")))
+}
+
+func TestSecretConsumers(t *testing.T) {
+	g := NewWithT(t)
+
+	secretName := "memcached-servers-memcached"
+	pods := []corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "keystone"},
+			Spec: corev1.PodSpec{
+				Volumes: []corev1.Volume{{
+					Name: "memcached",
+					VolumeSource: corev1.VolumeSource{
+						Secret: &corev1.SecretVolumeSource{SecretName: secretName},
+					},
+				}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "nova"},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Name: "nova",
+					Env: []corev1.EnvVar{{
+						Name: "MEMCACHE_SERVERS",
+						ValueFrom: &corev1.EnvVarSource{
+							SecretKeyRef: &corev1.SecretKeySelector{
+								LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+								Key:                  "memcache_servers",
+							},
+						},
+					}},
+				}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "glance"},
+		},
+	}
+
+	g.Expect(SecretConsumers(pods, secretName)).To(Equal([]string{"keystone", "nova"}))
+	g.Expect(SecretConsumers(pods, "other")).To(BeEmpty())
+}
+
+func TestMemcachedValidateExtraMounts(t *testing.T) {
+	g := NewWithT(t)
+
+	spec := MemcachedSpec{ExtraMounts: []MemcachedExtraVolMounts{{
+		Volumes: []MemcachedVolume{{
+			Name:   "sasl",
+			Secret: &corev1.SecretVolumeSource{SecretName: "memcached-sasl"},
+		}},
+		Mounts: []corev1.VolumeMount{{
+			Name:      "sasl",
+			MountPath: "/etc/sasl2",
+		}},
+	}}}
+	g.Expect(spec.ValidateExtraMounts(field.NewPath("spec"))).To(BeEmpty())
+
+	spec.ExtraMounts = append(spec.ExtraMounts, MemcachedExtraVolMounts{
+		Volumes: []MemcachedVolume{{Name: "sasl"}, {Name: "config-data"}},
+		Mounts:  []corev1.VolumeMount{{Name: "tuning", MountPath: "/etc/tuning"}},
+	})
+	g.Expect(spec.ValidateExtraMounts(field.NewPath("spec"))).To(HaveLen(3))
+}
+
+func TestMemcachedValidateMaxItemSize(t *testing.T) {
+	g := NewWithT(t)
+
+	maxItemSize := int32(4096)
+	spec := MemcachedSpec{MaxItemSizeKB: &maxItemSize}
+	g.Expect(spec.ValidateMaxItemSize(field.NewPath("spec"))).To(BeEmpty())
+
+	// the cache size is derived from the memory limit if not set
+	spec.Resources.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("8Mi")}
+	g.Expect(spec.ValidateMaxItemSize(field.NewPath("spec"))).To(HaveLen(1))
+
+	spec.Resources.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")}
+	g.Expect(spec.ValidateMaxItemSize(field.NewPath("spec"))).To(BeEmpty())
+
+	cacheSize := int64(8)
+	spec.CacheSizeMB = &cacheSize
+	g.Expect(spec.ValidateMaxItemSize(field.NewPath("spec"))).To(BeEmpty())
+
+	cacheSize = 4
+	g.Expect(spec.ValidateMaxItemSize(field.NewPath("spec"))).To(HaveLen(1))
+}
+
+func TestMemcachedValidateGrowthFactor(t *testing.T) {
+	g := NewWithT(t)
+
+	spec := MemcachedSpec{}
+	g.Expect(spec.ValidateGrowthFactor(field.NewPath("spec"))).To(BeEmpty())
+
+	spec.GrowthFactor = "1.08"
+	g.Expect(spec.ValidateGrowthFactor(field.NewPath("spec"))).To(BeEmpty())
+
+	spec.GrowthFactor = "1"
+	g.Expect(spec.ValidateGrowthFactor(field.NewPath("spec"))).To(HaveLen(1))
+
+	spec.GrowthFactor = "0.9"
+	g.Expect(spec.ValidateGrowthFactor(field.NewPath("spec"))).To(HaveLen(1))
+}
+
+func TestMemcachedValidateDeploymentMode(t *testing.T) {
+	g := NewWithT(t)
+
+	spec := MemcachedSpec{
+		DeploymentMode: MemcachedDeploymentModeStatefulSet,
+		Pools:          []MemcachedPool{{Name: "sessions"}},
+		PerPodServices: true,
+	}
+	g.Expect(spec.ValidateDeploymentMode(field.NewPath("spec"))).To(BeEmpty())
+
+	spec.DeploymentMode = MemcachedDeploymentModeDaemonSet
+	g.Expect(spec.ValidateDeploymentMode(field.NewPath("spec"))).To(HaveLen(2))
+}
@@ -23,7 +23,10 @@ package v1beta1
 
 import (
 	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -68,6 +71,75 @@ func (in *MemcachedDefaults) DeepCopy() *MemcachedDefaults {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemcachedExtraVolMounts) DeepCopyInto(out *MemcachedExtraVolMounts) {
+	*out = *in
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]MemcachedVolume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Mounts != nil {
+		in, out := &in.Mounts, &out.Mounts
+		*out = make([]v1.VolumeMount, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemcachedExtraVolMounts.
+func (in *MemcachedExtraVolMounts) DeepCopy() *MemcachedExtraVolMounts {
+	if in == nil {
+		return nil
+	}
+	out := new(MemcachedExtraVolMounts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemcachedExtstore) DeepCopyInto(out *MemcachedExtstore) {
+	*out = *in
+	if in.FileSizeMB != nil {
+		in, out := &in.FileSizeMB, &out.FileSizeMB
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ItemSize != nil {
+		in, out := &in.ItemSize, &out.ItemSize
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ItemAge != nil {
+		in, out := &in.ItemAge, &out.ItemAge
+		*out = new(int32)
+		**out = **in
+	}
+	if in.WriteBufferSizeMB != nil {
+		in, out := &in.WriteBufferSizeMB, &out.WriteBufferSizeMB
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Threads != nil {
+		in, out := &in.Threads, &out.Threads
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemcachedExtstore.
+func (in *MemcachedExtstore) DeepCopy() *MemcachedExtstore {
+	if in == nil {
+		return nil
+	}
+	out := new(MemcachedExtstore)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MemcachedList) DeepCopyInto(out *MemcachedList) {
 	*out = *in
@@ -100,6 +172,130 @@ func (in *MemcachedList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemcachedNetworkPolicy) DeepCopyInto(out *MemcachedNetworkPolicy) {
+	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodSelector != nil {
+		in, out := &in.PodSelector, &out.PodSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemcachedNetworkPolicy.
+func (in *MemcachedNetworkPolicy) DeepCopy() *MemcachedNetworkPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(MemcachedNetworkPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemcachedPodStats) DeepCopyInto(out *MemcachedPodStats) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemcachedPodStats.
+func (in *MemcachedPodStats) DeepCopy() *MemcachedPodStats {
+	if in == nil {
+		return nil
+	}
+	out := new(MemcachedPodStats)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemcachedPool) DeepCopyInto(out *MemcachedPool) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemcachedPool.
+func (in *MemcachedPool) DeepCopy() *MemcachedPool {
+	if in == nil {
+		return nil
+	}
+	out := new(MemcachedPool)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemcachedPoolStatus) DeepCopyInto(out *MemcachedPoolStatus) {
+	*out = *in
+	if in.ServerList != nil {
+		in, out := &in.ServerList, &out.ServerList
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ServerListWithInet != nil {
+		in, out := &in.ServerListWithInet, &out.ServerListWithInet
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DrainingSince != nil {
+		in, out := &in.DrainingSince, &out.DrainingSince
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemcachedPoolStatus.
+func (in *MemcachedPoolStatus) DeepCopy() *MemcachedPoolStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MemcachedPoolStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemcachedProxy) DeepCopyInto(out *MemcachedProxy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemcachedProxy.
+func (in *MemcachedProxy) DeepCopy() *MemcachedProxy {
+	if in == nil {
+		return nil
+	}
+	out := new(MemcachedProxy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemcachedServiceAffinity) DeepCopyInto(out *MemcachedServiceAffinity) {
+	*out = *in
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemcachedServiceAffinity.
+func (in *MemcachedServiceAffinity) DeepCopy() *MemcachedServiceAffinity {
+	if in == nil {
+		return nil
+	}
+	out := new(MemcachedServiceAffinity)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MemcachedSpec) DeepCopyInto(out *MemcachedSpec) {
 	*out = *in
@@ -108,6 +304,89 @@ func (in *MemcachedSpec) DeepCopyInto(out *MemcachedSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.TLS.DeepCopyInto(&out.TLS)
+	if in.IPFamilies != nil {
+		in, out := &in.IPFamilies, &out.IPFamilies
+		*out = make([]v1.IPFamily, len(*in))
+		copy(*out, *in)
+	}
+	if in.Pools != nil {
+		in, out := &in.Pools, &out.Pools
+		*out = make([]MemcachedPool, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NetworkPolicy != nil {
+		in, out := &in.NetworkPolicy, &out.NetworkPolicy
+		*out = new(MemcachedNetworkPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExtraOptions != nil {
+		in, out := &in.ExtraOptions, &out.ExtraOptions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ServiceAffinity != nil {
+		in, out := &in.ServiceAffinity, &out.ServiceAffinity
+		*out = new(MemcachedServiceAffinity)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.CacheSizeMB != nil {
+		in, out := &in.CacheSizeMB, &out.CacheSizeMB
+		*out = new(int64)
+		**out = **in
+	}
+	if in.MaxItemSizeKB != nil {
+		in, out := &in.MaxItemSizeKB, &out.MaxItemSizeKB
+		*out = new(int32)
+		**out = **in
+	}
+	if in.IdleTimeoutSeconds != nil {
+		in, out := &in.IdleTimeoutSeconds, &out.IdleTimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxRequestsPerEvent != nil {
+		in, out := &in.MaxRequestsPerEvent, &out.MaxRequestsPerEvent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Stats != nil {
+		in, out := &in.Stats, &out.Stats
+		*out = new(MemcachedStats)
+		**out = **in
+	}
+	if in.UpdateStrategy != nil {
+		in, out := &in.UpdateStrategy, &out.UpdateStrategy
+		*out = new(MemcachedUpdateStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Extstore != nil {
+		in, out := &in.Extstore, &out.Extstore
+		*out = new(MemcachedExtstore)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExtraMounts != nil {
+		in, out := &in.ExtraMounts, &out.ExtraMounts
+		*out = make([]MemcachedExtraVolMounts, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Proxy != nil {
+		in, out := &in.Proxy, &out.Proxy
+		*out = new(MemcachedProxy)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemcachedSpec.
@@ -120,9 +399,31 @@ func (in *MemcachedSpec) DeepCopy() *MemcachedSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemcachedStats) DeepCopyInto(out *MemcachedStats) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemcachedStats.
+func (in *MemcachedStats) DeepCopy() *MemcachedStats {
+	if in == nil {
+		return nil
+	}
+	out := new(MemcachedStats)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MemcachedStatus) DeepCopyInto(out *MemcachedStatus) {
 	*out = *in
+	if in.Hash != nil {
+		in, out := &in.Hash, &out.Hash
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make(condition.Conditions, len(*in))
@@ -140,6 +441,53 @@ func (in *MemcachedStatus) DeepCopyInto(out *MemcachedStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.PlaintextServerList != nil {
+		in, out := &in.PlaintextServerList, &out.PlaintextServerList
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PlaintextServerListWithInet != nil {
+		in, out := &in.PlaintextServerListWithInet, &out.PlaintextServerListWithInet
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Pools != nil {
+		in, out := &in.Pools, &out.Pools
+		*out = make([]MemcachedPoolStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastScheduledRestart != nil {
+		in, out := &in.LastScheduledRestart, &out.LastScheduledRestart
+		*out = (*in).DeepCopy()
+	}
+	if in.NextScheduledRestart != nil {
+		in, out := &in.NextScheduledRestart, &out.NextScheduledRestart
+		*out = (*in).DeepCopy()
+	}
+	if in.DrainingSince != nil {
+		in, out := &in.DrainingSince, &out.DrainingSince
+		*out = (*in).DeepCopy()
+	}
+	if in.SkippedResources != nil {
+		in, out := &in.SkippedResources, &out.SkippedResources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Stats != nil {
+		in, out := &in.Stats, &out.Stats
+		*out = make([]MemcachedPodStats, len(*in))
+		copy(*out, *in)
+	}
+	if in.StatsCollectedAt != nil {
+		in, out := &in.StatsCollectedAt, &out.StatsCollectedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.TLSCertRotationStartedAt != nil {
+		in, out := &in.TLSCertRotationStartedAt, &out.TLSCertRotationStartedAt
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemcachedStatus.
@@ -151,3 +499,68 @@ func (in *MemcachedStatus) DeepCopy() *MemcachedStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemcachedUpdateStrategy) DeepCopyInto(out *MemcachedUpdateStrategy) {
+	*out = *in
+	if in.Partition != nil {
+		in, out := &in.Partition, &out.Partition
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemcachedUpdateStrategy.
+func (in *MemcachedUpdateStrategy) DeepCopy() *MemcachedUpdateStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(MemcachedUpdateStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemcachedVolume) DeepCopyInto(out *MemcachedVolume) {
+	*out = *in
+	if in.Secret != nil {
+		in, out := &in.Secret, &out.Secret
+		*out = new(v1.SecretVolumeSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(v1.ConfigMapVolumeSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Projected != nil {
+		in, out := &in.Projected, &out.Projected
+		*out = new(v1.ProjectedVolumeSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EmptyDir != nil {
+		in, out := &in.EmptyDir, &out.EmptyDir
+		*out = new(v1.EmptyDirVolumeSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PersistentVolumeClaim != nil {
+		in, out := &in.PersistentVolumeClaim, &out.PersistentVolumeClaim
+		*out = new(v1.PersistentVolumeClaimVolumeSource)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemcachedVolume.
+func (in *MemcachedVolume) DeepCopy() *MemcachedVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(MemcachedVolume)
+	in.DeepCopyInto(out)
+	return out
+}
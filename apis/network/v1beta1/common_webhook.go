@@ -25,7 +25,13 @@ const (
 	errInvalidRange           = "Start address: %s > End address %s"
 	errDupeNetworkName        = "network name %s already in use at %s, must be uniq"
 	errDupeCIDR               = "CIDR %s already in use at %s"
+	errOverlappingCIDR        = "CIDR overlaps with %s at %s"
+	errOverlappingRange       = "allocation range overlaps with %s at %s"
 	errInvalidDNSDomain       = "DNSDoman name %s is not valid"
+	errInvalidHostname        = "hostname %s is not a valid RFC 1123 hostname"
+	errViewWildcard           = "wildcard hostnames are not supported in views"
+	errViewNotAttached        = "network %s is not attached to DNSMasq %s, dnsmasq can not answer with the addresses of the view"
+	errControlChars           = "must not contain control characters or newlines"
 	errDupeDNSDomain          = "DNSDoman name %s already in use at %s, must be uniq"
 	errNetworkNotFound        = "network %s not in NetConfig"
 	errSubnetNotInNetwork     = "subnet %s not in network %s of the NetConfig"
@@ -35,6 +41,19 @@ const (
 	errFixedIPChanged         = "fixedIP must not change"
 	errDefaultRouteChanged    = "defaultRoute must not change"
 	errMultiDefaultRoute      = "%s defaultRoute can only be requested on a singe network"
+	errConflictingHostname    = "hostname already resolves to %s in DNSData %s"
+	errDupeImportedIPSet      = "IPSet %s already imported at %s, must be uniq"
+	errDupeImportedAddress    = "address %s already imported at %s"
+	errIPv6MTU                = "MTU below the IPv6 minimum of %d, required by subnet %s"
+	errMTURange               = "MTU must be between 68 and 65535"
+	errDupeVlan               = "vlan %d already in use by network %s at %s"
+	errDupeRoute              = "route to %s already defined at %s"
+	errSubnetInUse            = "subnet %s/%s has %d reserved addresses, set the %s annotation to change or remove it"
+	errReservedNotInRange     = "reserved address %s of subnet %s/%s not in the allocation ranges, set the %s annotation to change them"
+	errOrdinalsNotInCidr      = "the block of %d addresses at the fixedIP exceeds the subnet cidr %s"
+	errDupeBGPNeighbor        = "BGP neighbor %s already defined at %s"
+	errDupeViewNetwork        = "view of network %s already defined at %s, must be uniq"
+	errBGPNotConfigured       = "the operator is not configured to announce addresses via BGP"
 )
 
 func getNetConfig(
@@ -61,6 +80,42 @@ func getNetConfig(
 	return &netcfgs.Items[0], nil
 }
 
+func getDNSData(
+	c client.Client,
+	obj metav1.Object,
+) (*DNSDataList, error) {
+	// get the DNSData of the namespace
+	opts := &client.ListOptions{
+		Namespace: obj.GetNamespace(),
+	}
+
+	dnsdata := &DNSDataList{}
+	err := webhookClient.List(context.TODO(), dnsdata, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return dnsdata, nil
+}
+
+func getDNSMasqs(
+	c client.Client,
+	obj metav1.Object,
+) (*DNSMasqList, error) {
+	// get the DNSMasqs of the namespace
+	opts := &client.ListOptions{
+		Namespace: obj.GetNamespace(),
+	}
+
+	dnsmasqs := &DNSMasqList{}
+	err := webhookClient.List(context.TODO(), dnsmasqs, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return dnsmasqs, nil
+}
+
 func getIPSets(
 	c client.Client,
 	obj metav1.Object,
@@ -78,3 +133,21 @@ func getIPSets(
 
 	return ipsets, nil
 }
+
+func getReservations(
+	c client.Client,
+	obj metav1.Object,
+) (*ReservationList, error) {
+	// get the Reservations of the namespace
+	opts := &client.ListOptions{
+		Namespace: obj.GetNamespace(),
+	}
+
+	reservations := &ReservationList{}
+	err := webhookClient.List(context.TODO(), reservations, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
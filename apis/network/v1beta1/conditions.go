@@ -24,6 +24,62 @@ import (
 const (
 	// ReservationReadyCondition indicates if the IP reservation was successful
 	ReservationReadyCondition condition.Type = "ReservationReady"
+
+	// ConsumerReleasedCondition indicates if the consumer of a deleted IPSet is gone, so its
+	// addresses can be released
+	ConsumerReleasedCondition condition.Type = "ConsumerReleased"
+
+	// IPOwnerReadyCondition indicates if the IP owner ConfigMap got generated
+	IPOwnerReadyCondition condition.Type = "IPOwnerReady"
+
+	// ReservationsValidCondition indicates if all reserved addresses are valid for the subnets of the NetConfig
+	ReservationsValidCondition condition.Type = "ReservationsValid"
+
+	// DNSDataReadyCondition indicates if the DNSData with the records of the IPSets got generated
+	DNSDataReadyCondition condition.Type = "DNSDataReady"
+
+	// BGPAnnouncementReadyCondition indicates if the FRRConfiguration announcing the addresses
+	// of the IPSets got generated
+	BGPAnnouncementReadyCondition condition.Type = "BGPAnnouncementReady"
+
+	// DNSSECReadyCondition indicates if the DNSSEC validation config of the DNSMasq is valid
+	DNSSECReadyCondition condition.Type = "DNSSECReady"
+
+	// ExternalDNSReadyCondition indicates if the records got published via an ExternalDNS DNSEndpoint
+	ExternalDNSReadyCondition condition.Type = "ExternalDNSReady"
+
+	// AddressPoolAvailableCondition indicates if all subnets of the NetConfig have more
+	// free addresses than the FreeAddressesThreshold. It does not affect the Ready condition.
+	AddressPoolAvailableCondition condition.Type = "AddressPoolAvailable"
+
+	// ResolutionDegradedCondition indicates that the canary record of the DNSMasq health
+	// check could not be resolved or resolved too slow. It is only set while degraded
+	// and does not affect the Ready condition.
+	ResolutionDegradedCondition condition.Type = "ResolutionDegraded"
+)
+
+// Common Reasons used by API objects.
+const (
+	// ResolutionFailedReason
+	ResolutionFailedReason condition.Reason = "ResolutionFailed"
+
+	// ResolutionSlowReason
+	ResolutionSlowReason condition.Reason = "ResolutionSlow"
+
+	// ResolutionRecoveredReason
+	ResolutionRecoveredReason condition.Reason = "ResolutionRecovered"
+
+	// AddressPoolLowReason
+	AddressPoolLowReason condition.Reason = "AddressPoolLow"
+
+	// ConsumerExistsReason
+	ConsumerExistsReason condition.Reason = "ConsumerExists"
+
+	// FixedIPReservedReason
+	FixedIPReservedReason condition.Reason = "FixedIPReserved"
+
+	// DuplicateIPOwnerReason
+	DuplicateIPOwnerReason condition.Reason = "DuplicateIPOwner"
 )
 
 // Common Messages used by API objects.
@@ -48,4 +104,88 @@ const (
 
 	// ReservationReadyMessage
 	ReservationReadyMessage = "Reservation successful"
+
+	// FixedIPReservedMessage
+	FixedIPReservedMessage = "FixedIP %s on network %s already reserved for IPSet %s"
+
+	// ConsumerExistsMessage
+	ConsumerExistsMessage = "Releasing the addresses blocked while consumer %s %s exists"
+
+	// ConsumerReleasedErrorMessage
+	ConsumerReleasedErrorMessage = "Getting the consumer error occured %s"
+
+	// IPOwnerReadyInitMessage
+	IPOwnerReadyInitMessage = "IP owner ConfigMap not started"
+
+	// IPOwnerReadyErrorMessage
+	IPOwnerReadyErrorMessage = "IP owner ConfigMap error occured %s"
+
+	// IPOwnerReadyMessage
+	IPOwnerReadyMessage = "IP owner ConfigMap created"
+
+	// IPOwnerDuplicateMessage
+	IPOwnerDuplicateMessage = "IP owner ConfigMap lists only the first owner of addresses reserved more than once: %s"
+
+	// ReservationsValidInitMessage
+	ReservationsValidInitMessage = "Reservations not validated"
+
+	// ReservationsInvalidMessage
+	ReservationsInvalidMessage = "Reserved addresses not valid for their subnet: %s"
+
+	// ImportedReservationsConflictMessage
+	ImportedReservationsConflictMessage = "Imported addresses already reserved, not imported: %s"
+
+	// ReservationsValidMessage
+	ReservationsValidMessage = "All reserved addresses valid"
+
+	// DNSDataReadyInitMessage
+	DNSDataReadyInitMessage = "DNSData not started"
+
+	// DNSDataReadyErrorMessage
+	DNSDataReadyErrorMessage = "DNSData error occured %s"
+
+	// DNSDataReadyMessage
+	DNSDataReadyMessage = "DNSData created"
+
+	// BGPAnnouncementReadyInitMessage
+	BGPAnnouncementReadyInitMessage = "BGP announcement not started"
+
+	// BGPAnnouncementReadyErrorMessage
+	BGPAnnouncementReadyErrorMessage = "BGP announcement error occured %s"
+
+	// BGPAnnouncementReadyMessage
+	BGPAnnouncementReadyMessage = "BGP announcement created"
+
+	// AddressPoolAvailableMessage
+	AddressPoolAvailableMessage = "All subnets have enough free addresses"
+
+	// AddressPoolLowMessage
+	AddressPoolLowMessage = "Less than %d free addresses in subnets: %s"
+
+	// ImportedReservationErrorMessage
+	ImportedReservationErrorMessage = "Importing Reservations error occured %s"
+
+	// StaleReservationErrorMessage
+	StaleReservationErrorMessage = "Releasing stale Reservations error occured %s"
+
+	// DNSSECReadyErrorMessage
+	DNSSECReadyErrorMessage = "DNSSEC validation config error occured %s"
+
+	// DNSSECReadyMessage
+	DNSSECReadyMessage = "DNSSEC validation enabled"
+
+	// ExternalDNSReadyErrorMessage
+	ExternalDNSReadyErrorMessage = "DNSEndpoint error occured %s"
+
+	// ExternalDNSReadyMessage
+	ExternalDNSReadyMessage = "DNSEndpoint published"
+
+	// ResolutionFailedMessage
+	ResolutionFailedMessage = "Resolving %s via %s failed: %s"
+
+	// ResolutionSlowMessage
+	ResolutionSlowMessage = "Resolving %s via %s took %dms, exceeds %dms"
+
+	// ResolutionRecoveredMessage
+	ResolutionRecoveredMessage = "Resolving %s via %s recovered"
 )
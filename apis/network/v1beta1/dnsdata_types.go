@@ -22,15 +22,121 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	// DNSHostOwnerIPSet - host entry owned by an IPSet
+	DNSHostOwnerIPSet = "IPSet"
+	// DNSHostOwnerReservation - host entry owned by a Reservation
+	DNSHostOwnerReservation = "Reservation"
+)
+
 // DNSHost holds the mapping between IP and hostnames that will be added to dnsmasq hosts file.
 type DNSHost struct {
 	// +kubebuilder:validation:Required
-	// IP address of the host file entry.
+	// IP address of the host file entry. IPv6 addresses get served as AAAA records.
 	IP string `json:"ip"`
 
 	// +kubebuilder:validation:Required
-	// Hostnames for the IP address.
+	// Hostnames for the IP address, RFC 1123 hostnames. A hostname of the form *.<domain>, e.g.
+	// *.apps.example.com, resolves the domain and all of its subdomains to the IP address.
 	Hostnames []string `json:"hostnames"`
+
+	// +kubebuilder:validation:Optional
+	// Owner - CR in the namespace of the DNSData the entry got contributed for, e.g. the IPSet
+	// of a node. The entry gets dropped from the hosts once the owner got deleted, e.g. on scale down.
+	Owner *DNSHostOwner `json:"owner,omitempty"`
+}
+
+// DNSHostOwner references the CR owning a host entry
+type DNSHostOwner struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=IPSet;Reservation
+	// Kind of the owner
+	Kind string `json:"kind"`
+
+	// +kubebuilder:validation:Required
+	// Name of the owner
+	Name string `json:"name"`
+}
+
+// DNSSRVRecord defines a SRV record, _<service>._<protocol>.<domain>
+type DNSSRVRecord struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// Service - symbolic name of the service, without the leading underscore, e.g. ldap
+	Service string `json:"service"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=tcp
+	// +kubebuilder:validation:Enum=tcp;udp;sctp
+	// Protocol - transport protocol of the service
+	Protocol string `json:"protocol"`
+
+	// +kubebuilder:validation:Required
+	// Domain - domain the record is valid for, a RFC 1123 hostname
+	Domain string `json:"domain"`
+
+	// +kubebuilder:validation:Required
+	// Target - hostname of the host providing the service, a RFC 1123 hostname
+	Target string `json:"target"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=65535
+	// Port - port the service listens on
+	Port int32 `json:"port"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=0
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=65535
+	// Priority - priority of the target host, lower value means more preferred
+	Priority int32 `json:"priority"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=0
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=65535
+	// Weight - relative weight for records with the same priority
+	Weight int32 `json:"weight"`
+}
+
+// DNSCNAMERecord defines aliases for a hostname
+type DNSCNAMERecord struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	// Aliases - names resolving to the target, RFC 1123 hostnames
+	Aliases []string `json:"aliases"`
+
+	// +kubebuilder:validation:Required
+	// Target - canonical RFC 1123 hostname, it has to be known to dnsmasq, e.g. from the hosts of a DNSData
+	Target string `json:"target"`
+}
+
+// DNSDataView defines hosts resolving to different addresses for queries
+// arriving on a specific network
+type DNSDataView struct {
+	// +kubebuilder:validation:Required
+	// Network - name of the NetworkAttachment the queries arrive on, only one view per network.
+	// The DNSMasqs serving the DNSData have to be attached to it via networkAttachments.
+	Network string `json:"network"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	// Hosts - hosts of the view, their IPs have to be in the subnet of the network.
+	// Wildcard hostnames are not supported in views.
+	Hosts []DNSHost `json:"hosts"`
+}
+
+// DNSDataExternalDNS defines how the records get published via ExternalDNS
+type DNSDataExternalDNS struct {
+	// +kubebuilder:validation:Optional
+	// Labels - additional labels of the DNSEndpoint, e.g. to match the --label-filter of external-dns
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// RecordTTL - TTL in seconds of the published records, the default of the provider is used if not set
+	RecordTTL *int64 `json:"recordTTL,omitempty"`
 }
 
 // DNSDataSpec defines the desired state of DNSData
@@ -38,10 +144,37 @@ type DNSDataSpec struct {
 	// +kubebuilder:validation:Optional
 	Hosts []DNSHost `json:"hosts,omitempty"`
 
+	// +kubebuilder:validation:Optional
+	// SRVRecords - SRV records used for service discovery
+	SRVRecords []DNSSRVRecord `json:"srvRecords,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// CNAMERecords - aliases of hostnames
+	CNAMERecords []DNSCNAMERecord `json:"cnameRecords,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Views - split-horizon views, a hostname of a view resolves to the IP of the view for
+	// queries arriving on its network. dnsmasq answers with the addresses in the subnet of
+	// the interface the query arrived on (localise-queries), so the network has to be attached
+	// to the DNSMasqs serving the DNSData. dnsmasq answers queries arriving on other networks
+	// with all addresses of the hostname.
+	Views []DNSDataView `json:"views,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// ExternalDNS - mirror the hosts, SRV and CNAME records into the provider zones
+	// of external-dns via a DNSEndpoint. Requires the DNSEndpoint CRD to be installed.
+	ExternalDNS *DNSDataExternalDNS `json:"externalDNS,omitempty"`
+
 	// +kubebuilder:validation:Optional
 	// +kubebuilder:default="dnsdata"
 	// Value of the DNSDataLabelSelector to set on the created configmaps containing hosts information
 	DNSDataLabelSelectorValue string `json:"dnsDataLabelSelectorValue"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=false
+	// PTRRecords - generate reverse zone PTR records for all hostnames of the hosts. Without,
+	// dnsmasq answers reverse lookups of an IP only with one of its hostnames.
+	PTRRecords bool `json:"ptrRecords"`
 }
 
 // DNSDataStatus defines the observed state of DNSData
@@ -51,6 +184,10 @@ type DNSDataStatus struct {
 
 	// Map of the dns data configmap
 	Hash string `json:"hash,omitempty"`
+
+	// StaleHosts - host entries which got dropped as their owner does not exist anymore,
+	// in the format <ip> (<kind>/<name>)
+	StaleHosts []string `json:"staleHosts,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -84,3 +221,26 @@ func init() {
 func (instance DNSData) IsReady() bool {
 	return instance.Status.Conditions.IsTrue(condition.ReadyCondition)
 }
+
+// HasHostOwner returns true if a host entry of the DNSData, or of one of its
+// views, is owned by the object of the kind with the name
+func (spec DNSDataSpec) HasHostOwner(kind string, name string) bool {
+	owned := func(hosts []DNSHost) bool {
+		for _, host := range hosts {
+			if host.Owner != nil && host.Owner.Kind == kind && host.Owner.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	if owned(spec.Hosts) {
+		return true
+	}
+	for _, view := range spec.Views {
+		if owned(view.Hosts) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,275 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"golang.org/x/exp/slices"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var dnsdatalog = logf.Log.WithName("dnsdata-resource")
+
+// SetupWebhookWithManager sets up the webhook with the Manager
+func (r *DNSData) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	if webhookClient == nil {
+		webhookClient = mgr.GetClient()
+	}
+
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-network-openstack-org-v1beta1-dnsdata,mutating=false,failurePolicy=fail,sideEffects=None,groups=network.openstack.org,resources=dnsdata,verbs=create;update,versions=v1beta1,name=vdnsdata.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &DNSData{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *DNSData) ValidateCreate() error {
+	dnsdatalog.Info("validate create", "name", r.Name)
+
+	return r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *DNSData) ValidateUpdate(old runtime.Object) error {
+	dnsdatalog.Info("validate update", "name", r.Name)
+
+	// only run the validations on update if the object won't get updated
+	// to be deleted (remove finalizer).
+	if !r.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	return r.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *DNSData) ValidateDelete() error {
+	dnsdatalog.Info("validate delete", "name", r.Name)
+
+	return nil
+}
+
+// validate - validates the names of the records and the views, and rejects hostnames
+// which resolve to a different IP in another DNSData merged into the same DNSMasq
+func (r *DNSData) validate() error {
+	basePath := field.NewPath("spec")
+	allErrs := r.Spec.validateNames(basePath)
+
+	dnsmasqs, err := getDNSMasqs(webhookClient, r)
+	if err != nil {
+		return err
+	}
+	allErrs = append(allErrs, r.Spec.validateViews(basePath, dnsmasqs.Items)...)
+
+	dnsdata, err := getDNSData(webhookClient, r)
+	if err != nil {
+		return err
+	}
+
+	others := []DNSData{}
+	for _, other := range dnsdata.Items {
+		if other.Name == r.Name || !other.DeletionTimestamp.IsZero() {
+			continue
+		}
+		others = append(others, other)
+	}
+
+	allErrs = append(allErrs, r.Spec.validateConflicts(basePath, others)...)
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("DNSData").GroupKind(), r.Name, allErrs)
+}
+
+// dnsHostnameRegex - RFC 1123 hostname, optionally fully qualified with a trailing dot
+var dnsHostnameRegex = regexp.MustCompile(
+	`^[a-zA-Z0-9]([-a-zA-Z0-9]{0,61}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([-a-zA-Z0-9]{0,61}[a-zA-Z0-9])?)*\.?$`)
+
+// IsDNSHostname - returns if name is a RFC 1123 hostname. With wildcard, a
+// hostname of the form *.<domain> is valid too.
+func IsDNSHostname(name string, wildcard bool) bool {
+	if wildcard {
+		name = strings.TrimPrefix(name, "*.")
+	}
+
+	return len(name) <= 253 && dnsHostnameRegex.MatchString(name)
+}
+
+// validateNames - checks that the names of the records are RFC 1123 hostnames,
+// as they get rendered verbatim into the dnsmasq hosts and config files
+func (spec *DNSDataSpec) validateNames(basePath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	validateHosts := func(hosts []DNSHost, path *field.Path) {
+		for idx, host := range hosts {
+			for hostnameIdx, hostname := range host.Hostnames {
+				if !IsDNSHostname(hostname, true) {
+					allErrs = append(allErrs, field.Invalid(path.Index(idx).Child("hostnames").Index(hostnameIdx),
+						hostname, fmt.Sprintf(errInvalidHostname, hostname)))
+				}
+			}
+		}
+	}
+
+	validateHosts(spec.Hosts, basePath.Child("hosts"))
+	for idx, view := range spec.Views {
+		validateHosts(view.Hosts, basePath.Child("views").Index(idx).Child("hosts"))
+	}
+
+	validateName := func(name string, path *field.Path) {
+		if !IsDNSHostname(name, false) {
+			allErrs = append(allErrs, field.Invalid(path, name, fmt.Sprintf(errInvalidHostname, name)))
+		}
+	}
+
+	for idx, srv := range spec.SRVRecords {
+		path := basePath.Child("srvRecords").Index(idx)
+		validateName(srv.Domain, path.Child("domain"))
+		validateName(srv.Target, path.Child("target"))
+	}
+
+	for idx, cname := range spec.CNAMERecords {
+		path := basePath.Child("cnameRecords").Index(idx)
+		for aliasIdx, alias := range cname.Aliases {
+			validateName(alias, path.Child("aliases").Index(aliasIdx))
+		}
+		validateName(cname.Target, path.Child("target"))
+	}
+
+	return allErrs
+}
+
+// validateViews - rejects views dnsmasq can not honour. dnsmasq answers with the
+// addresses in the subnet of the interface the query arrived on, so
+// - wildcard hostnames, rendered as address directives, are not supported
+// - there is only one view per network
+// - the network has to be attached to the DNSMasqs serving the DNSData
+func (spec *DNSDataSpec) validateViews(basePath *field.Path, dnsmasqs []DNSMasq) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	networks := map[string]field.Path{}
+	for idx, view := range spec.Views {
+		path := basePath.Child("views").Index(idx)
+
+		allErrs = append(allErrs, valiateUniqElement(networks, view.Network, path, "network", errDupeViewNetwork)...)
+		for _, dnsmasq := range dnsmasqs {
+			if !strings.EqualFold(dnsmasq.Spec.DNSDataLabelSelectorValue, spec.DNSDataLabelSelectorValue) ||
+				!dnsmasq.DeletionTimestamp.IsZero() {
+				continue
+			}
+			if !slices.Contains(dnsmasq.Spec.NetworkAttachments, view.Network) {
+				allErrs = append(allErrs, field.Invalid(path.Child("network"), view.Network,
+					fmt.Sprintf(errViewNotAttached, view.Network, dnsmasq.Name)))
+			}
+		}
+
+		for hostIdx, host := range view.Hosts {
+			for hostnameIdx, hostname := range host.Hostnames {
+				if strings.HasPrefix(hostname, "*.") {
+					allErrs = append(allErrs, field.Forbidden(
+						path.Child("hosts").Index(hostIdx).Child("hostnames").Index(hostnameIdx), errViewWildcard))
+				}
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// dnsRecordKey - identifies the addresses of a hostname within a view and address family
+type dnsRecordKey struct {
+	view     string
+	hostname string
+	ipv6     bool
+}
+
+// dnsRecord - address of a hostname and where it is defined
+type dnsRecord struct {
+	ip   string
+	path *field.Path
+}
+
+// records - returns the addresses of the hostnames of the hosts and views
+func (spec *DNSDataSpec) records(basePath *field.Path) map[dnsRecordKey][]dnsRecord {
+	records := map[dnsRecordKey][]dnsRecord{}
+	addHosts := func(view string, hosts []DNSHost, path *field.Path) {
+		for idx, host := range hosts {
+			ip := net.ParseIP(host.IP)
+			ipv6 := ip != nil && ip.To4() == nil
+			for hostnameIdx, hostname := range host.Hostnames {
+				key := dnsRecordKey{
+					view:     view,
+					hostname: strings.TrimSuffix(strings.ToLower(hostname), "."),
+					ipv6:     ipv6,
+				}
+				records[key] = append(records[key], dnsRecord{
+					ip:   host.IP,
+					path: path.Index(idx).Child("hostnames").Index(hostnameIdx),
+				})
+			}
+		}
+	}
+
+	addHosts("", spec.Hosts, basePath.Child("hosts"))
+	for idx, view := range spec.Views {
+		addHosts(view.Network, view.Hosts, basePath.Child("views").Index(idx).Child("hosts"))
+	}
+
+	return records
+}
+
+// validateConflicts - checks that the hostnames do not resolve to a different IP
+// of the same address family in one of the other DNSData with the same label selector
+// value, as dnsmasq would answer with the addresses of all of them.
+func (spec *DNSDataSpec) validateConflicts(basePath *field.Path, others []DNSData) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	records := spec.records(basePath)
+	for _, other := range others {
+		if !strings.EqualFold(other.Spec.DNSDataLabelSelectorValue, spec.DNSDataLabelSelectorValue) {
+			continue
+		}
+
+		for key, otherRecords := range other.Spec.records(field.NewPath("spec")) {
+			for _, record := range records[key] {
+				for _, otherRecord := range otherRecords {
+					if record.ip != otherRecord.ip {
+						allErrs = append(allErrs, field.Invalid(record.path, key.hostname,
+							fmt.Sprintf(errConflictingHostname, otherRecord.ip, other.Name)))
+						break
+					}
+				}
+			}
+		}
+	}
+
+	return allErrs
+}
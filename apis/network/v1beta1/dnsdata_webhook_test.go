@@ -0,0 +1,344 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestDNSDataValidateConflicts(t *testing.T) {
+	others := []DNSData{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "other"},
+			Spec: DNSDataSpec{
+				DNSDataLabelSelectorValue: "dnsdata",
+				Hosts: []DNSHost{
+					{IP: "172.17.0.80", Hostnames: []string{"keystone.example.com"}},
+					{IP: "fd00:bbbb::80", Hostnames: []string{"keystone.example.com"}},
+					{IP: "172.17.0.82", Hostnames: []string{"nova.example.com"}},
+				},
+				Views: []DNSDataView{
+					{
+						Network: "internalapi",
+						Hosts: []DNSHost{
+							{IP: "172.17.0.81", Hostnames: []string{"glance.example.com"}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		expectErr bool
+		spec      DNSDataSpec
+	}{
+		{
+			name:      "should succeed with the same IP",
+			expectErr: false,
+			spec: DNSDataSpec{
+				DNSDataLabelSelectorValue: "dnsdata",
+				Hosts: []DNSHost{
+					{IP: "172.17.0.80", Hostnames: []string{"keystone.example.com"}},
+				},
+			},
+		},
+		{
+			name:      "should succeed with an IP of the other address family",
+			expectErr: false,
+			spec: DNSDataSpec{
+				DNSDataLabelSelectorValue: "dnsdata",
+				Hosts: []DNSHost{
+					{IP: "fd00:bbbb::82", Hostnames: []string{"nova.example.com"}},
+				},
+			},
+		},
+		{
+			name:      "should succeed with a different label selector value",
+			expectErr: false,
+			spec: DNSDataSpec{
+				DNSDataLabelSelectorValue: "other",
+				Hosts: []DNSHost{
+					{IP: "172.17.0.90", Hostnames: []string{"keystone.example.com"}},
+				},
+			},
+		},
+		{
+			name:      "should succeed with the hostname of a view",
+			expectErr: false,
+			spec: DNSDataSpec{
+				DNSDataLabelSelectorValue: "dnsdata",
+				Hosts: []DNSHost{
+					{IP: "10.0.0.81", Hostnames: []string{"glance.example.com"}},
+				},
+			},
+		},
+		{
+			name:      "should fail with a different IP",
+			expectErr: true,
+			spec: DNSDataSpec{
+				DNSDataLabelSelectorValue: "dnsdata",
+				Hosts: []DNSHost{
+					{IP: "172.17.0.90", Hostnames: []string{"Keystone.example.com."}},
+				},
+			},
+		},
+		{
+			name:      "should fail with a different IP in the same view",
+			expectErr: true,
+			spec: DNSDataSpec{
+				DNSDataLabelSelectorValue: "dnsdata",
+				Views: []DNSDataView{
+					{
+						Network: "internalapi",
+						Hosts: []DNSHost{
+							{IP: "172.17.0.91", Hostnames: []string{"glance.example.com"}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			allErrs := tt.spec.validateConflicts(field.NewPath("spec"), others)
+			if tt.expectErr {
+				g.Expect(allErrs).NotTo(BeEmpty())
+			} else {
+				g.Expect(allErrs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestDNSDataValidateNames(t *testing.T) {
+	tests := []struct {
+		name      string
+		expectErr bool
+		spec      DNSDataSpec
+	}{
+		{
+			name:      "should succeed with hostnames and a wildcard",
+			expectErr: false,
+			spec: DNSDataSpec{
+				Hosts: []DNSHost{
+					{IP: "172.17.0.80", Hostnames: []string{"keystone", "keystone.example.com.", "*.apps.example.com"}},
+				},
+			},
+		},
+		{
+			name:      "should fail with a newline in a hostname",
+			expectErr: true,
+			spec: DNSDataSpec{
+				Hosts: []DNSHost{
+					{IP: "172.17.0.80", Hostnames: []string{"keystone\n172.17.0.81 glance"}},
+				},
+			},
+		},
+		{
+			name:      "should fail with a wildcard not at the start",
+			expectErr: true,
+			spec: DNSDataSpec{
+				Hosts: []DNSHost{
+					{IP: "172.17.0.80", Hostnames: []string{"apps.*.example.com"}},
+				},
+			},
+		},
+		{
+			name:      "should succeed with a SRV record",
+			expectErr: false,
+			spec: DNSDataSpec{
+				SRVRecords: []DNSSRVRecord{
+					{Service: "ldap", Protocol: "tcp", Domain: "example.com", Target: "ldap.example.com", Port: 389},
+				},
+			},
+		},
+		{
+			name:      "should fail with an invalid SRV record domain",
+			expectErr: true,
+			spec: DNSDataSpec{
+				SRVRecords: []DNSSRVRecord{
+					{Service: "ldap", Protocol: "tcp", Domain: "example.com,ldap", Target: "ldap.example.com", Port: 389},
+				},
+			},
+		},
+		{
+			name:      "should fail with an invalid SRV record target",
+			expectErr: true,
+			spec: DNSDataSpec{
+				SRVRecords: []DNSSRVRecord{
+					{Service: "ldap", Protocol: "tcp", Domain: "example.com", Target: "ldap.example.com\naddress=/#/127.0.0.1", Port: 389},
+				},
+			},
+		},
+		{
+			name:      "should succeed with a CNAME record",
+			expectErr: false,
+			spec: DNSDataSpec{
+				CNAMERecords: []DNSCNAMERecord{
+					{Aliases: []string{"identity.example.com", "auth.example.com"}, Target: "keystone.example.com"},
+				},
+			},
+		},
+		{
+			name:      "should fail with an invalid CNAME record alias",
+			expectErr: true,
+			spec: DNSDataSpec{
+				CNAMERecords: []DNSCNAMERecord{
+					{Aliases: []string{"identity.example.com,auth.example.com"}, Target: "keystone.example.com"},
+				},
+			},
+		},
+		{
+			name:      "should fail with an invalid CNAME record target",
+			expectErr: true,
+			spec: DNSDataSpec{
+				CNAMERecords: []DNSCNAMERecord{
+					{Aliases: []string{"identity.example.com"}, Target: "*.example.com"},
+				},
+			},
+		},
+		{
+			name:      "should fail with an invalid hostname in a view",
+			expectErr: true,
+			spec: DNSDataSpec{
+				Views: []DNSDataView{
+					{
+						Network: "internalapi",
+						Hosts: []DNSHost{
+							{IP: "172.17.0.81", Hostnames: []string{"-glance.example.com"}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			allErrs := tt.spec.validateNames(field.NewPath("spec"))
+			if tt.expectErr {
+				g.Expect(allErrs).NotTo(BeEmpty())
+			} else {
+				g.Expect(allErrs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestDNSDataValidateViews(t *testing.T) {
+	dnsmasqs := []DNSMasq{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "dns"},
+			Spec: DNSMasqSpec{
+				DNSDataLabelSelectorValue: "dnsdata",
+				NetworkAttachments:        []string{"internalapi"},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "other"},
+			Spec: DNSMasqSpec{
+				DNSDataLabelSelectorValue: "other",
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		expectErr bool
+		spec      DNSDataSpec
+	}{
+		{
+			name:      "should succeed with a view of an attached network",
+			expectErr: false,
+			spec: DNSDataSpec{
+				DNSDataLabelSelectorValue: "dnsdata",
+				Views: []DNSDataView{
+					{
+						Network: "internalapi",
+						Hosts:   []DNSHost{{IP: "172.17.0.80", Hostnames: []string{"keystone.example.com"}}},
+					},
+				},
+			},
+		},
+		{
+			name:      "should fail with a view of a network not attached to the DNSMasq",
+			expectErr: true,
+			spec: DNSDataSpec{
+				DNSDataLabelSelectorValue: "dnsdata",
+				Views: []DNSDataView{
+					{
+						Network: "storage",
+						Hosts:   []DNSHost{{IP: "172.18.0.80", Hostnames: []string{"keystone.example.com"}}},
+					},
+				},
+			},
+		},
+		{
+			name:      "should fail with two views of the same network",
+			expectErr: true,
+			spec: DNSDataSpec{
+				DNSDataLabelSelectorValue: "dnsdata",
+				Views: []DNSDataView{
+					{
+						Network: "internalapi",
+						Hosts:   []DNSHost{{IP: "172.17.0.80", Hostnames: []string{"keystone.example.com"}}},
+					},
+					{
+						Network: "internalapi",
+						Hosts:   []DNSHost{{IP: "172.17.0.81", Hostnames: []string{"glance.example.com"}}},
+					},
+				},
+			},
+		},
+		{
+			name:      "should fail with a wildcard hostname in a view",
+			expectErr: true,
+			spec: DNSDataSpec{
+				DNSDataLabelSelectorValue: "dnsdata",
+				Views: []DNSDataView{
+					{
+						Network: "internalapi",
+						Hosts:   []DNSHost{{IP: "172.17.0.80", Hostnames: []string{"*.apps.example.com"}}},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			allErrs := tt.spec.validateViews(field.NewPath("spec"), dnsmasqs)
+			if tt.expectErr {
+				g.Expect(allErrs).NotTo(BeEmpty())
+			} else {
+				g.Expect(allErrs).To(BeEmpty())
+			}
+		})
+	}
+}
@@ -17,8 +17,11 @@ limitations under the License.
 package v1beta1
 
 import (
+	"strconv"
+
 	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/service"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/tls"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -35,12 +38,22 @@ const (
 
 	// DNSMasqContainerImage is the fall-back container image for DNSMasq
 	DNSMasqContainerImage = "quay.io/podified-antelope-centos9/openstack-neutron-server:current-podified"
+
+	// DNSMasqUnboundContainerImage is the fall-back container image for the DNS-over-TLS forwarder
+	DNSMasqUnboundContainerImage = "quay.io/podified-antelope-centos9/openstack-unbound:current-podified"
+
+	// DNSMasqExporterContainerImage is the fall-back container image for the metrics exporter
+	DNSMasqExporterContainerImage = "ghcr.io/google/dnsmasq_exporter:v0.3.0"
 )
 
-// DNSMasqOption defines allowed options for dnsmasq
+// DNSMasqOption defines an option for dnsmasq
 type DNSMasqOption struct {
-	// +kubebuilder:validation:Enum=server;rev-server;srv-host;txt-record;ptr-record;rebind-domain-ok;naptr-record;cname;host-record;caa-record;dns-rr;auth-zone;synth-domain;no-negcache;local
-	Key    string   `json:"key"`
+	// +kubebuilder:validation:Pattern=`^[a-z0-9][a-z0-9-]*$`
+	// Key - long name of the dnsmasq option, without the leading dashes, e.g. server.
+	// Options managed by the operator, like conf-file or listen-address, are rejected.
+	Key string `json:"key"`
+	// Values - values of the option, joined by commas, e.g. /example.com/192.168.122.1.
+	// Values with control characters or newlines are rejected.
 	Values []string `json:"values"`
 }
 
@@ -73,11 +86,306 @@ type DNSMasqSpec struct {
 	// +kubebuilder:validation:Optional
 	// Override, provides the ability to override the generated manifest of several child resources.
 	Override DNSMasqOverrideSpec `json:"override,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// UpstreamTLS - forward the queries to upstream resolvers over DNS-over-TLS, using an unbound
+	// sidecar dnsmasq forwards to in addition to the servers of the options
+	UpstreamTLS *DNSMasqUpstreamTLS `json:"upstreamTLS,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// DNSSEC - validate the answers of the upstream servers using DNSSEC
+	DNSSEC *DNSMasqDNSSEC `json:"dnssec,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Metrics - run an exporter sidecar exposing the dnsmasq statistics for Prometheus.
+	// The metrics get exposed via the dnsmasq-<name>-metrics service, and scraped via
+	// a ServiceMonitor of the same name if the prometheus-operator CRDs are installed.
+	Metrics *DNSMasqMetrics `json:"metrics,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// NetworkAttachments is a list of NetworkAttachment resource names to attach the dnsmasq pods to.
+	// If set, dnsmasq listens on the pod IP and the interfaces of these networks, so nodes on
+	// those networks can query it directly.
+	NetworkAttachments []string `json:"networkAttachments,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Debug - troubleshooting options of the dnsmasq instance. If not set, the queries
+	// get logged with the default LogQueueSize.
+	Debug *DNSMasqDebug `json:"debug,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default={}
+	// Cache - tuning of the dnsmasq query cache
+	Cache DNSMasqCache `json:"cache,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Authoritative - answer authoritatively for local zones, using the records of the
+	// DNSData, so downstream resolvers can delegate those zones to dnsmasq
+	Authoritative *DNSMasqAuthoritative `json:"authoritative,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// ClientSubnets - return answers depending on the subnet the query comes from, e.g.
+	// for multiple sites sharing one DNSMasq. Note dnsmasq can not select the forwarder
+	// based on the client, but forward the client subnet so the upstream servers can.
+	ClientSubnets *DNSMasqClientSubnets `json:"clientSubnets,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// NetworkPolicy - when set, a NetworkPolicy is created which limits the DNS queries
+	// to the clients in the CIDRs or matching the selectors, and the operator pods if
+	// healthCheck is set. Otherwise any pod in the cluster can query dnsmasq.
+	NetworkPolicy *DNSMasqNetworkPolicy `json:"networkPolicy,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// HealthCheck - when set, the operator periodically resolves a canary record via the
+	// service in the background and reports failing or slow resolution, e.g. caused by broken upstream
+	// forwarders, via the ResolutionDegraded condition and events
+	HealthCheck *DNSMasqHealthCheck `json:"healthCheck,omitempty"`
+}
+
+// DNSMasqClientSubnets defines the answers based on the subnet of the client
+type DNSMasqClientSubnets struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=false
+	// LocaliseQueries - if a host of the DNSData has addresses on multiple networks, answer
+	// with the address of the network the query arrived on. Requires the dnsmasq pods to
+	// be attached to those networks using networkAttachments.
+	LocaliseQueries bool `json:"localiseQueries"`
+
+	// +kubebuilder:validation:Optional
+	// AddSubnet - forward the subnet of the client to the upstream servers using the
+	// EDNS client subnet option, so they can return site specific answers
+	AddSubnet *DNSMasqAddSubnet `json:"addSubnet,omitempty"`
+}
+
+// DNSMasqAddSubnet defines the client subnet forwarded to the upstream servers
+type DNSMasqAddSubnet struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=24
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=32
+	// IPv4PrefixLength - prefix length of the forwarded IPv4 client subnet
+	IPv4PrefixLength int32 `json:"ipv4PrefixLength"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=56
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=128
+	// IPv6PrefixLength - prefix length of the forwarded IPv6 client subnet
+	IPv6PrefixLength int32 `json:"ipv6PrefixLength"`
+}
+
+// DNSMasqAuthoritative defines the zones dnsmasq is authoritative for
+type DNSMasqAuthoritative struct {
+	// +kubebuilder:validation:Required
+	// Server - name of the authoritative name server, used for the NS and SOA records
+	// of the zones. The downstream resolvers must resolve it to the dnsmasq service.
+	Server string `json:"server"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	// Zones - the zones dnsmasq is authoritative for
+	Zones []DNSMasqAuthZone `json:"zones"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default={}
+	// SOA - parameters of the SOA record of the zones
+	SOA DNSMasqSOA `json:"soa,omitempty"`
+}
+
+// DNSMasqAuthZone defines an authoritative zone
+type DNSMasqAuthZone struct {
+	// +kubebuilder:validation:Required
+	// Domain - domain of the zone
+	Domain string `json:"domain"`
+
+	// +kubebuilder:validation:Optional
+	// Subnets - restrict the records of the zone to addresses in these CIDRs, which
+	// also makes dnsmasq authoritative for the matching reverse zones
+	Subnets []string `json:"subnets,omitempty"`
+}
+
+// DNSMasqSOA defines the parameters of the SOA record of the authoritative zones
+type DNSMasqSOA struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=4294967295
+	// Serial - serial of the zones. If not set dnsmasq uses the time it got started.
+	Serial *int64 `json:"serial,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Hostmaster - mailbox of the zone admin in domain name format, e.g. hostmaster.example.com.
+	// If not set dnsmasq uses hostmaster.<server>.
+	Hostmaster string `json:"hostmaster,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=1200
+	// +kubebuilder:validation:Minimum=1
+	// Refresh - seconds secondary servers wait before checking the zone for changes, 1200 if not set
+	Refresh *int32 `json:"refresh,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=180
+	// +kubebuilder:validation:Minimum=1
+	// Retry - seconds secondary servers wait before retrying a failed refresh, 180 if not set
+	Retry *int32 `json:"retry,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=1209600
+	// +kubebuilder:validation:Minimum=1
+	// Expiry - seconds after which secondary servers stop answering for the zone
+	// if it could not be refreshed, 1209600 if not set
+	Expiry *int32 `json:"expiry,omitempty"`
+}
+
+// DNSMasqCache defines the tuning of the dnsmasq query cache
+type DNSMasqCache struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=10000
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1000000
+	// Size - number of names dnsmasq caches, 0 disables the cache, 10000 if not set. The dnsmasq
+	// default of 150 names is too small for large deployments and results in a lot of upstream queries.
+	Size *int32 `json:"size,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=86400
+	// NegTTL - seconds negative replies without SOA record get cached. If not set
+	// dnsmasq does not cache those replies.
+	NegTTL *int32 `json:"negTTL,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=604800
+	// MaxCacheTTL - upper limit in seconds for the TTL of cached entries. If not set the
+	// TTL of the upstream answer is used.
+	MaxCacheTTL *int32 `json:"maxCacheTTL,omitempty"`
+}
+
+// DNSMasqDebug defines the troubleshooting options of dnsmasq
+type DNSMasqDebug struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=true
+	// QueryLogging - log every query and its answer to stdout, like before the option
+	// got added. The container log gets rotated by the kubelet, like any other container log.
+	QueryLogging bool `json:"queryLogging"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=25
+	// +kubebuilder:validation:Minimum=5
+	// +kubebuilder:validation:Maximum=100
+	// LogQueueSize - number of log lines dnsmasq queues when stdout can not keep up.
+	// Further lines get dropped instead of delaying the queries.
+	LogQueueSize int32 `json:"logQueueSize"`
+}
+
+// DNSMasqMetrics defines the metrics exporter sidecar
+type DNSMasqMetrics struct {
+	// +kubebuilder:validation:Optional
+	// ContainerImage - dnsmasq_exporter container image
+	ContainerImage string `json:"containerImage"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=9153
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// Port - port the metrics are exposed on
+	Port int32 `json:"port"`
+}
+
+// DNSMasqDNSSEC defines the DNSSEC validation of dnsmasq
+type DNSMasqDNSSEC struct {
+	// +kubebuilder:validation:Optional
+	// TrustAnchors - DS records in the dnsmasq trust-anchor format
+	// <domain>,<key-tag>,<algorithm>,<digest-type>,<digest>, e.g. for a
+	// private root. If not set the root trust anchors shipped with dnsmasq are used.
+	TrustAnchors []string `json:"trustAnchors,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=true
+	// CheckUnsigned - also check that unsigned answers are from zones which are legitimately unsigned
+	CheckUnsigned *bool `json:"checkUnsigned,omitempty"`
+}
+
+// DNSMasqUpstreamTLS defines the upstream resolvers queried over DNS-over-TLS
+type DNSMasqUpstreamTLS struct {
+	// +kubebuilder:validation:Optional
+	// ContainerImage - unbound container image of the DNS-over-TLS forwarder
+	ContainerImage string `json:"containerImage"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	// Servers - the upstream resolvers
+	Servers []DNSMasqTLSServer `json:"servers"`
+
+	// +kubebuilder:validation:Optional
+	// Ca - secret holding the CA bundle (tls-ca-bundle.pem) to verify the certificates of the
+	// upstream resolvers, the CA bundle of the image is used if not set
+	tls.Ca `json:",inline"`
+}
+
+// DNSMasqTLSServer defines an upstream resolver queried over DNS-over-TLS
+type DNSMasqTLSServer struct {
+	// +kubebuilder:validation:Required
+	// Address - IP address of the resolver
+	Address string `json:"address"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=853
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// Port - DNS-over-TLS port of the resolver
+	Port int32 `json:"port"`
+
+	// +kubebuilder:validation:Required
+	// AuthName - name the certificate of the resolver has to be valid for, e.g. dns.example.com
+	AuthName string `json:"authName"`
+}
+
+// DNSMasqNetworkPolicy defines the clients allowed to query dnsmasq. If neither
+// CIDRs nor selectors are set, only the pods in the namespace of the DNSMasq are allowed.
+type DNSMasqNetworkPolicy struct {
+	// +kubebuilder:validation:Optional
+	// CIDRs - source subnets of the allowed clients, e.g. of the nodes or of the clients
+	// reaching the LoadBalancer service, which requires their source IP to be preserved
+	CIDRs []string `json:"cidrs,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// NamespaceSelector - selects the namespaces of the allowed client pods
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// PodSelector - selects the allowed client pods within the selected namespaces,
+	// or within the namespace of the DNSMasq if no namespace selector is set
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+}
+
+// DNSMasqHealthCheck defines the resolution health check of the DNSMasq
+type DNSMasqHealthCheck struct {
+	// +kubebuilder:validation:Required
+	// Hostname - canary record to resolve, e.g. a name served by the upstream
+	// forwarders to also cover them
+	Hostname string `json:"hostname"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=60
+	// +kubebuilder:validation:Minimum=10
+	// IntervalSeconds - seconds between two health checks
+	IntervalSeconds int32 `json:"intervalSeconds,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=500
+	// +kubebuilder:validation:Minimum=1
+	// LatencyThresholdMilliseconds - resolution latency above which the resolution is
+	// reported as degraded
+	LatencyThresholdMilliseconds int64 `json:"latencyThresholdMilliseconds,omitempty"`
 }
 
 // DNSMasqOverrideSpec to override the generated manifest of several child resources.
 type DNSMasqOverrideSpec struct {
 	// Override configuration for the Service created to serve traffic to the cluster.
+	// E.g. set the type to LoadBalancer and add the metallb.universe.tf annotations to
+	// expose the DNS service on a MetalLB address pool of a network outside the cluster.
 	Service *service.OverrideSpec `json:"service,omitempty"`
 }
 
@@ -97,11 +405,36 @@ type DNSMasqStatus struct {
 
 	// DNSServer Cluster Addresses
 	DNSClusterAddresses []string `json:"dnsClusterAddresses,omitempty"`
+
+	// SkippedResources - owned resources not managed by the operator, because they
+	// are opted out via a <group>/manage-<resource>: "false" annotation on the CR
+	SkippedResources []string `json:"skippedResources,omitempty" optional:"true"`
+
+	// NetworkAttachments status of the deployment pods
+	NetworkAttachments map[string][]string `json:"networkAttachments,omitempty"`
+
+	// RecordCount - number of records rendered from the DNSData
+	RecordCount int32 `json:"recordCount,omitempty"`
+
+	// HostsHash - hash of the aggregated hosts of the DNSData
+	HostsHash string `json:"hostsHash,omitempty"`
+
+	// ConfigSerial - incremented whenever the rendered config or the hosts change. It tells
+	// which version of the records got rendered, not if the dnsmasq pods already reloaded it
+	ConfigSerial int64 `json:"configSerial,omitempty"`
+
+	// HealthCheckedAt - time the canary record got resolved, only set when spec.healthCheck is set
+	HealthCheckedAt *metav1.Time `json:"healthCheckedAt,omitempty" optional:"true"`
+
+	// ResolutionLatencyMilliseconds - latency of the last successful resolution of the canary record
+	ResolutionLatencyMilliseconds int64 `json:"resolutionLatencyMilliseconds,omitempty" optional:"true"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[0].status",description="Ready"
+//+kubebuilder:printcolumn:name="Records",type="integer",JSONPath=".status.recordCount",description="Records"
+//+kubebuilder:printcolumn:name="Serial",type="integer",JSONPath=".status.configSerial",description="Serial"
 //+kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[0].message",description="Message"
 
 // DNSMasq is the Schema for the dnsmasqs API
@@ -155,8 +488,27 @@ func (s DNSMasqStatus) GetConditions() condition.Conditions {
 func SetupDefaults() {
 	// Acquire environmental defaults and initialize DNSMasq defaults with them
 	dnsMasqDefaults := DNSMasqDefaults{
-		ContainerImageURL: util.GetEnvVar("RELATED_IMAGE_INFRA_DNSMASQ_IMAGE_URL_DEFAULT", DNSMasqContainerImage),
+		ContainerImageURL:         util.GetEnvVar("RELATED_IMAGE_INFRA_DNSMASQ_IMAGE_URL_DEFAULT", DNSMasqContainerImage),
+		UnboundContainerImageURL:  util.GetEnvVar("RELATED_IMAGE_INFRA_UNBOUND_IMAGE_URL_DEFAULT", DNSMasqUnboundContainerImage),
+		ExporterContainerImageURL: util.GetEnvVar("RELATED_IMAGE_INFRA_DNSMASQ_EXPORTER_IMAGE_URL_DEFAULT", DNSMasqExporterContainerImage),
 	}
 
 	SetupDNSMasqDefaults(dnsMasqDefaults)
+
+	// the BGP fabric the NetConfigs announce addresses to is operator configuration
+	bgpASN, err := strconv.ParseInt(util.GetEnvVar("BGP_ASN", "0"), 10, 64)
+	if err != nil {
+		netconfiglog.Error(err, "invalid BGP_ASN, BGP announcements disabled")
+		bgpASN = 0
+	}
+	bgpNeighbors, err := ParseBGPNeighbors(util.GetEnvVar("BGP_NEIGHBORS", ""))
+	if err != nil {
+		netconfiglog.Error(err, "invalid BGP_NEIGHBORS, BGP announcements disabled")
+		bgpNeighbors = nil
+	}
+	SetupNetConfigDefaults(NetConfigDefaults{
+		FRRK8sNamespace: util.GetEnvVar("FRR_K8S_NAMESPACE", "metallb-system"),
+		BGPASN:          bgpASN,
+		BGPNeighbors:    bgpNeighbors,
+	})
 }
@@ -17,7 +17,16 @@ limitations under the License.
 package v1beta1
 
 import (
+	"fmt"
+	"net"
+	"strings"
+	"unicode"
+
+	"golang.org/x/exp/slices"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -25,11 +34,53 @@ import (
 
 // DNSMasqDefaults -
 type DNSMasqDefaults struct {
-	ContainerImageURL string
+	ContainerImageURL         string
+	UnboundContainerImageURL  string
+	ExporterContainerImageURL string
 }
 
 var dnsMasqDefaults DNSMasqDefaults
 
+// dnsMasqDeniedOptions - dnsmasq options which can not be passed via spec.options,
+// as they would break the config, listeners or files managed by the operator
+var dnsMasqDeniedOptions = map[string]struct{}{
+	"add-subnet":         {},
+	"addn-hosts":         {},
+	"auth-server":        {},
+	"auth-soa":           {},
+	"auth-zone":          {},
+	"bind-dynamic":       {},
+	"bind-interfaces":    {},
+	"conf-dir":           {},
+	"conf-file":          {},
+	"conf-script":        {},
+	"dhcp-script":        {},
+	"except-interface":   {},
+	"group":              {},
+	"hostsdir":           {},
+	"interface":          {},
+	"keep-in-foreground": {},
+	"listen-address":     {},
+	"localise-queries":   {},
+	"log-async":          {},
+	"log-facility":       {},
+	"log-queries":        {},
+	"no-daemon":          {},
+	"pid-file":           {},
+	"port":               {},
+	"resolv-file":        {},
+	"servers-file":       {},
+	"user":               {},
+}
+
+// dnsMasqCacheOptions - dnsmasq options configured via spec.cache. They could be passed
+// via spec.options before, so existing CRs keep them on update as long as they are unchanged.
+var dnsMasqCacheOptions = map[string]struct{}{
+	"cache-size":    {},
+	"max-cache-ttl": {},
+	"neg-ttl":       {},
+}
+
 // log is for logging in this package.
 var dnsmasqlog = logf.Log.WithName("dnsmasq-resource")
 
@@ -62,6 +113,12 @@ func (spec *DNSMasqSpec) Default() {
 	if spec.ContainerImage == "" {
 		spec.ContainerImage = dnsMasqDefaults.ContainerImageURL
 	}
+	if spec.UpstreamTLS != nil && spec.UpstreamTLS.ContainerImage == "" {
+		spec.UpstreamTLS.ContainerImage = dnsMasqDefaults.UnboundContainerImageURL
+	}
+	if spec.Metrics != nil && spec.Metrics.ContainerImage == "" {
+		spec.Metrics.ContainerImage = dnsMasqDefaults.ExporterContainerImageURL
+	}
 }
 
 // TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
@@ -73,16 +130,29 @@ var _ webhook.Validator = &DNSMasq{}
 func (r *DNSMasq) ValidateCreate() error {
 	dnsmasqlog.Info("validate create", "name", r.Name)
 
-	// TODO(user): fill in your validation logic upon object creation.
-	return nil
+	allErrs := r.Spec.validate(field.NewPath("spec"), nil)
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("DNSMasq").GroupKind(), r.Name, allErrs)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
 func (r *DNSMasq) ValidateUpdate(old runtime.Object) error {
 	dnsmasqlog.Info("validate update", "name", r.Name)
 
-	// TODO(user): fill in your validation logic upon object update.
-	return nil
+	oldDNSMasq, ok := old.(*DNSMasq)
+	if !ok || oldDNSMasq == nil {
+		return apierrors.NewInternalError(fmt.Errorf("unable to convert existing object"))
+	}
+
+	allErrs := r.Spec.validate(field.NewPath("spec"), &oldDNSMasq.Spec)
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("DNSMasq").GroupKind(), r.Name, allErrs)
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
@@ -92,3 +162,106 @@ func (r *DNSMasq) ValidateDelete() error {
 	// TODO(user): fill in your validation logic upon object deletion.
 	return nil
 }
+
+// hasOption - returns if the spec has the option with the same values
+func (spec *DNSMasqSpec) hasOption(option DNSMasqOption) bool {
+	if spec == nil {
+		return false
+	}
+
+	return slices.ContainsFunc(spec.Options, func(o DNSMasqOption) bool {
+		return o.Key == option.Key && slices.Equal(o.Values, option.Values)
+	})
+}
+
+// Validate - returns an error if the option can not be rendered into the dnsmasq
+// config, because it is managed by the operator or a value has control characters,
+// e.g. a newline which would inject further directives
+func (o DNSMasqOption) Validate() error {
+	if _, denied := dnsMasqDeniedOptions[o.Key]; denied {
+		return fmt.Errorf("option %s is managed by the operator", o.Key)
+	}
+	if strings.IndexFunc(o.Key, unicode.IsControl) >= 0 {
+		return fmt.Errorf("option %s: key %s", o.Key, errControlChars)
+	}
+	for _, value := range o.Values {
+		if strings.IndexFunc(value, unicode.IsControl) >= 0 {
+			return fmt.Errorf("option %s: value %q %s", o.Key, value, errControlChars)
+		}
+	}
+
+	return nil
+}
+
+// validate - validates the DNSMasq spec, oldSpec is the spec of the existing
+// DNSMasq on update, nil on create
+func (spec *DNSMasqSpec) validate(basePath *field.Path, oldSpec *DNSMasqSpec) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	for idx, option := range spec.Options {
+		path := basePath.Child("options").Index(idx)
+		if _, denied := dnsMasqDeniedOptions[option.Key]; denied {
+			allErrs = append(allErrs, field.Forbidden(path.Child("key"),
+				fmt.Sprintf("option %s is managed by the operator", option.Key)))
+		}
+		if _, cache := dnsMasqCacheOptions[option.Key]; cache && !oldSpec.hasOption(option) {
+			allErrs = append(allErrs, field.Forbidden(path.Child("key"),
+				fmt.Sprintf("option %s is configured via spec.cache", option.Key)))
+		}
+		for valueIdx, value := range option.Values {
+			if strings.IndexFunc(value, unicode.IsControl) >= 0 {
+				allErrs = append(allErrs, field.Invalid(path.Child("values").Index(valueIdx), value, errControlChars))
+			}
+		}
+	}
+
+	if spec.UpstreamTLS != nil {
+		path := basePath.Child("upstreamTLS").Child("servers")
+		for idx, server := range spec.UpstreamTLS.Servers {
+			if net.ParseIP(server.Address) == nil {
+				allErrs = append(allErrs, field.Invalid(path.Index(idx).Child("address"), server.Address, errNotIPAddr))
+			}
+		}
+	}
+
+	if spec.Authoritative != nil {
+		path := basePath.Child("authoritative")
+		if !validateDNSDomain(spec.Authoritative.Server) {
+			allErrs = append(allErrs, field.Invalid(path.Child("server"), spec.Authoritative.Server,
+				fmt.Sprintf(errInvalidDNSDomain, spec.Authoritative.Server)))
+		}
+		for idx, zone := range spec.Authoritative.Zones {
+			zonePath := path.Child("zones").Index(idx)
+			if !validateDNSDomain(zone.Domain) {
+				allErrs = append(allErrs, field.Invalid(zonePath.Child("domain"), zone.Domain,
+					fmt.Sprintf(errInvalidDNSDomain, zone.Domain)))
+			}
+			for subnetIdx, subnet := range zone.Subnets {
+				if _, _, err := net.ParseCIDR(subnet); err != nil {
+					allErrs = append(allErrs, field.Invalid(zonePath.Child("subnets").Index(subnetIdx), subnet, errInvalidCidr))
+				}
+			}
+		}
+		hostmaster := spec.Authoritative.SOA.Hostmaster
+		if hostmaster != "" && !validateDNSDomain(hostmaster) {
+			allErrs = append(allErrs, field.Invalid(path.Child("soa").Child("hostmaster"), hostmaster,
+				fmt.Sprintf(errInvalidDNSDomain, hostmaster)))
+		}
+	}
+
+	if spec.HealthCheck != nil && !validateDNSDomain(spec.HealthCheck.Hostname) {
+		allErrs = append(allErrs, field.Invalid(basePath.Child("healthCheck").Child("hostname"),
+			spec.HealthCheck.Hostname, fmt.Sprintf(errInvalidDNSDomain, spec.HealthCheck.Hostname)))
+	}
+
+	if spec.NetworkPolicy != nil {
+		path := basePath.Child("networkPolicy").Child("cidrs")
+		for idx, cidr := range spec.NetworkPolicy.CIDRs {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				allErrs = append(allErrs, field.Invalid(path.Index(idx), cidr, errInvalidCidr))
+			}
+		}
+	}
+
+	return allErrs
+}
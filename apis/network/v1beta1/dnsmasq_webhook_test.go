@@ -0,0 +1,165 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestDNSMasqValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		expectErr bool
+		spec      DNSMasqSpec
+		oldSpec   *DNSMasqSpec
+	}{
+		{
+			name:      "should succeed with passthrough options",
+			expectErr: false,
+			spec: DNSMasqSpec{
+				Options: []DNSMasqOption{
+					{Key: "server", Values: []string{"1.1.1.1"}},
+					{Key: "cache-size", Values: []string{"1000"}},
+					{Key: "no-negcache", Values: []string{}},
+				},
+			},
+			oldSpec: &DNSMasqSpec{
+				Options: []DNSMasqOption{
+					{Key: "cache-size", Values: []string{"1000"}},
+				},
+			},
+		},
+		{
+			name:      "should fail adding a cache option configured via spec.cache",
+			expectErr: true,
+			spec: DNSMasqSpec{
+				Options: []DNSMasqOption{
+					{Key: "cache-size", Values: []string{"1000"}},
+				},
+			},
+		},
+		{
+			name:      "should fail changing a cache option configured via spec.cache",
+			expectErr: true,
+			spec: DNSMasqSpec{
+				Options: []DNSMasqOption{
+					{Key: "cache-size", Values: []string{"2000"}},
+				},
+			},
+			oldSpec: &DNSMasqSpec{
+				Options: []DNSMasqOption{
+					{Key: "cache-size", Values: []string{"1000"}},
+				},
+			},
+		},
+		{
+			name:      "should fail with an option managed by the operator",
+			expectErr: true,
+			spec: DNSMasqSpec{
+				Options: []DNSMasqOption{
+					{Key: "conf-file", Values: []string{"/tmp/dnsmasq.conf"}},
+				},
+			},
+		},
+		{
+			name:      "should fail with an option value with a newline",
+			expectErr: true,
+			spec: DNSMasqSpec{
+				Options: []DNSMasqOption{
+					{Key: "server", Values: []string{"1.1.1.1\nconf-file=/tmp/dnsmasq.conf"}},
+				},
+			},
+		},
+		{
+			name:      "should fail with a DNS-over-TLS server which is not an IP",
+			expectErr: true,
+			spec: DNSMasqSpec{
+				UpstreamTLS: &DNSMasqUpstreamTLS{
+					Servers: []DNSMasqTLSServer{
+						{Address: "dns.example.com", Port: 853, AuthName: "dns.example.com"},
+					},
+				},
+			},
+		},
+		{
+			name:      "should succeed with an authoritative zone",
+			expectErr: false,
+			spec: DNSMasqSpec{
+				Authoritative: &DNSMasqAuthoritative{
+					Server: "ns1.example.com",
+					Zones: []DNSMasqAuthZone{
+						{Domain: "ctlplane.example.com", Subnets: []string{"192.168.122.0/24"}},
+					},
+				},
+			},
+		},
+		{
+			name:      "should fail with an invalid authoritative zone",
+			expectErr: true,
+			spec: DNSMasqSpec{
+				Authoritative: &DNSMasqAuthoritative{
+					Server: "ns1.example.com",
+					Zones: []DNSMasqAuthZone{
+						{Domain: "ctlplane.example.com", Subnets: []string{"192.168.122.0"}},
+					},
+				},
+			},
+		},
+		{
+			name:      "should succeed with a network policy",
+			expectErr: false,
+			spec: DNSMasqSpec{
+				NetworkPolicy: &DNSMasqNetworkPolicy{
+					CIDRs: []string{"192.168.122.0/24", "fd00:bbbb::/64"},
+				},
+			},
+		},
+		{
+			name:      "should fail with an invalid health check hostname",
+			expectErr: true,
+			spec: DNSMasqSpec{
+				HealthCheck: &DNSMasqHealthCheck{
+					Hostname: "canary",
+				},
+			},
+		},
+		{
+			name:      "should fail with an invalid network policy CIDR",
+			expectErr: true,
+			spec: DNSMasqSpec{
+				NetworkPolicy: &DNSMasqNetworkPolicy{
+					CIDRs: []string{"192.168.122.1"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			allErrs := tt.spec.validate(field.NewPath("spec"), tt.oldSpec)
+			if tt.expectErr {
+				g.Expect(allErrs).NotTo(BeEmpty())
+			} else {
+				g.Expect(allErrs).To(BeEmpty())
+			}
+		})
+	}
+}
@@ -17,12 +17,30 @@ limitations under the License.
 package v1beta1
 
 import (
+	"fmt"
+	"strings"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
 )
 
-// IPSetNetwork Type
+const (
+	// AnnotationConsumer - the workload using the addresses of the IPSet, in the format
+	// <kind>.<version>.<group>/<name>, e.g. StatefulSet.v1.apps/compute-0, or Pod.v1/compute-0
+	// for the core group. The deletion of the IPSet, and so the release of its addresses, is
+	// blocked while the consumer exists in the namespace of the IPSet. Removing the annotation
+	// unblocks it. The consumer must be a Pod, StatefulSet or Deployment.
+	AnnotationConsumer = "network.openstack.org/consumer"
+
+	// AnnotationBGPAnnounce - if "true", the reserved addresses of the IPSet get announced via
+	// BGP, if the NetConfig sets a BGPAnnouncement
+	AnnotationBGPAnnounce = "network.openstack.org/bgp-announce"
+)
+
+// IPSetNetwork Type. A network can be listed once per IP family to request a
+// dual-stack address pair, the IPv6 reservation is then stored as <net>.ipv6
 type IPSetNetwork struct {
 	// +kubebuilder:validation:Required
 	// Network Name
@@ -33,7 +51,8 @@ type IPSetNetwork struct {
 	SubnetName NetNameStr `json:"subnetName"`
 
 	// +kubebuilder:validation:Optional
-	// Fixed Ip
+	// Fixed Ip, the address within the subnet to reserve. If it is already reserved for another
+	// IPSet, the ReservationReady condition reports it until the address gets released.
 	FixedIP *string `json:"fixedIP,omitempty"`
 
 	// +kubebuilder:validation:Optional
@@ -52,6 +71,17 @@ type IPSetSpec struct {
 
 	// Networks used to request IPs for
 	Networks []IPSetNetwork `json:"networks"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=256
+	// Ordinals, if set, a block of consecutive addresses is reserved per requested network, one
+	// address for each StatefulSet pod ordinal from 0 to Ordinals-1. The address of ordinal i is
+	// the i-th address of the block. The start of the block is kept when Ordinals changes, so the
+	// pods keep their addresses when the StatefulSet gets scaled up or down. A FixedIP is the
+	// start of the block, otherwise the lowest AllocationRange addresses the block fits in get
+	// used, independent of the AllocationStrategy of the subnet.
+	Ordinals *int `json:"ordinals,omitempty"`
 }
 
 // IPSetReservation defines reservation status per requested network
@@ -65,13 +95,16 @@ type IPSetReservation struct {
 	// Address contains the IP address
 	Address string `json:"address"`
 
-	// MTU of the network
+	// Ordinal of the StatefulSet pod the address is for, if the IPSet sets Ordinals
+	Ordinal *int `json:"ordinal,omitempty" optional:"true"`
+
+	// MTU of the network from the NetConfig, consumers should configure the interface with it
 	MTU int `json:"mtu,omitempty" optional:"true"`
 
 	// Cidr the cidr to use for this network
 	Cidr string `json:"cidr,omitempty" optional:"true"`
 
-	// Vlan ID
+	// Vlan ID of the subnet from the NetConfig
 	Vlan *int `json:"vlan,omitempty" optional:"true"`
 
 	// Gateway optional gateway for the network
@@ -125,3 +158,29 @@ func init() {
 func (s IPSetStatus) GetConditions() condition.Conditions {
 	return s.Conditions
 }
+
+// GetConsumer returns the GroupVersionKind and name of the workload set with the
+// AnnotationConsumer annotation, nil if the annotation is not set
+func (instance IPSet) GetConsumer() (*schema.GroupVersionKind, string, error) {
+	consumer, ok := instance.Annotations[AnnotationConsumer]
+	if !ok {
+		return nil, "", nil
+	}
+
+	idx := strings.LastIndex(consumer, "/")
+	if idx < 0 {
+		return nil, "", fmt.Errorf("consumer %s not in format <kind>.<version>.<group>/<name>", consumer)
+	}
+	name := consumer[idx+1:]
+	parts := strings.SplitN(consumer[:idx], ".", 3)
+	if name == "" || len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return nil, "", fmt.Errorf("consumer %s not in format <kind>.<version>.<group>/<name>", consumer)
+	}
+
+	gvk := &schema.GroupVersionKind{Kind: parts[0], Version: parts[1]}
+	if len(parts) == 3 {
+		gvk.Group = parts[2]
+	}
+
+	return gvk, name, nil
+}
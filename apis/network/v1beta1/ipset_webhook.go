@@ -19,11 +19,13 @@ package v1beta1
 import (
 	"fmt"
 	"net"
+	"net/netip"
 	"strings"
 
 	"golang.org/x/exp/slices"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/validation/field"
@@ -36,6 +38,13 @@ import (
 // log is for logging in this package.
 var ipsetlog = logf.Log.WithName("ipset-resource")
 
+// consumerKinds - the kinds of consumers the operator has RBAC to get
+var consumerKinds = []schema.GroupKind{
+	{Kind: "Pod"},
+	{Group: "apps", Kind: "StatefulSet"},
+	{Group: "apps", Kind: "Deployment"},
+}
+
 // SetupWebhookWithManager sets up the webhook with the Manager
 func (r *IPSet) SetupWebhookWithManager(mgr ctrl.Manager) error {
 	if webhookClient == nil {
@@ -82,6 +91,8 @@ func (r *IPSet) ValidateCreate() error {
 
 	// validate requested networks exist in netcfg
 	allErrs = append(allErrs, valiateIPSetNetwork(r.Spec.Networks, basePath, &netcfg.Spec)...)
+	allErrs = append(allErrs, valiateIPSetOrdinals(r.Spec, basePath, &netcfg.Spec)...)
+	allErrs = append(allErrs, valiateIPSetConsumer(r, webhookClient.RESTMapper())...)
 
 	if len(allErrs) == 0 {
 		return nil
@@ -132,9 +143,11 @@ func (r *IPSet) ValidateUpdate(old runtime.Object) error {
 
 		// validate requested networks exist in
 		allErrs = append(allErrs, valiateIPSetNetwork(r.Spec.Networks, basePath, &netcfg.Spec)...)
+		allErrs = append(allErrs, valiateIPSetOrdinals(r.Spec, basePath, &netcfg.Spec)...)
 
 		// validate against the previous object only
 		allErrs = append(allErrs, valiateIPSetChanged(r.Spec.Networks, oldIPSet.Spec.Networks, basePath)...)
+		allErrs = append(allErrs, valiateIPSetConsumer(r, webhookClient.RESTMapper())...)
 	}
 
 	if len(allErrs) == 0 {
@@ -152,8 +165,77 @@ func (r *IPSet) ValidateDelete() error {
 	return nil
 }
 
+// valiateIPSetConsumer - the consumer annotation can be parsed, is of a supported
+// kind and the kind is served by the API server
+func valiateIPSetConsumer(ipset *IPSet, mapper meta.RESTMapper) field.ErrorList {
+	allErrs := field.ErrorList{}
+	path := field.NewPath("metadata").Child("annotations").Key(AnnotationConsumer)
+	consumer := ipset.Annotations[AnnotationConsumer]
+
+	gvk, _, err := ipset.GetConsumer()
+	if err != nil {
+		allErrs = append(allErrs, field.Invalid(path, consumer, err.Error()))
+		return allErrs
+	}
+	if gvk == nil {
+		return allErrs
+	}
+
+	if !slices.Contains(consumerKinds, gvk.GroupKind()) {
+		supported := []string{}
+		for _, gk := range consumerKinds {
+			supported = append(supported, gk.String())
+		}
+		allErrs = append(allErrs, field.NotSupported(path, gvk.GroupKind().String(), supported))
+		return allErrs
+	}
+
+	if _, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+		allErrs = append(allErrs, field.Invalid(path, consumer, err.Error()))
+	}
+
+	return allErrs
+}
+
+// valiateIPSetOrdinals - the block of Ordinals addresses starting at a FixedIP
+// is in the subnet cidr
+func valiateIPSetOrdinals(
+	spec IPSetSpec,
+	path *field.Path,
+	netCfgSpec *NetConfigSpec,
+) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if spec.Ordinals == nil {
+		return allErrs
+	}
+
+	for _netIdx, _net := range spec.Networks {
+		subnet := findSubnet(netCfgSpec.Networks, _net.Name, _net.SubnetName)
+		if _net.FixedIP == nil || subnet == nil {
+			// a missing subnet is reported by valiateIPSetNetwork
+			continue
+		}
+		fixedIP, ipErr := netip.ParseAddr(*_net.FixedIP)
+		prefix, prefixErr := netip.ParsePrefix(subnet.Cidr)
+		if ipErr != nil || prefixErr != nil {
+			continue
+		}
+
+		last := fixedIP.Unmap()
+		for i := 1; i < *spec.Ordinals && last.IsValid(); i++ {
+			last = last.Next()
+		}
+		if !last.IsValid() || !prefix.Contains(last) {
+			path := path.Child("networks").Index(_netIdx).Child("fixedIP")
+			allErrs = append(allErrs, field.Invalid(path, *_net.FixedIP, fmt.Sprintf(errOrdinalsNotInCidr, *spec.Ordinals, subnet.Cidr)))
+		}
+	}
+
+	return allErrs
+}
+
 // valiateIPSetNetwork
-// - networks are uniq in the list
+// - networks are uniq in the list per IP family, to allow dual-stack requests
 // - networks and subnets exist in netcfg
 // - FixedIP is a valid IP address
 // - FixedIP has correct IP version of subnet
@@ -165,7 +247,10 @@ func valiateIPSetNetwork(
 	netCfgSpec *NetConfigSpec,
 ) field.ErrorList {
 	allErrs := field.ErrorList{}
-	netNames := map[string]field.Path{}
+	netNames := map[k8snet.IPFamily]map[string]field.Path{
+		k8snet.IPv4: {},
+		k8snet.IPv6: {},
+	}
 	defaultRouteCount := map[k8snet.IPFamily]int{
 		k8snet.IPv4:            0,
 		k8snet.IPv6:            0,
@@ -175,9 +260,6 @@ func valiateIPSetNetwork(
 	for _netIdx, _net := range networks {
 		path := path.Child("networks").Index(_netIdx)
 
-		// validate uniqe networks in request
-		allErrs = append(allErrs, valiateUniqElement(netNames, string(_net.Name), path, "name", errDupeNetworkName)...)
-
 		// validate if requested network is in NetConfig
 		f := func(n Network) bool {
 			return strings.EqualFold(string(n.Name), string(_net.Name))
@@ -193,34 +275,34 @@ func valiateIPSetNetwork(
 
 			if subNetIdx >= 0 {
 				// net and subnet are valid
+				cidr := netCfgSpec.Networks[netIdx].Subnets[subNetIdx].Cidr
+				_, ipPrefix, ipPrefixErr := net.ParseCIDR(cidr)
+				if ipPrefixErr != nil {
+					// this should never happen as the subnet CIDR was already validated
+					// via the netcfg webhook
+					allErrs = append(allErrs, field.Invalid(path.Child("cidr"), cidr, errInvalidCidr))
+					return allErrs
+				}
+				ipFam := k8snet.IPFamilyOfCIDR(ipPrefix)
 
-				if _net.FixedIP != nil || _net.DefaultRoute != nil {
-					cidr := netCfgSpec.Networks[netIdx].Subnets[subNetIdx].Cidr
-					_, ipPrefix, ipPrefixErr := net.ParseCIDR(cidr)
-					if ipPrefixErr != nil {
-						// this should never happen as the subnet CIDR was already validated
-						// via the netcfg webhook
-						allErrs = append(allErrs, field.Invalid(path.Child("cidr"), cidr, errInvalidCidr))
-						return allErrs
-					}
-					ipFam := k8snet.IPFamilyOfCIDR(ipPrefix)
+				// validate uniqe networks per IP family in request
+				allErrs = append(allErrs, valiateUniqElement(netNames[ipFam], strings.ToLower(string(_net.Name)), path, "name", errDupeNetworkName)...)
 
-					// validate the requested FixedIP
-					if _net.FixedIP != nil {
-						path := path.Child("fixedIP")
-						if err := valiateAddress(*_net.FixedIP, ipPrefix, path); err != nil {
-							allErrs = append(allErrs, err...)
-						}
+				// validate the requested FixedIP
+				if _net.FixedIP != nil {
+					path := path.Child("fixedIP")
+					if err := valiateAddress(*_net.FixedIP, ipPrefix, path); err != nil {
+						allErrs = append(allErrs, err...)
 					}
+				}
 
-					// check that there are not multiple have the defaultRoute flag
-					if _net.DefaultRoute != nil && *_net.DefaultRoute {
-						defaultRouteCount[ipFam]++
+				// check that there are not multiple have the defaultRoute flag
+				if _net.DefaultRoute != nil && *_net.DefaultRoute {
+					defaultRouteCount[ipFam]++
 
-						for fam, count := range defaultRouteCount {
-							if count > 1 {
-								allErrs = append(allErrs, field.Invalid(path.Child("defaultRoute"), _net.Name, fmt.Sprintf(errMultiDefaultRoute, string(fam))))
-							}
+					for fam, count := range defaultRouteCount {
+						if count > 1 {
+							allErrs = append(allErrs, field.Invalid(path.Child("defaultRoute"), _net.Name, fmt.Sprintf(errMultiDefaultRoute, string(fam))))
 						}
 					}
 				}
@@ -255,11 +337,16 @@ func valiateIPSetChanged(
 	for _netIdx, _net := range oldNetworks {
 		path := path.Child("networks").Index(_netIdx)
 
-		// validate if a previous requested network is still in the CR
-		f := func(n IPSetNetwork) bool {
-			return equality.Semantic.DeepEqual(n.Name, _net.Name)
+		// validate if a previous requested network is still in the CR. A dual-stack
+		// network is requested twice, match the subnet first.
+		netIdx := slices.IndexFunc(networks, func(n IPSetNetwork) bool {
+			return equality.Semantic.DeepEqual(n.Name, _net.Name) && equality.Semantic.DeepEqual(n.SubnetName, _net.SubnetName)
+		})
+		if netIdx < 0 {
+			netIdx = slices.IndexFunc(networks, func(n IPSetNetwork) bool {
+				return equality.Semantic.DeepEqual(n.Name, _net.Name)
+			})
 		}
-		netIdx := slices.IndexFunc(networks, f)
 		if netIdx < 0 {
 			// the network was removed
 			allErrs = append(allErrs, field.Invalid(path.Child("name"), _net.Name, fmt.Sprintf(errNetworkChanged, _net.Name)))
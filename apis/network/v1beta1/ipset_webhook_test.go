@@ -21,7 +21,9 @@ import (
 
 	. "github.com/onsi/gomega"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/ptr"
 )
@@ -261,6 +263,55 @@ func TestIPSetValiateIPSetNetwork(t *testing.T) {
 			},
 			n: getDefaultIPv4IPv6NetConfigSpec(),
 		},
+		{
+			name:      "should succeed with a network requested from an IPv4 and IPv6 subnet",
+			expectErr: false,
+			c: &IPSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "netcfg",
+					Namespace: "foo",
+				},
+				Spec: IPSetSpec{
+					Networks: []IPSetNetwork{
+						{
+							Name:         "net1",
+							SubnetName:   "subnet1",
+							DefaultRoute: ptr.To(true),
+						},
+						{
+							Name:         "net1",
+							SubnetName:   "subnet2",
+							FixedIP:      ptr.To("fd00:fd00:fd00:2000::0010"),
+							DefaultRoute: ptr.To(true),
+						},
+					},
+				},
+			},
+			n: getDefaultDualStackNetConfigSpec(),
+		},
+		{
+			name:      "should fail with a network requested twice from the same IP family",
+			expectErr: true,
+			c: &IPSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "netcfg",
+					Namespace: "foo",
+				},
+				Spec: IPSetSpec{
+					Networks: []IPSetNetwork{
+						{
+							Name:       "net1",
+							SubnetName: "subnet1",
+						},
+						{
+							Name:       "net1",
+							SubnetName: "subnet2",
+						},
+					},
+				},
+			},
+			n: getDefaultIPv4NetConfigSpec(),
+		},
 	}
 
 	for _, tt := range tests {
@@ -477,3 +528,142 @@ func TestIPSetUpdateValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestIPSetConsumerValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		consumer  *string
+		expectErr bool
+		wantGVK   *schema.GroupVersionKind
+		wantName  string
+	}{
+		{
+			name:      "should succeed without consumer",
+			expectErr: false,
+		},
+		{
+			name:      "should succeed with a consumer of a group",
+			consumer:  ptr.To("StatefulSet.v1.apps/compute-0"),
+			expectErr: false,
+			wantGVK:   &schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "StatefulSet"},
+			wantName:  "compute-0",
+		},
+		{
+			name:      "should fail with a consumer of an unsupported kind",
+			consumer:  ptr.To("OpenStackDataPlaneNodeSet.v1beta1.dataplane.openstack.org/edpm"),
+			expectErr: true,
+		},
+		{
+			name:      "should fail with a misspelled kind",
+			consumer:  ptr.To("Statefulset.v1.apps/compute-0"),
+			expectErr: true,
+		},
+		{
+			name:      "should fail with a version not served",
+			consumer:  ptr.To("StatefulSet.v2.apps/compute-0"),
+			expectErr: true,
+		},
+		{
+			name:      "should succeed with a consumer of the core group",
+			consumer:  ptr.To("Pod.v1/compute-0"),
+			expectErr: false,
+			wantGVK:   &schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			wantName:  "compute-0",
+		},
+		{
+			name:      "should fail without name",
+			consumer:  ptr.To("Pod.v1/"),
+			expectErr: true,
+		},
+		{
+			name:      "should fail without version",
+			consumer:  ptr.To("Pod/compute-0"),
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			ipset := &IPSet{}
+			if tt.consumer != nil {
+				ipset.Annotations = map[string]string{AnnotationConsumer: *tt.consumer}
+			}
+
+			mapper := meta.NewDefaultRESTMapper(nil)
+			mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+			mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "StatefulSet"}, meta.RESTScopeNamespace)
+			mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+
+			allErrs := valiateIPSetConsumer(ipset, mapper)
+			if tt.expectErr {
+				g.Expect(allErrs).NotTo(BeEmpty())
+				return
+			}
+			g.Expect(allErrs).To(BeEmpty())
+
+			gvk, name, err := ipset.GetConsumer()
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(gvk).To(Equal(tt.wantGVK))
+			g.Expect(name).To(Equal(tt.wantName))
+		})
+	}
+}
+
+func TestIPSetOrdinalsValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		ordinals  *int
+		fixedIP   *string
+		expectErr bool
+	}{
+		{
+			name:      "should succeed without ordinals",
+			fixedIP:   ptr.To("172.17.0.250"),
+			expectErr: false,
+		},
+		{
+			name:      "should succeed with ordinals without fixedIP",
+			ordinals:  ptr.To(10),
+			expectErr: false,
+		},
+		{
+			name:      "should succeed with the block at the fixedIP in the cidr",
+			ordinals:  ptr.To(6),
+			fixedIP:   ptr.To("172.17.0.250"),
+			expectErr: false,
+		},
+		{
+			name:      "should fail with the block at the fixedIP exceeding the cidr",
+			ordinals:  ptr.To(7),
+			fixedIP:   ptr.To("172.17.0.250"),
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			spec := IPSetSpec{
+				Networks: []IPSetNetwork{
+					{
+						Name:       "net1",
+						SubnetName: "subnet1",
+						FixedIP:    tt.fixedIP,
+					},
+				},
+				Ordinals: tt.ordinals,
+			}
+			netcfg := getDefaultIPv4NetConfigSpec()
+
+			allErrs := valiateIPSetOrdinals(spec, field.NewPath("spec"), &netcfg)
+			if tt.expectErr {
+				g.Expect(allErrs).NotTo(BeEmpty())
+			} else {
+				g.Expect(allErrs).To(BeEmpty())
+			}
+		})
+	}
+}
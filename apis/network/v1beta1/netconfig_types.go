@@ -17,12 +17,22 @@ limitations under the License.
 package v1beta1
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"strings"
 
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	// AnnotationAllowSubnetChanges - set to "true" on the NetConfig to allow changing or
+	// removing networks and subnets in use by IPSets and Reservations, e.g. for an
+	// intentional migration. The existing Reservations do not get updated.
+	AnnotationAllowSubnetChanges = "network.openstack.org/allow-subnet-changes"
+)
+
 // +kubebuilder:validation:Pattern="^[a-zA-Z0-9][a-zA-Z0-9\\-_]*[a-zA-Z0-9]$"
 
 // NetNameStr is used for validation of a net name.
@@ -40,8 +50,16 @@ type Network struct {
 
 	// +kubebuilder:validation:Optional
 	// +kubebuilder:default=1500
-	// MTU of the network
-	MTU int `json:"mtu"`
+	// MTU of the network, reported with each address in the IPSet status, 1500 if not set.
+	// It has to be between 68 and 65535, a network with an IPv6 subnet requires at least 1280.
+	MTU int `json:"mtu,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=false
+	// PublishDNSRecords - publish the addresses reserved for IPSets on the network as
+	// <ipset>.<dnsDomain> A/AAAA and PTR records via the DNSData of the NetConfig. The
+	// addresses of an IPSet with Ordinals get published as <ipset>-<ordinal>.<dnsDomain>
+	PublishDNSRecords bool `json:"publishDNSRecords"`
 
 	// +kubebuilder:validation:Required
 	// Subnets of the network
@@ -63,20 +81,41 @@ type Subnet struct {
 	DNSDomain *string `json:"dnsDomain,omitempty"`
 
 	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
 	// +kubebuilder:validation:Maximum=4094
-	// Vlan ID
+	// Vlan ID, reported with the addresses of the subnet in the IPSet status. A vlan can
+	// only be used by the subnets of a single network.
 	Vlan *int `json:"vlan,omitempty"`
 
 	// +kubebuilder:validation:Required
 	// AllocationRanges a list of AllocationRange for assignment. Allocation will start
-	// from first range, first address.
+	// from first range, first address. Ranges can be extended or added while addresses are
+	// reserved, IPSets waiting for a free address then get their reservation.
 	AllocationRanges []AllocationRange `json:"allocationRanges"`
 
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=LowestFree;Sequential;Random
+	// +kubebuilder:default=LowestFree
+	// AllocationStrategy how an address gets picked from the AllocationRanges.
+	// LowestFree - the first free address, the result is deterministic.
+	// Sequential - the first free address after the highest currently reserved one, wrapping
+	// around at the end of the AllocationRanges. No cursor is persisted, released addresses
+	// below the highest reserved one get reused only after the end got reached, but releasing
+	// the highest reserved address makes it the next one picked.
+	// Random - a random free address, to reduce conflicts with unmanaged hosts.
+	AllocationStrategy AllocationStrategy `json:"allocationStrategy,omitempty"`
+
 	// +kubebuilder:validation:Optional
 	// ExcludeAddresses a set of IPs that should be excluded from used as reservation, for both dynamic
 	// and static via IPSet FixedIP parameter
 	ExcludeAddresses []string `json:"excludeAddresses,omitempty"`
 
+	// +kubebuilder:validation:Optional
+	// ExcludeRanges a list of address ranges that should be excluded from used as reservation, for
+	// both dynamic and static via IPSet FixedIP parameter, e.g. addresses owned by routers or load
+	// balancers within the AllocationRanges.
+	ExcludeRanges []AllocationRange `json:"excludeRanges,omitempty"`
+
 	// +kubebuilder:validation:Optional
 	// Gateway optional gateway for the network
 	Gateway *string `json:"gateway,omitempty"`
@@ -86,6 +125,19 @@ type Subnet struct {
 	Routes []Route `json:"routes,omitempty"`
 }
 
+// AllocationStrategy - how an address gets picked from the AllocationRanges of a subnet
+type AllocationStrategy string
+
+const (
+	// AllocationStrategyLowestFree - use the first free address
+	AllocationStrategyLowestFree AllocationStrategy = "LowestFree"
+	// AllocationStrategySequential - use the first free address after the highest currently
+	// reserved one
+	AllocationStrategySequential AllocationStrategy = "Sequential"
+	// AllocationStrategyRandom - use a random free address
+	AllocationStrategyRandom AllocationStrategy = "Random"
+)
+
 // AllocationRange definition
 type AllocationRange struct {
 	// +kubebuilder:validation:Required
@@ -113,10 +165,140 @@ type NetConfigSpec struct {
 	// +kubebuilder:validation:Required
 	// Networks, list of all networks of the deployment
 	Networks []Network `json:"networks"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default="dnsdata"
+	// Value of the DNSDataLabelSelector to set on the DNSData holding the records of the
+	// networks with PublishDNSRecords
+	DNSDataLabelSelectorValue string `json:"dnsDataLabelSelectorValue"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default="10m"
+	// StaleReservationTTL, time a Reservation whose IPSet no longer exists is kept before its
+	// addresses get released. With 0s stale Reservations get released once the API server confirms the IPSet is gone.
+	StaleReservationTTL *metav1.Duration `json:"staleReservationTTL,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=5
+	// +kubebuilder:validation:Minimum=0
+	// FreeAddressesThreshold, the AddressPoolAvailable condition reports a warning if a subnet
+	// has less free addresses in its AllocationRanges. With 0 no warning gets reported.
+	FreeAddressesThreshold *int64 `json:"freeAddressesThreshold,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// ImportedReservations, existing IP assignments of an adopted environment, e.g. from the
+	// TripleO ips-from-pool data. A Reservation gets seeded for each entry until the IPSet with
+	// the same name gets created, which then keeps the imported addresses. Remove the entry once
+	// the IPSet got created, otherwise the addresses get imported again after it got deleted.
+	ImportedReservations []ImportedReservation `json:"importedReservations,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// BGPAnnouncement, if set, the addresses reserved for IPSets with the AnnotationBGPAnnounce
+	// annotation, e.g. VIPs, get announced to the BGP neighbors via an FRRConfiguration of FRR-K8s
+	BGPAnnouncement *BGPAnnouncement `json:"bgpAnnouncement,omitempty"`
+}
+
+// BGPAnnouncement definition. The namespace of FRR-K8s, the local ASN and the BGP neighbors
+// are operator configuration, see NetConfigDefaults, as they configure the BGP fabric.
+type BGPAnnouncement struct {
+	// +kubebuilder:validation:Optional
+	// Neighbors, addresses of the BGP neighbors allowed by the operator the addresses get
+	// announced to, all of them if not set
+	Neighbors []string `json:"neighbors,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// NodeSelector of the nodes announcing the addresses, all nodes running FRR-K8s if not set
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+}
+
+// BGPNeighbor - a BGP neighbor the operator allows to announce addresses to
+type BGPNeighbor struct {
+	// Address of the neighbor
+	Address string `json:"address"`
+
+	// ASN of the neighbor
+	ASN int64 `json:"asn"`
+}
+
+// ImportedReservation definition
+type ImportedReservation struct {
+	// +kubebuilder:validation:Required
+	// IPSet name of the IPSet which will adopt the addresses
+	IPSet string `json:"ipSet"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	// Addresses currently assigned to the host
+	Addresses []ImportedAddress `json:"addresses"`
+}
+
+// ImportedAddress definition
+type ImportedAddress struct {
+	// +kubebuilder:validation:Required
+	// Network name
+	Network NetNameStr `json:"network"`
+
+	// +kubebuilder:validation:Required
+	// Subnet name
+	Subnet NetNameStr `json:"subnet"`
+
+	// +kubebuilder:validation:Required
+	// Address assigned to the host
+	Address string `json:"address"`
 }
 
 // NetConfigStatus defines the observed state of NetConfig
 type NetConfigStatus struct {
+	// Conditions
+	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+
+	// IPOwnerConfigMap - name of the ConfigMap holding the owner of each reserved IP
+	IPOwnerConfigMap string `json:"ipOwnerConfigMap,omitempty"`
+
+	// StaleReservations - Reservations whose IPSet no longer exists, waiting for
+	// the StaleReservationTTL to expire before their addresses get released
+	StaleReservations []string `json:"staleReservations,omitempty"`
+
+	// Subnets - address utilization of the AllocationRanges of each subnet
+	Subnets []SubnetUtilization `json:"subnets,omitempty"`
+
+	// InvalidReservations - reserved addresses not valid for their subnet anymore, e.g. not
+	// in its CIDR, in the format <address> (<network>/<subnet>, IPSet <name>)
+	InvalidReservations []string `json:"invalidReservations,omitempty"`
+
+	// ConflictingImports - ImportedReservations not imported as one of their addresses is
+	// already reserved, in the format <address> (IPSet <imported>, reserved for <name>)
+	ConflictingImports []string `json:"conflictingImports,omitempty"`
+
+	// AnnouncedPrefixes - prefixes of the reserved addresses announced via BGP
+	AnnouncedPrefixes []string `json:"announcedPrefixes,omitempty"`
+}
+
+// SubnetUtilization - address utilization of the AllocationRanges of a subnet.
+// The counts are capped at the maximum int64 value, e.g. for IPv6 subnets.
+type SubnetUtilization struct {
+	// Network name
+	Network NetNameStr `json:"network"`
+	// Subnet name
+	Subnet NetNameStr `json:"subnet"`
+	// Total - addresses in the AllocationRanges which get assigned, not excluded and not ending with 0
+	Total int64 `json:"total"`
+	// Reserved - reserved addresses in the AllocationRanges
+	Reserved int64 `json:"reserved"`
+	// Free - addresses in the AllocationRanges still available
+	Free int64 `json:"free"`
+}
+
+// IPOwner - owner of a reserved IP address
+type IPOwner struct {
+	// IPSet the IP was reserved for
+	IPSet string `json:"ipSet"`
+	// Network name
+	Network NetNameStr `json:"network"`
+	// Subnet name
+	Subnet NetNameStr `json:"subnet"`
+	// Ordinal of the StatefulSet pod the IP was reserved for, if the IPSet sets Ordinals
+	Ordinal *int `json:"ordinal,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -145,6 +327,35 @@ func init() {
 	SchemeBuilder.Register(&NetConfig{}, &NetConfigList{})
 }
 
+// IPOwnerConfigMapName returns the name of the ConfigMap holding the owner of each reserved IP
+func (instance NetConfig) IPOwnerConfigMapName() string {
+	return instance.Name + "-ip-owners"
+}
+
+// DNSDataName returns the name of the DNSData holding the records of the IPSets
+func (instance NetConfig) DNSDataName() string {
+	return instance.Name + "-ipsets"
+}
+
+// FRRConfigurationName returns the name of the FRRConfiguration announcing the
+// addresses of the IPSets. It is created in the namespace of FRR-K8s, shared by
+// the NetConfigs of all namespaces, so the name is a hash of namespace and name.
+func (instance NetConfig) FRRConfigurationName() string {
+	hash := sha256.Sum256([]byte(instance.Namespace + "/" + instance.Name))
+	return "netconfig-" + hex.EncodeToString(hash[:])[:16]
+}
+
+// DefaultMTU - MTU of a network without MTU
+const DefaultMTU = 1500
+
+// GetMTU returns the MTU of the network, DefaultMTU if not set
+func (n Network) GetMTU() int {
+	if n.MTU == 0 {
+		return DefaultMTU
+	}
+	return n.MTU
+}
+
 // GetNet returns the network with name
 func (instance NetConfig) GetNet(name NetNameStr) (*Network, error) {
 	for _, net := range instance.Spec.Networks {
@@ -20,8 +20,10 @@ import (
 	"bytes"
 	"fmt"
 	"net"
+	"net/netip"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"golang.org/x/exp/slices"
 	"k8s.io/apimachinery/pkg/api/equality"
@@ -34,9 +36,88 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
 
+// ipv6MinMTU - minimum link MTU required by IPv6, RFC 8200
+const ipv6MinMTU = 1280
+
 // log is for logging in this package.
 var netconfiglog = logf.Log.WithName("netconfig-resource")
 
+// NetConfigDefaults - operator configuration of the NetConfigs
+type NetConfigDefaults struct {
+	// FRRK8sNamespace - namespace of FRR-K8s the FRRConfigurations get created in, FRR_K8S_NAMESPACE
+	FRRK8sNamespace string
+	// BGPASN - local ASN of the BGP announcements, BGP_ASN, 0 disables them
+	BGPASN int64
+	// BGPNeighbors - BGP neighbors the NetConfigs can announce addresses to, BGP_NEIGHBORS in
+	// the format <address>:<asn>[,<address>:<asn>...], e.g. 172.30.0.1:64998,[fd00:30::1]:64998
+	BGPNeighbors []BGPNeighbor
+}
+
+var netConfigDefaults NetConfigDefaults
+
+// SetupNetConfigDefaults - initialize the NetConfig operator configuration
+func SetupNetConfigDefaults(defaults NetConfigDefaults) {
+	netConfigDefaults = defaults
+	netconfiglog.Info("NetConfig defaults initialized", "defaults", defaults)
+}
+
+// GetNetConfigDefaults - returns the NetConfig operator configuration
+func GetNetConfigDefaults() NetConfigDefaults {
+	return netConfigDefaults
+}
+
+// BGPEnabled - returns if the operator is configured to announce addresses via BGP
+func (d NetConfigDefaults) BGPEnabled() bool {
+	return d.BGPASN > 0 && len(d.BGPNeighbors) > 0
+}
+
+// ParseBGPNeighbors - parses the BGP neighbors in the format
+// <address>:<asn>[,<address>:<asn>...], IPv6 addresses in brackets
+func ParseBGPNeighbors(neighbors string) ([]BGPNeighbor, error) {
+	parsed := []BGPNeighbor{}
+	for _, neighbor := range strings.Split(neighbors, ",") {
+		neighbor = strings.TrimSpace(neighbor)
+		if neighbor == "" {
+			continue
+		}
+		host, port, err := net.SplitHostPort(neighbor)
+		if err != nil {
+			return nil, fmt.Errorf("BGP neighbor %s not in format <address>:<asn>: %w", neighbor, err)
+		}
+		addr, err := netip.ParseAddr(host)
+		if err != nil {
+			return nil, fmt.Errorf("BGP neighbor %s: %w", neighbor, err)
+		}
+		asn, err := strconv.ParseInt(port, 10, 64)
+		if err != nil || asn < 1 || asn > 4294967295 {
+			return nil, fmt.Errorf("BGP neighbor %s: invalid ASN %s", neighbor, port)
+		}
+		parsed = append(parsed, BGPNeighbor{Address: addr.Unmap().String(), ASN: asn})
+	}
+
+	return parsed, nil
+}
+
+// AllowedBGPNeighbors - returns the BGP neighbors allowed by the operator the
+// addresses of the BGPAnnouncement get announced to
+func (d NetConfigDefaults) AllowedBGPNeighbors(bgp *BGPAnnouncement) []BGPNeighbor {
+	if len(bgp.Neighbors) == 0 {
+		return d.BGPNeighbors
+	}
+
+	neighbors := []BGPNeighbor{}
+	for _, neighbor := range d.BGPNeighbors {
+		if slices.ContainsFunc(bgp.Neighbors, func(addr string) bool {
+			parsed, err := netip.ParseAddr(addr)
+			return err == nil && parsed.Unmap().String() == neighbor.Address
+		}) {
+			neighbors = append(neighbors, neighbor)
+		}
+	}
+
+	return neighbors
+}
+
 // SetupWebhookWithManager -
 func (r *NetConfig) SetupWebhookWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewWebhookManagedBy(mgr).
@@ -77,7 +158,10 @@ func (r *NetConfig) ValidateCreate() error {
 	basePath := field.NewPath("spec")
 
 	// common network validation
-	allErrs = append(allErrs, valiateNetworks(r.Spec.Networks, basePath)...)
+	allErrs = append(allErrs, valiateNetworks(r.Spec.Networks, nil, basePath)...)
+	allErrs = append(allErrs, valiateNetworksMTU(r.Spec.Networks, nil, basePath)...)
+	allErrs = append(allErrs, valiateImportedReservations(r, basePath)...)
+	allErrs = append(allErrs, valiateBGPAnnouncement(r.Spec.BGPAnnouncement, basePath, netConfigDefaults)...)
 
 	if len(allErrs) == 0 {
 		return nil
@@ -99,16 +183,29 @@ func (r *NetConfig) ValidateUpdate(old runtime.Object) error {
 	basePath := field.NewPath("spec")
 
 	// common network validation
-	allErrs = append(allErrs, valiateNetworks(r.Spec.Networks, basePath)...)
+	allErrs = append(allErrs, valiateNetworks(r.Spec.Networks, oldNetConfig.Spec.Networks, basePath)...)
+	allErrs = append(allErrs, valiateNetworksMTU(r.Spec.Networks, oldNetConfig.Spec.Networks, basePath)...)
+	allErrs = append(allErrs, valiateImportedReservations(r, basePath)...)
+	allErrs = append(allErrs, valiateBGPAnnouncement(r.Spec.BGPAnnouncement, basePath, netConfigDefaults)...)
+
+	// changes of networks and subnets in use can be allowed for intentional migrations
+	if r.Annotations[AnnotationAllowSubnetChanges] != "true" {
+		// validate against the previous object only _if_ there are IPSets in the namespace.
+		// If there are none, the NetConfig could be updated to the needs without checking old <-> new.
+		ipsets, err := getIPSets(webhookClient, r)
+		if err != nil {
+			return err
+		}
+		if len(ipsets.Items) > 0 {
+			allErrs = append(allErrs, valiateNetworksChanged(r.Spec.Networks, oldNetConfig.Spec.Networks, basePath)...)
+		}
 
-	// validate against the previous object only _if_ there are IPSets in the namespace.
-	// If there are none, the NetConfig could be updated to the needs without checking old <-> new.
-	ipsets, err := getIPSets(webhookClient, r)
-	if err != nil {
-		return err
-	}
-	if len(ipsets.Items) > 0 {
-		allErrs = append(allErrs, valiateNetworksChanged(r.Spec.Networks, oldNetConfig.Spec.Networks, basePath)...)
+		// Reservations can exist without IPSet, e.g. imported or stale ones
+		reservations, err := getReservations(webhookClient, r)
+		if err != nil {
+			return err
+		}
+		allErrs = append(allErrs, valiateSubnetsInUse(r.Spec.Networks, oldNetConfig.Spec.Networks, reservations, basePath)...)
 	}
 
 	if len(allErrs) == 0 {
@@ -137,14 +234,20 @@ func (r *NetConfig) ValidateDelete() error {
 // - networks have uniq names
 // - subnets within a network have uniq names
 // - common subnet validation
-// - validate uniq CIDRs on all subnets. While it would be possible to have same CIDR on different VLANs, we exlude this config
+// - validate the CIDRs of all subnets do not overlap. While it would be possible to have same CIDR on different VLANs, we exlude this config
+// - a vlan is only used by the subnets of a single network
+// On update overlaps already present in oldNetworks are not reported, so
+// existing NetConfigs can still be changed.
 func valiateNetworks(
 	networks []Network,
+	oldNetworks []Network,
 	path *field.Path,
 ) field.ErrorList {
 	allErrs := field.ErrorList{}
 	netNames := map[string]field.Path{}
-	netCIDR := map[string]field.Path{}
+	netCIDR := map[netip.Prefix]field.Path{}
+	netVlans := map[int]networkVlan{}
+	oldOverlaps := getNetworkOverlaps(oldNetworks)
 
 	for netIdx, _net := range networks {
 		path := path.Child("networks").Index(netIdx)
@@ -166,9 +269,104 @@ func valiateNetworks(
 			path := path.Index(subnetIdx)
 
 			// subnet validation
-			if err := valiateSubnet(_subnet, subnetNames, netCIDR, path); err != nil {
+			if err := valiateSubnet(_subnet, subnetNames, netCIDR, oldOverlaps, path); err != nil {
 				allErrs = append(allErrs, err...)
 			}
+
+			// validate the vlan is not used by another network
+			allErrs = append(allErrs, valiateVlan(netVlans, _net.Name, _subnet.Vlan, path)...)
+		}
+	}
+
+	return allErrs
+}
+
+// valiateNetworksMTU, on create or when the MTU of the network changes, so
+// existing NetConfigs with an out of bounds MTU can still be updated
+// - the MTU is between 68 and 65535
+// - the MTU of a network with IPv6 subnets is at least 1280, also checked for new IPv6 subnets
+func valiateNetworksMTU(
+	networks []Network,
+	oldNetworks []Network,
+	path *field.Path,
+) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	for netIdx, _net := range networks {
+		mtuPath := path.Child("networks").Index(netIdx).Child("mtu")
+		mtu := _net.GetMTU()
+
+		oldNetIdx := slices.IndexFunc(oldNetworks, func(n Network) bool { return n.Name == _net.Name })
+		mtuChanged := oldNetIdx < 0 || oldNetworks[oldNetIdx].GetMTU() != mtu
+		if mtuChanged && (mtu < 68 || mtu > 65535) {
+			allErrs = append(allErrs, field.Invalid(mtuPath, _net.MTU, errMTURange))
+			continue
+		}
+
+		for _, _subnet := range _net.Subnets {
+			prefix, err := netip.ParsePrefix(_subnet.Cidr)
+			if err != nil || !prefix.Addr().Is6() || mtu >= ipv6MinMTU {
+				continue
+			}
+			newSubnet := oldNetIdx < 0 || !slices.ContainsFunc(oldNetworks[oldNetIdx].Subnets, func(s Subnet) bool {
+				return s.Name == _subnet.Name && s.Cidr == _subnet.Cidr
+			})
+			if mtuChanged || newSubnet {
+				allErrs = append(allErrs, field.Invalid(mtuPath, _net.MTU, fmt.Sprintf(errIPv6MTU, ipv6MinMTU, _subnet.Name)))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// valiateImportedReservations
+// - IPSet names are uniq
+// - networks and subnets exist
+// - addresses are in the subnet cidr and uniq
+func valiateImportedReservations(
+	netcfg *NetConfig,
+	path *field.Path,
+) field.ErrorList {
+	allErrs := field.ErrorList{}
+	ipsetNames := map[string]field.Path{}
+	addresses := map[string]field.Path{}
+
+	for resIdx, res := range netcfg.Spec.ImportedReservations {
+		path := path.Child("importedReservations").Index(resIdx)
+
+		// validate uniqe IPSet names
+		allErrs = append(allErrs, valiateUniqElement(ipsetNames, res.IPSet, path, "ipSet", errDupeImportedIPSet)...)
+
+		for addrIdx, addr := range res.Addresses {
+			path := path.Child("addresses").Index(addrIdx)
+
+			if _, err := netcfg.GetNet(addr.Network); err != nil {
+				allErrs = append(allErrs, field.Invalid(path.Child("network"), addr.Network, fmt.Sprintf(errNetworkNotFound, addr.Network)))
+				continue
+			}
+			_, subnet, err := netcfg.GetNetAndSubnet(addr.Network, addr.Subnet)
+			if err != nil {
+				allErrs = append(allErrs, field.Invalid(path.Child("subnet"), addr.Subnet, fmt.Sprintf(errSubnetNotInNetwork, addr.Subnet, addr.Network)))
+				continue
+			}
+
+			_, ipPrefix, err := net.ParseCIDR(subnet.Cidr)
+			if err != nil {
+				// already reported by the subnet validation
+				continue
+			}
+			if err := valiateAddress(addr.Address, ipPrefix, path.Child("address")); len(err) > 0 {
+				allErrs = append(allErrs, err...)
+				continue
+			}
+
+			// validate the address is imported only once, independent of its textual form
+			ip, err := netip.ParseAddr(addr.Address)
+			if err != nil {
+				continue
+			}
+			allErrs = append(allErrs, valiateUniqElement(addresses, ip.Unmap().String(), path, "address", errDupeImportedAddress)...)
 		}
 	}
 
@@ -245,6 +443,149 @@ func valiateNetworksChanged(
 	return allErrs
 }
 
+// valiateSubnetsInUse - validates that subnets with reserved addresses
+// - are not removed
+// - cidr did not change
+// - allocationRanges still contain the reserved addresses they contained before
+func valiateSubnetsInUse(
+	networks []Network,
+	oldNetworks []Network,
+	reservations *ReservationList,
+	path *field.Path,
+) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	// reserved addresses per network and subnet
+	reserved := map[[2]string][]netip.Addr{}
+	for _, res := range reservations.Items {
+		for _, ip := range res.Spec.Reservation {
+			addr, err := netip.ParseAddr(ip.Address)
+			if err != nil {
+				continue
+			}
+			key := [2]string{strings.ToLower(string(ip.Network)), strings.ToLower(string(ip.Subnet))}
+			reserved[key] = append(reserved[key], addr.Unmap())
+		}
+	}
+
+	for oldNetIdx, _net := range oldNetworks {
+		path := path.Child("networks").Index(oldNetIdx).Child("subnets")
+
+		for oldSubnetIdx, _subnet := range _net.Subnets {
+			path := path.Index(oldSubnetIdx)
+
+			addrs := reserved[[2]string{strings.ToLower(string(_net.Name)), strings.ToLower(string(_subnet.Name))}]
+			if len(addrs) == 0 {
+				continue
+			}
+
+			subnet := findSubnet(networks, _net.Name, _subnet.Name)
+			if subnet == nil {
+				allErrs = append(allErrs, field.Invalid(path.Child("name"), _subnet.Name,
+					fmt.Sprintf(errSubnetInUse, _net.Name, _subnet.Name, len(addrs), AnnotationAllowSubnetChanges)))
+				continue
+			}
+			if subnet.Cidr != _subnet.Cidr {
+				allErrs = append(allErrs, field.Invalid(path.Child("cidr"), subnet.Cidr,
+					fmt.Sprintf(errSubnetInUse, _net.Name, _subnet.Name, len(addrs), AnnotationAllowSubnetChanges)))
+				continue
+			}
+
+			// addresses outside of the allocation ranges, e.g. a FixedIP, are not affected
+			for _, addr := range addrs {
+				if inAllocationRanges(addr, _subnet.AllocationRanges) && !inAllocationRanges(addr, subnet.AllocationRanges) {
+					allErrs = append(allErrs, field.Invalid(path.Child("allocationRanges"), addr.String(),
+						fmt.Sprintf(errReservedNotInRange, addr.String(), _net.Name, _subnet.Name, AnnotationAllowSubnetChanges)))
+				}
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// valiateBGPAnnouncement
+// - the operator is configured to announce addresses via BGP
+// - neighbor addresses are valid IP addresses
+// - neighbors are uniq
+// - neighbors are allowed by the operator
+func valiateBGPAnnouncement(
+	bgp *BGPAnnouncement,
+	path *field.Path,
+	defaults NetConfigDefaults,
+) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if bgp == nil {
+		return allErrs
+	}
+	path = path.Child("bgpAnnouncement")
+
+	if !defaults.BGPEnabled() {
+		return append(allErrs, field.Forbidden(path, errBGPNotConfigured))
+	}
+
+	neighbors := map[string]field.Path{}
+	for idx, neighbor := range bgp.Neighbors {
+		path := path.Child("neighbors").Index(idx)
+
+		addr, err := netip.ParseAddr(neighbor)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(path, neighbor, errNotIPAddr))
+			continue
+		}
+		addrStr := addr.Unmap().String()
+		if existPath, ok := neighbors[addrStr]; ok {
+			allErrs = append(allErrs, field.Invalid(path, neighbor, fmt.Sprintf(errDupeBGPNeighbor, addrStr, existPath.String())))
+			continue
+		}
+		neighbors[addrStr] = *path
+		if !slices.ContainsFunc(defaults.BGPNeighbors, func(n BGPNeighbor) bool { return n.Address == addrStr }) {
+			allErrs = append(allErrs, field.NotSupported(path, neighbor, bgpNeighborAddresses(defaults.BGPNeighbors)))
+		}
+	}
+
+	return allErrs
+}
+
+// bgpNeighborAddresses - returns the addresses of the BGP neighbors
+func bgpNeighborAddresses(neighbors []BGPNeighbor) []string {
+	addresses := []string{}
+	for _, neighbor := range neighbors {
+		addresses = append(addresses, neighbor.Address)
+	}
+	return addresses
+}
+
+// findSubnet - returns the subnet of the network, nil if it does not exist
+func findSubnet(networks []Network, netName NetNameStr, subnetName NetNameStr) *Subnet {
+	for _, _net := range networks {
+		if !strings.EqualFold(string(_net.Name), string(netName)) {
+			continue
+		}
+		for idx := range _net.Subnets {
+			if strings.EqualFold(string(_net.Subnets[idx].Name), string(subnetName)) {
+				return &_net.Subnets[idx]
+			}
+		}
+	}
+	return nil
+}
+
+// inAllocationRanges - returns if the address is within one of the allocation ranges
+func inAllocationRanges(addr netip.Addr, allocRanges []AllocationRange) bool {
+	for _, allocRange := range allocRanges {
+		start, startErr := netip.ParseAddr(allocRange.Start)
+		end, endErr := netip.ParseAddr(allocRange.End)
+		if startErr != nil || endErr != nil {
+			continue
+		}
+		if start.Unmap().Compare(addr) <= 0 && addr.Compare(end.Unmap()) <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func valiateUniqElement(
 	elements map[string]field.Path,
 	name string,
@@ -263,6 +604,36 @@ func valiateUniqElement(
 	return allErrs
 }
 
+// networkVlan - network a vlan is used by and where
+type networkVlan struct {
+	network NetNameStr
+	path    field.Path
+}
+
+// valiateVlan - validates that the vlan of the subnet is not used by a subnet of
+// another network. Subnets of the same network can share it, e.g. dual-stack.
+func valiateVlan(
+	netVlans map[int]networkVlan,
+	netName NetNameStr,
+	vlan *int,
+	path *field.Path,
+) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if vlan == nil {
+		return allErrs
+	}
+
+	if exist, ok := netVlans[*vlan]; !ok {
+		netVlans[*vlan] = networkVlan{network: netName, path: *path.Child("vlan")}
+	} else if !strings.EqualFold(string(exist.network), string(netName)) {
+		allErrs = append(allErrs, field.Invalid(path.Child("vlan"), *vlan,
+			fmt.Sprintf(errDupeVlan, *vlan, exist.network, exist.path.String())))
+	}
+
+	return allErrs
+}
+
 // CIDRs must be uniq, while its possible to have same CIDR on different VLANs, we exlude this config
 func valiateUniqCIDR(
 	netCIDRs map[int]map[string]field.Path,
@@ -293,14 +664,146 @@ func valiateUniqCIDR(
 	return allErrs
 }
 
+// networkOverlaps - the overlapping CIDRs and allocation ranges of networks
+type networkOverlaps struct {
+	cidrs  map[[2]netip.Prefix]bool
+	ranges map[[2][2]netip.Addr]bool
+}
+
+// hasCIDRs - returns true if the two CIDRs overlap in the networks
+func (o networkOverlaps) hasCIDRs(a netip.Prefix, b netip.Prefix) bool {
+	return o.cidrs[[2]netip.Prefix{a, b}] || o.cidrs[[2]netip.Prefix{b, a}]
+}
+
+// hasRanges - returns true if the two allocation ranges overlap in the networks
+func (o networkOverlaps) hasRanges(a [2]netip.Addr, b [2]netip.Addr) bool {
+	return o.ranges[[2][2]netip.Addr{a, b}] || o.ranges[[2][2]netip.Addr{b, a}]
+}
+
+// getNetworkOverlaps - returns the overlapping CIDRs of all subnets and the
+// overlapping allocation ranges within each subnet of the networks
+func getNetworkOverlaps(networks []Network) networkOverlaps {
+	overlaps := networkOverlaps{
+		cidrs:  map[[2]netip.Prefix]bool{},
+		ranges: map[[2][2]netip.Addr]bool{},
+	}
+
+	prefixes := []netip.Prefix{}
+	for _, _net := range networks {
+		for _, _subnet := range _net.Subnets {
+			_, ipPrefix, err := net.ParseCIDR(_subnet.Cidr)
+			if err != nil {
+				continue
+			}
+			prefix, err := netip.ParsePrefix(ipPrefix.String())
+			if err != nil {
+				continue
+			}
+			for _, existPrefix := range prefixes {
+				if existPrefix.Overlaps(prefix) {
+					overlaps.cidrs[[2]netip.Prefix{existPrefix, prefix}] = true
+				}
+			}
+			prefixes = append(prefixes, prefix)
+
+			ranges := [][2]netip.Addr{}
+			for _, allocRange := range _subnet.AllocationRanges {
+				start, startErr := netip.ParseAddr(allocRange.Start)
+				end, endErr := netip.ParseAddr(allocRange.End)
+				if startErr != nil || endErr != nil {
+					continue
+				}
+				r := [2]netip.Addr{start.Unmap(), end.Unmap()}
+				for _, existRange := range ranges {
+					if r[0].Compare(existRange[1]) <= 0 && existRange[0].Compare(r[1]) <= 0 {
+						overlaps.ranges[[2][2]netip.Addr{existRange, r}] = true
+					}
+				}
+				ranges = append(ranges, r)
+			}
+		}
+	}
+
+	return overlaps
+}
+
+// valiateCIDROverlap - validates that the CIDR does not overlap with any of the
+// CIDRs already validated, unless the overlap already exists in oldOverlaps
+func valiateCIDROverlap(
+	netCIDRs map[netip.Prefix]field.Path,
+	ipPrefix *net.IPNet,
+	oldOverlaps networkOverlaps,
+	path *field.Path,
+) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	prefix, err := netip.ParsePrefix(ipPrefix.String())
+	if err != nil {
+		allErrs = append(allErrs, field.Invalid(path.Child("cidr"), ipPrefix.String(), errInvalidCidr))
+		return allErrs
+	}
+
+	for existPrefix, existPath := range netCIDRs {
+		if oldOverlaps.hasCIDRs(existPrefix, prefix) {
+			continue
+		}
+		if existPrefix == prefix {
+			allErrs = append(allErrs, field.Invalid(path.Child("cidr"), prefix.String(), fmt.Sprintf(errDupeCIDR, prefix.String(), existPath.String())))
+		} else if existPrefix.Overlaps(prefix) {
+			allErrs = append(allErrs, field.Invalid(path.Child("cidr"), prefix.String(), fmt.Sprintf(errOverlappingCIDR, existPrefix.String(), existPath.String())))
+		}
+	}
+	netCIDRs[prefix] = *path.Child("cidr")
+
+	return allErrs
+}
+
+// valiateAllocationRangeOverlap - validates that the allocation range does not
+// overlap with any of the allocation ranges of the subnet already validated,
+// unless the overlap already exists in oldOverlaps
+func valiateAllocationRangeOverlap(
+	allocRanges map[[2]netip.Addr]field.Path,
+	allocRange AllocationRange,
+	oldOverlaps networkOverlaps,
+	path *field.Path,
+) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	start, startErr := netip.ParseAddr(allocRange.Start)
+	end, endErr := netip.ParseAddr(allocRange.End)
+	if startErr != nil || endErr != nil {
+		// already reported by valiateAllocationRange
+		return allErrs
+	}
+	start = start.Unmap()
+	end = end.Unmap()
+
+	for existRange, existPath := range allocRanges {
+		if oldOverlaps.hasRanges(existRange, [2]netip.Addr{start, end}) {
+			continue
+		}
+		if start.Compare(existRange[1]) <= 0 && existRange[0].Compare(end) <= 0 {
+			allErrs = append(allErrs, field.Invalid(path, allocRange, fmt.Sprintf(errOverlappingRange, existRange[0].String()+"-"+existRange[1].String(), existPath.String())))
+		}
+	}
+	allocRanges[[2]netip.Addr{start, end}] = *path
+
+	return allErrs
+}
+
 // valiateSubnet
 // - CIDR is correct
+// - CIDR does not overlap with the CIDR of another subnet
+// - allocationRanges are in the CIDR and do not overlap
+// - excludeRanges are in the CIDR
+// - routes match the IP family, their destinations are uniq and the nexthop is in the CIDR
 // - gateway is correct
 // - common subnet validation
 func valiateSubnet(
 	subnet Subnet,
 	subnetNames map[string]field.Path,
-	netCIDR map[string]field.Path,
+	netCIDR map[netip.Prefix]field.Path,
+	oldOverlaps networkOverlaps,
 	path *field.Path,
 ) field.ErrorList {
 	allErrs := field.ErrorList{}
@@ -313,15 +816,15 @@ func valiateSubnet(
 	allErrs = append(allErrs, valiateUniqElement(subnetNames, string(subnet.Name), path, "name", errDupeNetworkName)...)
 
 	// validate CIDR
-	// validate uniq CIDRs on all subnets. While it would be possible to have same CIDR on different VLANs, we exlude this config
-	allErrs = append(allErrs, valiateUniqElement(netCIDR, subnet.Cidr, path, "name", errDupeCIDR)...)
-
 	_, ipPrefix, ipPrefixErr := net.ParseCIDR(cidr)
 	if ipPrefixErr != nil {
 		allErrs = append(allErrs, field.Invalid(path.Child("cidr"), cidr, errInvalidCidr))
 		return allErrs
 	}
 
+	// validate the CIDR does not overlap with the CIDR of any other subnet. While it would be possible to have same CIDR on different VLANs, we exlude this config
+	allErrs = append(allErrs, valiateCIDROverlap(netCIDR, ipPrefix, oldOverlaps, path)...)
+
 	// validate gateway
 	if gateway != nil {
 		path := path.Child("gateway")
@@ -331,12 +834,17 @@ func valiateSubnet(
 	}
 
 	// validate allocationRanges
+	allocRanges := map[[2]netip.Addr]field.Path{}
 	for idx, allocRange := range subnet.AllocationRanges {
 		path := path.Child("allocationRanges").Index(idx)
 
-		if err := valiateAllocationRange(allocRange, ipPrefix, path); err != nil {
+		if err := valiateAllocationRange(allocRange, ipPrefix, path); len(err) > 0 {
 			allErrs = append(allErrs, err...)
+			continue
 		}
+
+		// validate the allocationRange does not overlap with another one of the subnet
+		allErrs = append(allErrs, valiateAllocationRangeOverlap(allocRanges, allocRange, oldOverlaps, path)...)
 	}
 
 	// validate excludeAddresses
@@ -348,16 +856,31 @@ func valiateSubnet(
 		}
 	}
 
+	// validate excludeRanges
+	for idx, exclRange := range subnet.ExcludeRanges {
+		path := path.Child("excludeRanges").Index(idx)
+
+		if err := valiateAllocationRange(exclRange, ipPrefix, path); err != nil {
+			allErrs = append(allErrs, err...)
+		}
+	}
+
 	// validate routes
+	destinations := map[string]field.Path{}
 	for idx, route := range subnet.Routes {
 		path := path.Child("routes").Index(idx)
 
 		// validate destination
-		_, _, ipPrefixErr := net.ParseCIDR(route.Destination)
+		_, destPrefix, ipPrefixErr := net.ParseCIDR(route.Destination)
 		if ipPrefixErr != nil {
 			allErrs = append(allErrs, field.Invalid(path.Child("destination"), route.Destination, errInvalidCidr))
-			return allErrs
+			continue
+		}
+		if k8snet.IsIPv4CIDR(destPrefix) != k8snet.IsIPv4CIDR(ipPrefix) {
+			allErrs = append(allErrs, field.Invalid(path.Child("destination"), route.Destination, errMixedAddressFamily))
 		}
+		// validate destination is uniq within the subnet
+		allErrs = append(allErrs, valiateUniqElement(destinations, destPrefix.String(), path, "destination", errDupeRoute)...)
 
 		// validate nexthop
 		pathNexthop := path.Child("nexthop")
@@ -384,6 +384,36 @@ func subnet1BadRouteDestination(ipv4 bool) Subnet {
 	return *subnet
 }
 
+// subnet1 with route destination of the wrong IP version
+func subnet1RouteDestinationWrongIPVersion(ipv4 bool) Subnet {
+	var subnet *Subnet
+
+	if ipv4 {
+		subnet = ipv4Subnet1.DeepCopy()
+		subnet.Routes = append(subnet.Routes, Route{Destination: "fd00:fd00:fd00:2001::/64", Nexthop: "172.17.0.6"})
+	} else {
+		subnet = ipv6Subnet1.DeepCopy()
+		subnet.Routes = append(subnet.Routes, Route{Destination: "172.17.1.0/24", Nexthop: "fd00:fd00:fd00:2000::6"})
+	}
+
+	return *subnet
+}
+
+// subnet1 with a route destination defined twice
+func subnet1DuplicateRouteDestination(ipv4 bool) Subnet {
+	var subnet *Subnet
+
+	if ipv4 {
+		subnet = ipv4Subnet1.DeepCopy()
+		subnet.Routes = append(subnet.Routes, Route{Destination: subnet.Routes[0].Destination, Nexthop: "172.17.0.6"})
+	} else {
+		subnet = ipv6Subnet1.DeepCopy()
+		subnet.Routes = append(subnet.Routes, Route{Destination: subnet.Routes[0].Destination, Nexthop: "fd00:fd00:fd00:2000::6"})
+	}
+
+	return *subnet
+}
+
 // return a default NetConfig with IPv4 networks
 func getDefaultIPv4NetConfigSpec() NetConfigSpec {
 	return NetConfigSpec{
@@ -452,6 +482,26 @@ func getDefaultIPv4IPv6NetConfigSpec() NetConfigSpec {
 	}
 }
 
+// return a default NetConfig with a dual-stack network
+func getDefaultDualStackNetConfigSpec() NetConfigSpec {
+	subnet2 := ipv6Subnet1.DeepCopy()
+	subnet2.Name = "subnet2"
+
+	return NetConfigSpec{
+		Networks: []Network{
+			{
+				Name:      "net1",
+				DNSDomain: "net1.example.com",
+				MTU:       1500,
+				Subnets: []Subnet{
+					ipv4Subnet1,
+					*subnet2,
+				},
+			},
+		},
+	}
+}
+
 func TestNetConfigValidation(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -1052,6 +1102,66 @@ func TestNetConfigValidation(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "[IPv4] should fail when the excludeRange is ouside the CIDR",
+			expectErr: true,
+			c: &NetConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "netcfg",
+					Namespace: "foo",
+				},
+				Spec: NetConfigSpec{
+					Networks: []Network{
+						{
+							Name:      "net1",
+							DNSDomain: "net1.example.com",
+							Subnets: []Subnet{
+								{
+									Name: "subnet1",
+									Cidr: "172.17.0.0/24",
+									ExcludeRanges: []AllocationRange{
+										{
+											Start: "172.17.0.250",
+											End:   "172.17.1.10",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "[IPv6] should fail when the excludeRange start is > end",
+			expectErr: true,
+			c: &NetConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "netcfg",
+					Namespace: "foo",
+				},
+				Spec: NetConfigSpec{
+					Networks: []Network{
+						{
+							Name:      "net1",
+							DNSDomain: "net1.example.com",
+							Subnets: []Subnet{
+								{
+									Name: "subnet1",
+									Cidr: "fd00:fd00:fd00:2000::/64",
+									ExcludeRanges: []AllocationRange{
+										{
+											Start: "fd00:fd00:fd00:2000::20",
+											End:   "fd00:fd00:fd00:2000::10",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			name:      "[IPv4] should fail with bad route nexthop",
 			expectErr: true,
@@ -1228,6 +1338,94 @@ func TestNetConfigValidation(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "[IPv4] should fail with route destination of the wrong IP version",
+			expectErr: true,
+			c: &NetConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "netcfg",
+					Namespace: "foo",
+				},
+				Spec: NetConfigSpec{
+					Networks: []Network{
+						{
+							Name:      "net1",
+							DNSDomain: "net1.example.com",
+							MTU:       1500,
+							Subnets: []Subnet{
+								subnet1RouteDestinationWrongIPVersion(true),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "[IPv6] should fail with route destination of the wrong IP version",
+			expectErr: true,
+			c: &NetConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "netcfg",
+					Namespace: "foo",
+				},
+				Spec: NetConfigSpec{
+					Networks: []Network{
+						{
+							Name:      "net1",
+							DNSDomain: "net1.example.com",
+							MTU:       1500,
+							Subnets: []Subnet{
+								subnet1RouteDestinationWrongIPVersion(false),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "[IPv4] should fail with duplicate route destination",
+			expectErr: true,
+			c: &NetConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "netcfg",
+					Namespace: "foo",
+				},
+				Spec: NetConfigSpec{
+					Networks: []Network{
+						{
+							Name:      "net1",
+							DNSDomain: "net1.example.com",
+							MTU:       1500,
+							Subnets: []Subnet{
+								subnet1DuplicateRouteDestination(true),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "[IPv6] should fail with duplicate route destination",
+			expectErr: true,
+			c: &NetConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "netcfg",
+					Namespace: "foo",
+				},
+				Spec: NetConfigSpec{
+					Networks: []Network{
+						{
+							Name:      "net1",
+							DNSDomain: "net1.example.com",
+							MTU:       1500,
+							Subnets: []Subnet{
+								subnet1DuplicateRouteDestination(false),
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			name:      "should fail with duplicate network names",
 			expectErr: true,
@@ -1411,7 +1609,7 @@ func TestNetConfigValidation(t *testing.T) {
 			},
 		},
 		{
-			name:      "should fail with bad DNSDomain name - start with hyphen",
+			name:      "[IPv4] should fail with overlapping subnet CIDRs on different networks",
 			expectErr: true,
 			c: &NetConfig{
 				ObjectMeta: metav1.ObjectMeta{
@@ -1422,11 +1620,21 @@ func TestNetConfigValidation(t *testing.T) {
 					Networks: []Network{
 						{
 							Name:      "net1",
-							DNSDomain: "-123net1.example.com",
+							DNSDomain: "net1.example.com",
 							Subnets: []Subnet{
 								{
 									Name: "subnet1",
-									Cidr: "172.17.0.0/24",
+									Cidr: "172.17.0.0/16",
+								},
+							},
+						},
+						{
+							Name:      "net2",
+							DNSDomain: "net2.example.com",
+							Subnets: []Subnet{
+								{
+									Name: "subnet1",
+									Cidr: "172.17.1.0/24",
 								},
 							},
 						},
@@ -1435,7 +1643,7 @@ func TestNetConfigValidation(t *testing.T) {
 			},
 		},
 		{
-			name:      "should fail with bad DNSDomain name - end with hyphen",
+			name:      "[IPv4] should fail with duplicate subnet CIDRs in non canonical form",
 			expectErr: true,
 			c: &NetConfig{
 				ObjectMeta: metav1.ObjectMeta{
@@ -1446,7 +1654,7 @@ func TestNetConfigValidation(t *testing.T) {
 					Networks: []Network{
 						{
 							Name:      "net1",
-							DNSDomain: "123net1.example.com-",
+							DNSDomain: "net1.example.com",
 							Subnets: []Subnet{
 								{
 									Name: "subnet1",
@@ -1454,12 +1662,22 @@ func TestNetConfigValidation(t *testing.T) {
 								},
 							},
 						},
+						{
+							Name:      "net2",
+							DNSDomain: "net2.example.com",
+							Subnets: []Subnet{
+								{
+									Name: "subnet1",
+									Cidr: "172.17.0.10/24",
+								},
+							},
+						},
 					},
 				},
 			},
 		},
 		{
-			name:      "should fail with bad DNSDomain name - part too long",
+			name:      "[IPv6] should fail with overlapping subnet CIDRs on different networks",
 			expectErr: true,
 			c: &NetConfig{
 				ObjectMeta: metav1.ObjectMeta{
@@ -1470,11 +1688,21 @@ func TestNetConfigValidation(t *testing.T) {
 					Networks: []Network{
 						{
 							Name:      "net1",
-							DNSDomain: "123456789012345678901234567890123456789012345678901234567890net1.example.com",
+							DNSDomain: "net1.example.com",
 							Subnets: []Subnet{
 								{
 									Name: "subnet1",
-									Cidr: "172.17.0.0/24",
+									Cidr: "fd00:fd00:fd00:2000::/64",
+								},
+							},
+						},
+						{
+							Name:      "net2",
+							DNSDomain: "net2.example.com",
+							Subnets: []Subnet{
+								{
+									Name: "subnet1",
+									Cidr: "fd00:fd00:fd00:2000:0::/80",
 								},
 							},
 						},
@@ -1483,8 +1711,8 @@ func TestNetConfigValidation(t *testing.T) {
 			},
 		},
 		{
-			name:      "should fail with duplicate DNSDomain names in different networks",
-			expectErr: true,
+			name:      "[IPv4] should succeed with adjacent subnet CIDRs",
+			expectErr: false,
 			c: &NetConfig{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "netcfg",
@@ -1504,7 +1732,7 @@ func TestNetConfigValidation(t *testing.T) {
 						},
 						{
 							Name:      "net2",
-							DNSDomain: "net1.example.com",
+							DNSDomain: "net2.example.com",
 							Subnets: []Subnet{
 								{
 									Name: "subnet1",
@@ -1517,7 +1745,7 @@ func TestNetConfigValidation(t *testing.T) {
 			},
 		},
 		{
-			name:      "should fail with duplicate DNSDomain names in different subnets within a network",
+			name:      "[IPv4] should fail with overlapping allocation ranges",
 			expectErr: true,
 			c: &NetConfig{
 				ObjectMeta: metav1.ObjectMeta{
@@ -1531,14 +1759,52 @@ func TestNetConfigValidation(t *testing.T) {
 							DNSDomain: "net1.example.com",
 							Subnets: []Subnet{
 								{
-									Name:      "subnet1",
-									Cidr:      "172.17.0.0/24",
-									DNSDomain: ptr.To("foo.net1.example.com"),
+									Name: "subnet1",
+									Cidr: "172.17.0.0/24",
+									AllocationRanges: []AllocationRange{
+										{
+											Start: "172.17.0.10",
+											End:   "172.17.0.20",
+										},
+										{
+											Start: "172.17.0.20",
+											End:   "172.17.0.30",
+										},
+									},
 								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "[IPv6] should fail with overlapping allocation ranges in abbreviated form",
+			expectErr: true,
+			c: &NetConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "netcfg",
+					Namespace: "foo",
+				},
+				Spec: NetConfigSpec{
+					Networks: []Network{
+						{
+							Name:      "net1",
+							DNSDomain: "net1.example.com",
+							Subnets: []Subnet{
 								{
-									Name:      "subnet2",
-									Cidr:      "172.17.1.0/24",
-									DNSDomain: ptr.To("foo.net1.example.com"),
+									Name: "subnet1",
+									Cidr: "fd00:fd00:fd00:2000::/64",
+									AllocationRanges: []AllocationRange{
+										{
+											Start: "fd00:fd00:fd00:2000::10",
+											End:   "fd00:fd00:fd00:2000::20",
+										},
+										{
+											Start: "fd00:fd00:fd00:2000:0:0:0:0015",
+											End:   "fd00:fd00:fd00:2000::30",
+										},
+									},
 								},
 							},
 						},
@@ -1546,29 +1812,428 @@ func TestNetConfigValidation(t *testing.T) {
 				},
 			},
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			g := NewWithT(t)
-			basePath := field.NewPath("spec")
-
-			if tt.expectErr {
-				g.Expect(len(valiateNetworks(tt.c.Spec.Networks, basePath))).Should(BeNumerically(">", 0))
-			} else {
-				g.Expect(len(valiateNetworks(tt.c.Spec.Networks, basePath))).Should(BeNumerically("==", 0))
-
-			}
-		})
-	}
-}
-
-func TestNetConfigUpdateValidation(t *testing.T) {
-	tests := []struct {
-		name      string
-		expectErr bool
-		newSpec   *NetConfigSpec
-		oldSpec   *NetConfigSpec
+		{
+			name:      "[IPv6] should succeed with non overlapping allocation ranges",
+			expectErr: false,
+			c: &NetConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "netcfg",
+					Namespace: "foo",
+				},
+				Spec: NetConfigSpec{
+					Networks: []Network{
+						{
+							Name:      "net1",
+							DNSDomain: "net1.example.com",
+							MTU:       1500,
+							Subnets: []Subnet{
+								{
+									Name: "subnet1",
+									Cidr: "fd00:fd00:fd00:2000::/64",
+									AllocationRanges: []AllocationRange{
+										{
+											Start: "fd00:fd00:fd00:2000::10",
+											End:   "fd00:fd00:fd00:2000::20",
+										},
+										{
+											Start: "fd00:fd00:fd00:2000::21",
+											End:   "fd00:fd00:fd00:2000::30",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "should fail with bad DNSDomain name - start with hyphen",
+			expectErr: true,
+			c: &NetConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "netcfg",
+					Namespace: "foo",
+				},
+				Spec: NetConfigSpec{
+					Networks: []Network{
+						{
+							Name:      "net1",
+							DNSDomain: "-123net1.example.com",
+							Subnets: []Subnet{
+								{
+									Name: "subnet1",
+									Cidr: "172.17.0.0/24",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "should fail with bad DNSDomain name - end with hyphen",
+			expectErr: true,
+			c: &NetConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "netcfg",
+					Namespace: "foo",
+				},
+				Spec: NetConfigSpec{
+					Networks: []Network{
+						{
+							Name:      "net1",
+							DNSDomain: "123net1.example.com-",
+							Subnets: []Subnet{
+								{
+									Name: "subnet1",
+									Cidr: "172.17.0.0/24",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "should fail with bad DNSDomain name - part too long",
+			expectErr: true,
+			c: &NetConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "netcfg",
+					Namespace: "foo",
+				},
+				Spec: NetConfigSpec{
+					Networks: []Network{
+						{
+							Name:      "net1",
+							DNSDomain: "123456789012345678901234567890123456789012345678901234567890net1.example.com",
+							Subnets: []Subnet{
+								{
+									Name: "subnet1",
+									Cidr: "172.17.0.0/24",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "should fail with duplicate DNSDomain names in different networks",
+			expectErr: true,
+			c: &NetConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "netcfg",
+					Namespace: "foo",
+				},
+				Spec: NetConfigSpec{
+					Networks: []Network{
+						{
+							Name:      "net1",
+							DNSDomain: "net1.example.com",
+							Subnets: []Subnet{
+								{
+									Name: "subnet1",
+									Cidr: "172.17.0.0/24",
+								},
+							},
+						},
+						{
+							Name:      "net2",
+							DNSDomain: "net1.example.com",
+							Subnets: []Subnet{
+								{
+									Name: "subnet1",
+									Cidr: "172.17.1.0/24",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "should fail with duplicate DNSDomain names in different subnets within a network",
+			expectErr: true,
+			c: &NetConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "netcfg",
+					Namespace: "foo",
+				},
+				Spec: NetConfigSpec{
+					Networks: []Network{
+						{
+							Name:      "net1",
+							DNSDomain: "net1.example.com",
+							Subnets: []Subnet{
+								{
+									Name:      "subnet1",
+									Cidr:      "172.17.0.0/24",
+									DNSDomain: ptr.To("foo.net1.example.com"),
+								},
+								{
+									Name:      "subnet2",
+									Cidr:      "172.17.1.0/24",
+									DNSDomain: ptr.To("foo.net1.example.com"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "should fail with the same vlan on different networks",
+			expectErr: true,
+			c: &NetConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "netcfg",
+					Namespace: "foo",
+				},
+				Spec: NetConfigSpec{
+					Networks: []Network{
+						{
+							Name:      "net1",
+							DNSDomain: "net1.example.com",
+							MTU:       1500,
+							Subnets: []Subnet{
+								{
+									Name: "subnet1",
+									Cidr: "172.17.0.0/24",
+									Vlan: ptr.To(20),
+								},
+							},
+						},
+						{
+							Name:      "net2",
+							DNSDomain: "net2.example.com",
+							MTU:       1500,
+							Subnets: []Subnet{
+								{
+									Name: "subnet1",
+									Cidr: "172.18.0.0/24",
+									Vlan: ptr.To(20),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "should succeed with the same vlan on subnets of a network",
+			expectErr: false,
+			c: &NetConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "netcfg",
+					Namespace: "foo",
+				},
+				Spec: NetConfigSpec{
+					Networks: []Network{
+						{
+							Name:      "net1",
+							DNSDomain: "net1.example.com",
+							MTU:       1500,
+							Subnets: []Subnet{
+								{
+									Name: "subnet1",
+									Cidr: "172.17.0.0/24",
+									Vlan: ptr.To(20),
+								},
+								{
+									Name: "subnet2",
+									Cidr: "fd00:fd00:fd00:2000::/64",
+									Vlan: ptr.To(20),
+								},
+							},
+						},
+						{
+							Name:      "net2",
+							DNSDomain: "net2.example.com",
+							MTU:       1500,
+							Subnets: []Subnet{
+								{
+									Name: "subnet1",
+									Cidr: "172.18.0.0/24",
+									Vlan: ptr.To(21),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "[IPv4] should succeed with MTU below the IPv6 minimum",
+			expectErr: false,
+			c: &NetConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "netcfg",
+					Namespace: "foo",
+				},
+				Spec: NetConfigSpec{
+					Networks: []Network{
+						{
+							Name:      "net1",
+							DNSDomain: "net1.example.com",
+							MTU:       1200,
+							Subnets: []Subnet{
+								ipv4Subnet1,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "[IPv6] should fail with MTU below the IPv6 minimum",
+			expectErr: true,
+			c: &NetConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "netcfg",
+					Namespace: "foo",
+				},
+				Spec: NetConfigSpec{
+					Networks: []Network{
+						{
+							Name:      "net1",
+							DNSDomain: "net1.example.com",
+							MTU:       1200,
+							Subnets: []Subnet{
+								ipv6Subnet1,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			basePath := field.NewPath("spec")
+
+			allErrs := valiateNetworks(tt.c.Spec.Networks, nil, basePath)
+			allErrs = append(allErrs, valiateNetworksMTU(tt.c.Spec.Networks, nil, basePath)...)
+			if tt.expectErr {
+				g.Expect(allErrs).ShouldNot(BeEmpty())
+			} else {
+				g.Expect(allErrs).Should(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestNetConfigImportedReservationsValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		expectErr bool
+		imports   []ImportedReservation
+	}{
+		{
+			name:      "should succeed with good values",
+			expectErr: false,
+			imports: []ImportedReservation{
+				{
+					IPSet: "compute-0",
+					Addresses: []ImportedAddress{
+						{Network: "net1", Subnet: "subnet1", Address: "172.17.0.5"},
+						{Network: "net2", Subnet: "subnet3", Address: "172.18.0.5"},
+					},
+				},
+				{
+					IPSet: "compute-1",
+					Addresses: []ImportedAddress{
+						{Network: "net1", Subnet: "subnet1", Address: "172.17.0.6"},
+					},
+				},
+			},
+		},
+		{
+			name:      "should fail with duplicate IPSet names",
+			expectErr: true,
+			imports: []ImportedReservation{
+				{
+					IPSet:     "compute-0",
+					Addresses: []ImportedAddress{{Network: "net1", Subnet: "subnet1", Address: "172.17.0.5"}},
+				},
+				{
+					IPSet:     "compute-0",
+					Addresses: []ImportedAddress{{Network: "net1", Subnet: "subnet1", Address: "172.17.0.6"}},
+				},
+			},
+		},
+		{
+			name:      "should fail with network not in NetConfig",
+			expectErr: true,
+			imports: []ImportedReservation{
+				{
+					IPSet:     "compute-0",
+					Addresses: []ImportedAddress{{Network: "foo", Subnet: "subnet1", Address: "172.17.0.5"}},
+				},
+			},
+		},
+		{
+			name:      "should fail with subnet not in network",
+			expectErr: true,
+			imports: []ImportedReservation{
+				{
+					IPSet:     "compute-0",
+					Addresses: []ImportedAddress{{Network: "net1", Subnet: "foo", Address: "172.17.0.5"}},
+				},
+			},
+		},
+		{
+			name:      "should fail with address outside the subnet CIDR",
+			expectErr: true,
+			imports: []ImportedReservation{
+				{
+					IPSet:     "compute-0",
+					Addresses: []ImportedAddress{{Network: "net1", Subnet: "subnet1", Address: "172.18.0.5"}},
+				},
+			},
+		},
+		{
+			name:      "should fail with address imported twice",
+			expectErr: true,
+			imports: []ImportedReservation{
+				{
+					IPSet:     "compute-0",
+					Addresses: []ImportedAddress{{Network: "net1", Subnet: "subnet1", Address: "172.17.0.5"}},
+				},
+				{
+					IPSet:     "compute-1",
+					Addresses: []ImportedAddress{{Network: "net1", Subnet: "subnet1", Address: "172.17.0.5"}},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			basePath := field.NewPath("spec")
+
+			netcfg := &NetConfig{Spec: getDefaultIPv4NetConfigSpec()}
+			netcfg.Spec.ImportedReservations = tt.imports
+
+			if tt.expectErr {
+				g.Expect(valiateImportedReservations(netcfg, basePath)).ShouldNot(BeEmpty())
+			} else {
+				g.Expect(valiateImportedReservations(netcfg, basePath)).Should(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestNetConfigUpdateValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		expectErr bool
+		newSpec   *NetConfigSpec
+		oldSpec   *NetConfigSpec
 	}{
 		{
 			name:      "should succeed when values and templates correct",
@@ -1790,7 +2455,7 @@ func TestNetConfigUpdateValidation(t *testing.T) {
 
 			var err error
 
-			allErrs := valiateNetworks(tt.newSpec.Networks, basePath)
+			allErrs := valiateNetworks(tt.newSpec.Networks, tt.oldSpec.Networks, basePath)
 			if len(allErrs) > 0 {
 				err = apierrors.NewInvalid(GroupVersion.WithKind("NetConfig").GroupKind(), new.Name, allErrs)
 			}
@@ -1809,3 +2474,376 @@ func TestNetConfigUpdateValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestNetConfigSubnetsInUseValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		expectErr bool
+		reserved  []IPAddress
+		update    func(spec *NetConfigSpec)
+	}{
+		{
+			name:      "should succeed to remove a subnet without reservations",
+			expectErr: false,
+			reserved: []IPAddress{
+				{Network: "net1", Subnet: "subnet2", Address: "172.17.1.25"},
+			},
+			update: func(spec *NetConfigSpec) {
+				spec.Networks[0].Subnets = spec.Networks[0].Subnets[1:]
+			},
+		},
+		{
+			name:      "should fail to remove a subnet with reservations",
+			expectErr: true,
+			reserved: []IPAddress{
+				{Network: "NET1", Subnet: "subnet1", Address: "172.17.0.25"},
+			},
+			update: func(spec *NetConfigSpec) {
+				spec.Networks[0].Subnets = spec.Networks[0].Subnets[1:]
+			},
+		},
+		{
+			name:      "should fail to remove a network with reservations",
+			expectErr: true,
+			reserved: []IPAddress{
+				{Network: "net2", Subnet: "subnet3", Address: "172.18.0.25"},
+			},
+			update: func(spec *NetConfigSpec) {
+				spec.Networks = spec.Networks[:1]
+			},
+		},
+		{
+			name:      "should fail to change the cidr of a subnet with reservations",
+			expectErr: true,
+			reserved: []IPAddress{
+				{Network: "net1", Subnet: "subnet1", Address: "172.17.0.25"},
+			},
+			update: func(spec *NetConfigSpec) {
+				spec.Networks[0].Subnets[0].Cidr = "172.17.0.0/23"
+			},
+		},
+		{
+			name:      "should succeed to extend the allocation range of a reserved address",
+			expectErr: false,
+			reserved: []IPAddress{
+				{Network: "net1", Subnet: "subnet1", Address: "172.17.0.25"},
+			},
+			update: func(spec *NetConfigSpec) {
+				spec.Networks[0].Subnets[0].AllocationRanges[1].End = "172.17.0.40"
+			},
+		},
+		{
+			name:      "should fail to shrink the allocation range of a reserved address",
+			expectErr: true,
+			reserved: []IPAddress{
+				{Network: "net1", Subnet: "subnet1", Address: "172.17.0.25"},
+			},
+			update: func(spec *NetConfigSpec) {
+				spec.Networks[0].Subnets[0].AllocationRanges[1].End = "172.17.0.24"
+			},
+		},
+		{
+			name:      "should succeed to shrink the allocation ranges with a reserved address outside of them",
+			expectErr: false,
+			reserved: []IPAddress{
+				{Network: "net1", Subnet: "subnet1", Address: "172.17.0.200"},
+			},
+			update: func(spec *NetConfigSpec) {
+				spec.Networks[0].Subnets[0].AllocationRanges = spec.Networks[0].Subnets[0].AllocationRanges[:1]
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			basePath := field.NewPath("spec")
+
+			oldSpec := getDefaultIPv4NetConfigSpec()
+			newSpec := getDefaultIPv4NetConfigSpec()
+			for idx := range newSpec.Networks {
+				newSpec.Networks[idx] = *newSpec.Networks[idx].DeepCopy()
+			}
+			tt.update(&newSpec)
+
+			reservations := &ReservationList{}
+			for _, ip := range tt.reserved {
+				reservations.Items = append(reservations.Items, Reservation{
+					Spec: ReservationSpec{
+						Reservation: map[string]IPAddress{string(ip.Network): ip},
+					},
+				})
+			}
+
+			allErrs := valiateSubnetsInUse(newSpec.Networks, oldSpec.Networks, reservations, basePath)
+			if tt.expectErr {
+				g.Expect(allErrs).NotTo(BeEmpty())
+			} else {
+				g.Expect(allErrs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestNetConfigBGPAnnouncementValidation(t *testing.T) {
+	bgpDefaults := NetConfigDefaults{
+		FRRK8sNamespace: "metallb-system",
+		BGPASN:          64999,
+		BGPNeighbors: []BGPNeighbor{
+			{Address: "172.30.0.1", ASN: 64998},
+			{Address: "fd00:30::1", ASN: 64998},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		bgp       *BGPAnnouncement
+		defaults  NetConfigDefaults
+		expectErr bool
+	}{
+		{
+			name:      "should succeed without BGPAnnouncement",
+			expectErr: false,
+		},
+		{
+			name:      "should succeed with all neighbors of the operator",
+			bgp:       &BGPAnnouncement{},
+			defaults:  bgpDefaults,
+			expectErr: false,
+		},
+		{
+			name: "should succeed with IPv4 and IPv6 neighbors of the operator",
+			bgp: &BGPAnnouncement{
+				Neighbors: []string{"172.30.0.1", "fd00:30::1"},
+			},
+			defaults:  bgpDefaults,
+			expectErr: false,
+		},
+		{
+			name:      "should fail without BGP configured in the operator",
+			bgp:       &BGPAnnouncement{},
+			expectErr: true,
+		},
+		{
+			name: "should fail with a neighbor not allowed by the operator",
+			bgp: &BGPAnnouncement{
+				Neighbors: []string{"172.30.0.2"},
+			},
+			defaults:  bgpDefaults,
+			expectErr: true,
+		},
+		{
+			name: "should fail with an invalid neighbor address",
+			bgp: &BGPAnnouncement{
+				Neighbors: []string{"172.30.0"},
+			},
+			defaults:  bgpDefaults,
+			expectErr: true,
+		},
+		{
+			name: "should fail with a duplicate neighbor",
+			bgp: &BGPAnnouncement{
+				Neighbors: []string{"fd00:30::1", "FD00:30:0::1"},
+			},
+			defaults:  bgpDefaults,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			allErrs := valiateBGPAnnouncement(tt.bgp, field.NewPath("spec"), tt.defaults)
+			if tt.expectErr {
+				g.Expect(allErrs).NotTo(BeEmpty())
+			} else {
+				g.Expect(allErrs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestParseBGPNeighbors(t *testing.T) {
+	tests := []struct {
+		name      string
+		neighbors string
+		want      []BGPNeighbor
+		expectErr bool
+	}{
+		{
+			name:      "should succeed without neighbors",
+			neighbors: "",
+			want:      []BGPNeighbor{},
+		},
+		{
+			name:      "should succeed with IPv4 and IPv6 neighbors",
+			neighbors: "172.30.0.1:64998, [fd00:30::1]:64997",
+			want: []BGPNeighbor{
+				{Address: "172.30.0.1", ASN: 64998},
+				{Address: "fd00:30::1", ASN: 64997},
+			},
+		},
+		{
+			name:      "should fail without ASN",
+			neighbors: "172.30.0.1",
+			expectErr: true,
+		},
+		{
+			name:      "should fail with an invalid address",
+			neighbors: "172.30.0:64998",
+			expectErr: true,
+		},
+		{
+			name:      "should fail with an invalid ASN",
+			neighbors: "172.30.0.1:0",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			neighbors, err := ParseBGPNeighbors(tt.neighbors)
+			if tt.expectErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(neighbors).To(Equal(tt.want))
+			}
+		})
+	}
+}
+
+func TestNetConfigMTUUpdateValidation(t *testing.T) {
+	network := func(mtu int, subnets ...Subnet) []Network {
+		return []Network{{Name: "net1", DNSDomain: "net1.example.com", MTU: mtu, Subnets: subnets}}
+	}
+	ipv4Subnet := Subnet{Name: "subnet1", Cidr: "172.17.0.0/24"}
+	ipv6Subnet := Subnet{Name: "subnet2", Cidr: "fd00:fd00:fd00:2000::/64"}
+
+	tests := []struct {
+		name        string
+		networks    []Network
+		oldNetworks []Network
+		expectErr   bool
+	}{
+		{
+			name:      "should succeed without MTU",
+			networks:  network(0, ipv6Subnet),
+			expectErr: false,
+		},
+		{
+			name:      "should fail on create with MTU out of bounds",
+			networks:  network(65536, ipv4Subnet),
+			expectErr: true,
+		},
+		{
+			name:        "should succeed on update with unchanged MTU out of bounds",
+			networks:    network(20, ipv4Subnet, Subnet{Name: "subnet3", Cidr: "172.18.0.0/24"}),
+			oldNetworks: network(20, ipv4Subnet),
+			expectErr:   false,
+		},
+		{
+			name:        "should fail on update when the MTU changes out of bounds",
+			networks:    network(30, ipv4Subnet),
+			oldNetworks: network(20, ipv4Subnet),
+			expectErr:   true,
+		},
+		{
+			name:        "should succeed on update with unchanged MTU below the IPv6 minimum",
+			networks:    network(1200, ipv6Subnet),
+			oldNetworks: network(1200, ipv6Subnet),
+			expectErr:   false,
+		},
+		{
+			name:        "should fail on update adding an IPv6 subnet with MTU below the IPv6 minimum",
+			networks:    network(1200, ipv4Subnet, ipv6Subnet),
+			oldNetworks: network(1200, ipv4Subnet),
+			expectErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			allErrs := valiateNetworksMTU(tt.networks, tt.oldNetworks, field.NewPath("spec"))
+			if tt.expectErr {
+				g.Expect(allErrs).NotTo(BeEmpty())
+			} else {
+				g.Expect(allErrs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestNetConfigOverlapUpdateValidation(t *testing.T) {
+	overlappingRanges := Subnet{
+		Name: "subnet1",
+		Cidr: "172.17.0.0/24",
+		AllocationRanges: []AllocationRange{
+			{Start: "172.17.0.10", End: "172.17.0.20"},
+			{Start: "172.17.0.20", End: "172.17.0.30"},
+		},
+	}
+	overlappingCIDR := Subnet{
+		Name: "subnet2",
+		Cidr: "172.17.0.0/16",
+	}
+
+	tests := []struct {
+		name        string
+		expectErr   bool
+		networks    []Network
+		oldNetworks []Network
+	}{
+		{
+			name:      "should succeed with overlaps already present in the existing NetConfig",
+			expectErr: false,
+			networks: []Network{
+				{Name: "net1", DNSDomain: "net1.example.com", Subnets: []Subnet{overlappingRanges, overlappingCIDR}},
+				{Name: "net2", DNSDomain: "net2.example.com", Subnets: []Subnet{{Name: "subnet1", Cidr: "192.168.0.0/24"}}},
+			},
+			oldNetworks: []Network{
+				{Name: "net1", DNSDomain: "net1.example.com", Subnets: []Subnet{overlappingRanges, overlappingCIDR}},
+			},
+		},
+		{
+			name:      "should fail with overlapping allocation ranges added on update",
+			expectErr: true,
+			networks: []Network{
+				{Name: "net1", DNSDomain: "net1.example.com", Subnets: []Subnet{overlappingRanges}},
+			},
+			oldNetworks: []Network{
+				{Name: "net1", DNSDomain: "net1.example.com", Subnets: []Subnet{
+					{Name: "subnet1", Cidr: "172.17.0.0/24", AllocationRanges: overlappingRanges.AllocationRanges[:1]},
+				}},
+			},
+		},
+		{
+			name:      "should fail with an overlapping CIDR added on update",
+			expectErr: true,
+			networks: []Network{
+				{Name: "net1", DNSDomain: "net1.example.com", Subnets: []Subnet{overlappingRanges, overlappingCIDR}},
+			},
+			oldNetworks: []Network{
+				{Name: "net1", DNSDomain: "net1.example.com", Subnets: []Subnet{overlappingRanges}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			allErrs := valiateNetworks(tt.networks, tt.oldNetworks, field.NewPath("spec"))
+			if tt.expectErr {
+				g.Expect(allErrs).ShouldNot(BeEmpty())
+			} else {
+				g.Expect(allErrs).Should(BeEmpty())
+			}
+		})
+	}
+}
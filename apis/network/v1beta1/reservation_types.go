@@ -31,6 +31,10 @@ type IPAddress struct {
 
 	// Address contains the IP address
 	Address string `json:"address"`
+
+	// +kubebuilder:validation:Optional
+	// Ordinal of the StatefulSet pod the address is for, if the IPSet sets Ordinals
+	Ordinal *int `json:"ordinal,omitempty"`
 }
 
 // ReservationSpec defines the desired state of Reservation
@@ -39,7 +43,8 @@ type ReservationSpec struct {
 	IPSetRef corev1.ObjectReference `json:"ipSetRef"`
 
 	// +kubebuilder:validation:Required
-	// Reservation, map (index network name) with reservation
+	// Reservation, map (index network name, <net>.ipv6 for the IPv6 address of a dual-stack network) with reservation.
+	// The addresses of an IPSet with Ordinals use the key <net>.<ordinal>, or <net>.ipv6.<ordinal>
 	Reservation map[string]IPAddress `json:"reservation"`
 }
 
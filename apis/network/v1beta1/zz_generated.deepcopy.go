@@ -24,6 +24,7 @@ package v1beta1
 import (
 	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/service"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -42,6 +43,68 @@ func (in *AllocationRange) DeepCopy() *AllocationRange {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BGPAnnouncement) DeepCopyInto(out *BGPAnnouncement) {
+	*out = *in
+	if in.Neighbors != nil {
+		in, out := &in.Neighbors, &out.Neighbors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BGPAnnouncement.
+func (in *BGPAnnouncement) DeepCopy() *BGPAnnouncement {
+	if in == nil {
+		return nil
+	}
+	out := new(BGPAnnouncement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BGPNeighbor) DeepCopyInto(out *BGPNeighbor) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BGPNeighbor.
+func (in *BGPNeighbor) DeepCopy() *BGPNeighbor {
+	if in == nil {
+		return nil
+	}
+	out := new(BGPNeighbor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DNSCNAMERecord) DeepCopyInto(out *DNSCNAMERecord) {
+	*out = *in
+	if in.Aliases != nil {
+		in, out := &in.Aliases, &out.Aliases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSCNAMERecord.
+func (in *DNSCNAMERecord) DeepCopy() *DNSCNAMERecord {
+	if in == nil {
+		return nil
+	}
+	out := new(DNSCNAMERecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DNSData) DeepCopyInto(out *DNSData) {
 	*out = *in
@@ -69,6 +132,33 @@ func (in *DNSData) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DNSDataExternalDNS) DeepCopyInto(out *DNSDataExternalDNS) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.RecordTTL != nil {
+		in, out := &in.RecordTTL, &out.RecordTTL
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSDataExternalDNS.
+func (in *DNSDataExternalDNS) DeepCopy() *DNSDataExternalDNS {
+	if in == nil {
+		return nil
+	}
+	out := new(DNSDataExternalDNS)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DNSDataList) DeepCopyInto(out *DNSDataList) {
 	*out = *in
@@ -111,6 +201,30 @@ func (in *DNSDataSpec) DeepCopyInto(out *DNSDataSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.SRVRecords != nil {
+		in, out := &in.SRVRecords, &out.SRVRecords
+		*out = make([]DNSSRVRecord, len(*in))
+		copy(*out, *in)
+	}
+	if in.CNAMERecords != nil {
+		in, out := &in.CNAMERecords, &out.CNAMERecords
+		*out = make([]DNSCNAMERecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Views != nil {
+		in, out := &in.Views, &out.Views
+		*out = make([]DNSDataView, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ExternalDNS != nil {
+		in, out := &in.ExternalDNS, &out.ExternalDNS
+		*out = new(DNSDataExternalDNS)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSDataSpec.
@@ -133,6 +247,11 @@ func (in *DNSDataStatus) DeepCopyInto(out *DNSDataStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.StaleHosts != nil {
+		in, out := &in.StaleHosts, &out.StaleHosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSDataStatus.
@@ -145,6 +264,28 @@ func (in *DNSDataStatus) DeepCopy() *DNSDataStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DNSDataView) DeepCopyInto(out *DNSDataView) {
+	*out = *in
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]DNSHost, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSDataView.
+func (in *DNSDataView) DeepCopy() *DNSDataView {
+	if in == nil {
+		return nil
+	}
+	out := new(DNSDataView)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DNSHost) DeepCopyInto(out *DNSHost) {
 	*out = *in
@@ -153,6 +294,11 @@ func (in *DNSHost) DeepCopyInto(out *DNSHost) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Owner != nil {
+		in, out := &in.Owner, &out.Owner
+		*out = new(DNSHostOwner)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSHost.
@@ -165,6 +311,21 @@ func (in *DNSHost) DeepCopy() *DNSHost {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DNSHostOwner) DeepCopyInto(out *DNSHostOwner) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSHostOwner.
+func (in *DNSHostOwner) DeepCopy() *DNSHostOwner {
+	if in == nil {
+		return nil
+	}
+	out := new(DNSHostOwner)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DNSMasq) DeepCopyInto(out *DNSMasq) {
 	*out = *in
@@ -192,6 +353,154 @@ func (in *DNSMasq) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DNSMasqAddSubnet) DeepCopyInto(out *DNSMasqAddSubnet) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSMasqAddSubnet.
+func (in *DNSMasqAddSubnet) DeepCopy() *DNSMasqAddSubnet {
+	if in == nil {
+		return nil
+	}
+	out := new(DNSMasqAddSubnet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DNSMasqAuthZone) DeepCopyInto(out *DNSMasqAuthZone) {
+	*out = *in
+	if in.Subnets != nil {
+		in, out := &in.Subnets, &out.Subnets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSMasqAuthZone.
+func (in *DNSMasqAuthZone) DeepCopy() *DNSMasqAuthZone {
+	if in == nil {
+		return nil
+	}
+	out := new(DNSMasqAuthZone)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DNSMasqAuthoritative) DeepCopyInto(out *DNSMasqAuthoritative) {
+	*out = *in
+	if in.Zones != nil {
+		in, out := &in.Zones, &out.Zones
+		*out = make([]DNSMasqAuthZone, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.SOA.DeepCopyInto(&out.SOA)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSMasqAuthoritative.
+func (in *DNSMasqAuthoritative) DeepCopy() *DNSMasqAuthoritative {
+	if in == nil {
+		return nil
+	}
+	out := new(DNSMasqAuthoritative)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DNSMasqCache) DeepCopyInto(out *DNSMasqCache) {
+	*out = *in
+	if in.Size != nil {
+		in, out := &in.Size, &out.Size
+		*out = new(int32)
+		**out = **in
+	}
+	if in.NegTTL != nil {
+		in, out := &in.NegTTL, &out.NegTTL
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxCacheTTL != nil {
+		in, out := &in.MaxCacheTTL, &out.MaxCacheTTL
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSMasqCache.
+func (in *DNSMasqCache) DeepCopy() *DNSMasqCache {
+	if in == nil {
+		return nil
+	}
+	out := new(DNSMasqCache)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DNSMasqClientSubnets) DeepCopyInto(out *DNSMasqClientSubnets) {
+	*out = *in
+	if in.AddSubnet != nil {
+		in, out := &in.AddSubnet, &out.AddSubnet
+		*out = new(DNSMasqAddSubnet)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSMasqClientSubnets.
+func (in *DNSMasqClientSubnets) DeepCopy() *DNSMasqClientSubnets {
+	if in == nil {
+		return nil
+	}
+	out := new(DNSMasqClientSubnets)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DNSMasqDNSSEC) DeepCopyInto(out *DNSMasqDNSSEC) {
+	*out = *in
+	if in.TrustAnchors != nil {
+		in, out := &in.TrustAnchors, &out.TrustAnchors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CheckUnsigned != nil {
+		in, out := &in.CheckUnsigned, &out.CheckUnsigned
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSMasqDNSSEC.
+func (in *DNSMasqDNSSEC) DeepCopy() *DNSMasqDNSSEC {
+	if in == nil {
+		return nil
+	}
+	out := new(DNSMasqDNSSEC)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DNSMasqDebug) DeepCopyInto(out *DNSMasqDebug) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSMasqDebug.
+func (in *DNSMasqDebug) DeepCopy() *DNSMasqDebug {
+	if in == nil {
+		return nil
+	}
+	out := new(DNSMasqDebug)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DNSMasqDefaults) DeepCopyInto(out *DNSMasqDefaults) {
 	*out = *in
@@ -207,6 +516,21 @@ func (in *DNSMasqDefaults) DeepCopy() *DNSMasqDefaults {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DNSMasqHealthCheck) DeepCopyInto(out *DNSMasqHealthCheck) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSMasqHealthCheck.
+func (in *DNSMasqHealthCheck) DeepCopy() *DNSMasqHealthCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(DNSMasqHealthCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DNSMasqList) DeepCopyInto(out *DNSMasqList) {
 	*out = *in
@@ -239,6 +563,51 @@ func (in *DNSMasqList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DNSMasqMetrics) DeepCopyInto(out *DNSMasqMetrics) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSMasqMetrics.
+func (in *DNSMasqMetrics) DeepCopy() *DNSMasqMetrics {
+	if in == nil {
+		return nil
+	}
+	out := new(DNSMasqMetrics)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DNSMasqNetworkPolicy) DeepCopyInto(out *DNSMasqNetworkPolicy) {
+	*out = *in
+	if in.CIDRs != nil {
+		in, out := &in.CIDRs, &out.CIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodSelector != nil {
+		in, out := &in.PodSelector, &out.PodSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSMasqNetworkPolicy.
+func (in *DNSMasqNetworkPolicy) DeepCopy() *DNSMasqNetworkPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(DNSMasqNetworkPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DNSMasqOption) DeepCopyInto(out *DNSMasqOption) {
 	*out = *in
@@ -279,6 +648,41 @@ func (in *DNSMasqOverrideSpec) DeepCopy() *DNSMasqOverrideSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DNSMasqSOA) DeepCopyInto(out *DNSMasqSOA) {
+	*out = *in
+	if in.Serial != nil {
+		in, out := &in.Serial, &out.Serial
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Refresh != nil {
+		in, out := &in.Refresh, &out.Refresh
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Retry != nil {
+		in, out := &in.Retry, &out.Retry
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Expiry != nil {
+		in, out := &in.Expiry, &out.Expiry
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSMasqSOA.
+func (in *DNSMasqSOA) DeepCopy() *DNSMasqSOA {
+	if in == nil {
+		return nil
+	}
+	out := new(DNSMasqSOA)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DNSMasqSpec) DeepCopyInto(out *DNSMasqSpec) {
 	*out = *in
@@ -302,6 +706,52 @@ func (in *DNSMasqSpec) DeepCopyInto(out *DNSMasqSpec) {
 		}
 	}
 	in.Override.DeepCopyInto(&out.Override)
+	if in.UpstreamTLS != nil {
+		in, out := &in.UpstreamTLS, &out.UpstreamTLS
+		*out = new(DNSMasqUpstreamTLS)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DNSSEC != nil {
+		in, out := &in.DNSSEC, &out.DNSSEC
+		*out = new(DNSMasqDNSSEC)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = new(DNSMasqMetrics)
+		**out = **in
+	}
+	if in.NetworkAttachments != nil {
+		in, out := &in.NetworkAttachments, &out.NetworkAttachments
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Debug != nil {
+		in, out := &in.Debug, &out.Debug
+		*out = new(DNSMasqDebug)
+		**out = **in
+	}
+	in.Cache.DeepCopyInto(&out.Cache)
+	if in.Authoritative != nil {
+		in, out := &in.Authoritative, &out.Authoritative
+		*out = new(DNSMasqAuthoritative)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClientSubnets != nil {
+		in, out := &in.ClientSubnets, &out.ClientSubnets
+		*out = new(DNSMasqClientSubnets)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NetworkPolicy != nil {
+		in, out := &in.NetworkPolicy, &out.NetworkPolicy
+		*out = new(DNSMasqNetworkPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HealthCheck != nil {
+		in, out := &in.HealthCheck, &out.HealthCheck
+		*out = new(DNSMasqHealthCheck)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSMasqSpec.
@@ -341,6 +791,30 @@ func (in *DNSMasqStatus) DeepCopyInto(out *DNSMasqStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.SkippedResources != nil {
+		in, out := &in.SkippedResources, &out.SkippedResources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NetworkAttachments != nil {
+		in, out := &in.NetworkAttachments, &out.NetworkAttachments
+		*out = make(map[string][]string, len(*in))
+		for key, val := range *in {
+			var outVal []string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.HealthCheckedAt != nil {
+		in, out := &in.HealthCheckedAt, &out.HealthCheckedAt
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSMasqStatus.
@@ -353,9 +827,65 @@ func (in *DNSMasqStatus) DeepCopy() *DNSMasqStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DNSMasqTLSServer) DeepCopyInto(out *DNSMasqTLSServer) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSMasqTLSServer.
+func (in *DNSMasqTLSServer) DeepCopy() *DNSMasqTLSServer {
+	if in == nil {
+		return nil
+	}
+	out := new(DNSMasqTLSServer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DNSMasqUpstreamTLS) DeepCopyInto(out *DNSMasqUpstreamTLS) {
+	*out = *in
+	if in.Servers != nil {
+		in, out := &in.Servers, &out.Servers
+		*out = make([]DNSMasqTLSServer, len(*in))
+		copy(*out, *in)
+	}
+	out.Ca = in.Ca
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSMasqUpstreamTLS.
+func (in *DNSMasqUpstreamTLS) DeepCopy() *DNSMasqUpstreamTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(DNSMasqUpstreamTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DNSSRVRecord) DeepCopyInto(out *DNSSRVRecord) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSSRVRecord.
+func (in *DNSSRVRecord) DeepCopy() *DNSSRVRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(DNSSRVRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IPAddress) DeepCopyInto(out *IPAddress) {
 	*out = *in
+	if in.Ordinal != nil {
+		in, out := &in.Ordinal, &out.Ordinal
+		*out = new(int)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPAddress.
@@ -368,6 +898,26 @@ func (in *IPAddress) DeepCopy() *IPAddress {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IPOwner) DeepCopyInto(out *IPOwner) {
+	*out = *in
+	if in.Ordinal != nil {
+		in, out := &in.Ordinal, &out.Ordinal
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPOwner.
+func (in *IPOwner) DeepCopy() *IPOwner {
+	if in == nil {
+		return nil
+	}
+	out := new(IPOwner)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IPSet) DeepCopyInto(out *IPSet) {
 	*out = *in
@@ -455,6 +1005,11 @@ func (in *IPSetNetwork) DeepCopy() *IPSetNetwork {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IPSetReservation) DeepCopyInto(out *IPSetReservation) {
 	*out = *in
+	if in.Ordinal != nil {
+		in, out := &in.Ordinal, &out.Ordinal
+		*out = new(int)
+		**out = **in
+	}
 	if in.Vlan != nil {
 		in, out := &in.Vlan, &out.Vlan
 		*out = new(int)
@@ -492,6 +1047,11 @@ func (in *IPSetSpec) DeepCopyInto(out *IPSetSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Ordinals != nil {
+		in, out := &in.Ordinals, &out.Ordinals
+		*out = new(int)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPSetSpec.
@@ -533,13 +1093,48 @@ func (in *IPSetStatus) DeepCopy() *IPSetStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImportedAddress) DeepCopyInto(out *ImportedAddress) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImportedAddress.
+func (in *ImportedAddress) DeepCopy() *ImportedAddress {
+	if in == nil {
+		return nil
+	}
+	out := new(ImportedAddress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImportedReservation) DeepCopyInto(out *ImportedReservation) {
+	*out = *in
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]ImportedAddress, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImportedReservation.
+func (in *ImportedReservation) DeepCopy() *ImportedReservation {
+	if in == nil {
+		return nil
+	}
+	out := new(ImportedReservation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetConfig) DeepCopyInto(out *NetConfig) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetConfig.
@@ -560,6 +1155,26 @@ func (in *NetConfig) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetConfigDefaults) DeepCopyInto(out *NetConfigDefaults) {
+	*out = *in
+	if in.BGPNeighbors != nil {
+		in, out := &in.BGPNeighbors, &out.BGPNeighbors
+		*out = make([]BGPNeighbor, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetConfigDefaults.
+func (in *NetConfigDefaults) DeepCopy() *NetConfigDefaults {
+	if in == nil {
+		return nil
+	}
+	out := new(NetConfigDefaults)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetConfigList) DeepCopyInto(out *NetConfigList) {
 	*out = *in
@@ -602,6 +1217,28 @@ func (in *NetConfigSpec) DeepCopyInto(out *NetConfigSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.StaleReservationTTL != nil {
+		in, out := &in.StaleReservationTTL, &out.StaleReservationTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.FreeAddressesThreshold != nil {
+		in, out := &in.FreeAddressesThreshold, &out.FreeAddressesThreshold
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ImportedReservations != nil {
+		in, out := &in.ImportedReservations, &out.ImportedReservations
+		*out = make([]ImportedReservation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.BGPAnnouncement != nil {
+		in, out := &in.BGPAnnouncement, &out.BGPAnnouncement
+		*out = new(BGPAnnouncement)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetConfigSpec.
@@ -617,6 +1254,38 @@ func (in *NetConfigSpec) DeepCopy() *NetConfigSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetConfigStatus) DeepCopyInto(out *NetConfigStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(condition.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.StaleReservations != nil {
+		in, out := &in.StaleReservations, &out.StaleReservations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Subnets != nil {
+		in, out := &in.Subnets, &out.Subnets
+		*out = make([]SubnetUtilization, len(*in))
+		copy(*out, *in)
+	}
+	if in.InvalidReservations != nil {
+		in, out := &in.InvalidReservations, &out.InvalidReservations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ConflictingImports != nil {
+		in, out := &in.ConflictingImports, &out.ConflictingImports
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AnnouncedPrefixes != nil {
+		in, out := &in.AnnouncedPrefixes, &out.AnnouncedPrefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetConfigStatus.
@@ -718,7 +1387,7 @@ func (in *ReservationSpec) DeepCopyInto(out *ReservationSpec) {
 		in, out := &in.Reservation, &out.Reservation
 		*out = make(map[string]IPAddress, len(*in))
 		for key, val := range *in {
-			(*out)[key] = val
+			(*out)[key] = *val.DeepCopy()
 		}
 	}
 }
@@ -786,6 +1455,11 @@ func (in *Subnet) DeepCopyInto(out *Subnet) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ExcludeRanges != nil {
+		in, out := &in.ExcludeRanges, &out.ExcludeRanges
+		*out = make([]AllocationRange, len(*in))
+		copy(*out, *in)
+	}
 	if in.Gateway != nil {
 		in, out := &in.Gateway, &out.Gateway
 		*out = new(string)
@@ -807,3 +1481,18 @@ func (in *Subnet) DeepCopy() *Subnet {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SubnetUtilization) DeepCopyInto(out *SubnetUtilization) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubnetUtilization.
+func (in *SubnetUtilization) DeepCopy() *SubnetUtilization {
+	if in == nil {
+		return nil
+	}
+	out := new(SubnetUtilization)
+	in.DeepCopyInto(out)
+	return out
+}
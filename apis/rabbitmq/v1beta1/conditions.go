@@ -23,6 +23,12 @@ import (
 const (
 	// TransportURLReadyCondition Status=True condition which indicates if TransportURL is configured and operational
 	TransportURLReadyCondition condition.Type = "TransportURLReady"
+
+	// MessagingPolicyReadyCondition Status=True condition which indicates if the MessagingPolicy is applied
+	MessagingPolicyReadyCondition condition.Type = "MessagingPolicyReady"
+
+	// MessagingLinkReadyCondition Status=True condition which indicates if the MessagingLink is configured
+	MessagingLinkReadyCondition condition.Type = "MessagingLinkReady"
 )
 
 // TransportURL Reasons used by API objects.
@@ -45,4 +51,36 @@ const (
 
 	// TransportURLInProgressMessage
 	TransportURLInProgressMessage = "TransportURL in progress"
+
+	//
+	// MessagingPolicyReady condition messages
+	//
+
+	// MessagingPolicyReadyErrorMessage
+	MessagingPolicyReadyErrorMessage = "MessagingPolicy error occured %s"
+
+	// MessagingPolicyReadyInitMessage
+	MessagingPolicyReadyInitMessage = "MessagingPolicy not applied"
+
+	// MessagingPolicyReadyMessage
+	MessagingPolicyReadyMessage = "MessagingPolicy applied"
+
+	// MessagingPolicyInProgressMessage
+	MessagingPolicyInProgressMessage = "MessagingPolicy in progress"
+
+	//
+	// MessagingLinkReady condition messages
+	//
+
+	// MessagingLinkReadyErrorMessage
+	MessagingLinkReadyErrorMessage = "MessagingLink error occured %s"
+
+	// MessagingLinkReadyInitMessage
+	MessagingLinkReadyInitMessage = "MessagingLink not configured"
+
+	// MessagingLinkReadyMessage
+	MessagingLinkReadyMessage = "MessagingLink configured"
+
+	// MessagingLinkInProgressMessage
+	MessagingLinkInProgressMessage = "MessagingLink in progress"
 )
@@ -0,0 +1,147 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// MessagingLinkTypeShovel - a dynamic shovel moving the messages from the source to the
+	// destination, configured on the source cluster
+	MessagingLinkTypeShovel = "shovel"
+	// MessagingLinkTypeFederation - a federation upstream and policy on the destination
+	// cluster, federating the exchange or queue of the source
+	MessagingLinkTypeFederation = "federation"
+)
+
+// MessagingLinkSpec defines the desired state of MessagingLink
+type MessagingLinkSpec struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=shovel
+	// +kubebuilder:validation:Enum=shovel;federation
+	// Type - shovel or federation. The rabbitmq_shovel or rabbitmq_federation plugin has to be
+	// enabled on the Rabbitmq cluster the link gets configured on.
+	Type string `json:"type"`
+
+	// +kubebuilder:validation:Required
+	// Source - the messages are consumed from, the upstream of a federation
+	Source MessagingLinkEndpoint `json:"source"`
+
+	// +kubebuilder:validation:Required
+	// Destination - the messages are published to, the downstream of a federation
+	Destination MessagingLinkEndpoint `json:"destination"`
+}
+
+// MessagingLinkEndpoint defines a queue or exchange of a Rabbitmq cluster
+type MessagingLinkEndpoint struct {
+	// +kubebuilder:validation:Required
+	// RabbitmqClusterName - name of the Rabbitmq cluster. With TLS the certificates of the
+	// source and destination clusters have to be issued by the same CA, the link verifies
+	// the remote cluster against the CA of the cluster it runs on.
+	RabbitmqClusterName string `json:"rabbitmqClusterName"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default="/"
+	// Vhost - RabbitMQ vhost of the queue or exchange
+	Vhost string `json:"vhost"`
+
+	// +kubebuilder:validation:Optional
+	// Queue - name of the queue, either queue or exchange has to be set
+	Queue string `json:"queue,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Exchange - name of the exchange, either queue or exchange has to be set
+	Exchange string `json:"exchange,omitempty"`
+}
+
+// MessagingLinkStatus defines the observed state of MessagingLink
+type MessagingLinkStatus struct {
+	// Conditions
+	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+
+	// Type - type of the configured link
+	Type string `json:"type,omitempty"`
+
+	// RabbitmqClusterName - name of the Rabbitmq cluster the link got configured on
+	RabbitmqClusterName string `json:"rabbitmqClusterName,omitempty"`
+
+	// Vhost - RabbitMQ vhost the link got configured on
+	Vhost string `json:"vhost,omitempty"`
+
+	// UserRabbitmqClusterNames - names of the Rabbitmq clusters the user of the link got created on
+	UserRabbitmqClusterNames []string `json:"userRabbitmqClusterNames,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Type",type="string",JSONPath=".spec.type",description="Type"
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[0].status",description="Status"
+//+kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[0].message",description="Message"
+
+// MessagingLink is the Schema for the messaginglinks API
+type MessagingLink struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MessagingLinkSpec   `json:"spec,omitempty"`
+	Status MessagingLinkStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// MessagingLinkList contains a list of MessagingLink
+type MessagingLinkList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MessagingLink `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MessagingLink{}, &MessagingLinkList{})
+}
+
+// IsReady - returns true if the link got configured
+func (instance MessagingLink) IsReady() bool {
+	return instance.Status.Conditions.IsTrue(MessagingLinkReadyCondition)
+}
+
+// LinkRabbitmqClusterName - returns the name of the Rabbitmq cluster the link
+// gets configured on, the source of a shovel and the destination of a federation
+func (instance MessagingLink) LinkRabbitmqClusterName() string {
+	if instance.Spec.Type == MessagingLinkTypeFederation {
+		return instance.Spec.Destination.RabbitmqClusterName
+	}
+	return instance.Spec.Source.RabbitmqClusterName
+}
+
+// LinkVhost - returns the vhost the link gets configured on
+func (instance MessagingLink) LinkVhost() string {
+	if instance.Spec.Type == MessagingLinkTypeFederation {
+		return instance.Spec.Destination.Vhost
+	}
+	return instance.Spec.Source.Vhost
+}
+
+// LinkClusterNames - returns the names of the Rabbitmq clusters the user of the
+// link gets created on, the source and the destination
+func (instance MessagingLink) LinkClusterNames() []string {
+	if instance.Spec.Source.RabbitmqClusterName == instance.Spec.Destination.RabbitmqClusterName {
+		return []string{instance.Spec.Source.RabbitmqClusterName}
+	}
+	return []string{instance.Spec.Source.RabbitmqClusterName, instance.Spec.Destination.RabbitmqClusterName}
+}
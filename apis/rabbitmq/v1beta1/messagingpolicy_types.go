@@ -0,0 +1,156 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MessagingPolicySpec defines the desired state of MessagingPolicy
+type MessagingPolicySpec struct {
+	// +kubebuilder:validation:Required
+	// RabbitmqClusterName - name of the Rabbitmq cluster the policy gets applied on
+	RabbitmqClusterName string `json:"rabbitmqClusterName"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default="/"
+	// Vhost - RabbitMQ vhost of the policy, it has to exist
+	Vhost string `json:"vhost"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// Pattern - regular expression matching the names of the queues and/or exchanges the
+	// policy applies to
+	Pattern string `json:"pattern"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=queues
+	// +kubebuilder:validation:Enum=queues;exchanges;all
+	// ApplyTo - kind of the objects the policy applies to
+	ApplyTo string `json:"applyTo"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=0
+	// Priority - of the policy, only the matching policy with the highest priority applies
+	Priority int32 `json:"priority"`
+
+	// +kubebuilder:validation:Required
+	// Definition - the settings the policy applies
+	Definition MessagingPolicyDefinition `json:"definition"`
+}
+
+// MessagingPolicyDefinition defines the settings of a RabbitMQ policy
+type MessagingPolicyDefinition struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// MessageTTL - time in milliseconds after which messages get discarded (message-ttl)
+	MessageTTL *int64 `json:"messageTTL,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// Expires - time in milliseconds after which unused queues get deleted (expires)
+	Expires *int64 `json:"expires,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// MaxLength - maximum number of messages of a queue (max-length)
+	MaxLength *int64 `json:"maxLength,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// MaxLengthBytes - maximum size of the message bodies of a queue (max-length-bytes)
+	MaxLengthBytes *int64 `json:"maxLengthBytes,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=drop-head;reject-publish;reject-publish-dlx
+	// Overflow - behaviour once a queue reached its maximum length (overflow)
+	Overflow string `json:"overflow,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// DeadLetterExchange - exchange discarded messages get republished to (dead-letter-exchange)
+	DeadLetterExchange string `json:"deadLetterExchange,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=all;exactly
+	// HAMode - mirroring of classic queues (ha-mode)
+	HAMode string `json:"haMode,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// HAParams - number of replicas of a classic queue with haMode exactly (ha-params)
+	HAParams *int32 `json:"haParams,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=manual;automatic
+	// HASyncMode - synchronisation of new mirrors of classic queues (ha-sync-mode)
+	HASyncMode string `json:"haSyncMode,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// DeliveryLimit - number of redeliveries of a message of a quorum queue before it gets
+	// dead-lettered (delivery-limit)
+	DeliveryLimit *int32 `json:"deliveryLimit,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=client-local;balanced
+	// QueueLeaderLocator - placement of the leader of new quorum queues (queue-leader-locator)
+	QueueLeaderLocator string `json:"queueLeaderLocator,omitempty"`
+}
+
+// MessagingPolicyStatus defines the observed state of MessagingPolicy
+type MessagingPolicyStatus struct {
+	// Conditions
+	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+
+	// RabbitmqClusterName - name of the Rabbitmq cluster the policy got applied on
+	RabbitmqClusterName string `json:"rabbitmqClusterName,omitempty"`
+
+	// Vhost - RabbitMQ vhost the policy got applied on
+	Vhost string `json:"vhost,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[0].status",description="Status"
+//+kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.conditions[0].message",description="Message"
+
+// MessagingPolicy is the Schema for the messagingpolicies API
+type MessagingPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MessagingPolicySpec   `json:"spec,omitempty"`
+	Status MessagingPolicyStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// MessagingPolicyList contains a list of MessagingPolicy
+type MessagingPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MessagingPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MessagingPolicy{}, &MessagingPolicyList{})
+}
+
+// IsReady - returns true if the policy got applied
+func (instance MessagingPolicy) IsReady() bool {
+	return instance.Status.Conditions.IsTrue(MessagingPolicyReadyCondition)
+}
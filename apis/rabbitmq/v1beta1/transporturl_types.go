@@ -17,15 +17,233 @@ limitations under the License.
 package v1beta1
 
 import (
+	"sort"
+	"strings"
+
 	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	// TransportURLConsumersAnnotation - annotation of a TransportURL listing the services using
+	// it without owning it, as comma separated <kind>/<name> entries
+	TransportURLConsumersAnnotation = "rabbitmq.openstack.org/consumers"
+
+	// TransportURLDriverRabbit - the oslo.messaging RabbitMQ driver
+	TransportURLDriverRabbit = "rabbit"
+
+	// TransportURLDriverAMQP1 - the oslo.messaging AMQP 1.0 driver
+	TransportURLDriverAMQP1 = "amqp1"
+)
+
 // TransportURLSpec defines the desired state of TransportURL
 type TransportURLSpec struct {
-	// +kubebuilder:validation:Required
-	// RabbitmqClusterName the name of the Rabbitmq cluster which to configure the transport URL
+	// +kubebuilder:validation:Optional
+	// RabbitmqClusterName the name of the Rabbitmq cluster which to configure the transport URL,
+	// required unless external is set
 	RabbitmqClusterName string `json:"rabbitmqClusterName"`
+
+	// +kubebuilder:validation:Optional
+	// AdditionalRabbitmqClusterNames - names of further Rabbitmq clusters rendered as failover
+	// hosts into the transport URL, after the one of rabbitmqClusterName. The vhost and the user
+	// of the transport get created on all clusters.
+	AdditionalRabbitmqClusterNames []string `json:"additionalRabbitmqClusterNames,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Vhost - RabbitMQ vhost the transport is isolated in, created together with a user scoped
+	// to it. Defaults to the name of the consumer service, the controller owner of the
+	// TransportURL, or the name of the TransportURL if it has none. Set to "/" to share the
+	// default vhost. TransportURLs of the same service share the vhost, it gets deleted together
+	// with the last TransportURL of the namespace using it.
+	Vhost string `json:"vhost,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// CredentialRotation - arbitrary value, changing it rotates the credentials of the transport.
+	// A new user gets created and published in the transport Secret, the previous user gets
+	// deleted once the consumers picked up the new Secret and closed its connections.
+	CredentialRotation string `json:"credentialRotation,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// External - a pre-existing RabbitMQ outside of the cluster to target instead of a Rabbitmq
+	// cluster. Its vhost and user are not managed, the given credentials are published as is.
+	External *TransportURLExternal `json:"external,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// ClientCert - client certificate the consumers connect with (mTLS), requires TLS. The paths
+	// of the certificate and key get rendered into the transport URL.
+	ClientCert *TransportURLClientCert `json:"clientCert,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// QuorumQueues - append the oslo.messaging options to use durable quorum queues
+	// (rabbit_quorum_queue, rabbit_transient_quorum_queue, amqp_durable_queues) to the transport URL.
+	// Only supported by the rabbit driver.
+	QuorumQueues bool `json:"quorumQueues,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Connection - oslo.messaging connection handling options rendered into the transport URL,
+	// unset options keep the oslo.messaging defaults. Only supported by the rabbit driver.
+	Connection *TransportURLConnection `json:"connection,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`
+	// QueuePrefix - prefix of the exchange and queue names the consumers declare, so control
+	// planes sharing a broker and vhost do not collide. oslo.messaging does not read these options
+	// from the transport URL, they get published as oslo.config snippet under the
+	// secretKeys.queuePrefixConfig key of the Secret, which the consumers have to add to their
+	// configuration: the [DEFAULT] control_exchange option for the rabbit driver and the
+	// [oslo_messaging_amqp] address prefixes for the amqp1 driver.
+	QueuePrefix string `json:"queuePrefix,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=rabbit
+	// +kubebuilder:validation:Enum=rabbit;amqp1
+	// Driver - oslo.messaging driver the transport URL is rendered for, rabbit renders a rabbit://
+	// and amqp1 an amqp:// URL for the AMQP 1.0 driver
+	Driver string `json:"driver,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Notifications - render a separate transport URL for the oslo.messaging notifications,
+	// published next to the RPC one under the notification_transport_url key of the Secret.
+	// It shares the user of the RPC transport, unset fields default to the ones of the RPC
+	// transport.
+	Notifications *TransportURLNotifications `json:"notifications,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default={}
+	// Permissions - configure, write and read permissions of the user of the transport on its
+	// vhosts, vhost-wide by default
+	Permissions TransportURLPermissions `json:"permissions"`
+
+	// +kubebuilder:validation:Optional
+	// SecretName - name of the Secret the transport URLs are published in, defaults to
+	// rabbitmq-transport-url-<name of the TransportURL>
+	SecretName string `json:"secretName,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// SecretKeys - keys the transport URLs are published under in the Secret
+	SecretKeys TransportURLSecretKeys `json:"secretKeys,omitempty"`
+}
+
+// TransportURLConnection defines the connection handling options of the consumers
+type TransportURLConnection struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// HeartbeatTimeoutThreshold - seconds without heartbeat after which the connection is
+	// considered dead, 0 disables the heartbeat (heartbeat_timeout_threshold)
+	HeartbeatTimeoutThreshold *int32 `json:"heartbeatTimeoutThreshold,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// RetryInterval - seconds between retries of connecting to the brokers (rabbit_retry_interval)
+	RetryInterval *int32 `json:"retryInterval,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	// KombuReconnectDelay - seconds to wait before reconnecting in response to a consumer cancel
+	// notification, e.g. 1.0 (kombu_reconnect_delay)
+	KombuReconnectDelay string `json:"kombuReconnectDelay,omitempty"`
+}
+
+// TransportURLClientCert defines the client certificate of the consumers of a transport
+type TransportURLClientCert struct {
+	// +kubebuilder:validation:Required
+	// SecretName - name of the kubernetes.io/tls secret holding the client certificate (tls.crt)
+	// and key (tls.key), e.g. issued by a cert-manager Certificate. The consumers mount it at
+	// mountPath.
+	SecretName string `json:"secretName"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default="/etc/pki/tls/certs/rabbitmq-client"
+	// MountPath - directory the consumers mount the secret at
+	MountPath string `json:"mountPath"`
+
+	// +kubebuilder:validation:Optional
+	// External - authenticate with the EXTERNAL mechanism, i.e. by the client certificate,
+	// instead of username and password, for brokers enforcing it
+	External bool `json:"external,omitempty"`
+}
+
+// TransportURLPermissions defines the permission regular expressions of a user on a vhost,
+// matching the names of the queues and exchanges. An empty expression grants no permission,
+// an unset one all permissions, i.e. .*
+type TransportURLPermissions struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=".*"
+	// Configure - queues and exchanges the user may declare and delete
+	Configure *string `json:"configure,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=".*"
+	// Write - queues and exchanges the user may publish to
+	Write *string `json:"write,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=".*"
+	// Read - queues and exchanges the user may consume from
+	Read *string `json:"read,omitempty"`
+}
+
+// TransportURLSecretKeys defines the keys of the transport Secret, empty keys use the default
+type TransportURLSecretKeys struct {
+	// +kubebuilder:validation:Optional
+	// TransportURL - key of the oslo.messaging transport URL, defaults to transport_url
+	TransportURL string `json:"transportURL,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// AMQPURL - key of the AMQP URI, defaults to amqp_url
+	AMQPURL string `json:"amqpURL,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// NotificationTransportURL - key of the transport URL of the notifications, defaults to
+	// notification_transport_url
+	NotificationTransportURL string `json:"notificationTransportURL,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// NotificationAMQPURL - key of the AMQP URI of the notifications, defaults to notification_amqp_url
+	NotificationAMQPURL string `json:"notificationAMQPURL,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// QueuePrefixConfig - key of the oslo.config snippet with the queue prefix options, defaults
+	// to queue_prefix.conf
+	QueuePrefixConfig string `json:"queuePrefixConfig,omitempty"`
+}
+
+// TransportURLNotifications defines the transport of the oslo.messaging notifications
+type TransportURLNotifications struct {
+	// +kubebuilder:validation:Optional
+	// RabbitmqClusterName - name of the Rabbitmq cluster the notifications are sent to,
+	// defaults to the Rabbitmq clusters of the RPC transport. Not supported with external.
+	RabbitmqClusterName string `json:"rabbitmqClusterName,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// Vhost - RabbitMQ vhost of the notifications, defaults to the vhost of the RPC transport
+	Vhost string `json:"vhost,omitempty"`
+}
+
+// TransportURLExternal defines a RabbitMQ endpoint not managed by the operator
+type TransportURLExternal struct {
+	// +kubebuilder:validation:Required
+	// Host - host name or IP address of the RabbitMQ endpoint
+	Host string `json:"host"`
+
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// Port - port of the RabbitMQ endpoint, defaults to 5671 with TLS and 5672 otherwise
+	Port int32 `json:"port,omitempty"`
+
+	// +kubebuilder:validation:Required
+	// SecretName - name of the secret holding the username and password keys of the user
+	SecretName string `json:"secretName"`
+
+	// +kubebuilder:validation:Optional
+	// TLS - connect to the endpoint with TLS
+	TLS bool `json:"tls,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// CaSecretName - name of the secret holding the CA certificate (ca.crt) of the endpoint,
+	// published to the consumers if TLS is enabled
+	CaSecretName string `json:"caSecretName,omitempty"`
 }
 
 // TransportURLStatus defines the observed state of TransportURL
@@ -36,6 +254,48 @@ type TransportURLStatus struct {
 
 	// SecretName - name of the secret containing the rabbitmq transport URL
 	SecretName string `json:"secretName,omitempty"`
+
+	// SecretHash - hash of the data of the secret containing the rabbitmq transport URL, changes
+	// exactly when the transport changes. Consumers can add it to their input hash to restart
+	// their services on transport changes.
+	SecretHash string `json:"secretHash,omitempty"`
+
+	// CaSecretName - name of the secret containing the CA certificate (ca.crt) of the
+	// rabbitmq server certificate, set if TLS is enabled on the rabbitmq cluster
+	CaSecretName string `json:"caSecretName,omitempty"`
+
+	// ClientCertSecretName - name of the secret containing the client certificate the consumers
+	// have to mount, set if spec.clientCert is set
+	ClientCertSecretName string `json:"clientCertSecretName,omitempty"`
+
+	// Vhost - RabbitMQ vhost of the transport
+	Vhost string `json:"vhost,omitempty"`
+
+	// NotificationVhost - RabbitMQ vhost of the notification transport, set if spec.notifications is set
+	NotificationVhost string `json:"notificationVhost,omitempty"`
+
+	// CredentialRotation - the spec.credentialRotation value the current credentials got created for
+	CredentialRotation string `json:"credentialRotation,omitempty"`
+
+	// RetiringUser - the previous user after a credential rotation, deleted once it has no
+	// connections left
+	RetiringUser string `json:"retiringUser,omitempty"`
+
+	// RetiringSince - time the previous user got replaced
+	RetiringSince *metav1.Time `json:"retiringSince,omitempty"`
+
+	// Consumers - services using the transport, the owners of the TransportURL and the ones
+	// listed in its rabbitmq.openstack.org/consumers annotation
+	Consumers []TransportURLConsumer `json:"consumers,omitempty"`
+}
+
+// TransportURLConsumer identifies a service using a TransportURL
+type TransportURLConsumer struct {
+	// Kind - kind of the consumer, empty if not known
+	Kind string `json:"kind,omitempty"`
+
+	// Name - name of the consumer
+	Name string `json:"name"`
 }
 
 //+kubebuilder:object:root=true
@@ -69,3 +329,80 @@ func init() {
 func (instance TransportURL) IsReady() bool {
 	return instance.Status.Conditions.IsTrue(TransportURLReadyCondition)
 }
+
+// RabbitmqClusterNames - returns the names of all Rabbitmq clusters of the
+// transport, the one of rabbitmqClusterName first
+func (instance TransportURL) RabbitmqClusterNames() []string {
+	if instance.Spec.RabbitmqClusterName == "" {
+		return instance.Spec.AdditionalRabbitmqClusterNames
+	}
+	return append([]string{instance.Spec.RabbitmqClusterName}, instance.Spec.AdditionalRabbitmqClusterNames...)
+}
+
+// VhostName - returns the vhost of the transport, spec.vhost if set, otherwise
+// the name of the consumer service owning the TransportURL or the name of the
+// TransportURL. External endpoints default to the default vhost.
+func (instance TransportURL) VhostName() string {
+	if instance.Spec.Vhost != "" {
+		return instance.Spec.Vhost
+	}
+	if instance.Spec.External != nil {
+		return "/"
+	}
+	if owner := metav1.GetControllerOf(&instance); owner != nil {
+		return owner.Name
+	}
+	return instance.Name
+}
+
+// NotificationRabbitmqClusterNames - returns the names of the Rabbitmq
+// clusters of the notification transport
+func (instance TransportURL) NotificationRabbitmqClusterNames() []string {
+	if instance.Spec.Notifications != nil && instance.Spec.Notifications.RabbitmqClusterName != "" {
+		return []string{instance.Spec.Notifications.RabbitmqClusterName}
+	}
+	return instance.RabbitmqClusterNames()
+}
+
+// NotificationVhostName - returns the vhost of the notification transport,
+// spec.notifications.vhost if set, otherwise the vhost of the RPC transport
+func (instance TransportURL) NotificationVhostName() string {
+	if instance.Spec.Notifications != nil && instance.Spec.Notifications.Vhost != "" {
+		return instance.Spec.Notifications.Vhost
+	}
+	return instance.VhostName()
+}
+
+// ConsumerList - returns the consumers of the transport, the owners of the
+// TransportURL and the ones of the consumers annotation, sorted by kind and name
+func (instance TransportURL) ConsumerList() []TransportURLConsumer {
+	seen := map[TransportURLConsumer]bool{}
+	consumers := []TransportURLConsumer{}
+	add := func(c TransportURLConsumer) {
+		if c.Name == "" || seen[c] {
+			return
+		}
+		seen[c] = true
+		consumers = append(consumers, c)
+	}
+
+	for _, owner := range instance.GetOwnerReferences() {
+		add(TransportURLConsumer{Kind: owner.Kind, Name: owner.Name})
+	}
+	for _, entry := range strings.Split(instance.GetAnnotations()[TransportURLConsumersAnnotation], ",") {
+		entry = strings.TrimSpace(entry)
+		kind, name, found := strings.Cut(entry, "/")
+		if !found {
+			kind, name = "", entry
+		}
+		add(TransportURLConsumer{Kind: strings.TrimSpace(kind), Name: strings.TrimSpace(name)})
+	}
+
+	sort.Slice(consumers, func(i, j int) bool {
+		if consumers[i].Kind != consumers[j].Kind {
+			return consumers[i].Kind < consumers[j].Kind
+		}
+		return consumers[i].Name < consumers[j].Name
+	})
+	return consumers
+}
@@ -0,0 +1,166 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var transporturllog = logf.Log.WithName("transporturl-resource")
+
+// SetupWebhookWithManager sets up the webhook with the Manager
+func (r *TransportURL) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-rabbitmq-openstack-org-v1beta1-transporturl,mutating=false,failurePolicy=fail,sideEffects=None,groups=rabbitmq.openstack.org,resources=transporturls,verbs=create;update,versions=v1beta1,name=vtransporturl.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &TransportURL{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *TransportURL) ValidateCreate() error {
+	transporturllog.Info("validate create", "name", r.Name)
+
+	allErrs := r.Spec.ValidateTransport(field.NewPath("spec"))
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("TransportURL").GroupKind(), r.Name, allErrs)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *TransportURL) ValidateUpdate(old runtime.Object) error {
+	transporturllog.Info("validate update", "name", r.Name)
+
+	allErrs := r.Spec.ValidateTransport(field.NewPath("spec"))
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("TransportURL").GroupKind(), r.Name, allErrs)
+}
+
+// ValidateTransport - validates the references to the RabbitMQ clusters or the
+// external endpoint and the driver specific options
+func (spec *TransportURLSpec) ValidateTransport(basePath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	// the names of the RabbitmqClusters
+	if spec.RabbitmqClusterName != "" {
+		allErrs = append(allErrs, validateClusterName(basePath.Child("rabbitmqClusterName"), spec.RabbitmqClusterName)...)
+	}
+	seen := map[string]bool{spec.RabbitmqClusterName: true}
+	for i, name := range spec.AdditionalRabbitmqClusterNames {
+		path := basePath.Child("additionalRabbitmqClusterNames").Index(i)
+		allErrs = append(allErrs, validateClusterName(path, name)...)
+		if seen[name] {
+			allErrs = append(allErrs, field.Duplicate(path, name))
+		}
+		seen[name] = true
+	}
+	if spec.Notifications != nil && spec.Notifications.RabbitmqClusterName != "" {
+		allErrs = append(allErrs, validateClusterName(
+			basePath.Child("notifications").Child("rabbitmqClusterName"), spec.Notifications.RabbitmqClusterName)...)
+	}
+
+	// either RabbitmqClusters or an external endpoint
+	clusterSet := spec.RabbitmqClusterName != "" || len(spec.AdditionalRabbitmqClusterNames) > 0
+	if spec.External != nil {
+		if clusterSet {
+			allErrs = append(allErrs, field.Forbidden(
+				basePath.Child("external"), "external can not be set together with rabbitmqClusterName or additionalRabbitmqClusterNames"))
+		}
+		if spec.Notifications != nil && spec.Notifications.RabbitmqClusterName != "" {
+			allErrs = append(allErrs, field.Forbidden(
+				basePath.Child("notifications").Child("rabbitmqClusterName"), "notifications.rabbitmqClusterName can not be set together with external"))
+		}
+		if spec.ClientCert != nil && !spec.External.TLS {
+			allErrs = append(allErrs, field.Invalid(
+				basePath.Child("clientCert"), spec.ClientCert.SecretName, "clientCert requires TLS on the external endpoint"))
+		}
+	} else if !clusterSet {
+		allErrs = append(allErrs, field.Required(
+			basePath.Child("rabbitmqClusterName"), "either rabbitmqClusterName or external has to be set"))
+	}
+
+	// the keys of the transport Secret have to be uniq, including the defaults
+	keysPath := basePath.Child("secretKeys")
+	keys := []struct {
+		child      string
+		key        string
+		defaultKey string
+	}{
+		{"transportURL", spec.SecretKeys.TransportURL, "transport_url"},
+		{"amqpURL", spec.SecretKeys.AMQPURL, "amqp_url"},
+		{"notificationTransportURL", spec.SecretKeys.NotificationTransportURL, "notification_transport_url"},
+		{"notificationAMQPURL", spec.SecretKeys.NotificationAMQPURL, "notification_amqp_url"},
+		{"queuePrefixConfig", spec.SecretKeys.QueuePrefixConfig, "queue_prefix.conf"},
+	}
+	seenKeys := map[string]bool{}
+	for _, k := range keys {
+		key := k.key
+		if key == "" {
+			key = k.defaultKey
+		}
+		if seenKeys[key] {
+			allErrs = append(allErrs, field.Duplicate(keysPath.Child(k.child), key))
+		}
+		seenKeys[key] = true
+	}
+
+	// the options of the rabbit driver
+	if spec.Driver == TransportURLDriverAMQP1 {
+		if spec.QuorumQueues {
+			allErrs = append(allErrs, field.Invalid(
+				basePath.Child("quorumQueues"), spec.QuorumQueues, fmt.Sprintf("quorumQueues is not supported by the %s driver", spec.Driver)))
+		}
+		if spec.Connection != nil {
+			allErrs = append(allErrs, field.Forbidden(
+				basePath.Child("connection"), fmt.Sprintf("connection is not supported by the %s driver", spec.Driver)))
+		}
+	}
+
+	return allErrs
+}
+
+// validateClusterName - validates the name of a RabbitmqCluster is a valid
+// object name
+func validateClusterName(path *field.Path, name string) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for _, msg := range validation.IsDNS1123Subdomain(name) {
+		allErrs = append(allErrs, field.Invalid(path, name, msg))
+	}
+	return allErrs
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *TransportURL) ValidateDelete() error {
+	transporturllog.Info("validate delete", "name", r.Name)
+
+	return nil
+}
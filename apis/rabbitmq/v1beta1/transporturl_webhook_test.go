@@ -0,0 +1,140 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestTransportURLValidateTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		expectErr bool
+		spec      TransportURLSpec
+	}{
+		{
+			name:      "should succeed with a rabbitmq cluster",
+			expectErr: false,
+			spec: TransportURLSpec{
+				RabbitmqClusterName: "rabbitmq",
+				Driver:              TransportURLDriverRabbit,
+				QuorumQueues:        true,
+			},
+		},
+		{
+			name:      "should succeed with an external endpoint",
+			expectErr: false,
+			spec: TransportURLSpec{
+				External:   &TransportURLExternal{Host: "rabbitmq.example.com", SecretName: "rabbitmq", TLS: true},
+				ClientCert: &TransportURLClientCert{SecretName: "rabbitmq-client"},
+			},
+		},
+		{
+			name:      "should fail without a rabbitmq cluster or an external endpoint",
+			expectErr: true,
+			spec:      TransportURLSpec{},
+		},
+		{
+			name:      "should fail with an invalid rabbitmq cluster name",
+			expectErr: true,
+			spec: TransportURLSpec{
+				RabbitmqClusterName: "RabbitMQ_cell1",
+			},
+		},
+		{
+			name:      "should fail with an invalid notification rabbitmq cluster name",
+			expectErr: true,
+			spec: TransportURLSpec{
+				RabbitmqClusterName: "rabbitmq",
+				Notifications:       &TransportURLNotifications{RabbitmqClusterName: "rabbitmq.notifications."},
+			},
+		},
+		{
+			name:      "should fail with a duplicate additional rabbitmq cluster",
+			expectErr: true,
+			spec: TransportURLSpec{
+				RabbitmqClusterName:            "rabbitmq",
+				AdditionalRabbitmqClusterNames: []string{"rabbitmq-edge", "rabbitmq"},
+			},
+		},
+		{
+			name:      "should fail with a rabbitmq cluster and an external endpoint",
+			expectErr: true,
+			spec: TransportURLSpec{
+				RabbitmqClusterName: "rabbitmq",
+				External:            &TransportURLExternal{Host: "rabbitmq.example.com", SecretName: "rabbitmq"},
+			},
+		},
+		{
+			name:      "should fail with a client certificate for an external endpoint without TLS",
+			expectErr: true,
+			spec: TransportURLSpec{
+				External:   &TransportURLExternal{Host: "rabbitmq.example.com", SecretName: "rabbitmq"},
+				ClientCert: &TransportURLClientCert{SecretName: "rabbitmq-client"},
+			},
+		},
+		{
+			name:      "should fail with quorum queues for the amqp1 driver",
+			expectErr: true,
+			spec: TransportURLSpec{
+				RabbitmqClusterName: "rabbitmq",
+				Driver:              TransportURLDriverAMQP1,
+				QuorumQueues:        true,
+			},
+		},
+		{
+			name:      "should fail with connection options for the amqp1 driver",
+			expectErr: true,
+			spec: TransportURLSpec{
+				RabbitmqClusterName: "rabbitmq",
+				Driver:              TransportURLDriverAMQP1,
+				Connection:          &TransportURLConnection{KombuReconnectDelay: "1.0"},
+			},
+		},
+		{
+			name:      "should succeed with custom secret keys",
+			expectErr: false,
+			spec: TransportURLSpec{
+				RabbitmqClusterName: "rabbitmq",
+				SecretKeys:          TransportURLSecretKeys{TransportURL: "amqp_url", AMQPURL: "transport_url"},
+			},
+		},
+		{
+			name:      "should fail with a duplicate secret key",
+			expectErr: true,
+			spec: TransportURLSpec{
+				RabbitmqClusterName: "rabbitmq",
+				SecretKeys:          TransportURLSecretKeys{NotificationTransportURL: "transport_url"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			errs := tt.spec.ValidateTransport(field.NewPath("spec"))
+			if tt.expectErr {
+				g.Expect(errs).NotTo(BeEmpty())
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
@@ -23,11 +23,11 @@ package v1beta1
 
 import (
 	"github.com/openstack-k8s-operators/lib-common/modules/common/condition"
-	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *TransportURL) DeepCopyInto(out *TransportURL) {
+func (in *MessagingLink) DeepCopyInto(out *MessagingLink) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -35,6 +35,266 @@ func (in *TransportURL) DeepCopyInto(out *TransportURL) {
 	in.Status.DeepCopyInto(&out.Status)
 }
 
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MessagingLink.
+func (in *MessagingLink) DeepCopy() *MessagingLink {
+	if in == nil {
+		return nil
+	}
+	out := new(MessagingLink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MessagingLink) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MessagingLinkEndpoint) DeepCopyInto(out *MessagingLinkEndpoint) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MessagingLinkEndpoint.
+func (in *MessagingLinkEndpoint) DeepCopy() *MessagingLinkEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(MessagingLinkEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MessagingLinkList) DeepCopyInto(out *MessagingLinkList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MessagingLink, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MessagingLinkList.
+func (in *MessagingLinkList) DeepCopy() *MessagingLinkList {
+	if in == nil {
+		return nil
+	}
+	out := new(MessagingLinkList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MessagingLinkList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MessagingLinkSpec) DeepCopyInto(out *MessagingLinkSpec) {
+	*out = *in
+	out.Source = in.Source
+	out.Destination = in.Destination
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MessagingLinkSpec.
+func (in *MessagingLinkSpec) DeepCopy() *MessagingLinkSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MessagingLinkSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MessagingLinkStatus) DeepCopyInto(out *MessagingLinkStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(condition.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.UserRabbitmqClusterNames != nil {
+		in, out := &in.UserRabbitmqClusterNames, &out.UserRabbitmqClusterNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MessagingLinkStatus.
+func (in *MessagingLinkStatus) DeepCopy() *MessagingLinkStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MessagingLinkStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MessagingPolicy) DeepCopyInto(out *MessagingPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MessagingPolicy.
+func (in *MessagingPolicy) DeepCopy() *MessagingPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(MessagingPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MessagingPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MessagingPolicyDefinition) DeepCopyInto(out *MessagingPolicyDefinition) {
+	*out = *in
+	if in.MessageTTL != nil {
+		in, out := &in.MessageTTL, &out.MessageTTL
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Expires != nil {
+		in, out := &in.Expires, &out.Expires
+		*out = new(int64)
+		**out = **in
+	}
+	if in.MaxLength != nil {
+		in, out := &in.MaxLength, &out.MaxLength
+		*out = new(int64)
+		**out = **in
+	}
+	if in.MaxLengthBytes != nil {
+		in, out := &in.MaxLengthBytes, &out.MaxLengthBytes
+		*out = new(int64)
+		**out = **in
+	}
+	if in.HAParams != nil {
+		in, out := &in.HAParams, &out.HAParams
+		*out = new(int32)
+		**out = **in
+	}
+	if in.DeliveryLimit != nil {
+		in, out := &in.DeliveryLimit, &out.DeliveryLimit
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MessagingPolicyDefinition.
+func (in *MessagingPolicyDefinition) DeepCopy() *MessagingPolicyDefinition {
+	if in == nil {
+		return nil
+	}
+	out := new(MessagingPolicyDefinition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MessagingPolicyList) DeepCopyInto(out *MessagingPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MessagingPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MessagingPolicyList.
+func (in *MessagingPolicyList) DeepCopy() *MessagingPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(MessagingPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MessagingPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MessagingPolicySpec) DeepCopyInto(out *MessagingPolicySpec) {
+	*out = *in
+	in.Definition.DeepCopyInto(&out.Definition)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MessagingPolicySpec.
+func (in *MessagingPolicySpec) DeepCopy() *MessagingPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MessagingPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MessagingPolicyStatus) DeepCopyInto(out *MessagingPolicyStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(condition.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MessagingPolicyStatus.
+func (in *MessagingPolicyStatus) DeepCopy() *MessagingPolicyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MessagingPolicyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransportURL) DeepCopyInto(out *TransportURL) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransportURL.
 func (in *TransportURL) DeepCopy() *TransportURL {
 	if in == nil {
@@ -53,6 +313,76 @@ func (in *TransportURL) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransportURLClientCert) DeepCopyInto(out *TransportURLClientCert) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransportURLClientCert.
+func (in *TransportURLClientCert) DeepCopy() *TransportURLClientCert {
+	if in == nil {
+		return nil
+	}
+	out := new(TransportURLClientCert)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransportURLConnection) DeepCopyInto(out *TransportURLConnection) {
+	*out = *in
+	if in.HeartbeatTimeoutThreshold != nil {
+		in, out := &in.HeartbeatTimeoutThreshold, &out.HeartbeatTimeoutThreshold
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RetryInterval != nil {
+		in, out := &in.RetryInterval, &out.RetryInterval
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransportURLConnection.
+func (in *TransportURLConnection) DeepCopy() *TransportURLConnection {
+	if in == nil {
+		return nil
+	}
+	out := new(TransportURLConnection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransportURLConsumer) DeepCopyInto(out *TransportURLConsumer) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransportURLConsumer.
+func (in *TransportURLConsumer) DeepCopy() *TransportURLConsumer {
+	if in == nil {
+		return nil
+	}
+	out := new(TransportURLConsumer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransportURLExternal) DeepCopyInto(out *TransportURLExternal) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransportURLExternal.
+func (in *TransportURLExternal) DeepCopy() *TransportURLExternal {
+	if in == nil {
+		return nil
+	}
+	out := new(TransportURLExternal)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TransportURLList) DeepCopyInto(out *TransportURLList) {
 	*out = *in
@@ -85,9 +415,96 @@ func (in *TransportURLList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransportURLNotifications) DeepCopyInto(out *TransportURLNotifications) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransportURLNotifications.
+func (in *TransportURLNotifications) DeepCopy() *TransportURLNotifications {
+	if in == nil {
+		return nil
+	}
+	out := new(TransportURLNotifications)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransportURLPermissions) DeepCopyInto(out *TransportURLPermissions) {
+	*out = *in
+	if in.Configure != nil {
+		in, out := &in.Configure, &out.Configure
+		*out = new(string)
+		**out = **in
+	}
+	if in.Write != nil {
+		in, out := &in.Write, &out.Write
+		*out = new(string)
+		**out = **in
+	}
+	if in.Read != nil {
+		in, out := &in.Read, &out.Read
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransportURLPermissions.
+func (in *TransportURLPermissions) DeepCopy() *TransportURLPermissions {
+	if in == nil {
+		return nil
+	}
+	out := new(TransportURLPermissions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransportURLSecretKeys) DeepCopyInto(out *TransportURLSecretKeys) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransportURLSecretKeys.
+func (in *TransportURLSecretKeys) DeepCopy() *TransportURLSecretKeys {
+	if in == nil {
+		return nil
+	}
+	out := new(TransportURLSecretKeys)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TransportURLSpec) DeepCopyInto(out *TransportURLSpec) {
 	*out = *in
+	if in.AdditionalRabbitmqClusterNames != nil {
+		in, out := &in.AdditionalRabbitmqClusterNames, &out.AdditionalRabbitmqClusterNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.External != nil {
+		in, out := &in.External, &out.External
+		*out = new(TransportURLExternal)
+		**out = **in
+	}
+	if in.ClientCert != nil {
+		in, out := &in.ClientCert, &out.ClientCert
+		*out = new(TransportURLClientCert)
+		**out = **in
+	}
+	if in.Connection != nil {
+		in, out := &in.Connection, &out.Connection
+		*out = new(TransportURLConnection)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Notifications != nil {
+		in, out := &in.Notifications, &out.Notifications
+		*out = new(TransportURLNotifications)
+		**out = **in
+	}
+	in.Permissions.DeepCopyInto(&out.Permissions)
+	out.SecretKeys = in.SecretKeys
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransportURLSpec.
@@ -110,6 +527,15 @@ func (in *TransportURLStatus) DeepCopyInto(out *TransportURLStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.RetiringSince != nil {
+		in, out := &in.RetiringSince, &out.RetiringSince
+		*out = (*in).DeepCopy()
+	}
+	if in.Consumers != nil {
+		in, out := &in.Consumers, &out.Consumers
+		*out = make([]TransportURLConsumer, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransportURLStatus.
@@ -0,0 +1,55 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+)
+
+// Condition Types used by API objects.
+const (
+	// RebalancingCondition indicates if the replica placement satisfies the
+	// requested topology spread, it is False while replicas get re-spread
+	RebalancingCondition condition.Type = "Rebalancing"
+)
+
+// Common Messages used by API objects.
+const (
+	// RebalancingInitMessage
+	RebalancingInitMessage = "Replica placement not checked"
+
+	// RebalancingErrorMessage
+	RebalancingErrorMessage = "Rebalancing error occured %s"
+
+	// RebalancingWaitingMessage
+	RebalancingWaitingMessage = "Topology skew %d exceeds %d, waiting for all replicas to be ready before rebalancing"
+
+	// RebalancingInProgressMessage
+	RebalancingInProgressMessage = "Topology skew %d exceeds %d, rebalancing replica %s"
+
+	// RebalancingMasterOnlyMessage
+	RebalancingMasterOnlyMessage = "Topology skew %d exceeds %d, but only the master can be moved, waiting for a failover"
+
+	// RebalancingStuckMessage
+	RebalancingStuckMessage = "Topology skew %d exceeds %d, but replica %s got scheduled into domain %s again, waiting for the schedulable domains to change"
+
+	// RebalancingNotRequestedMessage
+	RebalancingNotRequestedMessage = "Topology spread not requested"
+
+	// RebalancingReadyMessage
+	RebalancingReadyMessage = "Replica placement satisfies the topology spread"
+)
@@ -43,6 +43,70 @@ type RedisSpec struct {
 	// +operator-sdk:csv:customresourcedefinitions:type=spec
 	// TLS settings for Redis service and internal Redis replication
 	TLS tls.SimpleService `json:"tls,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=cache;coordination
+	// +kubebuilder:default=cache
+	// Profile - workload profile of the Redis instance. "cache" for data that can be
+	// rebuilt at any time, "coordination" for data like locks that must not get lost.
+	Profile RedisProfile `json:"profile,omitempty"`
+	// +kubebuilder:validation:Optional
+	// Persistence - how Redis persists its data to disk
+	Persistence RedisPersistence `json:"persistence,omitempty"`
+	// +kubebuilder:validation:Optional
+	// TopologySpread - when set, the replicas get spread across the topology domains, e.g.
+	// zones, and are rebalanced one at a time when nodes or domains get added or removed
+	TopologySpread *RedisTopologySpread `json:"topologySpread,omitempty"`
+}
+
+// RedisTopologySpread defines how the replicas get spread across topology domains
+type RedisTopologySpread struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default="topology.kubernetes.io/zone"
+	// TopologyKey - node label defining the topology domains
+	TopologyKey string `json:"topologyKey,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	// MaxSkew - maximum allowed difference of the number of replicas between two domains
+	MaxSkew int32 `json:"maxSkew,omitempty"`
+}
+
+// RedisProfile - workload profile of a Redis instance
+type RedisProfile string
+
+const (
+	// RedisProfileCache - cached data which can be rebuilt
+	RedisProfileCache RedisProfile = "cache"
+	// RedisProfileCoordination - coordination data, e.g. distributed locks
+	RedisProfileCoordination RedisProfile = "coordination"
+)
+
+// RedisPersistenceMode - persistence mode of a Redis instance
+type RedisPersistenceMode string
+
+const (
+	// RedisPersistenceNone - no persistence
+	RedisPersistenceNone RedisPersistenceMode = "none"
+	// RedisPersistenceRDB - point-in-time snapshots
+	RedisPersistenceRDB RedisPersistenceMode = "rdb"
+	// RedisPersistenceAOF - append only file
+	RedisPersistenceAOF RedisPersistenceMode = "aof"
+	// RedisPersistenceMixed - append only file with a RDB preamble and snapshots
+	RedisPersistenceMixed RedisPersistenceMode = "mixed"
+)
+
+// RedisPersistence defines the durability settings of a Redis instance
+type RedisPersistence struct {
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=none;rdb;aof;mixed
+	// +kubebuilder:default=rdb
+	// Mode - none, rdb (snapshots), aof (append only file) or mixed (aof with rdb preamble)
+	Mode RedisPersistenceMode `json:"mode,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=always;everysec;no
+	// +kubebuilder:default=everysec
+	// AppendFsync - fsync policy of the append only file, used by the aof and mixed modes
+	AppendFsync string `json:"appendFsync,omitempty"`
 }
 
 // RedisStatus defines the observed state of Redis
@@ -51,6 +115,21 @@ type RedisStatus struct {
 	Hash map[string]string `json:"hash,omitempty"`
 	// Conditions
 	Conditions condition.Conditions `json:"conditions,omitempty" optional:"true"`
+	// SkippedResources - owned resources not managed by the operator, because they
+	// are opted out via a <group>/manage-<resource>: "false" annotation on the CR
+	SkippedResources []string `json:"skippedResources,omitempty" optional:"true"`
+	// LastRebalance - the replica last recreated to reduce the topology skew
+	LastRebalance *RedisRebalance `json:"lastRebalance,omitempty" optional:"true"`
+}
+
+// RedisRebalance - a replica recreated to reduce the topology skew
+type RedisRebalance struct {
+	// Pod - name of the recreated replica
+	Pod string `json:"pod"`
+	// Domain - topology domain the replica got recreated from
+	Domain string `json:"domain"`
+	// Domains - topology domains with nodes the replicas can be scheduled to at the time
+	Domains []string `json:"domains,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -99,6 +178,16 @@ func (instance Redis) RbacResourceName() string {
 	return "redis-" + instance.Name
 }
 
+// RDBEnabled - returns true if the persistence mode uses RDB snapshots
+func (p RedisPersistence) RDBEnabled() bool {
+	return p.Mode == "" || p.Mode == RedisPersistenceRDB || p.Mode == RedisPersistenceMixed
+}
+
+// AOFEnabled - returns true if the persistence mode uses the append only file
+func (p RedisPersistence) AOFEnabled() bool {
+	return p.Mode == RedisPersistenceAOF || p.Mode == RedisPersistenceMixed
+}
+
 // SetupDefaults - initializes any CRD field defaults based on environment variables (the defaulting mechanism itself is implemented via webhooks)
 func SetupDefaults() {
 	// Acquire environmental defaults and initialize Redis defaults with them
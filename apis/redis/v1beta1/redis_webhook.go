@@ -23,7 +23,11 @@ limitations under the License.
 package v1beta1
 
 import (
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -79,16 +83,39 @@ var _ webhook.Validator = &Redis{}
 func (r *Redis) ValidateCreate() error {
 	redislog.Info("validate create", "name", r.Name)
 
-	// TODO(user): fill in your validation logic upon object creation.
-	return nil
+	allErrs := r.Spec.ValidatePersistence(field.NewPath("spec"))
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("Redis").GroupKind(), r.Name, allErrs)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
 func (r *Redis) ValidateUpdate(old runtime.Object) error {
 	redislog.Info("validate update", "name", r.Name)
 
-	// TODO(user): fill in your validation logic upon object update.
-	return nil
+	allErrs := r.Spec.ValidatePersistence(field.NewPath("spec"))
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("Redis").GroupKind(), r.Name, allErrs)
+}
+
+// ValidatePersistence - validates the persistence mode against the workload profile
+func (spec *RedisSpec) ValidatePersistence(basePath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	// coordination data like locks must survive a restart of redis
+	if spec.Profile == RedisProfileCoordination && spec.Persistence.Mode == RedisPersistenceNone {
+		allErrs = append(allErrs, field.Invalid(
+			basePath.Child("persistence").Child("mode"),
+			spec.Persistence.Mode,
+			fmt.Sprintf("persistence mode %s is not supported with the %s profile", spec.Persistence.Mode, spec.Profile)))
+	}
+
+	return allErrs
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
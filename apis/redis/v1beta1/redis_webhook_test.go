@@ -0,0 +1,70 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestRedisValidatePersistence(t *testing.T) {
+	tests := []struct {
+		name      string
+		expectErr bool
+		spec      RedisSpec
+	}{
+		{
+			name:      "should succeed with cache profile and no persistence",
+			expectErr: false,
+			spec: RedisSpec{
+				Profile:     RedisProfileCache,
+				Persistence: RedisPersistence{Mode: RedisPersistenceNone},
+			},
+		},
+		{
+			name:      "should succeed with coordination profile and aof persistence",
+			expectErr: false,
+			spec: RedisSpec{
+				Profile:     RedisProfileCoordination,
+				Persistence: RedisPersistence{Mode: RedisPersistenceAOF, AppendFsync: "always"},
+			},
+		},
+		{
+			name:      "should fail with coordination profile and no persistence",
+			expectErr: true,
+			spec: RedisSpec{
+				Profile:     RedisProfileCoordination,
+				Persistence: RedisPersistence{Mode: RedisPersistenceNone},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			errs := tt.spec.ValidatePersistence(field.NewPath("spec"))
+			if tt.expectErr {
+				g.Expect(errs).NotTo(BeEmpty())
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
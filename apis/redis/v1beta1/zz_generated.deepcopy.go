@@ -100,6 +100,41 @@ func (in *RedisList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RedisPersistence) DeepCopyInto(out *RedisPersistence) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisPersistence.
+func (in *RedisPersistence) DeepCopy() *RedisPersistence {
+	if in == nil {
+		return nil
+	}
+	out := new(RedisPersistence)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RedisRebalance) DeepCopyInto(out *RedisRebalance) {
+	*out = *in
+	if in.Domains != nil {
+		in, out := &in.Domains, &out.Domains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisRebalance.
+func (in *RedisRebalance) DeepCopy() *RedisRebalance {
+	if in == nil {
+		return nil
+	}
+	out := new(RedisRebalance)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RedisSpec) DeepCopyInto(out *RedisSpec) {
 	*out = *in
@@ -109,6 +144,12 @@ func (in *RedisSpec) DeepCopyInto(out *RedisSpec) {
 		**out = **in
 	}
 	in.TLS.DeepCopyInto(&out.TLS)
+	out.Persistence = in.Persistence
+	if in.TopologySpread != nil {
+		in, out := &in.TopologySpread, &out.TopologySpread
+		*out = new(RedisTopologySpread)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisSpec.
@@ -138,6 +179,16 @@ func (in *RedisStatus) DeepCopyInto(out *RedisStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.SkippedResources != nil {
+		in, out := &in.SkippedResources, &out.SkippedResources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastRebalance != nil {
+		in, out := &in.LastRebalance, &out.LastRebalance
+		*out = new(RedisRebalance)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisStatus.
@@ -149,3 +200,18 @@ func (in *RedisStatus) DeepCopy() *RedisStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RedisTopologySpread) DeepCopyInto(out *RedisTopologySpread) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisTopologySpread.
+func (in *RedisTopologySpread) DeepCopy() *RedisTopologySpread {
+	if in == nil {
+		return nil
+	}
+	out := new(RedisTopologySpread)
+	in.DeepCopyInto(out)
+	return out
+}
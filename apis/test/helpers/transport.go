@@ -58,3 +58,47 @@ func (tc *TestHelper) AssertTransportURLDoesNotExist(name types.NamespacedName)
 		g.Expect(k8s_errors.IsNotFound(err)).To(gomega.BeTrue())
 	}, tc.Timeout, tc.Interval).Should(gomega.Succeed())
 }
+
+// GetMessagingPolicy retrieves a MessagingPolicy resource with the specified name.
+//
+// Example usage:
+//
+//	th.GetMessagingPolicy(types.NamespacedName{Name: "test-policy", Namespace: "test-namespace"})
+func (tc *TestHelper) GetMessagingPolicy(name types.NamespacedName) *rabbitmqv1.MessagingPolicy {
+	instance := &rabbitmqv1.MessagingPolicy{}
+	gomega.Eventually(func(g gomega.Gomega) {
+		g.Expect(tc.K8sClient.Get(tc.Ctx, name, instance)).Should(gomega.Succeed())
+	}, tc.Timeout, tc.Interval).Should(gomega.Succeed())
+	return instance
+}
+
+// AssertMessagingPolicyDoesNotExist ensures the MessagingPolicy resource does not exist in a k8s cluster.
+func (tc *TestHelper) AssertMessagingPolicyDoesNotExist(name types.NamespacedName) {
+	instance := &rabbitmqv1.MessagingPolicy{}
+	gomega.Eventually(func(g gomega.Gomega) {
+		err := tc.K8sClient.Get(tc.Ctx, name, instance)
+		g.Expect(k8s_errors.IsNotFound(err)).To(gomega.BeTrue())
+	}, tc.Timeout, tc.Interval).Should(gomega.Succeed())
+}
+
+// GetMessagingLink retrieves a MessagingLink resource with the specified name.
+//
+// Example usage:
+//
+//	th.GetMessagingLink(types.NamespacedName{Name: "test-link", Namespace: "test-namespace"})
+func (tc *TestHelper) GetMessagingLink(name types.NamespacedName) *rabbitmqv1.MessagingLink {
+	instance := &rabbitmqv1.MessagingLink{}
+	gomega.Eventually(func(g gomega.Gomega) {
+		g.Expect(tc.K8sClient.Get(tc.Ctx, name, instance)).Should(gomega.Succeed())
+	}, tc.Timeout, tc.Interval).Should(gomega.Succeed())
+	return instance
+}
+
+// AssertMessagingLinkDoesNotExist ensures the MessagingLink resource does not exist in a k8s cluster.
+func (tc *TestHelper) AssertMessagingLinkDoesNotExist(name types.NamespacedName) {
+	instance := &rabbitmqv1.MessagingLink{}
+	gomega.Eventually(func(g gomega.Gomega) {
+		err := tc.K8sClient.Get(tc.Ctx, name, instance)
+		g.Expect(k8s_errors.IsNotFound(err)).To(gomega.BeTrue())
+	}, tc.Timeout, tc.Interval).Should(gomega.Succeed())
+}
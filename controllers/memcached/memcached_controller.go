@@ -18,41 +18,98 @@ package memcached
 
 import (
 	"context"
+	cryptotls "crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/openstack-k8s-operators/lib-common/modules/common"
 	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
 	configmap "github.com/openstack-k8s-operators/lib-common/modules/common/configmap"
+	commondaemonset "github.com/openstack-k8s-operators/lib-common/modules/common/daemonset"
 	common_rbac "github.com/openstack-k8s-operators/lib-common/modules/common/rbac"
+	oko_secret "github.com/openstack-k8s-operators/lib-common/modules/common/secret"
 	commonservice "github.com/openstack-k8s-operators/lib-common/modules/common/service"
 	commonstatefulset "github.com/openstack-k8s-operators/lib-common/modules/common/statefulset"
 
 	env "github.com/openstack-k8s-operators/lib-common/modules/common/env"
 	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	labels "github.com/openstack-k8s-operators/lib-common/modules/common/labels"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/tls"
 	util "github.com/openstack-k8s-operators/lib-common/modules/common/util"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 
 	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	memcachedv1 "github.com/openstack-k8s-operators/infra-operator/apis/memcached/v1beta1"
+	managed "github.com/openstack-k8s-operators/infra-operator/pkg/managed"
 	memcached "github.com/openstack-k8s-operators/infra-operator/pkg/memcached"
 )
 
+// certRotationInterval - interval to check the progress of the roll of the
+// pods after a TLS certificate rotation
+const certRotationInterval = 10 * time.Second
+
+// fields to index to reconcile on CR change
+const (
+	serviceSecretNameField = ".spec.tls.genericService.SecretName"
+	caSecretNameField      = ".spec.tls.ca.caBundleSecretName"
+)
+
+var (
+	allWatchFields = []string{
+		serviceSecretNameField,
+		caSecretNameField,
+	}
+)
+
 // Reconciler reconciles a Memcached object
 type Reconciler struct {
 	client.Client
-	Kclient kubernetes.Interface
-	Scheme  *runtime.Scheme
+	Kclient  kubernetes.Interface
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
 }
 
+// Reasons of the Events recorded on the Memcached CR, to correlate cache
+// invalidations on the consumer side with the operator actions
+const (
+	// ScaledReason - a StatefulSet got scaled
+	ScaledReason = "Scaled"
+	// ScaleInDrainingReason - departing replicas are drained before scale-in
+	ScaleInDrainingReason = "ScaleInDraining"
+	// ServerListChangedReason - the published server list changed
+	ServerListChangedReason = "ServerListChanged"
+	// ConfigChangedReason - the input hash changed and the pods get rolled
+	ConfigChangedReason = "ConfigChanged"
+	// CertRotationReason - the pods get rolled to load a rotated TLS certificate
+	CertRotationReason = "CertRotation"
+)
+
 // GetLogger returns a logger object with a prefix of "controller.name" and additional controller context fields
 func (r *Reconciler) GetLogger(ctx context.Context) logr.Logger {
 	return log.FromContext(ctx).WithName("Controllers").WithName("memcached")
@@ -65,11 +122,20 @@ func (r *Reconciler) GetLogger(ctx context.Context) logr.Logger {
 
 // RBAC for statefulsets and their pods
 // +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;
+// +kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;patch
+// +kubebuilder:rbac:groups=core,resources=pods/eviction,verbs=create
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 // RBAC for services
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete;
 
+// RBAC for the server list secret
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete;
+
+// RBAC for networkpolicies
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete;
+
 // service account, role, rolebinding
 // +kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;create;update
 // +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=roles,verbs=get;list;watch;create;update
@@ -109,6 +175,12 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ct
 
 	// Always patch the instance status when exiting this function so we can persist any changes.
 	defer func() {
+		// the Degraded and Paused conditions do not affect the Ready condition
+		informational := []*condition.Condition{}
+		for _, t := range []condition.Type{memcachedv1.DegradedCondition, memcachedv1.PausedCondition} {
+			informational = append(informational, instance.Status.Conditions.Get(t))
+			instance.Status.Conditions.Remove(t)
+		}
 		// update the Ready condition based on the sub conditions
 		if instance.Status.Conditions.AllSubConditionIsTrue() {
 			instance.Status.Conditions.MarkTrue(
@@ -121,6 +193,9 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ct
 			instance.Status.Conditions.Set(
 				instance.Status.Conditions.Mirror(condition.ReadyCondition))
 		}
+		for _, c := range informational {
+			instance.Status.Conditions.Set(c)
+		}
 		err := helper.PatchInstance(ctx, instance)
 		if err != nil {
 			_err = err
@@ -139,6 +214,8 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ct
 			condition.UnknownCondition(condition.ExposeServiceReadyCondition, condition.InitReason, condition.ExposeServiceReadyInitMessage),
 			// configmap generation
 			condition.UnknownCondition(condition.ServiceConfigReadyCondition, condition.InitReason, condition.ServiceConfigReadyInitMessage),
+			// TLS cert secrets
+			condition.UnknownCondition(condition.TLSInputReadyCondition, condition.InitReason, condition.InputReadyInitMessage),
 			// memcache pods ready
 			condition.UnknownCondition(condition.DeploymentReadyCondition, condition.InitReason, condition.DeploymentReadyInitMessage),
 			// service account, role, rolebinding conditions
@@ -153,6 +230,16 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ct
 		return ctrl.Result{}, nil
 	}
 
+	// reconciliation paused, e.g. to debug with manual changes to the owned resources
+	if managed.IsPaused(instance, memcachedv1.GroupVersion.Group) {
+		Log.Info("Reconciliation paused", "annotation", managed.PausedAnnotationKey(memcachedv1.GroupVersion.Group))
+		paused := condition.TrueCondition(memcachedv1.PausedCondition, memcachedv1.PausedMessage)
+		paused.Reason = memcachedv1.PausedReason
+		instance.Status.Conditions.Set(paused)
+		return ctrl.Result{}, nil
+	}
+	instance.Status.Conditions.Remove(memcachedv1.PausedCondition)
+
 	if instance.Status.ServerList == nil {
 		instance.Status.ServerList = []string{}
 	}
@@ -185,9 +272,119 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ct
 		return rbacResult, nil
 	}
 
+	// resources opted out via annotation in favor of externally managed ones
+	serviceManaged := managed.IsManaged(instance, memcachedv1.GroupVersion.Group, managed.Service)
+	networkPolicyManaged := managed.IsManaged(instance, memcachedv1.GroupVersion.Group, managed.NetworkPolicy)
+	instance.Status.SkippedResources = []string{}
+	if !serviceManaged {
+		instance.Status.SkippedResources = append(instance.Status.SkippedResources,
+			managed.SkippedResource("Service", instance.Name))
+	}
+	if !networkPolicyManaged {
+		instance.Status.SkippedResources = append(instance.Status.SkippedResources,
+			managed.SkippedResource("NetworkPolicy", instance.Name))
+	}
+
+	var ipFamilies []corev1.IPFamily
+	if serviceManaged {
+		// Service to expose Memcached pods, created first as the listen
+		// addresses depend on its IP families
+		commonsvc, err := commonservice.NewService(memcached.InstanceService(instance), time.Duration(5)*time.Second, nil)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.ExposeServiceReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				condition.ExposeServiceReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+		sres, serr := commonsvc.CreateOrPatch(ctx, helper)
+		if serr != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.ExposeServiceReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				condition.ExposeServiceReadyErrorMessage,
+				serr.Error()))
+			return sres, serr
+		} else if (sres != ctrl.Result{}) {
+			return sres, nil
+		}
+
+		err = r.reconcileProxyService(ctx, helper, instance)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.ExposeServiceReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				condition.ExposeServiceReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+
+		err = r.reconcilePodServices(ctx, helper, instance)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.ExposeServiceReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				condition.ExposeServiceReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+		ipFamilies = commonsvc.GetIPFamilies()
+	} else {
+		// the externally managed service defines the IP families
+		svc := &corev1.Service{}
+		err = r.Client.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, svc)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.ExposeServiceReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				condition.ExposeServiceReadyErrorMessage,
+				fmt.Sprintf("error getting externally managed service %s: %s", instance.Name, err.Error())))
+			return ctrl.Result{}, err
+		}
+		ipFamilies = svc.Spec.IPFamilies
+		if len(ipFamilies) == 0 {
+			ipFamilies = []corev1.IPFamily{corev1.IPv4Protocol}
+		}
+	}
+
+	// Hash of all resources that may cause a service restart
+	inputHashEnv := make(map[string]env.Setter)
+
+	// Check and hash inputs
+	var certHash, caHash string
+	specTLS := &instance.Spec.TLS
+	if specTLS.Enabled() {
+		// not part of the input hash, a rotated certificate is rolled out
+		// by reconcileCertRotation one pod at a time
+		certHash, _, err = specTLS.GenericService.ValidateCertSecret(ctx, helper, instance.Namespace)
+	}
+	if err == nil && specTLS.Ca.CaBundleSecretName != "" {
+		caName := types.NamespacedName{
+			Name:      specTLS.Ca.CaBundleSecretName,
+			Namespace: instance.Namespace,
+		}
+		caHash, _, err = tls.ValidateCACertSecret(ctx, helper.GetClient(), caName)
+		inputHashEnv["CA"] = env.SetValue(caHash)
+	}
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			condition.TLSInputReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			condition.TLSInputErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, fmt.Errorf("error calculating input hash: %w", err)
+	}
+	instance.Status.Conditions.MarkTrue(condition.TLSInputReadyCondition, condition.InputReadyMessage)
+
 	// Memcached config maps
-	configMapVars := make(map[string]env.Setter)
-	err = r.generateConfigMaps(ctx, helper, instance, &configMapVars)
+	err = r.generateConfigMaps(ctx, helper, instance, ipFamilies, &inputHashEnv)
 	if err != nil {
 		instance.Status.Conditions.Set(condition.FalseCondition(
 			condition.ServiceConfigReadyCondition,
@@ -199,8 +396,46 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ct
 	}
 	instance.Status.Conditions.MarkTrue(condition.ServiceConfigReadyCondition, condition.ServiceConfigReadyMessage)
 
-	// Service to expose Memcached pods
-	commonsvc, err := commonservice.NewService(memcached.HeadlessService(instance), time.Duration(5)*time.Second, nil)
+	//
+	// create hash over all the different input resources to identify if any those changed
+	// and a restart/recreate is required.
+	//
+	hashOfHashes, err := util.HashOfInputHashes(inputHashEnv)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if hashMap, changed := util.SetHash(instance.Status.Hash, common.InputHashName, hashOfHashes); changed {
+		if instance.Status.Hash[common.InputHashName] != "" {
+			r.Recorder.Event(instance, corev1.EventTypeNormal, ConfigChangedReason,
+				"Input hash changed, rolling the pods")
+		}
+		// Hash changed and instance status should be updated (which will be done by main defer func),
+		// so update all the input hashes and return to reconcile again
+		instance.Status.Hash = hashMap
+		for k, s := range inputHashEnv {
+			var envVar corev1.EnvVar
+			s(&envVar)
+			instance.Status.Hash[k] = envVar.Value
+		}
+		util.LogForObject(helper, fmt.Sprintf("Input hash changed %s", hashOfHashes), instance)
+		return ctrl.Result{}, nil
+	}
+
+	// the server lists use the primary IP family of the service
+	ipFamily := ipFamilies[0]
+	serverList, serverListWithInet := r.GetServerLists(instance, ipFamily)
+	instance.Status.ServerList = serverList
+	instance.Status.ServerListWithInet = serverListWithInet
+	if instance.PlaintextPortEnabled() {
+		instance.Status.PlaintextServerList, instance.Status.PlaintextServerListWithInet = memcached.InstanceServerLists(
+			instance, ipFamily, memcached.PlaintextPort(instance), "")
+	} else {
+		instance.Status.PlaintextServerList = nil
+		instance.Status.PlaintextServerListWithInet = nil
+	}
+
+	// Secret publishing the server list for the service operators
+	serverListHash, _, err := oko_secret.CreateOrPatchSecret(ctx, helper, instance, memcached.ServerListSecret(instance, ipFamily))
 	if err != nil {
 		instance.Status.Conditions.Set(condition.FalseCondition(
 			condition.ExposeServiceReadyCondition,
@@ -210,41 +445,796 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ct
 			err.Error()))
 		return ctrl.Result{}, err
 	}
-	sres, serr := commonsvc.CreateOrPatch(ctx, helper)
-	if serr != nil {
+	instance.Status.ServerListSecret = instance.ServerListSecretName()
+	// consumers can track this hash to notice topology changes, e.g. when
+	// the instance got scaled via the scale subresource
+	if oldHash := instance.Status.Hash[memcached.ServerListHashName]; oldHash != "" && oldHash != serverListHash {
+		r.Recorder.Eventf(instance, corev1.EventTypeNormal, ServerListChangedReason,
+			"Server list changed to %s", strings.Join(serverList, ","))
+	}
+	instance.Status.Hash[memcached.ServerListHashName] = serverListHash
+
+	instance.Status.Conditions.MarkTrue(condition.ExposeServiceReadyCondition, condition.ExposeServiceReadyMessage)
+
+	// NetworkPolicy limiting the access to the memcached pods
+	if networkPolicyManaged {
+		err = r.reconcileNetworkPolicy(ctx, helper, instance)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.ExposeServiceReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				condition.ExposeServiceReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Scheduled rolling restart
+	restartAfter, err := r.reconcileRestartSchedule(ctx, instance)
+	if err != nil {
 		instance.Status.Conditions.Set(condition.FalseCondition(
-			condition.ExposeServiceReadyCondition,
+			condition.DeploymentReadyCondition,
 			condition.ErrorReason,
 			condition.SeverityWarning,
-			condition.ExposeServiceReadyErrorMessage,
+			condition.DeploymentReadyErrorMessage,
 			err.Error()))
-		return sres, serr
+		return ctrl.Result{}, err
 	}
 
-	// TODO: We have to make sure this works properly in dual stack env (if we support it)
-	ipFamily := commonsvc.GetIPFamilies()[0]
-	serverList, serverListWithInet := r.GetServerLists(instance, ipFamily)
-	instance.Status.ServerList = serverList
-	instance.Status.ServerListWithInet = serverListWithInet
+	var drainAfter time.Duration
+	var expectedPods int32
+	if instance.DaemonSetMode() {
+		// Daemonset for a node-local replica on every selected node
+		ds := memcached.DaemonSet(instance, instance.Status.Hash[common.InputHashName])
+		commondaemonset := commondaemonset.NewDaemonSet(ds, time.Duration(5)*time.Second)
+		dsres, dserr := commondaemonset.CreateOrPatch(ctx, helper)
+		if dserr != nil {
+			return dsres, dserr
+		}
 
-	instance.Status.Conditions.MarkTrue(condition.ExposeServiceReadyCondition, condition.ExposeServiceReadyMessage)
+		//
+		// Reconstruct the state of the memcached resource based on the daemonset and its pods
+		//
+		instance.Status.ReadyCount = commondaemonset.GetDaemonSet().Status.NumberReady
+		expectedPods = commondaemonset.GetDaemonSet().Status.DesiredNumberScheduled
+	} else {
+		// Graceful drain of the departing replicas on scale-in
+		var replicas int32
+		replicas, drainAfter, err = r.drainReplicas(ctx, instance, instance.Name, *instance.Spec.Replicas, &instance.Status.DrainingSince)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.DeploymentReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				condition.DeploymentReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+
+		// Statefulset for stable names
+		sts := memcached.StatefulSet(instance, instance.Status.Hash[common.InputHashName])
+		sts.Spec.Replicas = &replicas
+		commonstatefulset := commonstatefulset.NewStatefulSet(sts, time.Duration(5)*time.Second)
+		sfres, sferr := commonstatefulset.CreateOrPatch(ctx, helper)
+		if sferr != nil {
+			return sfres, sferr
+		}
 
-	// Statefulset for stable names
-	commonstatefulset := commonstatefulset.NewStatefulSet(memcached.StatefulSet(instance), time.Duration(5)*time.Second)
-	sfres, sferr := commonstatefulset.CreateOrPatch(ctx, helper)
-	if sferr != nil {
-		return sfres, sferr
+		//
+		// Reconstruct the state of the memcached resource based on the statefulset and its pods
+		//
+		instance.Status.ReadyCount = commonstatefulset.GetStatefulSet().Status.ReadyReplicas
+		expectedPods = *instance.Spec.Replicas
+		for _, pool := range instance.Spec.Pools {
+			expectedPods += *pool.Replicas
+		}
 	}
+	instance.Status.Selector = memcached.Selector(instance)
 
-	//
-	// Reconstruct the state of the memcached resource based on the statefulset and its pods
-	//
-	instance.Status.ReadyCount = commonstatefulset.GetStatefulSet().Status.ReadyReplicas
-	if instance.Status.ReadyCount > 0 {
+	// Additional memcached pools
+	poolsReady, poolDrainAfter, err := r.reconcilePools(ctx, helper, instance, ipFamily, serviceManaged)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			condition.DeploymentReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			condition.DeploymentReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+
+	if instance.Status.ReadyCount > 0 && poolsReady {
 		instance.Status.Conditions.MarkTrue(condition.DeploymentReadyCondition, condition.DeploymentReadyMessage)
 	}
 
-	return ctrl.Result{}, nil
+	// Roll the pods after a TLS certificate rotation
+	rotateAfter, err := r.reconcileCertRotation(ctx, instance, certHash, expectedPods)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			condition.DeploymentReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			condition.DeploymentReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+
+	// Stats of the replicas
+	statsAfter, err := r.reconcileStats(ctx, helper, instance)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			condition.DeploymentReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			condition.DeploymentReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+
+	setDegradedCondition(instance, expectedPods)
+
+	return ctrl.Result{RequeueAfter: minRequeueAfter(restartAfter, drainAfter, poolDrainAfter, rotateAfter, statsAfter)}, nil
+}
+
+// minRequeueAfter returns the shortest non zero duration, or zero if none is set
+func minRequeueAfter(durations ...time.Duration) time.Duration {
+	var requeueAfter time.Duration
+	for _, d := range durations {
+		if d > 0 && (requeueAfter == 0 || d < requeueAfter) {
+			requeueAfter = d
+		}
+	}
+	return requeueAfter
+}
+
+// drainReplicas returns the replicas to set on the StatefulSet name. When
+// the desired replicas are lower than the current ones, the departing replicas
+// are taken out of rotation and kept until the drain period elapsed, tracked
+// via drainingSince. The second return value is the remaining drain period.
+func (r *Reconciler) drainReplicas(
+	ctx context.Context,
+	instance *memcachedv1.Memcached,
+	name string,
+	desired int32,
+	drainingSince **metav1.Time,
+) (int32, time.Duration, error) {
+	Log := r.GetLogger(ctx)
+
+	sts := &appsv1.StatefulSet{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: instance.Namespace}, sts)
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return 0, 0, err
+	}
+	if k8s_errors.IsNotFound(err) || sts.Spec.Replicas == nil {
+		*drainingSince = nil
+		return desired, 0, nil
+	}
+	if desired >= *sts.Spec.Replicas || instance.Spec.ScaleInDrainSeconds == 0 {
+		if *drainingSince != nil {
+			// the scale-in got reverted, put the replicas back into rotation
+			err = r.setPodsServing(ctx, instance, name, 0, *sts.Spec.Replicas, true)
+			if err != nil {
+				return 0, 0, err
+			}
+		}
+		*drainingSince = nil
+		r.recordScaling(instance, name, *sts.Spec.Replicas, desired)
+		return desired, 0, nil
+	}
+
+	// mark the departing replicas not ready for the Services, the desired
+	// replicas might have changed while draining
+	err = r.setPodsServing(ctx, instance, name, 0, desired, true)
+	if err != nil {
+		return 0, 0, err
+	}
+	err = r.setPodsServing(ctx, instance, name, desired, *sts.Spec.Replicas, false)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	now := time.Now()
+	if *drainingSince == nil {
+		*drainingSince = &metav1.Time{Time: now}
+		msg := fmt.Sprintf("Draining replicas %d-%d of %s for %ds before scale-in",
+			desired, *sts.Spec.Replicas-1, name, instance.Spec.ScaleInDrainSeconds)
+		Log.Info(msg)
+		r.Recorder.Event(instance, corev1.EventTypeNormal, ScaleInDrainingReason, msg)
+	}
+
+	deadline := (*drainingSince).Add(time.Duration(instance.Spec.ScaleInDrainSeconds) * time.Second)
+	if now.Before(deadline) {
+		return *sts.Spec.Replicas, deadline.Sub(now), nil
+	}
+
+	*drainingSince = nil
+	r.recordScaling(instance, name, *sts.Spec.Replicas, desired)
+	return desired, 0, nil
+}
+
+// setPodsServing adds or removes the Service selector label of the pods of the
+// StatefulSet name with an ordinal from first up to but excluding last, which
+// puts them into or takes them out of the endpoints of the memcached Services
+func (r *Reconciler) setPodsServing(
+	ctx context.Context,
+	instance *memcachedv1.Memcached,
+	name string,
+	first int32,
+	last int32,
+	serving bool,
+) error {
+	Log := r.GetLogger(ctx)
+
+	for ordinal := first; ordinal < last; ordinal++ {
+		pod := &corev1.Pod{}
+		err := r.Client.Get(ctx, types.NamespacedName{
+			Name:      fmt.Sprintf("%s-%d", name, ordinal),
+			Namespace: instance.Namespace,
+		}, pod)
+		if k8s_errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if _, ok := pod.Labels[memcached.ServiceSelectorLabel]; ok == serving {
+			continue
+		}
+
+		patch := client.MergeFrom(pod.DeepCopy())
+		if serving {
+			if pod.Labels == nil {
+				pod.Labels = map[string]string{}
+			}
+			pod.Labels[memcached.ServiceSelectorLabel] = instance.Name
+		} else {
+			delete(pod.Labels, memcached.ServiceSelectorLabel)
+		}
+		err = r.Client.Patch(ctx, pod, patch)
+		if err != nil && !k8s_errors.IsNotFound(err) {
+			return fmt.Errorf("error patching the labels of pod %s: %w", pod.Name, err)
+		}
+		if serving {
+			Log.Info(fmt.Sprintf("Pod %s put back into rotation", pod.Name))
+		} else {
+			Log.Info(fmt.Sprintf("Pod %s taken out of rotation for the scale-in drain", pod.Name))
+		}
+	}
+
+	return nil
+}
+
+// recordScaling records an Event if the StatefulSet name gets scaled from
+// current to desired replicas
+func (r *Reconciler) recordScaling(instance *memcachedv1.Memcached, name string, current int32, desired int32) {
+	if current == desired {
+		return
+	}
+	r.Recorder.Eventf(instance, corev1.EventTypeNormal, ScaledReason,
+		"Scaling %s from %d to %d replicas", name, current, desired)
+}
+
+// reconcilePools creates the headless Service and StatefulSet of each pool,
+// deletes the ones of removed pools and returns true if every pool has ready
+// replicas, as well as the remaining drain period of pools being scaled in
+func (r *Reconciler) reconcilePools(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *memcachedv1.Memcached,
+	ipFamily corev1.IPFamily,
+	serviceManaged bool,
+) (bool, time.Duration, error) {
+	poolsReady := true
+	poolStatus := []memcachedv1.MemcachedPoolStatus{}
+	poolNames := map[string]bool{}
+	var drainAfter time.Duration
+
+	drainingSince := map[string]*metav1.Time{}
+	for _, status := range instance.Status.Pools {
+		drainingSince[status.Name] = status.DrainingSince
+	}
+
+	for _, pool := range instance.Spec.Pools {
+		poolNames[pool.Name] = true
+
+		if serviceManaged {
+			svc, err := commonservice.NewService(memcached.PoolHeadlessService(instance, pool), time.Duration(5)*time.Second, nil)
+			if err != nil {
+				return false, 0, err
+			}
+			if _, err := svc.CreateOrPatch(ctx, h); err != nil {
+				return false, 0, err
+			}
+		}
+
+		since := drainingSince[pool.Name]
+		replicas, poolDrainAfter, err := r.drainReplicas(ctx, instance, instance.PoolName(pool.Name), *pool.Replicas, &since)
+		if err != nil {
+			return false, 0, err
+		}
+		drainAfter = minRequeueAfter(drainAfter, poolDrainAfter)
+
+		poolSts := memcached.PoolStatefulSet(instance, pool, instance.Status.Hash[common.InputHashName])
+		poolSts.Spec.Replicas = &replicas
+		sts := commonstatefulset.NewStatefulSet(poolSts, time.Duration(5)*time.Second)
+		if _, err := sts.CreateOrPatch(ctx, h); err != nil {
+			return false, 0, err
+		}
+
+		status := memcachedv1.MemcachedPoolStatus{
+			Name:          pool.Name,
+			ReadyCount:    sts.GetStatefulSet().Status.ReadyReplicas,
+			DrainingSince: since,
+		}
+		status.ServerList, status.ServerListWithInet = memcached.ServerLists(
+			instance.PoolName(pool.Name), *pool.Replicas, ipFamily, memcached.Port(instance), "")
+		poolStatus = append(poolStatus, status)
+
+		if *pool.Replicas > 0 && status.ReadyCount == 0 {
+			poolsReady = false
+		}
+	}
+	instance.Status.Pools = poolStatus
+
+	// delete the resources of pools which got removed from the spec
+	poolSelector := client.MatchingLabels{
+		labels.GetOwnerUIDLabelSelector("memcached"): string(instance.GetUID()),
+	}
+	stsList := &appsv1.StatefulSetList{}
+	if err := r.Client.List(ctx, stsList, client.InNamespace(instance.Namespace), poolSelector, client.HasLabels{memcached.PoolLabel}); err != nil {
+		return false, 0, err
+	}
+	for i := range stsList.Items {
+		if !poolNames[stsList.Items[i].Labels[memcached.PoolLabel]] {
+			if err := r.Client.Delete(ctx, &stsList.Items[i]); err != nil && !k8s_errors.IsNotFound(err) {
+				return false, 0, err
+			}
+		}
+	}
+	if !serviceManaged {
+		return poolsReady, drainAfter, nil
+	}
+	svcList := &corev1.ServiceList{}
+	if err := r.Client.List(ctx, svcList, client.InNamespace(instance.Namespace), poolSelector, client.HasLabels{memcached.PoolLabel}); err != nil {
+		return false, 0, err
+	}
+	for i := range svcList.Items {
+		if !poolNames[svcList.Items[i].Labels[memcached.PoolLabel]] {
+			if err := r.Client.Delete(ctx, &svcList.Items[i]); err != nil && !k8s_errors.IsNotFound(err) {
+				return false, 0, err
+			}
+		}
+	}
+
+	return poolsReady, drainAfter, nil
+}
+
+// reconcileProxyService creates or updates the load balanced proxy Service if
+// requested in the spec and deletes it otherwise
+func (r *Reconciler) reconcileProxyService(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *memcachedv1.Memcached,
+) error {
+	if !instance.ProxyServiceEnabled() {
+		svc := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      instance.ProxyServiceName(),
+				Namespace: instance.Namespace,
+			},
+		}
+		err := r.Client.Delete(ctx, svc)
+		if err != nil && !k8s_errors.IsNotFound(err) {
+			return fmt.Errorf("error deleting service %s: %w", svc.Name, err)
+		}
+		return nil
+	}
+
+	svc, err := commonservice.NewService(memcached.ProxyService(instance), time.Duration(5)*time.Second, nil)
+	if err != nil {
+		return err
+	}
+	_, err = svc.CreateOrPatch(ctx, h)
+	return err
+}
+
+// reconcilePodServices creates or updates a Service per memcached pod if
+// requested in the spec and deletes the ones no longer needed
+func (r *Reconciler) reconcilePodServices(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *memcachedv1.Memcached,
+) error {
+	desired := map[string]bool{}
+	if instance.Spec.PerPodServices {
+		for i := int32(0); i < *instance.Spec.Replicas; i++ {
+			svc, err := commonservice.NewService(memcached.PodService(instance, i), time.Duration(5)*time.Second, nil)
+			if err != nil {
+				return err
+			}
+			_, err = svc.CreateOrPatch(ctx, h)
+			if err != nil {
+				return err
+			}
+			desired[memcached.PodServiceName(instance.Name, i)] = true
+		}
+	}
+
+	svcs := &corev1.ServiceList{}
+	err := r.Client.List(ctx, svcs,
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabels{memcached.PodServiceLabel: instance.Name})
+	if err != nil {
+		return fmt.Errorf("error listing per pod services of %s: %w", instance.Name, err)
+	}
+	for i := range svcs.Items {
+		svc := &svcs.Items[i]
+		if desired[svc.Name] {
+			continue
+		}
+		err = r.Client.Delete(ctx, svc)
+		if err != nil && !k8s_errors.IsNotFound(err) {
+			return fmt.Errorf("error deleting service %s: %w", svc.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// reconcileNetworkPolicy creates or updates the NetworkPolicy if requested
+// in the spec and deletes it otherwise
+func (r *Reconciler) reconcileNetworkPolicy(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *memcachedv1.Memcached,
+) error {
+	Log := r.GetLogger(ctx)
+
+	if instance.Spec.NetworkPolicy == nil {
+		np := &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      instance.Name,
+				Namespace: instance.Namespace,
+			},
+		}
+		err := r.Client.Delete(ctx, np)
+		if err != nil && !k8s_errors.IsNotFound(err) {
+			return fmt.Errorf("error deleting networkpolicy %s: %w", np.Name, err)
+		}
+		return nil
+	}
+
+	desired := memcached.NetworkPolicy(instance)
+	np := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      desired.Name,
+			Namespace: desired.Namespace,
+		},
+	}
+	op, err := controllerutil.CreateOrPatch(ctx, r.Client, np, func() error {
+		np.Labels = util.MergeStringMaps(np.Labels, desired.Labels)
+		np.Spec = desired.Spec
+
+		return controllerutil.SetControllerReference(instance, np, h.GetScheme())
+	})
+	if err != nil {
+		return fmt.Errorf("error create/updating networkpolicy %s: %w", np.Name, err)
+	}
+	if op != controllerutil.OperationResultNone {
+		Log.Info(fmt.Sprintf("NetworkPolicy %s successfully reconciled - operation: %s", np.Name, string(op)))
+	}
+
+	return nil
+}
+
+// reconcileRestartSchedule triggers a rolling restart when the scheduled time
+// has passed and returns the duration until the next scheduled restart. The
+// restart itself is performed by the statefulset rolling update which replaces
+// one pod at a time.
+func (r *Reconciler) reconcileRestartSchedule(
+	ctx context.Context,
+	instance *memcachedv1.Memcached,
+) (time.Duration, error) {
+	Log := r.GetLogger(ctx)
+
+	if instance.Spec.RestartSchedule == "" {
+		instance.Status.NextScheduledRestart = nil
+		return 0, nil
+	}
+
+	schedule, err := memcached.ParseSchedule(instance.Spec.RestartSchedule)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	if next := instance.Status.NextScheduledRestart; next != nil && !next.After(now) {
+		Log.Info("Triggering scheduled rolling restart", "schedule", instance.Spec.RestartSchedule)
+		lastRestart := metav1.NewTime(now)
+		instance.Status.LastScheduledRestart = &lastRestart
+	}
+
+	// always recalculate, the schedule might have changed
+	next := schedule.Next(now)
+	if next.IsZero() {
+		instance.Status.NextScheduledRestart = nil
+		return 0, nil
+	}
+	nextRestart := metav1.NewTime(next)
+	instance.Status.NextScheduledRestart = &nextRestart
+
+	return next.Sub(now), nil
+}
+
+// reconcileCertRotation rolls the memcached pods after the TLS certificate
+// got rotated. Instead of restarting all pods at once, one pod at a time gets
+// evicted, which honors PodDisruptionBudgets, once all expected pods are ready. The
+// status TLS cert hash is updated when all pods got rolled.
+func (r *Reconciler) reconcileCertRotation(
+	ctx context.Context,
+	instance *memcachedv1.Memcached,
+	certHash string,
+	expected int32,
+) (time.Duration, error) {
+	Log := r.GetLogger(ctx)
+
+	if certHash == "" || instance.Status.TLSCertHash == "" || instance.Status.TLSCertHash == certHash {
+		// new pods get created with the current certificate
+		instance.Status.TLSCertHash = certHash
+		instance.Status.TLSCertRotationStartedAt = nil
+		return 0, nil
+	}
+
+	if instance.Status.TLSCertRotationStartedAt == nil {
+		Log.Info("TLS certificate rotated, rolling the pods one at a time")
+		r.Recorder.Event(instance, corev1.EventTypeNormal, CertRotationReason,
+			"TLS certificate rotated, rolling the pods one at a time")
+		startedAt := metav1.NewTime(time.Now().Truncate(time.Second))
+		instance.Status.TLSCertRotationStartedAt = &startedAt
+	}
+
+	// pods of the main statefulset and of the pools
+	pods := &corev1.PodList{}
+	err := r.Client.List(ctx, pods,
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabels{"cr": instance.Name, "owner": "infra-operator"})
+	if err != nil {
+		return 0, fmt.Errorf("error listing pods of %s: %w", instance.Name, err)
+	}
+
+	outdated := []*corev1.Pod{}
+	ready := int32(0)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.DeletionTimestamp == nil && podReady(pod) {
+			ready++
+		}
+		if pod.DeletionTimestamp == nil && pod.CreationTimestamp.Before(instance.Status.TLSCertRotationStartedAt) {
+			outdated = append(outdated, pod)
+		}
+	}
+
+	if len(outdated) == 0 {
+		Log.Info("All pods rolled after the TLS certificate rotation")
+		instance.Status.TLSCertHash = certHash
+		instance.Status.TLSCertRotationStartedAt = nil
+		return 0, nil
+	}
+
+	// wait for the previously rolled pod to be ready again
+	if ready < expected || ready < int32(len(pods.Items)) {
+		return certRotationInterval, nil
+	}
+
+	// roll in reverse ordinal order, like the statefulset rolling update
+	sort.Slice(outdated, func(i, j int) bool {
+		return outdated[i].Name > outdated[j].Name
+	})
+	pod := outdated[0]
+	eviction := &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+		},
+	}
+	err = r.Client.SubResource("eviction").Create(ctx, pod, eviction)
+	switch {
+	case k8s_errors.IsTooManyRequests(err):
+		Log.Info(fmt.Sprintf("Eviction of pod %s blocked by a PodDisruptionBudget, retrying", pod.Name))
+	case err != nil && !k8s_errors.IsNotFound(err):
+		return 0, fmt.Errorf("error evicting pod %s: %w", pod.Name, err)
+	default:
+		Log.Info(fmt.Sprintf("Evicted pod %s to load the rotated TLS certificate", pod.Name))
+		r.Recorder.Eventf(instance, corev1.EventTypeNormal, CertRotationReason,
+			"Evicted pod %s to load the rotated TLS certificate", pod.Name)
+	}
+
+	return certRotationInterval, nil
+}
+
+// podReady returns true if the pod has the Ready condition
+func podReady(pod *corev1.Pod) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// reconcileStats collects the stats of all memcached replicas once the
+// collection interval elapsed and returns the duration until the next
+// collection
+func (r *Reconciler) reconcileStats(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *memcachedv1.Memcached,
+) (time.Duration, error) {
+	Log := r.GetLogger(ctx)
+
+	if instance.Spec.Stats == nil {
+		instance.Status.Stats = nil
+		instance.Status.StatsCollectedAt = nil
+		return 0, nil
+	}
+
+	interval := time.Duration(instance.Spec.Stats.IntervalSeconds) * time.Second
+	now := time.Now().UTC()
+	var elapsed time.Duration
+	if last := instance.Status.StatsCollectedAt; last != nil {
+		elapsed = now.Sub(last.Time)
+		if elapsed < interval {
+			return interval - elapsed, nil
+		}
+	}
+
+	tlsConfig, err := r.statsTLSConfig(ctx, h, instance)
+	if err != nil {
+		return 0, err
+	}
+
+	// pods of the main statefulset and of the pools
+	pods := &corev1.PodList{}
+	err = r.Client.List(ctx, pods,
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabels{"cr": instance.Name, "owner": "infra-operator"})
+	if err != nil {
+		return 0, fmt.Errorf("error listing pods of %s: %w", instance.Name, err)
+	}
+
+	previous := map[string]*memcachedv1.MemcachedPodStats{}
+	for i := range instance.Status.Stats {
+		previous[instance.Status.Stats[i].Pod] = &instance.Status.Stats[i]
+	}
+
+	targets := []memcached.StatsTarget{}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+			continue
+		}
+
+		port := memcached.Port(instance)
+		podTLSConfig := tlsConfig
+		if instance.PlaintextPortEnabled() {
+			port = memcached.PlaintextPort(instance)
+			podTLSConfig = nil
+		} else if podTLSConfig != nil {
+			podTLSConfig = podTLSConfig.Clone()
+			podTLSConfig.ServerName = statsServerName(instance, &pod)
+		}
+
+		targets = append(targets, memcached.StatsTarget{
+			Pod:       pod.Name,
+			Addr:      net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(int(port))),
+			TLSConfig: podTLSConfig,
+		})
+	}
+
+	stats := []memcachedv1.MemcachedPodStats{}
+	for pod, result := range memcached.CollectAllStats(ctx, targets) {
+		if result.Err != nil {
+			// e.g. the pod is not yet listening, keep the stats of the other replicas
+			Log.Info(fmt.Sprintf("Unable to collect stats of pod %s: %s", pod, result.Err))
+			continue
+		}
+
+		stats = append(stats, memcached.PodStats(pod, result.Stats, result.Slabs, previous[pod], elapsed))
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Pod < stats[j].Pod
+	})
+
+	instance.Status.Stats = stats
+	collectedAt := metav1.NewTime(now)
+	instance.Status.StatsCollectedAt = &collectedAt
+
+	return interval, nil
+}
+
+// setDegradedCondition sets the Degraded condition while only a subset of the
+// expected replicas is ready or the eviction rate of a replica exceeds the
+// configured maximum, and removes it otherwise
+func setDegradedCondition(instance *memcachedv1.Memcached, expected int32) {
+	ready := instance.Status.ReadyCount
+	for _, pool := range instance.Status.Pools {
+		ready += pool.ReadyCount
+	}
+
+	var worst *memcachedv1.MemcachedPodStats
+	if instance.Spec.Stats != nil {
+		for i, stats := range instance.Status.Stats {
+			if stats.EvictionsPerMinute > instance.Spec.Stats.MaxEvictionsPerMinute &&
+				(worst == nil || stats.EvictionsPerMinute > worst.EvictionsPerMinute) {
+				worst = &instance.Status.Stats[i]
+			}
+		}
+	}
+
+	var degraded *condition.Condition
+	switch {
+	// without any ready replica the instance is not ready at all
+	case ready > 0 && ready < expected:
+		degraded = condition.TrueCondition(
+			memcachedv1.DegradedCondition,
+			memcachedv1.DegradedReplicasMessage,
+			ready,
+			expected)
+		degraded.Reason = memcachedv1.ReplicasUnavailableReason
+	case worst != nil:
+		degraded = condition.TrueCondition(
+			memcachedv1.DegradedCondition,
+			memcachedv1.DegradedEvictionRateMessage,
+			worst.Pod,
+			worst.EvictionsPerMinute,
+			instance.Spec.Stats.MaxEvictionsPerMinute)
+		degraded.Reason = memcachedv1.EvictionRateReason
+	default:
+		instance.Status.Conditions.Remove(memcachedv1.DegradedCondition)
+		return
+	}
+	instance.Status.Conditions.Set(degraded)
+}
+
+// statsServerName returns the hostname the clients use to connect to the
+// memcached pod, which the TLS certificate is valid for
+func statsServerName(instance *memcachedv1.Memcached, pod *corev1.Pod) string {
+	switch {
+	case instance.DaemonSetMode():
+		return fmt.Sprintf("%s.%s.svc", instance.Name, pod.Namespace)
+	case instance.Spec.PerPodServices && pod.Spec.Subdomain == instance.Name:
+		return fmt.Sprintf("%s.%s.svc", pod.Name, pod.Namespace)
+	default:
+		return fmt.Sprintf("%s.%s.%s.svc", pod.Spec.Hostname, pod.Spec.Subdomain, pod.Namespace)
+	}
+}
+
+// statsTLSConfig returns the TLS config to connect to the memcached replicas
+// to collect their stats, nil if TLS is disabled
+func (r *Reconciler) statsTLSConfig(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *memcachedv1.Memcached,
+) (*cryptotls.Config, error) {
+	if !instance.Spec.TLS.Enabled() {
+		return nil, nil
+	}
+
+	tlsConfig := &cryptotls.Config{
+		MinVersion: cryptotls.VersionTLS12,
+	}
+	if instance.Spec.TLS.Ca.CaBundleSecretName != "" {
+		caSecret, _, err := oko_secret.GetSecret(ctx, h, instance.Spec.TLS.Ca.CaBundleSecretName, instance.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caSecret.Data[tls.CABundleKey]) {
+			return nil, fmt.Errorf("no CA certificates found in secret %s", caSecret.Name)
+		}
+	}
+
+	return tlsConfig, nil
 }
 
 // generateConfigMaps returns the config map resource for a galera instance
@@ -252,12 +1242,50 @@ func (r *Reconciler) generateConfigMaps(
 	ctx context.Context,
 	h *helper.Helper,
 	instance *memcachedv1.Memcached,
+	ipFamilies []corev1.IPFamily,
 	envVars *map[string]env.Setter,
 ) error {
 	Log := r.GetLogger(ctx)
 
-	templateParameters := make(map[string]interface{})
+	templateParameters := map[string]interface{}{
+		"memcachedPort":      memcached.Port(instance),
+		"memcachedTLS":       instance.Spec.TLS.Enabled(),
+		"memcachedTLSCA":     instance.Spec.TLS.Ca.CaBundleSecretName != "",
+		"memcachedCacheSize": memcached.CacheSizeMB(instance),
+		"memcachedUDP":       instance.Spec.UDPEnabled,
+		"memcachedUDPPort":   memcached.UDPPort(instance),
+	}
+
+	listen := []string{}
+	for _, addr := range memcached.ListenAddresses(ipFamilies) {
+		if instance.PlaintextPortEnabled() {
+			listen = append(listen,
+				fmt.Sprintf("%s:%d", addr, memcached.Port(instance)),
+				fmt.Sprintf("notls:%s:%d", addr, memcached.PlaintextPort(instance)))
+		} else {
+			listen = append(listen, addr)
+		}
+	}
+	templateParameters["memcachedListen"] = strings.Join(listen, ",")
+	templateParameters["memcachedExtraOptions"] = strings.Join(instance.Spec.ExtraOptions, " ")
+	templateParameters["memcachedExtstoreOptions"] = memcached.ExtstoreOptions(instance)
+	templateParameters["memcachedExtstorePath"] = memcached.ExtstorePath
+	if instance.Spec.MaxItemSizeKB != nil {
+		templateParameters["memcachedMaxItemSize"] = fmt.Sprintf("%dk", *instance.Spec.MaxItemSizeKB)
+	}
+	templateParameters["memcachedProxyOptions"] = memcached.ProxyOptions(instance)
+	if instance.Spec.IdleTimeoutSeconds != nil {
+		templateParameters["memcachedIdleTimeout"] = *instance.Spec.IdleTimeoutSeconds
+	}
+	if instance.Spec.MaxRequestsPerEvent != nil {
+		templateParameters["memcachedMaxRequestsPerEvent"] = *instance.Spec.MaxRequestsPerEvent
+	}
+	templateParameters["memcachedGrowthFactor"] = instance.Spec.GrowthFactor
 	customData := make(map[string]string)
+	if instance.Spec.Proxy != nil {
+		customData[memcached.ProxyConfigKey] = instance.Spec.Proxy.RouteConfig
+		customData[memcached.ProxyBackendsKey] = memcached.ProxyBackendsLua(instance, ipFamilies[0])
+	}
 
 	cms := []util.Template{
 		// ConfigMap
@@ -283,37 +1311,87 @@ func (r *Reconciler) generateConfigMaps(
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Various CR fields need to be indexed to filter watch events
+	// for the secret changes we want to be notified of
+	// index caBundleSecretName
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &memcachedv1.Memcached{}, caSecretNameField, func(rawObj client.Object) []string {
+		// Extract the secret name from the spec, if one is provided
+		cr := rawObj.(*memcachedv1.Memcached)
+		tls := &cr.Spec.TLS
+		if tls.Ca.CaBundleSecretName != "" {
+			return []string{tls.Ca.CaBundleSecretName}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	// index secretName
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &memcachedv1.Memcached{}, serviceSecretNameField, func(rawObj client.Object) []string {
+		// Extract the secret name from the spec, if one is provided
+		cr := rawObj.(*memcachedv1.Memcached)
+		tls := &cr.Spec.TLS
+		if tls.Enabled() {
+			return []string{*tls.GenericService.SecretName}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&memcachedv1.Memcached{}).
 		Owns(&appsv1.StatefulSet{}).
+		Owns(&appsv1.DaemonSet{}).
 		Owns(&corev1.Service{}).
+		Owns(&corev1.Secret{}).
+		Owns(&networkingv1.NetworkPolicy{}).
 		Owns(&corev1.ServiceAccount{}).
 		Owns(&rbacv1.Role{}).
 		Owns(&rbacv1.RoleBinding{}).
+		// a rotated certificate or CA bundle has to be rolled out, even if
+		// no periodic requeue is active
+		Watches(
+			&source.Kind{Type: &corev1.Secret{}},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForSrc),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
 		Complete(r)
 }
 
+// findObjectsForSrc - returns a reconcile request if the object is referenced by a Memcached CR
+func (r *Reconciler) findObjectsForSrc(src client.Object) []reconcile.Request {
+	requests := []reconcile.Request{}
+
+	for _, field := range allWatchFields {
+		crList := &memcachedv1.MemcachedList{}
+		listOps := &client.ListOptions{
+			FieldSelector: fields.OneTermEqualSelector(field, src.GetName()),
+			Namespace:     src.GetNamespace(),
+		}
+		err := r.List(context.TODO(), crList, listOps)
+		if err != nil {
+			return []reconcile.Request{}
+		}
+
+		for _, item := range crList.Items {
+			requests = append(requests,
+				reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      item.GetName(),
+						Namespace: item.GetNamespace(),
+					},
+				},
+			)
+		}
+	}
+
+	return requests
+}
+
 // GetServerLists returns list of memcached server without/with inet prefix
 func (r *Reconciler) GetServerLists(
 	instance *memcachedv1.Memcached,
 	ipFamily corev1.IPFamily,
 ) ([]string, []string) {
-	var serverList []string
-	var serverListWithInet []string
-
-	prefix := "inet"
-	if ipFamily == corev1.IPv6Protocol {
-		prefix = "inet6"
-	}
-
-	for i := int32(0); i < *(instance.Spec.Replicas); i++ {
-		server := fmt.Sprintf("%s-%d.%s", instance.Name, i, instance.Name)
-		serverList = append(serverList, fmt.Sprintf("%s:%d", server, memcached.MemcachedPort))
-
-		// python-memcached requires inet(6) prefix according to the IP version
-		// used by the memcached server.
-		serverListWithInet = append(serverListWithInet, fmt.Sprintf("%s:[%s]:%d", prefix, server, memcached.MemcachedPort))
-	}
-
-	return serverList, serverListWithInet
+	return memcached.InstanceServerLists(instance, ipFamily, memcached.Port(instance), "")
 }
@@ -0,0 +1,204 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memcached
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"golang.org/x/exp/slices"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	memcachedv1 "github.com/openstack-k8s-operators/infra-operator/apis/memcached/v1beta1"
+	memcached "github.com/openstack-k8s-operators/infra-operator/pkg/memcached"
+)
+
+func TestDrainReplicas(t *testing.T) {
+	tests := []struct {
+		name              string
+		current           *int32
+		desired           int32
+		drainSeconds      int32
+		drainingSince     *metav1.Time
+		outOfRotation     []string
+		wantReplicas      int32
+		wantOutOfRotation []string
+		wantDraining      bool
+		wantEventReason   string
+		wantRemainingOver time.Duration
+	}{
+		{
+			name:         "creates the StatefulSet with the desired replicas",
+			desired:      3,
+			drainSeconds: 60,
+			wantReplicas: 3,
+		},
+		{
+			name:            "scales out right away",
+			current:         ptr.To[int32](2),
+			desired:         3,
+			drainSeconds:    60,
+			wantReplicas:    3,
+			wantEventReason: ScaledReason,
+		},
+		{
+			name:            "scales in right away without drain period",
+			current:         ptr.To[int32](3),
+			desired:         1,
+			wantReplicas:    1,
+			wantEventReason: ScaledReason,
+		},
+		{
+			name:              "keeps the departing replicas during the drain period",
+			current:           ptr.To[int32](3),
+			desired:           1,
+			drainSeconds:      60,
+			wantReplicas:      3,
+			wantOutOfRotation: []string{"memcached-1", "memcached-2"},
+			wantDraining:      true,
+			wantEventReason:   ScaleInDrainingReason,
+			wantRemainingOver: 50 * time.Second,
+		},
+		{
+			name:              "keeps draining until the drain period elapsed",
+			current:           ptr.To[int32](3),
+			desired:           1,
+			drainSeconds:      60,
+			drainingSince:     &metav1.Time{Time: time.Now().Add(-30 * time.Second)},
+			outOfRotation:     []string{"memcached-1", "memcached-2"},
+			wantReplicas:      3,
+			wantOutOfRotation: []string{"memcached-1", "memcached-2"},
+			wantDraining:      true,
+			// started before, no new Event
+			wantRemainingOver: 20 * time.Second,
+		},
+		{
+			name:              "puts replicas back into rotation when less get removed while draining",
+			current:           ptr.To[int32](3),
+			desired:           2,
+			drainSeconds:      60,
+			drainingSince:     &metav1.Time{Time: time.Now().Add(-30 * time.Second)},
+			outOfRotation:     []string{"memcached-1", "memcached-2"},
+			wantReplicas:      3,
+			wantOutOfRotation: []string{"memcached-2"},
+			wantDraining:      true,
+			wantRemainingOver: 20 * time.Second,
+		},
+		{
+			name:          "puts the replicas back into rotation when the scale-in got reverted",
+			current:       ptr.To[int32](3),
+			desired:       3,
+			drainSeconds:  60,
+			drainingSince: &metav1.Time{Time: time.Now().Add(-30 * time.Second)},
+			outOfRotation: []string{"memcached-1", "memcached-2"},
+			wantReplicas:  3,
+		},
+		{
+			name:              "scales in once the drain period elapsed",
+			current:           ptr.To[int32](3),
+			desired:           1,
+			drainSeconds:      60,
+			drainingSince:     &metav1.Time{Time: time.Now().Add(-2 * time.Minute)},
+			outOfRotation:     []string{"memcached-1", "memcached-2"},
+			wantReplicas:      1,
+			wantOutOfRotation: []string{"memcached-1", "memcached-2"},
+			wantEventReason:   ScaledReason,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			instance := &memcachedv1.Memcached{
+				ObjectMeta: metav1.ObjectMeta{Name: "memcached", Namespace: "test"},
+				Spec:       memcachedv1.MemcachedSpec{ScaleInDrainSeconds: tt.drainSeconds},
+			}
+
+			builder := fake.NewClientBuilder().WithScheme(scheme.Scheme)
+			if tt.current != nil {
+				builder = builder.WithObjects(&appsv1.StatefulSet{
+					ObjectMeta: metav1.ObjectMeta{Name: "memcached", Namespace: "test"},
+					Spec:       appsv1.StatefulSetSpec{Replicas: tt.current},
+				})
+				for i := int32(0); i < *tt.current; i++ {
+					pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+						Name:      fmt.Sprintf("memcached-%d", i),
+						Namespace: "test",
+						Labels:    map[string]string{"app": "memcached"},
+					}}
+					if !slices.Contains(tt.outOfRotation, pod.Name) {
+						pod.Labels[memcached.ServiceSelectorLabel] = "memcached"
+					}
+					builder = builder.WithObjects(pod)
+				}
+			}
+			recorder := record.NewFakeRecorder(10)
+			c := builder.Build()
+			r := &Reconciler{Client: c, Recorder: recorder}
+
+			since := tt.drainingSince
+			replicas, remaining, err := r.drainReplicas(context.Background(), instance, "memcached", tt.desired, &since)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(replicas).To(Equal(tt.wantReplicas))
+
+			pods := &corev1.PodList{}
+			g.Expect(c.List(context.Background(), pods)).To(Succeed())
+			outOfRotation := []string{}
+			for _, pod := range pods.Items {
+				if _, ok := pod.Labels[memcached.ServiceSelectorLabel]; !ok {
+					outOfRotation = append(outOfRotation, pod.Name)
+				}
+			}
+			g.Expect(outOfRotation).To(ConsistOf(tt.wantOutOfRotation))
+
+			if tt.wantDraining {
+				g.Expect(since).NotTo(BeNil())
+				g.Expect(remaining).To(BeNumerically(">", tt.wantRemainingOver))
+				g.Expect(remaining).To(BeNumerically("<=", time.Duration(tt.drainSeconds)*time.Second))
+			} else {
+				g.Expect(since).To(BeNil())
+				g.Expect(remaining).To(BeZero())
+			}
+
+			if tt.wantEventReason == "" {
+				g.Expect(recorder.Events).To(BeEmpty())
+			} else {
+				g.Expect(recorder.Events).To(HaveLen(1))
+				g.Expect(strings.Fields(<-recorder.Events)).To(ContainElement(tt.wantEventReason))
+			}
+		})
+	}
+}
+
+func TestMinRequeueAfter(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(minRequeueAfter()).To(BeZero())
+	g.Expect(minRequeueAfter(0, 0)).To(BeZero())
+	g.Expect(minRequeueAfter(0, time.Minute, 30*time.Second, 0)).To(Equal(30 * time.Second))
+}
@@ -18,16 +18,21 @@ package network
 
 import (
 	"context"
-	"sort"
+	"fmt"
 	"strings"
 
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/go-logr/logr"
 	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
@@ -59,6 +64,9 @@ func (r *DNSDataReconciler) GetLogger(ctx context.Context) logr.Logger {
 // +kubebuilder:rbac:groups=network.openstack.org,resources=dnsdata/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=network.openstack.org,resources=dnsdata/finalizers,verbs=update
 // +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete;
+// +kubebuilder:rbac:groups=externaldns.k8s.io,resources=dnsendpoints,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=network.openstack.org,resources=ipsets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=network.openstack.org,resources=reservations,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -144,10 +152,45 @@ func (r *DNSDataReconciler) Reconcile(ctx context.Context, req ctrl.Request) (re
 }
 
 // SetupWithManager sets up the controller with the Manager.
-func (r *DNSDataReconciler) SetupWithManager(mgr ctrl.Manager) error {
+func (r *DNSDataReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	// reconcile the DNSData with host entries owned by the object, to drop
+	// the entries when it gets deleted, or to restore them when it gets recreated.
+	ownerFN := func(kind string) handler.EventHandler {
+		return handler.EnqueueRequestsFromMapFunc(func(o client.Object) []reconcile.Request {
+			Log := r.GetLogger(ctx)
+			result := []reconcile.Request{}
+
+			dnsdata := &networkv1.DNSDataList{}
+
+			listOpts := []client.ListOption{
+				client.InNamespace(o.GetNamespace()),
+			}
+			if err := r.Client.List(ctx, dnsdata, listOpts...); err != nil {
+				Log.Error(err, "Unable to retrieve DNSDataList")
+				return nil
+			}
+
+			for _, i := range dnsdata.Items {
+				if i.Spec.HasHostOwner(kind, o.GetName()) {
+					name := client.ObjectKey{
+						Namespace: o.GetNamespace(),
+						Name:      i.Name,
+					}
+					result = append(result, reconcile.Request{NamespacedName: name})
+				}
+			}
+			if len(result) > 0 {
+				return result
+			}
+			return nil
+		})
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&networkv1.DNSData{}).
 		Owns(&corev1.ConfigMap{}).
+		Watches(&source.Kind{Type: &networkv1.IPSet{}}, ownerFN(networkv1.DNSHostOwnerIPSet)).
+		Watches(&source.Kind{Type: &networkv1.Reservation{}}, ownerFN(networkv1.DNSHostOwnerReservation)).
 		Complete(r)
 }
 
@@ -170,10 +213,28 @@ func (r *DNSDataReconciler) reconcileNormal(ctx context.Context, instance *netwo
 
 	configMapVars := make(map[string]env.Setter)
 
+	//
+	// drop the host entries whose owner got deleted
+	//
+	spec, staleHosts, err := r.getLiveSpec(ctx, instance)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			condition.ServiceConfigReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			condition.ServiceConfigReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+	if len(staleHosts) != len(instance.Status.StaleHosts) {
+		Log.Info("Stale host entries", "StaleHosts", staleHosts)
+	}
+	instance.Status.StaleHosts = staleHosts
+
 	//
 	// create Configmap with hosts file
 	//
-	err := r.generateServiceConfigMaps(ctx, helper, instance, &configMapVars)
+	err = r.generateServiceConfigMaps(ctx, helper, instance, spec, &configMapVars)
 	if err != nil {
 		instance.Status.Conditions.Set(condition.FalseCondition(
 			condition.ServiceConfigReadyCondition,
@@ -195,6 +256,28 @@ func (r *DNSDataReconciler) reconcileNormal(ctx context.Context, instance *netwo
 
 	instance.Status.Conditions.MarkTrue(condition.ServiceConfigReadyCondition, condition.InputReadyMessage)
 
+	// mirror the records into the provider zones of external-dns
+	if instance.Spec.ExternalDNS != nil {
+		err = r.createOrPatchDNSEndpoint(ctx, instance, spec)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				networkv1.ExternalDNSReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				networkv1.ExternalDNSReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+		instance.Status.Conditions.MarkTrue(networkv1.ExternalDNSReadyCondition, networkv1.ExternalDNSReadyMessage)
+	} else if instance.Status.Conditions.Has(networkv1.ExternalDNSReadyCondition) {
+		// publishing got disabled
+		err = r.deleteDNSEndpoint(ctx, instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		instance.Status.Conditions.Remove(networkv1.ExternalDNSReadyCondition)
+	}
+
 	Log.Info("Reconciled Service successfully")
 	return ctrl.Result{}, nil
 }
@@ -204,24 +287,19 @@ func (r *DNSDataReconciler) generateServiceConfigMaps(
 	ctx context.Context,
 	h *helper.Helper,
 	instance *networkv1.DNSData,
+	spec *networkv1.DNSDataSpec,
 	envVars *map[string]env.Setter,
 ) error {
 	cmLabels := labels.GetLabels(instance, labels.GetGroupLabel(dnsmasq.ServiceName), map[string]string{networkv1.DNSDataLabelSelectorKey: strings.ToLower(instance.Spec.DNSDataLabelSelectorValue)})
 
 	configMapData := map[string]string{}
 
-	var configData string
-	for _, host := range instance.Spec.Hosts {
-		configData += host.IP
-		hosts := host.Hostnames
-		sort.Strings(hosts)
-		hostsStr := strings.Join(hosts, " ")
-		configData += " " + hostsStr
-		configData += "\n"
+	hostsData, configData := dnsmasq.DNSDataConfig(spec)
+	configMapData[instance.Name] = hostsData
+	if configData != "" {
+		configMapData[dnsmasq.DNSDataConfigKey] = configData
 	}
 
-	configMapData[instance.Name] = configData
-
 	cms := []util.Template{
 		{
 			Name:         strings.ToLower(instance.Name),
@@ -235,3 +313,128 @@ func (r *DNSDataReconciler) generateServiceConfigMaps(
 
 	return configmap.EnsureConfigMaps(ctx, h, instance, cms, envVars)
 }
+
+// createOrPatchDNSEndpoint - publishes the records of the DNSData via an ExternalDNS DNSEndpoint
+func (r *DNSDataReconciler) createOrPatchDNSEndpoint(
+	ctx context.Context,
+	instance *networkv1.DNSData,
+	spec *networkv1.DNSDataSpec,
+) error {
+	Log := r.GetLogger(ctx)
+
+	dnsEndpoint := &unstructured.Unstructured{}
+	dnsEndpoint.SetGroupVersionKind(DNSEndpointGVK)
+	dnsEndpoint.SetName(instance.Name)
+	dnsEndpoint.SetNamespace(instance.Namespace)
+
+	op, err := controllerutil.CreateOrPatch(ctx, r.Client, dnsEndpoint, func() error {
+		dnsEndpoint.SetLabels(util.MergeStringMaps(dnsEndpoint.GetLabels(), instance.Spec.ExternalDNS.Labels))
+
+		err := unstructured.SetNestedSlice(dnsEndpoint.Object, dnsmasq.ExternalDNSEndpoints(spec), "spec", "endpoints")
+		if err != nil {
+			return err
+		}
+
+		return controllerutil.SetControllerReference(instance, dnsEndpoint, r.Scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("error create/updating DNSEndpoint %s: %w", instance.Name, err)
+	}
+
+	if op != controllerutil.OperationResultNone {
+		Log.Info("operation:", "DNSEndpoint name", dnsEndpoint.GetName(), "Operation", string(op))
+	}
+
+	return nil
+}
+
+// deleteDNSEndpoint - deletes the DNSEndpoint publishing the records of the DNSData
+func (r *DNSDataReconciler) deleteDNSEndpoint(
+	ctx context.Context,
+	instance *networkv1.DNSData,
+) error {
+	dnsEndpoint := &unstructured.Unstructured{}
+	dnsEndpoint.SetGroupVersionKind(DNSEndpointGVK)
+	dnsEndpoint.SetName(instance.Name)
+	dnsEndpoint.SetNamespace(instance.Namespace)
+
+	err := r.Client.Delete(ctx, dnsEndpoint)
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return fmt.Errorf("error deleting DNSEndpoint %s: %w", instance.Name, err)
+	}
+
+	return nil
+}
+
+// getLiveSpec - returns a copy of the spec without the host entries whose owner
+// does not exist anymore, and the dropped entries
+func (r *DNSDataReconciler) getLiveSpec(
+	ctx context.Context,
+	instance *networkv1.DNSData,
+) (*networkv1.DNSDataSpec, []string, error) {
+	spec := instance.Spec.DeepCopy()
+	var staleHosts []string
+
+	liveHosts := func(hosts []networkv1.DNSHost) ([]networkv1.DNSHost, error) {
+		live := []networkv1.DNSHost{}
+		for _, host := range hosts {
+			exists, err := r.hostOwnerExists(ctx, instance.Namespace, host.Owner)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				staleHosts = append(staleHosts, fmt.Sprintf("%s (%s/%s)", host.IP, host.Owner.Kind, host.Owner.Name))
+				continue
+			}
+			live = append(live, host)
+		}
+		return live, nil
+	}
+
+	var err error
+	spec.Hosts, err = liveHosts(spec.Hosts)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range spec.Views {
+		spec.Views[i].Hosts, err = liveHosts(spec.Views[i].Hosts)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return spec, staleHosts, nil
+}
+
+// hostOwnerExists - returns true if the host entry has no owner, or the owner
+// exists. An owner being deleted still exists, e.g. an IPSet waits on its
+// finalizer for the consumer to be gone, which still resolves the name.
+func (r *DNSDataReconciler) hostOwnerExists(
+	ctx context.Context,
+	namespace string,
+	owner *networkv1.DNSHostOwner,
+) (bool, error) {
+	if owner == nil {
+		return true, nil
+	}
+
+	var obj client.Object
+	switch owner.Kind {
+	case networkv1.DNSHostOwnerIPSet:
+		obj = &networkv1.IPSet{}
+	case networkv1.DNSHostOwnerReservation:
+		obj = &networkv1.Reservation{}
+	default:
+		return false, fmt.Errorf("unsupported host owner kind %s", owner.Kind)
+	}
+
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: owner.Name}, obj)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("error getting %s %s: %w", owner.Kind, owner.Name, err)
+	}
+
+	return true, nil
+}
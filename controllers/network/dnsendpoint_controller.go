@@ -0,0 +1,194 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/go-logr/logr"
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+	dnsmasq "github.com/openstack-k8s-operators/infra-operator/pkg/dnsmasq"
+	"golang.org/x/exp/maps"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// DNSEndpointGVK - GroupVersionKind of the ExternalDNS DNSEndpoint CRD
+var DNSEndpointGVK = schema.GroupVersionKind{
+	Group:   "externaldns.k8s.io",
+	Version: "v1alpha1",
+	Kind:    "DNSEndpoint",
+}
+
+// DNSEndpointReconciler publishes the A and AAAA records of the ExternalDNS
+// DNSEndpoint objects of a namespace into the DNSMasq of the namespace
+type DNSEndpointReconciler struct {
+	client.Client
+	Kclient kubernetes.Interface
+	Scheme  *runtime.Scheme
+}
+
+// GetLogger returns a logger object with a prefix of "controller.name" and additional controller context fields
+func (r *DNSEndpointReconciler) GetLogger(ctx context.Context) logr.Logger {
+	return log.FromContext(ctx).WithName("Controllers").WithName("DNSEndpoint")
+}
+
+// +kubebuilder:rbac:groups=externaldns.k8s.io,resources=dnsendpoints,verbs=get;list;watch
+// +kubebuilder:rbac:groups=network.openstack.org,resources=dnsmasqs,verbs=get;list;watch;
+// +kubebuilder:rbac:groups=network.openstack.org,resources=dnsdata,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+//
+// For more details, check Reconcile and its Result here:
+// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.13.0/pkg/reconcile
+func (r *DNSEndpointReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	// Get a list of DNSMasq CRs which are in the same namespace the DNSEndpoint
+	// is in to add the records to those DNSMasqs.
+	dnsmasqs := &networkv1.DNSMasqList{}
+
+	listOpts := []client.ListOption{
+		client.InNamespace(req.Namespace),
+	}
+	if err := r.Client.List(ctx, dnsmasqs, listOpts...); err != nil {
+		return ctrl.Result{}, fmt.Errorf("Unable to retrieve DNSMasqList %w", err)
+	}
+
+	dnsHosts, err := r.getDNSEndpointDNSData(ctx, req.Namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// sort entries for DNSData spec to reduce not required updates
+	sortedDNSHosts := []networkv1.DNSHost{}
+
+	keys := maps.Keys(dnsHosts)
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		sortedDNSHosts = append(sortedDNSHosts, dnsHosts[key])
+	}
+
+	for _, dnsmasq := range dnsmasqs.Items {
+		err = r.createOrPatchDNSData(ctx, &dnsmasq, sortedDNSHosts)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DNSEndpointReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	dnsEndpoint := &unstructured.Unstructured{}
+	dnsEndpoint.SetGroupVersionKind(DNSEndpointGVK)
+
+	// reconcile the namespace of a new DNSMasq to publish the existing records.
+	// Reconcile only uses the namespace of the request.
+	dnsmasqFN := handler.EnqueueRequestsFromMapFunc(func(o client.Object) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: client.ObjectKeyFromObject(o)}}
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(dnsEndpoint).
+		Watches(&source.Kind{Type: &networkv1.DNSMasq{}}, dnsmasqFN).
+		Complete(r)
+}
+
+// getDNSEndpointDNSData - returns the hosts of the A and AAAA records of all
+// DNSEndpoints of the namespace, keyed by IP
+func (r *DNSEndpointReconciler) getDNSEndpointDNSData(
+	ctx context.Context,
+	namespace string,
+) (map[string]networkv1.DNSHost, error) {
+	Log := r.GetLogger(ctx)
+	dnsHosts := map[string]networkv1.DNSHost{}
+
+	// get all DNSEndpoints from the namespace triggered the reconcile
+	dnsEndpoints := &unstructured.UnstructuredList{}
+	dnsEndpoints.SetGroupVersionKind(DNSEndpointGVK.GroupVersion().WithKind(DNSEndpointGVK.Kind + "List"))
+
+	if err := r.List(ctx, dnsEndpoints, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("error getting list of dnsendpoints %w", err)
+	}
+
+	for _, dnsEndpoint := range dnsEndpoints.Items {
+		// skip the DNSEndpoints mirroring DNSData records, dnsmasq already serves those
+		if owner := metav1.GetControllerOf(&dnsEndpoint); owner != nil &&
+			owner.APIVersion == networkv1.GroupVersion.String() && owner.Kind == "DNSData" {
+			continue
+		}
+
+		endpoints, _, err := unstructured.NestedSlice(dnsEndpoint.Object, "spec", "endpoints")
+		if err != nil {
+			return nil, fmt.Errorf("error getting endpoints of dnsendpoint %s: %w", dnsEndpoint.GetName(), err)
+		}
+
+		for _, target := range dnsmasq.AddDNSEndpointHosts(dnsHosts, endpoints) {
+			Log.Info(fmt.Sprintf("Skipping unrecognized address %s of dnsendpoint %s", target, dnsEndpoint.GetName()))
+		}
+	}
+
+	return dnsHosts, nil
+}
+
+// createOrPatchDNSData -
+func (r *DNSEndpointReconciler) createOrPatchDNSData(
+	ctx context.Context,
+	dnsmasq *networkv1.DNSMasq,
+	dnsHosts []networkv1.DNSHost,
+) error {
+	Log := r.GetLogger(ctx)
+
+	dnsData := &networkv1.DNSData{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dnsmasq.GetName() + "-dnsendpoint",
+			Namespace: dnsmasq.GetNamespace(),
+		},
+	}
+
+	// create or update the DNSData
+	op, err := controllerutil.CreateOrPatch(ctx, r.Client, dnsData, func() error {
+
+		dnsData.Spec.DNSDataLabelSelectorValue = dnsmasq.Spec.DNSDataLabelSelectorValue
+		dnsData.Spec.Hosts = dnsHosts
+
+		return controllerutil.SetControllerReference(dnsmasq, dnsData, r.Scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("error create/updating dnsendpoint DNSData: %w", err)
+	}
+
+	if op != controllerutil.OperationResultNone {
+		Log.Info("operation:", "dnsEndpointDNSData name", dnsData.Name, "Operation", string(op))
+	}
+
+	return nil
+}
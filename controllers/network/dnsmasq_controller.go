@@ -19,13 +19,21 @@ package network
 import (
 	"context"
 	"fmt"
+	"net"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -41,9 +49,13 @@ import (
 	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	dnsmasq "github.com/openstack-k8s-operators/infra-operator/pkg/dnsmasq"
+	managed "github.com/openstack-k8s-operators/infra-operator/pkg/managed"
 	common "github.com/openstack-k8s-operators/lib-common/modules/common"
 	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
 	configmap "github.com/openstack-k8s-operators/lib-common/modules/common/configmap"
@@ -51,16 +63,39 @@ import (
 	env "github.com/openstack-k8s-operators/lib-common/modules/common/env"
 	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
 	labels "github.com/openstack-k8s-operators/lib-common/modules/common/labels"
+	nad "github.com/openstack-k8s-operators/lib-common/modules/common/networkattachment"
 	common_rbac "github.com/openstack-k8s-operators/lib-common/modules/common/rbac"
 	service "github.com/openstack-k8s-operators/lib-common/modules/common/service"
+	tls "github.com/openstack-k8s-operators/lib-common/modules/common/tls"
 	util "github.com/openstack-k8s-operators/lib-common/modules/common/util"
 )
 
+// ServiceMonitorGVK - GroupVersionKind of the prometheus-operator ServiceMonitor CRD
+var ServiceMonitorGVK = schema.GroupVersionKind{
+	Group:   "monitoring.coreos.com",
+	Version: "v1",
+	Kind:    "ServiceMonitor",
+}
+
+const (
+	// healthCheckPollInterval - interval to pick up the result of a canary record resolution
+	healthCheckPollInterval = 2 * time.Second
+)
+
+// fields to index to reconcile on CR change
+const (
+	upstreamCaSecretNameField = ".spec.upstreamTLS.caBundleSecretName"
+)
+
 // DNSMasqReconciler reconciles a DNSMasq object
 type DNSMasqReconciler struct {
 	client.Client
-	Kclient kubernetes.Interface
-	Scheme  *runtime.Scheme
+	Kclient  kubernetes.Interface
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// healthChecker - resolves the canary records off the reconciliation
+	healthChecker dnsmasq.HealthChecker
 }
 
 // GetLogger returns a logger object with a prefix of "controller.name" and additional controller context fields
@@ -73,8 +108,13 @@ func (r *DNSMasqReconciler) GetLogger(ctx context.Context) logr.Logger {
 // +kubebuilder:rbac:groups=network.openstack.org,resources=dnsmasqs/finalizers,verbs=update
 // +kubebuilder:rbac:groups=network.openstack.org,resources=dnsdatas,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete;
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=k8s.cni.cncf.io,resources=network-attachment-definitions,verbs=get;list;watch
 // service account, role, rolebinding
 // +kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;create;update
 // +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=roles,verbs=get;list;watch;create;update
@@ -82,6 +122,7 @@ func (r *DNSMasqReconciler) GetLogger(ctx context.Context) logr.Logger {
 // service account permissions that are needed to grant permission to the above
 // +kubebuilder:rbac:groups="security.openshift.io",resourceNames=anyuid,resources=securitycontextconstraints,verbs=use
 // +kubebuilder:rbac:groups="",resources=pods,verbs=create;delete;get;list;patch;update;watch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -118,6 +159,9 @@ func (r *DNSMasqReconciler) Reconcile(ctx context.Context, req ctrl.Request) (re
 
 	// Always patch the instance status when exiting this function so we can persist any changes.
 	defer func() {
+		// the ResolutionDegraded condition does not affect the Ready condition
+		degraded := instance.Status.Conditions.Get(networkv1.ResolutionDegradedCondition)
+		instance.Status.Conditions.Remove(networkv1.ResolutionDegradedCondition)
 		// update the Ready condition based on the sub conditions
 		if instance.Status.Conditions.AllSubConditionIsTrue() {
 			instance.Status.Conditions.MarkTrue(
@@ -130,6 +174,7 @@ func (r *DNSMasqReconciler) Reconcile(ctx context.Context, req ctrl.Request) (re
 			instance.Status.Conditions.Set(
 				instance.Status.Conditions.Mirror(condition.ReadyCondition))
 		}
+		instance.Status.Conditions.Set(degraded)
 
 		err := helper.PatchInstance(ctx, instance)
 		if err != nil {
@@ -151,6 +196,7 @@ func (r *DNSMasqReconciler) Reconcile(ctx context.Context, req ctrl.Request) (re
 			condition.UnknownCondition(condition.InputReadyCondition, condition.InitReason, condition.InputReadyInitMessage),
 			condition.UnknownCondition(condition.ServiceConfigReadyCondition, condition.InitReason, condition.ServiceConfigReadyInitMessage),
 			condition.UnknownCondition(condition.DeploymentReadyCondition, condition.InitReason, condition.DeploymentReadyInitMessage),
+			condition.UnknownCondition(condition.NetworkAttachmentsReadyCondition, condition.InitReason, condition.NetworkAttachmentsReadyInitMessage),
 			// service account, role, rolebinding conditions
 			condition.UnknownCondition(condition.ServiceAccountReadyCondition, condition.InitReason, condition.ServiceAccountReadyInitMessage),
 			condition.UnknownCondition(condition.RoleReadyCondition, condition.InitReason, condition.RoleReadyInitMessage),
@@ -199,6 +245,19 @@ func (r *DNSMasqReconciler) Reconcile(ctx context.Context, req ctrl.Request) (re
 // SetupWithManager sets up the controller with the Manager.
 func (r *DNSMasqReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
 	Log := r.GetLogger(ctx)
+
+	// index upstreamTLS.caBundleSecretName
+	if err := mgr.GetFieldIndexer().IndexField(ctx, &networkv1.DNSMasq{}, upstreamCaSecretNameField, func(rawObj client.Object) []string {
+		// Extract the secret name from the spec, if one is provided
+		cr := rawObj.(*networkv1.DNSMasq)
+		if cr.Spec.UpstreamTLS == nil || cr.Spec.UpstreamTLS.CaBundleSecretName == "" {
+			return nil
+		}
+		return []string{cr.Spec.UpstreamTLS.CaBundleSecretName}
+	}); err != nil {
+		return err
+	}
+
 	dnsmasqFN := handler.EnqueueRequestsFromMapFunc(func(o client.Object) []reconcile.Request {
 		result := []reconcile.Request{}
 
@@ -263,19 +322,55 @@ func (r *DNSMasqReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manag
 		Owns(&appsv1.Deployment{}).
 		Owns(&corev1.Service{}).
 		Owns(&corev1.ConfigMap{}).
+		Owns(&policyv1.PodDisruptionBudget{}).
+		Owns(&networkingv1.NetworkPolicy{}).
 		Owns(&corev1.ServiceAccount{}).
 		Owns(&rbacv1.Role{}).
 		Owns(&rbacv1.RoleBinding{}).
 		Watches(&source.Kind{Type: &corev1.ConfigMap{}},
 			dnsmasqFN,
 			builder.WithPredicates(p)).
+		Watches(&source.Kind{Type: &corev1.Secret{}},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForSecret),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})).
 		Complete(r)
 }
 
+// findObjectsForSecret - returns a reconcile request for each DNSMasq CR
+// verifying its DNS-over-TLS upstreams with the CA bundle secret
+func (r *DNSMasqReconciler) findObjectsForSecret(secret client.Object) []reconcile.Request {
+	requests := []reconcile.Request{}
+
+	crList := &networkv1.DNSMasqList{}
+	listOps := &client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector(upstreamCaSecretNameField, secret.GetName()),
+		Namespace:     secret.GetNamespace(),
+	}
+	err := r.List(context.TODO(), crList, listOps)
+	if err != nil {
+		return []reconcile.Request{}
+	}
+
+	for _, item := range crList.Items {
+		requests = append(requests,
+			reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      item.GetName(),
+					Namespace: item.GetNamespace(),
+				},
+			},
+		)
+	}
+
+	return requests
+}
+
 func (r *DNSMasqReconciler) reconcileDelete(ctx context.Context, instance *networkv1.DNSMasq, helper *helper.Helper) (ctrl.Result, error) {
 	Log := r.GetLogger(ctx)
 	Log.Info("Reconciling Service delete")
 
+	r.healthChecker.Forget(client.ObjectKeyFromObject(instance).String())
+
 	// Service is deleted so remove the finalizer.
 	controllerutil.RemoveFinalizer(instance, helper.GetFinalizer())
 	Log.Info("Reconciled Service delete successfully")
@@ -294,16 +389,52 @@ func (r *DNSMasqReconciler) reconcileNormal(ctx context.Context, instance *netwo
 	serviceAnnotations := map[string]string{}
 	configMapVars := make(map[string]env.Setter)
 
-	// create Configmap for dnsmasq input
-	err := r.generateServiceConfigMaps(ctx, helper, instance, &configMapVars)
-	if err != nil {
-		instance.Status.Conditions.Set(condition.FalseCondition(
-			condition.ServiceConfigReadyCondition,
-			condition.ErrorReason,
-			condition.SeverityWarning,
-			condition.ServiceConfigReadyErrorMessage,
-			err.Error()))
-		return ctrl.Result{}, err
+	// validate the CA bundle used to verify the DNS-over-TLS upstreams
+	if instance.Spec.UpstreamTLS != nil && instance.Spec.UpstreamTLS.CaBundleSecretName != "" {
+		caHash, ctrlResult, err := tls.ValidateCACertSecret(
+			ctx,
+			helper.GetClient(),
+			types.NamespacedName{
+				Name:      instance.Spec.UpstreamTLS.CaBundleSecretName,
+				Namespace: instance.Namespace,
+			},
+		)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.InputReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				condition.InputReadyErrorMessage,
+				err.Error()))
+			return ctrlResult, err
+		} else if (ctrlResult != ctrl.Result{}) {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.InputReadyCondition,
+				condition.RequestedReason,
+				condition.SeverityInfo,
+				condition.InputReadyWaitingMessage))
+			return ctrlResult, nil
+		}
+		configMapVars["CA"] = env.SetValue(caHash)
+	}
+
+	// render the DNSSEC validation options
+	dnssecCfg := ""
+	if instance.Spec.DNSSEC != nil {
+		var err error
+		dnssecCfg, err = dnsmasq.DNSSECConfig(instance.Spec.DNSSEC)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				networkv1.DNSSECReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				networkv1.DNSSECReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+		instance.Status.Conditions.MarkTrue(networkv1.DNSSECReadyCondition, networkv1.DNSSECReadyMessage)
+	} else {
+		instance.Status.Conditions.Remove(networkv1.DNSSECReadyCondition)
 	}
 
 	labelSelectorMap := map[string]string{networkv1.DNSDataLabelSelectorKey: strings.ToLower(instance.Spec.DNSDataLabelSelectorValue)}
@@ -312,7 +443,7 @@ func (r *DNSMasqReconciler) reconcileNormal(ctx context.Context, instance *netwo
 		client.InNamespace(instance.GetNamespace()),
 		client.MatchingLabels(labelSelectorMap),
 	}
-	err = r.List(ctx, configMaps, listOpts...)
+	err := r.List(ctx, configMaps, listOpts...)
 	if err != nil {
 		err = fmt.Errorf("error listing configmaps for labels: %v - %w", labelSelectorMap, err)
 		instance.Status.Conditions.Set(condition.FalseCondition(
@@ -331,22 +462,24 @@ func (r *DNSMasqReconciler) reconcileNormal(ctx context.Context, instance *netwo
 
 	cmNames := []string{}
 	for _, cm := range configMaps.Items {
-		hash, err := configmap.Hash(&cm)
-		if err != nil {
-			instance.Status.Conditions.Set(condition.FalseCondition(
-				condition.InputReadyCondition,
-				condition.ErrorReason,
-				condition.SeverityWarning,
-				condition.InputReadyErrorMessage,
-				err.Error()))
-			return ctrl.Result{}, fmt.Errorf("error calculating configuration hash: %w", err)
-		}
-		configMapVars[cm.Name] = env.SetValue(hash)
 		cmNames = append(cmNames, cm.GetName())
 	}
 	Log.Info("ConfigMaps providing host information:", "ConfigMaps", cmNames)
 	instance.Status.Conditions.MarkTrue(condition.InputReadyCondition, condition.InputReadyMessage)
 
+	// create Configmap for dnsmasq input. The hosts of the DNSData are not part
+	// of the input hash, dnsmasq gets signaled to re-read them instead of a restart.
+	hostsHash, recordCount, err := r.generateServiceConfigMaps(ctx, helper, instance, dnssecCfg, configMaps, &configMapVars)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			condition.ServiceConfigReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			condition.ServiceConfigReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+
 	// create hash over all the different input resources to identify if any of
 	// those changed and a restart/recreate is required.
 	inputHash, err := util.HashOfInputHashes(configMapVars)
@@ -359,83 +492,165 @@ func (r *DNSMasqReconciler) reconcileNormal(ctx context.Context, instance *netwo
 			err.Error()))
 		return ctrl.Result{}, err
 	}
+	// bump the serial whenever the config or the hosts served by dnsmasq change
+	if instance.Status.Hash[common.InputHashName] != inputHash || instance.Status.HostsHash != hostsHash {
+		instance.Status.ConfigSerial++
+	}
 	instance.Status.Hash[common.InputHashName] = inputHash
+	instance.Status.HostsHash = hostsHash
+	instance.Status.RecordCount = recordCount
 	instance.Status.Conditions.MarkTrue(condition.ServiceConfigReadyCondition, condition.ServiceConfigReadyMessage)
 
-	// expose the service
-	svcOverride := instance.Spec.Override.Service
-	if svcOverride == nil {
-		svcOverride = &service.OverrideSpec{}
-	}
+	// expose the service, unless opted out via annotation in favor of an
+	// externally managed one
+	serviceName := dnsmasq.ServiceName + "-" + instance.Name
+	instance.Status.SkippedResources = []string{}
+	if managed.IsManaged(instance, networkv1.GroupVersion.Group, managed.Service) {
+		// expose the service
+		svcOverride := instance.Spec.Override.Service
+		if svcOverride == nil {
+			svcOverride = &service.OverrideSpec{}
+		}
 
-	// Create the service
-	svc, err := service.NewService(
-		service.GenericService(&service.GenericServiceDetails{
-			Name:      dnsmasq.ServiceName + "-" + instance.Name,
+		// Create the service
+		svcDef := service.GenericService(&service.GenericServiceDetails{
+			Name:      serviceName,
 			Namespace: instance.Namespace,
 			Labels:    serviceLabels,
 			Selector:  serviceLabels,
-			Port: service.GenericServicePort{
-				Name:     dnsmasq.ServiceName,
-				Port:     dnsmasq.DNSPort,
-				Protocol: corev1.ProtocolUDP,
+			// DNS falls back to TCP for responses which exceed the UDP
+			// message size, e.g. for large records or DNSSEC
+			Ports: []corev1.ServicePort{
+				{
+					Name:     dnsmasq.ServiceName,
+					Port:     dnsmasq.DNSPort,
+					Protocol: corev1.ProtocolUDP,
+				},
+				{
+					Name:     dnsmasq.ServiceName + "-tcp",
+					Port:     dnsmasq.DNSPort,
+					Protocol: corev1.ProtocolTCP,
+				},
 			},
-		}),
-		5,
-		svcOverride,
-	)
-	if err != nil {
-		instance.Status.Conditions.Set(condition.FalseCondition(
-			condition.ExposeServiceReadyCondition,
-			condition.ErrorReason,
-			condition.SeverityWarning,
-			condition.ExposeServiceReadyErrorMessage,
-			err.Error()))
+		})
+		// get an address of each IP family on dual-stack clusters, unless the
+		// override requests a specific policy
+		preferDualStack := corev1.IPFamilyPolicyPreferDualStack
+		svcDef.Spec.IPFamilyPolicy = &preferDualStack
+
+		svc, err := service.NewService(
+			svcDef,
+			5,
+			svcOverride,
+		)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.ExposeServiceReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				condition.ExposeServiceReadyErrorMessage,
+				err.Error()))
 
-		return ctrl.Result{}, err
-	}
+			return ctrl.Result{}, err
+		}
 
-	svc.AddAnnotation(map[string]string{
-		service.AnnotationIngressCreateKey: "false",
-	})
+		svc.AddAnnotation(map[string]string{
+			service.AnnotationIngressCreateKey: "false",
+		})
 
-	ctrlResult, err := svc.CreateOrPatch(ctx, helper)
-	if err != nil {
-		instance.Status.Conditions.Set(condition.FalseCondition(
-			condition.ExposeServiceReadyCondition,
-			condition.ErrorReason,
-			condition.SeverityWarning,
-			condition.ExposeServiceReadyErrorMessage,
-			err.Error()))
+		ctrlResult, err := svc.CreateOrPatch(ctx, helper)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.ExposeServiceReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				condition.ExposeServiceReadyErrorMessage,
+				err.Error()))
 
-		return ctrlResult, err
-	} else if (ctrlResult != ctrl.Result{}) {
-		instance.Status.Conditions.Set(condition.FalseCondition(
-			condition.ExposeServiceReadyCondition,
-			condition.RequestedReason,
-			condition.SeverityInfo,
-			condition.ExposeServiceReadyRunningMessage))
-		return ctrlResult, nil
-	}
+			return ctrlResult, err
+		} else if (ctrlResult != ctrl.Result{}) {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.ExposeServiceReadyCondition,
+				condition.RequestedReason,
+				condition.SeverityInfo,
+				condition.ExposeServiceReadyRunningMessage))
+			return ctrlResult, nil
+		}
 
-	// Update status with LoadBalancerIPs
-	instance.Status.DNSAddresses = svc.GetExternalIPs()
+		// Update status with LoadBalancerIPs
+		instance.Status.DNSAddresses = svc.GetExternalIPs()
 
-	// Update status with Cluster Addresses
-	instance.Status.DNSClusterAddresses = svc.GetClusterIPs()
+		// Update status with Cluster Addresses
+		instance.Status.DNSClusterAddresses = svc.GetClusterIPs()
+
+	} else {
+		instance.Status.SkippedResources = append(instance.Status.SkippedResources,
+			managed.SkippedResource("Service", serviceName))
+
+		svc := &corev1.Service{}
+		err = r.Client.Get(ctx, types.NamespacedName{Name: serviceName, Namespace: instance.Namespace}, svc)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.ExposeServiceReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				condition.ExposeServiceReadyErrorMessage,
+				fmt.Sprintf("error getting externally managed service %s: %s", serviceName, err.Error())))
+			return ctrl.Result{}, err
+		}
+
+		instance.Status.DNSAddresses = []string{}
+		for _, ingr := range svc.Status.LoadBalancer.Ingress {
+			if ingr.IP != "" {
+				instance.Status.DNSAddresses = append(instance.Status.DNSAddresses, ingr.IP)
+			}
+		}
+		instance.Status.DNSClusterAddresses = svc.Spec.ClusterIPs
+	}
 
 	// create service - end
 
 	instance.Status.Conditions.MarkTrue(condition.ExposeServiceReadyCondition, condition.ExposeServiceReadyMessage)
 
+	// the network attachment definitions have to exist before the pods can
+	// get attached to them
+	for _, netAtt := range instance.Spec.NetworkAttachments {
+		_, err = nad.GetNADWithName(ctx, helper, netAtt, instance.Namespace)
+		if err != nil {
+			if k8s_errors.IsNotFound(err) {
+				Log.Info(fmt.Sprintf("network-attachment-definition %s not found", netAtt))
+				instance.Status.Conditions.Set(condition.FalseCondition(
+					condition.NetworkAttachmentsReadyCondition,
+					condition.RequestedReason,
+					condition.SeverityInfo,
+					condition.NetworkAttachmentsReadyWaitingMessage,
+					netAtt))
+				return ctrl.Result{RequeueAfter: time.Second * 10}, nil
+			}
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.NetworkAttachmentsReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				condition.NetworkAttachmentsReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+	}
+
+	serviceAnnotations, err = nad.CreateNetworksAnnotation(instance.Namespace, instance.Spec.NetworkAttachments)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed create network annotation from %s: %w",
+			instance.Spec.NetworkAttachments, err)
+	}
+
 	// Define a new Deployment object
-	deplDef := dnsmasq.Deployment(instance, instance.Status.Hash[common.InputHashName], serviceLabels, serviceAnnotations, configMaps)
+	deplDef := dnsmasq.Deployment(instance, instance.Status.Hash[common.InputHashName], serviceLabels, serviceAnnotations)
 	depl := deployment.NewDeployment(
 		deplDef,
 		time.Duration(5)*time.Second,
 	)
 
-	ctrlResult, err = depl.CreateOrPatch(ctx, helper)
+	ctrlResult, err := depl.CreateOrPatch(ctx, helper)
 	if err != nil {
 		instance.Status.Conditions.Set(condition.FalseCondition(
 			condition.DeploymentReadyCondition,
@@ -452,11 +667,81 @@ func (r *DNSMasqReconciler) reconcileNormal(ctx context.Context, instance *netwo
 			condition.DeploymentReadyRunningMessage))
 		return ctrlResult, nil
 	}
+
+	// expose the metrics of the exporter sidecars to the cluster Prometheus
+	err = r.reconcileMetrics(ctx, helper, instance)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			condition.ExposeServiceReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			condition.ExposeServiceReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+
+	// NetworkPolicy limiting the clients allowed to query dnsmasq
+	networkPolicyName := dnsmasq.ServiceName + "-" + instance.Name
+	if managed.IsManaged(instance, networkv1.GroupVersion.Group, managed.NetworkPolicy) {
+		err = r.reconcileNetworkPolicy(ctx, helper, instance)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.ExposeServiceReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				condition.ExposeServiceReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+	} else {
+		instance.Status.SkippedResources = append(instance.Status.SkippedResources,
+			managed.SkippedResource("NetworkPolicy", networkPolicyName))
+	}
+
+	// PodDisruptionBudget keeping resolvers available during node drains
+	pdbName := dnsmasq.ServiceName + "-" + instance.Name
+	if managed.IsManaged(instance, networkv1.GroupVersion.Group, managed.PodDisruptionBudget) {
+		err = r.reconcilePodDisruptionBudget(ctx, helper, instance)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.DeploymentReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				condition.DeploymentReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+	} else {
+		instance.Status.SkippedResources = append(instance.Status.SkippedResources,
+			managed.SkippedResource("PodDisruptionBudget", pdbName))
+	}
+
 	instance.Status.ReadyCount = depl.GetDeployment().Status.ReadyReplicas
 
 	if instance.Status.ReadyCount > 0 {
 		Log.Info("Deployment is ready: ", "Deployment", instance.Name)
 		instance.Status.Conditions.MarkTrue(condition.DeploymentReadyCondition, condition.DeploymentReadyMessage)
+
+		// verify the pods got an IP on each of the network attachments
+		networkReady, networkAttachmentStatus, err := nad.VerifyNetworkStatusFromAnnotation(
+			ctx, helper, instance.Spec.NetworkAttachments, serviceLabels, instance.Status.ReadyCount)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		instance.Status.NetworkAttachments = networkAttachmentStatus
+
+		if networkReady {
+			instance.Status.Conditions.MarkTrue(condition.NetworkAttachmentsReadyCondition, condition.NetworkAttachmentsReadyMessage)
+		} else {
+			err := fmt.Errorf("not all pods have interfaces with ips as configured in NetworkAttachments: %s", instance.Spec.NetworkAttachments)
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.NetworkAttachmentsReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				condition.NetworkAttachmentsReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
 	} else {
 		Log.Info("Deployment is not ready:", "Name", instance.Name, "deployment", depl.GetDeployment().Status)
 		instance.Status.Conditions.Set(condition.FalseCondition(
@@ -469,23 +754,336 @@ func (r *DNSMasqReconciler) reconcileNormal(ctx context.Context, instance *netwo
 	}
 	// create Deployment - end
 
+	// resolve the canary record to catch e.g. broken upstream forwarders
+	healthCheckAfter := r.reconcileHealthCheck(ctx, instance)
+
 	Log.Info("Reconciled Service successfully")
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: healthCheckAfter}, nil
+}
+
+// reconcileHealthCheck resolves the canary record via the service in the
+// background once the check interval elapsed, sets the ResolutionDegraded
+// condition from the result while the resolution fails or exceeds the latency
+// threshold and returns the duration until the next check
+func (r *DNSMasqReconciler) reconcileHealthCheck(
+	ctx context.Context,
+	instance *networkv1.DNSMasq,
+) time.Duration {
+	Log := r.GetLogger(ctx)
+	key := client.ObjectKeyFromObject(instance).String()
+
+	if instance.Spec.HealthCheck == nil {
+		r.healthChecker.Forget(key)
+		instance.Status.HealthCheckedAt = nil
+		instance.Status.ResolutionLatencyMilliseconds = 0
+		instance.Status.Conditions.Remove(networkv1.ResolutionDegradedCondition)
+		return 0
+	}
+
+	interval := time.Duration(instance.Spec.HealthCheck.IntervalSeconds) * time.Second
+	result, pending := r.healthChecker.Result(key)
+	if pending {
+		return healthCheckPollInterval
+	}
+	if result != nil {
+		r.applyHealthCheckResult(ctx, instance, result)
+	}
+
+	now := time.Now().UTC()
+	if last := instance.Status.HealthCheckedAt; last != nil {
+		if elapsed := now.Sub(last.Time); elapsed < interval {
+			return interval - elapsed
+		}
+	}
+	if len(instance.Status.DNSClusterAddresses) == 0 {
+		Log.Info("No cluster address to resolve the canary record via", "Name", instance.Name)
+		return interval
+	}
+
+	server := net.JoinHostPort(instance.Status.DNSClusterAddresses[0], strconv.Itoa(int(dnsmasq.DNSPort)))
+	r.healthChecker.Start(key, server, instance.Spec.HealthCheck.Hostname)
+
+	return healthCheckPollInterval
+}
+
+// applyHealthCheckResult sets the ResolutionDegraded condition and the status
+// of the health check from the result of a canary record resolution
+func (r *DNSMasqReconciler) applyHealthCheckResult(
+	ctx context.Context,
+	instance *networkv1.DNSMasq,
+	result *dnsmasq.HealthCheckResult,
+) {
+	Log := r.GetLogger(ctx)
+
+	hostname := result.Hostname
+	server, _, err := net.SplitHostPort(result.Server)
+	if err != nil {
+		server = result.Server
+	}
+	checkedAt := metav1.NewTime(result.CheckedAt)
+	instance.Status.HealthCheckedAt = &checkedAt
+
+	var degraded *condition.Condition
+	if result.Err != nil {
+		degraded = condition.TrueCondition(
+			networkv1.ResolutionDegradedCondition,
+			networkv1.ResolutionFailedMessage,
+			hostname,
+			server,
+			result.Err.Error())
+		degraded.Reason = networkv1.ResolutionFailedReason
+	} else {
+		instance.Status.ResolutionLatencyMilliseconds = result.Latency.Milliseconds()
+		if result.Latency.Milliseconds() > instance.Spec.HealthCheck.LatencyThresholdMilliseconds {
+			degraded = condition.TrueCondition(
+				networkv1.ResolutionDegradedCondition,
+				networkv1.ResolutionSlowMessage,
+				hostname,
+				server,
+				result.Latency.Milliseconds(),
+				instance.Spec.HealthCheck.LatencyThresholdMilliseconds)
+			degraded.Reason = networkv1.ResolutionSlowReason
+		}
+	}
+
+	previous := instance.Status.Conditions.Get(networkv1.ResolutionDegradedCondition)
+	if degraded == nil {
+		if previous != nil {
+			instance.Status.Conditions.Remove(networkv1.ResolutionDegradedCondition)
+			r.Recorder.Eventf(instance, corev1.EventTypeNormal, string(networkv1.ResolutionRecoveredReason),
+				networkv1.ResolutionRecoveredMessage, hostname, server)
+		}
+		return
+	}
+
+	Log.Info(degraded.Message)
+	if previous == nil || previous.Reason != degraded.Reason {
+		r.Recorder.Event(instance, corev1.EventTypeWarning, string(degraded.Reason), degraded.Message)
+	}
+	instance.Status.Conditions.Set(degraded)
+}
+
+// reconcilePodDisruptionBudget creates or updates the PodDisruptionBudget if
+// there are multiple replicas and deletes it otherwise
+func (r *DNSMasqReconciler) reconcilePodDisruptionBudget(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *networkv1.DNSMasq,
+) error {
+	Log := r.GetLogger(ctx)
+
+	desired := dnsmasq.PodDisruptionBudget(instance)
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      desired.Name,
+			Namespace: desired.Namespace,
+		},
+	}
+
+	// with a single replica the budget would only allow to disrupt the only pod
+	if instance.Spec.Replicas == nil || *instance.Spec.Replicas < 2 {
+		if err := r.deleteControlled(ctx, instance, pdb); err != nil {
+			return fmt.Errorf("error deleting poddisruptionbudget %s: %w", pdb.Name, err)
+		}
+		return nil
+	}
+
+	op, err := controllerutil.CreateOrPatch(ctx, r.Client, pdb, func() error {
+		pdb.Labels = util.MergeStringMaps(pdb.Labels, desired.Labels)
+		pdb.Spec = desired.Spec
+
+		return controllerutil.SetControllerReference(instance, pdb, h.GetScheme())
+	})
+	if err != nil {
+		return fmt.Errorf("error create/updating poddisruptionbudget %s: %w", pdb.Name, err)
+	}
+	if op != controllerutil.OperationResultNone {
+		Log.Info(fmt.Sprintf("PodDisruptionBudget %s successfully reconciled - operation: %s", pdb.Name, string(op)))
+	}
+
+	return nil
+}
+
+// reconcileNetworkPolicy creates or updates the NetworkPolicy if requested
+// in the spec and deletes it otherwise
+func (r *DNSMasqReconciler) reconcileNetworkPolicy(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *networkv1.DNSMasq,
+) error {
+	Log := r.GetLogger(ctx)
+
+	if instance.Spec.NetworkPolicy == nil {
+		np := &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      dnsmasq.ServiceName + "-" + instance.Name,
+				Namespace: instance.Namespace,
+			},
+		}
+		if err := r.deleteControlled(ctx, instance, np); err != nil {
+			return fmt.Errorf("error deleting networkpolicy %s: %w", np.Name, err)
+		}
+		return nil
+	}
+
+	desired := dnsmasq.NetworkPolicy(instance)
+	np := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      desired.Name,
+			Namespace: desired.Namespace,
+		},
+	}
+	op, err := controllerutil.CreateOrPatch(ctx, r.Client, np, func() error {
+		np.Labels = util.MergeStringMaps(np.Labels, desired.Labels)
+		np.Spec = desired.Spec
+
+		return controllerutil.SetControllerReference(instance, np, h.GetScheme())
+	})
+	if err != nil {
+		return fmt.Errorf("error create/updating networkpolicy %s: %w", np.Name, err)
+	}
+	if op != controllerutil.OperationResultNone {
+		Log.Info(fmt.Sprintf("NetworkPolicy %s successfully reconciled - operation: %s", np.Name, string(op)))
+	}
+
+	return nil
+}
+
+// reconcileMetrics creates or updates the metrics service and, if the
+// prometheus-operator CRDs are installed, the ServiceMonitor scraping it.
+// Both get deleted when the metrics sidecar is disabled.
+func (r *DNSMasqReconciler) reconcileMetrics(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *networkv1.DNSMasq,
+) error {
+	Log := r.GetLogger(ctx)
+
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dnsmasq.MetricsServiceName(instance),
+			Namespace: instance.Namespace,
+		},
+	}
+	serviceMonitor := &unstructured.Unstructured{}
+	serviceMonitor.SetGroupVersionKind(ServiceMonitorGVK)
+	serviceMonitor.SetName(svc.Name)
+	serviceMonitor.SetNamespace(instance.Namespace)
+
+	if instance.Spec.Metrics == nil {
+		if err := r.deleteControlled(ctx, instance, serviceMonitor); err != nil {
+			return fmt.Errorf("error deleting servicemonitor %s: %w", serviceMonitor.GetName(), err)
+		}
+		if err := r.deleteControlled(ctx, instance, svc); err != nil {
+			return fmt.Errorf("error deleting service %s: %w", svc.Name, err)
+		}
+		return nil
+	}
+
+	desired := dnsmasq.MetricsService(instance)
+	op, err := controllerutil.CreateOrPatch(ctx, r.Client, svc, func() error {
+		svc.Labels = util.MergeStringMaps(svc.Labels, desired.Labels)
+		svc.Spec.Selector = desired.Spec.Selector
+		svc.Spec.Ports = desired.Spec.Ports
+
+		return controllerutil.SetControllerReference(instance, svc, h.GetScheme())
+	})
+	if err != nil {
+		return fmt.Errorf("error create/updating service %s: %w", svc.Name, err)
+	}
+	if op != controllerutil.OperationResultNone {
+		Log.Info(fmt.Sprintf("Service %s successfully reconciled - operation: %s", svc.Name, string(op)))
+	}
+
+	// the ServiceMonitor is optional, skip it if the monitoring stack is not installed
+	_, err = r.Client.RESTMapper().RESTMapping(ServiceMonitorGVK.GroupKind(), ServiceMonitorGVK.Version)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			Log.Info("ServiceMonitor CRD not installed, skipping ServiceMonitor", "ServiceMonitor", serviceMonitor.GetName())
+			return nil
+		}
+		return err
+	}
+
+	op, err = controllerutil.CreateOrPatch(ctx, r.Client, serviceMonitor, func() error {
+		serviceMonitor.SetLabels(util.MergeStringMaps(serviceMonitor.GetLabels(), desired.Labels))
+
+		err := unstructured.SetNestedMap(serviceMonitor.Object, dnsmasq.ServiceMonitorSpec(instance), "spec")
+		if err != nil {
+			return err
+		}
+
+		return controllerutil.SetControllerReference(instance, serviceMonitor, h.GetScheme())
+	})
+	if err != nil {
+		return fmt.Errorf("error create/updating servicemonitor %s: %w", serviceMonitor.GetName(), err)
+	}
+	if op != controllerutil.OperationResultNone {
+		Log.Info(fmt.Sprintf("ServiceMonitor %s successfully reconciled - operation: %s", serviceMonitor.GetName(), string(op)))
+	}
+
+	return nil
 }
 
-// generateServiceConfigMaps - create create configmaps which hold service configuration
+// deleteControlled deletes obj, identified by its name and namespace, if it
+// exists and is controlled by the DNSMasq. Objects of someone else with the
+// same name are left alone.
+func (r *DNSMasqReconciler) deleteControlled(
+	ctx context.Context,
+	instance *networkv1.DNSMasq,
+	obj client.Object,
+) error {
+	Log := r.GetLogger(ctx)
+
+	err := r.Client.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+	if err != nil {
+		// the CRD of optional kinds, e.g. the ServiceMonitor, might not be installed
+		if k8s_errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return nil
+		}
+		return err
+	}
+	if !metav1.IsControlledBy(obj, instance) {
+		Log.Info(fmt.Sprintf("Not deleting %s, it is not owned by DNSMasq %s", obj.GetName(), instance.Name))
+		return nil
+	}
+	if !obj.GetDeletionTimestamp().IsZero() {
+		return nil
+	}
+
+	err = r.Client.Delete(ctx, obj)
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// generateServiceConfigMaps - create create configmaps which hold service configuration,
+// returns the hash of the aggregated hosts and the number of records of the DNSData
 func (r *DNSMasqReconciler) generateServiceConfigMaps(
 	ctx context.Context,
 	h *helper.Helper,
 	instance *networkv1.DNSMasq,
+	dnssecCfg string,
+	dnsDataCMs *corev1.ConfigMapList,
 	envVars *map[string]env.Setter,
-) error {
+) (string, int32, error) {
+	Log := r.GetLogger(ctx)
+
 	cmLabels := labels.GetLabels(instance, labels.GetGroupLabel(dnsmasq.ServiceName), map[string]string{})
 
 	configMapData := map[string]string{}
 
-	var cfg string
+	// the cache options first, cache options of existing CRs passed via
+	// spec.options before spec.cache existed take precedence
+	cfg := dnsmasq.CacheConfig(&instance.Spec.Cache)
 	for _, option := range instance.Spec.Options {
+		// the webhook rejects them, don't render options which would break or
+		// extend the config, e.g. created while the webhook was not active
+		if err := option.Validate(); err != nil {
+			Log.Info("Skipping invalid dnsmasq option", "error", err.Error())
+			continue
+		}
 		cfg += option.Key
 		if len(option.Values) > 0 {
 			values := strings.Join(option.Values, ",")
@@ -493,7 +1091,31 @@ func (r *DNSMasqReconciler) generateServiceConfigMaps(
 		}
 		cfg += "\n"
 	}
+	if instance.Spec.Authoritative != nil {
+		cfg += dnsmasq.AuthoritativeConfig(instance.Spec.Authoritative)
+	}
+	if instance.Spec.ClientSubnets != nil {
+		cfg += dnsmasq.ClientSubnetsConfig(instance.Spec.ClientSubnets)
+	}
+	cfg += dnssecCfg
 	configMapData[instance.Name] = cfg
+	if instance.Spec.UpstreamTLS != nil {
+		configMapData[dnsmasq.UnboundConfigKey] = dnsmasq.UnboundConfig(instance.Spec.UpstreamTLS)
+	}
+
+	// the hosts file of each DNSData and their config directives, which
+	// dnsmasq only reads on start
+	hostsData := map[string]string{}
+	var dnsDataCfg string
+	var recordCount int32
+	for _, cm := range dnsDataCMs.Items {
+		if hosts, ok := cm.Data[cm.Name]; ok {
+			hostsData[cm.Name] = hosts
+		}
+		dnsDataCfg += cm.Data[dnsmasq.DNSDataConfigKey]
+		recordCount += dnsmasq.CountRecords(cm.Data[cm.Name], cm.Data[dnsmasq.DNSDataConfigKey])
+	}
+	configMapData[dnsmasq.DNSDataConfigKey] = dnsDataCfg
 
 	cms := []util.Template{
 		{
@@ -506,5 +1128,31 @@ func (r *DNSMasqReconciler) generateServiceConfigMaps(
 		},
 	}
 
-	return configmap.EnsureConfigMaps(ctx, h, instance, cms, envVars)
+	err := configmap.EnsureConfigMaps(ctx, h, instance, cms, envVars)
+	if err != nil {
+		return "", 0, err
+	}
+
+	hostsCMs := []util.Template{
+		{
+			Name:         dnsmasq.HostsConfigMapName(instance),
+			Namespace:    instance.Namespace,
+			Type:         util.TemplateTypeNone,
+			InstanceType: instance.Kind,
+			CustomData:   hostsData,
+			Labels:       cmLabels,
+		},
+	}
+
+	err = configmap.EnsureConfigMaps(ctx, h, instance, hostsCMs, nil)
+	if err != nil {
+		return "", 0, err
+	}
+
+	hostsHash, err := util.ObjectHash(hostsData)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return hostsHash, recordCount, nil
 }
@@ -18,16 +18,22 @@ package network
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"sort"
+	"strings"
+	"time"
 
+	"golang.org/x/exp/slices"
 	corev1 "k8s.io/api/core/v1"
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
+	k8snet "k8s.io/utils/net"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -44,6 +50,11 @@ import (
 	util "github.com/openstack-k8s-operators/lib-common/modules/common/util"
 )
 
+const (
+	// consumerRequeueInterval - interval to check if the consumer of a deleted IPSet is gone
+	consumerRequeueInterval = 10 * time.Second
+)
+
 // IPSetReconciler reconciles a IPSet object
 type IPSetReconciler struct {
 	client.Client
@@ -62,6 +73,8 @@ func (r *IPSetReconciler) GetLogger(ctx context.Context) logr.Logger {
 //+kubebuilder:rbac:groups=network.openstack.org,resources=netconfigs,verbs=get;list;watch
 //+kubebuilder:rbac:groups=network.openstack.org,resources=reservations,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=network.openstack.org,resources=reservations/finalizers,verbs=update
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get
+//+kubebuilder:rbac:groups=apps,resources=statefulsets;deployments,verbs=get
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -182,10 +195,38 @@ func (r *IPSetReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager
 		return nil
 	})
 
+	// For each Reservation event trigger reconcile of the IPSets in the same
+	// namespace which could not get their reservation, e.g. a requested FixedIP
+	// might got released
+	pendingIPSetFN := handler.EnqueueRequestsFromMapFunc(func(o client.Object) []reconcile.Request {
+		Log := r.GetLogger(ctx)
+		result := []reconcile.Request{}
+
+		ipsets := &networkv1.IPSetList{}
+		if err := r.Client.List(ctx, ipsets, client.InNamespace(o.GetNamespace())); err != nil {
+			Log.Error(err, "Unable to retrieve IPSetList")
+			return nil
+		}
+
+		for _, i := range ipsets.Items {
+			if i.Status.Conditions.IsTrue(networkv1.ReservationReadyCondition) {
+				continue
+			}
+			result = append(result, reconcile.Request{
+				NamespacedName: client.ObjectKey{
+					Namespace: o.GetNamespace(),
+					Name:      i.Name,
+				},
+			})
+		}
+		return result
+	})
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&networkv1.IPSet{}).
 		Owns(&networkv1.Reservation{}).
 		Watches(&source.Kind{Type: &networkv1.NetConfig{}}, ipsetFN).
+		Watches(&source.Kind{Type: &networkv1.Reservation{}}, pendingIPSetFN).
 		Complete(r)
 }
 
@@ -193,6 +234,42 @@ func (r *IPSetReconciler) reconcileDelete(ctx context.Context, instance *network
 	Log := r.GetLogger(ctx)
 	Log.Info("Reconciling Service delete")
 
+	// keep the reservation while the consumer might still use the addresses
+	consumerGVK, consumerName, err := instance.GetConsumer()
+	if err != nil {
+		instance.Status.Conditions.MarkFalse(
+			networkv1.ConsumerReleasedCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			networkv1.ConsumerReleasedErrorMessage,
+			err.Error())
+		return ctrl.Result{}, err
+	}
+	if consumerGVK != nil {
+		consumer := &unstructured.Unstructured{}
+		consumer.SetGroupVersionKind(*consumerGVK)
+		err := r.Client.Get(ctx, types.NamespacedName{Namespace: instance.Namespace, Name: consumerName}, consumer)
+		if err == nil {
+			// arbitrary kinds are not watched, check again later
+			instance.Status.Conditions.MarkFalse(
+				networkv1.ConsumerReleasedCondition,
+				networkv1.ConsumerExistsReason,
+				condition.SeverityInfo,
+				networkv1.ConsumerExistsMessage,
+				consumerGVK.Kind,
+				consumerName)
+			return ctrl.Result{RequeueAfter: consumerRequeueInterval}, nil
+		} else if !k8s_errors.IsNotFound(err) {
+			instance.Status.Conditions.MarkFalse(
+				networkv1.ConsumerReleasedCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				networkv1.ConsumerReleasedErrorMessage,
+				err.Error())
+			return ctrl.Result{}, err
+		}
+	}
+
 	// Remove finalizer from reservation
 	res, err := r.getReservation(ctx, instance)
 	if err != nil && !k8s_errors.IsNotFound(err) {
@@ -253,7 +330,21 @@ func (r *IPSetReconciler) reconcileNormal(ctx context.Context, instance *network
 
 		// TODO: add validation, we expect only one netcfg in a namespace
 		ipSetRes, err := r.ensureReservation(ctx, instance, netcfg, helper, reservations)
-		if err != nil {
+		reservedErr := &ipam.FixedIPReservedError{}
+		if errors.As(err, &reservedErr) {
+			// nothing to retry until the other IPSet releases the address, the
+			// Reservation watch triggers the reconcile when this happens
+			instance.Status.Conditions.MarkFalse(
+				networkv1.ReservationReadyCondition,
+				networkv1.FixedIPReservedReason,
+				condition.SeverityError,
+				networkv1.FixedIPReservedMessage,
+				reservedErr.Address,
+				reservedErr.NetName,
+				reservedErr.IPSet)
+
+			return ctrl.Result{}, nil
+		} else if err != nil {
 			instance.Status.Conditions.MarkFalse(
 				networkv1.ReservationReadyCondition,
 				condition.ErrorReason,
@@ -263,21 +354,33 @@ func (r *IPSetReconciler) reconcileNormal(ctx context.Context, instance *network
 
 			return ctrl.Result{}, err
 		}
-		if len(ipSetRes.Spec.Reservation) != len(instance.Spec.Networks) {
+		requested := len(instance.Spec.Networks)
+		if instance.Spec.Ordinals != nil {
+			requested *= *instance.Spec.Ordinals
+		}
+		if len(ipSetRes.Spec.Reservation) != requested {
 			instance.Status.Conditions.MarkFalse(
 				networkv1.ReservationReadyCondition,
 				condition.ErrorReason,
 				condition.SeverityError,
 				networkv1.ReservationMisMatchErrorMessage,
 				len(instance.Status.Reservation),
-				len(instance.Spec.Networks))
+				requested)
 
 			return ctrl.Result{}, err
 		}
 
-		// sort instance.Status.Reservations by Network
+		// sort instance.Status.Reservations by Network, Subnet and Ordinal, a
+		// network can be requested for both IP families
 		sort.Slice(instance.Status.Reservation, func(i, j int) bool {
-			return instance.Status.Reservation[i].Network < instance.Status.Reservation[j].Network
+			resI, resJ := instance.Status.Reservation[i], instance.Status.Reservation[j]
+			if resI.Network != resJ.Network {
+				return resI.Network < resJ.Network
+			}
+			if resI.Subnet != resJ.Subnet {
+				return resI.Subnet < resJ.Subnet
+			}
+			return resI.Ordinal != nil && resJ.Ordinal != nil && *resI.Ordinal < *resJ.Ordinal
 		})
 
 		instance.Status.Conditions.MarkTrue(networkv1.ReservationReadyCondition, networkv1.ReservationReadyMessage)
@@ -332,6 +435,13 @@ func (r *IPSetReconciler) patchReservation(
 
 	// create or update the Reservation
 	op, err := controllerutil.CreateOrPatch(ctx, r.Client, res, func() error {
+		// drop the labels of released addresses, e.g. of the ordinals removed
+		// by a scale down
+		for key := range res.Labels {
+			if _, ok := labels[key]; !ok && strings.HasPrefix(key, ipam.IPAMLabelKey+"/") {
+				delete(res.Labels, key)
+			}
+		}
 		res.Labels = util.MergeStringMaps(res.Labels, labels)
 		res.Spec = spec
 
@@ -356,6 +466,22 @@ func (r *IPSetReconciler) patchReservation(
 	return res, nil
 }
 
+// currentAddress returns the address of the network and subnet in the
+// Reservation, for an IPSet with Ordinals the start of the block, nil if
+// there is none
+func currentAddress(res *networkv1.Reservation, netName networkv1.NetNameStr, subnetName networkv1.NetNameStr) net.IP {
+	for _, ip := range res.Spec.Reservation {
+		if ip.Ordinal != nil && *ip.Ordinal != 0 {
+			continue
+		}
+		if strings.EqualFold(string(ip.Network), string(netName)) && strings.EqualFold(string(ip.Subnet), string(subnetName)) {
+			return net.ParseIP(ip.Address)
+		}
+	}
+
+	return nil
+}
+
 func (r *IPSetReconciler) ensureReservation(
 	ctx context.Context,
 	ipset *networkv1.IPSet,
@@ -377,8 +503,12 @@ func (r *IPSetReconciler) ensureReservation(
 	}
 	reservationLabels := map[string]string{}
 
-	// always patch the Reservation
+	// always patch the Reservation, except if an address could not be assigned,
+	// the current addresses stay reserved until the assignment succeeds
 	defer func() {
+		if _err != nil {
+			return
+		}
 		reservation, _err = r.patchReservation(
 			ctx,
 			helper,
@@ -393,18 +523,29 @@ func (r *IPSetReconciler) ensureReservation(
 		}
 	}()
 
+	// the current Reservation, e.g. seeded from the ImportedReservations of the
+	// NetConfig, its addresses are kept
+	current := &networkv1.Reservation{}
+	for i := range reservations.Items {
+		if reservations.Items[i].Name == reservationName.Name {
+			current = &reservations.Items[i]
+			break
+		}
+	}
+
+	// networks requested for both IP families
+	netRequests := map[string]int{}
+	for _, ipsetNet := range ipset.Spec.Networks {
+		netRequests[strings.ToLower(string(ipsetNet.Name))]++
+	}
+
 	// create IPs per requested Network and Subnet
 	for _, ipsetNet := range ipset.Spec.Networks {
 		netDef, subnetDef, err := netcfg.GetNetAndSubnet(ipsetNet.Name, ipsetNet.SubnetName)
 		if err != nil {
 			return nil, err
 		}
-
-		// set net: subnet label
-		reservationLabels = util.MergeStringMaps(reservationLabels,
-			map[string]string{
-				fmt.Sprintf("%s/%s", ipam.IPAMLabelKey, string(netDef.Name)): string(subnetDef.Name),
-			})
+		dualStack := netRequests[strings.ToLower(string(ipsetNet.Name))] > 1
 
 		ipDetails := ipam.AssignIPDetails{
 			IPSet:       ipset.Name,
@@ -420,34 +561,100 @@ func (r *IPSetReconciler) ensureReservation(
 			}
 		}
 
-		ip, err := ipDetails.AssignIP()
+		if ipsetNet.FixedIP == nil {
+			// keep the address of the current Reservation
+			ipDetails.FixedIP = currentAddress(current, netDef.Name, subnetDef.Name)
+			ipDetails.CurrentIP = ipDetails.FixedIP != nil
+		}
+
+		ips, err := assignAddresses(ipset, ipsetNet, ipDetails)
 		if err != nil {
+			ipamAllocationFailures.WithLabelValues(ipset.Namespace, string(netDef.Name), string(subnetDef.Name)).Inc()
 			return nil, fmt.Errorf("failed to do ip reservation: %w", err)
 		}
 
-		// add IP to the reservation and IPSet status reservations
-		reservationSpec.Reservation[string(netDef.Name)] = *ip
-		ipsetRes := networkv1.IPSetReservation{
-			Network:   netDef.Name,
-			Subnet:    subnetDef.Name,
-			Address:   ip.Address,
-			MTU:       netDef.MTU,
-			Cidr:      subnetDef.Cidr,
-			Vlan:      subnetDef.Vlan,
-			Gateway:   subnetDef.Gateway,
-			Routes:    subnetDef.Routes,
-			DNSDomain: netDef.DNSDomain,
+		for _, ip := range ips {
+			// set net: subnet label
+			reservationKey := ipam.ReservationKey(netDef.Name, ip.Address, dualStack)
+			if ip.Ordinal != nil {
+				reservationKey = ipam.OrdinalReservationKey(netDef.Name, ip.Address, dualStack, *ip.Ordinal)
+			}
+			reservationLabels = util.MergeStringMaps(reservationLabels,
+				map[string]string{
+					fmt.Sprintf("%s/%s", ipam.IPAMLabelKey, reservationKey): string(subnetDef.Name),
+				})
+
+			// add IP to the reservation and IPSet status reservations
+			reservationSpec.Reservation[reservationKey] = *ip
+			ipset.Status.Reservation = append(ipset.Status.Reservation, ipSetReservation(ipsetNet, netDef, subnetDef, ip))
 		}
-		if ipsetNet.DefaultRoute != nil && *ipsetNet.DefaultRoute {
-			ipsetRes.Gateway = subnetDef.Gateway
-			ipsetRes.Routes = append(ipsetRes.Routes,
-				networkv1.Route{Destination: "0.0.0.0/0", Nexthop: *subnetDef.Gateway})
+	}
+
+	return reservation, nil
+}
+
+// assignAddresses assigns the address of the requested network, or the block
+// of addresses of the IPSet Ordinals
+func assignAddresses(
+	ipset *networkv1.IPSet,
+	ipsetNet networkv1.IPSetNetwork,
+	ipDetails ipam.AssignIPDetails,
+) ([]*networkv1.IPAddress, error) {
+	if ipset.Spec.Ordinals != nil {
+		// the current block is not moved, also if it can not grow, this would
+		// change the addresses of the existing ordinals
+		return ipDetails.AssignBlock(*ipset.Spec.Ordinals)
+	}
+
+	ip, err := ipDetails.AssignIP()
+	if err != nil && ipsetNet.FixedIP == nil && ipDetails.FixedIP != nil {
+		// the current address is not available anymore, e.g. got excluded
+		ipDetails.FixedIP = nil
+		ip, err = ipDetails.AssignIP()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return []*networkv1.IPAddress{ip}, nil
+}
+
+// ipSetReservation returns the IPSet status reservation of the address
+func ipSetReservation(
+	ipsetNet networkv1.IPSetNetwork,
+	netDef *networkv1.Network,
+	subnetDef *networkv1.Subnet,
+	ip *networkv1.IPAddress,
+) networkv1.IPSetReservation {
+	ipsetRes := networkv1.IPSetReservation{
+		Network:   netDef.Name,
+		Subnet:    subnetDef.Name,
+		Address:   ip.Address,
+		Ordinal:   ip.Ordinal,
+		MTU:       netDef.GetMTU(),
+		Cidr:      subnetDef.Cidr,
+		Vlan:      subnetDef.Vlan,
+		Gateway:   subnetDef.Gateway,
+		Routes:    subnetDef.Routes,
+		DNSDomain: netDef.DNSDomain,
+	}
+	if ipsetNet.DefaultRoute != nil && *ipsetNet.DefaultRoute {
+		defaultRoute := "0.0.0.0/0"
+		if k8snet.IsIPv6String(ip.Address) {
+			defaultRoute = "::/0"
 		}
-		if subnetDef.DNSDomain != nil {
-			ipsetRes.DNSDomain = *subnetDef.DNSDomain
+		ipsetRes.Gateway = subnetDef.Gateway
+		// copy the routes of the subnet to not modify the NetConfig, the subnet
+		// might already route the default destination
+		ipsetRes.Routes = append([]networkv1.Route{}, subnetDef.Routes...)
+		if !slices.ContainsFunc(ipsetRes.Routes, func(r networkv1.Route) bool { return r.Destination == defaultRoute }) {
+			ipsetRes.Routes = append(ipsetRes.Routes,
+				networkv1.Route{Destination: defaultRoute, Nexthop: *subnetDef.Gateway})
 		}
-		ipset.Status.Reservation = append(ipset.Status.Reservation, ipsetRes)
+	}
+	if subnetDef.DNSDomain != nil {
+		ipsetRes.DNSDomain = *subnetDef.DNSDomain
 	}
 
-	return reservation, nil
+	return ipsetRes
 }
@@ -0,0 +1,78 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// ipamAddresses - addresses of the AllocationRanges of a subnet, not excluded
+	ipamAddresses = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "infra_ipam_subnet_addresses",
+			Help: "Number of addresses in the allocation ranges of a subnet, not excluded",
+		},
+		[]string{"namespace", "network", "subnet"},
+	)
+
+	// ipamReservedAddresses - reserved addresses of the AllocationRanges of a subnet
+	ipamReservedAddresses = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "infra_ipam_subnet_reserved_addresses",
+			Help: "Number of reserved addresses in the allocation ranges of a subnet",
+		},
+		[]string{"namespace", "network", "subnet"},
+	)
+
+	// ipamFreeAddresses - free addresses of the AllocationRanges of a subnet
+	ipamFreeAddresses = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "infra_ipam_subnet_free_addresses",
+			Help: "Number of free addresses in the allocation ranges of a subnet",
+		},
+		[]string{"namespace", "network", "subnet"},
+	)
+
+	// ipamAllocationFailures - failed address reservations of IPSets per subnet
+	ipamAllocationFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "infra_ipam_allocation_failures_total",
+			Help: "Number of failed address reservations of IPSets in a subnet",
+		},
+		[]string{"namespace", "network", "subnet"},
+	)
+)
+
+func init() {
+	// served on the metrics endpoint of the manager
+	metrics.Registry.MustRegister(
+		ipamAddresses,
+		ipamReservedAddresses,
+		ipamFreeAddresses,
+		ipamAllocationFailures,
+	)
+}
+
+// deleteIPAMUtilizationMetrics - removes the utilization series of the subnets
+// in the namespace, e.g. when the NetConfig got deleted
+func deleteIPAMUtilizationMetrics(namespace string) {
+	labels := prometheus.Labels{"namespace": namespace}
+	ipamAddresses.DeletePartialMatch(labels)
+	ipamReservedAddresses.DeletePartialMatch(labels)
+	ipamFreeAddresses.DeletePartialMatch(labels)
+}
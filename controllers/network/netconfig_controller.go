@@ -0,0 +1,972 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/maps"
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/go-logr/logr"
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+	ipam "github.com/openstack-k8s-operators/infra-operator/pkg/ipam"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	configmap "github.com/openstack-k8s-operators/lib-common/modules/common/configmap"
+	env "github.com/openstack-k8s-operators/lib-common/modules/common/env"
+	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	util "github.com/openstack-k8s-operators/lib-common/modules/common/util"
+)
+
+const (
+	// ipOwnerConfigMapKey - key of the IP owner ConfigMap holding the owners keyed by IP
+	ipOwnerConfigMapKey = "owners.json"
+
+	// staleReservationAnnotation - annotation holding the time a Reservation was
+	// first found without its IPSet
+	staleReservationAnnotation = "network.openstack.org/stale-since"
+
+	// ipsetFinalizer - finalizer the IPSet controller adds to its Reservation
+	ipsetFinalizer = "IPSet"
+
+	// defaultStaleReservationTTL - used if the NetConfig has no StaleReservationTTL
+	defaultStaleReservationTTL = 10 * time.Minute
+
+	// netConfigNameLabel - label of the FRRConfiguration with the name of its NetConfig
+	netConfigNameLabel = "network.openstack.org/netconfig"
+
+	// netConfigNamespaceLabel - label of the FRRConfiguration with the namespace of its NetConfig
+	netConfigNamespaceLabel = "network.openstack.org/netconfig-namespace"
+)
+
+// frrConfigurationGVK - the FRR-K8s FRRConfiguration, the CRD is optional so it
+// is handled unstructured
+var frrConfigurationGVK = schema.GroupVersionKind{
+	Group:   "frrk8s.metallb.io",
+	Version: "v1beta1",
+	Kind:    "FRRConfiguration",
+}
+
+// NetConfigReconciler reconciles a NetConfig object
+type NetConfigReconciler struct {
+	client.Client
+	Kclient kubernetes.Interface
+	Scheme  *runtime.Scheme
+	// APIReader - not cache backed, used to confirm an IPSet is gone before
+	// its Reservations get released
+	APIReader client.Reader
+}
+
+// GetLogger returns a logger object with a prefix of "controller.name" and additional controller context fields
+func (r *NetConfigReconciler) GetLogger(ctx context.Context) logr.Logger {
+	return log.FromContext(ctx).WithName("Controllers").WithName("NetConfig")
+}
+
+//+kubebuilder:rbac:groups=network.openstack.org,resources=netconfigs,verbs=get;list;watch
+//+kubebuilder:rbac:groups=network.openstack.org,resources=netconfigs/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=network.openstack.org,resources=reservations,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=network.openstack.org,resources=ipsets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=network.openstack.org,resources=dnsdata,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete;
+//+kubebuilder:rbac:groups=frrk8s.metallb.io,resources=frrconfigurations,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+//
+// For more details, check Reconcile and its Result here:
+// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.13.0/pkg/reconcile
+func (r *NetConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, _err error) {
+	Log := r.GetLogger(ctx)
+
+	// Fetch the NetConfig instance
+	instance := &networkv1.NetConfig{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected.
+			// For additional cleanup logic use finalizers. Return and don't requeue.
+			deleteIPAMUtilizationMetrics(req.Namespace)
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	helper, err := helper.NewHelper(
+		instance,
+		r.Client,
+		r.Kclient,
+		r.Scheme,
+		Log,
+	)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Always patch the instance status when exiting this function so we can persist any changes.
+	defer func() {
+		// the AddressPoolAvailable condition does not affect the Ready condition
+		addressPool := instance.Status.Conditions.Get(networkv1.AddressPoolAvailableCondition)
+		instance.Status.Conditions.Remove(networkv1.AddressPoolAvailableCondition)
+		// update the Ready condition based on the sub conditions
+		if instance.Status.Conditions.AllSubConditionIsTrue() {
+			instance.Status.Conditions.MarkTrue(
+				condition.ReadyCondition, condition.ReadyMessage)
+		} else {
+			// something is not ready so reset the Ready condition
+			instance.Status.Conditions.MarkUnknown(
+				condition.ReadyCondition, condition.InitReason, condition.ReadyInitMessage)
+			// and recalculate it based on the state of the rest of the conditions
+			instance.Status.Conditions.Set(
+				instance.Status.Conditions.Mirror(condition.ReadyCondition))
+		}
+		instance.Status.Conditions.Set(addressPool)
+
+		err := helper.PatchInstance(ctx, instance)
+		if err != nil {
+			_err = err
+			return
+		}
+	}()
+
+	// initialize status
+	if instance.Status.Conditions == nil {
+		instance.Status.Conditions = condition.Conditions{}
+
+		cl := condition.CreateList(
+			condition.UnknownCondition(networkv1.IPOwnerReadyCondition, condition.InitReason, networkv1.IPOwnerReadyInitMessage),
+			condition.UnknownCondition(networkv1.ReservationsValidCondition, condition.InitReason, networkv1.ReservationsValidInitMessage),
+			condition.UnknownCondition(networkv1.DNSDataReadyCondition, condition.InitReason, networkv1.DNSDataReadyInitMessage),
+			condition.UnknownCondition(networkv1.BGPAnnouncementReadyCondition, condition.InitReason, networkv1.BGPAnnouncementReadyInitMessage),
+		)
+
+		instance.Status.Conditions.Init(&cl)
+
+		// Register overall status immediately to have an early feedback e.g. in the cli
+		return ctrl.Result{}, nil
+	}
+
+	// the FRRConfiguration of the BGPAnnouncement is not in the namespace of the
+	// NetConfig, the finalizer cleans it up. Add it before it gets created.
+	if instance.DeletionTimestamp.IsZero() && instance.Spec.BGPAnnouncement != nil &&
+		controllerutil.AddFinalizer(instance, helper.GetFinalizer()) {
+		return ctrl.Result{}, nil
+	}
+
+	// Handle service delete
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, instance, helper)
+	}
+
+	return r.reconcileNormal(ctx, instance, helper)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *NetConfigReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	netcfgFN := handler.EnqueueRequestsFromMapFunc(func(o client.Object) []reconcile.Request {
+		Log := r.GetLogger(ctx)
+		result := []reconcile.Request{}
+
+		// For each Reservation or IPSet update event get the list of all
+		// NetConfig to trigger reconcile for the one in the same namespace
+		netcfgs := &networkv1.NetConfigList{}
+
+		listOpts := []client.ListOption{
+			client.InNamespace(o.GetNamespace()),
+		}
+		if err := r.Client.List(ctx, netcfgs, listOpts...); err != nil {
+			Log.Error(err, "Unable to retrieve NetConfigList")
+			return nil
+		}
+
+		// For each netcfg instance create a reconcile request
+		for _, i := range netcfgs.Items {
+			name := client.ObjectKey{
+				Namespace: o.GetNamespace(),
+				Name:      i.Name,
+			}
+			result = append(result, reconcile.Request{NamespacedName: name})
+		}
+		if len(result) > 0 {
+			return result
+		}
+		return nil
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&networkv1.NetConfig{}).
+		Owns(&corev1.ConfigMap{}).
+		Owns(&networkv1.DNSData{}).
+		Watches(&source.Kind{Type: &networkv1.Reservation{}}, netcfgFN).
+		Watches(&source.Kind{Type: &networkv1.IPSet{}}, netcfgFN).
+		Complete(r)
+}
+
+func (r *NetConfigReconciler) reconcileDelete(ctx context.Context, instance *networkv1.NetConfig, helper *helper.Helper) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+	Log.Info("Reconciling Service delete")
+
+	// the FRRConfiguration is not in the namespace of the NetConfig, so it does
+	// not get garbage collected
+	if err := r.deleteFRRConfiguration(ctx, instance, helper); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	Log.Info("Reconciled Service delete successfully")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *NetConfigReconciler) reconcileNormal(ctx context.Context, instance *networkv1.NetConfig, helper *helper.Helper) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+	Log.Info("Reconciling Service")
+
+	// get list of Reservation objects in the namespace
+	reservations := &networkv1.ReservationList{}
+	err := r.List(ctx, reservations, &client.ListOptions{Namespace: instance.Namespace})
+	if err != nil {
+		instance.Status.Conditions.MarkFalse(
+			networkv1.IPOwnerReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			networkv1.ReservationListErrorMessage,
+			err.Error())
+		return ctrl.Result{}, err
+	}
+
+	// seed the Reservations of the ImportedReservations not adopted by an IPSet yet
+	reservations, err = r.importReservations(ctx, instance, reservations)
+	if err != nil {
+		instance.Status.Conditions.MarkFalse(
+			networkv1.IPOwnerReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			networkv1.ImportedReservationErrorMessage,
+			err.Error())
+		return ctrl.Result{}, err
+	}
+
+	// release the addresses of Reservations whose IPSet no longer exists
+	reservations, requeueAfter, err := r.releaseStaleReservations(ctx, instance, reservations)
+	if err != nil {
+		instance.Status.Conditions.MarkFalse(
+			networkv1.IPOwnerReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			networkv1.StaleReservationErrorMessage,
+			err.Error())
+		return ctrl.Result{}, err
+	}
+
+	duplicateOwners, err := r.generateIPOwnerConfigMap(ctx, helper, instance, reservations)
+	if err != nil {
+		instance.Status.Conditions.MarkFalse(
+			networkv1.IPOwnerReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			networkv1.IPOwnerReadyErrorMessage,
+			err.Error())
+		return ctrl.Result{}, err
+	}
+	instance.Status.IPOwnerConfigMap = instance.IPOwnerConfigMapName()
+	if len(duplicateOwners) > 0 {
+		instance.Status.Conditions.MarkFalse(
+			networkv1.IPOwnerReadyCondition,
+			networkv1.DuplicateIPOwnerReason,
+			condition.SeverityWarning,
+			networkv1.IPOwnerDuplicateMessage,
+			strings.Join(duplicateOwners, ", "))
+	} else {
+		instance.Status.Conditions.MarkTrue(networkv1.IPOwnerReadyCondition, networkv1.IPOwnerReadyMessage)
+	}
+
+	// the reserved addresses have to stay valid on subnet changes, e.g. when
+	// the allocation ranges got extended
+	instance.Status.InvalidReservations = ipam.InvalidReservations(instance, reservations)
+	if len(instance.Status.InvalidReservations) > 0 {
+		instance.Status.Conditions.MarkFalse(
+			networkv1.ReservationsValidCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			networkv1.ReservationsInvalidMessage,
+			strings.Join(instance.Status.InvalidReservations, ", "))
+	} else if len(instance.Status.ConflictingImports) > 0 {
+		instance.Status.Conditions.MarkFalse(
+			networkv1.ReservationsValidCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			networkv1.ImportedReservationsConflictMessage,
+			strings.Join(instance.Status.ConflictingImports, ", "))
+	} else {
+		instance.Status.Conditions.MarkTrue(networkv1.ReservationsValidCondition, networkv1.ReservationsValidMessage)
+	}
+
+	err = r.generateDNSData(ctx, instance, reservations)
+	if err != nil {
+		instance.Status.Conditions.MarkFalse(
+			networkv1.DNSDataReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			networkv1.DNSDataReadyErrorMessage,
+			err.Error())
+		return ctrl.Result{}, err
+	}
+	instance.Status.Conditions.MarkTrue(networkv1.DNSDataReadyCondition, networkv1.DNSDataReadyMessage)
+
+	err = r.generateFRRConfiguration(ctx, instance, helper, reservations)
+	if err != nil {
+		instance.Status.Conditions.MarkFalse(
+			networkv1.BGPAnnouncementReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			networkv1.BGPAnnouncementReadyErrorMessage,
+			err.Error())
+		return ctrl.Result{}, err
+	}
+	instance.Status.Conditions.MarkTrue(networkv1.BGPAnnouncementReadyCondition, networkv1.BGPAnnouncementReadyMessage)
+
+	r.updateSubnetUtilization(ctx, instance, reservations)
+
+	Log.Info("Reconciled Service successfully")
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// importReservations - creates a Reservation for each of the ImportedReservations
+// without one. The Reservation is named after the IPSet which will adopt it, the
+// IPSet controller then keeps the imported addresses. ImportedReservations with
+// an address already reserved are skipped and reported in ConflictingImports.
+func (r *NetConfigReconciler) importReservations(
+	ctx context.Context,
+	instance *networkv1.NetConfig,
+	reservations *networkv1.ReservationList,
+) (*networkv1.ReservationList, error) {
+	Log := r.GetLogger(ctx)
+
+	existing := map[string]bool{}
+	reserved := map[string]string{}
+	for _, res := range reservations.Items {
+		existing[res.Name] = true
+		for _, ip := range res.Spec.Reservation {
+			reserved[ipam.NormalizeAddress(ip.Address)] = res.Spec.IPSetRef.Name
+		}
+	}
+
+	conflicts := []string{}
+	for _, imported := range instance.Spec.ImportedReservations {
+		if existing[imported.IPSet] {
+			continue
+		}
+
+		// networks imported for both IP families
+		netRequests := map[string]int{}
+		for _, addr := range imported.Addresses {
+			netRequests[strings.ToLower(string(addr.Network))]++
+		}
+
+		res := networkv1.Reservation{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        imported.IPSet,
+				Namespace:   instance.Namespace,
+				Annotations: map[string]string{ipam.ImportedAnnotation: "true"},
+			},
+			Spec: networkv1.ReservationSpec{
+				IPSetRef: corev1.ObjectReference{
+					Namespace: instance.Namespace,
+					Name:      imported.IPSet,
+				},
+				Reservation: map[string]networkv1.IPAddress{},
+			},
+		}
+		conflict := false
+		for _, addr := range imported.Addresses {
+			address := ipam.NormalizeAddress(addr.Address)
+			if ipset, ok := reserved[address]; ok {
+				conflicts = append(conflicts, fmt.Sprintf("%s (IPSet %s, reserved for %s)", address, imported.IPSet, ipset))
+				conflict = true
+				continue
+			}
+			dualStack := netRequests[strings.ToLower(string(addr.Network))] > 1
+			res.Spec.Reservation[ipam.ReservationKey(addr.Network, address, dualStack)] = networkv1.IPAddress{
+				Network: addr.Network,
+				Subnet:  addr.Subnet,
+				Address: address,
+			}
+		}
+
+		// skip the whole IPSet, a partial import would hand out other addresses
+		if conflict {
+			Log.Info(fmt.Sprintf("Not importing Reservation %s, its addresses are already reserved", res.Name))
+			continue
+		}
+
+		if err := r.Create(ctx, &res); err != nil && !k8s_errors.IsAlreadyExists(err) {
+			return nil, err
+		}
+		Log.Info(fmt.Sprintf("Imported Reservation %s", res.Name))
+		reservations.Items = append(reservations.Items, res)
+		for _, ip := range res.Spec.Reservation {
+			reserved[ip.Address] = imported.IPSet
+		}
+	}
+	instance.Status.ConflictingImports = conflicts
+
+	return reservations, nil
+}
+
+// releaseStaleReservations - deletes the Reservations whose IPSet no longer
+// exists once the StaleReservationTTL expired, e.g. if the finalizer got removed
+// from the IPSet, so their addresses can be reserved again. Returns the
+// Reservations still holding addresses and when to check the stale ones again.
+func (r *NetConfigReconciler) releaseStaleReservations(
+	ctx context.Context,
+	instance *networkv1.NetConfig,
+	reservations *networkv1.ReservationList,
+) (*networkv1.ReservationList, time.Duration, error) {
+	Log := r.GetLogger(ctx)
+
+	ttl := defaultStaleReservationTTL
+	if instance.Spec.StaleReservationTTL != nil {
+		ttl = instance.Spec.StaleReservationTTL.Duration
+	}
+
+	live := &networkv1.ReservationList{}
+	stale := []string{}
+	requeueAfter := time.Duration(0)
+	for _, res := range reservations.Items {
+		res := res
+
+		exists, err := r.reservationIPSetExists(ctx, r.Client, &res)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		// an imported Reservation waits for its IPSet to get created
+		if _, ok := res.Annotations[ipam.ImportedAnnotation]; ok && res.Spec.IPSetRef.UID == "" {
+			live.Items = append(live.Items, res)
+			continue
+		}
+
+		if exists {
+			// the IPSet might got recreated, the Reservation is not stale anymore
+			if _, ok := res.Annotations[staleReservationAnnotation]; ok {
+				patch := client.MergeFrom(res.DeepCopy())
+				delete(res.Annotations, staleReservationAnnotation)
+				if err := r.Patch(ctx, &res, patch); err != nil {
+					return nil, 0, err
+				}
+			}
+			live.Items = append(live.Items, res)
+			continue
+		}
+
+		if ttl > 0 {
+			staleSince, err := time.Parse(time.RFC3339, res.Annotations[staleReservationAnnotation])
+			if err != nil {
+				staleSince = time.Now()
+				patch := client.MergeFrom(res.DeepCopy())
+				if res.Annotations == nil {
+					res.Annotations = map[string]string{}
+				}
+				res.Annotations[staleReservationAnnotation] = staleSince.Format(time.RFC3339)
+				if err := r.Patch(ctx, &res, patch); err != nil {
+					return nil, 0, err
+				}
+			}
+
+			if remaining := time.Until(staleSince.Add(ttl)); remaining > 0 {
+				stale = append(stale, res.Name)
+				live.Items = append(live.Items, res)
+				if requeueAfter == 0 || remaining < requeueAfter {
+					requeueAfter = remaining
+				}
+				continue
+			}
+		}
+
+		// the cache might not have seen a new IPSet yet, confirm it is gone
+		// with the API server before the addresses get released
+		if r.APIReader != nil {
+			exists, err := r.reservationIPSetExists(ctx, r.APIReader, &res)
+			if err != nil {
+				return nil, 0, err
+			}
+			if exists {
+				live.Items = append(live.Items, res)
+				continue
+			}
+		}
+
+		Log.Info(fmt.Sprintf("Releasing stale Reservation %s of IPSet %s", res.Name, res.Spec.IPSetRef.Name))
+		if controllerutil.RemoveFinalizer(&res, ipsetFinalizer) {
+			if err := r.Update(ctx, &res); err != nil && !k8s_errors.IsNotFound(err) {
+				return nil, 0, err
+			}
+		}
+		if err := r.Delete(ctx, &res); err != nil && !k8s_errors.IsNotFound(err) {
+			return nil, 0, err
+		}
+	}
+	instance.Status.StaleReservations = stale
+
+	return live, requeueAfter, nil
+}
+
+// reservationIPSetExists - returns true if the IPSet referenced by the
+// Reservation exists
+func (r *NetConfigReconciler) reservationIPSetExists(
+	ctx context.Context,
+	reader client.Reader,
+	res *networkv1.Reservation,
+) (bool, error) {
+	ipset := &networkv1.IPSet{}
+	err := reader.Get(ctx, client.ObjectKey{Namespace: res.Namespace, Name: res.Spec.IPSetRef.Name}, ipset)
+	if k8s_errors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	// an IPSet with the same name got created after the one of the Reservation got deleted
+	if res.Spec.IPSetRef.UID != "" && res.Spec.IPSetRef.UID != ipset.UID {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// generateDNSData - creates the DNSData with a <ipset>.<dnsDomain> host entry
+// for each address reserved on a network with PublishDNSRecords. The DNSData
+// gets deleted if none of the networks publishes its records.
+func (r *NetConfigReconciler) generateDNSData(
+	ctx context.Context,
+	instance *networkv1.NetConfig,
+	reservations *networkv1.ReservationList,
+) error {
+	Log := r.GetLogger(ctx)
+
+	dnsData := &networkv1.DNSData{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.DNSDataName(),
+			Namespace: instance.Namespace,
+		},
+	}
+
+	publish := false
+	for _, net := range instance.Spec.Networks {
+		publish = publish || net.PublishDNSRecords
+	}
+	if !publish {
+		// only delete the DNSData generated for the NetConfig, not one of the
+		// user with the same name
+		err := r.Client.Get(ctx, client.ObjectKeyFromObject(dnsData), dnsData)
+		if err != nil {
+			if k8s_errors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		if !metav1.IsControlledBy(dnsData, instance) || !dnsData.DeletionTimestamp.IsZero() {
+			return nil
+		}
+		err = r.Client.Delete(ctx, dnsData)
+		if err != nil && !k8s_errors.IsNotFound(err) {
+			return err
+		}
+		Log.Info(fmt.Sprintf("Deleted DNSData %s, no network publishes its DNS records", dnsData.Name))
+		return nil
+	}
+
+	dnsHosts := map[string]networkv1.DNSHost{}
+	for _, res := range reservations.Items {
+		for _, ip := range res.Spec.Reservation {
+			net, subnet, err := instance.GetNetAndSubnet(ip.Network, ip.Subnet)
+			if err != nil || !net.PublishDNSRecords {
+				continue
+			}
+			dnsDomain := net.DNSDomain
+			if subnet.DNSDomain != nil {
+				dnsDomain = *subnet.DNSDomain
+			}
+
+			// the address of a StatefulSet pod ordinal gets the pod name
+			hostname := res.Spec.IPSetRef.Name
+			if ip.Ordinal != nil {
+				hostname = fmt.Sprintf("%s-%d", hostname, *ip.Ordinal)
+			}
+
+			host := dnsHosts[ip.Address]
+			host.IP = ip.Address
+			host.Hostnames = append(host.Hostnames, hostname+"."+dnsDomain)
+			sort.Strings(host.Hostnames)
+			// the entry gets dropped by the DNSData controller once the IPSet got
+			// deleted, also before its Reservation got released
+			host.Owner = &networkv1.DNSHostOwner{
+				Kind: networkv1.DNSHostOwnerIPSet,
+				Name: res.Spec.IPSetRef.Name,
+			}
+			dnsHosts[ip.Address] = host
+		}
+	}
+
+	// sort entries for DNSData spec to reduce not required updates
+	keys := maps.Keys(dnsHosts)
+	sort.Strings(keys)
+	sortedDNSHosts := []networkv1.DNSHost{}
+	for _, key := range keys {
+		sortedDNSHosts = append(sortedDNSHosts, dnsHosts[key])
+	}
+
+	op, err := controllerutil.CreateOrPatch(ctx, r.Client, dnsData, func() error {
+		dnsData.Spec.DNSDataLabelSelectorValue = instance.Spec.DNSDataLabelSelectorValue
+		dnsData.Spec.Hosts = sortedDNSHosts
+		// dnsmasq answers the reverse lookups from the hosts file, PTR record
+		// directives would restart dnsmasq on every allocation
+		dnsData.Spec.PTRRecords = false
+
+		return controllerutil.SetControllerReference(instance, dnsData, r.Scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("error create/updating IPSet DNSData: %w", err)
+	}
+
+	if op != controllerutil.OperationResultNone {
+		Log.Info("operation:", "DNSData name", dnsData.Name, "Operation", string(op))
+	}
+
+	return nil
+}
+
+// generateFRRConfiguration - create the FRR-K8s FRRConfiguration announcing the
+// addresses reserved for the IPSets with the AnnotationBGPAnnounce annotation to
+// the BGP neighbors allowed by the operator. It gets created in the namespace of
+// FRR-K8s from the operator configuration, the finalizer of the NetConfig cleans
+// it up. An existing FRRConfiguration not labeled for the NetConfig is not taken over.
+func (r *NetConfigReconciler) generateFRRConfiguration(
+	ctx context.Context,
+	instance *networkv1.NetConfig,
+	h *helper.Helper,
+	reservations *networkv1.ReservationList,
+) error {
+	Log := r.GetLogger(ctx)
+
+	bgp := instance.Spec.BGPAnnouncement
+	if bgp == nil {
+		instance.Status.AnnouncedPrefixes = nil
+		return r.deleteFRRConfiguration(ctx, instance, h)
+	}
+
+	defaults := networkv1.GetNetConfigDefaults()
+	if !defaults.BGPEnabled() {
+		instance.Status.AnnouncedPrefixes = nil
+		return fmt.Errorf("the operator is not configured to announce addresses via BGP")
+	}
+	frrConfigKey := client.ObjectKey{
+		Namespace: defaults.FRRK8sNamespace,
+		Name:      instance.FRRConfigurationName(),
+	}
+
+	// drop the FRRConfiguration of a previous operator FRR-K8s namespace
+	if err := r.deleteStaleFRRConfigurations(ctx, instance, frrConfigKey); err != nil {
+		return err
+	}
+
+	ipsets := &networkv1.IPSetList{}
+	err := r.List(ctx, ipsets, &client.ListOptions{Namespace: instance.Namespace})
+	if err != nil {
+		return err
+	}
+	announced := map[string]bool{}
+	for _, ipset := range ipsets.Items {
+		announced[ipset.Name] = ipset.Annotations[networkv1.AnnotationBGPAnnounce] == "true"
+	}
+	prefixes := ipam.AnnouncedPrefixes(instance, reservations, announced)
+
+	allowedPrefixes := []interface{}{}
+	for _, prefix := range prefixes {
+		allowedPrefixes = append(allowedPrefixes, prefix)
+	}
+	neighbors := []interface{}{}
+	for _, neighbor := range defaults.AllowedBGPNeighbors(bgp) {
+		neighbors = append(neighbors, map[string]interface{}{
+			"address": neighbor.Address,
+			"asn":     neighbor.ASN,
+			"toAdvertise": map[string]interface{}{
+				"allowed": map[string]interface{}{
+					"prefixes": allowedPrefixes,
+				},
+			},
+		})
+	}
+	nodeSelector := map[string]interface{}{}
+	for key, value := range bgp.NodeSelector {
+		nodeSelector[key] = value
+	}
+
+	frrConfig := &unstructured.Unstructured{}
+	frrConfig.SetGroupVersionKind(frrConfigurationGVK)
+	frrConfig.SetName(frrConfigKey.Name)
+	frrConfig.SetNamespace(frrConfigKey.Namespace)
+
+	op, err := controllerutil.CreateOrPatch(ctx, r.Client, frrConfig, func() error {
+		// refuse to take over a FRRConfiguration of someone else
+		if frrConfig.GetResourceVersion() != "" &&
+			(frrConfig.GetLabels()[netConfigNameLabel] != instance.Name ||
+				frrConfig.GetLabels()[netConfigNamespaceLabel] != instance.Namespace) {
+			return fmt.Errorf("FRRConfiguration %s/%s exists and is not owned by NetConfig %s/%s",
+				frrConfig.GetNamespace(), frrConfig.GetName(), instance.Namespace, instance.Name)
+		}
+
+		frrConfig.SetLabels(util.MergeStringMaps(frrConfig.GetLabels(), map[string]string{
+			netConfigNameLabel:      instance.Name,
+			netConfigNamespaceLabel: instance.Namespace,
+		}))
+
+		return unstructured.SetNestedField(frrConfig.Object, map[string]interface{}{
+			"bgp": map[string]interface{}{
+				"routers": []interface{}{
+					map[string]interface{}{
+						"asn":       defaults.BGPASN,
+						"prefixes":  allowedPrefixes,
+						"neighbors": neighbors,
+					},
+				},
+			},
+			"nodeSelector": map[string]interface{}{
+				"matchLabels": nodeSelector,
+			},
+		}, "spec")
+	})
+	if err != nil {
+		return fmt.Errorf("error create/updating FRRConfiguration: %w", err)
+	}
+
+	if op != controllerutil.OperationResultNone {
+		Log.Info("operation:", "FRRConfiguration name", frrConfig.GetName(), "Operation", string(op))
+	}
+	instance.Status.AnnouncedPrefixes = prefixes
+
+	return nil
+}
+
+// deleteFRRConfiguration - delete the FRRConfiguration of the NetConfig and
+// remove the finalizer. Without the finalizer there is no FRRConfiguration.
+func (r *NetConfigReconciler) deleteFRRConfiguration(
+	ctx context.Context,
+	instance *networkv1.NetConfig,
+	h *helper.Helper,
+) error {
+	if !controllerutil.ContainsFinalizer(instance, h.GetFinalizer()) {
+		return nil
+	}
+
+	if err := r.deleteStaleFRRConfigurations(ctx, instance, client.ObjectKey{}); err != nil {
+		return err
+	}
+	controllerutil.RemoveFinalizer(instance, h.GetFinalizer())
+
+	return nil
+}
+
+// deleteStaleFRRConfigurations - delete the FRRConfigurations of the NetConfig
+// other than keep, with an empty keep all of them. A missing
+// FRRConfiguration CRD is not an error, there is nothing to delete then.
+func (r *NetConfigReconciler) deleteStaleFRRConfigurations(
+	ctx context.Context,
+	instance *networkv1.NetConfig,
+	keep client.ObjectKey,
+) error {
+	frrConfigs := &unstructured.UnstructuredList{}
+	frrConfigs.SetGroupVersionKind(frrConfigurationGVK)
+	err := r.Client.List(ctx, frrConfigs, client.MatchingLabels{
+		netConfigNameLabel:      instance.Name,
+		netConfigNamespaceLabel: instance.Namespace,
+	})
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil
+		}
+		return err
+	}
+
+	for idx := range frrConfigs.Items {
+		frrConfig := &frrConfigs.Items[idx]
+		if frrConfig.GetNamespace() == keep.Namespace && frrConfig.GetName() == keep.Name {
+			continue
+		}
+		if err := r.Client.Delete(ctx, frrConfig); err != nil && !k8s_errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateSubnetUtilization - sets the address utilization of the subnets of the
+// NetConfig in the status and metrics, and the AddressPoolAvailable condition
+// if a subnet has less free addresses than the FreeAddressesThreshold
+func (r *NetConfigReconciler) updateSubnetUtilization(
+	ctx context.Context,
+	instance *networkv1.NetConfig,
+	reservations *networkv1.ReservationList,
+) {
+	Log := r.GetLogger(ctx)
+
+	// drop the series of removed networks and subnets
+	deleteIPAMUtilizationMetrics(instance.Namespace)
+
+	threshold := int64(0)
+	if instance.Spec.FreeAddressesThreshold != nil {
+		threshold = *instance.Spec.FreeAddressesThreshold
+	}
+
+	utilization := []networkv1.SubnetUtilization{}
+	lowSubnets := []string{}
+	for _, net := range instance.Spec.Networks {
+		for _, subnet := range net.Subnets {
+			subnet := subnet
+			total, reserved, err := ipam.SubnetUtilization(net.Name, &subnet, reservations)
+			if err != nil {
+				Log.Error(err, fmt.Sprintf("Unable to get the utilization of subnet %s/%s", net.Name, subnet.Name))
+				continue
+			}
+			free := new(big.Int).Sub(total, reserved)
+
+			labels := []string{instance.Namespace, string(net.Name), string(subnet.Name)}
+			totalValue, _ := new(big.Float).SetInt(total).Float64()
+			reservedValue, _ := new(big.Float).SetInt(reserved).Float64()
+			freeValue, _ := new(big.Float).SetInt(free).Float64()
+			ipamAddresses.WithLabelValues(labels...).Set(totalValue)
+			ipamReservedAddresses.WithLabelValues(labels...).Set(reservedValue)
+			ipamFreeAddresses.WithLabelValues(labels...).Set(freeValue)
+
+			utilization = append(utilization, networkv1.SubnetUtilization{
+				Network:  net.Name,
+				Subnet:   subnet.Name,
+				Total:    cappedInt64(total),
+				Reserved: cappedInt64(reserved),
+				Free:     cappedInt64(free),
+			})
+
+			if threshold > 0 && free.Cmp(big.NewInt(threshold)) < 0 {
+				lowSubnets = append(lowSubnets, fmt.Sprintf("%s/%s (%s free)", net.Name, subnet.Name, free))
+			}
+		}
+	}
+	instance.Status.Subnets = utilization
+
+	if len(lowSubnets) > 0 {
+		instance.Status.Conditions.MarkFalse(
+			networkv1.AddressPoolAvailableCondition,
+			networkv1.AddressPoolLowReason,
+			condition.SeverityWarning,
+			networkv1.AddressPoolLowMessage,
+			threshold,
+			strings.Join(lowSubnets, ", "))
+		return
+	}
+	instance.Status.Conditions.MarkTrue(networkv1.AddressPoolAvailableCondition, networkv1.AddressPoolAvailableMessage)
+}
+
+// cappedInt64 - returns the value as int64, capped at the maximum int64 value
+func cappedInt64(value *big.Int) int64 {
+	if !value.IsInt64() {
+		return math.MaxInt64
+	}
+	return value.Int64()
+}
+
+// generateIPOwnerConfigMap - create the configmap holding the owner of each
+// reserved IP of the networks of the NetConfig, keyed by IP address. An IP
+// reserved for more than one IPSet keeps its first owner and gets returned
+// in the list of duplicates.
+func (r *NetConfigReconciler) generateIPOwnerConfigMap(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *networkv1.NetConfig,
+	reservations *networkv1.ReservationList,
+) ([]string, error) {
+	// iterate in a stable order, so the first owner of a duplicate IP does not change
+	items := append([]networkv1.Reservation{}, reservations.Items...)
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+
+	owners := map[string]networkv1.IPOwner{}
+	duplicates := []string{}
+	for _, res := range items {
+		for _, ip := range res.Spec.Reservation {
+			// only report IPs of networks managed by this NetConfig
+			if _, _, err := instance.GetNetAndSubnet(ip.Network, ip.Subnet); err != nil {
+				continue
+			}
+			addr := ipam.NormalizeAddress(ip.Address)
+			if owner, ok := owners[addr]; ok {
+				duplicates = append(duplicates, fmt.Sprintf("%s (IPSet %s, owned by %s)", addr, res.Spec.IPSetRef.Name, owner.IPSet))
+				continue
+			}
+			owners[addr] = networkv1.IPOwner{
+				IPSet:   res.Spec.IPSetRef.Name,
+				Network: ip.Network,
+				Subnet:  ip.Subnet,
+				Ordinal: ip.Ordinal,
+			}
+		}
+	}
+
+	sort.Strings(duplicates)
+
+	// json.Marshal sorts the map keys, so the content is stable
+	ownerData, err := json.Marshal(owners)
+	if err != nil {
+		return nil, err
+	}
+
+	cms := []util.Template{
+		{
+			Name:         instance.IPOwnerConfigMapName(),
+			Namespace:    instance.Namespace,
+			Type:         util.TemplateTypeNone,
+			InstanceType: instance.Kind,
+			CustomData:   map[string]string{ipOwnerConfigMapKey: string(ownerData)},
+			Labels:       map[string]string{},
+		},
+	}
+
+	return duplicates, configmap.EnsureConfigMaps(ctx, h, instance, cms, &map[string]env.Setter{})
+}
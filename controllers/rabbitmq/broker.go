@@ -0,0 +1,171 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rabbitmq
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/openstack-k8s-operators/infra-operator/pkg/rabbitmq"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	oko_secret "github.com/openstack-k8s-operators/lib-common/modules/common/secret"
+	rabbitmqclusterv1 "github.com/rabbitmq/cluster-operator/api/v1beta1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// broker - a RabbitMQ cluster managed through its management API
+type broker struct {
+	name         string
+	host         string
+	port         string
+	tls          bool
+	caSecretName string
+	// tlsConfig - client TLS config trusting the CA of the cluster, nil without TLS
+	tlsConfig *tls.Config
+	// mgmt - management API client authenticating as the default user of the cluster
+	mgmt rabbitmq.Management
+}
+
+// getBroker returns the broker of the RabbitmqCluster name, or nil if the
+// cluster is not ready yet
+func getBroker(
+	ctx context.Context,
+	h *helper.Helper,
+	namespace string,
+	name string,
+	newManagement rabbitmq.ManagementFactory,
+) (*broker, error) {
+	Log := h.GetLogger()
+
+	rabbit, err := getRabbitmqCluster(ctx, h, name, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	rabbitReady := false
+	for _, condition := range rabbit.Status.Conditions {
+		if condition.Reason == "AllPodsAreReady" && condition.Status == "True" {
+			rabbitReady = true
+			break
+		}
+	}
+	if !rabbitReady || rabbit.Status.DefaultUser == nil || rabbit.Status.DefaultUser.SecretReference == nil {
+		return nil, nil
+	}
+
+	rabbitSecret, _, err := oko_secret.GetSecret(ctx, h, rabbit.Status.DefaultUser.SecretReference.Name, namespace)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	data := map[string]string{}
+	for _, key := range []string{"username", "password", "host", "port"} {
+		value, ok := rabbitSecret.Data[key]
+		if !ok {
+			return nil, fmt.Errorf("%s does not exist in rabbitmq secret %s", key, rabbitSecret.Name)
+		}
+		data[key] = string(value)
+	}
+
+	tlsEnabled := false
+	if rabbit.Spec.TLS.SecretName != "" {
+		tlsEnabled = true
+	}
+	Log.Info(fmt.Sprintf("rabbitmq cluster %s has TLS enabled: %t", rabbit.Name, tlsEnabled))
+
+	var tlsConfig *tls.Config
+	if tlsEnabled {
+		tlsConfig, err = caTLSConfig(ctx, h, namespace, rabbit.Spec.TLS.CaSecretName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the default user of the cluster is used to manage the cluster
+	mgmt := managementClient(newManagement, data["host"], data["username"], data["password"], tlsConfig)
+
+	return &broker{
+		name:         rabbit.Name,
+		host:         data["host"],
+		port:         data["port"],
+		tls:          tlsEnabled,
+		caSecretName: rabbit.Spec.TLS.CaSecretName,
+		tlsConfig:    tlsConfig,
+		mgmt:         mgmt,
+	}, nil
+}
+
+// caTLSConfig returns the client TLS config trusting the ca.crt of the
+// secret caSecretName, or the system CAs if caSecretName is empty
+func caTLSConfig(
+	ctx context.Context,
+	h *helper.Helper,
+	namespace string,
+	caSecretName string,
+) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caSecretName == "" {
+		return tlsConfig, nil
+	}
+
+	caSecret, _, err := oko_secret.GetSecret(ctx, h, caSecretName, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error getting CA secret %s: %w", caSecretName, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caSecret.Data["ca.crt"]) {
+		return nil, fmt.Errorf("no CA certificate found in ca.crt of secret %s", caSecret.Name)
+	}
+	tlsConfig.RootCAs = pool
+
+	return tlsConfig, nil
+}
+
+// managementClient returns the client of the management API served on host,
+// authenticating as username, rabbitmq.DefaultManagementFactory is used if
+// newManagement is nil
+func managementClient(
+	newManagement rabbitmq.ManagementFactory,
+	host string,
+	username string,
+	password string,
+	tlsConfig *tls.Config,
+) rabbitmq.Management {
+	if newManagement == nil {
+		newManagement = rabbitmq.DefaultManagementFactory
+	}
+	return newManagement(rabbitmq.ManagementURL(host, tlsConfig != nil), username, password, tlsConfig)
+}
+
+// getRabbitmqCluster - get RabbitmqCluster object in namespace
+func getRabbitmqCluster(
+	ctx context.Context,
+	h *helper.Helper,
+	name string,
+	namespace string,
+) (*rabbitmqclusterv1.RabbitmqCluster, error) {
+	rabbitMqCluster := &rabbitmqclusterv1.RabbitmqCluster{}
+
+	err := h.GetClient().Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, rabbitMqCluster)
+
+	return rabbitMqCluster, err
+}
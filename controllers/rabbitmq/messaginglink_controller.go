@@ -0,0 +1,496 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"golang.org/x/exp/slices"
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	rabbitmqv1 "github.com/openstack-k8s-operators/infra-operator/apis/rabbitmq/v1beta1"
+	"github.com/openstack-k8s-operators/infra-operator/pkg/rabbitmq"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	oko_secret "github.com/openstack-k8s-operators/lib-common/modules/common/secret"
+	rabbitmqclusterv1 "github.com/rabbitmq/cluster-operator/api/v1beta1"
+)
+
+// MessagingLinkReconciler reconciles a MessagingLink object
+type MessagingLinkReconciler struct {
+	client.Client
+	Kclient kubernetes.Interface
+	Scheme  *runtime.Scheme
+	// NewManagement - returns the client of the RabbitMQ management API,
+	// rabbitmq.DefaultManagementFactory if not set
+	NewManagement rabbitmq.ManagementFactory
+}
+
+// GetLogger returns a logger object with a prefix of "controller.name" and additional controller context fields
+func (r *MessagingLinkReconciler) GetLogger(ctx context.Context) logr.Logger {
+	return log.FromContext(ctx).WithName("Controllers").WithName("MessagingLink")
+}
+
+//+kubebuilder:rbac:groups=rabbitmq.openstack.org,resources=messaginglinks,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=rabbitmq.openstack.org,resources=messaginglinks/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=rabbitmq.openstack.org,resources=messaginglinks/finalizers,verbs=update
+//+kubebuilder:rbac:groups=rabbitmq.com,resources=rabbitmqclusters,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.12.2/pkg/reconcile
+func (r *MessagingLinkReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, _err error) {
+	Log := r.GetLogger(ctx)
+	// Fetch the MessagingLink instance
+	instance := &rabbitmqv1.MessagingLink{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// Return and don't requeue
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	helper, err := helper.NewHelper(
+		instance,
+		r.Client,
+		r.Kclient,
+		r.Scheme,
+		Log,
+	)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Always patch the instance status when exiting this function so we can persist any changes.
+	defer func() {
+		// update the Ready condition based on the sub conditions
+		if instance.Status.Conditions.AllSubConditionIsTrue() {
+			instance.Status.Conditions.MarkTrue(
+				condition.ReadyCondition, condition.ReadyMessage)
+		} else {
+			// something is not ready so reset the Ready condition
+			instance.Status.Conditions.MarkUnknown(
+				condition.ReadyCondition, condition.InitReason, condition.ReadyInitMessage)
+			// and recalculate it based on the state of the rest of the conditions
+			instance.Status.Conditions.Set(
+				instance.Status.Conditions.Mirror(condition.ReadyCondition))
+		}
+		err := helper.PatchInstance(ctx, instance)
+		if err != nil {
+			_err = err
+			return
+		}
+	}()
+
+	// If we're not deleting this and the service object doesn't have our finalizer, add it.
+	if instance.DeletionTimestamp.IsZero() && controllerutil.AddFinalizer(instance, helper.GetFinalizer()) {
+		return ctrl.Result{}, nil
+	}
+
+	// initialize status
+	if instance.Status.Conditions == nil {
+		instance.Status.Conditions = condition.Conditions{}
+
+		cl := condition.CreateList(
+			condition.UnknownCondition(rabbitmqv1.MessagingLinkReadyCondition, condition.InitReason, rabbitmqv1.MessagingLinkReadyInitMessage),
+		)
+
+		instance.Status.Conditions.Init(&cl)
+
+		// Register overall status immediately to have an early feedback e.g. in the cli
+		return ctrl.Result{}, nil
+	}
+
+	// Handle MessagingLink delete
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, instance, helper)
+	}
+
+	// Handle non-deleted MessagingLinks
+	return r.reconcileNormal(ctx, instance, helper)
+}
+
+func (r *MessagingLinkReconciler) reconcileDelete(ctx context.Context, instance *rabbitmqv1.MessagingLink, helper *helper.Helper) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+
+	Log.Info("Reconciling MessagingLink delete")
+
+	if err := r.deleteLink(ctx, instance, helper); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// delete the user of the link on the clusters it got created on, links
+	// reconciled before the clusters got tracked fall back to the spec
+	clusters := instance.Status.UserRabbitmqClusterNames
+	if len(clusters) == 0 {
+		clusters = instance.LinkClusterNames()
+	}
+	if _, err := r.deleteUser(ctx, instance, helper, clusters); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// MessagingLink is deleted so remove the finalizer.
+	controllerutil.RemoveFinalizer(instance, helper.GetFinalizer())
+	Log.Info("Reconciled MessagingLink delete successfully")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *MessagingLinkReconciler) reconcileNormal(ctx context.Context, instance *rabbitmqv1.MessagingLink, helper *helper.Helper) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+
+	Log.Info("Reconciling MessagingLink")
+
+	if err := rabbitmq.ValidateLink(instance); err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			rabbitmqv1.MessagingLinkReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			rabbitmqv1.MessagingLinkReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+
+	brokers := []*broker{}
+	for _, name := range []string{instance.Spec.Source.RabbitmqClusterName, instance.Spec.Destination.RabbitmqClusterName} {
+		b, err := getBroker(ctx, helper, instance.Namespace, name, r.NewManagement)
+		if err != nil && !k8s_errors.IsNotFound(err) {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				rabbitmqv1.MessagingLinkReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				rabbitmqv1.MessagingLinkReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+		if b == nil {
+			// Wait on RabbitmqCluster to exist and be ready
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				rabbitmqv1.MessagingLinkReadyCondition,
+				condition.RequestedReason,
+				condition.SeverityInfo,
+				rabbitmqv1.MessagingLinkInProgressMessage))
+			return ctrl.Result{RequeueAfter: time.Duration(10) * time.Second}, nil
+		}
+		brokers = append(brokers, b)
+	}
+	src, dest := brokers[0], brokers[1]
+
+	// the link moved to other clusters, remove its user from the previous ones.
+	// Clusters which are not ready are kept to retry later.
+	previous := []string{}
+	for _, name := range instance.Status.UserRabbitmqClusterNames {
+		if !slices.Contains(instance.LinkClusterNames(), name) {
+			previous = append(previous, name)
+		}
+	}
+	pending, err := r.deleteUser(ctx, instance, helper, previous)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			rabbitmqv1.MessagingLinkReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			rabbitmqv1.MessagingLinkReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+	instance.Status.UserRabbitmqClusterNames = append(instance.LinkClusterNames(), pending...)
+
+	user, password, err := r.reconcileUser(ctx, helper, instance, src, dest)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			rabbitmqv1.MessagingLinkReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			rabbitmqv1.MessagingLinkReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+	srcURI := rabbitmq.LinkURI(rabbitmq.Transport{
+		Username: user,
+		Password: password,
+		Hosts:    []rabbitmq.Host{{Host: src.host, Port: src.port}},
+		TLS:      src.tls,
+		Vhost:    instance.Spec.Source.Vhost,
+	})
+	destURI := rabbitmq.LinkURI(rabbitmq.Transport{
+		Username: user,
+		Password: password,
+		Hosts:    []rabbitmq.Host{{Host: dest.host, Port: dest.port}},
+		TLS:      dest.tls,
+		Vhost:    instance.Spec.Destination.Vhost,
+	})
+
+	// the link moved to another cluster or vhost or changed its type, remove the previous one
+	if instance.Status.RabbitmqClusterName != "" &&
+		(instance.Status.RabbitmqClusterName != instance.LinkRabbitmqClusterName() ||
+			instance.Status.Vhost != instance.LinkVhost() ||
+			instance.Status.Type != instance.Spec.Type) {
+		if err := r.deleteLink(ctx, instance, helper); err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				rabbitmqv1.MessagingLinkReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				rabbitmqv1.MessagingLinkReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+		instance.Status.RabbitmqClusterName = ""
+		instance.Status.Vhost = ""
+		instance.Status.Type = ""
+	}
+
+	if instance.Spec.Type == rabbitmqv1.MessagingLinkTypeFederation {
+		vhost := instance.Spec.Destination.Vhost
+		err = dest.mgmt.PutParameter(ctx, rabbitmq.FederationUpstreamComponent, vhost, instance.Name,
+			rabbitmq.FederationUpstreamDefinition(instance, srcURI))
+		if err == nil {
+			err = dest.mgmt.PutPolicy(ctx, vhost, rabbitmq.FederationPolicyPrefix+instance.Name,
+				rabbitmq.FederationPolicy(instance))
+		}
+	} else {
+		err = src.mgmt.PutParameter(ctx, rabbitmq.ShovelComponent, instance.Spec.Source.Vhost, instance.Name,
+			rabbitmq.ShovelDefinition(instance, srcURI, destURI))
+	}
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			rabbitmqv1.MessagingLinkReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			rabbitmqv1.MessagingLinkReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+
+	instance.Status.Type = instance.Spec.Type
+	instance.Status.RabbitmqClusterName = instance.LinkRabbitmqClusterName()
+	instance.Status.Vhost = instance.LinkVhost()
+	instance.Status.Conditions.MarkTrue(rabbitmqv1.MessagingLinkReadyCondition, rabbitmqv1.MessagingLinkReadyMessage)
+
+	Log.Info("Reconciled MessagingLink successfully")
+	return ctrl.Result{}, nil
+}
+
+// reconcileUser creates the user the link connects with on the source and
+// the destination cluster, with permissions on their vhosts, and returns the
+// user name and password. The password is generated once and kept in a
+// Secret owned by the MessagingLink.
+func (r *MessagingLinkReconciler) reconcileUser(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *rabbitmqv1.MessagingLink,
+	src *broker,
+	dest *broker,
+) (string, string, error) {
+	user := rabbitmq.LinkUserPrefix + instance.Name
+
+	secretName := rabbitmq.LinkUserSecretPrefix + instance.Name
+	secret, _, err := oko_secret.GetSecret(ctx, h, secretName, instance.Namespace)
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return "", "", err
+	}
+
+	var password string
+	if err == nil {
+		password = string(secret.Data["password"])
+	}
+	if password == "" {
+		password, err = rabbitmq.GeneratePassword()
+		if err != nil {
+			return "", "", err
+		}
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretName,
+				Namespace: instance.Namespace,
+			},
+			Data: map[string][]byte{
+				"username": []byte(user),
+				"password": []byte(password),
+			},
+		}
+		if _, _, err := oko_secret.CreateOrPatchSecret(ctx, h, instance, secret); err != nil {
+			return "", "", err
+		}
+	}
+
+	// vhost-wide permissions, the link may move any queue or exchange
+	permissions := rabbitmq.Permissions{Configure: ".*", Write: ".*", Read: ".*"}
+	if err := grantUser(ctx, []*broker{src}, instance.Spec.Source.Vhost, user, password, permissions); err != nil {
+		return "", "", err
+	}
+	if err := grantUser(ctx, []*broker{dest}, instance.Spec.Destination.Vhost, user, password, permissions); err != nil {
+		return "", "", err
+	}
+
+	return user, password, nil
+}
+
+// deleteUser deletes the user of the link from the clusters. A cluster which
+// is gone has nothing left to delete, the clusters which are not ready get
+// skipped and returned.
+func (r *MessagingLinkReconciler) deleteUser(
+	ctx context.Context,
+	instance *rabbitmqv1.MessagingLink,
+	helper *helper.Helper,
+	clusters []string,
+) ([]string, error) {
+	skipped := []string{}
+	for _, name := range clusters {
+		b, err := getBroker(ctx, helper, instance.Namespace, name, r.NewManagement)
+		if k8s_errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if b == nil {
+			r.GetLogger(ctx).Info(fmt.Sprintf("rabbitmq cluster %s is not ready, skipping delete of the user of link %s",
+				name, instance.Name))
+			skipped = append(skipped, name)
+			continue
+		}
+		if err := b.mgmt.DeleteUser(ctx, rabbitmq.LinkUserPrefix+instance.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	return skipped, nil
+}
+
+// deleteLink deletes the shovel or the federation upstream and policy from the
+// cluster and vhost the link got configured on. Nothing is left to delete if
+// the cluster is gone, a cluster which is not ready gets skipped.
+func (r *MessagingLinkReconciler) deleteLink(ctx context.Context, instance *rabbitmqv1.MessagingLink, helper *helper.Helper) error {
+	if instance.Status.RabbitmqClusterName == "" {
+		return nil
+	}
+
+	b, err := getBroker(ctx, helper, instance.Namespace, instance.Status.RabbitmqClusterName, r.NewManagement)
+	if k8s_errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if b == nil {
+		// do not block on a cluster which is not ready, e.g. getting deleted as well
+		r.GetLogger(ctx).Info(fmt.Sprintf("rabbitmq cluster %s is not ready, skipping delete of link %s",
+			instance.Status.RabbitmqClusterName, instance.Name))
+		return nil
+	}
+
+	if instance.Status.Type == rabbitmqv1.MessagingLinkTypeFederation {
+		if err := b.mgmt.DeletePolicy(ctx, instance.Status.Vhost, rabbitmq.FederationPolicyPrefix+instance.Name); err != nil {
+			return err
+		}
+		return b.mgmt.DeleteParameter(ctx, rabbitmq.FederationUpstreamComponent, instance.Status.Vhost, instance.Name)
+	}
+	return b.mgmt.DeleteParameter(ctx, rabbitmq.ShovelComponent, instance.Status.Vhost, instance.Name)
+}
+
+// fields to index to reconcile when change
+const (
+	linkSourceRabbitmqClusterNameField      = ".spec.source.rabbitmqClusterName"
+	linkDestinationRabbitmqClusterNameField = ".spec.destination.rabbitmqClusterName"
+)
+
+var (
+	linkWatchFields = []string{
+		linkSourceRabbitmqClusterNameField,
+		linkDestinationRabbitmqClusterNameField,
+	}
+)
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MessagingLinkReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// index source.rabbitmqClusterName
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &rabbitmqv1.MessagingLink{}, linkSourceRabbitmqClusterNameField, func(rawObj client.Object) []string {
+		cr := rawObj.(*rabbitmqv1.MessagingLink)
+		return []string{cr.Spec.Source.RabbitmqClusterName}
+	}); err != nil {
+		return err
+	}
+
+	// index destination.rabbitmqClusterName
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &rabbitmqv1.MessagingLink{}, linkDestinationRabbitmqClusterNameField, func(rawObj client.Object) []string {
+		cr := rawObj.(*rabbitmqv1.MessagingLink)
+		return []string{cr.Spec.Destination.RabbitmqClusterName}
+	}); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&rabbitmqv1.MessagingLink{}).
+		Owns(&corev1.Secret{}).
+		Watches(
+			&source.Kind{Type: &rabbitmqclusterv1.RabbitmqCluster{}},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForSrc),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
+		Complete(r)
+}
+
+func (r *MessagingLinkReconciler) findObjectsForSrc(src client.Object) []reconcile.Request {
+	requests := []reconcile.Request{}
+
+	for _, field := range linkWatchFields {
+		crList := &rabbitmqv1.MessagingLinkList{}
+		listOps := &client.ListOptions{
+			FieldSelector: fields.OneTermEqualSelector(field, src.GetName()),
+			Namespace:     src.GetNamespace(),
+		}
+		err := r.List(context.TODO(), crList, listOps)
+		if err != nil {
+			return []reconcile.Request{}
+		}
+
+		for _, item := range crList.Items {
+			requests = append(requests,
+				reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      item.GetName(),
+						Namespace: item.GetNamespace(),
+					},
+				},
+			)
+		}
+	}
+
+	return requests
+}
@@ -0,0 +1,297 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	rabbitmqv1 "github.com/openstack-k8s-operators/infra-operator/apis/rabbitmq/v1beta1"
+	"github.com/openstack-k8s-operators/infra-operator/pkg/rabbitmq"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
+	rabbitmqclusterv1 "github.com/rabbitmq/cluster-operator/api/v1beta1"
+)
+
+// MessagingPolicyReconciler reconciles a MessagingPolicy object
+type MessagingPolicyReconciler struct {
+	client.Client
+	Kclient kubernetes.Interface
+	Scheme  *runtime.Scheme
+	// NewManagement - returns the client of the RabbitMQ management API,
+	// rabbitmq.DefaultManagementFactory if not set
+	NewManagement rabbitmq.ManagementFactory
+}
+
+// GetLogger returns a logger object with a prefix of "controller.name" and additional controller context fields
+func (r *MessagingPolicyReconciler) GetLogger(ctx context.Context) logr.Logger {
+	return log.FromContext(ctx).WithName("Controllers").WithName("MessagingPolicy")
+}
+
+//+kubebuilder:rbac:groups=rabbitmq.openstack.org,resources=messagingpolicies,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=rabbitmq.openstack.org,resources=messagingpolicies/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=rabbitmq.openstack.org,resources=messagingpolicies/finalizers,verbs=update
+//+kubebuilder:rbac:groups=rabbitmq.com,resources=rabbitmqclusters,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+
+// Reconcile - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.12.2/pkg/reconcile
+func (r *MessagingPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, _err error) {
+	Log := r.GetLogger(ctx)
+	// Fetch the MessagingPolicy instance
+	instance := &rabbitmqv1.MessagingPolicy{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// Return and don't requeue
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	helper, err := helper.NewHelper(
+		instance,
+		r.Client,
+		r.Kclient,
+		r.Scheme,
+		Log,
+	)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Always patch the instance status when exiting this function so we can persist any changes.
+	defer func() {
+		// update the Ready condition based on the sub conditions
+		if instance.Status.Conditions.AllSubConditionIsTrue() {
+			instance.Status.Conditions.MarkTrue(
+				condition.ReadyCondition, condition.ReadyMessage)
+		} else {
+			// something is not ready so reset the Ready condition
+			instance.Status.Conditions.MarkUnknown(
+				condition.ReadyCondition, condition.InitReason, condition.ReadyInitMessage)
+			// and recalculate it based on the state of the rest of the conditions
+			instance.Status.Conditions.Set(
+				instance.Status.Conditions.Mirror(condition.ReadyCondition))
+		}
+		err := helper.PatchInstance(ctx, instance)
+		if err != nil {
+			_err = err
+			return
+		}
+	}()
+
+	// If we're not deleting this and the service object doesn't have our finalizer, add it.
+	if instance.DeletionTimestamp.IsZero() && controllerutil.AddFinalizer(instance, helper.GetFinalizer()) {
+		return ctrl.Result{}, nil
+	}
+
+	// initialize status
+	if instance.Status.Conditions == nil {
+		instance.Status.Conditions = condition.Conditions{}
+
+		cl := condition.CreateList(
+			condition.UnknownCondition(rabbitmqv1.MessagingPolicyReadyCondition, condition.InitReason, rabbitmqv1.MessagingPolicyReadyInitMessage),
+		)
+
+		instance.Status.Conditions.Init(&cl)
+
+		// Register overall status immediately to have an early feedback e.g. in the cli
+		return ctrl.Result{}, nil
+	}
+
+	// Handle service delete
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, instance, helper)
+	}
+
+	// Handle non-deleted clusters
+	return r.reconcileNormal(ctx, instance, helper)
+}
+
+func (r *MessagingPolicyReconciler) reconcileDelete(ctx context.Context, instance *rabbitmqv1.MessagingPolicy, helper *helper.Helper) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+
+	Log.Info("Reconciling Service delete")
+
+	if err := r.deletePolicy(ctx, instance, helper); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Service is deleted so remove the finalizer.
+	controllerutil.RemoveFinalizer(instance, helper.GetFinalizer())
+	Log.Info("Reconciled Service delete successfully")
+
+	return ctrl.Result{}, nil
+}
+
+func (r *MessagingPolicyReconciler) reconcileNormal(ctx context.Context, instance *rabbitmqv1.MessagingPolicy, helper *helper.Helper) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+
+	Log.Info("Reconciling Service")
+
+	b, err := getBroker(ctx, helper, instance.Namespace, instance.Spec.RabbitmqClusterName, r.NewManagement)
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			rabbitmqv1.MessagingPolicyReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			rabbitmqv1.MessagingPolicyReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+	if b == nil {
+		// Wait on RabbitmqCluster to exist and be ready
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			rabbitmqv1.MessagingPolicyReadyCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			rabbitmqv1.MessagingPolicyInProgressMessage))
+		return ctrl.Result{RequeueAfter: time.Duration(10) * time.Second}, nil
+	}
+
+	// the policy moved to another cluster or vhost, remove the previous one
+	if instance.Status.RabbitmqClusterName != "" &&
+		(instance.Status.RabbitmqClusterName != instance.Spec.RabbitmqClusterName ||
+			instance.Status.Vhost != instance.Spec.Vhost) {
+		if err := r.deletePolicy(ctx, instance, helper); err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				rabbitmqv1.MessagingPolicyReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				rabbitmqv1.MessagingPolicyReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+		instance.Status.RabbitmqClusterName = ""
+		instance.Status.Vhost = ""
+	}
+
+	err = b.mgmt.PutPolicy(ctx, instance.Spec.Vhost, instance.Name, rabbitmq.MessagingPolicy(instance))
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			rabbitmqv1.MessagingPolicyReadyCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			rabbitmqv1.MessagingPolicyReadyErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+
+	instance.Status.RabbitmqClusterName = instance.Spec.RabbitmqClusterName
+	instance.Status.Vhost = instance.Spec.Vhost
+	instance.Status.Conditions.MarkTrue(rabbitmqv1.MessagingPolicyReadyCondition, rabbitmqv1.MessagingPolicyReadyMessage)
+
+	Log.Info("Reconciled Service successfully")
+	return ctrl.Result{}, nil
+}
+
+// deletePolicy deletes the policy from the cluster and vhost it got applied
+// on. Nothing is left to delete if the cluster is gone, a cluster which is
+// not ready gets skipped.
+func (r *MessagingPolicyReconciler) deletePolicy(ctx context.Context, instance *rabbitmqv1.MessagingPolicy, helper *helper.Helper) error {
+	if instance.Status.RabbitmqClusterName == "" {
+		return nil
+	}
+
+	b, err := getBroker(ctx, helper, instance.Namespace, instance.Status.RabbitmqClusterName, r.NewManagement)
+	if k8s_errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if b == nil {
+		// do not block on a cluster which is not ready, e.g. getting deleted as well
+		r.GetLogger(ctx).Info(fmt.Sprintf("rabbitmq cluster %s is not ready, skipping delete of policy %s",
+			instance.Status.RabbitmqClusterName, instance.Name))
+		return nil
+	}
+
+	return b.mgmt.DeletePolicy(ctx, instance.Status.Vhost, instance.Name)
+}
+
+// fields to index to reconcile when change
+const (
+	policyRabbitmqClusterNameField = ".spec.rabbitmqClusterName"
+)
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MessagingPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// index rabbitmqClusterName
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &rabbitmqv1.MessagingPolicy{}, policyRabbitmqClusterNameField, func(rawObj client.Object) []string {
+		// Extract the cluster name from the spec
+		cr := rawObj.(*rabbitmqv1.MessagingPolicy)
+		return []string{cr.Spec.RabbitmqClusterName}
+	}); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&rabbitmqv1.MessagingPolicy{}).
+		Watches(
+			&source.Kind{Type: &rabbitmqclusterv1.RabbitmqCluster{}},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForSrc),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
+		Complete(r)
+}
+
+func (r *MessagingPolicyReconciler) findObjectsForSrc(src client.Object) []reconcile.Request {
+	requests := []reconcile.Request{}
+
+	crList := &rabbitmqv1.MessagingPolicyList{}
+	listOps := &client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector(policyRabbitmqClusterNameField, src.GetName()),
+		Namespace:     src.GetNamespace(),
+	}
+	err := r.List(context.TODO(), crList, listOps)
+	if err != nil {
+		return []reconcile.Request{}
+	}
+
+	for _, item := range crList.Items {
+		requests = append(requests,
+			reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      item.GetName(),
+					Namespace: item.GetNamespace(),
+				},
+			},
+		)
+	}
+
+	return requests
+}
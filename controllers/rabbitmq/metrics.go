@@ -0,0 +1,66 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rabbitmq
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// transportURLGenerationSeconds - time from the creation of a TransportURL
+	// until its transport Secret got published the first time
+	transportURLGenerationSeconds = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "infra_transporturl_generation_duration_seconds",
+			Help:    "Time from the creation of a TransportURL until its transport secret got published",
+			Buckets: []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800},
+		},
+	)
+
+	// transportURLRotations - credential rotations per TransportURL
+	transportURLRotations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "infra_transporturl_credential_rotations_total",
+			Help: "Number of credential rotations of a TransportURL",
+		},
+		[]string{"namespace", "name"},
+	)
+
+	// transportURLConnectionFailures - failed broker connectivity checks per TransportURL
+	transportURLConnectionFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "infra_transporturl_connection_check_failures_total",
+			Help: "Number of failed AMQP connectivity checks of the credentials of a TransportURL",
+		},
+		[]string{"namespace", "name"},
+	)
+)
+
+func init() {
+	// served on the metrics endpoint of the manager
+	metrics.Registry.MustRegister(
+		transportURLGenerationSeconds,
+		transportURLRotations,
+		transportURLConnectionFailures,
+	)
+}
+
+// deleteTransportURLMetrics - removes the series of a deleted TransportURL
+func deleteTransportURLMetrics(namespace string, name string) {
+	transportURLRotations.DeleteLabelValues(namespace, name)
+	transportURLConnectionFailures.DeleteLabelValues(namespace, name)
+}
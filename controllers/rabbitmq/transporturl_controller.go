@@ -18,7 +18,10 @@ package rabbitmq
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"path"
+	"strconv"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -36,6 +39,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	rabbitmqv1 "github.com/openstack-k8s-operators/infra-operator/apis/rabbitmq/v1beta1"
+	"github.com/openstack-k8s-operators/infra-operator/pkg/rabbitmq"
 	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
 	helper "github.com/openstack-k8s-operators/lib-common/modules/common/helper"
 	oko_secret "github.com/openstack-k8s-operators/lib-common/modules/common/secret"
@@ -71,6 +75,15 @@ type TransportURLReconciler struct {
 	client.Client
 	Kclient kubernetes.Interface
 	Scheme  *runtime.Scheme
+	// NewManagement - returns the client of the RabbitMQ management API,
+	// rabbitmq.DefaultManagementFactory if not set
+	NewManagement rabbitmq.ManagementFactory
+	// Connect - opens an AMQP connection to verify the published credentials,
+	// rabbitmq.CheckConnection if not set
+	Connect rabbitmq.ConnectFunc
+	// APIReader - not cache backed, used to confirm no other TransportURL
+	// shares a vhost before it gets deleted
+	APIReader client.Reader
 }
 
 // GetLogger returns a logger object with a prefix of "controller.name" and additional controller context fields
@@ -149,42 +162,216 @@ func (r *TransportURLReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		}
 	}()
 
+	// If we're not deleting this and the service object doesn't have our finalizer, add it.
+	if instance.DeletionTimestamp.IsZero() && controllerutil.AddFinalizer(instance, helper.GetFinalizer()) {
+		return ctrl.Result{}, nil
+	}
+
+	// track the consumers independent of the state of the transport, so they are
+	// known before deleting or rotating it
+	instance.Status.Consumers = instance.ConsumerList()
+
+	// Handle service delete
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, instance, helper)
+	}
+
 	return r.reconcileNormal(ctx, instance, helper)
 
 }
 
+func (r *TransportURLReconciler) reconcileDelete(ctx context.Context, instance *rabbitmqv1.TransportURL, helper *helper.Helper) (ctrl.Result, error) {
+	Log := r.GetLogger(ctx)
+
+	Log.Info("Reconciling Service delete")
+
+	// the users and vhosts of external endpoints are not managed by the operator
+	if instance.Spec.External == nil {
+		if err := r.deleteUsers(ctx, helper, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	deleteTransportURLMetrics(instance.Namespace, instance.Name)
+
+	// Service is deleted so remove the finalizer.
+	controllerutil.RemoveFinalizer(instance, helper.GetFinalizer())
+	Log.Info("Reconciled Service delete successfully")
+
+	return ctrl.Result{}, nil
+}
+
 func (r *TransportURLReconciler) reconcileNormal(ctx context.Context, instance *rabbitmqv1.TransportURL, helper *helper.Helper) (ctrl.Result, error) {
 	Log := r.GetLogger(ctx)
 	Log.Info("Reconciling Service")
 
-	//TODO (implement a watch on the rabbitmq cluster resources to update things if there are changes)
-	rabbit, err := getRabbitmqCluster(ctx, helper, instance)
-	if err != nil {
-		return ctrl.Result{}, err
+	var brokers []*broker
+	var transport rabbitmq.Transport
+	var caSecretName string
+	// tlsConfigs - client TLS config of the hosts of the transports
+	tlsConfigs := map[rabbitmq.Host]*tls.Config{}
+	if instance.Spec.External != nil {
+		external, err := r.externalTransport(ctx, helper, instance)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				rabbitmqv1.TransportURLReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				rabbitmqv1.TransportURLReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+		if external == nil {
+			// Wait on the credentials secret
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				rabbitmqv1.TransportURLReadyCondition,
+				condition.RequestedReason,
+				condition.SeverityInfo,
+				rabbitmqv1.TransportURLInProgressMessage))
+			return ctrl.Result{RequeueAfter: time.Duration(10) * time.Second}, nil
+		}
+		transport = *external
+		caSecretName = instance.Spec.External.CaSecretName
+
+		var tlsConfig *tls.Config
+		if external.TLS {
+			tlsConfig, err = caTLSConfig(ctx, helper, instance.Namespace, caSecretName)
+			if err != nil {
+				instance.Status.Conditions.Set(condition.FalseCondition(
+					rabbitmqv1.TransportURLReadyCondition,
+					condition.ErrorReason,
+					condition.SeverityWarning,
+					rabbitmqv1.TransportURLReadyErrorMessage,
+					err.Error()))
+				return ctrl.Result{}, err
+			}
+		}
+		tlsConfigs[transport.Hosts[0]] = tlsConfig
+	} else {
+		if len(instance.RabbitmqClusterNames()) == 0 {
+			err := fmt.Errorf("either rabbitmqClusterName or external has to be set")
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				rabbitmqv1.TransportURLReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				rabbitmqv1.TransportURLReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+
+		brokers = []*broker{}
+		for _, name := range instance.RabbitmqClusterNames() {
+			b, err := getBroker(ctx, helper, instance.Namespace, name, r.NewManagement)
+			if err != nil {
+				instance.Status.Conditions.Set(condition.FalseCondition(
+					rabbitmqv1.TransportURLReadyCondition,
+					condition.ErrorReason,
+					condition.SeverityWarning,
+					rabbitmqv1.TransportURLReadyErrorMessage,
+					err.Error()))
+				return ctrl.Result{}, err
+			}
+			if b == nil {
+				// Wait on RabbitmqCluster to be ready
+				instance.Status.Conditions.Set(condition.FalseCondition(
+					rabbitmqv1.TransportURLReadyCondition,
+					condition.RequestedReason,
+					condition.SeverityInfo,
+					rabbitmqv1.TransportURLInProgressMessage))
+				return ctrl.Result{RequeueAfter: time.Duration(10) * time.Second}, nil
+			}
+			brokers = append(brokers, b)
+		}
+
+		// the clients use the same ssl setting for all hosts of the transport URL
+		tlsEnabled := brokers[0].tls
+		for _, b := range brokers[1:] {
+			if b.tls != tlsEnabled {
+				err := fmt.Errorf("rabbitmq clusters %s and %s differ in TLS", brokers[0].name, b.name)
+				instance.Status.Conditions.Set(condition.FalseCondition(
+					rabbitmqv1.TransportURLReadyCondition,
+					condition.ErrorReason,
+					condition.SeverityWarning,
+					rabbitmqv1.TransportURLReadyErrorMessage,
+					err.Error()))
+				return ctrl.Result{}, err
+			}
+		}
+
+		user, userPassword, err := r.reconcileUser(ctx, helper, instance, brokers, instance.VhostName())
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				rabbitmqv1.TransportURLReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				rabbitmqv1.TransportURLReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+
+		transport = rabbitmq.Transport{
+			Username: user,
+			Password: userPassword,
+			TLS:      tlsEnabled,
+			Vhost:    instance.VhostName(),
+		}
+		for _, b := range brokers {
+			host := rabbitmq.Host{Host: b.host, Port: b.port}
+			transport.Hosts = append(transport.Hosts, host)
+			tlsConfigs[host] = b.tlsConfig
+		}
+		caSecretName = brokers[0].caSecretName
 	}
 
-	// Wait on RabbitmqCluster to be ready
-	rabbitReady := false
-	for _, condition := range rabbit.Status.Conditions {
-		if condition.Reason == "AllPodsAreReady" && condition.Status == "True" {
-			rabbitReady = true
-			break
+	transport.Driver = instance.Spec.Driver
+	transport.Options = map[string]string{}
+	if instance.Spec.QuorumQueues {
+		if transport.Driver == rabbitmq.DriverAMQP1 {
+			err := fmt.Errorf("quorumQueues is not supported by the %s driver", transport.Driver)
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				rabbitmqv1.TransportURLReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				rabbitmqv1.TransportURLReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+		for key, value := range rabbitmq.QuorumQueueOptions {
+			transport.Options[key] = value
 		}
 	}
-	if !rabbitReady {
-		instance.Status.Conditions.Set(condition.FalseCondition(
-			rabbitmqv1.TransportURLReadyCondition,
-			condition.RequestedReason,
-			condition.SeverityInfo,
-			rabbitmqv1.TransportURLInProgressMessage))
-		return ctrl.Result{RequeueAfter: time.Duration(10) * time.Second}, nil
+	if instance.Spec.Connection != nil {
+		if transport.Driver == rabbitmq.DriverAMQP1 {
+			err := fmt.Errorf("connection is not supported by the %s driver", transport.Driver)
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				rabbitmqv1.TransportURLReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				rabbitmqv1.TransportURLReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+		for key, value := range connectionOptions(instance.Spec.Connection) {
+			transport.Options[key] = value
+		}
 	}
 
-	// TODO(dprince): Future we may want to use vhosts for each OpenStackService instead.
-	// vhosts would likely require use of https://github.com/rabbitmq/messaging-topology-operator/ which we do not yet include
-	rabbitSecret, _, err := oko_secret.GetSecret(ctx, helper, rabbit.Status.DefaultUser.SecretReference.Name, instance.Namespace)
-	if err != nil {
-		if k8s_errors.IsNotFound(err) {
+	var notification *rabbitmq.Transport
+	if instance.Spec.Notifications != nil {
+		var notificationBrokers []*broker
+		var err error
+		notification, notificationBrokers, err = r.notificationTransport(ctx, helper, instance, transport, brokers, tlsConfigs)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				rabbitmqv1.TransportURLReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				rabbitmqv1.TransportURLReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+		if notification == nil {
+			// Wait on RabbitmqCluster of the notifications to be ready
 			instance.Status.Conditions.Set(condition.FalseCondition(
 				rabbitmqv1.TransportURLReadyCondition,
 				condition.RequestedReason,
@@ -192,34 +379,71 @@ func (r *TransportURLReconciler) reconcileNormal(ctx context.Context, instance *
 				rabbitmqv1.TransportURLInProgressMessage))
 			return ctrl.Result{RequeueAfter: time.Duration(10) * time.Second}, nil
 		}
-		instance.Status.Conditions.Set(condition.FalseCondition(
-			rabbitmqv1.TransportURLReadyCondition,
-			condition.ErrorReason,
-			condition.SeverityWarning,
-			rabbitmqv1.TransportURLReadyErrorMessage,
-			err.Error()))
-		return ctrl.Result{}, err
+
+		// the user has to be retired on the brokers of the notifications as well
+		brokers = mergeBrokers(brokers, notificationBrokers)
 	}
 
-	var username string
-	if u, ok := rabbitSecret.Data["username"]; ok {
-		username = string(u)
-	} else {
-		err := fmt.Errorf("username does not exist in rabbitmq secret %s", rabbitSecret.Name)
-		instance.Status.Conditions.Set(condition.FalseCondition(
-			rabbitmqv1.TransportURLReadyCondition,
-			condition.ErrorReason,
-			condition.SeverityWarning,
-			rabbitmqv1.TransportURLReadyErrorMessage,
-			err.Error()))
-		return ctrl.Result{}, err
+	if instance.Spec.ClientCert != nil {
+		clientCert, err := r.clientCertificate(ctx, helper, instance, transport, notification)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				rabbitmqv1.TransportURLReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				rabbitmqv1.TransportURLReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+		if clientCert == nil {
+			// Wait on the client certificate secret
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				rabbitmqv1.TransportURLReadyCondition,
+				condition.RequestedReason,
+				condition.SeverityInfo,
+				rabbitmqv1.TransportURLInProgressMessage))
+			return ctrl.Result{RequeueAfter: time.Duration(10) * time.Second}, nil
+		}
+
+		// connect with the client certificate of the consumers
+		for host, tlsConfig := range tlsConfigs {
+			tlsConfig = tlsConfig.Clone()
+			tlsConfig.Certificates = []tls.Certificate{*clientCert}
+			tlsConfigs[host] = tlsConfig
+		}
+		paths := &rabbitmq.ClientCert{
+			CertFile: path.Join(instance.Spec.ClientCert.MountPath, corev1.TLSCertKey),
+			KeyFile:  path.Join(instance.Spec.ClientCert.MountPath, corev1.TLSPrivateKeyKey),
+			External: instance.Spec.ClientCert.External,
+		}
+		transport.ClientCert = paths
+		if notification != nil {
+			notification.ClientCert = paths
+		}
 	}
 
-	var password string
-	if p, ok := rabbitSecret.Data["password"]; ok {
-		password = string(p)
-	} else {
-		err := fmt.Errorf("password does not exist in rabbitmq secret %s", rabbitSecret.Name)
+	// Verify the brokers accept the credentials before publishing them
+	transports := []rabbitmq.Transport{transport}
+	if notification != nil {
+		transports = append(transports, *notification)
+	}
+	for _, t := range transports {
+		if err := r.checkConnections(ctx, t, tlsConfigs); err != nil {
+			transportURLConnectionFailures.WithLabelValues(instance.Namespace, instance.Name).Inc()
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				rabbitmqv1.TransportURLReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				rabbitmqv1.TransportURLReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Create a new secret with the transport URL for this CR
+	secret := r.createTransportURLSecret(instance, transport, notification)
+	_, err := getOwnedSecret(ctx, helper, instance, secret.Name)
+	if err != nil {
 		instance.Status.Conditions.Set(condition.FalseCondition(
 			rabbitmqv1.TransportURLReadyCondition,
 			condition.ErrorReason,
@@ -228,12 +452,8 @@ func (r *TransportURLReconciler) reconcileNormal(ctx context.Context, instance *
 			err.Error()))
 		return ctrl.Result{}, err
 	}
-
-	var host string
-	if h, ok := rabbitSecret.Data["host"]; ok {
-		host = string(h)
-	} else {
-		err := fmt.Errorf("host does not exist in rabbitmq secret %s", rabbitSecret.Name)
+	secretHash, op, err := oko_secret.CreateOrPatchSecret(ctx, helper, instance, secret)
+	if err != nil {
 		instance.Status.Conditions.Set(condition.FalseCondition(
 			rabbitmqv1.TransportURLReadyCondition,
 			condition.ErrorReason,
@@ -242,30 +462,31 @@ func (r *TransportURLReconciler) reconcileNormal(ctx context.Context, instance *
 			err.Error()))
 		return ctrl.Result{}, err
 	}
-
-	var port string
-	if p, ok := rabbitSecret.Data["port"]; ok {
-		port = string(p)
-	} else {
-		err := fmt.Errorf("port does not exist in rabbitmq secret %s", rabbitSecret.Name)
+	if op != controllerutil.OperationResultNone {
 		instance.Status.Conditions.Set(condition.FalseCondition(
 			rabbitmqv1.TransportURLReadyCondition,
-			condition.ErrorReason,
-			condition.SeverityWarning,
-			rabbitmqv1.TransportURLReadyErrorMessage,
-			err.Error()))
-		return ctrl.Result{}, err
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			rabbitmqv1.TransportURLReadyInitMessage))
+		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
 	}
 
-	tlsEnabled := false
-	if rabbit.Spec.TLS.SecretName != "" {
-		tlsEnabled = true
+	// the Secret got renamed, remove the previous one if it is still ours
+	if instance.Status.SecretName != "" && instance.Status.SecretName != secret.Name {
+		err := deleteOwnedSecret(ctx, helper, instance, instance.Status.SecretName)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				rabbitmqv1.TransportURLReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				rabbitmqv1.TransportURLReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
 	}
-	Log.Info(fmt.Sprintf("rabbitmq cluster %s has TLS enabled: %t", rabbit.Name, tlsEnabled))
 
-	// Create a new secret with the transport URL for this CR
-	secret := r.createTransportURLSecret(instance, string(username), string(password), string(host), string(port), tlsEnabled)
-	_, op, err := oko_secret.CreateOrPatchSecret(ctx, helper, instance, secret)
+	// delete the previous user once the consumers switched to the new credentials
+	retireAfter, err := r.retireUser(ctx, instance, brokers)
 	if err != nil {
 		instance.Status.Conditions.Set(condition.FalseCondition(
 			rabbitmqv1.TransportURLReadyCondition,
@@ -275,60 +496,620 @@ func (r *TransportURLReconciler) reconcileNormal(ctx context.Context, instance *
 			err.Error()))
 		return ctrl.Result{}, err
 	}
-	if op != controllerutil.OperationResultNone {
-		instance.Status.Conditions.Set(condition.FalseCondition(
-			rabbitmqv1.TransportURLReadyCondition,
-			condition.RequestedReason,
-			condition.SeverityInfo,
-			rabbitmqv1.TransportURLReadyInitMessage))
-		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
-	}
 
 	// Update the CR and return
+	if instance.Status.SecretName == "" {
+		// first publish of the transport Secret
+		transportURLGenerationSeconds.Observe(time.Since(instance.CreationTimestamp.Time).Seconds())
+	}
 	instance.Status.SecretName = secret.Name
+	instance.Status.SecretHash = secretHash
+	instance.Status.Vhost = transport.Vhost
+	instance.Status.NotificationVhost = ""
+	if notification != nil {
+		instance.Status.NotificationVhost = notification.Vhost
+	}
+	instance.Status.CaSecretName = ""
+	if transport.TLS {
+		// the CA of the server certificate, consumers need it to verify the broker
+		instance.Status.CaSecretName = caSecretName
+	}
+	instance.Status.ClientCertSecretName = ""
+	if instance.Spec.ClientCert != nil {
+		instance.Status.ClientCertSecretName = instance.Spec.ClientCert.SecretName
+	}
 
 	instance.Status.Conditions.MarkTrue(rabbitmqv1.TransportURLReadyCondition, rabbitmqv1.TransportURLReadyMessage)
 
 	Log.Info("Reconciled Service successfully")
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: retireAfter}, nil
+}
+
+// notificationTransport returns the transport of the notifications and its
+// brokers. It shares the user, the TLS setting and the options of the RPC
+// transport. Returns a nil transport if its Rabbitmq cluster is not ready yet.
+func (r *TransportURLReconciler) notificationTransport(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *rabbitmqv1.TransportURL,
+	rpc rabbitmq.Transport,
+	rpcBrokers []*broker,
+	tlsConfigs map[rabbitmq.Host]*tls.Config,
+) (*rabbitmq.Transport, []*broker, error) {
+	notification := rpc
+	notification.Vhost = instance.NotificationVhostName()
+
+	if instance.Spec.External != nil {
+		if instance.Spec.Notifications.RabbitmqClusterName != "" {
+			return nil, nil, fmt.Errorf("notifications.rabbitmqClusterName can not be set together with external")
+		}
+		return &notification, nil, nil
+	}
+
+	brokers := rpcBrokers
+	if name := instance.Spec.Notifications.RabbitmqClusterName; name != "" {
+		brokers = nil
+		for _, b := range rpcBrokers {
+			if b.name == name {
+				brokers = []*broker{b}
+			}
+		}
+		if brokers == nil {
+			b, err := getBroker(ctx, h, instance.Namespace, name, r.NewManagement)
+			if err != nil || b == nil {
+				return nil, nil, err
+			}
+			if b.tls != rpc.TLS {
+				return nil, nil, fmt.Errorf("rabbitmq clusters %s and %s differ in TLS", rpcBrokers[0].name, b.name)
+			}
+			brokers = []*broker{b}
+		}
+
+		notification.Hosts = nil
+		for _, b := range brokers {
+			host := rabbitmq.Host{Host: b.host, Port: b.port}
+			notification.Hosts = append(notification.Hosts, host)
+			tlsConfigs[host] = b.tlsConfig
+		}
+	}
+
+	err := grantUser(ctx, brokers, notification.Vhost, notification.Username, notification.Password,
+		userPermissions(instance))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &notification, brokers, nil
+}
+
+// mergeBrokers returns the brokers of a followed by the ones of b not in a
+func mergeBrokers(a []*broker, b []*broker) []*broker {
+	merged := append([]*broker{}, a...)
+	for _, nb := range b {
+		found := false
+		for _, ab := range a {
+			if ab.name == nb.name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, nb)
+		}
+	}
+	return merged
+}
+
+// externalTransport returns the transport of the external RabbitMQ endpoint,
+// or nil if its credentials secret does not exist yet
+func (r *TransportURLReconciler) externalTransport(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *rabbitmqv1.TransportURL,
+) (*rabbitmq.Transport, error) {
+	external := instance.Spec.External
+
+	secret, _, err := oko_secret.GetSecret(ctx, h, external.SecretName, instance.Namespace)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	data := map[string]string{}
+	for _, key := range []string{"username", "password"} {
+		value, ok := secret.Data[key]
+		if !ok {
+			return nil, fmt.Errorf("%s does not exist in secret %s", key, secret.Name)
+		}
+		data[key] = string(value)
+	}
+
+	port := external.Port
+	if port == 0 {
+		port = rabbitmq.AMQPPort
+		if external.TLS {
+			port = rabbitmq.AMQPTLSPort
+		}
+	}
+
+	return &rabbitmq.Transport{
+		Username: data["username"],
+		Password: data["password"],
+		Hosts:    []rabbitmq.Host{{Host: external.Host, Port: strconv.Itoa(int(port))}},
+		TLS:      external.TLS,
+		Vhost:    instance.VhostName(),
+	}, nil
+}
+
+// clientCertificate returns the client certificate of spec.clientCert, nil if
+// its secret does not exist yet. The transports have to use TLS.
+func (r *TransportURLReconciler) clientCertificate(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *rabbitmqv1.TransportURL,
+	transport rabbitmq.Transport,
+	notification *rabbitmq.Transport,
+) (*tls.Certificate, error) {
+	if !transport.TLS || (notification != nil && !notification.TLS) {
+		return nil, fmt.Errorf("clientCert requires TLS")
+	}
+
+	secretName := instance.Spec.ClientCert.SecretName
+	secret, _, err := oko_secret.GetSecret(ctx, h, secretName, instance.Namespace)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	cert, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return nil, fmt.Errorf("error loading the client certificate of secret %s: %w", secretName, err)
+	}
+	return &cert, nil
+}
+
+// checkConnections opens an AMQP connection with the credentials of the
+// transport to each of its hosts. The check speaks AMQP 0-9-1, which brokers
+// of the amqp1 driver do not necessarily serve, so those are not checked.
+func (r *TransportURLReconciler) checkConnections(
+	ctx context.Context,
+	transport rabbitmq.Transport,
+	tlsConfigs map[rabbitmq.Host]*tls.Config,
+) error {
+	if transport.Driver == rabbitmq.DriverAMQP1 {
+		return nil
+	}
+
+	connect := r.Connect
+	if connect == nil {
+		connect = rabbitmq.CheckConnection
+	}
+
+	vhost := transport.Vhost
+	if vhost == "" {
+		vhost = "/"
+	}
+	// without a password the client certificate gets authenticated
+	password := transport.Password
+	if transport.ClientCert != nil && transport.ClientCert.External {
+		password = ""
+	}
+	for _, host := range transport.Hosts {
+		err := connect(ctx, host, transport.Username, password, vhost, tlsConfigs[host])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getOwnedSecret returns the Secret if it exists and is controlled by the
+// TransportURL, nil if it does not exist. Secrets of someone else are never
+// taken over.
+func getOwnedSecret(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *rabbitmqv1.TransportURL,
+	name string,
+) (*corev1.Secret, error) {
+	secret, _, err := oko_secret.GetSecret(ctx, h, name, instance.Namespace)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !metav1.IsControlledBy(secret, instance) {
+		return nil, fmt.Errorf("Secret %s exists and is not owned by TransportURL %s", name, instance.Name)
+	}
+
+	return secret, nil
+}
+
+// deleteOwnedSecret deletes the Secret if it is controlled by the TransportURL
+func deleteOwnedSecret(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *rabbitmqv1.TransportURL,
+	name string,
+) error {
+	secret, _, err := oko_secret.GetSecret(ctx, h, name, instance.Namespace)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !metav1.IsControlledBy(secret, instance) {
+		h.GetLogger().Info(fmt.Sprintf("Not deleting Secret %s, it is not owned by TransportURL %s", name, instance.Name))
+		return nil
+	}
+	if err := h.GetClient().Delete(ctx, secret); err != nil && !k8s_errors.IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+// reconcileUser creates the vhost and the user of the transport with
+// permissions on the vhost on each broker and returns the user name and
+// password. The credentials are generated once and kept in a Secret owned by
+// the TransportURL.
+func (r *TransportURLReconciler) reconcileUser(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *rabbitmqv1.TransportURL,
+	brokers []*broker,
+	vhost string,
+) (string, string, error) {
+	Log := r.GetLogger(ctx)
+
+	secretName := rabbitmq.UserSecretPrefix + instance.Name
+	secret, err := getOwnedSecret(ctx, h, instance, secretName)
+	if err != nil {
+		return "", "", err
+	}
+
+	var user, password string
+	if secret != nil {
+		user = string(secret.Data["username"])
+		password = string(secret.Data["password"])
+	}
+	// a new rotation only starts once the user of the previous one got retired
+	rotate := user != "" && password != "" &&
+		instance.Spec.CredentialRotation != instance.Status.CredentialRotation &&
+		instance.Status.RetiringUser == ""
+	if user == "" || password == "" || rotate {
+		if rotate {
+			Log.Info(fmt.Sprintf("Rotating the credentials of user %s", user))
+			instance.Status.RetiringUser = user
+			instance.Status.RetiringSince = &metav1.Time{Time: time.Now()}
+			user, err = rabbitmq.RotatedUsername(instance.Name)
+		} else {
+			user = instance.Name
+		}
+		if err != nil {
+			return "", "", err
+		}
+		password, err = rabbitmq.GeneratePassword()
+		if err != nil {
+			return "", "", err
+		}
+		instance.Status.CredentialRotation = instance.Spec.CredentialRotation
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretName,
+				Namespace: instance.Namespace,
+			},
+			Data: map[string][]byte{
+				"username": []byte(user),
+				"password": []byte(password),
+			},
+		}
+		if _, _, err := oko_secret.CreateOrPatchSecret(ctx, h, instance, secret); err != nil {
+			return "", "", err
+		}
+		if rotate {
+			transportURLRotations.WithLabelValues(instance.Namespace, instance.Name).Inc()
+		}
+	}
+
+	if err := grantUser(ctx, brokers, vhost, user, password, userPermissions(instance)); err != nil {
+		return "", "", err
+	}
+
+	return user, password, nil
+}
+
+// grantUser creates the vhost and the user with the permissions on the vhost
+// on each broker
+func grantUser(
+	ctx context.Context,
+	brokers []*broker,
+	vhost string,
+	user string,
+	password string,
+	permissions rabbitmq.Permissions,
+) error {
+	for _, b := range brokers {
+		if err := b.mgmt.PutVhost(ctx, vhost); err != nil {
+			return err
+		}
+		if err := b.mgmt.PutUser(ctx, user, password); err != nil {
+			return err
+		}
+		if err := b.mgmt.PutPermissions(ctx, vhost, user, permissions); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteUsers deletes the users of the transport, including a retiring one, and
+// its vhosts on all clusters of the transport. Deleting a user removes its
+// permissions. Vhosts still used by another TransportURL and the default vhost
+// are kept. Clusters which are gone or not ready are skipped.
+func (r *TransportURLReconciler) deleteUsers(
+	ctx context.Context,
+	h *helper.Helper,
+	instance *rabbitmqv1.TransportURL,
+) error {
+	Log := r.GetLogger(ctx)
+
+	users := []string{}
+	secret, _, err := oko_secret.GetSecret(ctx, h, rabbitmq.UserSecretPrefix+instance.Name, instance.Namespace)
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && len(secret.Data["username"]) > 0 {
+		users = append(users, string(secret.Data["username"]))
+	}
+	if instance.Status.RetiringUser != "" {
+		users = append(users, instance.Status.RetiringUser)
+	}
+
+	vhosts := []string{}
+	for _, vhost := range []string{instance.Status.Vhost, instance.Status.NotificationVhost} {
+		if vhost == "" || vhost == "/" {
+			continue
+		}
+		inUse, err := r.vhostInUse(ctx, instance, vhost)
+		if err != nil {
+			return err
+		}
+		if !inUse {
+			vhosts = append(vhosts, vhost)
+		}
+	}
+
+	clusterNames := append([]string{}, instance.RabbitmqClusterNames()...)
+	for _, name := range instance.NotificationRabbitmqClusterNames() {
+		if !containsString(clusterNames, name) {
+			clusterNames = append(clusterNames, name)
+		}
+	}
+
+	for _, name := range clusterNames {
+		b, err := getBroker(ctx, h, instance.Namespace, name, r.NewManagement)
+		if err != nil && !k8s_errors.IsNotFound(err) {
+			return err
+		}
+		if b == nil {
+			continue
+		}
+		for _, user := range users {
+			if err := b.mgmt.DeleteUser(ctx, user); err != nil {
+				return err
+			}
+		}
+		for _, vhost := range vhosts {
+			if err := b.mgmt.DeleteVhost(ctx, vhost); err != nil {
+				return err
+			}
+		}
+		Log.Info(fmt.Sprintf("Deleted users %v and vhosts %v on %s", users, vhosts, name))
+	}
+
+	return nil
+}
+
+// vhostInUse returns true if another TransportURL of the namespace uses the
+// vhost. TransportURLs of the same consumer service share its vhost, the list
+// is read from the API server, so one created just now is not missed.
+func (r *TransportURLReconciler) vhostInUse(
+	ctx context.Context,
+	instance *rabbitmqv1.TransportURL,
+	vhost string,
+) (bool, error) {
+	var reader client.Reader = r.Client
+	if r.APIReader != nil {
+		reader = r.APIReader
+	}
+	transportURLs := &rabbitmqv1.TransportURLList{}
+	if err := reader.List(ctx, transportURLs, client.InNamespace(instance.Namespace)); err != nil {
+		return false, err
+	}
+	for _, tr := range transportURLs.Items {
+		if tr.Name == instance.Name || tr.Spec.External != nil {
+			continue
+		}
+		if tr.Status.Vhost == vhost || tr.Status.NotificationVhost == vhost ||
+			tr.VhostName() == vhost || tr.NotificationVhostName() == vhost {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// containsString returns true if s is in list
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// connectionOptions returns the oslo.messaging options of the set connection
+// handling fields
+func connectionOptions(connection *rabbitmqv1.TransportURLConnection) map[string]string {
+	options := map[string]string{}
+	if connection.HeartbeatTimeoutThreshold != nil {
+		options[rabbitmq.HeartbeatTimeoutThresholdOption] = strconv.Itoa(int(*connection.HeartbeatTimeoutThreshold))
+	}
+	if connection.RetryInterval != nil {
+		options[rabbitmq.RetryIntervalOption] = strconv.Itoa(int(*connection.RetryInterval))
+	}
+	if connection.KombuReconnectDelay != "" {
+		options[rabbitmq.KombuReconnectDelayOption] = connection.KombuReconnectDelay
+	}
+	return options
+}
+
+// userPermissions returns the permissions of the user of the transport,
+// unset ones default to all permissions
+func userPermissions(instance *rabbitmqv1.TransportURL) rabbitmq.Permissions {
+	permission := func(p *string) string {
+		if p == nil {
+			return ".*"
+		}
+		return *p
+	}
+
+	return rabbitmq.Permissions{
+		Configure: permission(instance.Spec.Permissions.Configure),
+		Write:     permission(instance.Spec.Permissions.Write),
+		Read:      permission(instance.Spec.Permissions.Read),
+	}
+}
+
+// retireUser deletes the previous user after a credential rotation once the
+// grace period elapsed and the user has no connections left, i.e. the
+// consumers picked up the new transport Secret. Returns the interval to check
+// again while the user is retiring.
+func (r *TransportURLReconciler) retireUser(
+	ctx context.Context,
+	instance *rabbitmqv1.TransportURL,
+	brokers []*broker,
+) (time.Duration, error) {
+	Log := r.GetLogger(ctx)
+
+	user := instance.Status.RetiringUser
+	if user == "" {
+		return 0, nil
+	}
+
+	if instance.Status.RetiringSince != nil {
+		remaining := time.Until(instance.Status.RetiringSince.Add(retireUserGracePeriod))
+		if remaining > 0 {
+			return remaining, nil
+		}
+	}
+
+	connections := 0
+	for _, b := range brokers {
+		count, err := b.mgmt.UserConnections(ctx, user)
+		if err != nil {
+			return 0, err
+		}
+		connections += count
+	}
+	if connections > 0 {
+		Log.Info(fmt.Sprintf("Waiting for %d connections of the previous user %s to be closed", connections, user))
+		return retireUserInterval, nil
+	}
+
+	for _, b := range brokers {
+		if err := b.mgmt.DeleteUser(ctx, user); err != nil {
+			return 0, err
+		}
+	}
+	Log.Info(fmt.Sprintf("Deleted the previous user %s", user))
+	instance.Status.RetiringUser = ""
+	instance.Status.RetiringSince = nil
+
+	return 0, nil
 }
 
 // Create k8s secret with transport URL
 func (r *TransportURLReconciler) createTransportURLSecret(
 	instance *rabbitmqv1.TransportURL,
-	username string,
-	password string,
-	host string,
-	port string,
-	tlsEnabled bool,
+	transport rabbitmq.Transport,
+	notification *rabbitmq.Transport,
 ) *corev1.Secret {
-	query := ""
-	if tlsEnabled {
-		query += "?ssl=1"
-	} else {
-		query += "?ssl=0"
-	}
+	keys := instance.Spec.SecretKeys
 
 	// Create a new secret with the transport URL for this CR
-	return &corev1.Secret{
+	secret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "rabbitmq-transport-url-" + instance.Name,
+			Name:      transportURLSecretName(instance),
 			Namespace: instance.Namespace,
 		},
 		Data: map[string][]byte{
-			"transport_url": []byte(fmt.Sprintf("rabbit://%s:%s@%s:%s/%s", username, password, host, port, query)),
+			secretKey(keys.TransportURL, rabbitmq.TransportURLKey): []byte(transport.URL()),
+			secretKey(keys.AMQPURL, rabbitmq.AMQPURLKey):           []byte(transport.AMQPURL()),
 		},
 	}
+	if notification != nil {
+		secret.Data[secretKey(keys.NotificationTransportURL, rabbitmq.NotificationTransportURLKey)] = []byte(notification.URL())
+		secret.Data[secretKey(keys.NotificationAMQPURL, rabbitmq.NotificationAMQPURLKey)] = []byte(notification.AMQPURL())
+	}
+	// the queue prefix options are no transport URL options, they get
+	// published as configuration snippet for the consumers
+	if instance.Spec.QueuePrefix != "" {
+		secret.Data[secretKey(keys.QueuePrefixConfig, rabbitmq.QueuePrefixConfigKey)] = []byte(
+			rabbitmq.QueuePrefixConfig(transport.Driver, instance.Spec.QueuePrefix))
+	}
+
+	return secret
+}
+
+// transportURLSecretName returns the name of the Secret holding the transport
+// URLs, spec.secretName if set
+func transportURLSecretName(instance *rabbitmqv1.TransportURL) string {
+	if instance.Spec.SecretName != "" {
+		return instance.Spec.SecretName
+	}
+	return rabbitmq.TransportURLSecretPrefix + instance.Name
+}
+
+// secretKey returns key, or defaultKey if key is empty
+func secretKey(key string, defaultKey string) string {
+	if key == "" {
+		return defaultKey
+	}
+	return key
 }
 
+const (
+	// retireUserGracePeriod - minimum time the previous user is kept after a
+	// credential rotation, for the consumers to start rolling to the new Secret
+	retireUserGracePeriod = 5 * time.Minute
+	// retireUserInterval - interval to check the connections of the previous user
+	retireUserInterval = 30 * time.Second
+)
+
 // fields to index to reconcile when change
 const (
-	rabbitmqClusterNameField = ".spec.rabbitmqClusterName"
+	rabbitmqClusterNameField  = ".spec.rabbitmqClusterName"
+	externalSecretNameField   = ".spec.external.secretName"
+	externalCaSecretNameField = ".spec.external.caSecretName"
+	clientCertSecretNameField = ".spec.clientCert.secretName"
 )
 
 var (
 	allWatchFields = []string{
 		rabbitmqClusterNameField,
+		externalSecretNameField,
+		externalCaSecretNameField,
+		clientCertSecretNameField,
 	}
 )
 
@@ -336,12 +1117,46 @@ var (
 func (r *TransportURLReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	// index caSecretName
 	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &rabbitmqv1.TransportURL{}, rabbitmqClusterNameField, func(rawObj client.Object) []string {
-		// Extract the secret name from the spec, if one is provided
+		// Extract the cluster names from the spec
+		cr := rawObj.(*rabbitmqv1.TransportURL)
+		names := append([]string{}, cr.RabbitmqClusterNames()...)
+		if cr.Spec.Notifications != nil && cr.Spec.Notifications.RabbitmqClusterName != "" {
+			names = append(names, cr.Spec.Notifications.RabbitmqClusterName)
+		}
+		return names
+	}); err != nil {
+		return err
+	}
+
+	// index external.secretName
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &rabbitmqv1.TransportURL{}, externalSecretNameField, func(rawObj client.Object) []string {
+		cr := rawObj.(*rabbitmqv1.TransportURL)
+		if cr.Spec.External == nil {
+			return nil
+		}
+		return []string{cr.Spec.External.SecretName}
+	}); err != nil {
+		return err
+	}
+
+	// index external.caSecretName
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &rabbitmqv1.TransportURL{}, externalCaSecretNameField, func(rawObj client.Object) []string {
+		cr := rawObj.(*rabbitmqv1.TransportURL)
+		if cr.Spec.External == nil || cr.Spec.External.CaSecretName == "" {
+			return nil
+		}
+		return []string{cr.Spec.External.CaSecretName}
+	}); err != nil {
+		return err
+	}
+
+	// index clientCert.secretName
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &rabbitmqv1.TransportURL{}, clientCertSecretNameField, func(rawObj client.Object) []string {
 		cr := rawObj.(*rabbitmqv1.TransportURL)
-		if cr.Spec.RabbitmqClusterName == "" {
+		if cr.Spec.ClientCert == nil {
 			return nil
 		}
-		return []string{cr.Spec.RabbitmqClusterName}
+		return []string{cr.Spec.ClientCert.SecretName}
 	}); err != nil {
 		return err
 	}
@@ -354,6 +1169,11 @@ func (r *TransportURLReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			handler.EnqueueRequestsFromMapFunc(r.findObjectsForSrc),
 			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
 		).
+		Watches(
+			&source.Kind{Type: &corev1.Secret{}},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForSecret),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
 		Complete(r)
 }
 
@@ -386,15 +1206,26 @@ func (r *TransportURLReconciler) findObjectsForSrc(src client.Object) []reconcil
 	return requests
 }
 
-// GetRabbitmqCluster - get RabbitmqCluster object in namespace
-func getRabbitmqCluster(
-	ctx context.Context,
-	h *helper.Helper,
-	instance *rabbitmqv1.TransportURL,
-) (*rabbitmqclusterv1.RabbitmqCluster, error) {
-	rabbitMqCluster := &rabbitmqclusterv1.RabbitmqCluster{}
+// findObjectsForSecret returns the TransportURLs referencing the Secret, either
+// directly or as the default user secret, holding the host and port, or the CA
+// secret of their RabbitmqClusters
+func (r *TransportURLReconciler) findObjectsForSecret(src client.Object) []reconcile.Request {
+	requests := r.findObjectsForSrc(src)
 
-	err := h.GetClient().Get(ctx, types.NamespacedName{Name: instance.Spec.RabbitmqClusterName, Namespace: instance.Namespace}, rabbitMqCluster)
+	rabbitList := &rabbitmqclusterv1.RabbitmqClusterList{}
+	err := r.List(context.TODO(), rabbitList, client.InNamespace(src.GetNamespace()))
+	if err != nil {
+		return requests
+	}
+
+	for i := range rabbitList.Items {
+		rabbit := &rabbitList.Items[i]
+		defaultUser := rabbit.Status.DefaultUser
+		if rabbit.Spec.TLS.CaSecretName == src.GetName() ||
+			(defaultUser != nil && defaultUser.SecretReference != nil && defaultUser.SecretReference.Name == src.GetName()) {
+			requests = append(requests, r.findObjectsForSrc(rabbit)...)
+		}
+	}
 
-	return rabbitMqCluster, err
+	return requests
 }
@@ -21,15 +21,21 @@ import (
 	"fmt"
 	"time"
 
+	"golang.org/x/exp/slices"
+
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/go-logr/logr"
 	redisv1 "github.com/openstack-k8s-operators/infra-operator/apis/redis/v1beta1"
@@ -45,6 +51,7 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
 
+	managed "github.com/openstack-k8s-operators/infra-operator/pkg/managed"
 	redis "github.com/openstack-k8s-operators/infra-operator/pkg/redis"
 	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
 
@@ -71,6 +78,9 @@ var (
 	}
 )
 
+// rebalanceInterval - interval to check the replica placement while rebalancing
+const rebalanceInterval = 10 * time.Second
+
 // Reconciler reconciles a Redis object
 type Reconciler struct {
 	client.Client
@@ -87,6 +97,7 @@ type Reconciler struct {
 // RBAC for deployments and their pods
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
 
 // RBAC for services
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete;
@@ -168,6 +179,8 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ct
 			condition.UnknownCondition(condition.ServiceAccountReadyCondition, condition.InitReason, condition.ServiceAccountReadyInitMessage),
 			condition.UnknownCondition(condition.RoleReadyCondition, condition.InitReason, condition.RoleReadyInitMessage),
 			condition.UnknownCondition(condition.RoleBindingReadyCondition, condition.InitReason, condition.RoleBindingReadyInitMessage),
+			// replica placement
+			condition.UnknownCondition(redisv1.RebalancingCondition, condition.InitReason, redisv1.RebalancingInitMessage),
 		)
 
 		instance.Status.Conditions.Init(&cl)
@@ -287,26 +300,34 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ct
 		return hlres, hlerr
 	}
 
-	// Service to expose Redis pods
-	commonsvc, err := commonservice.NewService(redis.Service(instance), time.Duration(5)*time.Second, nil)
-	if err != nil {
-		instance.Status.Conditions.Set(condition.FalseCondition(
-			condition.ExposeServiceReadyCondition,
-			condition.ErrorReason,
-			condition.SeverityWarning,
-			condition.ExposeServiceReadyErrorMessage,
-			err.Error()))
-		return ctrl.Result{}, err
-	}
-	sres, serr := commonsvc.CreateOrPatch(ctx, helper)
-	if serr != nil {
-		instance.Status.Conditions.Set(condition.FalseCondition(
-			condition.ExposeServiceReadyCondition,
-			condition.ErrorReason,
-			condition.SeverityWarning,
-			condition.ExposeServiceReadyErrorMessage,
-			err.Error()))
-		return sres, serr
+	// Service to expose Redis pods, unless opted out via annotation in
+	// favor of an externally managed one
+	instance.Status.SkippedResources = []string{}
+	if managed.IsManaged(instance, redisv1.GroupVersion.Group, managed.Service) {
+		// Service to expose Redis pods
+		commonsvc, err := commonservice.NewService(redis.Service(instance), time.Duration(5)*time.Second, nil)
+		if err != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.ExposeServiceReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				condition.ExposeServiceReadyErrorMessage,
+				err.Error()))
+			return ctrl.Result{}, err
+		}
+		sres, serr := commonsvc.CreateOrPatch(ctx, helper)
+		if serr != nil {
+			instance.Status.Conditions.Set(condition.FalseCondition(
+				condition.ExposeServiceReadyCondition,
+				condition.ErrorReason,
+				condition.SeverityWarning,
+				condition.ExposeServiceReadyErrorMessage,
+				err.Error()))
+			return sres, serr
+		}
+	} else {
+		instance.Status.SkippedResources = append(instance.Status.SkippedResources,
+			managed.SkippedResource("Service", instance.Name))
 	}
 	instance.Status.Conditions.MarkTrue(condition.ExposeServiceReadyCondition, condition.ExposeServiceReadyMessage)
 
@@ -326,7 +347,126 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ct
 		instance.Status.Conditions.MarkTrue(condition.DeploymentReadyCondition, condition.DeploymentReadyMessage)
 	}
 
-	return ctrl.Result{}, nil
+	// Re-spread the replicas across the topology domains
+	rebalanceAfter, err := r.reconcileTopologySpread(ctx, instance, &statefulset)
+	if err != nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			redisv1.RebalancingCondition,
+			condition.ErrorReason,
+			condition.SeverityWarning,
+			redisv1.RebalancingErrorMessage,
+			err.Error()))
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: rebalanceAfter}, nil
+}
+
+// reconcileTopologySpread recreates one replica at a time while the replica
+// placement exceeds the requested topology skew, e.g. after nodes or zones got
+// added. To protect against failovers, nothing is done unless all replicas are
+// ready and the master is never moved. Returns when to check the placement again.
+func (r *Reconciler) reconcileTopologySpread(
+	ctx context.Context,
+	instance *redisv1.Redis,
+	statefulset *appsv1.StatefulSet,
+) (time.Duration, error) {
+	Log := r.GetLogger(ctx)
+
+	spread := instance.Spec.TopologySpread
+	if spread == nil {
+		instance.Status.LastRebalance = nil
+		instance.Status.Conditions.MarkTrue(redisv1.RebalancingCondition, redisv1.RebalancingNotRequestedMessage)
+		return 0, nil
+	}
+
+	pods := &corev1.PodList{}
+	err := r.Client.List(ctx, pods, client.InNamespace(instance.Namespace), client.MatchingLabels{
+		common.AppSelector:   "redis",
+		common.OwnerSelector: instance.Name,
+	})
+	if err != nil {
+		return 0, err
+	}
+	nodes := &corev1.NodeList{}
+	err = r.Client.List(ctx, nodes)
+	if err != nil {
+		return 0, err
+	}
+
+	podSpec := &statefulset.Spec.Template.Spec
+	skew, candidate := redis.RebalanceCandidate(pods.Items, nodes.Items, podSpec, spread.TopologyKey, spread.MaxSkew)
+	if skew <= spread.MaxSkew {
+		instance.Status.LastRebalance = nil
+		instance.Status.Conditions.MarkTrue(redisv1.RebalancingCondition, redisv1.RebalancingReadyMessage)
+		return 0, nil
+	}
+
+	// only move a replica when the cluster is complete, which also covers
+	// the recreation of the previously moved replica
+	if statefulset.Status.ReadyReplicas != *instance.Spec.Replicas ||
+		statefulset.Status.UpdatedReplicas != *instance.Spec.Replicas ||
+		int32(len(pods.Items)) != *instance.Spec.Replicas {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			redisv1.RebalancingCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			redisv1.RebalancingWaitingMessage,
+			skew, spread.MaxSkew))
+		return rebalanceInterval, nil
+	}
+
+	// the scheduler placed the previously moved replica into the same domain
+	// again, e.g. because of resources or pod affinities. Moving replicas again
+	// would not help until the schedulable domains change.
+	domains := redis.SchedulableDomains(nodes.Items, podSpec, spread.TopologyKey)
+	if last := instance.Status.LastRebalance; last != nil {
+		if !slices.Equal(last.Domains, domains) {
+			instance.Status.LastRebalance = nil
+		} else {
+			for i := range pods.Items {
+				if pods.Items[i].Name == last.Pod &&
+					redis.PodDomain(&pods.Items[i], nodes.Items, spread.TopologyKey) == last.Domain {
+					instance.Status.Conditions.Set(condition.FalseCondition(
+						redisv1.RebalancingCondition,
+						condition.RequestedReason,
+						condition.SeverityWarning,
+						redisv1.RebalancingStuckMessage,
+						skew, spread.MaxSkew, last.Pod, last.Domain))
+					return rebalanceInterval, nil
+				}
+			}
+		}
+	}
+
+	if candidate == nil {
+		instance.Status.Conditions.Set(condition.FalseCondition(
+			redisv1.RebalancingCondition,
+			condition.RequestedReason,
+			condition.SeverityInfo,
+			redisv1.RebalancingMasterOnlyMessage,
+			skew, spread.MaxSkew))
+		return rebalanceInterval, nil
+	}
+
+	Log.Info(fmt.Sprintf("Topology skew %d exceeds %d, recreating replica %s", skew, spread.MaxSkew, candidate.Name))
+	err = r.Client.Delete(ctx, candidate)
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return 0, err
+	}
+	instance.Status.LastRebalance = &redisv1.RedisRebalance{
+		Pod:     candidate.Name,
+		Domain:  redis.PodDomain(candidate, nodes.Items, spread.TopologyKey),
+		Domains: domains,
+	}
+	instance.Status.Conditions.Set(condition.FalseCondition(
+		redisv1.RebalancingCondition,
+		condition.RequestedReason,
+		condition.SeverityInfo,
+		redisv1.RebalancingInProgressMessage,
+		skew, spread.MaxSkew, candidate.Name))
+
+	return rebalanceInterval, nil
 }
 
 // generateConfigMaps returns the config map resource for a redis instance
@@ -336,7 +476,15 @@ func (r *Reconciler) generateConfigMaps(
 	instance *redisv1.Redis,
 	envVars *map[string]env.Setter,
 ) error {
-	templateParameters := make(map[string]interface{})
+	appendFsync := instance.Spec.Persistence.AppendFsync
+	if appendFsync == "" {
+		appendFsync = "everysec"
+	}
+	templateParameters := map[string]interface{}{
+		"rdbEnabled":  instance.Spec.Persistence.RDBEnabled(),
+		"aofEnabled":  instance.Spec.Persistence.AOFEnabled(),
+		"appendFsync": appendFsync,
+	}
 	customData := make(map[string]string)
 
 	cms := []util.Template{
@@ -407,9 +555,40 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Owns(&corev1.ServiceAccount{}).
 		Owns(&rbacv1.Role{}).
 		Owns(&rbacv1.RoleBinding{}).
+		Watches(&source.Kind{Type: &corev1.Node{}},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForNode),
+			builder.WithPredicates(predicate.LabelChangedPredicate{})).
 		Complete(r)
 }
 
+// findObjectsForNode - returns a reconcile request for each Redis CR with a
+// topology spread, as added or removed nodes might require a rebalancing
+func (r *Reconciler) findObjectsForNode(node client.Object) []reconcile.Request {
+	requests := []reconcile.Request{}
+
+	crList := &redisv1.RedisList{}
+	err := r.List(context.TODO(), crList)
+	if err != nil {
+		return []reconcile.Request{}
+	}
+
+	for _, item := range crList.Items {
+		if item.Spec.TopologySpread == nil {
+			continue
+		}
+		requests = append(requests,
+			reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      item.GetName(),
+					Namespace: item.GetNamespace(),
+				},
+			},
+		)
+	}
+
+	return requests
+}
+
 // findObjectsForSrc - returns a reconcile request if the object is referenced by a Redis CR
 func (r *Reconciler) findObjectsForSrc(src client.Object) []reconcile.Request {
 	requests := []reconcile.Request{}
@@ -58,6 +58,33 @@ webhooks:
   timeoutSeconds: 10
 ---
 apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: vmemcachedscale.kb.io
+webhooks:
+- admissionReviewVersions:
+  - v1
+  clientConfig:
+    caBundle: ${CA_BUNDLE}
+    url: https://${CRC_IP}:9443/validate-memcached-openstack-org-v1beta1-memcached-scale
+  failurePolicy: Fail
+  matchPolicy: Equivalent
+  name: vmemcachedscale.kb.io
+  objectSelector: {}
+  rules:
+  - apiGroups:
+    - memcached.openstack.org
+    apiVersions:
+    - v1beta1
+    operations:
+    - UPDATE
+    resources:
+    - memcacheds/scale
+    scope: '*'
+  sideEffects: None
+  timeoutSeconds: 10
+---
+apiVersion: admissionregistration.k8s.io/v1
 kind: MutatingWebhookConfiguration
 metadata:
   name: mmemcached.kb.io
@@ -114,6 +141,34 @@ webhooks:
   timeoutSeconds: 10
 ---
 apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: vdnsdata.kb.io
+webhooks:
+- admissionReviewVersions:
+  - v1
+  clientConfig:
+    caBundle: ${CA_BUNDLE}
+    url: https://${CRC_IP}:9443/validate-network-openstack-org-v1beta1-dnsdata
+  failurePolicy: Fail
+  matchPolicy: Equivalent
+  name: vdnsdata.kb.io
+  objectSelector: {}
+  rules:
+  - apiGroups:
+    - network.openstack.org
+    apiVersions:
+    - v1beta1
+    operations:
+    - CREATE
+    - UPDATE
+    resources:
+    - dnsdata
+    scope: '*'
+  sideEffects: None
+  timeoutSeconds: 10
+---
+apiVersion: admissionregistration.k8s.io/v1
 kind: MutatingWebhookConfiguration
 metadata:
   name: mdnsmasq.kb.io
@@ -364,6 +419,34 @@ webhooks:
     scope: '*'
   sideEffects: None
   timeoutSeconds: 10
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: vtransporturl.kb.io
+webhooks:
+- admissionReviewVersions:
+  - v1
+  clientConfig:
+    caBundle: ${CA_BUNDLE}
+    url: https://${CRC_IP}:9443/validate-rabbitmq-openstack-org-v1beta1-transporturl
+  failurePolicy: Fail
+  matchPolicy: Equivalent
+  name: vtransporturl.kb.io
+  objectSelector: {}
+  rules:
+  - apiGroups:
+    - rabbitmq.openstack.org
+    apiVersions:
+    - v1beta1
+    operations:
+    - CREATE
+    - UPDATE
+    resources:
+    - transporturls
+    scope: '*'
+  sideEffects: None
+  timeoutSeconds: 10
 EOF_CAT
 
 oc apply -n openstack -f ${TMPDIR}/patch_webhook_configurations.yaml
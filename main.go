@@ -22,6 +22,7 @@ import (
 	"flag"
 	"os"
 	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -34,8 +35,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
+	k8snetworkv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 	rabbitmqclusterv1 "github.com/rabbitmq/cluster-operator/api/v1beta1"
 	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 
 	memcachedv1 "github.com/openstack-k8s-operators/infra-operator/apis/memcached/v1beta1"
@@ -46,6 +49,7 @@ import (
 	networkcontrollers "github.com/openstack-k8s-operators/infra-operator/controllers/network"
 	rabbitmqcontrollers "github.com/openstack-k8s-operators/infra-operator/controllers/rabbitmq"
 	rediscontrollers "github.com/openstack-k8s-operators/infra-operator/controllers/redis"
+	"github.com/openstack-k8s-operators/infra-operator/pkg/rbacaudit"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -62,6 +66,7 @@ func init() {
 	utilruntime.Must(memcachedv1.AddToScheme(scheme))
 	utilruntime.Must(redisv1.AddToScheme(scheme))
 	utilruntime.Must(networkv1.AddToScheme(scheme))
+	utilruntime.Must(k8snetworkv1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -70,12 +75,28 @@ func main() {
 	var enableLeaderElection bool
 	var probeAddr string
 	var enableHTTP2 bool
+	var rbacAudit bool
+	var rbacAuditReportPath string
+	var rbacAuditInterval time.Duration
+	var rbacAuditClusterRole string
+	var enableDNSEndpointSource bool
 	flag.BoolVar(&enableHTTP2, "enable-http2", enableHTTP2, "If HTTP/2 should be enabled for the metrics and webhook servers.")
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.BoolVar(&rbacAudit, "rbac-audit", false,
+		"Record the API verbs and resources used by each controller and periodically report them "+
+			"compared to the rules granted by the operator ClusterRole.")
+	flag.StringVar(&rbacAuditReportPath, "rbac-audit-report-path", "/tmp/rbac-audit-report.json",
+		"The file the RBAC audit report gets written to. If empty the report is only logged.")
+	flag.DurationVar(&rbacAuditInterval, "rbac-audit-interval", 10*time.Minute, "The interval between two RBAC audit reports.")
+	flag.StringVar(&rbacAuditClusterRole, "rbac-audit-clusterrole", "infra-operator-manager-role",
+		"The ClusterRole granted to the operator the RBAC audit compares the used rules with.")
+	flag.BoolVar(&enableDNSEndpointSource, "enable-dnsendpoint-source", false,
+		"Publish the A and AAAA records of ExternalDNS DNSEndpoint objects via the DNSMasq of their namespace. "+
+			"Requires the DNSEndpoint CRD to be installed.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -126,24 +147,52 @@ func main() {
 		os.Exit(1)
 	}
 
+	// when the RBAC audit is enabled each controller gets a client recording
+	// the requests it makes
+	rbacRecorder := rbacaudit.NewRecorder()
+	controllerClient := func(controller string) client.Client {
+		if rbacAudit {
+			return rbacaudit.NewClient(mgr.GetClient(), controller, rbacRecorder)
+		}
+		return mgr.GetClient()
+	}
+
 	if err = (&rabbitmqcontrollers.TransportURLReconciler{
-		Client:  mgr.GetClient(),
-		Scheme:  mgr.GetScheme(),
-		Kclient: kclient,
+		Client:    controllerClient("TransportURL"),
+		Scheme:    mgr.GetScheme(),
+		Kclient:   kclient,
+		APIReader: mgr.GetAPIReader(),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "TransportURL")
 		os.Exit(1)
 	}
-	if err = (&memcachedcontrollers.Reconciler{
-		Client:  mgr.GetClient(),
+	if err = (&rabbitmqcontrollers.MessagingPolicyReconciler{
+		Client:  controllerClient("MessagingPolicy"),
+		Scheme:  mgr.GetScheme(),
 		Kclient: kclient,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MessagingPolicy")
+		os.Exit(1)
+	}
+	if err = (&rabbitmqcontrollers.MessagingLinkReconciler{
+		Client:  controllerClient("MessagingLink"),
 		Scheme:  mgr.GetScheme(),
+		Kclient: kclient,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MessagingLink")
+		os.Exit(1)
+	}
+	if err = (&memcachedcontrollers.Reconciler{
+		Client:   controllerClient("Memcached"),
+		Kclient:  kclient,
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("memcached-controller"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Memcached")
 		os.Exit(1)
 	}
 	if err = (&rediscontrollers.Reconciler{
-		Client:  mgr.GetClient(),
+		Client:  controllerClient("Redis"),
 		Kclient: kclient,
 		Scheme:  mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
@@ -152,25 +201,26 @@ func main() {
 	}
 
 	if err = (&networkcontrollers.DNSMasqReconciler{
-		Client:  mgr.GetClient(),
-		Kclient: kclient,
-		Scheme:  mgr.GetScheme(),
+		Client:   controllerClient("DNSMasq"),
+		Kclient:  kclient,
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("dnsmasq-controller"),
 	}).SetupWithManager(context.Background(), mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "DNSMasq")
 		os.Exit(1)
 	}
 
 	if err = (&networkcontrollers.DNSDataReconciler{
-		Client:  mgr.GetClient(),
+		Client:  controllerClient("DNSData"),
 		Kclient: kclient,
 		Scheme:  mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
+	}).SetupWithManager(context.Background(), mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "DNSData")
 		os.Exit(1)
 	}
 
 	if err = (&networkcontrollers.ServiceReconciler{
-		Client:  mgr.GetClient(),
+		Client:  controllerClient("Service"),
 		Kclient: kclient,
 		Scheme:  mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
@@ -178,13 +228,47 @@ func main() {
 		os.Exit(1)
 	}
 	if err = (&networkcontrollers.IPSetReconciler{
-		Client:  mgr.GetClient(),
+		Client:  controllerClient("IPSet"),
 		Kclient: kclient,
 		Scheme:  mgr.GetScheme(),
 	}).SetupWithManager(context.Background(), mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "IPSet")
 		os.Exit(1)
 	}
+	if err = (&networkcontrollers.NetConfigReconciler{
+		Client:    controllerClient("NetConfig"),
+		Kclient:   kclient,
+		Scheme:    mgr.GetScheme(),
+		APIReader: mgr.GetAPIReader(),
+	}).SetupWithManager(context.Background(), mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "NetConfig")
+		os.Exit(1)
+	}
+	if enableDNSEndpointSource {
+		if err = (&networkcontrollers.DNSEndpointReconciler{
+			Client:  controllerClient("DNSEndpoint"),
+			Kclient: kclient,
+			Scheme:  mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "DNSEndpoint")
+			os.Exit(1)
+		}
+	}
+
+	if rbacAudit {
+		setupLog.Info("RBAC audit enabled", "clusterrole", rbacAuditClusterRole, "path", rbacAuditReportPath)
+		if err := mgr.Add(&rbacaudit.Reporter{
+			Recorder:    rbacRecorder,
+			Reader:      mgr.GetAPIReader(),
+			ClusterRole: rbacAuditClusterRole,
+			Path:        rbacAuditReportPath,
+			Interval:    rbacAuditInterval,
+			Log:         ctrl.Log.WithName("rbac-audit"),
+		}); err != nil {
+			setupLog.Error(err, "unable to set up RBAC audit reporter")
+			os.Exit(1)
+		}
+	}
 
 	// Acquire environmental defaults and initialize operator defaults with them
 	memcachedv1.SetupDefaults()
@@ -210,6 +294,10 @@ func main() {
 			setupLog.Error(err, "unable to create webhook", "webhook", "DNSMasq")
 			os.Exit(1)
 		}
+		if err = (&networkv1.DNSData{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "DNSData")
+			os.Exit(1)
+		}
 		if err = (&networkv1.NetConfig{}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "NetConfig")
 			os.Exit(1)
@@ -222,6 +310,10 @@ func main() {
 			setupLog.Error(err, "unable to create webhook", "webhook", "IPSet")
 			os.Exit(1)
 		}
+		if err = (&rabbitmqv1beta1.TransportURL{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "TransportURL")
+			os.Exit(1)
+		}
 		checker = mgr.GetWebhookServer().StartedChecker()
 	}
 
@@ -0,0 +1,68 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsmasq
+
+import (
+	"fmt"
+	"strings"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+)
+
+const (
+	// defaultSOARefresh - seconds secondary servers wait before checking the zone for changes
+	defaultSOARefresh = 1200
+	// defaultSOARetry - seconds secondary servers wait before retrying a failed refresh
+	defaultSOARetry = 180
+	// defaultSOAExpiry - seconds after which secondary servers stop answering for the zone
+	defaultSOAExpiry = 1209600
+)
+
+// AuthoritativeConfig - renders the dnsmasq options making it authoritative
+// for the local zones
+func AuthoritativeConfig(auth *networkv1.DNSMasqAuthoritative) string {
+	var cfg strings.Builder
+	cfg.WriteString("auth-server=" + auth.Server + "\n")
+
+	for _, zone := range auth.Zones {
+		cfg.WriteString("auth-zone=" + strings.Join(append([]string{zone.Domain}, zone.Subnets...), ",") + "\n")
+	}
+
+	// auth-soa=<serial>[,<hostmaster>[,<refresh>[,<retry>[,<expiry>]]]], a
+	// serial of 0 makes dnsmasq use the time it got started
+	serial := int64(0)
+	if auth.SOA.Serial != nil {
+		serial = *auth.SOA.Serial
+	}
+	hostmaster := auth.SOA.Hostmaster
+	if hostmaster == "" {
+		hostmaster = "hostmaster." + auth.Server
+	}
+	cfg.WriteString(fmt.Sprintf("auth-soa=%d,%s,%d,%d,%d\n", serial, hostmaster,
+		soaTimer(auth.SOA.Refresh, defaultSOARefresh),
+		soaTimer(auth.SOA.Retry, defaultSOARetry),
+		soaTimer(auth.SOA.Expiry, defaultSOAExpiry)))
+
+	return cfg.String()
+}
+
+// soaTimer - returns the SOA timer or its default if not set
+func soaTimer(timer *int32, defaultTimer int32) int32 {
+	if timer == nil {
+		return defaultTimer
+	}
+	return *timer
+}
@@ -0,0 +1,73 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsmasq
+
+import (
+	"testing"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+)
+
+func TestAuthoritativeConfig(t *testing.T) {
+	serial := int64(2024010101)
+	refresh, retry, expiry := int32(3600), int32(600), int32(604800)
+
+	tests := []struct {
+		name string
+		auth networkv1.DNSMasqAuthoritative
+		want string
+	}{
+		{
+			name: "zone with default SOA",
+			auth: networkv1.DNSMasqAuthoritative{
+				Server: "ns1.example.com",
+				Zones:  []networkv1.DNSMasqAuthZone{{Domain: "ctlplane.example.com"}},
+			},
+			want: "auth-server=ns1.example.com\n" +
+				"auth-zone=ctlplane.example.com\n" +
+				"auth-soa=0,hostmaster.ns1.example.com,1200,180,1209600\n",
+		},
+		{
+			name: "zones with subnets and SOA parameters",
+			auth: networkv1.DNSMasqAuthoritative{
+				Server: "ns1.example.com",
+				Zones: []networkv1.DNSMasqAuthZone{
+					{Domain: "ctlplane.example.com", Subnets: []string{"192.168.122.0/24"}},
+					{Domain: "internalapi.example.com", Subnets: []string{"172.17.0.0/24", "fd00:bbbb::/64"}},
+				},
+				SOA: networkv1.DNSMasqSOA{
+					Serial:     &serial,
+					Hostmaster: "admin.example.com",
+					Refresh:    &refresh,
+					Retry:      &retry,
+					Expiry:     &expiry,
+				},
+			},
+			want: "auth-server=ns1.example.com\n" +
+				"auth-zone=ctlplane.example.com,192.168.122.0/24\n" +
+				"auth-zone=internalapi.example.com,172.17.0.0/24,fd00:bbbb::/64\n" +
+				"auth-soa=2024010101,admin.example.com,3600,600,604800\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AuthoritativeConfig(&tt.auth); got != tt.want {
+				t.Errorf("AuthoritativeConfig() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
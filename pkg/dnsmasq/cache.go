@@ -0,0 +1,45 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsmasq
+
+import (
+	"fmt"
+	"strings"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+)
+
+// DefaultCacheSize - number of names dnsmasq caches if the size is not set
+const DefaultCacheSize = 10000
+
+// CacheConfig - renders the dnsmasq options tuning the query cache
+func CacheConfig(cache *networkv1.DNSMasqCache) string {
+	var cfg strings.Builder
+	size := int32(DefaultCacheSize)
+	if cache.Size != nil {
+		size = *cache.Size
+	}
+	cfg.WriteString(fmt.Sprintf("cache-size=%d\n", size))
+
+	if cache.NegTTL != nil {
+		cfg.WriteString(fmt.Sprintf("neg-ttl=%d\n", *cache.NegTTL))
+	}
+	if cache.MaxCacheTTL != nil {
+		cfg.WriteString(fmt.Sprintf("max-cache-ttl=%d\n", *cache.MaxCacheTTL))
+	}
+
+	return cfg.String()
+}
@@ -0,0 +1,63 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsmasq
+
+import (
+	"testing"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+)
+
+func TestCacheConfig(t *testing.T) {
+	negTTL := int32(60)
+	maxCacheTTL := int32(3600)
+	size := int32(5000)
+	disabled := int32(0)
+
+	tests := []struct {
+		name  string
+		cache networkv1.DNSMasqCache
+		want  string
+	}{
+		{
+			name:  "default size",
+			cache: networkv1.DNSMasqCache{},
+			want:  "cache-size=10000\n",
+		},
+		{
+			name:  "cache disabled",
+			cache: networkv1.DNSMasqCache{Size: &disabled},
+			want:  "cache-size=0\n",
+		},
+		{
+			name: "ttl limits",
+			cache: networkv1.DNSMasqCache{
+				Size:        &size,
+				NegTTL:      &negTTL,
+				MaxCacheTTL: &maxCacheTTL,
+			},
+			want: "cache-size=5000\nneg-ttl=60\nmax-cache-ttl=3600\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CacheConfig(&tt.cache); got != tt.want {
+				t.Errorf("CacheConfig() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsmasq
+
+import (
+	"fmt"
+	"strings"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+)
+
+// ClientSubnetsConfig - renders the dnsmasq options returning answers based
+// on the subnet of the client
+func ClientSubnetsConfig(clientSubnets *networkv1.DNSMasqClientSubnets) string {
+	var cfg strings.Builder
+	if clientSubnets.LocaliseQueries {
+		cfg.WriteString("localise-queries\n")
+	}
+	if clientSubnets.AddSubnet != nil {
+		cfg.WriteString(fmt.Sprintf("add-subnet=%d,%d\n",
+			clientSubnets.AddSubnet.IPv4PrefixLength, clientSubnets.AddSubnet.IPv6PrefixLength))
+	}
+
+	return cfg.String()
+}
@@ -0,0 +1,60 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsmasq
+
+import (
+	"testing"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+)
+
+func TestClientSubnetsConfig(t *testing.T) {
+	tests := []struct {
+		name          string
+		clientSubnets networkv1.DNSMasqClientSubnets
+		want          string
+	}{
+		{
+			name:          "disabled",
+			clientSubnets: networkv1.DNSMasqClientSubnets{},
+			want:          "",
+		},
+		{
+			name:          "localise queries",
+			clientSubnets: networkv1.DNSMasqClientSubnets{LocaliseQueries: true},
+			want:          "localise-queries\n",
+		},
+		{
+			name: "forward client subnet",
+			clientSubnets: networkv1.DNSMasqClientSubnets{
+				LocaliseQueries: true,
+				AddSubnet: &networkv1.DNSMasqAddSubnet{
+					IPv4PrefixLength: 24,
+					IPv6PrefixLength: 56,
+				},
+			},
+			want: "localise-queries\nadd-subnet=24,56\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClientSubnetsConfig(&tt.clientSubnets); got != tt.want {
+				t.Errorf("ClientSubnetsConfig() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
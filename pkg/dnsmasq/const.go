@@ -21,4 +21,16 @@ const (
 
 	// DNSPort -
 	DNSPort int32 = 53
+
+	// HostsDir - directory of the hosts files of the DNSData, dnsmasq re-reads it on SIGHUP
+	HostsDir = "/etc/dnsmasq.d/hosts"
+
+	// MetricsPortName - name of the container port of the metrics exporter
+	MetricsPortName = "metrics"
+
+	// OperatorNameLabel - label of the operator pods, see config/manager/manager.yaml
+	OperatorNameLabel = "openstack.org/operator-name"
+
+	// OperatorName - value of the OperatorNameLabel of the operator pods
+	OperatorName = "infra"
 )
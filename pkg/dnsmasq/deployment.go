@@ -25,6 +25,8 @@ import (
 	common "github.com/openstack-k8s-operators/lib-common/modules/common"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/affinity"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/env"
+	nad "github.com/openstack-k8s-operators/lib-common/modules/common/networkattachment"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -35,6 +37,12 @@ import (
 const (
 	// ServiceCommand -
 	ServiceCommand = "dnsmasq"
+
+	// ReloadInterval - seconds between the checks of the reload sidecar for updated hosts files
+	ReloadInterval = 5
+
+	// defaultLogQueueSize - log lines queued when stdout can not keep up, if no Debug options are set
+	defaultLogQueueSize int32 = 25
 )
 
 // Deployment func
@@ -43,9 +51,9 @@ func Deployment(
 	configHash string,
 	labels map[string]string,
 	annotations map[string]string,
-	cms *corev1.ConfigMapList,
 ) *appsv1.Deployment {
 	runAsUser := int64(0)
+	shareProcessNamespace := true
 	terminationGracePeriodSeconds := int64(10)
 
 	livenessProbe := &corev1.Probe{
@@ -65,14 +73,26 @@ func Deployment(
 	args := []string{"-c"}
 	initArgs := []string{"-c"}
 	dnsmasqCmd := []string{ServiceCommand}
-	dnsmasqCmd = append(dnsmasqCmd, "--interface=*")
+	if len(instance.Spec.NetworkAttachments) == 0 {
+		dnsmasqCmd = append(dnsmasqCmd, "--interface=*")
+	}
+	// listen on the interfaces of the network attachments in addition to the pod IP
+	for _, netAtt := range instance.Spec.NetworkAttachments {
+		dnsmasqCmd = append(dnsmasqCmd, "--interface="+nad.GetNetworkIFName(netAtt))
+	}
 	dnsmasqCmd = append(dnsmasqCmd, "--conf-dir=/etc/dnsmasq.d")
-	dnsmasqCmd = append(dnsmasqCmd, "--hostsdir=/etc/dnsmasq.d/hosts")
+	dnsmasqCmd = append(dnsmasqCmd, "--hostsdir="+HostsDir)
 	dnsmasqCmd = append(dnsmasqCmd, "--keep-in-foreground")
 	dnsmasqCmd = append(dnsmasqCmd, "--no-daemon")
 	dnsmasqCmd = append(dnsmasqCmd, "--log-debug")
 	dnsmasqCmd = append(dnsmasqCmd, "--bind-interfaces")
-	dnsmasqCmd = append(dnsmasqCmd, "--listen-address=$(POD_IP)")
+	// all pod IPs, to listen on both IP families on dual-stack clusters
+	dnsmasqCmd = append(dnsmasqCmd, "--listen-address=$(POD_IPS)")
+	// the metrics exporter queries via loopback, which is not part of the
+	// interfaces of the network attachments
+	if instance.Spec.Metrics != nil {
+		dnsmasqCmd = append(dnsmasqCmd, "--listen-address=127.0.0.1")
+	}
 	dnsmasqCmd = append(dnsmasqCmd, "--port "+strconv.Itoa(int(DNSPort)))
 	// log to stdout
 	dnsmasqCmd = append(dnsmasqCmd, "--log-facility=-")
@@ -81,7 +101,21 @@ func Deployment(
 	dnsmasqCmd = append(dnsmasqCmd, "--domain-needed")
 	dnsmasqCmd = append(dnsmasqCmd, "--no-resolv")
 	dnsmasqCmd = append(dnsmasqCmd, "--bogus-priv")
-	dnsmasqCmd = append(dnsmasqCmd, "--log-queries")
+	// queries get logged unless disabled in the Debug options
+	queryLogging, logQueueSize := true, defaultLogQueueSize
+	if instance.Spec.Debug != nil {
+		queryLogging, logQueueSize = instance.Spec.Debug.QueryLogging, instance.Spec.Debug.LogQueueSize
+	}
+	if queryLogging {
+		// log asynchronously, dropping lines if stdout can not keep up, so
+		// logging never slows down the query processing
+		dnsmasqCmd = append(dnsmasqCmd, "--log-queries")
+		dnsmasqCmd = append(dnsmasqCmd, fmt.Sprintf("--log-async=%d", logQueueSize))
+	}
+	if instance.Spec.UpstreamTLS != nil {
+		// forward to the DNS-over-TLS sidecar
+		dnsmasqCmd = append(dnsmasqCmd, fmt.Sprintf("--server=127.0.0.1#%d", UnboundPort))
+	}
 
 	// append dnsmasqCmd for service container
 	args = append(args, strings.Join(dnsmasqCmd, " "))
@@ -101,7 +135,7 @@ func Deployment(
 	}
 
 	envVars := map[string]env.Setter{}
-	envVars["POD_IP"] = env.DownwardAPI("status.podIP")
+	envVars["POD_IPS"] = env.DownwardAPI("status.podIPs")
 	envVars["CONFIG_HASH"] = env.SetValue(configHash)
 
 	deployment := &appsv1.Deployment{
@@ -117,11 +151,14 @@ func Deployment(
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Annotations: annotations,
-					Labels:      labels,
+					// the instance specific labels are used by the PodDisruptionBudget
+					Labels: util.MergeStringMaps(labels, PodSelector(instance)),
 				},
 				Spec: corev1.PodSpec{
 					ServiceAccountName: instance.RbacResourceName(),
-					Volumes:            getVolumes(instance.Name, cms),
+					Volumes:            getVolumes(instance),
+					// allow the reload sidecar to signal dnsmasq
+					ShareProcessNamespace: &shareProcessNamespace,
 					InitContainers: []corev1.Container{
 						{
 							Name:    "init",
@@ -132,7 +169,7 @@ func Deployment(
 								RunAsUser: &runAsUser,
 							},
 							Env:          env.MergeEnvs([]corev1.EnvVar{}, envVars),
-							VolumeMounts: getVolumeMounts(instance.Name, cms),
+							VolumeMounts: getVolumeMounts(instance),
 						},
 					},
 					Containers: []corev1.Container{
@@ -145,10 +182,11 @@ func Deployment(
 								RunAsUser: &runAsUser,
 							},
 							Env:            env.MergeEnvs([]corev1.EnvVar{}, envVars),
-							VolumeMounts:   getVolumeMounts(instance.Name, cms),
+							VolumeMounts:   getVolumeMounts(instance),
 							ReadinessProbe: readinessProbe,
 							LivenessProbe:  livenessProbe,
 						},
+						reloadContainer(instance),
 					},
 					TerminationGracePeriodSeconds: &terminationGracePeriodSeconds,
 				},
@@ -165,9 +203,114 @@ func Deployment(
 		},
 		corev1.LabelHostname,
 	)
+	if instance.Spec.UpstreamTLS != nil {
+		deployment.Spec.Template.Spec.Containers = append(
+			deployment.Spec.Template.Spec.Containers,
+			unboundContainer(instance),
+		)
+		if instance.Spec.UpstreamTLS.CaBundleSecretName != "" {
+			deployment.Spec.Template.Spec.Volumes = append(
+				deployment.Spec.Template.Spec.Volumes,
+				instance.Spec.UpstreamTLS.CreateVolume(),
+			)
+		}
+	}
+	if instance.Spec.Metrics != nil {
+		deployment.Spec.Template.Spec.Containers = append(
+			deployment.Spec.Template.Spec.Containers,
+			exporterContainer(instance),
+		)
+	}
 	if instance.Spec.NodeSelector != nil && len(instance.Spec.NodeSelector) > 0 {
 		deployment.Spec.Template.Spec.NodeSelector = instance.Spec.NodeSelector
 	}
 
 	return deployment
 }
+
+// reloadContainer - sidecar sending dnsmasq a SIGHUP to re-read the hosts
+// files when the kubelet updated the hosts ConfigMap volume, which it does
+// by swapping the ..data symlink of the volume
+func reloadContainer(instance *networkv1.DNSMasq) corev1.Container {
+	runAsUser := int64(0)
+
+	script := fmt.Sprintf(
+		`last=$(readlink %[1]s/..data); `+
+			`while sleep %[2]d; do `+
+			`current=$(readlink %[1]s/..data); `+
+			`if [ "$current" != "$last" ] && pkill -HUP -x %[3]s; then last=$current; fi; `+
+			`done`,
+		HostsDir, ReloadInterval, ServiceCommand)
+
+	return corev1.Container{
+		Name:    ServiceName + "-reload",
+		Command: []string{"/bin/bash"},
+		Args:    []string{"-c", script},
+		Image:   instance.Spec.ContainerImage,
+		SecurityContext: &corev1.SecurityContext{
+			RunAsUser: &runAsUser,
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      "hosts",
+				MountPath: HostsDir,
+				ReadOnly:  true,
+			},
+		},
+	}
+}
+
+// unboundContainer - sidecar forwarding the queries of dnsmasq to the
+// upstream servers over DNS-over-TLS
+func unboundContainer(instance *networkv1.DNSMasq) corev1.Container {
+	runAsUser := int64(0)
+
+	return corev1.Container{
+		Name:    ServiceName + "-unbound",
+		Command: []string{"/usr/sbin/unbound"},
+		Args:    []string{"-d", "-c", "/etc/unbound/unbound.conf"},
+		Image:   instance.Spec.UpstreamTLS.ContainerImage,
+		SecurityContext: &corev1.SecurityContext{
+			RunAsUser: &runAsUser,
+		},
+		VolumeMounts: getUnboundVolumeMounts(instance),
+	}
+}
+
+// exporterContainer - sidecar exposing the statistics dnsmasq reports via
+// the CHAOS class TXT records as Prometheus metrics
+func exporterContainer(instance *networkv1.DNSMasq) corev1.Container {
+	return corev1.Container{
+		Name:    ServiceName + "-exporter",
+		Command: []string{"/dnsmasq_exporter"},
+		Args: []string{
+			// query via loopback, which works independent of the IP family of the pod
+			fmt.Sprintf("--dnsmasq=127.0.0.1:%d", DNSPort),
+			fmt.Sprintf("--listen=:%d", instance.Spec.Metrics.Port),
+			"--expose_leases=false",
+		},
+		Image: instance.Spec.Metrics.ContainerImage,
+		Ports: []corev1.ContainerPort{
+			{
+				Name:          MetricsPortName,
+				ContainerPort: instance.Spec.Metrics.Port,
+				Protocol:      corev1.ProtocolTCP,
+			},
+		},
+		ReadinessProbe: &corev1.Probe{
+			TimeoutSeconds: 5,
+			PeriodSeconds:  10,
+			ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{
+					Path: "/metrics",
+					Port: intstr.FromString(MetricsPortName),
+				},
+			},
+		},
+	}
+}
+
+// HostsConfigMapName - name of the ConfigMap holding the hosts files of all DNSData of the DNSMasq
+func HostsConfigMapName(instance *networkv1.DNSMasq) string {
+	return fmt.Sprintf("%s-%s-hosts", ServiceName, instance.Name)
+}
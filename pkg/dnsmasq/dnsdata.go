@@ -0,0 +1,159 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsmasq
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"golang.org/x/exp/slices"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+)
+
+const (
+	// DNSDataConfigKey - key of the dnsmasq config directives in a DNSData ConfigMap,
+	// for records which can not be expressed in the hosts file format
+	DNSDataConfigKey = "dnsmasq.conf"
+
+	// wildcardPrefix - prefix of a hostname matching all names of a subdomain
+	wildcardPrefix = "*."
+)
+
+// recordDirectives - dnsmasq config directives of a DNSData defining a record
+var recordDirectives = []string{"address=", "ptr-record=", "srv-host=", "cname="}
+
+// DNSDataConfig - renders the hosts of a DNSData into the hosts file and the
+// dnsmasq config directives for the records not supported by the hosts file
+func DNSDataConfig(spec *networkv1.DNSDataSpec) (string, string) {
+	hostsData, configData := hostsConfig(spec.Hosts, spec.PTRRecords, true)
+
+	// the webhook rejects wildcards in views, address directives apply to
+	// queries arriving on all interfaces
+	for _, view := range spec.Views {
+		viewHosts, viewConfig := hostsConfig(view.Hosts, spec.PTRRecords, false)
+		hostsData += viewHosts
+		configData += viewConfig
+	}
+	if len(spec.Views) > 0 {
+		// answer with the addresses in the subnet of the interface the query
+		// arrived on, which are the ones of the view of that network
+		configData += "localise-queries\n"
+	}
+
+	for _, srv := range spec.SRVRecords {
+		if !networkv1.IsDNSHostname(srv.Domain, false) || !networkv1.IsDNSHostname(srv.Target, false) {
+			continue
+		}
+		configData += fmt.Sprintf("srv-host=_%s._%s.%s,%s,%d,%d,%d\n",
+			srv.Service, srv.Protocol, srv.Domain, srv.Target, srv.Port, srv.Priority, srv.Weight)
+	}
+
+	for _, cname := range spec.CNAMERecords {
+		if !networkv1.IsDNSHostname(cname.Target, false) || slices.ContainsFunc(cname.Aliases,
+			func(alias string) bool { return !networkv1.IsDNSHostname(alias, false) }) {
+			continue
+		}
+		configData += "cname=" + strings.Join(cname.Aliases, ",") + "," + cname.Target + "\n"
+	}
+
+	return hostsData, configData
+}
+
+// CountRecords - returns the number of hostname to address records of a hosts
+// file and of the record directives of a DNSData config
+func CountRecords(hosts string, config string) int32 {
+	var count int32
+	for _, line := range strings.Split(hosts, "\n") {
+		if fields := strings.Fields(line); len(fields) > 1 {
+			count += int32(len(fields) - 1)
+		}
+	}
+	for _, line := range strings.Split(config, "\n") {
+		for _, prefix := range recordDirectives {
+			if strings.HasPrefix(line, prefix) {
+				count++
+				break
+			}
+		}
+	}
+
+	return count
+}
+
+// hostsConfig - renders hosts into the hosts file format and the config
+// directives for wildcard hostnames and PTR records. dnsmasq answers the
+// reverse lookup of an address with a single hostname from the hosts file,
+// which it reloads without a restart, PTR records are only rendered for the
+// addresses with multiple hostnames.
+func hostsConfig(hosts []networkv1.DNSHost, ptrRecords bool, wildcards bool) (string, string) {
+	var hostsData, configData string
+	for _, host := range hosts {
+		hostnames := []string{}
+		for _, hostname := range host.Hostnames {
+			// the webhook rejects them, never render names which break the files
+			if !networkv1.IsDNSHostname(hostname, true) {
+				continue
+			}
+			if strings.HasPrefix(hostname, wildcardPrefix) {
+				if wildcards {
+					// address=/<domain>/<ip> answers for the domain and all of its subdomains
+					configData += "address=/" + strings.TrimPrefix(hostname, wildcardPrefix) + "/" + host.IP + "\n"
+				}
+				continue
+			}
+			hostnames = append(hostnames, hostname)
+		}
+		if len(hostnames) == 0 {
+			continue
+		}
+		sort.Strings(hostnames)
+		hostsData += host.IP + " " + strings.Join(hostnames, " ") + "\n"
+
+		if ptrRecords && len(hostnames) > 1 {
+			if reverse := ReverseName(host.IP); reverse != "" {
+				for _, hostname := range hostnames {
+					configData += "ptr-record=" + reverse + "," + hostname + "\n"
+				}
+			}
+		}
+	}
+
+	return hostsData, configData
+}
+
+// ReverseName - returns the in-addr.arpa/ip6.arpa name of an IP address,
+// or an empty string if addr is not an IP address
+func ReverseName(addr string) string {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return ""
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa", ip4[3], ip4[2], ip4[1], ip4[0])
+	}
+
+	var name strings.Builder
+	for i := len(ip) - 1; i >= 0; i-- {
+		fmt.Fprintf(&name, "%x.%x.", ip[i]&0x0f, ip[i]>>4)
+	}
+	name.WriteString("ip6.arpa")
+
+	return name.String()
+}
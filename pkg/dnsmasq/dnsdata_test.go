@@ -0,0 +1,192 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsmasq
+
+import (
+	"testing"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+)
+
+func TestDNSDataConfig(t *testing.T) {
+	spec := &networkv1.DNSDataSpec{
+		Hosts: []networkv1.DNSHost{
+			{IP: "172.20.0.80", Hostnames: []string{"keystone", "glance"}},
+			{IP: "172.20.0.90", Hostnames: []string{"*.apps.example.com", "ingress.example.com"}},
+			{IP: "172.20.0.91", Hostnames: []string{"*.other.example.com"}},
+		},
+	}
+
+	hosts, cfg := DNSDataConfig(spec)
+
+	wantHosts := "172.20.0.80 glance keystone\n" +
+		"172.20.0.90 ingress.example.com\n"
+	if hosts != wantHosts {
+		t.Errorf("hosts = %q, want %q", hosts, wantHosts)
+	}
+
+	wantCfg := "address=/apps.example.com/172.20.0.90\n" +
+		"address=/other.example.com/172.20.0.91\n"
+	if cfg != wantCfg {
+		t.Errorf("config = %q, want %q", cfg, wantCfg)
+	}
+
+	// the spec must not be modified
+	if spec.Hosts[0].Hostnames[0] != "keystone" {
+		t.Errorf("hostnames of the spec got sorted: %v", spec.Hosts[0].Hostnames)
+	}
+}
+
+func TestDNSDataConfigPTRRecords(t *testing.T) {
+	spec := &networkv1.DNSDataSpec{
+		Hosts: []networkv1.DNSHost{
+			{IP: "172.20.0.80", Hostnames: []string{"keystone", "glance"}},
+			{IP: "172.20.0.81", Hostnames: []string{"placement"}},
+			{IP: "172.20.0.90", Hostnames: []string{"*.apps.example.com"}},
+			{IP: "not-an-ip", Hostnames: []string{"nova", "cinder"}},
+		},
+		PTRRecords: true,
+	}
+
+	_, cfg := DNSDataConfig(spec)
+
+	wantCfg := "ptr-record=80.0.20.172.in-addr.arpa,glance\n" +
+		"ptr-record=80.0.20.172.in-addr.arpa,keystone\n" +
+		"address=/apps.example.com/172.20.0.90\n"
+	if cfg != wantCfg {
+		t.Errorf("config = %q, want %q", cfg, wantCfg)
+	}
+}
+
+func TestReverseName(t *testing.T) {
+	tests := map[string]string{
+		"192.168.122.10":     "10.122.168.192.in-addr.arpa",
+		"2001:db8::567:89ab": "b.a.9.8.7.6.5.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa",
+		"host-ip-1":          "",
+	}
+
+	for addr, want := range tests {
+		if got := ReverseName(addr); got != want {
+			t.Errorf("ReverseName(%s) = %q, want %q", addr, got, want)
+		}
+	}
+}
+
+func TestDNSDataConfigSRVRecords(t *testing.T) {
+	spec := &networkv1.DNSDataSpec{
+		SRVRecords: []networkv1.DNSSRVRecord{
+			{Service: "ldap", Protocol: "tcp", Domain: "example.com", Target: "ldap1.example.com", Port: 389, Priority: 10, Weight: 60},
+			{Service: "sip", Protocol: "udp", Domain: "example.com", Target: "sip.example.com", Port: 5060},
+		},
+	}
+
+	hosts, cfg := DNSDataConfig(spec)
+	if hosts != "" {
+		t.Errorf("hosts = %q, want empty", hosts)
+	}
+
+	wantCfg := "srv-host=_ldap._tcp.example.com,ldap1.example.com,389,10,60\n" +
+		"srv-host=_sip._udp.example.com,sip.example.com,5060,0,0\n"
+	if cfg != wantCfg {
+		t.Errorf("config = %q, want %q", cfg, wantCfg)
+	}
+}
+
+func TestDNSDataConfigCNAMERecords(t *testing.T) {
+	spec := &networkv1.DNSDataSpec{
+		CNAMERecords: []networkv1.DNSCNAMERecord{
+			{Aliases: []string{"identity.example.com", "auth.example.com"}, Target: "keystone.example.com"},
+			{Aliases: []string{"images.example.com"}, Target: "glance.example.com"},
+		},
+	}
+
+	_, cfg := DNSDataConfig(spec)
+
+	wantCfg := "cname=identity.example.com,auth.example.com,keystone.example.com\n" +
+		"cname=images.example.com,glance.example.com\n"
+	if cfg != wantCfg {
+		t.Errorf("config = %q, want %q", cfg, wantCfg)
+	}
+}
+
+func TestDNSDataConfigViews(t *testing.T) {
+	spec := &networkv1.DNSDataSpec{
+		Hosts: []networkv1.DNSHost{
+			{IP: "10.0.0.80", Hostnames: []string{"keystone.example.com", "identity.example.com"}},
+		},
+		Views: []networkv1.DNSDataView{
+			{
+				Network: "internalapi",
+				Hosts: []networkv1.DNSHost{
+					{IP: "172.17.0.80", Hostnames: []string{"keystone.example.com", "*.apps.example.com"}},
+				},
+			},
+		},
+		PTRRecords: true,
+	}
+
+	hosts, cfg := DNSDataConfig(spec)
+
+	wantHosts := "10.0.0.80 identity.example.com keystone.example.com\n" +
+		"172.17.0.80 keystone.example.com\n"
+	if hosts != wantHosts {
+		t.Errorf("hosts = %q, want %q", hosts, wantHosts)
+	}
+
+	wantCfg := "ptr-record=80.0.0.10.in-addr.arpa,identity.example.com\n" +
+		"ptr-record=80.0.0.10.in-addr.arpa,keystone.example.com\n" +
+		"localise-queries\n"
+	if cfg != wantCfg {
+		t.Errorf("config = %q, want %q", cfg, wantCfg)
+	}
+}
+
+func TestCountRecords(t *testing.T) {
+	hosts := "172.20.0.80 glance keystone\n" +
+		"172.20.0.90 ingress.example.com\n"
+	cfg := "address=/apps.example.com/172.20.0.90\n" +
+		"srv-host=_ldap._tcp.example.com,ldap.example.com,389,0,0\n" +
+		"localise-queries\n"
+
+	if got := CountRecords(hosts, cfg); got != 5 {
+		t.Errorf("CountRecords() = %d, want 5", got)
+	}
+	if got := CountRecords("", ""); got != 0 {
+		t.Errorf("CountRecords() = %d, want 0", got)
+	}
+}
+
+func TestDNSDataConfigIPv6(t *testing.T) {
+	spec := &networkv1.DNSDataSpec{
+		Hosts: []networkv1.DNSHost{
+			{IP: "fd00:bbbb::80", Hostnames: []string{"keystone.example.com"}},
+			{IP: "fd00:bbbb::90", Hostnames: []string{"*.apps.example.com"}},
+		},
+	}
+
+	hosts, cfg := DNSDataConfig(spec)
+
+	// dnsmasq answers AAAA queries from IPv6 entries of the hosts file
+	wantHosts := "fd00:bbbb::80 keystone.example.com\n"
+	if hosts != wantHosts {
+		t.Errorf("hosts = %q, want %q", hosts, wantHosts)
+	}
+
+	wantCfg := "address=/apps.example.com/fd00:bbbb::90\n"
+	if cfg != wantCfg {
+		t.Errorf("config = %q, want %q", cfg, wantCfg)
+	}
+}
@@ -0,0 +1,84 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsmasq
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// DefaultTrustAnchors - root trust anchors shipped with dnsmasq
+	DefaultTrustAnchors = "/usr/share/dnsmasq/trust-anchors.conf"
+)
+
+// DNSSECConfig - renders the dnsmasq options enabling DNSSEC validation,
+// returns an error if a trust anchor is malformed
+func DNSSECConfig(dnssec *networkv1.DNSMasqDNSSEC) (string, error) {
+	var cfg strings.Builder
+	cfg.WriteString("dnssec\n")
+
+	if len(dnssec.TrustAnchors) == 0 {
+		cfg.WriteString("conf-file=" + DefaultTrustAnchors + "\n")
+	}
+	for _, anchor := range dnssec.TrustAnchors {
+		if err := validateTrustAnchor(anchor); err != nil {
+			return "", fmt.Errorf("invalid trust anchor %q: %w", anchor, err)
+		}
+		cfg.WriteString("trust-anchor=" + anchor + "\n")
+	}
+
+	if dnssec.CheckUnsigned == nil || *dnssec.CheckUnsigned {
+		cfg.WriteString("dnssec-check-unsigned\n")
+	}
+
+	return cfg.String(), nil
+}
+
+// validateTrustAnchor - checks a DS record in the dnsmasq format
+// <domain>,<key-tag>,<algorithm>,<digest-type>,<digest>
+func validateTrustAnchor(anchor string) error {
+	fields := strings.Split(anchor, ",")
+	if len(fields) != 5 {
+		return fmt.Errorf("expected <domain>,<key-tag>,<algorithm>,<digest-type>,<digest>")
+	}
+
+	domain := strings.TrimSuffix(fields[0], ".")
+	if domain != "" {
+		if errs := validation.IsDNS1123Subdomain(strings.ToLower(domain)); len(errs) > 0 {
+			return fmt.Errorf("domain %s: %s", fields[0], strings.Join(errs, ", "))
+		}
+	}
+	if _, err := strconv.ParseUint(fields[1], 10, 16); err != nil {
+		return fmt.Errorf("key-tag %s: %w", fields[1], err)
+	}
+	if _, err := strconv.ParseUint(fields[2], 10, 8); err != nil {
+		return fmt.Errorf("algorithm %s: %w", fields[2], err)
+	}
+	if _, err := strconv.ParseUint(fields[3], 10, 8); err != nil {
+		return fmt.Errorf("digest-type %s: %w", fields[3], err)
+	}
+	if _, err := hex.DecodeString(fields[4]); err != nil || fields[4] == "" {
+		return fmt.Errorf("digest %s is not hex encoded", fields[4])
+	}
+
+	return nil
+}
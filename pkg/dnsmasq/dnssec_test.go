@@ -0,0 +1,91 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsmasq
+
+import (
+	"strings"
+	"testing"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+)
+
+const rootAnchor = ".,20326,8,2,E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D"
+
+func TestDNSSECConfig(t *testing.T) {
+	checkUnsigned := false
+
+	tests := []struct {
+		name    string
+		dnssec  networkv1.DNSMasqDNSSEC
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "default trust anchors",
+			dnssec: networkv1.DNSMasqDNSSEC{},
+			want:   []string{"dnssec\n", "conf-file=" + DefaultTrustAnchors + "\n", "dnssec-check-unsigned\n"},
+		},
+		{
+			name: "custom trust anchor",
+			dnssec: networkv1.DNSMasqDNSSEC{
+				TrustAnchors:  []string{rootAnchor},
+				CheckUnsigned: &checkUnsigned,
+			},
+			want: []string{"dnssec\n", "trust-anchor=" + rootAnchor + "\n"},
+		},
+		{
+			name:    "missing digest",
+			dnssec:  networkv1.DNSMasqDNSSEC{TrustAnchors: []string{".,20326,8,2"}},
+			wantErr: true,
+		},
+		{
+			name:    "bad key tag",
+			dnssec:  networkv1.DNSMasqDNSSEC{TrustAnchors: []string{"example.com,70000,8,2,AB"}},
+			wantErr: true,
+		},
+		{
+			name:    "bad digest",
+			dnssec:  networkv1.DNSMasqDNSSEC{TrustAnchors: []string{"example.com,1,8,2,XYZ"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := DNSSECConfig(&tt.dnssec)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got config %q", cfg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(cfg, want) {
+					t.Errorf("config does not contain %q:\n%s", want, cfg)
+				}
+			}
+			if tt.dnssec.CheckUnsigned != nil && strings.Contains(cfg, "dnssec-check-unsigned") {
+				t.Errorf("config should not check unsigned answers:\n%s", cfg)
+			}
+			if len(tt.dnssec.TrustAnchors) > 0 && strings.Contains(cfg, "conf-file=") {
+				t.Errorf("config should not include the default trust anchors:\n%s", cfg)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsmasq
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+	"golang.org/x/exp/slices"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ExternalDNSEndpoints - returns the records of a DNSData as the spec.endpoints
+// of an ExternalDNS DNSEndpoint, sorted by name and record type
+func ExternalDNSEndpoints(spec *networkv1.DNSDataSpec) []interface{} {
+	// targets keyed by record type and name
+	records := map[string]map[string][]string{}
+	add := func(recordType string, name string, target string) {
+		if records[recordType] == nil {
+			records[recordType] = map[string][]string{}
+		}
+		records[recordType][name] = append(records[recordType][name], target)
+	}
+
+	for _, host := range spec.Hosts {
+		ip := net.ParseIP(host.IP)
+		if ip == nil {
+			continue
+		}
+		recordType := "AAAA"
+		if ip.To4() != nil {
+			recordType = "A"
+		}
+		for _, hostname := range host.Hostnames {
+			add(recordType, hostname, ip.String())
+		}
+	}
+	for _, srv := range spec.SRVRecords {
+		add("SRV",
+			fmt.Sprintf("_%s._%s.%s", srv.Service, srv.Protocol, srv.Domain),
+			fmt.Sprintf("%d %d %d %s", srv.Priority, srv.Weight, srv.Port, srv.Target))
+	}
+	for _, cname := range spec.CNAMERecords {
+		for _, alias := range cname.Aliases {
+			add("CNAME", alias, cname.Target)
+		}
+	}
+
+	endpoints := []interface{}{}
+	for _, recordType := range []string{"A", "AAAA", "CNAME", "SRV"} {
+		names := []string{}
+		for name := range records[recordType] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			targets := []interface{}{}
+			for _, target := range records[recordType][name] {
+				targets = append(targets, target)
+			}
+			endpoint := map[string]interface{}{
+				"dnsName":    strings.TrimSuffix(name, "."),
+				"recordType": recordType,
+				"targets":    targets,
+			}
+			if spec.ExternalDNS != nil && spec.ExternalDNS.RecordTTL != nil {
+				endpoint["recordTTL"] = *spec.ExternalDNS.RecordTTL
+			}
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+
+	return endpoints
+}
+
+// AddDNSEndpointHosts - adds the A and AAAA records of the spec.endpoints of an
+// ExternalDNS DNSEndpoint to the hosts keyed by IP, only those can be represented
+// in the dnsmasq hosts file. Returns the targets which are no IP address.
+func AddDNSEndpointHosts(hosts map[string]networkv1.DNSHost, endpoints []interface{}) []string {
+	invalid := []string{}
+	for _, e := range endpoints {
+		endpoint, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		recordType, _, _ := unstructured.NestedString(endpoint, "recordType")
+		if recordType != "A" && recordType != "AAAA" {
+			continue
+		}
+		hostname, _, _ := unstructured.NestedString(endpoint, "dnsName")
+		hostname = strings.TrimSuffix(hostname, ".")
+		if hostname == "" {
+			continue
+		}
+		targets, _, _ := unstructured.NestedStringSlice(endpoint, "targets")
+
+		for _, target := range targets {
+			addr := net.ParseIP(target)
+			if addr == nil {
+				invalid = append(invalid, target)
+				continue
+			}
+
+			host, ok := hosts[addr.String()]
+			if !ok {
+				host = networkv1.DNSHost{IP: addr.String()}
+			}
+			if !slices.Contains(host.Hostnames, hostname) {
+				host.Hostnames = append(host.Hostnames, hostname)
+				sort.Strings(host.Hostnames)
+			}
+			hosts[addr.String()] = host
+		}
+	}
+
+	return invalid
+}
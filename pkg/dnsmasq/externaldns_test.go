@@ -0,0 +1,84 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsmasq
+
+import (
+	"reflect"
+	"testing"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+)
+
+func TestExternalDNSEndpoints(t *testing.T) {
+	ttl := int64(300)
+	spec := &networkv1.DNSDataSpec{
+		Hosts: []networkv1.DNSHost{
+			{IP: "172.20.0.81", Hostnames: []string{"keystone.example.com"}},
+			{IP: "172.20.0.80", Hostnames: []string{"keystone.example.com", "*.apps.example.com"}},
+			{IP: "fd00::80", Hostnames: []string{"keystone.example.com"}},
+			{IP: "not-an-ip", Hostnames: []string{"nova.example.com"}},
+		},
+		SRVRecords: []networkv1.DNSSRVRecord{
+			{Service: "ldap", Protocol: "tcp", Domain: "example.com", Target: "ldap1.example.com", Port: 389, Priority: 10, Weight: 60},
+		},
+		CNAMERecords: []networkv1.DNSCNAMERecord{
+			{Aliases: []string{"identity.example.com"}, Target: "keystone.example.com"},
+		},
+		ExternalDNS: &networkv1.DNSDataExternalDNS{RecordTTL: &ttl},
+	}
+
+	want := []interface{}{
+		map[string]interface{}{"dnsName": "*.apps.example.com", "recordType": "A", "targets": []interface{}{"172.20.0.80"}, "recordTTL": ttl},
+		map[string]interface{}{"dnsName": "keystone.example.com", "recordType": "A", "targets": []interface{}{"172.20.0.81", "172.20.0.80"}, "recordTTL": ttl},
+		map[string]interface{}{"dnsName": "keystone.example.com", "recordType": "AAAA", "targets": []interface{}{"fd00::80"}, "recordTTL": ttl},
+		map[string]interface{}{"dnsName": "identity.example.com", "recordType": "CNAME", "targets": []interface{}{"keystone.example.com"}, "recordTTL": ttl},
+		map[string]interface{}{"dnsName": "_ldap._tcp.example.com", "recordType": "SRV", "targets": []interface{}{"10 60 389 ldap1.example.com"}, "recordTTL": ttl},
+	}
+
+	got := ExternalDNSEndpoints(spec)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("endpoints = %v, want %v", got, want)
+	}
+}
+
+func TestAddDNSEndpointHosts(t *testing.T) {
+	hosts := map[string]networkv1.DNSHost{}
+
+	invalid := AddDNSEndpointHosts(hosts, []interface{}{
+		map[string]interface{}{"dnsName": "keystone.example.com.", "recordType": "A", "targets": []interface{}{"172.20.0.80", "not-an-ip"}},
+		map[string]interface{}{"dnsName": "api.example.com", "recordType": "A", "targets": []interface{}{"172.20.0.80"}},
+		map[string]interface{}{"dnsName": "keystone.example.com", "recordType": "AAAA", "targets": []interface{}{"fd00:0::0080"}},
+		// only address records are added
+		map[string]interface{}{"dnsName": "identity.example.com", "recordType": "CNAME", "targets": []interface{}{"keystone.example.com"}},
+		map[string]interface{}{"recordType": "A", "targets": []interface{}{"172.20.0.81"}},
+		"not-an-endpoint",
+	})
+	// the records of another DNSEndpoint get merged
+	invalid = append(invalid, AddDNSEndpointHosts(hosts, []interface{}{
+		map[string]interface{}{"dnsName": "keystone.example.com", "recordType": "A", "targets": []interface{}{"172.20.0.80"}},
+	})...)
+
+	want := map[string]networkv1.DNSHost{
+		"172.20.0.80": {IP: "172.20.0.80", Hostnames: []string{"api.example.com", "keystone.example.com"}},
+		"fd00::80":    {IP: "fd00::80", Hostnames: []string{"keystone.example.com"}},
+	}
+	if !reflect.DeepEqual(hosts, want) {
+		t.Errorf("hosts = %v, want %v", hosts, want)
+	}
+	if !reflect.DeepEqual(invalid, []string{"not-an-ip"}) {
+		t.Errorf("invalid = %v, want [not-an-ip]", invalid)
+	}
+}
@@ -0,0 +1,137 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsmasq
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// healthCheckTimeout - timeout to resolve the canary record
+const healthCheckTimeout = 5 * time.Second
+
+// Resolve resolves hostname via the DNS server at addr, <ip>:<port>, and
+// returns the latency of the resolution. The hostname is resolved as fully
+// qualified name, so the search domains of the operator pod are not applied.
+func Resolve(ctx context.Context, addr string, hostname string) (time.Duration, error) {
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			dialer := &net.Dialer{}
+			return dialer.DialContext(ctx, network, addr)
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	_, err := resolver.LookupHost(ctx, strings.TrimSuffix(hostname, ".")+".")
+	return time.Since(start), err
+}
+
+// HealthCheckResult - outcome of a canary record resolution
+type HealthCheckResult struct {
+	// CheckedAt - when the resolution started
+	CheckedAt time.Time
+	Server    string
+	Hostname  string
+	Latency   time.Duration
+	Err       error
+}
+
+// HealthChecker resolves the canary records in the background, so the
+// reconciliation never waits on a slow or unreachable DNS server. The zero
+// value is ready to use.
+type HealthChecker struct {
+	mu sync.Mutex
+	// pending - the resolutions in flight, by the sequence number they got started with
+	pending map[string]uint64
+	results map[string]HealthCheckResult
+	seq     uint64
+}
+
+// Start resolves hostname via server, <ip>:<port>, in the background and
+// stores the result under key. Returns false if a resolution of key is still
+// in flight.
+func (c *HealthChecker) Start(key string, server string, hostname string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.pending[key]; ok {
+		return false
+	}
+	if c.pending == nil {
+		c.pending = map[string]uint64{}
+		c.results = map[string]HealthCheckResult{}
+	}
+	c.seq++
+	seq := c.seq
+	c.pending[key] = seq
+	delete(c.results, key)
+
+	checkedAt := time.Now().UTC()
+	go func() {
+		latency, err := Resolve(context.Background(), server, hostname)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		// the key got forgotten while resolving
+		if c.pending[key] != seq {
+			return
+		}
+		delete(c.pending, key)
+		c.results[key] = HealthCheckResult{
+			CheckedAt: checkedAt,
+			Server:    server,
+			Hostname:  hostname,
+			Latency:   latency,
+			Err:       err,
+		}
+	}()
+
+	return true
+}
+
+// Result returns and removes the result of the finished resolution of key.
+// pending is true while a resolution of key is in flight.
+func (c *HealthChecker) Result(key string) (result *HealthCheckResult, pending bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.pending[key]; ok {
+		return nil, true
+	}
+	res, ok := c.results[key]
+	if !ok {
+		return nil, false
+	}
+	delete(c.results, key)
+
+	return &res, false
+}
+
+// Forget drops the resolution in flight and the result of key
+func (c *HealthChecker) Forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.pending, key)
+	delete(c.results, key)
+}
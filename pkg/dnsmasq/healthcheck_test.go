@@ -0,0 +1,117 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsmasq
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+// serveDNS answers the A query for canary.example.com. received on conn with
+// 192.0.2.1, its other queries without records and all other names with NXDOMAIN
+func serveDNS(conn net.PacketConn) {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		query := buf[:n]
+		// the question follows the 12 bytes header, the name is followed by type and class
+		end := 12
+		for end < n && query[end] != 0 {
+			end += int(query[end]) + 1
+		}
+		end += 5
+		if end > n {
+			continue
+		}
+		name := query[12 : end-4]
+		qtype := binary.BigEndian.Uint16(query[end-4 : end-2])
+
+		resp := append([]byte{}, query[:end]...)
+		// response, recursion desired and available
+		resp[2] = 0x81
+		resp[3] = 0x80
+		// no additional records, e.g. EDNS
+		resp[10], resp[11] = 0, 0
+		switch {
+		case string(name) != "\x06canary\x07example\x03com\x00":
+			// NXDOMAIN
+			resp[3] |= 3
+		case qtype == 1:
+			resp[7] = 1
+			// pointer to the question name, type A, class IN, TTL, rdata
+			resp = append(resp, 0xc0, 0x0c, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4, 192, 0, 2, 1)
+		}
+		_, _ = conn.WriteTo(resp, addr)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	g := NewWithT(t)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer conn.Close()
+	go serveDNS(conn)
+
+	_, err = Resolve(context.Background(), conn.LocalAddr().String(), "canary.example.com")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	_, err = Resolve(context.Background(), conn.LocalAddr().String(), "missing.example.com")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestHealthChecker(t *testing.T) {
+	g := NewWithT(t)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer conn.Close()
+	go serveDNS(conn)
+
+	checker := &HealthChecker{}
+	res, pending := checker.Result("foo/dnsmasq")
+	g.Expect(res).To(BeNil())
+	g.Expect(pending).To(BeFalse())
+
+	g.Expect(checker.Start("foo/dnsmasq", conn.LocalAddr().String(), "canary.example.com")).To(BeTrue())
+	g.Eventually(func(g Gomega) {
+		res, pending := checker.Result("foo/dnsmasq")
+		g.Expect(pending).To(BeFalse())
+		g.Expect(res).NotTo(BeNil())
+		g.Expect(res.Err).NotTo(HaveOccurred())
+		g.Expect(res.Hostname).To(Equal("canary.example.com"))
+	}, 10*time.Second, 10*time.Millisecond).Should(Succeed())
+
+	// the result is returned once
+	res, pending = checker.Result("foo/dnsmasq")
+	g.Expect(res).To(BeNil())
+	g.Expect(pending).To(BeFalse())
+
+	g.Expect(checker.Start("foo/dnsmasq", conn.LocalAddr().String(), "missing.example.com")).To(BeTrue())
+	checker.Forget("foo/dnsmasq")
+	time.Sleep(100 * time.Millisecond)
+	res, pending = checker.Result("foo/dnsmasq")
+	g.Expect(res).To(BeNil())
+	g.Expect(pending).To(BeFalse())
+}
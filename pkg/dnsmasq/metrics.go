@@ -0,0 +1,79 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsmasq
+
+import (
+	"fmt"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+	labels "github.com/openstack-k8s-operators/lib-common/modules/common/labels"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// MetricsServiceName - returns the name of the service exposing the metrics of the instance
+func MetricsServiceName(instance *networkv1.DNSMasq) string {
+	return fmt.Sprintf("%s-%s-%s", ServiceName, instance.Name, MetricsPortName)
+}
+
+// MetricsService - returns the service in front of the exporter sidecars, the
+// ServiceMonitor selects it by the labels of the instance
+func MetricsService(instance *networkv1.DNSMasq) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      MetricsServiceName(instance),
+			Namespace: instance.Namespace,
+			Labels: util.MergeStringMaps(
+				labels.GetLabels(instance, labels.GetGroupLabel(ServiceName), map[string]string{}),
+				PodSelector(instance)),
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: PodSelector(instance),
+			Ports: []corev1.ServicePort{
+				{
+					Name:       MetricsPortName,
+					Port:       instance.Spec.Metrics.Port,
+					TargetPort: intstr.FromString(MetricsPortName),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+		},
+	}
+}
+
+// ServiceMonitorSpec - returns the spec of the prometheus-operator ServiceMonitor
+// scraping the exporter sidecars of the instance via the metrics service
+func ServiceMonitorSpec(instance *networkv1.DNSMasq) map[string]interface{} {
+	matchLabels := map[string]interface{}{}
+	for k, v := range PodSelector(instance) {
+		matchLabels[k] = v
+	}
+
+	return map[string]interface{}{
+		"selector": map[string]interface{}{
+			"matchLabels": matchLabels,
+		},
+		"endpoints": []interface{}{
+			map[string]interface{}{
+				"port": MetricsPortName,
+				"path": "/metrics",
+			},
+		},
+	}
+}
@@ -0,0 +1,107 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsmasq
+
+import (
+	"fmt"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+	labels "github.com/openstack-k8s-operators/lib-common/modules/common/labels"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// NetworkPolicy - returns the NetworkPolicy limiting the DNS queries to the
+// dnsmasq pods of the instance to the configured clients. The metrics port of
+// the exporter sidecar stays reachable for the cluster monitoring.
+func NetworkPolicy(instance *networkv1.DNSMasq) *networkingv1.NetworkPolicy {
+	udp := corev1.ProtocolUDP
+	tcp := corev1.ProtocolTCP
+	dnsPort := intstr.FromInt(int(DNSPort))
+
+	peers := []networkingv1.NetworkPolicyPeer{}
+	for _, cidr := range instance.Spec.NetworkPolicy.CIDRs {
+		peers = append(peers, networkingv1.NetworkPolicyPeer{
+			IPBlock: &networkingv1.IPBlock{CIDR: cidr},
+		})
+	}
+	// a peer with only a podSelector matches pods in the policy namespace,
+	// with both selectors set the pods have to match both
+	if instance.Spec.NetworkPolicy.NamespaceSelector != nil || instance.Spec.NetworkPolicy.PodSelector != nil {
+		peers = append(peers, networkingv1.NetworkPolicyPeer{
+			NamespaceSelector: instance.Spec.NetworkPolicy.NamespaceSelector,
+			PodSelector:       instance.Spec.NetworkPolicy.PodSelector,
+		})
+	}
+	if len(peers) == 0 {
+		peers = append(peers, networkingv1.NetworkPolicyPeer{
+			PodSelector: &metav1.LabelSelector{},
+		})
+	}
+
+	ingress := []networkingv1.NetworkPolicyIngressRule{
+		{
+			From: peers,
+			Ports: []networkingv1.NetworkPolicyPort{
+				{Protocol: &udp, Port: &dnsPort},
+				{Protocol: &tcp, Port: &dnsPort},
+			},
+		},
+	}
+	// the operator resolves the canary record of the health check
+	if instance.Spec.HealthCheck != nil {
+		ingress = append(ingress, networkingv1.NetworkPolicyIngressRule{
+			From: []networkingv1.NetworkPolicyPeer{
+				{
+					NamespaceSelector: &metav1.LabelSelector{},
+					PodSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{OperatorNameLabel: OperatorName},
+					},
+				},
+			},
+			Ports: []networkingv1.NetworkPolicyPort{
+				{Protocol: &udp, Port: &dnsPort},
+				{Protocol: &tcp, Port: &dnsPort},
+			},
+		})
+	}
+	if instance.Spec.Metrics != nil {
+		metricsPort := intstr.FromInt(int(instance.Spec.Metrics.Port))
+		ingress = append(ingress, networkingv1.NetworkPolicyIngressRule{
+			Ports: []networkingv1.NetworkPolicyPort{
+				{Protocol: &tcp, Port: &metricsPort},
+			},
+		})
+	}
+
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", ServiceName, instance.Name),
+			Namespace: instance.Namespace,
+			Labels:    labels.GetLabels(instance, labels.GetGroupLabel(ServiceName), map[string]string{}),
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: PodSelector(instance),
+			},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress:     ingress,
+		},
+	}
+}
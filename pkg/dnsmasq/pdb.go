@@ -0,0 +1,55 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsmasq
+
+import (
+	"fmt"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+	labels "github.com/openstack-k8s-operators/lib-common/modules/common/labels"
+
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// PodSelector - returns the labels selecting the dnsmasq pods of the instance
+func PodSelector(instance *networkv1.DNSMasq) map[string]string {
+	return map[string]string{
+		labels.GetOwnerUIDLabelSelector(labels.GetGroupLabel(ServiceName)): string(instance.UID),
+	}
+}
+
+// PodDisruptionBudget - returns the PodDisruptionBudget allowing only one dnsmasq
+// pod of the instance to be unavailable at a time, so node drains never take down
+// all resolvers at once
+func PodDisruptionBudget(instance *networkv1.DNSMasq) *policyv1.PodDisruptionBudget {
+	maxUnavailable := intstr.FromInt(1)
+
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", ServiceName, instance.Name),
+			Namespace: instance.Namespace,
+			Labels:    labels.GetLabels(instance, labels.GetGroupLabel(ServiceName), map[string]string{}),
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MaxUnavailable: &maxUnavailable,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: PodSelector(instance),
+			},
+		},
+	}
+}
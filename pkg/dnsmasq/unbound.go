@@ -0,0 +1,63 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsmasq
+
+import (
+	"fmt"
+	"strings"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/tls"
+)
+
+const (
+	// UnboundConfigKey - key of the unbound config in the service ConfigMap
+	UnboundConfigKey = "unbound.conf"
+
+	// UnboundPort - local port the DNS-over-TLS forwarder listens on
+	UnboundPort int32 = 5353
+
+	// unboundSystemCABundle - CA bundle of the image, used if no CA secret is configured
+	unboundSystemCABundle = "/etc/pki/tls/certs/ca-bundle.crt"
+)
+
+// UnboundConfig - renders the config of the unbound sidecar which forwards
+// all queries it receives from dnsmasq to the upstream servers over TLS
+func UnboundConfig(upstream *networkv1.DNSMasqUpstreamTLS) string {
+	caBundle := unboundSystemCABundle
+	if upstream.CaBundleSecretName != "" {
+		caBundle = tls.DownstreamTLSCABundlePath
+	}
+
+	var cfg strings.Builder
+	cfg.WriteString("server:\n")
+	fmt.Fprintf(&cfg, "  interface: 127.0.0.1@%d\n", UnboundPort)
+	cfg.WriteString("  do-daemonize: no\n")
+	cfg.WriteString("  username: \"\"\n")
+	cfg.WriteString("  chroot: \"\"\n")
+	cfg.WriteString("  use-syslog: no\n")
+	cfg.WriteString("  logfile: \"\"\n")
+	cfg.WriteString("  module-config: \"iterator\"\n")
+	fmt.Fprintf(&cfg, "  tls-cert-bundle: %s\n", caBundle)
+	cfg.WriteString("forward-zone:\n")
+	cfg.WriteString("  name: \".\"\n")
+	cfg.WriteString("  forward-tls-upstream: yes\n")
+	for _, server := range upstream.Servers {
+		fmt.Fprintf(&cfg, "  forward-addr: %s@%d#%s\n", server.Address, server.Port, server.AuthName)
+	}
+
+	return cfg.String()
+}
@@ -0,0 +1,52 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsmasq
+
+import (
+	"strings"
+	"testing"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/tls"
+)
+
+func TestUnboundConfig(t *testing.T) {
+	upstream := &networkv1.DNSMasqUpstreamTLS{
+		Servers: []networkv1.DNSMasqTLSServer{
+			{Address: "1.1.1.1", Port: 853, AuthName: "cloudflare-dns.com"},
+			{Address: "2001:db8::1", Port: 8853, AuthName: "dns.example.com"},
+		},
+	}
+
+	cfg := UnboundConfig(upstream)
+	for _, want := range []string{
+		"interface: 127.0.0.1@5353\n",
+		"forward-tls-upstream: yes\n",
+		"forward-addr: 1.1.1.1@853#cloudflare-dns.com\n",
+		"forward-addr: 2001:db8::1@8853#dns.example.com\n",
+		"tls-cert-bundle: " + unboundSystemCABundle + "\n",
+	} {
+		if !strings.Contains(cfg, want) {
+			t.Errorf("config does not contain %q:\n%s", want, cfg)
+		}
+	}
+
+	upstream.CaBundleSecretName = "combined-ca-bundle"
+	cfg = UnboundConfig(upstream)
+	if !strings.Contains(cfg, "tls-cert-bundle: "+tls.DownstreamTLSCABundlePath+"\n") {
+		t.Errorf("config does not use the mounted CA bundle:\n%s", cfg)
+	}
+}
@@ -16,13 +16,13 @@ limitations under the License.
 package dnsmasq
 
 import (
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 )
 
 // getVolumes - service volumes
 func getVolumes(
-	name string,
-	cms *corev1.ConfigMapList,
+	instance *networkv1.DNSMasq,
 ) []corev1.Volume {
 	var config0640AccessMode int32 = 0640
 
@@ -33,25 +33,22 @@ func getVolumes(
 				ConfigMap: &corev1.ConfigMapVolumeSource{
 					DefaultMode: &config0640AccessMode,
 					LocalObjectReference: corev1.LocalObjectReference{
-						Name: name,
+						Name: instance.Name,
 					},
 				},
 			},
 		},
-	}
-
-	for _, cm := range cms.Items {
-		volumes = append(volumes, corev1.Volume{
-			Name: cm.Name,
+		{
+			Name: "hosts",
 			VolumeSource: corev1.VolumeSource{
 				ConfigMap: &corev1.ConfigMapVolumeSource{
 					DefaultMode: &config0640AccessMode,
 					LocalObjectReference: corev1.LocalObjectReference{
-						Name: cm.Name,
+						Name: HostsConfigMapName(instance),
 					},
 				},
 			},
-		})
+		},
 	}
 
 	return volumes
@@ -59,26 +56,46 @@ func getVolumes(
 
 // getVolumeMounts - general VolumeMounts
 func getVolumeMounts(
-	name string,
-	cms *corev1.ConfigMapList,
+	instance *networkv1.DNSMasq,
 ) []corev1.VolumeMount {
 
 	volumeMounts := []corev1.VolumeMount{
 		{
 			Name:      "config",
 			MountPath: "/etc/dnsmasq.d/config.cfg",
-			SubPath:   name,
+			SubPath:   instance.Name,
+			ReadOnly:  true,
+		},
+		{
+			Name:      "config",
+			MountPath: "/etc/dnsmasq.d/dnsdata.conf",
+			SubPath:   DNSDataConfigKey,
+			ReadOnly:  true,
+		},
+		// no SubPath, so the kubelet updates the hosts files in place
+		{
+			Name:      "hosts",
+			MountPath: HostsDir,
 			ReadOnly:  true,
 		},
 	}
 
-	for _, cm := range cms.Items {
-		volumeMounts = append(volumeMounts, corev1.VolumeMount{
-			Name:      cm.Name,
-			MountPath: "/etc/dnsmasq.d/hosts/" + cm.Name,
-			SubPath:   cm.Name,
+	return volumeMounts
+}
+
+// getUnboundVolumeMounts - VolumeMounts of the DNS-over-TLS sidecar
+func getUnboundVolumeMounts(instance *networkv1.DNSMasq) []corev1.VolumeMount {
+	volumeMounts := []corev1.VolumeMount{
+		{
+			Name:      "config",
+			MountPath: "/etc/unbound/unbound.conf",
+			SubPath:   UnboundConfigKey,
 			ReadOnly:  true,
-		})
+		},
+	}
+
+	if instance.Spec.UpstreamTLS.CaBundleSecretName != "" {
+		volumeMounts = append(volumeMounts, instance.Spec.UpstreamTLS.CreateVolumeMounts(nil)...)
 	}
 
 	return volumeMounts
@@ -0,0 +1,56 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipam
+
+import (
+	"net/netip"
+	"sort"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+)
+
+// AnnouncedPrefixes returns the host prefixes, /32 or /128, of the addresses
+// reserved on the networks of the NetConfig for the announced IPSets. The
+// prefixes are sorted and unique.
+func AnnouncedPrefixes(
+	netcfg *networkv1.NetConfig,
+	reservations *networkv1.ReservationList,
+	announced map[string]bool,
+) []string {
+	prefixes := map[string]bool{}
+
+	for _, res := range reservations.Items {
+		if !announced[res.Spec.IPSetRef.Name] {
+			continue
+		}
+		for _, ip := range res.Spec.Reservation {
+			if _, _, err := netcfg.GetNetAndSubnet(ip.Network, ip.Subnet); err != nil {
+				continue
+			}
+			addr, err := netip.ParseAddr(ip.Address)
+			if err != nil {
+				continue
+			}
+			addr = addr.Unmap()
+			prefixes[netip.PrefixFrom(addr, addr.BitLen()).String()] = true
+		}
+	}
+
+	sorted := []string{}
+	for prefix := range prefixes {
+		sorted = append(sorted, prefix)
+	}
+	sort.Strings(sorted)
+
+	return sorted
+}
@@ -0,0 +1,94 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipam
+
+import (
+	"reflect"
+	"testing"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+)
+
+func TestAnnouncedPrefixes(t *testing.T) {
+	netcfg := &networkv1.NetConfig{
+		Spec: networkv1.NetConfigSpec{
+			Networks: []networkv1.Network{
+				{
+					Name: "net1",
+					Subnets: []networkv1.Subnet{
+						{Name: "subnet1", Cidr: "172.17.0.0/24"},
+						{Name: "subnet2", Cidr: "fd00::/64"},
+					},
+				},
+			},
+		},
+	}
+
+	reservations := &networkv1.ReservationList{}
+	for _, ipset := range []struct {
+		name  string
+		addrs []networkv1.IPAddress
+	}{
+		{
+			name: "vip",
+			addrs: []networkv1.IPAddress{
+				{Network: "net1", Subnet: "subnet1", Address: "172.17.0.10"},
+				{Network: "net1", Subnet: "subnet2", Address: "fd00:0::0010"},
+			},
+		},
+		{
+			name: "vip-other-netconfig",
+			addrs: []networkv1.IPAddress{
+				{Network: "net2", Subnet: "subnet1", Address: "172.18.0.10"},
+			},
+		},
+		{
+			name: "host",
+			addrs: []networkv1.IPAddress{
+				{Network: "net1", Subnet: "subnet1", Address: "172.17.0.11"},
+			},
+		},
+	} {
+		res := networkv1.Reservation{}
+		res.Spec.IPSetRef.Name = ipset.name
+		res.Spec.Reservation = map[string]networkv1.IPAddress{}
+		for _, addr := range ipset.addrs {
+			res.Spec.Reservation[ReservationKey(addr.Network, addr.Address, true)] = addr
+		}
+		reservations.Items = append(reservations.Items, res)
+	}
+
+	tests := []struct {
+		name      string
+		announced map[string]bool
+		want      []string
+	}{
+		{
+			name: "no IPSet announced",
+			want: []string{},
+		},
+		{
+			name:      "host prefixes of the announced IPSets on the networks of the NetConfig",
+			announced: map[string]bool{"vip": true, "vip-other-netconfig": true},
+			want:      []string{"172.17.0.10/32", "fd00::10/128"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AnnouncedPrefixes(netcfg, reservations, tt.announced); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AnnouncedPrefixes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
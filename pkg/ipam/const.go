@@ -15,4 +15,13 @@ package ipam
 const (
 	// IPAMLabelKey -
 	IPAMLabelKey = "ipam.network.openstack.org"
+
+	// DualStackIPv6Suffix - suffix of the Reservation key and label of the IPv6
+	// address of a network an IPSet requests addresses of both IP families from.
+	// The dot is not allowed in network names, so the key can not clash.
+	DualStackIPv6Suffix = ".ipv6"
+
+	// ImportedAnnotation - annotation of a Reservation seeded from the
+	// ImportedReservations of the NetConfig
+	ImportedAnnotation = "network.openstack.org/imported"
 )
@@ -1,12 +1,14 @@
 package ipam
 
 import (
+	"crypto/rand"
 	"fmt"
+	"math/big"
 	"net"
 	"net/netip"
+	"strings"
 
 	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
-	"golang.org/x/exp/slices"
 )
 
 // AssignIPDetails -
@@ -16,6 +18,20 @@ type AssignIPDetails struct {
 	SubNet      *networkv1.Subnet
 	Reservelist *networkv1.ReservationList
 	FixedIP     net.IP
+	// CurrentIP - FixedIP is the current address of an allocated block, not one
+	// requested by the user, so the block may only grow within the AllocationRanges
+	CurrentIP bool
+}
+
+// FixedIPReservedError - the requested FixedIP is already reserved for another IPSet
+type FixedIPReservedError struct {
+	Address string
+	NetName string
+	IPSet   string
+}
+
+func (e *FixedIPReservedError) Error() string {
+	return fmt.Sprintf("%s already reserved for %s", e.Address, e.IPSet)
 }
 
 // AssignIP assigns an IP using a range and a reserve list.
@@ -37,30 +53,218 @@ func (a *AssignIPDetails) AssignIP() (*networkv1.IPAddress, error) {
 	return newIP, nil
 }
 
+// reservedAddresses returns the addresses of the network reserved for other
+// IPSets, mapped to the IPSet. The addresses are parsed, so they match
+// independent of their textual form, e.g. fd00::10 and fd00:0::0010.
+func (a *AssignIPDetails) reservedAddresses() map[netip.Addr]string {
+	reserved := map[netip.Addr]string{}
+	for _, res := range a.Reservelist.Items {
+		if res.Spec.IPSetRef.Name == a.IPSet {
+			continue
+		}
+		for _, ip := range res.Spec.Reservation {
+			if !strings.EqualFold(string(ip.Network), a.NetName) {
+				continue
+			}
+			addr, err := netip.ParseAddr(ip.Address)
+			if err != nil {
+				continue
+			}
+			reserved[addr.Unmap()] = res.Spec.IPSetRef.Name
+		}
+	}
+
+	return reserved
+}
+
+// excludedAddresses returns the parsed ExcludeAddresses of the subnet
+func (a *AssignIPDetails) excludedAddresses() map[netip.Addr]bool {
+	excluded := map[netip.Addr]bool{}
+	for _, exclAddress := range a.SubNet.ExcludeAddresses {
+		addr, err := netip.ParseAddr(exclAddress)
+		if err != nil {
+			continue
+		}
+		excluded[addr.Unmap()] = true
+	}
+
+	return excluded
+}
+
+// excludedRanges returns the parsed ExcludeRanges of the subnet
+func (a *AssignIPDetails) excludedRanges() [][2]netip.Addr {
+	excluded := [][2]netip.Addr{}
+	for _, exclRange := range a.SubNet.ExcludeRanges {
+		start, err := netip.ParseAddr(exclRange.Start)
+		if err != nil {
+			continue
+		}
+		end, err := netip.ParseAddr(exclRange.End)
+		if err != nil {
+			continue
+		}
+		excluded = append(excluded, [2]netip.Addr{start.Unmap(), end.Unmap()})
+	}
+
+	return excluded
+}
+
+// rangeOf returns the range addr is within, if any
+func rangeOf(addr netip.Addr, ranges [][2]netip.Addr) ([2]netip.Addr, bool) {
+	for _, r := range ranges {
+		if addr.BitLen() == r[0].BitLen() && addr.Compare(r[0]) >= 0 && addr.Compare(r[1]) <= 0 {
+			return r, true
+		}
+	}
+
+	return [2]netip.Addr{}, false
+}
+
 func (a *AssignIPDetails) fixedIPExists() (*networkv1.IPAddress, error) {
-	fixedIP := a.FixedIP.String()
-	if slices.Contains(a.SubNet.ExcludeAddresses, fixedIP) {
+	fixedIP, ok := netip.AddrFromSlice(a.FixedIP)
+	if !ok {
+		return nil, fmt.Errorf("FixedIP %s is not a valid IP address", a.FixedIP)
+	}
+	fixedIP = fixedIP.Unmap()
+
+	if a.excludedAddresses()[fixedIP] {
 		return nil, fmt.Errorf("FixedIP %s is in ExcludeAddresses", fixedIP)
 	}
 
-	// validate of nextip is already in a reservation and its not us
-	f := func(c networkv1.Reservation) bool {
-		return c.Spec.Reservation[a.NetName].Address == fixedIP
+	if _, ok := rangeOf(fixedIP, a.excludedRanges()); ok {
+		return nil, fmt.Errorf("FixedIP %s is in ExcludeRanges", fixedIP)
 	}
-	idx := slices.IndexFunc(a.Reservelist.Items, f)
-	if idx >= 0 && a.Reservelist.Items[idx].Spec.IPSetRef.Name != a.IPSet {
-		return nil, fmt.Errorf(fmt.Sprintf("%s already reserved for %s", fixedIP, a.Reservelist.Items[idx].Spec.IPSetRef.Name))
+
+	// validate of fixedIP is already in a reservation and its not us
+	if ipset, ok := a.reservedAddresses()[fixedIP]; ok {
+		return nil, &FixedIPReservedError{Address: fixedIP.String(), NetName: a.NetName, IPSet: ipset}
 	}
 
 	return &networkv1.IPAddress{
 		Network: networkv1.NetNameStr(a.NetName),
 		Subnet:  a.SubNet.Name,
-		Address: fixedIP,
+		Address: fixedIP.String(),
 	}, nil
 }
 
-// IterateForAssignment iterates given an IP/IPNet and a list of reserved IPs
-func (a *AssignIPDetails) iterateForAssignment() (*networkv1.IPAddress, error) {
+// AssignBlock assigns a block of count consecutive addresses, the address at
+// index i is the one of the StatefulSet pod ordinal i. The block starts at the
+// FixedIP if set, otherwise at the lowest addresses of an AllocationRange the
+// whole block fits in.
+func (a *AssignIPDetails) AssignBlock(count int) ([]*networkv1.IPAddress, error) {
+	if a.FixedIP != nil {
+		return a.fixedBlockExists(count)
+	}
+
+	return a.iterateForBlock(count)
+}
+
+// fixedBlockExists returns the block of count addresses starting at the
+// FixedIP, if none of them is excluded or reserved for another IPSet. The
+// block at the current address of an allocated block has to stay within the
+// AllocationRanges and skips no addresses ending with 0, like iterateForBlock.
+func (a *AssignIPDetails) fixedBlockExists(count int) ([]*networkv1.IPAddress, error) {
+	start, ok := netip.AddrFromSlice(a.FixedIP)
+	if !ok {
+		return nil, fmt.Errorf("FixedIP %s is not a valid IP address", a.FixedIP)
+	}
+
+	ranges, err := a.allocationRanges()
+	if err != nil {
+		return nil, err
+	}
+
+	block := []*networkv1.IPAddress{}
+	details := *a
+	for i, addr := 0, start.Unmap(); i < count; i, addr = i+1, addr.Next() {
+		if !addr.IsValid() {
+			return nil, fmt.Errorf("block of %d addresses at %s exceeds the address space", count, a.FixedIP)
+		}
+		if a.CurrentIP {
+			if _, ok := rangeOf(addr, ranges); !ok || endsWithZero(addr) {
+				return nil, fmt.Errorf("block of %s at %s can not grow to %d addresses, %s is not allocatable in subnet %s",
+					a.IPSet, a.FixedIP, count, addr, a.SubNet.Name)
+			}
+		}
+		details.FixedIP = addr.AsSlice()
+		ip, err := details.fixedIPExists()
+		if err != nil {
+			return nil, err
+		}
+		ordinal := i
+		ip.Ordinal = &ordinal
+		block = append(block, ip)
+	}
+
+	return block, nil
+}
+
+// iterateForBlock returns the first block of count consecutive free addresses
+// within one of the AllocationRanges
+func (a *AssignIPDetails) iterateForBlock(count int) ([]*networkv1.IPAddress, error) {
+	reserved := a.reservedAddresses()
+	excluded := a.excludedAddresses()
+	excludedRanges := a.excludedRanges()
+
+	ranges, err := a.allocationRanges()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range ranges {
+		start, free := netip.Addr{}, 0
+		for nextip := r[0]; nextip.IsValid() && nextip.Compare(r[1]) < 1; nextip = nextip.Next() {
+			_, isReserved := reserved[nextip]
+			_, isExcluded := rangeOf(nextip, excludedRanges)
+			if endsWithZero(nextip) || excluded[nextip] || isExcluded || isReserved {
+				free = 0
+				continue
+			}
+			if free == 0 {
+				start = nextip
+			}
+			free++
+			if free < count {
+				continue
+			}
+
+			// Found a free block
+			block := []*networkv1.IPAddress{}
+			for i, addr := 0, start; i < count; i, addr = i+1, addr.Next() {
+				ordinal := i
+				block = append(block, &networkv1.IPAddress{
+					Network: networkv1.NetNameStr(a.NetName),
+					Subnet:  a.SubNet.Name,
+					Address: addr.String(),
+					Ordinal: &ordinal,
+				})
+			}
+			return block, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no block of %d ip addresses could be created for %s in subnet %s", count, a.IPSet, a.SubNet.Name)
+}
+
+// endsWithZero returns true for IPv4 addresses ending with 0 and IPv6
+// addresses ending with 00, those don't get assigned
+func endsWithZero(addr netip.Addr) bool {
+	ipSlice := addr.AsSlice()
+	return (addr.Is4() && ipSlice[3] == 0) || (addr.Is6() && ipSlice[14] == 0 && ipSlice[15] == 0)
+}
+
+// randomOffset returns a random offset in [0, max), replaced in tests
+var randomOffset = func(max *big.Int) *big.Int {
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return big.NewInt(0)
+	}
+	return n
+}
+
+// allocationRanges returns the parsed AllocationRanges of the subnet
+func (a *AssignIPDetails) allocationRanges() ([][2]netip.Addr, error) {
+	ranges := [][2]netip.Addr{}
 	for _, allocRange := range a.SubNet.AllocationRanges {
 		firstip, err := netip.ParseAddr(allocRange.Start)
 		if err != nil {
@@ -70,42 +274,182 @@ func (a *AssignIPDetails) iterateForAssignment() (*networkv1.IPAddress, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse AllocationRange.End IP %s: %w", allocRange.End, err)
 		}
+		firstip = firstip.Unmap()
+		lastip = lastip.Unmap()
+		if firstip.BitLen() != lastip.BitLen() {
+			return nil, fmt.Errorf("AllocationRange %s - %s mixes IPv4 and IPv6", allocRange.Start, allocRange.End)
+		}
+		ranges = append(ranges, [2]netip.Addr{firstip, lastip})
+	}
 
-		// Iterate every IP address in the range
-		nextip, _ := netip.ParseAddr(firstip.String())
-		endip, _ := netip.ParseAddr(lastip.String())
-		for nextip.Compare(endip) < 1 {
-			// Skip addresses ending with 0
-			ipSlice := nextip.AsSlice()
-			if (nextip.Is4()) && (ipSlice[3] == 0) || (nextip.Is6() && (ipSlice[14] == 0) && (ipSlice[15] == 0)) {
-				nextip = nextip.Next()
-				continue
+	return ranges, nil
+}
+
+// startAddress returns the index of the range and the address to start the
+// search for a free address at, depending on the AllocationStrategy
+func (a *AssignIPDetails) startAddress(ranges [][2]netip.Addr, reserved map[netip.Addr]string) (int, netip.Addr) {
+	switch a.SubNet.AllocationStrategy {
+	case networkv1.AllocationStrategySequential:
+		// continue after the highest reserved address, in order of the ranges
+		highestIdx, highest := -1, netip.Addr{}
+		for addr := range reserved {
+			for idx, r := range ranges {
+				if addr.BitLen() != r[0].BitLen() || addr.Compare(r[0]) < 0 || addr.Compare(r[1]) > 0 {
+					continue
+				}
+				if idx > highestIdx || (idx == highestIdx && addr.Compare(highest) > 0) {
+					highestIdx, highest = idx, addr
+				}
 			}
+		}
+		if highestIdx >= 0 && highest.Next().IsValid() {
+			return highestIdx, highest.Next()
+		}
 
-			// Skip if in ExcludeAddresses list
-			if slices.Contains(a.SubNet.ExcludeAddresses, nextip.String()) {
-				nextip = nextip.Next()
-				continue
+	case networkv1.AllocationStrategyRandom:
+		// start at a random offset within all ranges
+		total := big.NewInt(0)
+		for _, r := range ranges {
+			total.Add(total, rangeSize(r))
+		}
+		if total.Sign() > 0 {
+			offset := randomOffset(total)
+			for idx, r := range ranges {
+				size := rangeSize(r)
+				if offset.Cmp(size) < 0 {
+					return idx, addrFromBig(offset.Add(offset, addrToBig(r[0])), r[0].BitLen())
+				}
+				offset.Sub(offset, size)
 			}
+		}
+	}
+
+	return 0, ranges[0][0]
+}
 
-			// validate of nextip is already in a reservation and its not us
-			f := func(c networkv1.Reservation) bool {
-				return c.Spec.Reservation[a.NetName].Address == nextip.String()
+// iterateForAssignment returns the first free address of the AllocationRanges
+// from the start address of the AllocationStrategy, wrapping around to the
+// beginning of the ranges
+func (a *AssignIPDetails) iterateForAssignment() (*networkv1.IPAddress, error) {
+	reserved := a.reservedAddresses()
+	excluded := a.excludedAddresses()
+	excludedRanges := a.excludedRanges()
+
+	ranges, err := a.allocationRanges()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ranges) > 0 {
+		startIdx, start := a.startAddress(ranges, reserved)
+		for i := 0; i <= len(ranges); i++ {
+			idx := (startIdx + i) % len(ranges)
+			firstip, lastip := ranges[idx][0], ranges[idx][1]
+			if i == 0 {
+				firstip = start
 			}
-			idx := slices.IndexFunc(a.Reservelist.Items, f)
-			if idx >= 0 && a.Reservelist.Items[idx].Spec.IPSetRef.Name != a.IPSet {
-				nextip = nextip.Next()
-				continue
+			if i == len(ranges) {
+				// back at the start range, up to the start address
+				lastip = start.Prev()
 			}
 
-			// Found a free IP
-			return &networkv1.IPAddress{
-				Network: networkv1.NetNameStr(a.NetName),
-				Subnet:  a.SubNet.Name,
-				Address: nextip.String(),
-			}, nil
+			if nextip := firstFree(firstip, lastip, reserved, excluded, excludedRanges); nextip.IsValid() {
+				// Found a free IP
+				return &networkv1.IPAddress{
+					Network: networkv1.NetNameStr(a.NetName),
+					Subnet:  a.SubNet.Name,
+					Address: nextip.String(),
+				}, nil
+			}
 		}
 	}
 
 	return nil, fmt.Errorf(fmt.Sprintf("no ip address could be created for %s in subnet %s", a.IPSet, a.SubNet.Name))
 }
+
+// firstFree returns the first free address between firstip and lastip, the
+// zero Addr if there is none
+func firstFree(
+	firstip netip.Addr,
+	lastip netip.Addr,
+	reserved map[netip.Addr]string,
+	excluded map[netip.Addr]bool,
+	excludedRanges [][2]netip.Addr,
+) netip.Addr {
+	// Iterate every IP address in the range, Next() returns the zero
+	// Addr after the last address of the IP family
+	for nextip := firstip; nextip.IsValid() && nextip.Compare(lastip) < 1; nextip = nextip.Next() {
+		// Skip addresses ending with 0
+		if endsWithZero(nextip) {
+			continue
+		}
+
+		// Skip if in ExcludeAddresses list
+		if excluded[nextip] {
+			continue
+		}
+
+		// Skip to the end of an ExcludeRanges entry, the loop continues
+		// with the address after it
+		if exclRange, ok := rangeOf(nextip, excludedRanges); ok {
+			nextip = exclRange[1]
+			continue
+		}
+
+		// validate of nextip is already in a reservation and its not us
+		if _, ok := reserved[nextip]; ok {
+			continue
+		}
+
+		return nextip
+	}
+
+	return netip.Addr{}
+}
+
+// rangeSize returns the number of addresses in the range
+func rangeSize(r [2]netip.Addr) *big.Int {
+	size := new(big.Int).Sub(addrToBig(r[1]), addrToBig(r[0]))
+	if size.Sign() < 0 {
+		return big.NewInt(0)
+	}
+	return size.Add(size, big.NewInt(1))
+}
+
+func addrToBig(addr netip.Addr) *big.Int {
+	return new(big.Int).SetBytes(addr.AsSlice())
+}
+
+func addrFromBig(i *big.Int, bitLen int) netip.Addr {
+	addr, _ := netip.AddrFromSlice(i.FillBytes(make([]byte, bitLen/8)))
+	return addr
+}
+
+// NormalizeAddress returns the canonical textual form of the address, e.g.
+// fd00::10 for fd00:0::0010, or the address as is if it can not be parsed
+func NormalizeAddress(addr string) string {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return addr
+	}
+	return ip.Unmap().String()
+}
+
+// ReservationKey returns the key of the address of a network in the Reservation
+// of an IPSet, the network name. If the IPSet requests addresses of both IP
+// families from the network, the key of the IPv6 address gets the
+// DualStackIPv6Suffix appended.
+func ReservationKey(netName networkv1.NetNameStr, addr string, dualStack bool) string {
+	ip, err := netip.ParseAddr(addr)
+	if dualStack && err == nil && ip.Unmap().Is6() {
+		return string(netName) + DualStackIPv6Suffix
+	}
+	return string(netName)
+}
+
+// OrdinalReservationKey returns the key of the address of a StatefulSet pod
+// ordinal in the Reservation of an IPSet with Ordinals, the ReservationKey with
+// the ordinal appended, e.g. <net>.0 or <net>.ipv6.0
+func OrdinalReservationKey(netName networkv1.NetNameStr, addr string, dualStack bool, ordinal int) string {
+	return fmt.Sprintf("%s.%d", ReservationKey(netName, addr, dualStack), ordinal)
+}
@@ -0,0 +1,533 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipam
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"testing"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+)
+
+func reservationList(ipset string, addrs ...networkv1.IPAddress) *networkv1.ReservationList {
+	res := networkv1.Reservation{}
+	res.Spec.IPSetRef.Name = ipset
+	res.Spec.Reservation = map[string]networkv1.IPAddress{}
+	for _, addr := range addrs {
+		res.Spec.Reservation[ReservationKey(addr.Network, addr.Address, true)] = addr
+	}
+
+	return &networkv1.ReservationList{Items: []networkv1.Reservation{res}}
+}
+
+func TestAssignIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		details AssignIPDetails
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "IPv4 first free address",
+			details: AssignIPDetails{
+				IPSet:   "foo",
+				NetName: "net1",
+				SubNet: &networkv1.Subnet{
+					Name:             "subnet1",
+					AllocationRanges: []networkv1.AllocationRange{{Start: "172.17.0.10", End: "172.17.0.20"}},
+					ExcludeAddresses: []string{"172.17.0.10"},
+				},
+				Reservelist: reservationList("bar", networkv1.IPAddress{Network: "net1", Address: "172.17.0.11"}),
+			},
+			want: "172.17.0.12",
+		},
+		{
+			name: "IPv6 abbreviated exclude and reserved addresses",
+			details: AssignIPDetails{
+				IPSet:   "foo",
+				NetName: "net1",
+				SubNet: &networkv1.Subnet{
+					Name:             "subnet1",
+					AllocationRanges: []networkv1.AllocationRange{{Start: "fd00:fd00:fd00:2000::10", End: "fd00:fd00:fd00:2000::20"}},
+					ExcludeAddresses: []string{"fd00:fd00:fd00:2000:0:0:0:0010"},
+				},
+				Reservelist: reservationList("bar", networkv1.IPAddress{Network: "NET1", Address: "FD00:FD00:FD00:2000::0011"}),
+			},
+			want: "fd00:fd00:fd00:2000::12",
+		},
+		{
+			name: "IPv6 range crossing the upper 64 bits",
+			details: AssignIPDetails{
+				IPSet:   "foo",
+				NetName: "net1",
+				SubNet: &networkv1.Subnet{
+					Name:             "subnet1",
+					AllocationRanges: []networkv1.AllocationRange{{Start: "fd00:0:0:1:ffff:ffff:ffff:ffff", End: "fd00:0:0:2::5"}},
+				},
+				Reservelist: reservationList("bar", networkv1.IPAddress{Network: "net1", Address: "fd00:0:0:1:ffff:ffff:ffff:ffff"}),
+			},
+			want: "fd00:0:0:2::1",
+		},
+		{
+			name: "IPv6 range exhausted",
+			details: AssignIPDetails{
+				IPSet:   "foo",
+				NetName: "net1",
+				SubNet: &networkv1.Subnet{
+					Name:             "subnet1",
+					AllocationRanges: []networkv1.AllocationRange{{Start: "fd00::1", End: "fd00::2"}},
+					ExcludeAddresses: []string{"fd00::1"},
+				},
+				Reservelist: reservationList("bar", networkv1.IPAddress{Network: "net1", Address: "fd00::2"}),
+			},
+			wantErr: true,
+		},
+		{
+			name: "range mixing IP families",
+			details: AssignIPDetails{
+				IPSet:   "foo",
+				NetName: "net1",
+				SubNet: &networkv1.Subnet{
+					Name:             "subnet1",
+					AllocationRanges: []networkv1.AllocationRange{{Start: "172.17.0.10", End: "fd00::20"}},
+				},
+				Reservelist: &networkv1.ReservationList{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "IPv4 skip ExcludeRanges",
+			details: AssignIPDetails{
+				IPSet:   "foo",
+				NetName: "net1",
+				SubNet: &networkv1.Subnet{
+					Name:             "subnet1",
+					AllocationRanges: []networkv1.AllocationRange{{Start: "172.17.0.10", End: "172.17.0.20"}},
+					ExcludeRanges:    []networkv1.AllocationRange{{Start: "172.17.0.5", End: "172.17.0.12"}, {Start: "172.17.0.13", End: "172.17.0.14"}},
+				},
+				Reservelist: &networkv1.ReservationList{},
+			},
+			want: "172.17.0.15",
+		},
+		{
+			name: "IPv6 skip large ExcludeRanges",
+			details: AssignIPDetails{
+				IPSet:   "foo",
+				NetName: "net1",
+				SubNet: &networkv1.Subnet{
+					Name:             "subnet1",
+					AllocationRanges: []networkv1.AllocationRange{{Start: "fd00::1", End: "fd00::1:0:0:10"}},
+					ExcludeRanges:    []networkv1.AllocationRange{{Start: "fd00::", End: "fd00::ffff:ffff:ffff"}},
+				},
+				Reservelist: &networkv1.ReservationList{},
+			},
+			want: "fd00::1:0:0:1",
+		},
+		{
+			name: "FixedIP in ExcludeRanges",
+			details: AssignIPDetails{
+				IPSet:   "foo",
+				NetName: "net1",
+				SubNet: &networkv1.Subnet{
+					Name:          "subnet1",
+					ExcludeRanges: []networkv1.AllocationRange{{Start: "172.17.0.1", End: "172.17.0.9"}},
+				},
+				Reservelist: &networkv1.ReservationList{},
+				FixedIP:     net.ParseIP("172.17.0.5"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "IPv6 FixedIP returned in canonical form",
+			details: AssignIPDetails{
+				IPSet:       "foo",
+				NetName:     "net1",
+				SubNet:      &networkv1.Subnet{Name: "subnet1"},
+				Reservelist: &networkv1.ReservationList{},
+				FixedIP:     net.ParseIP("fd00:0000:0000:0000::0010"),
+			},
+			want: "fd00::10",
+		},
+		{
+			name: "IPv6 FixedIP reserved by another IPSet",
+			details: AssignIPDetails{
+				IPSet:       "foo",
+				NetName:     "net1",
+				SubNet:      &networkv1.Subnet{Name: "subnet1"},
+				Reservelist: reservationList("bar", networkv1.IPAddress{Network: "net1", Address: "fd00:0::0010"}),
+				FixedIP:     net.ParseIP("fd00::10"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "IPv4 FixedIP reserved by the same IPSet",
+			details: AssignIPDetails{
+				IPSet:       "foo",
+				NetName:     "net1",
+				SubNet:      &networkv1.Subnet{Name: "subnet1"},
+				Reservelist: reservationList("foo", networkv1.IPAddress{Network: "net1", Address: "172.17.0.10"}),
+				FixedIP:     net.ParseIP("172.17.0.10"),
+			},
+			want: "172.17.0.10",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.details.AssignIP()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("AssignIP() = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AssignIP() unexpected error: %v", err)
+			}
+			if got.Address != tt.want {
+				t.Errorf("AssignIP() = %s, want %s", got.Address, tt.want)
+			}
+		})
+	}
+}
+
+func TestReservationKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		addr      string
+		dualStack bool
+		want      string
+	}{
+		{name: "IPv4", addr: "172.17.0.10", want: "net1"},
+		{name: "IPv6", addr: "fd00::10", want: "net1"},
+		{name: "dual-stack IPv4", addr: "172.17.0.10", dualStack: true, want: "net1"},
+		{name: "dual-stack IPv6", addr: "fd00::10", dualStack: true, want: "net1" + DualStackIPv6Suffix},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReservationKey("net1", tt.addr, tt.dualStack); got != tt.want {
+				t.Errorf("ReservationKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssignIPFixedIPReserved(t *testing.T) {
+	details := AssignIPDetails{
+		IPSet:       "foo",
+		NetName:     "net1",
+		SubNet:      &networkv1.Subnet{Name: "subnet1"},
+		Reservelist: reservationList("bar", networkv1.IPAddress{Network: "net1", Address: "172.17.0.10"}),
+		FixedIP:     net.ParseIP("172.17.0.10"),
+	}
+
+	_, err := details.AssignIP()
+	reservedErr := &FixedIPReservedError{}
+	if !errors.As(err, &reservedErr) {
+		t.Fatalf("AssignIP() error = %v, want FixedIPReservedError", err)
+	}
+	if reservedErr.Address != "172.17.0.10" || reservedErr.NetName != "net1" || reservedErr.IPSet != "bar" {
+		t.Errorf("AssignIP() error = %+v", reservedErr)
+	}
+}
+
+func TestNormalizeAddress(t *testing.T) {
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{addr: "172.17.0.10", want: "172.17.0.10"},
+		{addr: "fd00:0::0010", want: "fd00::10"},
+		{addr: "FD00:FD00:FD00:2000:0:0:0:10", want: "fd00:fd00:fd00:2000::10"},
+		{addr: "::ffff:172.17.0.10", want: "172.17.0.10"},
+		{addr: "foo", want: "foo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := NormalizeAddress(tt.addr); got != tt.want {
+				t.Errorf("NormalizeAddress() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssignIPAllocationStrategy(t *testing.T) {
+	ranges := []networkv1.AllocationRange{
+		{Start: "172.17.0.10", End: "172.17.0.20"},
+		{Start: "172.17.0.100", End: "172.17.0.110"},
+	}
+
+	tests := []struct {
+		name     string
+		strategy networkv1.AllocationStrategy
+		ranges   []networkv1.AllocationRange
+		reserved []string
+		offset   int64
+		want     string
+	}{
+		{
+			name:     "LowestFree reuses released addresses",
+			strategy: networkv1.AllocationStrategyLowestFree,
+			ranges:   ranges,
+			reserved: []string{"172.17.0.10", "172.17.0.12"},
+			want:     "172.17.0.11",
+		},
+		{
+			name:     "default is LowestFree",
+			ranges:   ranges,
+			reserved: []string{"172.17.0.10", "172.17.0.12"},
+			want:     "172.17.0.11",
+		},
+		{
+			name:     "Sequential continues after the highest reserved address",
+			strategy: networkv1.AllocationStrategySequential,
+			ranges:   ranges,
+			reserved: []string{"172.17.0.10", "172.17.0.12"},
+			want:     "172.17.0.13",
+		},
+		{
+			name:     "Sequential continues in the next range",
+			strategy: networkv1.AllocationStrategySequential,
+			ranges:   ranges,
+			reserved: []string{"172.17.0.11", "172.17.0.20"},
+			want:     "172.17.0.100",
+		},
+		{
+			name:     "Sequential wraps around",
+			strategy: networkv1.AllocationStrategySequential,
+			ranges:   ranges,
+			reserved: []string{"172.17.0.11", "172.17.0.110"},
+			want:     "172.17.0.10",
+		},
+		{
+			name:     "Sequential without reservations",
+			strategy: networkv1.AllocationStrategySequential,
+			ranges:   ranges,
+			want:     "172.17.0.10",
+		},
+		{
+			name:     "Random offset in the second range",
+			strategy: networkv1.AllocationStrategyRandom,
+			ranges:   ranges,
+			offset:   13,
+			want:     "172.17.0.102",
+		},
+		{
+			name:     "Random offset reserved, next free address",
+			strategy: networkv1.AllocationStrategyRandom,
+			ranges:   ranges,
+			reserved: []string{"172.17.0.102", "172.17.0.103"},
+			offset:   13,
+			want:     "172.17.0.104",
+		},
+		{
+			name:     "Random wraps around",
+			strategy: networkv1.AllocationStrategyRandom,
+			ranges:   ranges,
+			reserved: []string{"172.17.0.110"},
+			offset:   21,
+			want:     "172.17.0.10",
+		},
+		{
+			name:     "Random in a large IPv6 range",
+			strategy: networkv1.AllocationStrategyRandom,
+			ranges:   []networkv1.AllocationRange{{Start: "fd00::1", End: "fd00::ffff:ffff:ffff:ffff"}},
+			offset:   0x10000,
+			want:     "fd00::1:1",
+		},
+	}
+
+	origRandomOffset := randomOffset
+	defer func() { randomOffset = origRandomOffset }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			randomOffset = func(max *big.Int) *big.Int {
+				return big.NewInt(tt.offset)
+			}
+
+			addrs := []networkv1.IPAddress{}
+			for _, addr := range tt.reserved {
+				addrs = append(addrs, networkv1.IPAddress{Network: "net1", Address: addr})
+			}
+			details := AssignIPDetails{
+				IPSet:   "foo",
+				NetName: "net1",
+				SubNet: &networkv1.Subnet{
+					Name:               "subnet1",
+					AllocationRanges:   tt.ranges,
+					AllocationStrategy: tt.strategy,
+				},
+				Reservelist: &networkv1.ReservationList{},
+			}
+			// one Reservation per address, as the keys are the network names
+			for idx, addr := range addrs {
+				details.Reservelist.Items = append(details.Reservelist.Items,
+					reservationList(fmt.Sprintf("bar-%d", idx), addr).Items...)
+			}
+
+			got, err := details.AssignIP()
+			if err != nil {
+				t.Fatalf("AssignIP() unexpected error: %v", err)
+			}
+			if got.Address != tt.want {
+				t.Errorf("AssignIP() = %s, want %s", got.Address, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssignBlock(t *testing.T) {
+	ranges := []networkv1.AllocationRange{
+		{Start: "172.17.0.10", End: "172.17.0.20"},
+		{Start: "172.17.0.100", End: "172.17.0.110"},
+	}
+
+	tests := []struct {
+		name     string
+		ranges   []networkv1.AllocationRange
+		reserved *networkv1.ReservationList
+		fixedIP  string
+		current  bool
+		count    int
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name:     "lowest free block",
+			ranges:   ranges,
+			reserved: reservationList("bar", networkv1.IPAddress{Network: "net1", Address: "172.17.0.12"}),
+			count:    3,
+			want:     []string{"172.17.0.13", "172.17.0.14", "172.17.0.15"},
+		},
+		{
+			name:     "block in the next range it fits in",
+			ranges:   ranges,
+			reserved: reservationList("bar", networkv1.IPAddress{Network: "net1", Address: "172.17.0.15"}),
+			count:    6,
+			want:     []string{"172.17.0.100", "172.17.0.101", "172.17.0.102", "172.17.0.103", "172.17.0.104", "172.17.0.105"},
+		},
+		{
+			name:     "block does not contain an address ending with 0",
+			ranges:   []networkv1.AllocationRange{{Start: "172.17.0.253", End: "172.17.1.10"}},
+			reserved: &networkv1.ReservationList{},
+			count:    4,
+			want:     []string{"172.17.1.1", "172.17.1.2", "172.17.1.3", "172.17.1.4"},
+		},
+		{
+			name:     "own addresses are kept",
+			ranges:   ranges,
+			reserved: reservationList("foo", networkv1.IPAddress{Network: "net1", Address: "172.17.0.10"}),
+			count:    2,
+			want:     []string{"172.17.0.10", "172.17.0.11"},
+		},
+		{
+			name:     "block at the FixedIP",
+			ranges:   ranges,
+			reserved: &networkv1.ReservationList{},
+			fixedIP:  "172.17.0.18",
+			count:    4,
+			want:     []string{"172.17.0.18", "172.17.0.19", "172.17.0.20", "172.17.0.21"},
+		},
+		{
+			name:     "block at the FixedIP with an address of another IPSet",
+			ranges:   ranges,
+			reserved: reservationList("bar", networkv1.IPAddress{Network: "net1", Address: "172.17.0.20"}),
+			fixedIP:  "172.17.0.18",
+			count:    4,
+			wantErr:  true,
+		},
+		{
+			name:     "no free block",
+			ranges:   ranges,
+			reserved: &networkv1.ReservationList{},
+			count:    12,
+			wantErr:  true,
+		},
+		{
+			name:     "current block grows within the range",
+			ranges:   ranges,
+			reserved: &networkv1.ReservationList{},
+			fixedIP:  "172.17.0.16",
+			current:  true,
+			count:    5,
+			want:     []string{"172.17.0.16", "172.17.0.17", "172.17.0.18", "172.17.0.19", "172.17.0.20"},
+		},
+		{
+			name:     "current block can not grow past the end of the range",
+			ranges:   ranges,
+			reserved: &networkv1.ReservationList{},
+			fixedIP:  "172.17.0.18",
+			current:  true,
+			count:    4,
+			wantErr:  true,
+		},
+		{
+			name:     "current block can not grow to an address ending with 0",
+			ranges:   []networkv1.AllocationRange{{Start: "172.17.0.250", End: "172.17.1.10"}},
+			reserved: &networkv1.ReservationList{},
+			fixedIP:  "172.17.0.254",
+			current:  true,
+			count:    3,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details := AssignIPDetails{
+				IPSet:   "foo",
+				NetName: "net1",
+				SubNet: &networkv1.Subnet{
+					Name:             "subnet1",
+					AllocationRanges: tt.ranges,
+				},
+				Reservelist: tt.reserved,
+			}
+			if tt.fixedIP != "" {
+				details.FixedIP = net.ParseIP(tt.fixedIP)
+				details.CurrentIP = tt.current
+			}
+
+			got, err := details.AssignBlock(tt.count)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AssignBlock() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("AssignBlock() = %d addresses, want %d", len(got), len(tt.want))
+			}
+			for idx, ip := range got {
+				if ip.Address != tt.want[idx] || ip.Ordinal == nil || *ip.Ordinal != idx {
+					t.Errorf("AssignBlock()[%d] = %+v, want %s with ordinal %d", idx, ip, tt.want[idx], idx)
+				}
+			}
+		})
+	}
+}
+
+func TestOrdinalReservationKey(t *testing.T) {
+	if got := OrdinalReservationKey("net1", "172.17.0.10", true, 2); got != "net1.2" {
+		t.Errorf("OrdinalReservationKey() = %q, want %q", got, "net1.2")
+	}
+	if got := OrdinalReservationKey("net1", "fd00::10", true, 2); got != "net1"+DualStackIPv6Suffix+".2" {
+		t.Errorf("OrdinalReservationKey() = %q, want %q", got, "net1"+DualStackIPv6Suffix+".2")
+	}
+}
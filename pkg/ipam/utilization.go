@@ -0,0 +1,151 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipam
+
+import (
+	"math/big"
+	"net/netip"
+	"sort"
+	"strings"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+)
+
+// SubnetUtilization returns the number of addresses in the AllocationRanges of
+// the subnet which get assigned, so are not excluded and don't end with 0, and
+// how many of them are reserved
+func SubnetUtilization(
+	netName networkv1.NetNameStr,
+	subnet *networkv1.Subnet,
+	reservations *networkv1.ReservationList,
+) (total *big.Int, reserved *big.Int, err error) {
+	a := &AssignIPDetails{
+		NetName: string(netName),
+		SubNet:  subnet,
+	}
+
+	ranges, err := a.allocationRanges()
+	if err != nil {
+		return nil, nil, err
+	}
+	excluded := a.excludedAddresses()
+	excludedRanges := a.excludedRanges()
+
+	inPool := func(addr netip.Addr) bool {
+		if _, ok := rangeOf(addr, ranges); !ok {
+			return false
+		}
+		if _, ok := rangeOf(addr, excludedRanges); ok {
+			return false
+		}
+		return !endsWithZero(addr)
+	}
+
+	total = big.NewInt(0)
+	for _, r := range ranges {
+		total.Add(total, assignableSize(r))
+		total.Sub(total, excludedSize(r, excludedRanges))
+	}
+	for addr := range excluded {
+		if inPool(addr) {
+			total.Sub(total, big.NewInt(1))
+		}
+	}
+
+	reservedAddrs := map[netip.Addr]bool{}
+	for _, res := range reservations.Items {
+		for _, ip := range res.Spec.Reservation {
+			if !strings.EqualFold(string(ip.Network), string(netName)) ||
+				!strings.EqualFold(string(ip.Subnet), string(subnet.Name)) {
+				continue
+			}
+			addr, err := netip.ParseAddr(ip.Address)
+			if err != nil {
+				continue
+			}
+			addr = addr.Unmap()
+			if inPool(addr) && !excluded[addr] {
+				reservedAddrs[addr] = true
+			}
+		}
+	}
+
+	return total, big.NewInt(int64(len(reservedAddrs))), nil
+}
+
+// assignableSize returns the number of addresses of the range not ending with
+// 0, those get skipped on assignment
+func assignableSize(r [2]netip.Addr) *big.Int {
+	size := rangeSize(r)
+	if size.Sign() == 0 {
+		return size
+	}
+
+	// count the multiples of 256 for IPv4, 65536 for IPv6, within the range
+	step := big.NewInt(1 << 8)
+	if r[0].Is6() {
+		step = big.NewInt(1 << 16)
+	}
+	first, last := addrToBig(r[0]), addrToBig(r[1])
+	zeros := new(big.Int).Sub(new(big.Int).Div(last, step), new(big.Int).Div(first, step))
+	if endsWithZero(r[0]) {
+		zeros.Add(zeros, big.NewInt(1))
+	}
+
+	return size.Sub(size, zeros)
+}
+
+// excludedSize returns the number of assignable addresses of the range within
+// the excluded ranges, which might overlap
+func excludedSize(r [2]netip.Addr, excludedRanges [][2]netip.Addr) *big.Int {
+	// the parts of the excluded ranges within the range, sorted by start
+	parts := [][2]netip.Addr{}
+	for _, excl := range excludedRanges {
+		if excl[0].BitLen() != r[0].BitLen() || excl[1].Compare(r[0]) < 0 || excl[0].Compare(r[1]) > 0 {
+			continue
+		}
+		part := excl
+		if part[0].Compare(r[0]) < 0 {
+			part[0] = r[0]
+		}
+		if part[1].Compare(r[1]) > 0 {
+			part[1] = r[1]
+		}
+		parts = append(parts, part)
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i][0].Compare(parts[j][0]) < 0
+	})
+
+	size := big.NewInt(0)
+	var current [2]netip.Addr
+	for idx, part := range parts {
+		switch {
+		case idx == 0:
+			current = part
+		case part[0].Compare(current[1]) <= 0 || part[0] == current[1].Next():
+			// overlapping or adjacent, merge them
+			if part[1].Compare(current[1]) > 0 {
+				current[1] = part[1]
+			}
+		default:
+			size.Add(size, assignableSize(current))
+			current = part
+		}
+	}
+	if len(parts) > 0 {
+		size.Add(size, assignableSize(current))
+	}
+
+	return size
+}
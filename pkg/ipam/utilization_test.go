@@ -0,0 +1,115 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipam
+
+import (
+	"testing"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+)
+
+func TestSubnetUtilization(t *testing.T) {
+	tests := []struct {
+		name         string
+		subnet       networkv1.Subnet
+		reserved     []networkv1.IPAddress
+		wantTotal    string
+		wantReserved string
+	}{
+		{
+			name: "IPv4 ranges",
+			subnet: networkv1.Subnet{
+				Name: "subnet1",
+				AllocationRanges: []networkv1.AllocationRange{
+					{Start: "172.17.0.10", End: "172.17.0.19"},
+					{Start: "172.17.0.100", End: "172.17.0.109"},
+				},
+			},
+			reserved: []networkv1.IPAddress{
+				{Network: "net1", Subnet: "subnet1", Address: "172.17.0.10"},
+				{Network: "net1", Subnet: "subnet1", Address: "172.17.0.100"},
+				// outside the AllocationRanges
+				{Network: "net1", Subnet: "subnet1", Address: "172.17.0.200"},
+				// other subnet
+				{Network: "net1", Subnet: "subnet2", Address: "172.17.0.11"},
+			},
+			wantTotal:    "20",
+			wantReserved: "2",
+		},
+		{
+			name: "IPv4 with overlapping excludes",
+			subnet: networkv1.Subnet{
+				Name:             "subnet1",
+				AllocationRanges: []networkv1.AllocationRange{{Start: "172.17.0.10", End: "172.17.0.29"}},
+				ExcludeRanges: []networkv1.AllocationRange{
+					{Start: "172.17.0.5", End: "172.17.0.12"},
+					{Start: "172.17.0.11", End: "172.17.0.14"},
+					{Start: "172.17.0.15", End: "172.17.0.15"},
+				},
+				ExcludeAddresses: []string{"172.17.0.13", "172.17.0.20", "172.17.0.50"},
+			},
+			reserved: []networkv1.IPAddress{
+				{Network: "net1", Subnet: "subnet1", Address: "172.17.0.12"},
+				{Network: "net1", Subnet: "subnet1", Address: "172.17.0.21"},
+			},
+			wantTotal:    "13",
+			wantReserved: "1",
+		},
+		{
+			name: "IPv6 large range",
+			subnet: networkv1.Subnet{
+				Name:             "subnet1",
+				AllocationRanges: []networkv1.AllocationRange{{Start: "fd00::", End: "fd00::ffff:ffff:ffff:ffff"}},
+			},
+			reserved: []networkv1.IPAddress{
+				{Network: "NET1", Subnet: "subnet1", Address: "fd00:0::0010"},
+			},
+			// without the 2^48 addresses ending with 00
+			wantTotal:    "18446462598732840960",
+			wantReserved: "1",
+		},
+		{
+			name: "IPv4 addresses ending with 0",
+			subnet: networkv1.Subnet{
+				Name:             "subnet1",
+				AllocationRanges: []networkv1.AllocationRange{{Start: "172.17.0.250", End: "172.17.2.5"}},
+				ExcludeRanges:    []networkv1.AllocationRange{{Start: "172.17.1.0", End: "172.17.1.10"}},
+				ExcludeAddresses: []string{"172.17.2.0"},
+			},
+			reserved: []networkv1.IPAddress{
+				// a FixedIP ending with 0
+				{Network: "net1", Subnet: "subnet1", Address: "172.17.2.0"},
+				{Network: "net1", Subnet: "subnet1", Address: "172.17.2.1"},
+			},
+			wantTotal:    "256",
+			wantReserved: "1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reservations := &networkv1.ReservationList{}
+			for _, addr := range tt.reserved {
+				reservations.Items = append(reservations.Items, reservationList(addr.Address, addr).Items...)
+			}
+
+			total, reserved, err := SubnetUtilization("net1", &tt.subnet, reservations)
+			if err != nil {
+				t.Fatalf("SubnetUtilization() unexpected error: %v", err)
+			}
+			if total.String() != tt.wantTotal || reserved.String() != tt.wantReserved {
+				t.Errorf("SubnetUtilization() = %s/%s, want %s/%s", total, reserved, tt.wantTotal, tt.wantReserved)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipam
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+)
+
+// InvalidReservations returns the reserved addresses which are not valid for
+// the subnets of the NetConfig anymore, e.g. after a subnet got changed with the
+// AnnotationAllowSubnetChanges annotation. An address is invalid if its subnet
+// does not exist, it is not in the CIDR of the subnet or it got excluded. The
+// entries are sorted and in the format <address> (<network>/<subnet>, IPSet <name>).
+func InvalidReservations(
+	netcfg *networkv1.NetConfig,
+	reservations *networkv1.ReservationList,
+) []string {
+	invalid := []string{}
+
+	for _, res := range reservations.Items {
+		for _, ip := range res.Spec.Reservation {
+			if validReservation(netcfg, ip) {
+				continue
+			}
+			invalid = append(invalid, fmt.Sprintf("%s (%s/%s, IPSet %s)",
+				ip.Address, ip.Network, ip.Subnet, res.Spec.IPSetRef.Name))
+		}
+	}
+	sort.Strings(invalid)
+
+	return invalid
+}
+
+// validReservation returns if the reserved address is valid for its subnet
+func validReservation(netcfg *networkv1.NetConfig, ip networkv1.IPAddress) bool {
+	_, subnet, err := netcfg.GetNetAndSubnet(ip.Network, ip.Subnet)
+	if err != nil {
+		return false
+	}
+
+	addr, err := netip.ParseAddr(ip.Address)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	prefix, err := netip.ParsePrefix(subnet.Cidr)
+	if err != nil || !prefix.Contains(addr) {
+		return false
+	}
+
+	a := &AssignIPDetails{
+		NetName: string(ip.Network),
+		SubNet:  subnet,
+	}
+	if a.excludedAddresses()[addr] {
+		return false
+	}
+	if _, ok := rangeOf(addr, a.excludedRanges()); ok {
+		return false
+	}
+
+	return true
+}
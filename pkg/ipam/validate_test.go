@@ -0,0 +1,101 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipam
+
+import (
+	"reflect"
+	"testing"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+)
+
+func TestInvalidReservations(t *testing.T) {
+	netcfg := &networkv1.NetConfig{
+		Spec: networkv1.NetConfigSpec{
+			Networks: []networkv1.Network{
+				{
+					Name: "net1",
+					Subnets: []networkv1.Subnet{
+						{
+							Name:             "subnet1",
+							Cidr:             "172.17.0.0/24",
+							AllocationRanges: []networkv1.AllocationRange{{Start: "172.17.0.10", End: "172.17.0.50"}},
+							ExcludeAddresses: []string{"172.17.0.15"},
+							ExcludeRanges:    []networkv1.AllocationRange{{Start: "172.17.0.40", End: "172.17.0.45"}},
+						},
+						{
+							Name:             "subnet2",
+							Cidr:             "fd00::/64",
+							AllocationRanges: []networkv1.AllocationRange{{Start: "fd00::10", End: "fd00::50"}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		reserved []networkv1.IPAddress
+		want     []string
+	}{
+		{
+			name: "valid addresses, also outside of the allocation ranges",
+			reserved: []networkv1.IPAddress{
+				{Network: "net1", Subnet: "subnet1", Address: "172.17.0.10"},
+				{Network: "NET1", Subnet: "subnet1", Address: "172.17.0.200"},
+				{Network: "net1", Subnet: "subnet2", Address: "fd00:0::0010"},
+			},
+			want: []string{},
+		},
+		{
+			name: "addresses outside of the cidr or excluded",
+			reserved: []networkv1.IPAddress{
+				{Network: "net1", Subnet: "subnet1", Address: "172.17.1.10"},
+				{Network: "net1", Subnet: "subnet1", Address: "172.17.0.15"},
+				{Network: "net1", Subnet: "subnet1", Address: "172.17.0.42"},
+				{Network: "net1", Subnet: "subnet2", Address: "fd01::10"},
+			},
+			want: []string{
+				"172.17.0.15 (net1/subnet1, IPSet foo)",
+				"172.17.0.42 (net1/subnet1, IPSet foo)",
+				"172.17.1.10 (net1/subnet1, IPSet foo)",
+				"fd01::10 (net1/subnet2, IPSet foo)",
+			},
+		},
+		{
+			name: "addresses of removed networks and subnets",
+			reserved: []networkv1.IPAddress{
+				{Network: "net1", Subnet: "subnet3", Address: "172.17.0.10"},
+				{Network: "net2", Subnet: "subnet1", Address: "172.18.0.10"},
+			},
+			want: []string{
+				"172.17.0.10 (net1/subnet3, IPSet foo)",
+				"172.18.0.10 (net2/subnet1, IPSet foo)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reservations := &networkv1.ReservationList{}
+			for _, addr := range tt.reserved {
+				reservations.Items = append(reservations.Items, reservationList("foo", addr).Items...)
+			}
+
+			if got := InvalidReservations(netcfg, reservations); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InvalidReservations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
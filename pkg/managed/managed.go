@@ -0,0 +1,65 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package managed implements the CR annotations to opt out of managing
+// particular owned resources, e.g. redis.openstack.org/manage-service: "false",
+// in favor of externally managed equivalents, as well as the annotation to
+// pause the reconciliation of a CR, e.g. memcached.openstack.org/paused: "true".
+package managed
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Service - the Service(s) exposing the pods
+	Service = "service"
+	// NetworkPolicy - the NetworkPolicy limiting the access to the pods
+	NetworkPolicy = "networkpolicy"
+	// PodDisruptionBudget - the PodDisruptionBudget of the pods
+	PodDisruptionBudget = "pdb"
+)
+
+// AnnotationKey - returns the annotation to opt out of managing resource,
+// <group>/manage-<resource>
+func AnnotationKey(group string, resource string) string {
+	return group + "/manage-" + resource
+}
+
+// IsManaged - returns false if obj opts out of managing resource by setting
+// its annotation to "false"
+func IsManaged(obj metav1.Object, group string, resource string) bool {
+	value, ok := obj.GetAnnotations()[AnnotationKey(group, resource)]
+	return !ok || !strings.EqualFold(value, "false")
+}
+
+// SkippedResource - returns the entry listed in the status for a skipped
+// resource, <kind>/<name>
+func SkippedResource(kind string, name string) string {
+	return kind + "/" + name
+}
+
+// PausedAnnotationKey - returns the annotation to pause the reconciliation, <group>/paused
+func PausedAnnotationKey(group string) string {
+	return group + "/paused"
+}
+
+// IsPaused - returns true if the reconciliation of obj is paused by setting
+// its paused annotation to "true"
+func IsPaused(obj metav1.Object, group string) bool {
+	return strings.EqualFold(obj.GetAnnotations()[PausedAnnotationKey(group)], "true")
+}
@@ -0,0 +1,52 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package managed
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsManaged(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &metav1.ObjectMeta{}
+	g.Expect(IsManaged(obj, "redis.openstack.org", Service)).To(BeTrue())
+
+	obj.Annotations = map[string]string{"redis.openstack.org/manage-service": "False"}
+	g.Expect(IsManaged(obj, "redis.openstack.org", Service)).To(BeFalse())
+	g.Expect(IsManaged(obj, "redis.openstack.org", NetworkPolicy)).To(BeTrue())
+	g.Expect(IsManaged(obj, "memcached.openstack.org", Service)).To(BeTrue())
+
+	obj.Annotations["redis.openstack.org/manage-service"] = "true"
+	g.Expect(IsManaged(obj, "redis.openstack.org", Service)).To(BeTrue())
+}
+
+func TestIsPaused(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &metav1.ObjectMeta{}
+	g.Expect(IsPaused(obj, "memcached.openstack.org")).To(BeFalse())
+
+	obj.Annotations = map[string]string{"memcached.openstack.org/paused": "True"}
+	g.Expect(IsPaused(obj, "memcached.openstack.org")).To(BeTrue())
+	g.Expect(IsPaused(obj, "redis.openstack.org")).To(BeFalse())
+
+	obj.Annotations["memcached.openstack.org/paused"] = "false"
+	g.Expect(IsPaused(obj, "memcached.openstack.org")).To(BeFalse())
+}
@@ -0,0 +1,18 @@
+package memcached
+
+import (
+	memcachedv1 "github.com/openstack-k8s-operators/infra-operator/apis/memcached/v1beta1"
+)
+
+// CacheSizeMB returns the cache size in megabytes passed to memcached -m. If
+// not set explicitly it is derived from the memory limit of the container,
+// instead of falling back to the memcached default of 64MB.
+func CacheSizeMB(m *memcachedv1.Memcached) int64 {
+	return m.Spec.GetCacheSizeMB()
+}
+
+// CacheSizeMemoryLimitPercent returns the share of the memory limit used as
+// cache size if the cache size is not set explicitly
+func CacheSizeMemoryLimitPercent(m *memcachedv1.Memcached) int32 {
+	return m.Spec.GetCacheSizeMemoryLimitPercent()
+}
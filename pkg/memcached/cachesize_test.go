@@ -0,0 +1,60 @@
+package memcached
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	memcachedv1 "github.com/openstack-k8s-operators/infra-operator/apis/memcached/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestCacheSizeMB(t *testing.T) {
+	explicit := int64(512)
+
+	tests := []struct {
+		name      string
+		cacheSize *int64
+		percent   int32
+		limit     string
+		expected  int64
+	}{
+		{
+			name:     "default without limit",
+			expected: DefaultCacheSizeMB,
+		},
+		{
+			name:     "derived from the memory limit",
+			limit:    "2Gi",
+			expected: 1638,
+		},
+		{
+			name:     "derived from the memory limit with custom percentage",
+			percent:  50,
+			limit:    "2Gi",
+			expected: 1024,
+		},
+		{
+			name:      "explicit cache size",
+			cacheSize: &explicit,
+			limit:     "2Gi",
+			expected:  512,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			m := &memcachedv1.Memcached{}
+			m.Spec.CacheSizeMB = tt.cacheSize
+			m.Spec.CacheSizeMemoryLimitPercent = tt.percent
+			if tt.limit != "" {
+				m.Spec.Resources.Limits = corev1.ResourceList{
+					corev1.ResourceMemory: resource.MustParse(tt.limit),
+				}
+			}
+			g.Expect(CacheSizeMB(m)).To(Equal(tt.expected))
+		})
+	}
+}
@@ -15,7 +15,57 @@ limitations under the License.
 
 package memcached
 
+import (
+	memcachedv1 "github.com/openstack-k8s-operators/infra-operator/apis/memcached/v1beta1"
+	labels "github.com/openstack-k8s-operators/lib-common/modules/common/labels"
+)
+
 const (
-	// MemcachedPort -
+	// MemcachedPort - default port, used if spec.port is not set
 	MemcachedPort int32 = 11211
+
+	// MemcachedCertPrefix - prefix of the TLS cert and key files
+	MemcachedCertPrefix = "memcached"
+
+	// PoolLabel - label holding the pool name on the resources of a pool
+	PoolLabel = "memcached.openstack.org/pool"
+
+	// PodServiceLabel - label holding the name of the StatefulSet on the per pod Services
+	PodServiceLabel = "memcached.openstack.org/pod-service"
+
+	// RestartedAtAnnotation - pod template annotation used to trigger a rolling restart
+	RestartedAtAnnotation = "memcached.openstack.org/restartedAt"
+
+	// ServerListHashName - status hash key of the server list Secret
+	ServerListHashName = "serverlist"
+
+	// DefaultCacheSizeMB - cache size used when neither the cache size nor a memory limit is set
+	DefaultCacheSizeMB = memcachedv1.MemcachedDefaultCacheSizeMB
+
+	// DefaultCacheSizeMemoryLimitPercent - share of the memory limit used as cache size,
+	// used if spec.cacheSizeMemoryLimitPercent is not set
+	DefaultCacheSizeMemoryLimitPercent = memcachedv1.MemcachedDefaultCacheSizeMemoryLimitPercent
+
+	// ExtstoreVolumeName - name of the volume holding the extstore data file
+	ExtstoreVolumeName = "extstore"
+
+	// ExtstorePath - mount path of the extstore volume
+	ExtstorePath = "/var/lib/memcached/extstore"
+
+	// ProxyConfigKey - config map key and file name of the proxy Lua route config
+	ProxyConfigKey = "proxy.lua"
+
+	// ProxyBackendsKey - config map key and file name of the generated proxy backends Lua table
+	ProxyBackendsKey = "backends.lua"
+
+	// ProxyConfigPath - directory the proxy Lua files get mounted to
+	ProxyConfigPath = "/etc/memcached/proxy"
+
+	// ExtstoreFileSizePercent - share of the storage request used as data file size if not set explicitly
+	ExtstoreFileSizePercent int64 = 90
 )
+
+// ServiceSelectorLabel - owner name label of the memcached pods the Services select
+// on next to the app label. It is not part of the StatefulSet selector, so it gets
+// removed from the departing replicas to take them out of rotation while draining.
+var ServiceSelectorLabel = labels.GetOwnerNameLabelSelector("memcached")
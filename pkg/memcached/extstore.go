@@ -0,0 +1,80 @@
+package memcached
+
+import (
+	"fmt"
+	"strings"
+
+	memcachedv1 "github.com/openstack-k8s-operators/infra-operator/apis/memcached/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ExtstoreOptions returns the memcached -o ext_* options for the extstore
+// settings of the Memcached CR, empty if extstore is not enabled
+func ExtstoreOptions(m *memcachedv1.Memcached) string {
+	ext := m.Spec.Extstore
+	if ext == nil {
+		return ""
+	}
+
+	opts := []string{
+		fmt.Sprintf("ext_path=%s/extstore:%dm", ExtstorePath, ExtstoreFileSizeMB(m)),
+	}
+	if ext.ItemSize != nil {
+		opts = append(opts, fmt.Sprintf("ext_item_size=%d", *ext.ItemSize))
+	}
+	if ext.ItemAge != nil {
+		opts = append(opts, fmt.Sprintf("ext_item_age=%d", *ext.ItemAge))
+	}
+	if ext.WriteBufferSizeMB != nil {
+		opts = append(opts, fmt.Sprintf("ext_wbuf_size=%d", *ext.WriteBufferSizeMB))
+	}
+	if ext.Threads != nil {
+		opts = append(opts, fmt.Sprintf("ext_threads=%d", *ext.Threads))
+	}
+
+	return "-o " + strings.Join(opts, ",")
+}
+
+// ExtstoreFileSizeMB returns the size of the extstore data file in megabytes.
+// If not set explicitly it is derived from the storage request.
+func ExtstoreFileSizeMB(m *memcachedv1.Memcached) int64 {
+	if m.Spec.Extstore.FileSizeMB != nil {
+		return *m.Spec.Extstore.FileSizeMB
+	}
+
+	// the storage request got validated by the webhook
+	request, _ := resource.ParseQuantity(m.Spec.Extstore.StorageRequest)
+	size := request.Value() * ExtstoreFileSizePercent / 100 / (1024 * 1024)
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// extstoreVolumeClaimTemplate returns the volume claim template of the
+// extstore data file
+func extstoreVolumeClaimTemplate(m *memcachedv1.Memcached, ls map[string]string) corev1.PersistentVolumeClaim {
+	pvc := corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   ExtstoreVolumeName,
+			Labels: ls,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{
+				corev1.ReadWriteOnce,
+			},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: resource.MustParse(m.Spec.Extstore.StorageRequest),
+				},
+			},
+		},
+	}
+	if m.Spec.Extstore.StorageClass != "" {
+		pvc.Spec.StorageClassName = &m.Spec.Extstore.StorageClass
+	}
+
+	return pvc
+}
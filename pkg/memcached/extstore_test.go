@@ -0,0 +1,27 @@
+package memcached
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	memcachedv1 "github.com/openstack-k8s-operators/infra-operator/apis/memcached/v1beta1"
+)
+
+func TestExtstoreOptions(t *testing.T) {
+	g := NewWithT(t)
+
+	m := &memcachedv1.Memcached{}
+	g.Expect(ExtstoreOptions(m)).To(BeEmpty())
+
+	m.Spec.Extstore = &memcachedv1.MemcachedExtstore{StorageRequest: "10Gi"}
+	g.Expect(ExtstoreOptions(m)).To(Equal("-o ext_path=/var/lib/memcached/extstore/extstore:9216m"))
+
+	fileSize := int64(4096)
+	itemSize := int32(512)
+	threads := int32(4)
+	m.Spec.Extstore.FileSizeMB = &fileSize
+	m.Spec.Extstore.ItemSize = &itemSize
+	m.Spec.Extstore.Threads = &threads
+	g.Expect(ExtstoreOptions(m)).To(Equal(
+		"-o ext_path=/var/lib/memcached/extstore/extstore:4096m,ext_item_size=512,ext_threads=4"))
+}
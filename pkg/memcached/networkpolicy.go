@@ -0,0 +1,68 @@
+package memcached
+
+import (
+	memcachedv1 "github.com/openstack-k8s-operators/infra-operator/apis/memcached/v1beta1"
+	labels "github.com/openstack-k8s-operators/lib-common/modules/common/labels"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// NetworkPolicy returns the NetworkPolicy limiting the ingress to the
+// memcached ports of the Memcached CR to the configured clients
+func NetworkPolicy(m *memcachedv1.Memcached) *networkingv1.NetworkPolicy {
+	protocol := corev1.ProtocolTCP
+	ports := []networkingv1.NetworkPolicyPort{{
+		Protocol: &protocol,
+		Port:     &intstr.IntOrString{Type: intstr.Int, IntVal: Port(m)},
+	}}
+	if m.PlaintextPortEnabled() {
+		ports = append(ports, networkingv1.NetworkPolicyPort{
+			Protocol: &protocol,
+			Port:     &intstr.IntOrString{Type: intstr.Int, IntVal: PlaintextPort(m)},
+		})
+	}
+	if m.Spec.UDPEnabled {
+		udp := corev1.ProtocolUDP
+		ports = append(ports, networkingv1.NetworkPolicyPort{
+			Protocol: &udp,
+			Port:     &intstr.IntOrString{Type: intstr.Int, IntVal: UDPPort(m)},
+		})
+	}
+
+	// a peer with only a podSelector matches pods in the policy namespace,
+	// with both selectors set the pods have to match both
+	peer := networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: m.Spec.NetworkPolicy.NamespaceSelector,
+		PodSelector:       m.Spec.NetworkPolicy.PodSelector,
+	}
+	if peer.NamespaceSelector == nil && peer.PodSelector == nil {
+		peer.PodSelector = &metav1.LabelSelector{}
+	}
+
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      m.Name,
+			Namespace: m.Namespace,
+			Labels: labels.GetLabels(m, "memcached", map[string]string{
+				"owner": "infra-operator",
+				"cr":    m.GetName(),
+				"app":   m.GetName(),
+			}),
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			// all memcached pods of the CR, including the ones of the pools
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					labels.GetOwnerUIDLabelSelector("memcached"): string(m.GetUID()),
+				},
+			},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{{
+				From:  []networkingv1.NetworkPolicyPeer{peer},
+				Ports: ports,
+			}},
+		},
+	}
+}
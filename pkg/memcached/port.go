@@ -0,0 +1,24 @@
+package memcached
+
+import (
+	memcachedv1 "github.com/openstack-k8s-operators/infra-operator/apis/memcached/v1beta1"
+)
+
+// Port returns the port memcached listens on
+func Port(m *memcachedv1.Memcached) int32 {
+	if m.Spec.Port == 0 {
+		return MemcachedPort
+	}
+	return m.Spec.Port
+}
+
+// PlaintextPort returns the plaintext port kept open next to the TLS one
+// during a TLS migration
+func PlaintextPort(m *memcachedv1.Memcached) int32 {
+	return Port(m) + 1
+}
+
+// UDPPort returns the UDP port, only opened when UDP is enabled
+func UDPPort(m *memcachedv1.Memcached) int32 {
+	return Port(m)
+}
@@ -0,0 +1,38 @@
+package memcached
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	memcachedv1 "github.com/openstack-k8s-operators/infra-operator/apis/memcached/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ProxyOptions returns the memcached -o proxy_config option for the proxy
+// settings of the Memcached CR, empty if the proxy is not enabled
+func ProxyOptions(m *memcachedv1.Memcached) string {
+	if m.Spec.Proxy == nil {
+		return ""
+	}
+	return fmt.Sprintf("-o proxy_config=%s/%s", ProxyConfigPath, ProxyConfigKey)
+}
+
+// ProxyBackendsLua returns the Lua chunk returning the table of the memcached
+// servers of the main StatefulSet of the Memcached CR, to be used as backends
+// by the proxy route config
+func ProxyBackendsLua(m *memcachedv1.Memcached, ipFamily corev1.IPFamily) string {
+	serverList, _ := InstanceServerLists(m, ipFamily, Port(m), fmt.Sprintf("%s.svc", m.Namespace))
+
+	var b strings.Builder
+	b.WriteString("-- generated by infra-operator, do not edit\nreturn {\n")
+	for _, server := range serverList {
+		// the server list entries are generated as host:port
+		host, port, _ := net.SplitHostPort(server)
+		fmt.Fprintf(&b, "  { name = %q, host = %q, port = %s, tls = %t },\n",
+			strings.SplitN(host, ".", 2)[0], host, port, m.Spec.TLS.Enabled())
+	}
+	b.WriteString("}\n")
+
+	return b.String()
+}
@@ -0,0 +1,37 @@
+package memcached
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	memcachedv1 "github.com/openstack-k8s-operators/infra-operator/apis/memcached/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestProxyOptions(t *testing.T) {
+	g := NewWithT(t)
+
+	m := &memcachedv1.Memcached{}
+	g.Expect(ProxyOptions(m)).To(BeEmpty())
+
+	m.Spec.Proxy = &memcachedv1.MemcachedProxy{RouteConfig: "pools{}"}
+	g.Expect(ProxyOptions(m)).To(Equal("-o proxy_config=/etc/memcached/proxy/proxy.lua"))
+}
+
+func TestProxyBackendsLua(t *testing.T) {
+	g := NewWithT(t)
+
+	replicas := int32(2)
+	m := &memcachedv1.Memcached{
+		ObjectMeta: metav1.ObjectMeta{Name: "memcached", Namespace: "openstack"},
+		Spec:       memcachedv1.MemcachedSpec{Replicas: &replicas},
+	}
+
+	g.Expect(ProxyBackendsLua(m, corev1.IPv4Protocol)).To(Equal(`-- generated by infra-operator, do not edit
+return {
+  { name = "memcached-0", host = "memcached-0.memcached.openstack.svc", port = 11211, tls = false },
+  { name = "memcached-1", host = "memcached-1.memcached.openstack.svc", port = 11211, tls = false },
+}
+`))
+}
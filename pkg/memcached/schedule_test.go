@@ -0,0 +1,109 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memcached
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestScheduleNext(t *testing.T) {
+	from := time.Date(2024, time.January, 31, 10, 30, 0, 0, time.UTC) // wednesday
+
+	tests := []struct {
+		name      string
+		expr      string
+		expectErr bool
+		next      time.Time
+	}{
+		{
+			name: "every minute",
+			expr: "* * * * *",
+			next: time.Date(2024, time.January, 31, 10, 31, 0, 0, time.UTC),
+		},
+		{
+			name: "daily at 03:00",
+			expr: "0 3 * * *",
+			next: time.Date(2024, time.February, 1, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "every 15 minutes",
+			expr: "*/15 * * * *",
+			next: time.Date(2024, time.January, 31, 10, 45, 0, 0, time.UTC),
+		},
+		{
+			name: "sundays as 7",
+			expr: "0 2 * * 7",
+			next: time.Date(2024, time.February, 4, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "weekdays range and list",
+			expr: "0 1,22 * * 1-5",
+			next: time.Date(2024, time.January, 31, 22, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "day of month or day of week",
+			expr: "0 0 15 * 5",
+			next: time.Date(2024, time.February, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "leap day",
+			expr: "0 0 29 2 *",
+			next: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "never matching date",
+			expr: "0 0 31 2 *",
+			next: time.Time{},
+		},
+		{
+			name:      "wrong number of fields",
+			expr:      "0 0 * *",
+			expectErr: true,
+		},
+		{
+			name:      "out of range",
+			expr:      "60 * * * *",
+			expectErr: true,
+		},
+		{
+			name:      "invalid step",
+			expr:      "*/0 * * * *",
+			expectErr: true,
+		},
+		{
+			name:      "not a number",
+			expr:      "0 three * * *",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			s, err := ParseSchedule(tt.expr)
+			if tt.expectErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(s.Next(from)).To(Equal(tt.next))
+		})
+	}
+}
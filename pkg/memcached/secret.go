@@ -0,0 +1,59 @@
+package memcached
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	memcachedv1 "github.com/openstack-k8s-operators/infra-operator/apis/memcached/v1beta1"
+	labels "github.com/openstack-k8s-operators/lib-common/modules/common/labels"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ServersSecretKey - secret key holding the server list in the oslo.cache memcache_servers format
+	ServersSecretKey = "memcache_servers"
+	// ServersWithInetSecretKey - secret key holding the server list with inet(6) prefix
+	ServersWithInetSecretKey = "memcache_servers_inet"
+	// PlaintextServersSecretKey - secret key holding the plaintext server list during TLS migration
+	PlaintextServersSecretKey = "memcache_servers_plaintext"
+	// ServersSecretKey.<pool> holds the server list of a pool
+
+	// TLSEnabledSecretKey - secret key holding if TLS is enabled, matches the oslo.cache tls_enabled option
+	TLSEnabledSecretKey = "tls_enabled"
+)
+
+// ServerListSecret returns the Secret publishing the memcached server
+// endpoints of the Memcached CR for consumption by the service operators
+func ServerListSecret(m *memcachedv1.Memcached, ipFamily corev1.IPFamily) *corev1.Secret {
+	domain := fmt.Sprintf("%s.svc", m.Namespace)
+	servers, serversWithInet := InstanceServerLists(m, ipFamily, Port(m), domain)
+
+	data := map[string][]byte{
+		ServersSecretKey:         []byte(strings.Join(servers, ",")),
+		ServersWithInetSecretKey: []byte(strings.Join(serversWithInet, ",")),
+		TLSEnabledSecretKey:      []byte(strconv.FormatBool(m.Spec.TLS.Enabled())),
+	}
+	if m.PlaintextPortEnabled() {
+		plaintextServers, _ := InstanceServerLists(m, ipFamily, PlaintextPort(m), domain)
+		data[PlaintextServersSecretKey] = []byte(strings.Join(plaintextServers, ","))
+	}
+	for _, pool := range m.Spec.Pools {
+		poolServers, _ := ServerLists(m.PoolName(pool.Name), *pool.Replicas, ipFamily, Port(m), domain)
+		data[fmt.Sprintf("%s.%s", ServersSecretKey, pool.Name)] = []byte(strings.Join(poolServers, ","))
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      m.ServerListSecretName(),
+			Namespace: m.Namespace,
+			Labels: labels.GetLabels(m, "memcached", map[string]string{
+				"owner": "infra-operator",
+				"cr":    m.GetName(),
+				"app":   m.GetName(),
+			}),
+		},
+		Data: data,
+	}
+}
@@ -0,0 +1,101 @@
+package memcached
+
+import (
+	"fmt"
+
+	memcachedv1 "github.com/openstack-k8s-operators/infra-operator/apis/memcached/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ServerLists returns the memcached servers of the StatefulSet name with replicas
+// pods listening on port, without and with inet(6) prefix. If domain is not empty
+// it is appended to the pod hostnames.
+func ServerLists(
+	name string,
+	replicas int32,
+	ipFamily corev1.IPFamily,
+	port int32,
+	domain string,
+) ([]string, []string) {
+	return serverLists(replicas, ipFamily, port, domain, func(i int32) string {
+		return fmt.Sprintf("%s-%d.%s", name, i, name)
+	})
+}
+
+// PodServiceServerLists returns the memcached servers of the StatefulSet name
+// with replicas pods like ServerLists, but addressed via their per pod Services
+func PodServiceServerLists(
+	name string,
+	replicas int32,
+	ipFamily corev1.IPFamily,
+	port int32,
+	domain string,
+) ([]string, []string) {
+	return serverLists(replicas, ipFamily, port, domain, func(i int32) string {
+		return PodServiceName(name, i)
+	})
+}
+
+// InstanceServerLists returns the memcached servers of the main StatefulSet of
+// the Memcached CR, addressed via the per pod Services if enabled. In DaemonSet
+// mode the node-local Service is the only server.
+func InstanceServerLists(
+	m *memcachedv1.Memcached,
+	ipFamily corev1.IPFamily,
+	port int32,
+	domain string,
+) ([]string, []string) {
+	if m.DaemonSetMode() {
+		return serverLists(1, ipFamily, port, domain, func(int32) string {
+			return m.Name
+		})
+	}
+	if m.Spec.PerPodServices {
+		return PodServiceServerLists(m.Name, *m.Spec.Replicas, ipFamily, port, domain)
+	}
+	return ServerLists(m.Name, *m.Spec.Replicas, ipFamily, port, domain)
+}
+
+func serverLists(
+	replicas int32,
+	ipFamily corev1.IPFamily,
+	port int32,
+	domain string,
+	hostname func(int32) string,
+) ([]string, []string) {
+	var serverList []string
+	var serverListWithInet []string
+
+	prefix := "inet"
+	if ipFamily == corev1.IPv6Protocol {
+		prefix = "inet6"
+	}
+
+	for i := int32(0); i < replicas; i++ {
+		server := hostname(i)
+		if domain != "" {
+			server = fmt.Sprintf("%s.%s", server, domain)
+		}
+		serverList = append(serverList, fmt.Sprintf("%s:%d", server, port))
+
+		// python-memcached requires inet(6) prefix according to the IP version
+		// used by the memcached server.
+		serverListWithInet = append(serverListWithInet, fmt.Sprintf("%s:[%s]:%d", prefix, server, port))
+	}
+
+	return serverList, serverListWithInet
+}
+
+// ListenAddresses returns the wildcard addresses memcached has to listen on
+// for the ipFamilies, IPv6 ones in the bracketed form expected by -l
+func ListenAddresses(ipFamilies []corev1.IPFamily) []string {
+	addresses := []string{}
+	for _, ipFamily := range ipFamilies {
+		if ipFamily == corev1.IPv6Protocol {
+			addresses = append(addresses, "[::]")
+		} else {
+			addresses = append(addresses, "0.0.0.0")
+		}
+	}
+	return addresses
+}
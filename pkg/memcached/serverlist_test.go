@@ -0,0 +1,38 @@
+package memcached
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	memcachedv1 "github.com/openstack-k8s-operators/infra-operator/apis/memcached/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestInstanceServerLists(t *testing.T) {
+	g := NewWithT(t)
+
+	replicas := int32(2)
+	m := &memcachedv1.Memcached{
+		ObjectMeta: metav1.ObjectMeta{Name: "memcached", Namespace: "openstack"},
+		Spec:       memcachedv1.MemcachedSpec{Replicas: &replicas},
+	}
+
+	servers, serversWithInet := InstanceServerLists(m, corev1.IPv4Protocol, Port(m), "")
+	g.Expect(servers).To(Equal([]string{"memcached-0.memcached:11211", "memcached-1.memcached:11211"}))
+	g.Expect(serversWithInet).To(Equal([]string{"inet:[memcached-0.memcached]:11211", "inet:[memcached-1.memcached]:11211"}))
+
+	m.Spec.PerPodServices = true
+	servers, serversWithInet = InstanceServerLists(m, corev1.IPv6Protocol, Port(m), "openstack.svc")
+	g.Expect(servers).To(Equal([]string{"memcached-0.openstack.svc:11211", "memcached-1.openstack.svc:11211"}))
+	g.Expect(serversWithInet).To(Equal([]string{"inet6:[memcached-0.openstack.svc]:11211", "inet6:[memcached-1.openstack.svc]:11211"}))
+
+	m.Spec.DeploymentMode = memcachedv1.MemcachedDeploymentModeDaemonSet
+	servers, _ = InstanceServerLists(m, corev1.IPv4Protocol, Port(m), "openstack.svc")
+	g.Expect(servers).To(Equal([]string{"memcached.openstack.svc:11211"}))
+
+	m.Spec.Port = 11311
+	servers, _ = InstanceServerLists(m, corev1.IPv4Protocol, Port(m), "openstack.svc")
+	g.Expect(servers).To(Equal([]string{"memcached.openstack.svc:11311"}))
+	g.Expect(PlaintextPort(m)).To(Equal(int32(11312)))
+}
@@ -1,34 +1,183 @@
 package memcached
 
 import (
+	"fmt"
+
 	memcachedv1 "github.com/openstack-k8s-operators/infra-operator/apis/memcached/v1beta1"
 	labels "github.com/openstack-k8s-operators/lib-common/modules/common/labels"
 	service "github.com/openstack-k8s-operators/lib-common/modules/common/service"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 )
 
-// HeadlessService exposes all memcached repliscas for a memcached CR
-func HeadlessService(m *memcachedv1.Memcached) *corev1.Service {
-	labels := labels.GetLabels(m, "memcached", map[string]string{
-		"owner": "infra-operator",
-		"cr":    m.GetName(),
-		"app":   m.GetName(),
-	})
+// InstanceService returns the Service of a memcached CR, the headless one or
+// the node-local one in DaemonSet mode
+func InstanceService(m *memcachedv1.Memcached) *corev1.Service {
+	if m.DaemonSetMode() {
+		return NodeLocalService(m)
+	}
+	return HeadlessService(m)
+}
+
+// NodeLocalService exposes the memcached replica running on the node of the
+// client for a memcached CR in DaemonSet mode
+func NodeLocalService(m *memcachedv1.Memcached) *corev1.Service {
 	details := &service.GenericServiceDetails{
 		Name:      m.GetName(),
 		Namespace: m.GetNamespace(),
-		Labels:    labels,
+		Labels: labels.GetLabels(m, "memcached", map[string]string{
+			"owner": "infra-operator",
+			"cr":    m.GetName(),
+			"app":   m.GetName(),
+		}),
 		Selector: map[string]string{
 			"app": m.GetName(),
 		},
-		Port: service.GenericServicePort{
-			Name:     "memcached",
-			Port:     MemcachedPort,
-			Protocol: "TCP",
+		Ports: servicePorts(m),
+	}
+
+	svc := service.GenericService(details)
+	setIPFamilies(m, svc)
+	local := corev1.ServiceInternalTrafficPolicyLocal
+	svc.Spec.InternalTrafficPolicy = &local
+	return svc
+}
+
+// HeadlessService exposes all memcached repliscas for a memcached CR
+func HeadlessService(m *memcachedv1.Memcached) *corev1.Service {
+	return headlessService(m, m.GetName(), map[string]string{})
+}
+
+// PoolHeadlessService exposes all memcached replicas of a pool of a memcached CR
+func PoolHeadlessService(m *memcachedv1.Memcached, pool memcachedv1.MemcachedPool) *corev1.Service {
+	return headlessService(m, m.PoolName(pool.Name), map[string]string{PoolLabel: pool.Name})
+}
+
+// ProxyService load balances the memcached replicas of a memcached CR with
+// client IP session affinity for proxying layers in front of the cache
+func ProxyService(m *memcachedv1.Memcached) *corev1.Service {
+	details := &service.GenericServiceDetails{
+		Name:      m.ProxyServiceName(),
+		Namespace: m.GetNamespace(),
+		Labels: labels.GetLabels(m, "memcached", map[string]string{
+			"owner": "infra-operator",
+			"cr":    m.GetName(),
+			"app":   m.GetName(),
+		}),
+		Selector: serviceSelector(m, m.GetName()),
+		Ports:    servicePorts(m),
+	}
+
+	svc := service.GenericService(details)
+	setIPFamilies(m, svc)
+	if m.Spec.ServiceAffinity != nil {
+		svc.Spec.SessionAffinity = corev1.ServiceAffinityClientIP
+		svc.Spec.SessionAffinityConfig = &corev1.SessionAffinityConfig{
+			ClientIP: &corev1.ClientIPConfig{
+				TimeoutSeconds: m.Spec.ServiceAffinity.TimeoutSeconds,
+			},
+		}
+	}
+	// the external traffic policy is only valid for externally reachable services
+	if m.Spec.ExternalTrafficPolicy != "" {
+		svc.Spec.Type = corev1.ServiceTypeLoadBalancer
+		svc.Spec.ExternalTrafficPolicy = m.Spec.ExternalTrafficPolicy
+	}
+	return svc
+}
+
+// PodServiceName returns the name of the per pod Service of the pod with
+// ordinal of the StatefulSet name
+func PodServiceName(name string, ordinal int32) string {
+	return fmt.Sprintf("%s-%d", name, ordinal)
+}
+
+// PodService exposes the memcached pod with ordinal of a memcached CR via a
+// stable virtual IP
+func PodService(m *memcachedv1.Memcached, ordinal int32) *corev1.Service {
+	name := PodServiceName(m.GetName(), ordinal)
+	details := &service.GenericServiceDetails{
+		Name:      name,
+		Namespace: m.GetNamespace(),
+		Labels: labels.GetLabels(m, "memcached", map[string]string{
+			"owner":         "infra-operator",
+			"cr":            m.GetName(),
+			"app":           m.GetName(),
+			PodServiceLabel: m.GetName(),
+		}),
+		Selector: map[string]string{
+			"app":                          m.GetName(),
+			appsv1.StatefulSetPodNameLabel: name,
 		},
+		Ports: servicePorts(m),
+	}
+
+	svc := service.GenericService(details)
+	setIPFamilies(m, svc)
+	return svc
+}
+
+func headlessService(m *memcachedv1.Memcached, name string, extraLabels map[string]string) *corev1.Service {
+	labels := labels.GetLabels(m, "memcached", util.MergeStringMaps(map[string]string{
+		"owner": "infra-operator",
+		"cr":    m.GetName(),
+		"app":   name,
+	}, extraLabels))
+
+	details := &service.GenericServiceDetails{
+		Name:      name,
+		Namespace: m.GetNamespace(),
+		Labels:    labels,
+		Selector:  serviceSelector(m, name),
+		Ports:     servicePorts(m),
 		ClusterIP: "None",
 	}
 
 	svc := service.GenericService(details)
+	setIPFamilies(m, svc)
 	return svc
 }
+
+// serviceSelector returns the selector of the memcached pods with the app
+// label, which are not draining
+func serviceSelector(m *memcachedv1.Memcached, app string) map[string]string {
+	return map[string]string{
+		"app":                app,
+		ServiceSelectorLabel: m.GetName(),
+	}
+}
+
+func servicePorts(m *memcachedv1.Memcached) []corev1.ServicePort {
+	ports := []corev1.ServicePort{{
+		Name:     "memcached",
+		Port:     Port(m),
+		Protocol: corev1.ProtocolTCP,
+	}}
+	if m.PlaintextPortEnabled() {
+		ports = append(ports, corev1.ServicePort{
+			Name:     "memcached-plain",
+			Port:     PlaintextPort(m),
+			Protocol: corev1.ProtocolTCP,
+		})
+	}
+	if m.Spec.UDPEnabled {
+		ports = append(ports, corev1.ServicePort{
+			Name:     "memcached-udp",
+			Port:     UDPPort(m),
+			Protocol: corev1.ProtocolUDP,
+		})
+	}
+	return ports
+}
+
+func setIPFamilies(m *memcachedv1.Memcached, svc *corev1.Service) {
+	if len(m.Spec.IPFamilies) > 0 {
+		policy := corev1.IPFamilyPolicySingleStack
+		if len(m.Spec.IPFamilies) > 1 {
+			policy = corev1.IPFamilyPolicyRequireDualStack
+		}
+		svc.Spec.IPFamilies = m.Spec.IPFamilies
+		svc.Spec.IPFamilyPolicy = &policy
+	}
+}
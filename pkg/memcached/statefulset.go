@@ -2,23 +2,120 @@ package memcached
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	memcachedv1 "github.com/openstack-k8s-operators/infra-operator/apis/memcached/v1beta1"
 	labels "github.com/openstack-k8s-operators/lib-common/modules/common/labels"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/tls"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s_labels "k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // StatefulSet returns a Stateful resource for the Memcached CR
-func StatefulSet(m *memcachedv1.Memcached) *appsv1.StatefulSet {
-	matchls := map[string]string{
+func StatefulSet(m *memcachedv1.Memcached, configHash string) *appsv1.StatefulSet {
+	return statefulSet(m, m.Name, m.Spec.Replicas, selectorLabels(m), configHash)
+}
+
+// Selector returns the label selector of the pods of the StatefulSet of the
+// Memcached CR in its string representation
+func Selector(m *memcachedv1.Memcached) string {
+	return k8s_labels.SelectorFromSet(selectorLabels(m)).String()
+}
+
+func selectorLabels(m *memcachedv1.Memcached) map[string]string {
+	return map[string]string{
 		"app":   m.Name,
 		"cr":    m.Name,
 		"owner": "infra-operator",
 	}
+}
+
+// PoolStatefulSet returns a Stateful resource for a pool of the Memcached CR
+func PoolStatefulSet(m *memcachedv1.Memcached, pool memcachedv1.MemcachedPool, configHash string) *appsv1.StatefulSet {
+	matchls := map[string]string{
+		"app":     m.PoolName(pool.Name),
+		"cr":      m.Name,
+		"owner":   "infra-operator",
+		PoolLabel: pool.Name,
+	}
+	return statefulSet(m, m.PoolName(pool.Name), pool.Replicas, matchls, configHash)
+}
+
+// DaemonSet returns a DaemonSet resource running memcached on every selected
+// node for the Memcached CR in DaemonSet mode
+func DaemonSet(m *memcachedv1.Memcached, configHash string) *appsv1.DaemonSet {
+	matchls := selectorLabels(m)
+	ls := labels.GetLabels(m, "memcached", matchls)
+
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      m.Name,
+			Namespace: m.Namespace,
+			Labels:    ls,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: matchls,
+			},
+			Template: podTemplate(m, ls, configHash),
+		},
+	}
+}
+
+func statefulSet(
+	m *memcachedv1.Memcached,
+	name string,
+	replicas *int32,
+	matchls map[string]string,
+	configHash string,
+) *appsv1.StatefulSet {
 	ls := labels.GetLabels(m, "memcached", matchls)
+
+	sfs := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: m.Namespace,
+			Labels:    ls,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			ServiceName: name,
+			Replicas:    replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: matchls,
+			},
+			Template: podTemplate(m, ls, configHash),
+		},
+	}
+
+	if m.Spec.Extstore != nil {
+		sfs.Spec.VolumeClaimTemplates = []corev1.PersistentVolumeClaim{
+			extstoreVolumeClaimTemplate(m, ls),
+		}
+	}
+
+	if m.Spec.UpdateStrategy != nil {
+		sfs.Spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{
+			Type: appsv1.RollingUpdateStatefulSetStrategyType,
+			RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{
+				Partition:      m.Spec.UpdateStrategy.Partition,
+				MaxUnavailable: m.Spec.UpdateStrategy.MaxUnavailable,
+			},
+		}
+	}
+
+	return sfs
+}
+
+// podTemplate returns the memcached pod template with the labels ls
+func podTemplate(
+	m *memcachedv1.Memcached,
+	ls map[string]string,
+	configHash string,
+) corev1.PodTemplateSpec {
 	runAsUser := int64(0)
 
 	livenessProbe := &corev1.Probe{
@@ -35,94 +132,150 @@ func StatefulSet(m *memcachedv1.Memcached) *appsv1.StatefulSet {
 	}
 
 	livenessProbe.TCPSocket = &corev1.TCPSocketAction{
-		Port: intstr.IntOrString{Type: intstr.Int, IntVal: MemcachedPort},
+		Port: intstr.IntOrString{Type: intstr.Int, IntVal: Port(m)},
 	}
 	readinessProbe.TCPSocket = &corev1.TCPSocketAction{
-		Port: intstr.IntOrString{Type: intstr.Int, IntVal: MemcachedPort},
+		Port: intstr.IntOrString{Type: intstr.Int, IntVal: Port(m)},
 	}
 
-	sfs := &appsv1.StatefulSet{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      m.Name,
-			Namespace: m.Namespace,
-		},
-		Spec: appsv1.StatefulSetSpec{
-			ServiceName: m.Name,
-			Replicas:    m.Spec.Replicas,
-			Selector: &metav1.LabelSelector{
-				MatchLabels: matchls,
-			},
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: ls,
-				},
-				Spec: corev1.PodSpec{
-					ServiceAccountName: m.RbacResourceName(),
-					Containers: []corev1.Container{{
-						Image:   m.Spec.ContainerImage,
-						Name:    "memcached",
-						Command: []string{"/usr/bin/dumb-init", "--", "/usr/local/bin/kolla_start"},
-						SecurityContext: &corev1.SecurityContext{
-							RunAsUser: &runAsUser,
-						},
-						Env: []corev1.EnvVar{{
-							Name:  "KOLLA_CONFIG_STRATEGY",
-							Value: "COPY_ALWAYS",
-						}},
-						VolumeMounts: []corev1.VolumeMount{{
-							MountPath: "/var/lib/kolla/config_files/src",
-							ReadOnly:  true,
-							Name:      "config-data",
-						}, {
-							MountPath: "/var/lib/kolla/config_files",
-							ReadOnly:  true,
-							Name:      "kolla-config",
-						}},
-						Ports: []corev1.ContainerPort{{
-							ContainerPort: MemcachedPort,
-							Name:          "memcached",
-						}},
-						ReadinessProbe: readinessProbe,
-						LivenessProbe:  livenessProbe,
-					}},
-					Volumes: []corev1.Volume{
+	volumes := []corev1.Volume{
+		{
+			Name: "kolla-config",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: fmt.Sprintf("%s-config-data", m.Name),
+					},
+					Items: []corev1.KeyToPath{
 						{
-							Name: "kolla-config",
-							VolumeSource: corev1.VolumeSource{
-								ConfigMap: &corev1.ConfigMapVolumeSource{
-									LocalObjectReference: corev1.LocalObjectReference{
-										Name: fmt.Sprintf("%s-config-data", m.Name),
-									},
-									Items: []corev1.KeyToPath{
-										{
-											Key:  "config.json",
-											Path: "config.json",
-										},
-									},
-								},
-							},
+							Key:  "config.json",
+							Path: "config.json",
 						},
+					},
+				},
+			},
+		},
+		{
+			Name: "config-data",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: fmt.Sprintf("%s-config-data", m.Name),
+					},
+					Items: []corev1.KeyToPath{
 						{
-							Name: "config-data",
-							VolumeSource: corev1.VolumeSource{
-								ConfigMap: &corev1.ConfigMapVolumeSource{
-									LocalObjectReference: corev1.LocalObjectReference{
-										Name: fmt.Sprintf("%s-config-data", m.Name),
-									},
-									Items: []corev1.KeyToPath{
-										{
-											Key:  "memcached",
-											Path: "etc/sysconfig/memcached",
-										},
-									},
-								},
-							},
+							Key:  "memcached",
+							Path: "etc/sysconfig/memcached",
 						},
 					},
 				},
 			},
 		},
 	}
+	if m.Spec.Proxy != nil {
+		configData := volumes[1].VolumeSource.ConfigMap
+		configData.Items = append(configData.Items,
+			corev1.KeyToPath{
+				Key:  ProxyConfigKey,
+				Path: strings.TrimPrefix(ProxyConfigPath, "/") + "/" + ProxyConfigKey,
+			},
+			corev1.KeyToPath{
+				Key:  ProxyBackendsKey,
+				Path: strings.TrimPrefix(ProxyConfigPath, "/") + "/" + ProxyBackendsKey,
+			})
+	}
+	volumeMounts := []corev1.VolumeMount{{
+		MountPath: "/var/lib/kolla/config_files/src",
+		ReadOnly:  true,
+		Name:      "config-data",
+	}, {
+		MountPath: "/var/lib/kolla/config_files",
+		ReadOnly:  true,
+		Name:      "kolla-config",
+	}}
 
-	return sfs
+	if m.Spec.TLS.Enabled() {
+		svc := tls.Service{
+			SecretName: *m.Spec.TLS.GenericService.SecretName,
+			CertMount:  nil,
+			KeyMount:   nil,
+			CaMount:    nil,
+		}
+		volumes = append(volumes, svc.CreateVolume(MemcachedCertPrefix))
+		volumeMounts = append(volumeMounts, svc.CreateVolumeMounts(MemcachedCertPrefix)...)
+		if m.Spec.TLS.Ca.CaBundleSecretName != "" {
+			volumes = append(volumes, m.Spec.TLS.Ca.CreateVolume())
+			volumeMounts = append(volumeMounts, m.Spec.TLS.Ca.CreateVolumeMounts(nil)...)
+		}
+	}
+
+	for _, extraMount := range m.Spec.ExtraMounts {
+		for _, vol := range extraMount.Volumes {
+			volumes = append(volumes, vol.ToCoreVolume())
+		}
+		volumeMounts = append(volumeMounts, extraMount.Mounts...)
+	}
+
+	if m.Spec.Extstore != nil {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			MountPath: ExtstorePath,
+			Name:      ExtstoreVolumeName,
+		})
+	}
+
+	ports := []corev1.ContainerPort{{
+		ContainerPort: Port(m),
+		Name:          "memcached",
+	}}
+	if m.PlaintextPortEnabled() {
+		ports = append(ports, corev1.ContainerPort{
+			ContainerPort: PlaintextPort(m),
+			Name:          "memcached-plain",
+		})
+	}
+	if m.Spec.UDPEnabled {
+		ports = append(ports, corev1.ContainerPort{
+			ContainerPort: UDPPort(m),
+			Name:          "memcached-udp",
+			Protocol:      corev1.ProtocolUDP,
+		})
+	}
+
+	annotations := map[string]string{}
+	if m.Status.LastScheduledRestart != nil {
+		// changing the annotation rolls the pods one at a time
+		annotations[RestartedAtAnnotation] = m.Status.LastScheduledRestart.UTC().Format(time.RFC3339)
+	}
+
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      ls,
+			Annotations: annotations,
+		},
+		Spec: corev1.PodSpec{
+			ServiceAccountName: m.RbacResourceName(),
+			Containers: []corev1.Container{{
+				Image:   m.Spec.ContainerImage,
+				Name:    "memcached",
+				Command: []string{"/usr/bin/dumb-init", "--", "/usr/local/bin/kolla_start"},
+				SecurityContext: &corev1.SecurityContext{
+					RunAsUser: &runAsUser,
+				},
+				Env: []corev1.EnvVar{{
+					Name:  "KOLLA_CONFIG_STRATEGY",
+					Value: "COPY_ALWAYS",
+				}, {
+					Name:  "CONFIG_HASH",
+					Value: configHash,
+				}},
+				VolumeMounts:   volumeMounts,
+				Resources:      m.Spec.Resources,
+				Ports:          ports,
+				ReadinessProbe: readinessProbe,
+				LivenessProbe:  livenessProbe,
+			}},
+			Volumes:      volumes,
+			NodeSelector: m.Spec.NodeSelector,
+		},
+	}
 }
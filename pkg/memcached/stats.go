@@ -0,0 +1,166 @@
+package memcached
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	memcachedv1 "github.com/openstack-k8s-operators/infra-operator/apis/memcached/v1beta1"
+)
+
+// statsTimeout - timeout to collect the stats of all replicas, the reconcile
+// waits at most that long for unreachable replicas
+var statsTimeout = 2 * time.Second
+
+// StatsTarget - a replica to collect the stats of
+type StatsTarget struct {
+	Pod       string
+	Addr      string
+	TLSConfig *tls.Config
+}
+
+// StatsResult - the stats of a replica, or the error collecting them
+type StatsResult struct {
+	Stats map[string]string
+	Slabs map[string]string
+	Err   error
+}
+
+// CollectAllStats collects the stats of the replicas in parallel and returns
+// the results by pod name. The collection of all replicas is bounded by
+// statsTimeout.
+func CollectAllStats(ctx context.Context, targets []StatsTarget) map[string]StatsResult {
+	ctx, cancel := context.WithTimeout(ctx, statsTimeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := map[string]StatsResult{}
+	for _, target := range targets {
+		wg.Add(1)
+		go func(target StatsTarget) {
+			defer wg.Done()
+			stats, slabs, err := CollectStats(ctx, target.Addr, target.TLSConfig)
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[target.Pod] = StatsResult{Stats: stats, Slabs: slabs, Err: err}
+		}(target)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// CollectStats connects to the memcached replica at addr and returns the
+// output of the "stats" and "stats slabs" commands. If tlsConfig is set the
+// connection uses TLS. The deadline of ctx, or statsTimeout if there is none,
+// bounds the whole exchange.
+func CollectStats(ctx context.Context, addr string, tlsConfig *tls.Config) (map[string]string, map[string]string, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(statsTimeout)
+	}
+	dialer := &net.Dialer{Deadline: deadline}
+
+	var conn net.Conn
+	var err error
+	if tlsConfig != nil {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	defer conn.Close()
+
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	stats, err := readStats(rw, "stats")
+	if err != nil {
+		return nil, nil, err
+	}
+	slabs, err := readStats(rw, "stats slabs")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return stats, slabs, nil
+}
+
+// readStats sends cmd and parses the "STAT <name> <value>" lines of the
+// response until "END"
+func readStats(rw *bufio.ReadWriter, cmd string) (map[string]string, error) {
+	if _, err := rw.WriteString(cmd + "\r\n"); err != nil {
+		return nil, err
+	}
+	if err := rw.Flush(); err != nil {
+		return nil, err
+	}
+
+	stats := map[string]string{}
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "END" {
+			return stats, nil
+		}
+
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) != 3 || fields[0] != "STAT" {
+			return nil, fmt.Errorf("unexpected response to %q: %q", cmd, line)
+		}
+		stats[fields[1]] = fields[2]
+	}
+}
+
+// PodStats returns the status summary of the stats of a replica. The eviction
+// rate is calculated from the evictions of the previous collection, if any.
+func PodStats(
+	pod string,
+	stats map[string]string,
+	slabs map[string]string,
+	previous *memcachedv1.MemcachedPodStats,
+	elapsed time.Duration,
+) memcachedv1.MemcachedPodStats {
+	statInt := func(m map[string]string, key string) int64 {
+		v, _ := strconv.ParseInt(m[key], 10, 64)
+		return v
+	}
+
+	podStats := memcachedv1.MemcachedPodStats{
+		Pod:             pod,
+		Evictions:       statInt(stats, "evictions"),
+		UsedMemoryBytes: statInt(stats, "bytes"),
+		LimitMaxBytes:   statInt(stats, "limit_maxbytes"),
+		TotalMalloced:   statInt(slabs, "total_malloced"),
+		ActiveSlabs:     int32(statInt(slabs, "active_slabs")),
+	}
+
+	hits := statInt(stats, "get_hits")
+	misses := statInt(stats, "get_misses")
+	if hits+misses > 0 {
+		podStats.HitRatio = strconv.FormatFloat(float64(hits)*100/float64(hits+misses), 'f', 2, 64)
+	}
+
+	// a lower counter means the replica got restarted in between
+	if previous != nil && elapsed > 0 && podStats.Evictions >= previous.Evictions {
+		podStats.EvictionsPerMinute = int64(float64(podStats.Evictions-previous.Evictions) / elapsed.Minutes())
+	}
+
+	return podStats
+}
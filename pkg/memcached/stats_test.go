@@ -0,0 +1,110 @@
+package memcached
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	memcachedv1 "github.com/openstack-k8s-operators/infra-operator/apis/memcached/v1beta1"
+)
+
+func TestReadStats(t *testing.T) {
+	g := NewWithT(t)
+
+	response := "STAT pid 1\r\nSTAT version 1.6.21\r\nEND\r\n"
+	out := &strings.Builder{}
+	rw := bufio.NewReadWriter(bufio.NewReader(strings.NewReader(response)), bufio.NewWriter(out))
+
+	stats, err := readStats(rw, "stats")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out.String()).To(Equal("stats\r\n"))
+	g.Expect(stats).To(Equal(map[string]string{"pid": "1", "version": "1.6.21"}))
+
+	rw = bufio.NewReadWriter(bufio.NewReader(strings.NewReader("ERROR\r\n")), bufio.NewWriter(out))
+	_, err = readStats(rw, "stats")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestCollectAllStats(t *testing.T) {
+	g := NewWithT(t)
+
+	timeout := statsTimeout
+	statsTimeout = 200 * time.Millisecond
+	defer func() { statsTimeout = timeout }()
+
+	// a replica answering the stats commands
+	ok, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer ok.Close()
+	go func() {
+		conn, err := ok.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for _, response := range []string{"STAT evictions 1\r\nEND\r\n", "STAT active_slabs 2\r\nEND\r\n"} {
+			if _, err := r.ReadString('\n'); err != nil {
+				return
+			}
+			if _, err := conn.Write([]byte(response)); err != nil {
+				return
+			}
+		}
+	}()
+
+	// a replica accepting the connection but never answering
+	hung, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer hung.Close()
+
+	start := time.Now()
+	results := CollectAllStats(context.Background(), []StatsTarget{
+		{Pod: "ok", Addr: ok.Addr().String()},
+		{Pod: "hung", Addr: hung.Addr().String()},
+	})
+	g.Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+
+	g.Expect(results).To(HaveLen(2))
+	g.Expect(results["ok"].Err).NotTo(HaveOccurred())
+	g.Expect(results["ok"].Stats).To(Equal(map[string]string{"evictions": "1"}))
+	g.Expect(results["ok"].Slabs).To(Equal(map[string]string{"active_slabs": "2"}))
+	g.Expect(results["hung"].Err).To(HaveOccurred())
+}
+
+func TestPodStats(t *testing.T) {
+	g := NewWithT(t)
+
+	stats := map[string]string{
+		"get_hits":       "75",
+		"get_misses":     "25",
+		"evictions":      "700",
+		"bytes":          "1024",
+		"limit_maxbytes": "4096",
+	}
+	slabs := map[string]string{
+		"active_slabs":   "3",
+		"total_malloced": "2048",
+	}
+	previous := &memcachedv1.MemcachedPodStats{Evictions: 100}
+
+	podStats := PodStats("memcached-0", stats, slabs, previous, 2*time.Minute)
+	g.Expect(podStats).To(Equal(memcachedv1.MemcachedPodStats{
+		Pod:                "memcached-0",
+		HitRatio:           "75.00",
+		Evictions:          700,
+		EvictionsPerMinute: 300,
+		UsedMemoryBytes:    1024,
+		LimitMaxBytes:      4096,
+		TotalMalloced:      2048,
+		ActiveSlabs:        3,
+	}))
+
+	// restarted replica
+	previous.Evictions = 1000
+	g.Expect(PodStats("memcached-0", stats, slabs, previous, 2*time.Minute).EvictionsPerMinute).To(BeZero())
+}
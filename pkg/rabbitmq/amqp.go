@@ -0,0 +1,243 @@
+package rabbitmq
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// AMQP 0-9-1 constants of the connection handshake
+const (
+	amqpFrameMethod = 1
+	amqpFrameEnd    = 0xCE
+
+	amqpClassConnection    = 10
+	amqpMethodStart        = 10
+	amqpMethodStartOk      = 11
+	amqpMethodTune         = 30
+	amqpMethodTuneOk       = 31
+	amqpMethodOpen         = 40
+	amqpMethodOpenOk       = 41
+	amqpMethodClose        = 50
+	amqpMethodCloseOk      = 51
+	amqpReplySuccess       = 200
+	amqpConnectTimeout     = 10 * time.Second
+	amqpMaxFramePayloadLen = 128 * 1024
+)
+
+var amqpProtocolHeader = []byte{'A', 'M', 'Q', 'P', 0, 0, 9, 1}
+
+// ConnectFunc - opens an AMQP connection to host and closes it again,
+// returns the broker error if the connection gets refused
+type ConnectFunc func(ctx context.Context, host Host, username string, password string, vhost string, tlsConfig *tls.Config) error
+
+// CheckConnection opens an AMQP 0-9-1 connection to host, using TLS if
+// tlsConfig is not nil, authenticates as username, opens the vhost and closes
+// the connection again. With an empty password and a client certificate in
+// tlsConfig it authenticates with the EXTERNAL mechanism instead, i.e. by the
+// client certificate. Errors of the broker, e.g. a refused login or vhost, are
+// returned with their reply text.
+func CheckConnection(
+	ctx context.Context,
+	host Host,
+	username string,
+	password string,
+	vhost string,
+	tlsConfig *tls.Config,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, amqpConnectTimeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(host.Host, host.Port)
+	var conn net.Conn
+	var err error
+	if tlsConfig != nil {
+		dialer := &tls.Dialer{Config: tlsConfig}
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	} else {
+		dialer := &net.Dialer{}
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("error connecting to %s: %w", addr, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	external := password == "" && tlsConfig != nil && len(tlsConfig.Certificates) > 0
+	err = amqpHandshake(bufio.NewReader(conn), conn, username, password, vhost, external)
+	if err != nil {
+		return fmt.Errorf("error opening AMQP connection to %s: %w", addr, err)
+	}
+	return nil
+}
+
+// amqpHandshake runs the connection handshake up to connection.open-ok and
+// closes the connection, authenticating with the EXTERNAL mechanism if
+// external is set and with PLAIN otherwise
+func amqpHandshake(r *bufio.Reader, w io.Writer, username string, password string, vhost string, external bool) error {
+	if _, err := w.Write(amqpProtocolHeader); err != nil {
+		return err
+	}
+
+	if _, err := readAMQPMethod(r, amqpMethodStart); err != nil {
+		return err
+	}
+
+	// announce authentication_failure_close to get a connection.close with the
+	// reason instead of a closed socket if the login gets refused
+	capabilities := amqpTable(map[string]bool{"authentication_failure_close": true})
+	props := &bytes.Buffer{}
+	writeShortStr(props, "capabilities")
+	props.WriteByte('F')
+	writeLongStr(props, capabilities)
+	writeShortStr(props, "product")
+	props.WriteByte('S')
+	writeLongStr(props, []byte("infra-operator"))
+
+	startOk := &bytes.Buffer{}
+	writeLongStr(startOk, props.Bytes())
+	if external {
+		writeShortStr(startOk, "EXTERNAL")
+		writeLongStr(startOk, nil)
+	} else {
+		writeShortStr(startOk, "PLAIN")
+		writeLongStr(startOk, []byte("\x00"+username+"\x00"+password))
+	}
+	writeShortStr(startOk, "en_US")
+	if err := writeAMQPMethod(w, amqpMethodStartOk, startOk.Bytes()); err != nil {
+		return err
+	}
+
+	tune, err := readAMQPMethod(r, amqpMethodTune)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("connection closed by the broker, login refused for user %s", username)
+		}
+		return err
+	}
+	if len(tune) < 8 {
+		return fmt.Errorf("malformed connection.tune")
+	}
+	// accept channel-max and frame-max of the broker, without heartbeats
+	tuneOk := &bytes.Buffer{}
+	tuneOk.Write(tune[0:6])
+	_ = binary.Write(tuneOk, binary.BigEndian, uint16(0))
+	if err := writeAMQPMethod(w, amqpMethodTuneOk, tuneOk.Bytes()); err != nil {
+		return err
+	}
+
+	open := &bytes.Buffer{}
+	writeShortStr(open, vhost)
+	writeShortStr(open, "")
+	open.WriteByte(0)
+	if err := writeAMQPMethod(w, amqpMethodOpen, open.Bytes()); err != nil {
+		return err
+	}
+	if _, err := readAMQPMethod(r, amqpMethodOpenOk); err != nil {
+		return err
+	}
+
+	closeArgs := &bytes.Buffer{}
+	_ = binary.Write(closeArgs, binary.BigEndian, uint16(amqpReplySuccess))
+	writeShortStr(closeArgs, "")
+	_ = binary.Write(closeArgs, binary.BigEndian, uint32(0))
+	return writeAMQPMethod(w, amqpMethodClose, closeArgs.Bytes())
+}
+
+// readAMQPMethod reads the next connection method frame and returns its
+// arguments if it is the expected method. A connection.close of the broker is
+// returned as error with its reply code and text.
+func readAMQPMethod(r *bufio.Reader, expected uint16) ([]byte, error) {
+	header := make([]byte, 7)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(header[3:7])
+	if size > amqpMaxFramePayloadLen {
+		return nil, fmt.Errorf("AMQP frame of %d bytes exceeds the maximum size", size)
+	}
+	payload := make([]byte, size+1)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	if payload[size] != amqpFrameEnd {
+		return nil, fmt.Errorf("malformed AMQP frame")
+	}
+	if header[0] != amqpFrameMethod || size < 4 {
+		return nil, fmt.Errorf("unexpected AMQP frame type %d", header[0])
+	}
+
+	class := binary.BigEndian.Uint16(payload[0:2])
+	method := binary.BigEndian.Uint16(payload[2:4])
+	args := payload[4:size]
+	if class != amqpClassConnection {
+		return nil, fmt.Errorf("unexpected AMQP method %d.%d", class, method)
+	}
+	if method == amqpMethodClose && method != expected {
+		if len(args) < 3 {
+			return nil, fmt.Errorf("connection closed by the broker")
+		}
+		code := binary.BigEndian.Uint16(args[0:2])
+		textLen := int(args[2])
+		text := ""
+		if len(args) >= 3+textLen {
+			text = string(args[3 : 3+textLen])
+		}
+		return nil, fmt.Errorf("connection closed by the broker: %d %s", code, text)
+	}
+	if method != expected {
+		return nil, fmt.Errorf("unexpected AMQP method %d.%d, expected %d.%d", class, method, amqpClassConnection, expected)
+	}
+
+	return args, nil
+}
+
+// writeAMQPMethod writes a connection method frame on channel 0
+func writeAMQPMethod(w io.Writer, method uint16, args []byte) error {
+	frame := &bytes.Buffer{}
+	frame.WriteByte(amqpFrameMethod)
+	_ = binary.Write(frame, binary.BigEndian, uint16(0))
+	_ = binary.Write(frame, binary.BigEndian, uint32(4+len(args)))
+	_ = binary.Write(frame, binary.BigEndian, uint16(amqpClassConnection))
+	_ = binary.Write(frame, binary.BigEndian, method)
+	frame.Write(args)
+	frame.WriteByte(amqpFrameEnd)
+
+	_, err := w.Write(frame.Bytes())
+	return err
+}
+
+// amqpTable encodes the boolean fields as AMQP field table content
+func amqpTable(fields map[string]bool) []byte {
+	table := &bytes.Buffer{}
+	for name, value := range fields {
+		writeShortStr(table, name)
+		table.WriteByte('t')
+		if value {
+			table.WriteByte(1)
+		} else {
+			table.WriteByte(0)
+		}
+	}
+	return table.Bytes()
+}
+
+func writeShortStr(b *bytes.Buffer, s string) {
+	b.WriteByte(byte(len(s)))
+	b.WriteString(s)
+}
+
+func writeLongStr(b *bytes.Buffer, s []byte) {
+	_ = binary.Write(b, binary.BigEndian, uint32(len(s)))
+	b.Write(s)
+}
@@ -0,0 +1,124 @@
+package rabbitmq
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+// serveAMQP runs the broker side of the connection handshake, accepting the
+// user guest with password secret on the vhost nova
+func serveAMQP(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	header := make([]byte, len(amqpProtocolHeader))
+	if _, err := io.ReadFull(r, header); err != nil || !bytes.Equal(header, amqpProtocolHeader) {
+		return
+	}
+	// version, server properties, mechanisms and locales
+	start := &bytes.Buffer{}
+	start.Write([]byte{0, 9})
+	writeLongStr(start, nil)
+	writeLongStr(start, []byte("PLAIN EXTERNAL"))
+	writeLongStr(start, []byte("en_US"))
+	_ = writeAMQPMethod(conn, amqpMethodStart, start.Bytes())
+
+	startOk, err := readAMQPMethod(r, amqpMethodStartOk)
+	if err != nil {
+		return
+	}
+	propsLen := binary.BigEndian.Uint32(startOk[0:4])
+	rest := startOk[4+propsLen:]
+	mechanism := string(rest[1 : 1+int(rest[0])])
+	rest = rest[1+int(rest[0]):]
+	response := string(rest[4 : 4+binary.BigEndian.Uint32(rest[0:4])])
+	// EXTERNAL logins are authenticated by the TLS client certificate
+	if mechanism != "EXTERNAL" && response != "\x00guest\x00secret" {
+		_ = writeAMQPMethod(conn, amqpMethodClose, closeArgs(403, "ACCESS_REFUSED - Login was refused"))
+		return
+	}
+
+	tune := &bytes.Buffer{}
+	_ = binary.Write(tune, binary.BigEndian, uint16(2047))
+	_ = binary.Write(tune, binary.BigEndian, uint32(131072))
+	_ = binary.Write(tune, binary.BigEndian, uint16(60))
+	_ = writeAMQPMethod(conn, amqpMethodTune, tune.Bytes())
+	if _, err := readAMQPMethod(r, amqpMethodTuneOk); err != nil {
+		return
+	}
+
+	open, err := readAMQPMethod(r, amqpMethodOpen)
+	if err != nil {
+		return
+	}
+	if vhost := string(open[1 : 1+int(open[0])]); vhost != "nova" {
+		_ = writeAMQPMethod(conn, amqpMethodClose, closeArgs(530, "NOT_ALLOWED - vhost "+vhost+" not found"))
+		return
+	}
+	_ = writeAMQPMethod(conn, amqpMethodOpenOk, []byte{0})
+
+	if _, err := readAMQPMethod(r, amqpMethodClose); err != nil {
+		return
+	}
+	_ = writeAMQPMethod(conn, amqpMethodCloseOk, nil)
+}
+
+func closeArgs(code uint16, text string) []byte {
+	args := &bytes.Buffer{}
+	_ = binary.Write(args, binary.BigEndian, code)
+	writeShortStr(args, text)
+	_ = binary.Write(args, binary.BigEndian, uint32(0))
+	return args.Bytes()
+}
+
+func TestCheckConnection(t *testing.T) {
+	g := NewWithT(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveAMQP(conn)
+		}
+	}()
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	g.Expect(err).ToNot(HaveOccurred())
+	broker := Host{Host: host, Port: port}
+	ctx := context.Background()
+
+	g.Expect(CheckConnection(ctx, broker, "guest", "secret", "nova", nil)).To(Succeed())
+
+	err = CheckConnection(ctx, broker, "guest", "wrong", "nova", nil)
+	g.Expect(err).To(MatchError(ContainSubstring("403 ACCESS_REFUSED - Login was refused")))
+
+	err = CheckConnection(ctx, broker, "guest", "secret", "glance", nil)
+	g.Expect(err).To(MatchError(ContainSubstring("530 NOT_ALLOWED - vhost glance not found")))
+
+	listener.Close()
+	err = CheckConnection(ctx, broker, "guest", "secret", "nova", nil)
+	g.Expect(err).To(MatchError(ContainSubstring("error connecting to")))
+}
+
+func TestAMQPHandshakeExternal(t *testing.T) {
+	g := NewWithT(t)
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go serveAMQP(server)
+
+	// without a password the broker authenticates the client certificate
+	g.Expect(amqpHandshake(bufio.NewReader(client), client, "guest", "", "nova", true)).To(Succeed())
+}
@@ -0,0 +1,44 @@
+package rabbitmq
+
+const (
+	// TransportURLSecretPrefix - prefix of the name of the Secret holding the transport URLs
+	TransportURLSecretPrefix = "rabbitmq-transport-url-"
+
+	// TransportURLKey - Secret key of the oslo.messaging transport URL
+	TransportURLKey = "transport_url"
+
+	// AMQPURLKey - Secret key of the AMQP URI, using the amqps scheme if TLS is enabled
+	AMQPURLKey = "amqp_url"
+
+	// NotificationTransportURLKey - Secret key of the oslo.messaging transport URL of the notifications
+	NotificationTransportURLKey = "notification_transport_url"
+
+	// NotificationAMQPURLKey - Secret key of the AMQP URI of the notifications
+	NotificationAMQPURLKey = "notification_amqp_url"
+
+	// QueuePrefixConfigKey - Secret key of the oslo.config snippet with the queue prefix options
+	QueuePrefixConfigKey = "queue_prefix.conf"
+
+	// UserSecretPrefix - prefix of the name of the Secret holding the credentials of the
+	// user created for a TransportURL
+	UserSecretPrefix = "rabbitmq-user-"
+
+	// LinkUserSecretPrefix - prefix of the name of the Secret holding the credentials of the
+	// user created for a MessagingLink
+	LinkUserSecretPrefix = "rabbitmq-link-user-"
+
+	// LinkUserPrefix - prefix of the name of the user created for a MessagingLink
+	LinkUserPrefix = "link-"
+
+	// AMQPPort - port of the AMQP listener without TLS
+	AMQPPort = 5672
+
+	// AMQPTLSPort - port of the AMQP listener with TLS
+	AMQPTLSPort = 5671
+
+	// ManagementPort - port of the management API without TLS
+	ManagementPort = 15672
+
+	// ManagementTLSPort - port of the management API with TLS
+	ManagementTLSPort = 15671
+)
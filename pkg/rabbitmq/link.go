@@ -0,0 +1,124 @@
+package rabbitmq
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	rabbitmqv1 "github.com/openstack-k8s-operators/infra-operator/apis/rabbitmq/v1beta1"
+)
+
+const (
+	// ShovelComponent - runtime parameter component of the dynamic shovels
+	ShovelComponent = "shovel"
+
+	// FederationUpstreamComponent - runtime parameter component of the federation upstreams
+	FederationUpstreamComponent = "federation-upstream"
+
+	// FederationPolicyPrefix - prefix of the name of the policy federating the destination
+	// of a MessagingLink
+	FederationPolicyPrefix = "federation-"
+
+	// LinkCACertFile - CA bundle the cluster-operator mounts into the pods of a Rabbitmq
+	// cluster with TLS, the links verify the remote cluster with it
+	LinkCACertFile = "/etc/rabbitmq-tls/ca.crt"
+)
+
+// ValidateLink returns an error unless exactly one of queue and exchange is
+// set on the source and destination of the link, a federation links either
+// queues or exchanges
+func ValidateLink(l *rabbitmqv1.MessagingLink) error {
+	for name, e := range map[string]rabbitmqv1.MessagingLinkEndpoint{
+		"source":      l.Spec.Source,
+		"destination": l.Spec.Destination,
+	} {
+		if (e.Queue == "") == (e.Exchange == "") {
+			return fmt.Errorf("exactly one of queue and exchange has to be set on the %s of the link", name)
+		}
+	}
+	if l.Spec.Type == rabbitmqv1.MessagingLinkTypeFederation &&
+		(l.Spec.Source.Queue == "") != (l.Spec.Destination.Queue == "") {
+		return fmt.Errorf("a federation links either queues or exchanges")
+	}
+	return nil
+}
+
+// LinkURI returns the AMQP URI a shovel or federation connects to the cluster
+// of the transport with. With TLS the peer gets verified against the CA of the
+// cluster running the link and the host name gets sent as SNI, so the remote
+// cluster has to use a certificate issued by the same CA.
+func LinkURI(t Transport) string {
+	uri := t.AMQPURL()
+	if !t.TLS {
+		return uri
+	}
+
+	query := url.Values{}
+	query.Set("cacertfile", LinkCACertFile)
+	query.Set("verify", "verify_peer")
+	if len(t.Hosts) > 0 {
+		query.Set("server_name_indication", t.Hosts[0].Host)
+	}
+	sep := "?"
+	if strings.Contains(uri, "?") {
+		sep = "&"
+	}
+	return uri + sep + query.Encode()
+}
+
+// ShovelDefinition returns the value of the dynamic shovel moving the messages
+// of the source of the link to its destination, connecting with the srcURI and
+// destURI AMQP URIs
+func ShovelDefinition(l *rabbitmqv1.MessagingLink, srcURI string, destURI string) map[string]interface{} {
+	value := map[string]interface{}{
+		"src-protocol":  "amqp091",
+		"src-uri":       srcURI,
+		"dest-protocol": "amqp091",
+		"dest-uri":      destURI,
+	}
+	if l.Spec.Source.Queue != "" {
+		value["src-queue"] = l.Spec.Source.Queue
+	} else {
+		value["src-exchange"] = l.Spec.Source.Exchange
+	}
+	if l.Spec.Destination.Queue != "" {
+		value["dest-queue"] = l.Spec.Destination.Queue
+	} else {
+		value["dest-exchange"] = l.Spec.Destination.Exchange
+	}
+
+	return value
+}
+
+// FederationUpstreamDefinition returns the value of the federation upstream
+// of the source of the link, connecting with the srcURI AMQP URI
+func FederationUpstreamDefinition(l *rabbitmqv1.MessagingLink, srcURI string) map[string]interface{} {
+	value := map[string]interface{}{
+		"uri": srcURI,
+	}
+	if l.Spec.Source.Queue != "" {
+		value["queue"] = l.Spec.Source.Queue
+	} else {
+		value["exchange"] = l.Spec.Source.Exchange
+	}
+
+	return value
+}
+
+// FederationPolicy returns the policy federating the queue or exchange of the
+// destination of the link from the upstream of the link
+func FederationPolicy(l *rabbitmqv1.MessagingLink) Policy {
+	pattern := "^" + regexp.QuoteMeta(l.Spec.Destination.Exchange) + "$"
+	applyTo := "exchanges"
+	if l.Spec.Destination.Queue != "" {
+		pattern = "^" + regexp.QuoteMeta(l.Spec.Destination.Queue) + "$"
+		applyTo = "queues"
+	}
+
+	return Policy{
+		Pattern:    pattern,
+		ApplyTo:    applyTo,
+		Definition: map[string]interface{}{"federation-upstream": l.Name},
+	}
+}
@@ -0,0 +1,261 @@
+package rabbitmq
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Permissions - configure/write/read permission regexes of a user on a vhost
+type Permissions struct {
+	Configure string `json:"configure"`
+	Write     string `json:"write"`
+	Read      string `json:"read"`
+}
+
+// Policy - a RabbitMQ policy applying the definition to the queues and/or
+// exchanges of a vhost matching the pattern
+type Policy struct {
+	Pattern string `json:"pattern"`
+	// ApplyTo - queues, exchanges or all
+	ApplyTo    string                 `json:"apply-to"`
+	Priority   int32                  `json:"priority"`
+	Definition map[string]interface{} `json:"definition"`
+}
+
+// Management - the RabbitMQ management API operations used by the operator
+type Management interface {
+	PutVhost(ctx context.Context, vhost string) error
+	DeleteVhost(ctx context.Context, vhost string) error
+	PutUser(ctx context.Context, user string, password string) error
+	PutPermissions(ctx context.Context, vhost string, user string, permissions Permissions) error
+	DeleteUser(ctx context.Context, user string) error
+	UserConnections(ctx context.Context, user string) (int, error)
+	PutPolicy(ctx context.Context, vhost string, name string, policy Policy) error
+	DeletePolicy(ctx context.Context, vhost string, name string) error
+	PutParameter(ctx context.Context, component string, vhost string, name string, value interface{}) error
+	DeleteParameter(ctx context.Context, component string, vhost string, name string) error
+}
+
+// APIError - error response of the management API
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Status     string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("rabbitmq management API %s %s: %s: %s", e.Method, e.Path, e.Status, e.Message)
+}
+
+// IsNotFound returns true if err is a 404 response of the management API
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// ManagementClient - client of the RabbitMQ management HTTP API
+type ManagementClient struct {
+	baseURL  string
+	username string
+	password string
+	client   *http.Client
+}
+
+// NewManagementClient returns a client of the management API at baseURL,
+// e.g. https://rabbitmq.openstack.svc:15671, authenticating as username.
+// tlsConfig is only used for https URLs and may be nil. A client gets created
+// per reconcile, so its connections are not kept alive for reuse.
+func NewManagementClient(baseURL string, username string, password string, tlsConfig *tls.Config) *ManagementClient {
+	return &ManagementClient{
+		baseURL:  baseURL,
+		username: username,
+		password: password,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig:   tlsConfig,
+				DisableKeepAlives: true,
+			},
+		},
+	}
+}
+
+// ManagementURL returns the base URL of the management API served on host,
+// the management plugin listens on 15671 with TLS and on 15672 otherwise
+func ManagementURL(host string, tlsEnabled bool) string {
+	if tlsEnabled {
+		return fmt.Sprintf("https://%s:%d", host, ManagementTLSPort)
+	}
+	return fmt.Sprintf("http://%s:%d", host, ManagementPort)
+}
+
+// PutVhost creates the vhost if it does not exist
+func (c *ManagementClient) PutVhost(ctx context.Context, vhost string) error {
+	return c.do(ctx, http.MethodPut, "/api/vhosts/"+url.PathEscape(vhost), map[string]string{})
+}
+
+// DeleteVhost deletes the vhost together with its queues, exchanges and
+// permissions, a vhost which does not exist is ignored
+func (c *ManagementClient) DeleteVhost(ctx context.Context, vhost string) error {
+	err := c.do(ctx, http.MethodDelete, "/api/vhosts/"+url.PathEscape(vhost), nil)
+	if IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// PutUser creates the user or updates its password
+func (c *ManagementClient) PutUser(ctx context.Context, user string, password string) error {
+	return c.do(ctx, http.MethodPut, "/api/users/"+url.PathEscape(user), map[string]string{
+		"password": password,
+		"tags":     "",
+	})
+}
+
+// PutPermissions sets the permissions of the user on the vhost
+func (c *ManagementClient) PutPermissions(ctx context.Context, vhost string, user string, permissions Permissions) error {
+	return c.do(ctx, http.MethodPut,
+		"/api/permissions/"+url.PathEscape(vhost)+"/"+url.PathEscape(user), permissions)
+}
+
+// DeleteUser deletes the user, a user which does not exist is ignored
+func (c *ManagementClient) DeleteUser(ctx context.Context, user string) error {
+	err := c.do(ctx, http.MethodDelete, "/api/users/"+url.PathEscape(user), nil)
+	if IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// UserConnections returns the number of client connections of the user
+func (c *ManagementClient) UserConnections(ctx context.Context, user string) (int, error) {
+	connections := []struct {
+		User string `json:"user"`
+	}{}
+	err := c.get(ctx, "/api/connections?columns=user", &connections)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, conn := range connections {
+		if conn.User == user {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// PutPolicy creates or updates the policy name on the vhost
+func (c *ManagementClient) PutPolicy(ctx context.Context, vhost string, name string, policy Policy) error {
+	return c.do(ctx, http.MethodPut,
+		"/api/policies/"+url.PathEscape(vhost)+"/"+url.PathEscape(name), policy)
+}
+
+// DeletePolicy deletes the policy name of the vhost, a policy which does not
+// exist is ignored
+func (c *ManagementClient) DeletePolicy(ctx context.Context, vhost string, name string) error {
+	err := c.do(ctx, http.MethodDelete,
+		"/api/policies/"+url.PathEscape(vhost)+"/"+url.PathEscape(name), nil)
+	if IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// PutParameter creates or updates the runtime parameter name of the
+// component, e.g. shovel or federation-upstream, on the vhost
+func (c *ManagementClient) PutParameter(ctx context.Context, component string, vhost string, name string, value interface{}) error {
+	return c.do(ctx, http.MethodPut,
+		"/api/parameters/"+url.PathEscape(component)+"/"+url.PathEscape(vhost)+"/"+url.PathEscape(name),
+		map[string]interface{}{"value": value})
+}
+
+// DeleteParameter deletes the runtime parameter name of the component on the
+// vhost, a parameter which does not exist is ignored
+func (c *ManagementClient) DeleteParameter(ctx context.Context, component string, vhost string, name string) error {
+	err := c.do(ctx, http.MethodDelete,
+		"/api/parameters/"+url.PathEscape(component)+"/"+url.PathEscape(vhost)+"/"+url.PathEscape(name), nil)
+	if IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// get sends a GET request and decodes the JSON response into out
+func (c *ManagementClient) get(ctx context.Context, path string, out interface{}) error {
+	resp, err := c.send(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// do sends the request with body encoded as JSON
+func (c *ManagementClient) do(ctx context.Context, method string, path string, body interface{}) error {
+	resp, err := c.send(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// send sends the request with body encoded as JSON and returns an APIError
+// including the response body if the API does not respond with 2xx
+func (c *ManagementClient) send(ctx context.Context, method string, path string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(c.username, c.password)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("rabbitmq management API %s %s: %w", method, path, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, &APIError{
+			Method:     method,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Message:    string(bytes.TrimSpace(msg)),
+		}
+	}
+
+	return resp, nil
+}
+
+// ManagementFactory - returns a Management client, allows replacing the
+// management API in tests
+type ManagementFactory func(baseURL string, username string, password string, tlsConfig *tls.Config) Management
+
+// DefaultManagementFactory - ManagementFactory returning a ManagementClient
+func DefaultManagementFactory(baseURL string, username string, password string, tlsConfig *tls.Config) Management {
+	return NewManagementClient(baseURL, username, password, tlsConfig)
+}
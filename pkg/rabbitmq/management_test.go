@@ -0,0 +1,131 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestManagementClient(t *testing.T) {
+	g := NewWithT(t)
+
+	requests := map[string]map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if !ok || user != "admin" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/connections":
+			_, _ = w.Write([]byte(`[{"user":"nova-api"},{"user":"nova-api-1a2b"},{"user":"nova-api"}]`))
+			return
+		case r.Method == http.MethodDelete && (r.URL.Path == "/api/users/gone" || r.URL.Path == "/api/vhosts/gone"):
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body := map[string]string{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		requests[r.Method+" "+r.URL.EscapedPath()] = body
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	c := NewManagementClient(server.URL, "admin", "secret", nil)
+	g.Expect(c.PutVhost(ctx, "nova")).To(Succeed())
+	g.Expect(c.PutUser(ctx, "nova-api", "12345678")).To(Succeed())
+	g.Expect(c.PutPermissions(ctx, "nova", "nova-api", Permissions{Configure: ".*", Write: ".*", Read: ".*"})).To(Succeed())
+
+	g.Expect(requests).To(HaveKey("PUT /api/vhosts/nova"))
+	g.Expect(requests).To(HaveKeyWithValue("PUT /api/users/nova-api",
+		map[string]string{"password": "12345678", "tags": ""}))
+	g.Expect(requests).To(HaveKeyWithValue("PUT /api/permissions/nova/nova-api",
+		map[string]string{"configure": ".*", "write": ".*", "read": ".*"}))
+
+	// the default vhost has to be escaped
+	g.Expect(c.PutVhost(ctx, "/")).To(Succeed())
+	g.Expect(requests).To(HaveKey("PUT /api/vhosts/%2F"))
+
+	g.Expect(c.UserConnections(ctx, "nova-api")).To(Equal(2))
+	g.Expect(c.UserConnections(ctx, "nova-conductor")).To(Equal(0))
+
+	g.Expect(c.DeleteUser(ctx, "nova-api-1a2b")).To(Succeed())
+	g.Expect(requests).To(HaveKey("DELETE /api/users/nova-api-1a2b"))
+	g.Expect(c.DeleteUser(ctx, "gone")).To(Succeed())
+
+	g.Expect(c.DeleteVhost(ctx, "nova")).To(Succeed())
+	g.Expect(requests).To(HaveKey("DELETE /api/vhosts/nova"))
+	g.Expect(c.DeleteVhost(ctx, "gone")).To(Succeed())
+
+	c = NewManagementClient(server.URL, "admin", "wrong", nil)
+	err := c.PutVhost(ctx, "nova")
+	g.Expect(err).To(MatchError(ContainSubstring("401 Unauthorized")))
+	g.Expect(IsNotFound(err)).To(BeFalse())
+}
+
+func TestManagementClientClosesConnections(t *testing.T) {
+	g := NewWithT(t)
+
+	closed := []bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		closed = append(closed, r.Close)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	c := NewManagementClient(server.URL, "admin", "secret", nil)
+	g.Expect(c.PutVhost(ctx, "nova")).To(Succeed())
+	g.Expect(c.DeleteVhost(ctx, "nova")).To(Succeed())
+
+	// no idle connections are left behind by the clients of past reconciles
+	g.Expect(closed).To(Equal([]bool{true, true}))
+}
+
+func TestManagementClientPolicyAndParameter(t *testing.T) {
+	g := NewWithT(t)
+
+	requests := map[string]map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete && r.URL.EscapedPath() == "/api/policies/nova/gone" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body := map[string]interface{}{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		requests[r.Method+" "+r.URL.EscapedPath()] = body
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	c := NewManagementClient(server.URL, "admin", "secret", nil)
+	g.Expect(c.PutPolicy(ctx, "/", "ttl", Policy{
+		Pattern:    "^notifications\\.",
+		ApplyTo:    "queues",
+		Priority:   1,
+		Definition: map[string]interface{}{"message-ttl": 60000},
+	})).To(Succeed())
+	g.Expect(requests).To(HaveKeyWithValue("PUT /api/policies/%2F/ttl", map[string]interface{}{
+		"pattern":    "^notifications\\.",
+		"apply-to":   "queues",
+		"priority":   float64(1),
+		"definition": map[string]interface{}{"message-ttl": float64(60000)},
+	}))
+
+	g.Expect(c.DeletePolicy(ctx, "/", "ttl")).To(Succeed())
+	g.Expect(requests).To(HaveKey("DELETE /api/policies/%2F/ttl"))
+	g.Expect(c.DeletePolicy(ctx, "nova", "gone")).To(Succeed())
+
+	g.Expect(c.PutParameter(ctx, "shovel", "nova", "edge", map[string]string{"src-queue": "q"})).To(Succeed())
+	g.Expect(requests).To(HaveKeyWithValue("PUT /api/parameters/shovel/nova/edge", map[string]interface{}{
+		"value": map[string]interface{}{"src-queue": "q"},
+	}))
+	g.Expect(c.DeleteParameter(ctx, "shovel", "nova", "edge")).To(Succeed())
+	g.Expect(requests).To(HaveKey("DELETE /api/parameters/shovel/nova/edge"))
+}
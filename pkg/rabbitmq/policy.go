@@ -0,0 +1,57 @@
+package rabbitmq
+
+import (
+	rabbitmqv1 "github.com/openstack-k8s-operators/infra-operator/apis/rabbitmq/v1beta1"
+)
+
+// MessagingPolicy returns the RabbitMQ policy declared by the MessagingPolicy
+func MessagingPolicy(p *rabbitmqv1.MessagingPolicy) Policy {
+	d := p.Spec.Definition
+	definition := map[string]interface{}{}
+
+	if d.MessageTTL != nil {
+		definition["message-ttl"] = *d.MessageTTL
+	}
+	if d.Expires != nil {
+		definition["expires"] = *d.Expires
+	}
+	if d.MaxLength != nil {
+		definition["max-length"] = *d.MaxLength
+	}
+	if d.MaxLengthBytes != nil {
+		definition["max-length-bytes"] = *d.MaxLengthBytes
+	}
+	if d.Overflow != "" {
+		definition["overflow"] = d.Overflow
+	}
+	if d.DeadLetterExchange != "" {
+		definition["dead-letter-exchange"] = d.DeadLetterExchange
+	}
+	if d.HAMode != "" {
+		definition["ha-mode"] = d.HAMode
+		if d.HAParams != nil {
+			definition["ha-params"] = *d.HAParams
+		}
+	}
+	if d.HASyncMode != "" {
+		definition["ha-sync-mode"] = d.HASyncMode
+	}
+	if d.DeliveryLimit != nil {
+		definition["delivery-limit"] = *d.DeliveryLimit
+	}
+	if d.QueueLeaderLocator != "" {
+		definition["queue-leader-locator"] = d.QueueLeaderLocator
+	}
+
+	applyTo := p.Spec.ApplyTo
+	if applyTo == "" {
+		applyTo = "queues"
+	}
+
+	return Policy{
+		Pattern:    p.Spec.Pattern,
+		ApplyTo:    applyTo,
+		Priority:   p.Spec.Priority,
+		Definition: definition,
+	}
+}
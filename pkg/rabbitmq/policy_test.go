@@ -0,0 +1,49 @@
+package rabbitmq
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	rabbitmqv1 "github.com/openstack-k8s-operators/infra-operator/apis/rabbitmq/v1beta1"
+)
+
+func TestMessagingPolicy(t *testing.T) {
+	g := NewWithT(t)
+
+	ttl := int64(60000)
+	maxLength := int64(1000)
+	replicas := int32(2)
+	p := &rabbitmqv1.MessagingPolicy{
+		Spec: rabbitmqv1.MessagingPolicySpec{
+			Pattern:  "^notifications\\.",
+			Priority: 1,
+			Definition: rabbitmqv1.MessagingPolicyDefinition{
+				MessageTTL: &ttl,
+				MaxLength:  &maxLength,
+				Overflow:   "reject-publish",
+				HAMode:     "exactly",
+				HAParams:   &replicas,
+			},
+		},
+	}
+
+	g.Expect(MessagingPolicy(p)).To(Equal(Policy{
+		Pattern:  "^notifications\\.",
+		ApplyTo:  "queues",
+		Priority: 1,
+		Definition: map[string]interface{}{
+			"message-ttl": int64(60000),
+			"max-length":  int64(1000),
+			"overflow":    "reject-publish",
+			"ha-mode":     "exactly",
+			"ha-params":   int32(2),
+		},
+	}))
+
+	// ha-params only applies together with ha-mode
+	p.Spec.Definition = rabbitmqv1.MessagingPolicyDefinition{HAParams: &replicas}
+	p.Spec.ApplyTo = "all"
+	policy := MessagingPolicy(p)
+	g.Expect(policy.ApplyTo).To(Equal("all"))
+	g.Expect(policy.Definition).To(BeEmpty())
+}
@@ -0,0 +1,195 @@
+package rabbitmq
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Host - address of a RabbitMQ broker
+type Host struct {
+	Host string
+	Port string
+}
+
+// Transport - connection details of the RabbitMQ brokers rendered into the
+// transport URLs published to the consumers
+type Transport struct {
+	Username string
+	Password string
+	// Hosts - the brokers, clients fail over between them in the given order
+	Hosts []Host
+	TLS   bool
+	// Vhost - the vhost, the default vhost "/" if empty
+	Vhost string
+	// Options - oslo.messaging options appended to the query of the URL
+	Options map[string]string
+	// Driver - oslo.messaging driver, the scheme of the URL, DriverRabbit if empty
+	Driver string
+	// ClientCert - client certificate the consumers connect with, requires TLS
+	ClientCert *ClientCert
+}
+
+// ClientCert - paths of the client certificate and key as mounted by the
+// consumers
+type ClientCert struct {
+	CertFile string
+	KeyFile  string
+	// External - authenticate with the EXTERNAL mechanism, i.e. by the client
+	// certificate, instead of username and password
+	External bool
+}
+
+const (
+	// DriverRabbit - the oslo.messaging RabbitMQ driver
+	DriverRabbit = "rabbit"
+	// DriverAMQP1 - the oslo.messaging AMQP 1.0 driver
+	DriverAMQP1 = "amqp1"
+)
+
+// driverSchemes - URL scheme of the oslo.messaging drivers
+var driverSchemes = map[string]string{
+	DriverRabbit: "rabbit",
+	DriverAMQP1:  "amqp",
+}
+
+// QuorumQueueOptions - oslo.messaging options to use durable quorum queues
+var QuorumQueueOptions = map[string]string{
+	"rabbit_quorum_queue":           "true",
+	"rabbit_transient_quorum_queue": "true",
+	"amqp_durable_queues":           "true",
+}
+
+// QueuePrefixConfig returns the oslo.config snippet prefixing the names the
+// consumers declare on the broker. These are not transport URL options, the
+// consumers have to add the snippet to their configuration. The rabbit driver
+// derives the exchanges from the [DEFAULT] control_exchange option, the amqp1
+// driver prefixes the addresses of its queues with the server request,
+// broadcast and group request prefixes of the [oslo_messaging_amqp] section.
+func QueuePrefixConfig(driver string, prefix string) string {
+	if driver == DriverAMQP1 {
+		return fmt.Sprintf("[oslo_messaging_amqp]\n"+
+			"server_request_prefix = %[1]s.exclusive\n"+
+			"broadcast_prefix = %[1]s.broadcast\n"+
+			"group_request_prefix = %[1]s.unicast\n", prefix)
+	}
+	return fmt.Sprintf("[DEFAULT]\ncontrol_exchange = %s\n", prefix)
+}
+
+// oslo.messaging rabbit driver options of the connection handling
+const (
+	// HeartbeatTimeoutThresholdOption - seconds without heartbeat after which the
+	// connection is considered dead, 0 disables the heartbeat
+	HeartbeatTimeoutThresholdOption = "heartbeat_timeout_threshold"
+	// RetryIntervalOption - seconds between retries of connecting
+	RetryIntervalOption = "rabbit_retry_interval"
+	// KombuReconnectDelayOption - seconds to wait before reconnecting in
+	// response to a consumer cancel notification
+	KombuReconnectDelayOption = "kombu_reconnect_delay"
+)
+
+// path returns the escaped vhost as URL path, empty for the default vhost
+func (t Transport) path() string {
+	if t.Vhost == "" || t.Vhost == "/" {
+		return ""
+	}
+	return url.PathEscape(t.Vhost)
+}
+
+// userinfo returns the escaped username and password of the URL
+func (t Transport) userinfo() string {
+	return url.UserPassword(t.Username, t.Password).String()
+}
+
+// URL returns the oslo.messaging transport URL, listing all hosts. The TLS
+// and authentication query parameters depend on the driver: the rabbit driver
+// takes ssl=0/1 and rabbit_login_method, the amqp1 driver takes the ssl and
+// sasl_mechanisms options of the [oslo_messaging_amqp] section. Both use the
+// AMQP port of the broker, RabbitMQ serves AMQP 1.0 on the same listener.
+func (t Transport) URL() string {
+	query := url.Values{}
+	for key, value := range t.Options {
+		query.Set(key, value)
+	}
+	if t.Driver == DriverAMQP1 {
+		t.amqp1TLSQuery(query)
+	} else {
+		t.rabbitTLSQuery(query)
+	}
+
+	hosts := []string{}
+	for _, h := range t.Hosts {
+		hosts = append(hosts, fmt.Sprintf("%s@%s:%s", t.userinfo(), h.Host, h.Port))
+	}
+	scheme, ok := driverSchemes[t.Driver]
+	if !ok {
+		scheme = driverSchemes[DriverRabbit]
+	}
+	transportURL := fmt.Sprintf("%s://%s/%s", scheme, strings.Join(hosts, ","), t.path())
+	if len(query) > 0 {
+		transportURL += "?" + query.Encode()
+	}
+	return transportURL
+}
+
+// rabbitTLSQuery sets the TLS options of the rabbit driver
+func (t Transport) rabbitTLSQuery(query url.Values) {
+	query.Set("ssl", "0")
+	if !t.TLS {
+		return
+	}
+	query.Set("ssl", "1")
+	if t.ClientCert != nil {
+		query.Set("ssl_cert_file", t.ClientCert.CertFile)
+		query.Set("ssl_key_file", t.ClientCert.KeyFile)
+		if t.ClientCert.External {
+			query.Set("rabbit_login_method", "EXTERNAL")
+		}
+	}
+}
+
+// amqp1TLSQuery sets the TLS options of the amqp1 driver, which is plain
+// text if ssl is not set
+func (t Transport) amqp1TLSQuery(query url.Values) {
+	if !t.TLS {
+		return
+	}
+	query.Set("ssl", "true")
+	if t.ClientCert != nil {
+		query.Set("ssl_cert_file", t.ClientCert.CertFile)
+		query.Set("ssl_key_file", t.ClientCert.KeyFile)
+		if t.ClientCert.External {
+			query.Set("sasl_mechanisms", "EXTERNAL")
+		}
+	}
+}
+
+// AMQPURL returns the AMQP URI of the transport for non oslo.messaging
+// clients, using the amqps scheme if TLS is enabled. AMQP URIs have a single
+// host, the first one is used. A client certificate is passed in the
+// certfile, keyfile and auth_mechanism query parameters.
+func (t Transport) AMQPURL() string {
+	scheme := "amqp"
+	if t.TLS {
+		scheme = "amqps"
+	}
+
+	query := url.Values{}
+	if t.TLS && t.ClientCert != nil {
+		query.Set("certfile", t.ClientCert.CertFile)
+		query.Set("keyfile", t.ClientCert.KeyFile)
+		if t.ClientCert.External {
+			query.Set("auth_mechanism", "external")
+		}
+	}
+
+	var host Host
+	if len(t.Hosts) > 0 {
+		host = t.Hosts[0]
+	}
+	amqpURL := fmt.Sprintf("%s://%s@%s:%s/%s", scheme, t.userinfo(), host.Host, host.Port, t.path())
+	if len(query) > 0 {
+		amqpURL += "?" + query.Encode()
+	}
+	return amqpURL
+}
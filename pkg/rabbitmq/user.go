@@ -0,0 +1,25 @@
+package rabbitmq
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// GeneratePassword returns a random password for a RabbitMQ user
+func GeneratePassword() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// RotatedUsername returns a new unique user name for the user name after a
+// credential rotation
+func RotatedUsername(name string) (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return name + "-" + hex.EncodeToString(b), nil
+}
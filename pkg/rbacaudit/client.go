@@ -0,0 +1,154 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbacaudit
+
+import (
+	"context"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// auditClient wraps a client.Client and records every request it makes
+type auditClient struct {
+	client.Client
+	controller string
+	recorder   *Recorder
+}
+
+// NewClient - returns a client.Client which records the requests of controller
+// in recorder before passing them to c
+func NewClient(c client.Client, controller string, recorder *Recorder) client.Client {
+	return &auditClient{
+		Client:     c,
+		controller: controller,
+		recorder:   recorder,
+	}
+}
+
+func (c *auditClient) record(obj runtime.Object, subResource string, verbs ...string) {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return
+	}
+	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+
+	mapping, err := c.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return
+	}
+	resource := mapping.Resource.Resource
+	if subResource != "" {
+		resource += "/" + subResource
+	}
+
+	for _, verb := range verbs {
+		c.recorder.Record(c.controller, Rule{
+			Group:    mapping.Resource.Group,
+			Resource: resource,
+			Verb:     verb,
+		})
+	}
+}
+
+// Get - reads are served from the informer cache, which requires list and watch
+func (c *auditClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	c.record(obj, "", "get", "list", "watch")
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+// List - reads are served from the informer cache, which requires list and watch
+func (c *auditClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	c.record(list, "", "list", "watch")
+	return c.Client.List(ctx, list, opts...)
+}
+
+// Create -
+func (c *auditClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	c.record(obj, "", "create")
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+// Delete -
+func (c *auditClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	c.record(obj, "", "delete")
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+// Update - an update of the finalizers also requires update on the finalizers subresource
+func (c *auditClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	c.record(obj, "", "update")
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+// Patch -
+func (c *auditClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	c.record(obj, "", "patch")
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+// DeleteAllOf -
+func (c *auditClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	c.record(obj, "", "deletecollection")
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
+
+// Status -
+func (c *auditClient) Status() client.SubResourceWriter {
+	return c.SubResource("status")
+}
+
+// SubResource -
+func (c *auditClient) SubResource(subResource string) client.SubResourceClient {
+	return &auditSubResourceClient{
+		SubResourceClient: c.Client.SubResource(subResource),
+		client:            c,
+		subResource:       subResource,
+	}
+}
+
+// auditSubResourceClient wraps a client.SubResourceClient and records every request it makes
+type auditSubResourceClient struct {
+	client.SubResourceClient
+	client      *auditClient
+	subResource string
+}
+
+// Get -
+func (c *auditSubResourceClient) Get(ctx context.Context, obj client.Object, subResource client.Object, opts ...client.SubResourceGetOption) error {
+	c.client.record(obj, c.subResource, "get")
+	return c.SubResourceClient.Get(ctx, obj, subResource, opts...)
+}
+
+// Create -
+func (c *auditSubResourceClient) Create(ctx context.Context, obj client.Object, subResource client.Object, opts ...client.SubResourceCreateOption) error {
+	c.client.record(obj, c.subResource, "create")
+	return c.SubResourceClient.Create(ctx, obj, subResource, opts...)
+}
+
+// Update -
+func (c *auditSubResourceClient) Update(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+	c.client.record(obj, c.subResource, "update")
+	return c.SubResourceClient.Update(ctx, obj, opts...)
+}
+
+// Patch -
+func (c *auditSubResourceClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+	c.client.record(obj, c.subResource, "patch")
+	return c.SubResourceClient.Patch(ctx, obj, patch, opts...)
+}
@@ -0,0 +1,82 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rbacaudit records the API verbs and resources the controllers use
+// and compares them to the RBAC rules granted to the operator, so that the
+// kubebuilder RBAC markers can be tightened to what is actually required.
+package rbacaudit
+
+import (
+	"sort"
+	"sync"
+)
+
+// Rule - a single verb on a resource of an API group
+type Rule struct {
+	Group    string `json:"group"`
+	Resource string `json:"resource"`
+	Verb     string `json:"verb"`
+}
+
+// Recorder collects the rules used per controller
+type Recorder struct {
+	mu   sync.Mutex
+	used map[string]map[Rule]struct{}
+}
+
+// NewRecorder - returns an empty Recorder
+func NewRecorder() *Recorder {
+	return &Recorder{
+		used: map[string]map[Rule]struct{}{},
+	}
+}
+
+// Record - records the use of rule by controller
+func (r *Recorder) Record(controller string, rule Rule) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.used[controller]; !ok {
+		r.used[controller] = map[Rule]struct{}{}
+	}
+	r.used[controller][rule] = struct{}{}
+}
+
+// Used - returns the sorted rules used per controller
+func (r *Recorder) Used() map[string][]Rule {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	used := map[string][]Rule{}
+	for controller, rules := range r.used {
+		for rule := range rules {
+			used[controller] = append(used[controller], rule)
+		}
+		sortRules(used[controller])
+	}
+	return used
+}
+
+func sortRules(rules []Rule) {
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Group != rules[j].Group {
+			return rules[i].Group < rules[j].Group
+		}
+		if rules[i].Resource != rules[j].Resource {
+			return rules[i].Resource < rules[j].Resource
+		}
+		return rules[i].Verb < rules[j].Verb
+	})
+}
@@ -0,0 +1,191 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbacaudit
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/go-logr/logr"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Report - result of the comparison of the used rules with the granted ones
+type Report struct {
+	// Timestamp when the report got generated
+	Timestamp time.Time `json:"timestamp"`
+	// ClusterRole the used rules got compared with
+	ClusterRole string `json:"clusterRole"`
+	// Used - rules used per controller
+	Used map[string][]Rule `json:"used"`
+	// Unused - granted rules no controller used so far, candidates for removal.
+	// Rules only required to be granted to other service accounts, like the
+	// use of a SecurityContextConstraints, show up here and need manual review.
+	Unused []Rule `json:"unused"`
+	// NotGranted - used rules not covered by the ClusterRole
+	NotGranted []Rule `json:"notGranted"`
+}
+
+// NewReport - compares the rules used per controller with the policy rules
+// granted by a ClusterRole
+func NewReport(clusterRole string, used map[string][]Rule, granted []rbacv1.PolicyRule) *Report {
+	report := &Report{
+		Timestamp:   time.Now().UTC(),
+		ClusterRole: clusterRole,
+		Used:        used,
+		Unused:      []Rule{},
+		NotGranted:  []Rule{},
+	}
+
+	usedSet := map[Rule]struct{}{}
+	for _, rules := range used {
+		for _, rule := range rules {
+			usedSet[rule] = struct{}{}
+		}
+	}
+
+	for rule := range usedSet {
+		if !isGranted(rule, granted) {
+			report.NotGranted = append(report.NotGranted, rule)
+		}
+	}
+
+	for _, policyRule := range granted {
+		// non resource URLs are not recorded
+		if len(policyRule.NonResourceURLs) > 0 {
+			continue
+		}
+		for _, group := range policyRule.APIGroups {
+			for _, resource := range policyRule.Resources {
+				for _, verb := range policyRule.Verbs {
+					rule := Rule{Group: group, Resource: resource, Verb: verb}
+					if !isUsed(rule, usedSet) {
+						report.Unused = append(report.Unused, rule)
+					}
+				}
+			}
+		}
+	}
+
+	sortRules(report.Unused)
+	sortRules(report.NotGranted)
+
+	return report
+}
+
+// isGranted - returns true if any of the policy rules allows rule
+func isGranted(rule Rule, granted []rbacv1.PolicyRule) bool {
+	for _, policyRule := range granted {
+		if matches(policyRule.APIGroups, rule.Group) &&
+			matches(policyRule.Resources, rule.Resource) &&
+			matches(policyRule.Verbs, rule.Verb) {
+			return true
+		}
+	}
+	return false
+}
+
+// isUsed - returns true if the granted rule, which might contain
+// wildcards, got used by any controller
+func isUsed(granted Rule, used map[Rule]struct{}) bool {
+	for rule := range used {
+		if matches([]string{granted.Group}, rule.Group) &&
+			matches([]string{granted.Resource}, rule.Resource) &&
+			matches([]string{granted.Verb}, rule.Verb) {
+			return true
+		}
+	}
+	return false
+}
+
+func matches(values []string, value string) bool {
+	for _, v := range values {
+		if v == rbacv1.ResourceAll || v == value {
+			return true
+		}
+	}
+	return false
+}
+
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=get
+
+// Reporter periodically writes the report of the recorded rules
+type Reporter struct {
+	// Recorder holding the rules used by the controllers
+	Recorder *Recorder
+	// Reader used to get the ClusterRole, should not be cache backed to
+	// not require list/watch on clusterroles
+	Reader client.Reader
+	// ClusterRole holding the rules granted to the operator
+	ClusterRole string
+	// Path of the file the report gets written to, when empty the report is only logged
+	Path string
+	// Interval between two reports
+	Interval time.Duration
+	// Log -
+	Log logr.Logger
+}
+
+// Start - implements manager.Runnable, reports on each interval and when
+// the manager gets stopped
+func (r *Reporter) Start(ctx context.Context) error {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// use a fresh context to write the final report
+			r.report(context.Background())
+			return nil
+		case <-ticker.C:
+			r.report(ctx)
+		}
+	}
+}
+
+func (r *Reporter) report(ctx context.Context) {
+	role := &rbacv1.ClusterRole{}
+	err := r.Reader.Get(ctx, types.NamespacedName{Name: r.ClusterRole}, role)
+	if err != nil {
+		r.Log.Error(err, "Unable to get ClusterRole for the RBAC audit report", "clusterrole", r.ClusterRole)
+		return
+	}
+
+	report := NewReport(r.ClusterRole, r.Recorder.Used(), role.Rules)
+	r.Log.Info("RBAC audit report",
+		"clusterrole", r.ClusterRole,
+		"unused", len(report.Unused),
+		"notGranted", report.NotGranted)
+
+	if r.Path == "" {
+		return
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		r.Log.Error(err, "Unable to marshal the RBAC audit report")
+		return
+	}
+	err = os.WriteFile(r.Path, data, 0644)
+	if err != nil {
+		r.Log.Error(err, "Unable to write the RBAC audit report", "path", r.Path)
+	}
+}
@@ -0,0 +1,63 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbacaudit
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestNewReport(t *testing.T) {
+	g := NewWithT(t)
+
+	rec := NewRecorder()
+	rec.Record("Memcached", Rule{Group: "", Resource: "configmaps", Verb: "get"})
+	rec.Record("Memcached", Rule{Group: "apps", Resource: "statefulsets", Verb: "patch"})
+	rec.Record("Redis", Rule{Group: "", Resource: "configmaps", Verb: "get"})
+	rec.Record("Redis", Rule{Group: "", Resource: "secrets", Verb: "create"})
+
+	granted := []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{""},
+			Resources: []string{"configmaps"},
+			Verbs:     []string{"get", "delete"},
+		},
+		{
+			APIGroups: []string{"apps"},
+			Resources: []string{"*"},
+			Verbs:     []string{"patch"},
+		},
+		{
+			NonResourceURLs: []string{"/metrics"},
+			Verbs:           []string{"get"},
+		},
+	}
+
+	report := NewReport("manager-role", rec.Used(), granted)
+
+	g.Expect(report.Used).To(HaveKeyWithValue("Memcached", []Rule{
+		{Group: "", Resource: "configmaps", Verb: "get"},
+		{Group: "apps", Resource: "statefulsets", Verb: "patch"},
+	}))
+	g.Expect(report.Unused).To(Equal([]Rule{
+		{Group: "", Resource: "configmaps", Verb: "delete"},
+	}))
+	g.Expect(report.NotGranted).To(Equal([]Rule{
+		{Group: "", Resource: "secrets", Verb: "create"},
+	}))
+}
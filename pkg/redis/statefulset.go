@@ -135,5 +135,18 @@ func StatefulSet(r *redisv1.Redis) *appsv1.StatefulSet {
 		},
 	}
 
+	if r.Spec.TopologySpread != nil {
+		// ScheduleAnyway to not block scheduling when domains are missing,
+		// the controller rebalances the replicas once they are back
+		sts.Spec.Template.Spec.TopologySpreadConstraints = []corev1.TopologySpreadConstraint{{
+			MaxSkew:           r.Spec.TopologySpread.MaxSkew,
+			TopologyKey:       r.Spec.TopologySpread.TopologyKey,
+			WhenUnsatisfiable: corev1.ScheduleAnyway,
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: matchls,
+			},
+		}}
+	}
+
 	return sts
 }
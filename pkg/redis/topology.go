@@ -0,0 +1,225 @@
+package redis
+
+import (
+	"sort"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+const (
+	// MasterLabel - pod label set on the current redis master
+	MasterLabel = "redis/master"
+)
+
+// TopologySkew returns the difference of the number of pods between the most
+// and the least loaded topology domain of the nodes the pods can be scheduled
+// to, as well as the pods placed in the most loaded domain
+func TopologySkew(pods []corev1.Pod, nodes []corev1.Node, podSpec *corev1.PodSpec, topologyKey string) (int32, []corev1.Pod) {
+	nodeDomain := map[string]string{}
+	domainPods := map[string][]corev1.Pod{}
+	for _, node := range nodes {
+		domain, ok := node.Labels[topologyKey]
+		if !ok {
+			continue
+		}
+		nodeDomain[node.Name] = domain
+		// domains without nodes the pods can be scheduled to can't receive replicas
+		if Schedulable(&node, podSpec) {
+			if _, ok := domainPods[domain]; !ok {
+				domainPods[domain] = []corev1.Pod{}
+			}
+		}
+	}
+
+	for _, pod := range pods {
+		domain, ok := nodeDomain[pod.Spec.NodeName]
+		if !ok {
+			continue
+		}
+		domainPods[domain] = append(domainPods[domain], pod)
+	}
+
+	if len(domainPods) == 0 {
+		return 0, nil
+	}
+
+	minPods, maxPods := -1, -1
+	var maxDomain string
+	for domain, p := range domainPods {
+		if minPods == -1 || len(p) < minPods {
+			minPods = len(p)
+		}
+		if maxPods == -1 || len(p) > maxPods || (len(p) == maxPods && domain < maxDomain) {
+			maxPods = len(p)
+			maxDomain = domain
+		}
+	}
+
+	return int32(maxPods - minPods), domainPods[maxDomain]
+}
+
+// RebalanceCandidate returns the pod to recreate to reduce the topology skew,
+// preferring replicas over the master. It returns nil if the skew is within
+// maxSkew or if only the master could be moved.
+func RebalanceCandidate(pods []corev1.Pod, nodes []corev1.Node, podSpec *corev1.PodSpec, topologyKey string, maxSkew int32) (int32, *corev1.Pod) {
+	skew, overloaded := TopologySkew(pods, nodes, podSpec, topologyKey)
+	if skew <= maxSkew {
+		return skew, nil
+	}
+
+	// pick the candidate deterministically
+	sort.Slice(overloaded, func(i, j int) bool {
+		return overloaded[i].Name > overloaded[j].Name
+	})
+	for i := range overloaded {
+		if overloaded[i].Labels[MasterLabel] != "true" {
+			return skew, &overloaded[i]
+		}
+	}
+
+	return skew, nil
+}
+
+// SchedulableDomains returns the sorted topology domains with nodes the pods
+// can be scheduled to
+func SchedulableDomains(nodes []corev1.Node, podSpec *corev1.PodSpec, topologyKey string) []string {
+	seen := map[string]bool{}
+	domains := []string{}
+	for i := range nodes {
+		domain, ok := nodes[i].Labels[topologyKey]
+		if !ok || seen[domain] || !Schedulable(&nodes[i], podSpec) {
+			continue
+		}
+		seen[domain] = true
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	return domains
+}
+
+// PodDomain returns the topology domain of the node the pod runs on
+func PodDomain(pod *corev1.Pod, nodes []corev1.Node, topologyKey string) string {
+	for i := range nodes {
+		if nodes[i].Name == pod.Spec.NodeName {
+			return nodes[i].Labels[topologyKey]
+		}
+	}
+
+	return ""
+}
+
+// Schedulable returns if a pod with the podSpec can be scheduled to the node:
+// the node is not cordoned, the pod tolerates its NoSchedule and NoExecute
+// taints and the node matches the nodeSelector and the required node affinity
+func Schedulable(node *corev1.Node, podSpec *corev1.PodSpec) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+	if podSpec == nil {
+		return true
+	}
+
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		tolerated := false
+		for j := range podSpec.Tolerations {
+			if podSpec.Tolerations[j].ToleratesTaint(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return false
+		}
+	}
+
+	if !labels.SelectorFromSet(podSpec.NodeSelector).Matches(labels.Set(node.Labels)) {
+		return false
+	}
+
+	if podSpec.Affinity == nil || podSpec.Affinity.NodeAffinity == nil ||
+		podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return true
+	}
+	// the terms are ORed
+	for _, term := range podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		if nodeSelectorTermMatches(node, term) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nodeSelectorTermMatches returns if the node matches all requirements of the term,
+// an empty term matches no node
+func nodeSelectorTermMatches(node *corev1.Node, term corev1.NodeSelectorTerm) bool {
+	if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+		return false
+	}
+	for _, req := range term.MatchExpressions {
+		if !nodeSelectorRequirementMatches(labels.Set(node.Labels), req) {
+			return false
+		}
+	}
+	for _, req := range term.MatchFields {
+		// metadata.name is the only supported field
+		if req.Key != "metadata.name" ||
+			!nodeSelectorRequirementMatches(labels.Set{req.Key: node.Name}, req) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// nodeSelectorRequirementMatches returns if the labels match the requirement
+func nodeSelectorRequirementMatches(set labels.Set, req corev1.NodeSelectorRequirement) bool {
+	var op selection.Operator
+	switch req.Operator {
+	case corev1.NodeSelectorOpIn:
+		op = selection.In
+	case corev1.NodeSelectorOpNotIn:
+		op = selection.NotIn
+	case corev1.NodeSelectorOpExists:
+		op = selection.Exists
+	case corev1.NodeSelectorOpDoesNotExist:
+		op = selection.DoesNotExist
+	case corev1.NodeSelectorOpGt, corev1.NodeSelectorOpLt:
+		if len(req.Values) != 1 {
+			return false
+		}
+		value, ok := set[req.Key]
+		if !ok {
+			return false
+		}
+		labelValue, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return false
+		}
+		reqValue, err := strconv.ParseInt(req.Values[0], 10, 64)
+		if err != nil {
+			return false
+		}
+		if req.Operator == corev1.NodeSelectorOpGt {
+			return labelValue > reqValue
+		}
+		return labelValue < reqValue
+	default:
+		return false
+	}
+
+	requirement, err := labels.NewRequirement(req.Key, op, req.Values)
+	if err != nil {
+		return false
+	}
+
+	return requirement.Matches(set)
+}
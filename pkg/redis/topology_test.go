@@ -0,0 +1,201 @@
+package redis
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const zoneKey = "topology.kubernetes.io/zone"
+
+func node(name string, zone string, unschedulable bool) corev1.Node {
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{zoneKey: zone},
+		},
+		Spec: corev1.NodeSpec{Unschedulable: unschedulable},
+	}
+}
+
+func tainted(n corev1.Node, effect corev1.TaintEffect) corev1.Node {
+	n.Spec.Taints = append(n.Spec.Taints, corev1.Taint{Key: "dedicated", Value: "infra", Effect: effect})
+	return n
+}
+
+func labeled(n corev1.Node, key string, value string) corev1.Node {
+	n.Labels[key] = value
+	return n
+}
+
+func pod(name string, nodeName string, master bool) corev1.Pod {
+	p := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{},
+		},
+		Spec: corev1.PodSpec{NodeName: nodeName},
+	}
+	if master {
+		p.Labels[MasterLabel] = "true"
+	}
+	return p
+}
+
+func TestRebalanceCandidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		pods      []corev1.Pod
+		nodes     []corev1.Node
+		podSpec   *corev1.PodSpec
+		skew      int32
+		candidate string
+	}{
+		{
+			name: "spread across zones",
+			pods: []corev1.Pod{
+				pod("redis-0", "a1", true),
+				pod("redis-1", "b1", false),
+				pod("redis-2", "c1", false),
+			},
+			nodes: []corev1.Node{node("a1", "a", false), node("b1", "b", false), node("c1", "c", false)},
+			skew:  0,
+		},
+		{
+			name: "new zone added",
+			pods: []corev1.Pod{
+				pod("redis-0", "a1", true),
+				pod("redis-1", "a1", false),
+				pod("redis-2", "b1", false),
+			},
+			nodes:     []corev1.Node{node("a1", "a", false), node("b1", "b", false), node("c1", "c", false)},
+			skew:      2,
+			candidate: "redis-1",
+		},
+		{
+			name: "unschedulable zone is ignored",
+			pods: []corev1.Pod{
+				pod("redis-0", "a1", true),
+				pod("redis-1", "a1", false),
+				pod("redis-2", "b1", false),
+			},
+			nodes: []corev1.Node{node("a1", "a", false), node("b1", "b", false), node("c1", "c", true)},
+			skew:  1,
+		},
+		{
+			name: "tainted zone is ignored",
+			pods: []corev1.Pod{
+				pod("redis-0", "a1", true),
+				pod("redis-1", "a1", false),
+				pod("redis-2", "b1", false),
+			},
+			nodes: []corev1.Node{
+				node("a1", "a", false),
+				node("b1", "b", false),
+				tainted(node("c1", "c", false), corev1.TaintEffectNoSchedule),
+			},
+			podSpec: &corev1.PodSpec{},
+			skew:    1,
+		},
+		{
+			name: "tolerated tainted zone is used",
+			pods: []corev1.Pod{
+				pod("redis-0", "a1", true),
+				pod("redis-1", "a1", false),
+				pod("redis-2", "b1", false),
+			},
+			nodes: []corev1.Node{
+				node("a1", "a", false),
+				node("b1", "b", false),
+				tainted(node("c1", "c", false), corev1.TaintEffectNoSchedule),
+			},
+			podSpec: &corev1.PodSpec{
+				Tolerations: []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}},
+			},
+			skew:      2,
+			candidate: "redis-1",
+		},
+		{
+			name: "zone not matching the nodeSelector is ignored",
+			pods: []corev1.Pod{
+				pod("redis-0", "a1", true),
+				pod("redis-1", "a1", false),
+				pod("redis-2", "b1", false),
+			},
+			nodes: []corev1.Node{
+				labeled(node("a1", "a", false), "redis", "true"),
+				labeled(node("b1", "b", false), "redis", "true"),
+				node("c1", "c", false),
+			},
+			podSpec: &corev1.PodSpec{
+				NodeSelector: map[string]string{"redis": "true"},
+			},
+			skew: 1,
+		},
+		{
+			name: "zone not matching the required node affinity is ignored",
+			pods: []corev1.Pod{
+				pod("redis-0", "a1", true),
+				pod("redis-1", "a1", false),
+				pod("redis-2", "b1", false),
+			},
+			nodes: []corev1.Node{node("a1", "a", false), node("b1", "b", false), node("c1", "c", false)},
+			podSpec: &corev1.PodSpec{
+				Affinity: &corev1.Affinity{
+					NodeAffinity: &corev1.NodeAffinity{
+						RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+							NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+								MatchExpressions: []corev1.NodeSelectorRequirement{{
+									Key:      zoneKey,
+									Operator: corev1.NodeSelectorOpNotIn,
+									Values:   []string{"c"},
+								}},
+							}},
+						},
+					},
+				},
+			},
+			skew: 1,
+		},
+		{
+			name: "only the master can be moved",
+			pods: []corev1.Pod{
+				pod("redis-0", "a1", true),
+				pod("redis-1", "a2", true),
+			},
+			nodes: []corev1.Node{node("a1", "a", false), node("a2", "a", false), node("b1", "b", false)},
+			skew:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			skew, candidate := RebalanceCandidate(tt.pods, tt.nodes, tt.podSpec, zoneKey, 1)
+			g.Expect(skew).To(Equal(tt.skew))
+			if tt.candidate == "" {
+				g.Expect(candidate).To(BeNil())
+			} else {
+				g.Expect(candidate).NotTo(BeNil())
+				g.Expect(candidate.Name).To(Equal(tt.candidate))
+			}
+		})
+	}
+}
+
+func TestSchedulableDomains(t *testing.T) {
+	g := NewWithT(t)
+
+	nodes := []corev1.Node{
+		node("c1", "c", false),
+		node("a1", "a", false),
+		node("a2", "a", false),
+		node("b1", "b", true),
+		tainted(node("d1", "d", false), corev1.TaintEffectNoExecute),
+		tainted(node("e1", "e", false), corev1.TaintEffectPreferNoSchedule),
+	}
+	g.Expect(SchedulableDomains(nodes, &corev1.PodSpec{}, zoneKey)).To(Equal([]string{"a", "c", "e"}))
+}
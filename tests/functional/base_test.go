@@ -158,6 +158,34 @@ func CreateTransportURL(name types.NamespacedName, spec map[string]interface{})
 	return th.CreateUnstructured(raw)
 }
 
+func CreateMessagingPolicy(name types.NamespacedName, spec map[string]interface{}) client.Object {
+	raw := map[string]interface{}{
+		"apiVersion": "rabbitmq.openstack.org/v1beta1",
+		"kind":       "MessagingPolicy",
+		"metadata": map[string]interface{}{
+			"name":      name.Name,
+			"namespace": name.Namespace,
+		},
+		"spec": spec,
+	}
+
+	return th.CreateUnstructured(raw)
+}
+
+func CreateMessagingLink(name types.NamespacedName, spec map[string]interface{}) client.Object {
+	raw := map[string]interface{}{
+		"apiVersion": "rabbitmq.openstack.org/v1beta1",
+		"kind":       "MessagingLink",
+		"metadata": map[string]interface{}{
+			"name":      name.Name,
+			"namespace": name.Namespace,
+		},
+		"spec": spec,
+	}
+
+	return th.CreateUnstructured(raw)
+}
+
 func CreateRabbitMQCluster(name types.NamespacedName, spec map[string]interface{}) client.Object {
 	raw := map[string]interface{}{
 		"apiVersion": "rabbitmq.com/v1beta1",
@@ -245,6 +273,24 @@ func CreateOrUpdateRabbitMQClusterSecret(name types.NamespacedName, mq *rabbitmq
 			secretData["port"] = []byte("5671")
 		}
 
+		// the CA is used to verify the management API of the cluster
+		if mq.Spec.TLS.CaSecretName != "" {
+			caSecret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      mq.Spec.TLS.CaSecretName,
+					Namespace: name.Namespace,
+				},
+			}
+			_, err := controllerutil.CreateOrPatch(
+				th.Ctx, th.K8sClient, caSecret, func() error {
+					if len(caSecret.Data["ca.crt"]) == 0 {
+						caSecret.Data = map[string][]byte{"ca.crt": generateCACert()}
+					}
+					return nil
+				})
+			g.Expect(err).ShouldNot(HaveOccurred())
+		}
+
 		_, err := controllerutil.CreateOrPatch(
 			th.Ctx, th.K8sClient, secret, func() error {
 				secret.Data = secretData
@@ -333,6 +379,13 @@ func SimulateRabbitMQClusterReady(name types.NamespacedName) {
 	th.Logger.Info("Simulated RabbitMQCluster ready", "on", name)
 }
 
+// GetTransportURLUser returns the user name and password of the RabbitMQ
+// user created for the TransportURL name
+func GetTransportURLUser(name types.NamespacedName) (string, string) {
+	secret := th.GetSecret(types.NamespacedName{Name: "rabbitmq-user-" + name.Name, Namespace: name.Namespace})
+	return string(secret.Data["username"]), string(secret.Data["password"])
+}
+
 func GetDNSMasq(name types.NamespacedName) *networkv1.DNSMasq {
 	instance := &networkv1.DNSMasq{}
 	Eventually(func(g Gomega) {
@@ -373,6 +426,34 @@ func GetReservation(name types.NamespacedName) *networkv1.Reservation {
 	return instance
 }
 
+// CreateStaleReservation - creates a Reservation for an IPSet which does not exist
+func CreateStaleReservation(namespace string, address string) *networkv1.Reservation {
+	name := uuid.New().String()
+
+	res := &networkv1.Reservation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: networkv1.ReservationSpec{
+			IPSetRef: corev1.ObjectReference{
+				Namespace: namespace,
+				Name:      name,
+			},
+			Reservation: map[string]networkv1.IPAddress{
+				net1: {
+					Network: net1,
+					Subnet:  subnet1,
+					Address: address,
+				},
+			},
+		},
+	}
+	Expect(k8sClient.Create(ctx, res)).Should(Succeed())
+
+	return res
+}
+
 func GetRabbitMQCluster(name types.NamespacedName) *rabbitmqclusterv1.RabbitmqCluster {
 	mq := &rabbitmqclusterv1.RabbitmqCluster{}
 	Eventually(func(g Gomega) {
@@ -396,6 +477,21 @@ func IPSetConditionGetter(name types.NamespacedName) condition.Conditions {
 	return instance.Status.Conditions
 }
 
+func NetConfigConditionGetter(name types.NamespacedName) condition.Conditions {
+	instance := GetNetConfig(name)
+	return instance.Status.Conditions
+}
+
+func MessagingPolicyConditionGetter(name types.NamespacedName) condition.Conditions {
+	instance := infra.GetMessagingPolicy(name)
+	return instance.Status.Conditions
+}
+
+func MessagingLinkConditionGetter(name types.NamespacedName) condition.Conditions {
+	instance := infra.GetMessagingLink(name)
+	return instance.Status.Conditions
+}
+
 func TransportURLConditionGetter(name types.NamespacedName) condition.Conditions {
 	instance := infra.GetTransportURL(name)
 	return instance.Status.Conditions
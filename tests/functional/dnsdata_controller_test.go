@@ -20,8 +20,11 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
 
@@ -100,4 +103,317 @@ var _ = Describe("DNSData controller", func() {
 			})
 		})
 	})
+
+	When("A DNSData with a wildcard hostname is created", func() {
+		BeforeEach(func() {
+			spec := GetDefaultDNSDataSpec()
+			spec["hosts"] = interface{}([]networkv1.DNSHost{
+				{
+					Hostnames: []string{"*.apps.example.com", "ingress.example.com"},
+					IP:        "172.20.0.90",
+				},
+			})
+			instance := CreateDNSData(namespace, spec)
+			dnsDataName = types.NamespacedName{
+				Name:      instance.GetName(),
+				Namespace: namespace,
+			}
+
+			DeferCleanup(th.DeleteInstance, instance)
+		})
+
+		It("renders the wildcard as address directive", func() {
+			th.ExpectCondition(
+				dnsDataName,
+				ConditionGetterFunc(DNSDataConditionGetter),
+				condition.ServiceConfigReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			configData := th.GetConfigMap(dnsDataName)
+			Expect(configData.Data[dnsDataName.Name]).To(Equal("172.20.0.90 ingress.example.com\n"))
+			Expect(configData.Data["dnsmasq.conf"]).To(Equal("address=/apps.example.com/172.20.0.90\n"))
+		})
+	})
+
+	When("A DNSData with PTR records is created", func() {
+		BeforeEach(func() {
+			spec := GetDefaultDNSDataSpec()
+			spec["ptrRecords"] = true
+			spec["hosts"] = interface{}([]networkv1.DNSHost{
+				{
+					Hostnames: []string{"keystone-internal.openstack.svc", "keystone.openstack.svc"},
+					IP:        "172.20.0.80",
+				},
+				{
+					Hostnames: []string{"glance-internal.openstack.svc"},
+					IP:        "172.20.0.81",
+				},
+			})
+			instance := CreateDNSData(namespace, spec)
+			dnsDataName = types.NamespacedName{
+				Name:      instance.GetName(),
+				Namespace: namespace,
+			}
+
+			DeferCleanup(th.DeleteInstance, instance)
+		})
+
+		It("renders the reverse records of the addresses with multiple hostnames", func() {
+			th.ExpectCondition(
+				dnsDataName,
+				ConditionGetterFunc(DNSDataConditionGetter),
+				condition.ServiceConfigReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			configData := th.GetConfigMap(dnsDataName)
+			Expect(configData.Data["dnsmasq.conf"]).To(
+				Equal("ptr-record=80.0.20.172.in-addr.arpa,keystone-internal.openstack.svc\n" +
+					"ptr-record=80.0.20.172.in-addr.arpa,keystone.openstack.svc\n"))
+		})
+	})
+
+	When("A DNSData with SRV records is created", func() {
+		BeforeEach(func() {
+			spec := GetDefaultDNSDataSpec()
+			spec["srvRecords"] = []interface{}{
+				map[string]interface{}{
+					"service": "ldap",
+					"domain":  "example.com",
+					"target":  "ldap1.example.com",
+					"port":    389,
+				},
+			}
+			instance := CreateDNSData(namespace, spec)
+			dnsDataName = types.NamespacedName{
+				Name:      instance.GetName(),
+				Namespace: namespace,
+			}
+
+			DeferCleanup(th.DeleteInstance, instance)
+		})
+
+		It("renders the SRV records", func() {
+			th.ExpectCondition(
+				dnsDataName,
+				ConditionGetterFunc(DNSDataConditionGetter),
+				condition.ServiceConfigReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			configData := th.GetConfigMap(dnsDataName)
+			Expect(configData.Data["dnsmasq.conf"]).To(
+				Equal("srv-host=_ldap._tcp.example.com,ldap1.example.com,389,0,0\n"))
+		})
+	})
+
+	When("A DNSData with CNAME records is created", func() {
+		BeforeEach(func() {
+			spec := GetDefaultDNSDataSpec()
+			spec["cnameRecords"] = []interface{}{
+				map[string]interface{}{
+					"aliases": []string{"identity.example.com"},
+					"target":  "host1",
+				},
+			}
+			instance := CreateDNSData(namespace, spec)
+			dnsDataName = types.NamespacedName{
+				Name:      instance.GetName(),
+				Namespace: namespace,
+			}
+
+			DeferCleanup(th.DeleteInstance, instance)
+		})
+
+		It("renders the CNAME records", func() {
+			th.ExpectCondition(
+				dnsDataName,
+				ConditionGetterFunc(DNSDataConditionGetter),
+				condition.ServiceConfigReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			configData := th.GetConfigMap(dnsDataName)
+			Expect(configData.Data["dnsmasq.conf"]).To(Equal("cname=identity.example.com,host1\n"))
+		})
+	})
+
+	When("A DNSData with a split-horizon view is created", func() {
+		BeforeEach(func() {
+			spec := GetDefaultDNSDataSpec()
+			spec["views"] = []interface{}{
+				map[string]interface{}{
+					"network": "internalapi",
+					"hosts": []interface{}{
+						map[string]interface{}{
+							"ip":        "172.17.0.80",
+							"hostnames": []string{host1},
+						},
+					},
+				},
+			}
+			instance := CreateDNSData(namespace, spec)
+			dnsDataName = types.NamespacedName{
+				Name:      instance.GetName(),
+				Namespace: namespace,
+			}
+
+			DeferCleanup(th.DeleteInstance, instance)
+		})
+
+		It("renders the hosts of the view and localises the queries", func() {
+			th.ExpectCondition(
+				dnsDataName,
+				ConditionGetterFunc(DNSDataConditionGetter),
+				condition.ServiceConfigReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			configData := th.GetConfigMap(dnsDataName)
+			Expect(configData.Data[dnsDataName.Name]).To(Equal(
+				"host-ip-1 host1\nhost-ip-2 host2 host3\n172.17.0.80 host1\n"))
+			Expect(configData.Data["dnsmasq.conf"]).To(Equal("localise-queries\n"))
+		})
+	})
+
+	When("A DNSData with host entries owned by IPSets is created", func() {
+		var ipSetName types.NamespacedName
+
+		BeforeEach(func() {
+			netCfg := CreateNetConfig(namespace, GetDefaultNetConfigSpec())
+			DeferCleanup(th.DeleteInstance, netCfg)
+
+			ipSet := CreateIPSet(namespace, GetDefaultIPSetSpec())
+			ipSetName = types.NamespacedName{
+				Name:      ipSet.GetName(),
+				Namespace: namespace,
+			}
+			DeferCleanup(th.DeleteInstance, ipSet)
+
+			spec := GetDefaultDNSDataSpec()
+			spec["hosts"] = []interface{}{
+				map[string]interface{}{
+					"ip":        "172.17.0.100",
+					"hostnames": []string{host1},
+					"owner": map[string]interface{}{
+						"kind": "IPSet",
+						"name": ipSetName.Name,
+					},
+				},
+				map[string]interface{}{
+					"ip":        "172.17.0.101",
+					"hostnames": []string{"host2"},
+					"owner": map[string]interface{}{
+						"kind": "IPSet",
+						"name": "missing",
+					},
+				},
+			}
+			instance := CreateDNSData(namespace, spec)
+			dnsDataName = types.NamespacedName{
+				Name:      instance.GetName(),
+				Namespace: namespace,
+			}
+
+			DeferCleanup(th.DeleteInstance, instance)
+		})
+
+		It("drops the entries of the missing owners", func() {
+			th.ExpectCondition(
+				dnsDataName,
+				ConditionGetterFunc(DNSDataConditionGetter),
+				condition.ServiceConfigReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			configData := th.GetConfigMap(dnsDataName)
+			Expect(configData.Data[dnsDataName.Name]).To(Equal("172.17.0.100 host1\n"))
+			Expect(GetDNSData(dnsDataName).Status.StaleHosts).To(
+				Equal([]string{"172.17.0.101 (IPSet/missing)"}))
+		})
+
+		It("drops the entries of an owner when it gets deleted", func() {
+			Eventually(func(g Gomega) {
+				configData := th.GetConfigMap(dnsDataName)
+				g.Expect(configData.Data[dnsDataName.Name]).To(Equal("172.17.0.100 host1\n"))
+			}, timeout, interval).Should(Succeed())
+
+			th.DeleteInstance(GetIPSet(ipSetName))
+
+			Eventually(func(g Gomega) {
+				configData := th.GetConfigMap(dnsDataName)
+				g.Expect(configData.Data[dnsDataName.Name]).To(BeEmpty())
+				g.Expect(GetDNSData(dnsDataName).Status.StaleHosts).To(ConsistOf(
+					"172.17.0.100 (IPSet/"+ipSetName.Name+")",
+					"172.17.0.101 (IPSet/missing)"))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("keeps the entries of an owner while it is being deleted", func() {
+			Eventually(func(g Gomega) {
+				configData := th.GetConfigMap(dnsDataName)
+				g.Expect(configData.Data[dnsDataName.Name]).To(Equal("172.17.0.100 host1\n"))
+			}, timeout, interval).Should(Succeed())
+
+			// block the deletion of the IPSet like a consumer still using it
+			Eventually(func(g Gomega) {
+				ipSet := GetIPSet(ipSetName)
+				ipSet.Finalizers = append(ipSet.Finalizers, "test/blocker")
+				g.Expect(th.K8sClient.Update(ctx, ipSet)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+			DeferCleanup(func() {
+				Eventually(func(g Gomega) {
+					ipSet := &networkv1.IPSet{}
+					err := th.K8sClient.Get(ctx, ipSetName, ipSet)
+					if k8s_errors.IsNotFound(err) {
+						return
+					}
+					g.Expect(err).NotTo(HaveOccurred())
+					controllerutil.RemoveFinalizer(ipSet, "test/blocker")
+					g.Expect(th.K8sClient.Update(ctx, ipSet)).To(Succeed())
+				}, timeout, interval).Should(Succeed())
+			})
+			Expect(th.K8sClient.Delete(ctx, GetIPSet(ipSetName))).To(Succeed())
+
+			Eventually(func(g Gomega) {
+				g.Expect(GetIPSet(ipSetName).DeletionTimestamp).NotTo(BeNil())
+			}, timeout, interval).Should(Succeed())
+			Consistently(func(g Gomega) {
+				configData := th.GetConfigMap(dnsDataName)
+				g.Expect(configData.Data[dnsDataName.Name]).To(Equal("172.17.0.100 host1\n"))
+			}, timeout/2, interval).Should(Succeed())
+		})
+	})
+
+	When("A DNSData published via ExternalDNS is created", func() {
+		BeforeEach(func() {
+			spec := GetDefaultDNSDataSpec()
+			spec["externalDNS"] = map[string]interface{}{
+				"recordTTL": 300,
+			}
+			instance := CreateDNSData(namespace, spec)
+			dnsDataName = types.NamespacedName{
+				Name:      instance.GetName(),
+				Namespace: namespace,
+			}
+
+			DeferCleanup(th.DeleteInstance, instance)
+		})
+
+		It("reports the missing DNSEndpoint CRD", func() {
+			th.ExpectCondition(
+				dnsDataName,
+				ConditionGetterFunc(DNSDataConditionGetter),
+				networkv1.ExternalDNSReadyCondition,
+				corev1.ConditionFalse,
+			)
+			th.ExpectCondition(
+				dnsDataName,
+				ConditionGetterFunc(DNSDataConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionFalse,
+			)
+		})
+	})
 })
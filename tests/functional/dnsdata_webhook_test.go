@@ -0,0 +1,80 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package functional_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+)
+
+var _ = Describe("DNSData webhook", func() {
+	createDNSData := func(name string, spec map[string]interface{}) error {
+		raw := map[string]interface{}{
+			"apiVersion": "network.openstack.org/v1beta1",
+			"kind":       "DNSData",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+			},
+			"spec": spec,
+		}
+
+		unstructuredObj := &unstructured.Unstructured{Object: raw}
+		_, err := controllerutil.CreateOrPatch(
+			th.Ctx, th.K8sClient, unstructuredObj, func() error { return nil })
+		if err == nil {
+			DeferCleanup(th.DeleteInstance, unstructuredObj)
+		}
+		return err
+	}
+
+	getSpec := func(ip string) map[string]interface{} {
+		spec := GetDefaultDNSDataSpec()
+		spec["hosts"] = interface{}([]networkv1.DNSHost{
+			{
+				Hostnames: []string{"keystone.example.com"},
+				IP:        ip,
+			},
+		})
+		return spec
+	}
+
+	BeforeEach(func() {
+		Expect(createDNSData("dnsdata-webhook", getSpec("172.17.0.80"))).To(Succeed())
+	})
+
+	It("accepts a hostname with the same IP in another DNSData", func() {
+		Expect(createDNSData("dnsdata-webhook-same-ip", getSpec("172.17.0.80"))).To(Succeed())
+	})
+
+	It("accepts a conflicting hostname for another DNSMasq", func() {
+		spec := getSpec("172.17.0.90")
+		spec["dnsDataLabelSelectorValue"] = "otherselector"
+		Expect(createDNSData("dnsdata-webhook-other-selector", spec)).To(Succeed())
+	})
+
+	It("rejects a hostname resolving to a different IP in another DNSData", func() {
+		err := createDNSData("dnsdata-webhook-conflict", getSpec("172.17.0.90"))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(
+			"hostname already resolves to 172.17.0.80 in DNSData dnsdata-webhook"))
+	})
+})
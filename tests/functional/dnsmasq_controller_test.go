@@ -22,8 +22,15 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
 	"github.com/openstack-k8s-operators/lib-common/modules/common/util"
@@ -130,6 +137,8 @@ var _ = Describe("DNSMasq controller", func() {
 				ContainSubstring("server=1.1.1.1"))
 			Expect(configData.Data[dnsMasqName.Name]).Should(
 				ContainSubstring("no-negcache\n"))
+			Expect(configData.Data[dnsMasqName.Name]).Should(
+				ContainSubstring("cache-size=10000\n"))
 			Expect(configData.Labels["dnsmasq.openstack.org/name"]).To(Equal(dnsMasqName.Name))
 		})
 
@@ -152,6 +161,12 @@ var _ = Describe("DNSMasq controller", func() {
 				Namespace: namespace,
 				Name:      fmt.Sprintf("dnsmasq-%s", dnsMasqName.Name)})
 			Expect(svc.Labels["service"]).To(Equal("dnsmasq"))
+			Expect(svc.Spec.Type).To(Equal(corev1.ServiceTypeLoadBalancer))
+			Expect(svc.Annotations).To(HaveKeyWithValue("metallb.universe.tf/address-pool", "ctlplane"))
+			Expect(svc.Spec.Ports).To(HaveLen(2))
+			Expect(svc.Spec.Ports[0].Protocol).To(Equal(corev1.ProtocolUDP))
+			Expect(svc.Spec.Ports[1].Protocol).To(Equal(corev1.ProtocolTCP))
+			Expect(*svc.Spec.IPFamilyPolicy).To(Equal(corev1.IPFamilyPolicyPreferDualStack))
 		})
 
 		It("creates a Deployment for the service", func() {
@@ -174,37 +189,62 @@ var _ = Describe("DNSMasq controller", func() {
 				depl := th.GetDeployment(deploymentName)
 
 				g.Expect(int(*depl.Spec.Replicas)).To(Equal(1))
-				g.Expect(depl.Spec.Template.Spec.Volumes).To(HaveLen(3))
-				g.Expect(depl.Spec.Template.Spec.Containers).To(HaveLen(1))
+				g.Expect(depl.Spec.Template.Spec.Volumes).To(HaveLen(2))
+				g.Expect(depl.Spec.Template.Spec.Containers).To(HaveLen(2))
 				g.Expect(depl.Spec.Template.Spec.InitContainers).To(HaveLen(1))
 				g.Expect(depl.Spec.Selector.MatchLabels).To(Equal(map[string]string{"service": "dnsmasq"}))
+				g.Expect(*depl.Spec.Template.Spec.ShareProcessNamespace).To(BeTrue())
+				g.Expect(depl.Spec.Template.Spec.Containers[1].Name).To(Equal("dnsmasq-reload"))
 
 				container := depl.Spec.Template.Spec.Containers[0]
 				g.Expect(container.VolumeMounts).To(HaveLen(3))
 				g.Expect(container.Image).To(Equal(containerImage))
+				g.Expect(container.Args[1]).To(ContainSubstring("--log-queries --log-async=25"))
+				g.Expect(container.Args[1]).To(ContainSubstring("--listen-address=$(POD_IPS)"))
+				g.Expect(container.Env).To(ContainElement(HaveField("Name", "POD_IPS")))
 
 				g.Expect(container.LivenessProbe.TCPSocket.Port.IntVal).To(Equal(int32(53)))
 				g.Expect(container.ReadinessProbe.TCPSocket.Port.IntVal).To(Equal(int32(53)))
 			}, timeout, interval).Should(Succeed())
 		})
 
+		It("does not create a PodDisruptionBudget for a single replica", func() {
+			th.ExpectCondition(
+				dnsMasqName,
+				ConditionGetterFunc(DNSMasqConditionGetter),
+				condition.ExposeServiceReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			Consistently(func(g Gomega) {
+				pdb := &policyv1.PodDisruptionBudget{}
+				err := th.K8sClient.Get(ctx, types.NamespacedName{
+					Name:      fmt.Sprintf("dnsmasq-%s", dnsMasqName.Name),
+					Namespace: namespace,
+				}, pdb)
+				g.Expect(k8s_errors.IsNotFound(err)).To(BeTrue())
+			}, timeout, interval).Should(Succeed())
+		})
+
 		When("the DNSData CM gets updated", func() {
-			It("the CONFIG_HASH on the deployment changes", func() {
+			It("updates the hosts ConfigMap without changing the CONFIG_HASH", func() {
 				cm := th.GetConfigMap(dnsDataCM)
 				configHash := ""
 				deploymentName = types.NamespacedName{
 					Name:      fmt.Sprintf("dnsmasq-%s", dnsMasqName.Name),
 					Namespace: namespace,
 				}
+				hostsCMName := types.NamespacedName{
+					Name:      fmt.Sprintf("dnsmasq-%s-hosts", dnsMasqName.Name),
+					Namespace: namespace,
+				}
 				Eventually(func(g Gomega) {
-					depl := th.GetDeployment(deploymentName)
-					g.Expect(depl.Spec.Template.Spec.Volumes).To(
-						ContainElement(HaveField("Name", Equal(dnsDataCM.Name))))
+					hostsCM := th.GetConfigMap(hostsCMName)
+					g.Expect(hostsCM.Data).To(HaveKeyWithValue(
+						dnsDataCM.Name, "172.20.0.80 keystone-internal.openstack.svc"))
 
+					depl := th.GetDeployment(deploymentName)
 					container := depl.Spec.Template.Spec.Containers[0]
-					g.Expect(container.VolumeMounts).To(
-						ContainElement(HaveField("Name", Equal(dnsDataCM.Name))))
-
 					configHash = GetEnvVarValue(container.Env, "CONFIG_HASH", "")
 					g.Expect(configHash).To(Not(Equal("")))
 				}, timeout, interval).Should(Succeed())
@@ -214,42 +254,60 @@ var _ = Describe("DNSMasq controller", func() {
 				th.K8sClient.Update(ctx, cm)
 
 				Eventually(func(g Gomega) {
+					hostsCM := th.GetConfigMap(hostsCMName)
+					g.Expect(hostsCM.Data).To(HaveKeyWithValue(
+						dnsDataCM.Name, "172.20.0.80 keystone-internal.openstack.svc some-other-node"))
+				}, timeout, interval).Should(Succeed())
+
+				Consistently(func(g Gomega) {
 					depl := th.GetDeployment(deploymentName)
 					container := depl.Spec.Template.Spec.Containers[0]
-					newConfigHash := GetEnvVarValue(container.Env, "CONFIG_HASH", "")
-					g.Expect(newConfigHash).To(Not(Equal("")))
-					g.Expect(newConfigHash).To(Not(Equal(configHash)))
+					g.Expect(GetEnvVarValue(container.Env, "CONFIG_HASH", "")).To(Equal(configHash))
+				}, timeout, interval).Should(Succeed())
+			})
+
+			It("reports the records and bumps the config serial", func() {
+				var serial int64
+				var hostsHash string
+				Eventually(func(g Gomega) {
+					instance := GetDNSMasq(dnsMasqName)
+					g.Expect(instance.Status.RecordCount).To(Equal(int32(1)))
+					g.Expect(instance.Status.HostsHash).ToNot(BeEmpty())
+					g.Expect(instance.Status.ConfigSerial).To(BeNumerically(">", 0))
+					serial = instance.Status.ConfigSerial
+					hostsHash = instance.Status.HostsHash
+				}, timeout, interval).Should(Succeed())
+
+				cm := th.GetConfigMap(dnsDataCM)
+				cm.Data[dnsDataCM.Name] = "172.20.0.80 keystone-internal.openstack.svc some-other-node"
+				Expect(th.K8sClient.Update(ctx, cm)).To(Succeed())
+
+				Eventually(func(g Gomega) {
+					instance := GetDNSMasq(dnsMasqName)
+					g.Expect(instance.Status.RecordCount).To(Equal(int32(2)))
+					g.Expect(instance.Status.HostsHash).ToNot(Equal(hostsHash))
+					g.Expect(instance.Status.ConfigSerial).To(Equal(serial + 1))
 				}, timeout, interval).Should(Succeed())
 			})
 		})
 
 		When("the DNSData CM gets deleted", func() {
-			It("the ConfigMap gets removed from the deployment", func() {
+			It("the hosts get removed from the hosts ConfigMap", func() {
 				th.GetConfigMap(dnsDataCM)
-				deploymentName = types.NamespacedName{
-					Name:      fmt.Sprintf("dnsmasq-%s", dnsMasqName.Name),
+				hostsCMName := types.NamespacedName{
+					Name:      fmt.Sprintf("dnsmasq-%s-hosts", dnsMasqName.Name),
 					Namespace: namespace,
 				}
 				Eventually(func(g Gomega) {
-					depl := th.GetDeployment(deploymentName)
-					g.Expect(depl.Spec.Template.Spec.Volumes).To(
-						ContainElement(HaveField("Name", Equal(dnsDataCM.Name))))
-
-					container := depl.Spec.Template.Spec.Containers[0]
-					g.Expect(container.VolumeMounts).To(
-						ContainElement(HaveField("Name", Equal(dnsDataCM.Name))))
+					hostsCM := th.GetConfigMap(hostsCMName)
+					g.Expect(hostsCM.Data).To(HaveKey(dnsDataCM.Name))
 				}, timeout, interval).Should(Succeed())
 
 				// Delete the cm providing dnsdata
 				th.DeleteConfigMap(dnsDataCM)
 				Eventually(func(g Gomega) {
-					depl := th.GetDeployment(deploymentName)
-					g.Expect(depl.Spec.Template.Spec.Volumes).NotTo(
-						ContainElement(HaveField("Name", Equal(dnsDataCM.Name))))
-
-					container := depl.Spec.Template.Spec.Containers[0]
-					g.Expect(container.VolumeMounts).NotTo(
-						ContainElement(HaveField("Name", Equal(dnsDataCM.Name))))
+					hostsCM := th.GetConfigMap(hostsCMName)
+					g.Expect(hostsCM.Data).NotTo(HaveKey(dnsDataCM.Name))
 				}, timeout, interval).Should(Succeed())
 			})
 		})
@@ -270,5 +328,642 @@ var _ = Describe("DNSMasq controller", func() {
 				}, timeout, interval).Should(BeEmpty())
 			})
 		})
+
+		It("keeps a metrics Service it does not own", func() {
+			svc := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("dnsmasq-%s-metrics", dnsMasqName.Name),
+					Namespace: namespace,
+				},
+				Spec: corev1.ServiceSpec{
+					Ports: []corev1.ServicePort{{Name: "metrics", Port: 9153}},
+				},
+			}
+			Expect(th.K8sClient.Create(ctx, svc)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(client.IgnoreNotFound(th.K8sClient.Delete(ctx, svc))).To(Succeed())
+			})
+
+			th.ExpectCondition(
+				dnsMasqName,
+				ConditionGetterFunc(DNSMasqConditionGetter),
+				condition.ServiceConfigReadyCondition,
+				corev1.ConditionTrue,
+			)
+			Consistently(func(g Gomega) {
+				g.Expect(th.K8sClient.Get(ctx, client.ObjectKeyFromObject(svc), &corev1.Service{})).To(Succeed())
+			}, timeout/2, interval).Should(Succeed())
+		})
+
+		It("keeps a PodDisruptionBudget it does not own", func() {
+			minAvailable := intstr.FromInt(1)
+			pdb := &policyv1.PodDisruptionBudget{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("dnsmasq-%s", dnsMasqName.Name),
+					Namespace: namespace,
+				},
+				Spec: policyv1.PodDisruptionBudgetSpec{
+					MinAvailable: &minAvailable,
+				},
+			}
+			Expect(th.K8sClient.Create(ctx, pdb)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(client.IgnoreNotFound(th.K8sClient.Delete(ctx, pdb))).To(Succeed())
+			})
+
+			th.ExpectCondition(
+				dnsMasqName,
+				ConditionGetterFunc(DNSMasqConditionGetter),
+				condition.ExposeServiceReadyCondition,
+				corev1.ConditionTrue,
+			)
+			Consistently(func(g Gomega) {
+				g.Expect(th.K8sClient.Get(ctx, client.ObjectKeyFromObject(pdb), &policyv1.PodDisruptionBudget{})).To(Succeed())
+			}, timeout/2, interval).Should(Succeed())
+		})
+
+		It("keeps a NetworkPolicy it does not own", func() {
+			np := &networkingv1.NetworkPolicy{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("dnsmasq-%s", dnsMasqName.Name),
+					Namespace: namespace,
+				},
+				Spec: networkingv1.NetworkPolicySpec{
+					PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+				},
+			}
+			Expect(th.K8sClient.Create(ctx, np)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(client.IgnoreNotFound(th.K8sClient.Delete(ctx, np))).To(Succeed())
+			})
+
+			th.ExpectCondition(
+				dnsMasqName,
+				ConditionGetterFunc(DNSMasqConditionGetter),
+				condition.ExposeServiceReadyCondition,
+				corev1.ConditionTrue,
+			)
+			Consistently(func(g Gomega) {
+				g.Expect(th.K8sClient.Get(ctx, client.ObjectKeyFromObject(np), &networkingv1.NetworkPolicy{})).To(Succeed())
+			}, timeout/2, interval).Should(Succeed())
+		})
+	})
+
+	When("A DNSMasq with DNS-over-TLS upstreams is created", func() {
+		var caBundleSecretName types.NamespacedName
+
+		BeforeEach(func() {
+			caBundleSecretName = types.NamespacedName{
+				Namespace: namespace,
+				Name:      "combined-ca-bundle",
+			}
+
+			spec := GetDefaultDNSMasqSpec()
+			spec["upstreamTLS"] = map[string]interface{}{
+				"containerImage":     "test-unbound-container-image",
+				"caBundleSecretName": caBundleSecretName.Name,
+				"servers": []interface{}{
+					map[string]interface{}{
+						"address":  "1.1.1.1",
+						"authName": "cloudflare-dns.com",
+					},
+				},
+			}
+			instance := CreateDNSMasq(namespace, spec)
+			dnsMasqName = types.NamespacedName{
+				Name:      instance.GetName(),
+				Namespace: namespace,
+			}
+			deploymentName = types.NamespacedName{
+				Name:      fmt.Sprintf("dnsmasq-%s", dnsMasqName.Name),
+				Namespace: namespace,
+			}
+			DeferCleanup(th.DeleteInstance, instance)
+		})
+
+		It("waits for the CA bundle secret", func() {
+			th.ExpectConditionWithDetails(
+				dnsMasqName,
+				ConditionGetterFunc(DNSMasqConditionGetter),
+				condition.InputReadyCondition,
+				corev1.ConditionFalse,
+				condition.RequestedReason,
+				condition.InputReadyWaitingMessage,
+			)
+		})
+
+		When("the CA bundle secret exists", func() {
+			BeforeEach(func() {
+				DeferCleanup(k8sClient.Delete, ctx, th.CreateSecret(
+					caBundleSecretName,
+					map[string][]byte{"tls-ca-bundle.pem": []byte("CA")},
+				))
+			})
+
+			It("renders the unbound config", func() {
+				th.ExpectCondition(
+					dnsMasqName,
+					ConditionGetterFunc(DNSMasqConditionGetter),
+					condition.ServiceConfigReadyCondition,
+					corev1.ConditionTrue,
+				)
+
+				configData := th.GetConfigMap(dnsMasqName)
+				Expect(configData.Data["unbound.conf"]).Should(
+					ContainSubstring("forward-addr: 1.1.1.1@853#cloudflare-dns.com\n"))
+				Expect(configData.Data["unbound.conf"]).Should(
+					ContainSubstring("tls-cert-bundle: /etc/pki/ca-trust/extracted/pem/tls-ca-bundle.pem\n"))
+			})
+
+			It("adds the unbound sidecar to the Deployment", func() {
+				Eventually(func(g Gomega) {
+					depl := th.GetDeployment(deploymentName)
+
+					g.Expect(depl.Spec.Template.Spec.Containers).To(HaveLen(3))
+					g.Expect(depl.Spec.Template.Spec.Containers[0].Args[1]).To(
+						ContainSubstring("--server=127.0.0.1#5353"))
+
+					sidecar := depl.Spec.Template.Spec.Containers[2]
+					g.Expect(sidecar.Image).To(Equal("test-unbound-container-image"))
+					g.Expect(sidecar.VolumeMounts).To(HaveLen(2))
+					g.Expect(sidecar.VolumeMounts[1].Name).To(Equal("combined-ca-bundle"))
+				}, timeout, interval).Should(Succeed())
+			})
+
+			It("rolls the Deployment when the CA bundle gets rotated", func() {
+				configHash := ""
+				Eventually(func(g Gomega) {
+					depl := th.GetDeployment(deploymentName)
+					configHash = GetEnvVarValue(depl.Spec.Template.Spec.Containers[0].Env, "CONFIG_HASH", "")
+					g.Expect(configHash).ToNot(BeEmpty())
+				}, timeout, interval).Should(Succeed())
+
+				th.UpdateSecret(caBundleSecretName, "tls-ca-bundle.pem", []byte("rotated CA"))
+
+				Eventually(func(g Gomega) {
+					depl := th.GetDeployment(deploymentName)
+					g.Expect(GetEnvVarValue(depl.Spec.Template.Spec.Containers[0].Env, "CONFIG_HASH", "")).ToNot(Equal(configHash))
+				}, timeout, interval).Should(Succeed())
+			})
+		})
+	})
+
+	When("A DNSMasq with DNSSEC validation is created", func() {
+		BeforeEach(func() {
+			spec := GetDefaultDNSMasqSpec()
+			spec["dnssec"] = map[string]interface{}{}
+			instance := CreateDNSMasq(namespace, spec)
+			dnsMasqName = types.NamespacedName{
+				Name:      instance.GetName(),
+				Namespace: namespace,
+			}
+			DeferCleanup(th.DeleteInstance, instance)
+		})
+
+		It("renders the DNSSEC options", func() {
+			th.ExpectCondition(
+				dnsMasqName,
+				ConditionGetterFunc(DNSMasqConditionGetter),
+				networkv1.DNSSECReadyCondition,
+				corev1.ConditionTrue,
+			)
+			th.ExpectCondition(
+				dnsMasqName,
+				ConditionGetterFunc(DNSMasqConditionGetter),
+				condition.ServiceConfigReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			configData := th.GetConfigMap(dnsMasqName)
+			Expect(configData.Data[dnsMasqName.Name]).Should(
+				ContainSubstring("dnssec\nconf-file=/usr/share/dnsmasq/trust-anchors.conf\ndnssec-check-unsigned\n"))
+		})
+
+		It("reports an invalid trust anchor", func() {
+			Eventually(func(g Gomega) {
+				instance := GetDNSMasq(dnsMasqName)
+				instance.Spec.DNSSEC.TrustAnchors = []string{"example.com,1,8,2"}
+				g.Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			th.ExpectCondition(
+				dnsMasqName,
+				ConditionGetterFunc(DNSMasqConditionGetter),
+				networkv1.DNSSECReadyCondition,
+				corev1.ConditionFalse,
+			)
+		})
+	})
+
+	When("A DNSMasq with metrics is created", func() {
+		BeforeEach(func() {
+			spec := GetDefaultDNSMasqSpec()
+			spec["metrics"] = map[string]interface{}{
+				"containerImage": "test-exporter-container-image",
+			}
+			instance := CreateDNSMasq(namespace, spec)
+			dnsMasqName = types.NamespacedName{
+				Name:      instance.GetName(),
+				Namespace: namespace,
+			}
+			deploymentName = types.NamespacedName{
+				Name:      fmt.Sprintf("dnsmasq-%s", dnsMasqName.Name),
+				Namespace: namespace,
+			}
+			DeferCleanup(th.DeleteInstance, instance)
+		})
+
+		It("adds the exporter sidecar to the Deployment", func() {
+			Eventually(func(g Gomega) {
+				depl := th.GetDeployment(deploymentName)
+
+				g.Expect(depl.Spec.Template.Spec.Containers).To(HaveLen(3))
+				exporter := depl.Spec.Template.Spec.Containers[2]
+				g.Expect(exporter.Image).To(Equal("test-exporter-container-image"))
+				g.Expect(exporter.Args).To(ContainElement("--dnsmasq=127.0.0.1:53"))
+				g.Expect(exporter.Ports).To(HaveLen(1))
+				g.Expect(exporter.Ports[0].Name).To(Equal("metrics"))
+				g.Expect(exporter.Ports[0].ContainerPort).To(Equal(int32(9153)))
+				// dnsmasq has to answer the exporter on loopback
+				g.Expect(depl.Spec.Template.Spec.Containers[0].Args[1]).To(ContainSubstring("--listen-address=127.0.0.1"))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("creates the metrics Service selecting the pods of the instance", func() {
+			Eventually(func(g Gomega) {
+				instance := GetDNSMasq(dnsMasqName)
+				svc := th.GetService(types.NamespacedName{
+					Name:      fmt.Sprintf("dnsmasq-%s-metrics", dnsMasqName.Name),
+					Namespace: namespace,
+				})
+				g.Expect(svc.Spec.Selector).To(Equal(map[string]string{
+					"dnsmasq.openstack.org/uid": string(instance.UID),
+				}))
+				g.Expect(svc.Spec.Ports).To(HaveLen(1))
+				g.Expect(svc.Spec.Ports[0].Name).To(Equal("metrics"))
+				g.Expect(svc.Spec.Ports[0].Port).To(Equal(int32(9153)))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("skips the ServiceMonitor without the monitoring CRDs", func() {
+			th.ExpectCondition(
+				dnsMasqName,
+				ConditionGetterFunc(DNSMasqConditionGetter),
+				condition.ExposeServiceReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+
+		When("the metrics get disabled", func() {
+			It("deletes the metrics Service", func() {
+				metricsSvcName := types.NamespacedName{
+					Name:      fmt.Sprintf("dnsmasq-%s-metrics", dnsMasqName.Name),
+					Namespace: namespace,
+				}
+				Eventually(func(g Gomega) {
+					g.Expect(th.K8sClient.Get(ctx, metricsSvcName, &corev1.Service{})).To(Succeed())
+				}, timeout, interval).Should(Succeed())
+
+				Eventually(func(g Gomega) {
+					dnsmasq := GetDNSMasq(dnsMasqName)
+					dnsmasq.Spec.Metrics = nil
+					g.Expect(th.K8sClient.Update(ctx, dnsmasq)).To(Succeed())
+				}, timeout, interval).Should(Succeed())
+
+				Eventually(func(g Gomega) {
+					err := th.K8sClient.Get(ctx, metricsSvcName, &corev1.Service{})
+					g.Expect(k8s_errors.IsNotFound(err)).To(BeTrue())
+				}, timeout, interval).Should(Succeed())
+			})
+		})
+	})
+
+	When("A DNSMasq with network attachments is created", func() {
+		var nadName types.NamespacedName
+		BeforeEach(func() {
+			nadName = types.NamespacedName{Name: "internalapi", Namespace: namespace}
+			spec := GetDefaultDNSMasqSpec()
+			spec["networkAttachments"] = []string{nadName.Name}
+			instance := CreateDNSMasq(namespace, spec)
+			dnsMasqName = types.NamespacedName{
+				Name:      instance.GetName(),
+				Namespace: namespace,
+			}
+			deploymentName = types.NamespacedName{
+				Name:      fmt.Sprintf("dnsmasq-%s", dnsMasqName.Name),
+				Namespace: namespace,
+			}
+			DeferCleanup(th.DeleteInstance, instance)
+		})
+
+		It("waits for the network attachment definition", func() {
+			th.ExpectConditionWithDetails(
+				dnsMasqName,
+				ConditionGetterFunc(DNSMasqConditionGetter),
+				condition.NetworkAttachmentsReadyCondition,
+				corev1.ConditionFalse,
+				condition.RequestedReason,
+				"NetworkAttachment resources missing: internalapi",
+			)
+		})
+
+		When("the network attachment definition exists", func() {
+			BeforeEach(func() {
+				DeferCleanup(th.DeleteInstance, th.CreateNetworkAttachmentDefinition(nadName))
+			})
+
+			It("attaches the pods and listens on the network interface", func() {
+				Eventually(func(g Gomega) {
+					depl := th.GetDeployment(deploymentName)
+
+					g.Expect(depl.Spec.Template.Annotations).To(HaveKeyWithValue(
+						"k8s.v1.cni.cncf.io/networks",
+						fmt.Sprintf(`[{"name":"internalapi","namespace":"%s","interface":"internalapi"}]`, namespace)))
+					g.Expect(depl.Spec.Template.Spec.Containers[0].Args[1]).To(ContainSubstring("--interface=internalapi"))
+					g.Expect(depl.Spec.Template.Spec.Containers[0].Args[1]).ToNot(ContainSubstring("--interface=*"))
+				}, timeout, interval).Should(Succeed())
+			})
+
+			It("reports the network attachments in the Status", func() {
+				th.SimulateDeploymentReadyWithPods(
+					deploymentName,
+					map[string][]string{namespace + "/internalapi": {"10.0.0.10"}},
+				)
+
+				th.ExpectCondition(
+					dnsMasqName,
+					ConditionGetterFunc(DNSMasqConditionGetter),
+					condition.NetworkAttachmentsReadyCondition,
+					corev1.ConditionTrue,
+				)
+				Expect(GetDNSMasq(dnsMasqName).Status.NetworkAttachments).To(Equal(
+					map[string][]string{namespace + "/internalapi": {"10.0.0.10"}}))
+			})
+		})
+	})
+
+	When("A DNSMasq with query logging disabled is created", func() {
+		BeforeEach(func() {
+			spec := GetDefaultDNSMasqSpec()
+			spec["debug"] = map[string]interface{}{
+				"queryLogging": false,
+			}
+			instance := CreateDNSMasq(namespace, spec)
+			dnsMasqName = types.NamespacedName{
+				Name:      instance.GetName(),
+				Namespace: namespace,
+			}
+			deploymentName = types.NamespacedName{
+				Name:      fmt.Sprintf("dnsmasq-%s", dnsMasqName.Name),
+				Namespace: namespace,
+			}
+			DeferCleanup(th.DeleteInstance, instance)
+		})
+
+		It("does not log the queries", func() {
+			Eventually(func(g Gomega) {
+				depl := th.GetDeployment(deploymentName)
+
+				container := depl.Spec.Template.Spec.Containers[0]
+				g.Expect(container.Args[1]).To(ContainSubstring("--log-facility=-"))
+				g.Expect(container.Args[1]).ToNot(ContainSubstring("--log-queries"))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("A DNSMasq authoritative for a local zone is created", func() {
+		BeforeEach(func() {
+			spec := GetDefaultDNSMasqSpec()
+			spec["authoritative"] = map[string]interface{}{
+				"server": "ns1.example.com",
+				"zones": []map[string]interface{}{
+					{
+						"domain":  "ctlplane.example.com",
+						"subnets": []string{"192.168.122.0/24"},
+					},
+				},
+			}
+			instance := CreateDNSMasq(namespace, spec)
+			dnsMasqName = types.NamespacedName{
+				Name:      instance.GetName(),
+				Namespace: namespace,
+			}
+			DeferCleanup(th.DeleteInstance, instance)
+		})
+
+		It("renders the authoritative zone options", func() {
+			th.ExpectCondition(
+				dnsMasqName,
+				ConditionGetterFunc(DNSMasqConditionGetter),
+				condition.ServiceConfigReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			configData := th.GetConfigMap(dnsMasqName)
+			Expect(configData.Data[dnsMasqName.Name]).Should(
+				ContainSubstring("auth-server=ns1.example.com\n" +
+					"auth-zone=ctlplane.example.com,192.168.122.0/24\n" +
+					"auth-soa=0,hostmaster.ns1.example.com,1200,180,1209600\n"))
+		})
+	})
+
+	When("A DNSMasq answering based on the client subnet is created", func() {
+		BeforeEach(func() {
+			spec := GetDefaultDNSMasqSpec()
+			spec["clientSubnets"] = map[string]interface{}{
+				"localiseQueries": true,
+				"addSubnet":       map[string]interface{}{},
+			}
+			instance := CreateDNSMasq(namespace, spec)
+			dnsMasqName = types.NamespacedName{
+				Name:      instance.GetName(),
+				Namespace: namespace,
+			}
+			DeferCleanup(th.DeleteInstance, instance)
+		})
+
+		It("renders the client subnet options", func() {
+			th.ExpectCondition(
+				dnsMasqName,
+				ConditionGetterFunc(DNSMasqConditionGetter),
+				condition.ServiceConfigReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			configData := th.GetConfigMap(dnsMasqName)
+			Expect(configData.Data[dnsMasqName.Name]).Should(
+				ContainSubstring("localise-queries\nadd-subnet=24,56\n"))
+		})
+	})
+
+	When("A DNSMasq with multiple replicas is created", func() {
+		BeforeEach(func() {
+			spec := GetDefaultDNSMasqSpec()
+			spec["replicas"] = 2
+			instance := CreateDNSMasq(namespace, spec)
+			dnsMasqName = types.NamespacedName{
+				Name:      instance.GetName(),
+				Namespace: namespace,
+			}
+			deploymentName = types.NamespacedName{
+				Name:      fmt.Sprintf("dnsmasq-%s", dnsMasqName.Name),
+				Namespace: namespace,
+			}
+			DeferCleanup(th.DeleteInstance, instance)
+		})
+
+		It("creates a PodDisruptionBudget for the pods of the instance", func() {
+			Eventually(func(g Gomega) {
+				instance := GetDNSMasq(dnsMasqName)
+				depl := th.GetDeployment(deploymentName)
+				g.Expect(depl.Spec.Template.Labels).To(HaveKeyWithValue(
+					"dnsmasq.openstack.org/uid", string(instance.UID)))
+
+				pdb := &policyv1.PodDisruptionBudget{}
+				g.Expect(th.K8sClient.Get(ctx, deploymentName, pdb)).To(Succeed())
+				g.Expect(pdb.Spec.MaxUnavailable.IntValue()).To(Equal(1))
+				g.Expect(pdb.Spec.Selector.MatchLabels).To(Equal(map[string]string{
+					"dnsmasq.openstack.org/uid": string(instance.UID),
+				}))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("deletes the PodDisruptionBudget when scaled down to one replica", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(th.K8sClient.Get(ctx, deploymentName, &policyv1.PodDisruptionBudget{})).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				instance := GetDNSMasq(dnsMasqName)
+				replicas := int32(1)
+				instance.Spec.Replicas = &replicas
+				g.Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				err := th.K8sClient.Get(ctx, deploymentName, &policyv1.PodDisruptionBudget{})
+				g.Expect(k8s_errors.IsNotFound(err)).To(BeTrue())
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("A DNSMasq with a network policy is created", func() {
+		var networkPolicyName types.NamespacedName
+		BeforeEach(func() {
+			spec := GetDefaultDNSMasqSpec()
+			spec["networkPolicy"] = map[string]interface{}{
+				"cidrs": []string{"192.168.122.0/24"},
+				"namespaceSelector": map[string]interface{}{
+					"matchLabels": map[string]interface{}{
+						"kubernetes.io/metadata.name": "openstack",
+					},
+				},
+			}
+			instance := CreateDNSMasq(namespace, spec)
+			dnsMasqName = types.NamespacedName{
+				Name:      instance.GetName(),
+				Namespace: namespace,
+			}
+			networkPolicyName = types.NamespacedName{
+				Name:      fmt.Sprintf("dnsmasq-%s", dnsMasqName.Name),
+				Namespace: namespace,
+			}
+			DeferCleanup(th.DeleteInstance, instance)
+		})
+
+		It("allows the DNS queries only from the configured clients", func() {
+			Eventually(func(g Gomega) {
+				instance := GetDNSMasq(dnsMasqName)
+				np := &networkingv1.NetworkPolicy{}
+				g.Expect(th.K8sClient.Get(ctx, networkPolicyName, np)).To(Succeed())
+				g.Expect(np.Spec.PodSelector.MatchLabels).To(Equal(map[string]string{
+					"dnsmasq.openstack.org/uid": string(instance.UID),
+				}))
+				g.Expect(np.Spec.Ingress).To(HaveLen(1))
+				g.Expect(np.Spec.Ingress[0].Ports).To(HaveLen(2))
+				g.Expect(np.Spec.Ingress[0].Ports[0].Port.IntValue()).To(Equal(53))
+				g.Expect(np.Spec.Ingress[0].From).To(HaveLen(2))
+				g.Expect(np.Spec.Ingress[0].From[0].IPBlock.CIDR).To(Equal("192.168.122.0/24"))
+				g.Expect(np.Spec.Ingress[0].From[1].NamespaceSelector.MatchLabels).To(
+					HaveKeyWithValue("kubernetes.io/metadata.name", "openstack"))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("deletes the NetworkPolicy when it gets disabled", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(th.K8sClient.Get(ctx, networkPolicyName, &networkingv1.NetworkPolicy{})).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				dnsmasq := GetDNSMasq(dnsMasqName)
+				dnsmasq.Spec.NetworkPolicy = nil
+				g.Expect(th.K8sClient.Update(ctx, dnsmasq)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				err := th.K8sClient.Get(ctx, networkPolicyName, &networkingv1.NetworkPolicy{})
+				g.Expect(k8s_errors.IsNotFound(err)).To(BeTrue())
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("A DNSMasq with a health check is created", func() {
+		BeforeEach(func() {
+			spec := GetDefaultDNSMasqSpec()
+			spec["healthCheck"] = map[string]interface{}{
+				"hostname": "canary.example.com",
+			}
+			instance := CreateDNSMasq(namespace, spec)
+			dnsMasqName = types.NamespacedName{
+				Name:      instance.GetName(),
+				Namespace: namespace,
+			}
+			deploymentName = types.NamespacedName{
+				Name:      fmt.Sprintf("dnsmasq-%s", dnsMasqName.Name),
+				Namespace: namespace,
+			}
+			DeferCleanup(th.DeleteInstance, instance)
+		})
+
+		It("allows the DNS queries of the operator in the NetworkPolicy", func() {
+			Eventually(func(g Gomega) {
+				dnsmasq := GetDNSMasq(dnsMasqName)
+				dnsmasq.Spec.NetworkPolicy = &networkv1.DNSMasqNetworkPolicy{
+					CIDRs: []string{"192.168.122.0/24"},
+				}
+				g.Expect(th.K8sClient.Update(ctx, dnsmasq)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				np := &networkingv1.NetworkPolicy{}
+				g.Expect(th.K8sClient.Get(ctx, deploymentName, np)).To(Succeed())
+				g.Expect(np.Spec.Ingress).To(HaveLen(2))
+				g.Expect(np.Spec.Ingress[1].From).To(HaveLen(1))
+				g.Expect(np.Spec.Ingress[1].From[0].PodSelector.MatchLabels).To(
+					HaveKeyWithValue("openstack.org/operator-name", "infra"))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("reports the failing resolution without affecting Ready", func() {
+			th.SimulateDeploymentReadyWithPods(deploymentName, map[string][]string{})
+
+			// nothing answers on the service address in the test environment
+			Eventually(func(g Gomega) {
+				instance := GetDNSMasq(dnsMasqName)
+				g.Expect(instance.Status.HealthCheckedAt).ToNot(BeNil())
+				degraded := instance.Status.Conditions.Get(networkv1.ResolutionDegradedCondition)
+				g.Expect(degraded).ToNot(BeNil())
+				g.Expect(degraded.Reason).To(Equal(networkv1.ResolutionFailedReason))
+				g.Expect(degraded.Message).To(ContainSubstring("canary.example.com"))
+				// the resolution runs in the background and times out after 5s
+			}, 2*timeout, interval).Should(Succeed())
+
+			th.ExpectCondition(
+				dnsMasqName,
+				ConditionGetterFunc(DNSMasqConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
 	})
 })
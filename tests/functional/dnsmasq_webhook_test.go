@@ -0,0 +1,72 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package functional_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+)
+
+var _ = Describe("DNSMasq webhook", func() {
+	createDNSMasq := func(spec map[string]interface{}) error {
+		raw := map[string]interface{}{
+			"apiVersion": "network.openstack.org/v1beta1",
+			"kind":       "DNSMasq",
+			"metadata": map[string]interface{}{
+				"name":      "dnsmasq-webhook",
+				"namespace": namespace,
+			},
+			"spec": spec,
+		}
+
+		unstructuredObj := &unstructured.Unstructured{Object: raw}
+		_, err := controllerutil.CreateOrPatch(
+			th.Ctx, th.K8sClient, unstructuredObj, func() error { return nil })
+		if err == nil {
+			DeferCleanup(th.DeleteInstance, unstructuredObj)
+		}
+		return err
+	}
+
+	It("accepts passthrough options", func() {
+		spec := GetDefaultDNSMasqSpec()
+		spec["options"] = interface{}([]networkv1.DNSMasqOption{
+			{
+				Key:    "dns-forward-max",
+				Values: []string{"300"},
+			},
+		})
+		Expect(createDNSMasq(spec)).To(Succeed())
+	})
+
+	It("rejects options managed by the operator", func() {
+		spec := GetDefaultDNSMasqSpec()
+		spec["options"] = interface{}([]networkv1.DNSMasqOption{
+			{
+				Key:    "conf-file",
+				Values: []string{"/tmp/dnsmasq.conf"},
+			},
+		})
+		err := createDNSMasq(spec)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("option conf-file is managed by the operator"))
+	})
+})
@@ -17,17 +17,25 @@ limitations under the License.
 package functional_test
 
 import (
+	"fmt"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	. "github.com/openstack-k8s-operators/lib-common/modules/common/test/helpers"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/ptr"
 
 	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+	"github.com/openstack-k8s-operators/infra-operator/pkg/ipam"
 	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
 )
 
@@ -83,6 +91,21 @@ var _ = Describe("IPSet controller", func() {
 				res := GetReservationFromNet(ipSetName, "net-1")
 				g.Expect(res.Address).To(Equal("172.17.0.100"))
 				g.Expect(res.DNSDomain).To(Equal("net-1.example.com"))
+				g.Expect(res.MTU).To(Equal(1400))
+				g.Expect(res.Vlan).To(Equal(ptr.To(20)))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("reports the MTU of the network after it got changed in the NetConfig", func() {
+			Eventually(func(g Gomega) {
+				netCfg := GetNetConfig(netCfgName)
+				netCfg.Spec.Networks[0].MTU = 9000
+				g.Expect(k8sClient.Update(ctx, netCfg)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				res := GetReservationFromNet(ipSetName, "net-1")
+				g.Expect(res.MTU).To(Equal(9000))
 			}, timeout, interval).Should(Succeed())
 		})
 
@@ -96,6 +119,189 @@ var _ = Describe("IPSet controller", func() {
 		})
 	})
 
+	When("an IPSet requests the default route on a subnet with routes", func() {
+		BeforeEach(func() {
+			subnet := GetSubnet1(subnet1)
+			subnet.Routes = []networkv1.Route{
+				{Destination: "172.18.0.0/24", Nexthop: "172.17.0.254"},
+			}
+			netCfg := CreateNetConfig(namespace, GetNetConfigSpec(GetNetSpec(net1, subnet)))
+			netCfgName.Name = netCfg.GetName()
+			netCfgName.Namespace = netCfg.GetNamespace()
+
+			Eventually(func(g Gomega) {
+				res := GetNetConfig(netCfgName)
+				g.Expect(res).ToNot(BeNil())
+			}, timeout, interval).Should(Succeed())
+
+			ipsetNet := GetIPSetNet1()
+			ipsetNet.DefaultRoute = ptr.To(true)
+			ipset := CreateIPSet(namespace, GetIPSetSpec(false, ipsetNet))
+			ipSetName = types.NamespacedName{
+				Name:      ipset.GetName(),
+				Namespace: namespace,
+			}
+
+			DeferCleanup(func(ctx SpecContext) {
+				th.DeleteInstance(ipset)
+				th.DeleteInstance(netCfg)
+			}, NodeTimeout(timeout))
+		})
+
+		It("reports the routes of the subnet and the default route", func() {
+			Eventually(func(g Gomega) {
+				res := GetReservationFromNet(ipSetName, "net-1")
+				g.Expect(res.Gateway).To(Equal(ptr.To("172.17.0.1")))
+				g.Expect(res.Routes).To(Equal([]networkv1.Route{
+					{Destination: "172.18.0.0/24", Nexthop: "172.17.0.254"},
+					{Destination: "0.0.0.0/0", Nexthop: "172.17.0.1"},
+				}))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("an IPSet requests an address from an exhausted subnet", func() {
+		var pendingIPSetName types.NamespacedName
+
+		BeforeEach(func() {
+			subnet := GetSubnet1(subnet1)
+			subnet.AllocationRanges = []networkv1.AllocationRange{
+				{Start: "172.17.0.100", End: "172.17.0.100"},
+			}
+			netCfg := CreateNetConfig(namespace, GetNetConfigSpec(GetNetSpec(net1, subnet)))
+			netCfgName.Name = netCfg.GetName()
+			netCfgName.Namespace = netCfg.GetNamespace()
+
+			Eventually(func(g Gomega) {
+				res := GetNetConfig(netCfgName)
+				g.Expect(res).ToNot(BeNil())
+			}, timeout, interval).Should(Succeed())
+
+			ipset := CreateIPSet(namespace, GetDefaultIPSetSpec())
+			ipSetName = types.NamespacedName{
+				Name:      ipset.GetName(),
+				Namespace: namespace,
+			}
+			Eventually(func(g Gomega) {
+				res := GetReservationFromNet(ipSetName, "net-1")
+				g.Expect(res.Address).To(Equal("172.17.0.100"))
+			}, timeout, interval).Should(Succeed())
+
+			pendingIPSet := CreateIPSet(namespace, GetDefaultIPSetSpec())
+			pendingIPSetName = types.NamespacedName{
+				Name:      pendingIPSet.GetName(),
+				Namespace: namespace,
+			}
+
+			DeferCleanup(func(ctx SpecContext) {
+				th.DeleteInstance(pendingIPSet)
+				th.DeleteInstance(ipset)
+				th.DeleteInstance(netCfg)
+			}, NodeTimeout(timeout))
+		})
+
+		It("gets the reservation after the allocation range got extended", func() {
+			th.ExpectCondition(
+				pendingIPSetName,
+				ConditionGetterFunc(IPSetConditionGetter),
+				networkv1.ReservationReadyCondition,
+				corev1.ConditionFalse,
+			)
+
+			Eventually(func(g Gomega) {
+				netCfg := GetNetConfig(netCfgName)
+				netCfg.Spec.Networks[0].Subnets[0].AllocationRanges[0].End = "172.17.0.110"
+				g.Expect(k8sClient.Update(ctx, netCfg)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				res := GetReservationFromNet(pendingIPSetName, "net-1")
+				g.Expect(res.Address).To(Equal("172.17.0.101"))
+			}, timeout, interval).Should(Succeed())
+			// the existing reservation is kept
+			Expect(GetReservationFromNet(ipSetName, "net-1").Address).To(Equal("172.17.0.100"))
+
+			th.ExpectCondition(
+				netCfgName,
+				ConditionGetterFunc(NetConfigConditionGetter),
+				networkv1.ReservationsValidCondition,
+				corev1.ConditionTrue,
+			)
+		})
+	})
+
+	When("an IPSet with a consumer gets deleted", func() {
+		var consumer *corev1.Pod
+
+		BeforeEach(func() {
+			netCfg := CreateNetConfig(namespace, GetDefaultNetConfigSpec())
+			netCfgName.Name = netCfg.GetName()
+			netCfgName.Namespace = netCfg.GetNamespace()
+
+			Eventually(func(g Gomega) {
+				res := GetNetConfig(netCfgName)
+				g.Expect(res).ToNot(BeNil())
+			}, timeout, interval).Should(Succeed())
+
+			consumer = &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "consumer",
+					Namespace: namespace,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "consumer", Image: "consumer"}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, consumer)).To(Succeed())
+
+			ipset := CreateIPSet(namespace, GetDefaultIPSetSpec())
+			ipSetName = types.NamespacedName{
+				Name:      ipset.GetName(),
+				Namespace: namespace,
+			}
+			Eventually(func(g Gomega) {
+				instance := GetIPSet(ipSetName)
+				instance.Annotations = map[string]string{networkv1.AnnotationConsumer: "Pod.v1/" + consumer.Name}
+				g.Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			DeferCleanup(func(ctx SpecContext) {
+				th.DeleteInstance(consumer)
+				th.DeleteInstance(ipset)
+				th.DeleteInstance(netCfg)
+			}, NodeTimeout(timeout*3))
+		})
+
+		It("keeps the reservation until the consumer got deleted", func() {
+			th.ExpectCondition(
+				ipSetName,
+				ConditionGetterFunc(IPSetConditionGetter),
+				networkv1.ReservationReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			Expect(k8sClient.Delete(ctx, GetIPSet(ipSetName))).To(Succeed())
+
+			th.ExpectConditionWithDetails(
+				ipSetName,
+				ConditionGetterFunc(IPSetConditionGetter),
+				networkv1.ConsumerReleasedCondition,
+				corev1.ConditionFalse,
+				networkv1.ConsumerExistsReason,
+				"Releasing the addresses blocked while consumer Pod consumer exists",
+			)
+			Expect(GetReservation(ipSetName).Spec.Reservation).To(HaveLen(1))
+
+			Expect(k8sClient.Delete(ctx, consumer)).To(Succeed())
+
+			// the consumer is not watched, it gets checked again after the requeue interval
+			Eventually(func(g Gomega) {
+				err := k8sClient.Get(ctx, ipSetName, &networkv1.IPSet{})
+				g.Expect(k8s_errors.IsNotFound(err)).To(BeTrue())
+			}, timeout*3, interval).Should(Succeed())
+		})
+	})
+
 	When("an IPSet with FixedIP inside AllocationRange gets created", func() {
 		BeforeEach(func() {
 			netCfg := CreateNetConfig(namespace, GetDefaultNetConfigSpec())
@@ -234,6 +440,220 @@ var _ = Describe("IPSet controller", func() {
 		})
 	})
 
+	When("an IPSet requests a FixedIP already reserved for another IPSet", func() {
+		var otherIPSet client.Object
+		var otherIPSetName types.NamespacedName
+
+		BeforeEach(func() {
+			netCfg := CreateNetConfig(namespace, GetDefaultNetConfigSpec())
+			netCfgName.Name = netCfg.GetName()
+			netCfgName.Namespace = netCfg.GetNamespace()
+
+			Eventually(func(g Gomega) {
+				res := GetNetConfig(netCfgName)
+				g.Expect(res).ToNot(BeNil())
+			}, timeout, interval).Should(Succeed())
+
+			otherIPSet = CreateIPSet(namespace, GetIPSetSpec(false, GetIPSetNet1WithFixedIP("172.17.0.150")))
+			otherIPSetName = types.NamespacedName{
+				Name:      otherIPSet.GetName(),
+				Namespace: namespace,
+			}
+			Eventually(func(g Gomega) {
+				res := GetReservationFromNet(otherIPSetName, "net-1")
+				g.Expect(res.Address).To(Equal("172.17.0.150"))
+			}, timeout, interval).Should(Succeed())
+
+			ipset := CreateIPSet(namespace, GetIPSetSpec(false, GetIPSetNet1WithFixedIP("172.17.0.150")))
+			ipSetName = types.NamespacedName{
+				Name:      ipset.GetName(),
+				Namespace: namespace,
+			}
+
+			DeferCleanup(func(ctx SpecContext) {
+				th.DeleteInstance(ipset)
+				th.DeleteInstance(netCfg)
+			}, NodeTimeout(timeout))
+		})
+
+		It("reports the FixedIP is already reserved", func() {
+			th.ExpectConditionWithDetails(
+				ipSetName,
+				ConditionGetterFunc(IPSetConditionGetter),
+				networkv1.ReservationReadyCondition,
+				corev1.ConditionFalse,
+				networkv1.FixedIPReservedReason,
+				fmt.Sprintf(networkv1.FixedIPReservedMessage, "172.17.0.150", "net-1", otherIPSet.GetName()),
+			)
+		})
+
+		It("counts the allocation failure", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(testutil.GatherAndCount(metrics.Registry, "infra_ipam_allocation_failures_total")).To(
+					BeNumerically(">=", 1))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("gets the FixedIP when the other IPSet releases it", func() {
+			th.DeleteInstance(otherIPSet)
+			// there is no garbage collector in envtest to remove the owned Reservation
+			th.DeleteInstance(GetReservation(otherIPSetName))
+
+			Eventually(func(g Gomega) {
+				res := GetReservationFromNet(ipSetName, "net-1")
+				g.Expect(res.Address).To(Equal("172.17.0.150"))
+			}, timeout, interval).Should(Succeed())
+
+			th.ExpectCondition(
+				ipSetName,
+				ConditionGetterFunc(IPSetConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+	})
+
+	When("an IPSet with Ordinals gets created", func() {
+		// ordinalAddresses returns the addresses of the IPSet status by ordinal
+		ordinalAddresses := func(g Gomega) map[int]string {
+			addresses := map[int]string{}
+			for _, res := range GetIPSet(ipSetName).Status.Reservation {
+				g.Expect(res.Ordinal).ToNot(BeNil())
+				addresses[*res.Ordinal] = res.Address
+			}
+			return addresses
+		}
+
+		BeforeEach(func() {
+			netCfg := CreateNetConfig(namespace, GetDefaultNetConfigSpec())
+			netCfgName.Name = netCfg.GetName()
+			netCfgName.Namespace = netCfg.GetNamespace()
+
+			Eventually(func(g Gomega) {
+				res := GetNetConfig(netCfgName)
+				g.Expect(res).ToNot(BeNil())
+			}, timeout, interval).Should(Succeed())
+
+			spec := GetDefaultIPSetSpec()
+			spec["ordinals"] = 3
+			ipset := CreateIPSet(namespace, spec)
+			ipSetName = types.NamespacedName{
+				Name:      ipset.GetName(),
+				Namespace: namespace,
+			}
+
+			DeferCleanup(func(ctx SpecContext) {
+				th.DeleteInstance(ipset)
+				th.DeleteInstance(netCfg)
+			}, NodeTimeout(timeout))
+		})
+
+		It("reserves a block of consecutive addresses, one per ordinal", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(ordinalAddresses(g)).To(Equal(map[int]string{
+					0: "172.17.0.100",
+					1: "172.17.0.101",
+					2: "172.17.0.102",
+				}))
+			}, timeout, interval).Should(Succeed())
+
+			res := GetReservation(ipSetName)
+			Expect(res.Spec.Reservation).To(HaveLen(3))
+			Expect(res.Spec.Reservation).To(HaveKey("net-1.2"))
+			Expect(res.Spec.Reservation["net-1.2"].Address).To(Equal("172.17.0.102"))
+
+			th.ExpectCondition(
+				ipSetName,
+				ConditionGetterFunc(IPSetConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+
+		It("keeps the addresses of the ordinals when scaled up and down", func() {
+			th.ExpectCondition(
+				ipSetName,
+				ConditionGetterFunc(IPSetConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			Eventually(func(g Gomega) {
+				instance := GetIPSet(ipSetName)
+				instance.Spec.Ordinals = ptr.To(5)
+				g.Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				g.Expect(ordinalAddresses(g)).To(Equal(map[int]string{
+					0: "172.17.0.100",
+					1: "172.17.0.101",
+					2: "172.17.0.102",
+					3: "172.17.0.103",
+					4: "172.17.0.104",
+				}))
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				instance := GetIPSet(ipSetName)
+				instance.Spec.Ordinals = ptr.To(2)
+				g.Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				g.Expect(ordinalAddresses(g)).To(Equal(map[int]string{
+					0: "172.17.0.100",
+					1: "172.17.0.101",
+				}))
+
+				res := GetReservation(ipSetName)
+				g.Expect(res.Spec.Reservation).To(HaveLen(2))
+				g.Expect(res.Labels).ToNot(HaveKey(ipam.IPAMLabelKey + "/net-1.2"))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("does not move the block if it can not grow", func() {
+			th.ExpectCondition(
+				ipSetName,
+				ConditionGetterFunc(IPSetConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			otherIPSet := CreateIPSet(namespace, GetDefaultIPSetSpec())
+			otherIPSetName := types.NamespacedName{
+				Name:      otherIPSet.GetName(),
+				Namespace: namespace,
+			}
+			DeferCleanup(th.DeleteInstance, otherIPSet)
+			Eventually(func(g Gomega) {
+				res := GetReservationFromNet(otherIPSetName, "net-1")
+				g.Expect(res.Address).To(Equal("172.17.0.103"))
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				instance := GetIPSet(ipSetName)
+				instance.Spec.Ordinals = ptr.To(4)
+				g.Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			th.ExpectConditionWithDetails(
+				ipSetName,
+				ConditionGetterFunc(IPSetConditionGetter),
+				networkv1.ReservationReadyCondition,
+				corev1.ConditionFalse,
+				networkv1.FixedIPReservedReason,
+				fmt.Sprintf(networkv1.FixedIPReservedMessage, "172.17.0.103", "net-1", otherIPSet.GetName()),
+			)
+
+			Eventually(func(g Gomega) {
+				res := GetReservation(ipSetName)
+				g.Expect(res.Spec.Reservation).To(HaveLen(3))
+				g.Expect(res.Spec.Reservation["net-1.0"].Address).To(Equal("172.17.0.100"))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
 	When("a GetDefaultIPSetSpec IPSet gets created using a custom NetConfig", func() {
 		BeforeEach(func() {
 			netSpec := GetNetSpec(net1, GetSubnet1(subnet1))
@@ -0,0 +1,199 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package functional_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/openstack-k8s-operators/lib-common/modules/common/tls"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	memcachedv1 "github.com/openstack-k8s-operators/infra-operator/apis/memcached/v1beta1"
+)
+
+// CreateMemcachedPod creates a ready pod of the Memcached CR, as there is no
+// StatefulSet controller running in envtest
+func CreateMemcachedPod(name types.NamespacedName, memcachedName string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+			Labels: map[string]string{
+				"cr":    memcachedName,
+				"owner": "infra-operator",
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:  "memcached",
+				Image: memcachedv1.MemcachedContainerImage,
+			}},
+		},
+	}
+	Expect(k8sClient.Create(ctx, pod)).Should(Succeed())
+
+	Eventually(func(g Gomega) {
+		g.Expect(k8sClient.Get(ctx, name, pod)).Should(Succeed())
+		pod.Status.Conditions = []corev1.PodCondition{{
+			Type:   corev1.PodReady,
+			Status: corev1.ConditionTrue,
+		}}
+		g.Expect(k8sClient.Status().Update(ctx, pod)).Should(Succeed())
+	}, timeout, interval).Should(Succeed())
+
+	return pod
+}
+
+var _ = Describe("Memcached controller", func() {
+	var memcachedName types.NamespacedName
+	var certSecretName types.NamespacedName
+
+	BeforeEach(func() {
+		memcachedName = types.NamespacedName{
+			Name:      "memcached",
+			Namespace: namespace,
+		}
+		certSecretName = types.NamespacedName{
+			Name:      "cert-memcached-svc",
+			Namespace: namespace,
+		}
+	})
+
+	When("the TLS certificate of a Memcached gets rotated", func() {
+		var podName types.NamespacedName
+
+		BeforeEach(func() {
+			DeferCleanup(th.DeleteInstance, th.CreateCertSecret(certSecretName))
+
+			spec := memcachedv1.MemcachedSpec{
+				ContainerImage: memcachedv1.MemcachedContainerImage,
+				Replicas:       ptr.To[int32](1),
+				TLS: tls.SimpleService{
+					GenericService: tls.GenericService{
+						SecretName: ptr.To(certSecretName.Name),
+					},
+				},
+			}
+			mc := &memcachedv1.Memcached{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      memcachedName.Name,
+					Namespace: memcachedName.Namespace,
+				},
+				Spec: spec,
+			}
+			// the webhook looks up the certificate secret via the cache
+			Eventually(func(g Gomega) {
+				g.Expect(k8sClient.Create(ctx, mc)).Should(Succeed())
+			}, timeout, interval).Should(Succeed())
+			DeferCleanup(th.DeleteInstance, mc)
+
+			podName = types.NamespacedName{
+				Name:      memcachedName.Name + "-0",
+				Namespace: namespace,
+			}
+			DeferCleanup(th.DeleteInstance, CreateMemcachedPod(podName, memcachedName.Name))
+
+			Eventually(func(g Gomega) {
+				g.Expect(infra.GetMemcached(memcachedName).Status.TLSCertHash).NotTo(BeEmpty())
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("should roll the pods which run with the previous certificate", func() {
+			hash := infra.GetMemcached(memcachedName).Status.TLSCertHash
+
+			// the creation timestamp of the pod has a granularity of seconds,
+			// it has to be before the start of the roll
+			time.Sleep(time.Second)
+			th.UpdateSecret(certSecretName, "tls.crt", []byte("rotated"))
+
+			Eventually(func(g Gomega) {
+				pod := &corev1.Pod{}
+				err := k8sClient.Get(ctx, podName, pod)
+				g.Expect(k8s_errors.IsNotFound(err)).To(BeTrue())
+			}, timeout, interval).Should(Succeed())
+
+			// the progress of the roll is checked every 10 seconds
+			Eventually(func(g Gomega) {
+				mc := infra.GetMemcached(memcachedName)
+				g.Expect(mc.Status.TLSCertHash).NotTo(Equal(hash))
+				g.Expect(mc.Status.TLSCertRotationStartedAt).To(BeNil())
+			}, 2*timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("a pod consumes the server list of a Memcached", func() {
+		var mc *memcachedv1.Memcached
+
+		BeforeEach(func() {
+			mc = &memcachedv1.Memcached{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      memcachedName.Name,
+					Namespace: memcachedName.Namespace,
+				},
+				Spec: memcachedv1.MemcachedSpec{
+					ContainerImage: memcachedv1.MemcachedContainerImage,
+					Replicas:       ptr.To[int32](1),
+				},
+			}
+			Expect(k8sClient.Create(ctx, mc)).Should(Succeed())
+			DeferCleanup(th.DeleteInstance, mc)
+
+			consumer := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "keystone",
+					Namespace: namespace,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:  "keystone",
+						Image: "keystone",
+					}},
+					Volumes: []corev1.Volume{{
+						Name: "memcached-servers",
+						VolumeSource: corev1.VolumeSource{
+							Secret: &corev1.SecretVolumeSource{SecretName: mc.ServerListSecretName()},
+						},
+					}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, consumer)).Should(Succeed())
+			DeferCleanup(th.DeleteInstance, consumer)
+		})
+
+		It("can not be scaled to zero via the scale subresource", func() {
+			// the webhook looks up the Memcached and the pods via the cache
+			Eventually(func(g Gomega) {
+				scale := &autoscalingv1.Scale{Spec: autoscalingv1.ScaleSpec{Replicas: 0}}
+				err := k8sClient.SubResource("scale").Update(ctx, mc, client.WithSubResourceBody(scale))
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring("the server list is consumed by pods keystone"))
+			}, timeout, interval).Should(Succeed())
+			Expect(*infra.GetMemcached(memcachedName).Spec.Replicas).To(Equal(int32(1)))
+
+			scale := &autoscalingv1.Scale{Spec: autoscalingv1.ScaleSpec{Replicas: 2}}
+			Expect(k8sClient.SubResource("scale").Update(ctx, mc, client.WithSubResourceBody(scale))).Should(Succeed())
+			Expect(*infra.GetMemcached(memcachedName).Spec.Replicas).To(Equal(int32(2)))
+		})
+	})
+})
@@ -0,0 +1,159 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package functional_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/openstack-k8s-operators/lib-common/modules/common/test/helpers"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	rabbitmqv1 "github.com/openstack-k8s-operators/infra-operator/apis/rabbitmq/v1beta1"
+	"github.com/openstack-k8s-operators/infra-operator/pkg/rabbitmq"
+)
+
+var _ = Describe("MessagingLink controller", func() {
+	var linkName types.NamespacedName
+	var centralClusterName types.NamespacedName
+	var edgeClusterName types.NamespacedName
+
+	BeforeEach(func() {
+		linkName = types.NamespacedName{
+			Name:      "edge-notifications",
+			Namespace: namespace,
+		}
+		centralClusterName = types.NamespacedName{
+			Name:      "rabbitmq",
+			Namespace: namespace,
+		}
+		edgeClusterName = types.NamespacedName{
+			Name:      "rabbitmq-edge",
+			Namespace: namespace,
+		}
+		CreateRabbitMQCluster(centralClusterName, GetDefaultRabbitMQClusterSpec(false))
+		DeferCleanup(DeleteRabbitMQCluster, centralClusterName)
+		CreateRabbitMQCluster(edgeClusterName, GetDefaultRabbitMQClusterSpec(false))
+		DeferCleanup(DeleteRabbitMQCluster, edgeClusterName)
+	})
+
+	When("a shovel MessagingLink gets created", func() {
+		BeforeEach(func() {
+			spec := map[string]interface{}{
+				"source": map[string]interface{}{
+					"rabbitmqClusterName": edgeClusterName.Name,
+					"vhost":               "nova",
+					"queue":               "notifications.info",
+				},
+				"destination": map[string]interface{}{
+					"rabbitmqClusterName": centralClusterName.Name,
+					"exchange":            "nova",
+				},
+			}
+			CreateMessagingLink(linkName, spec)
+		})
+
+		It("should wait for both clusters to be ready", func() {
+			SimulateRabbitMQClusterReady(edgeClusterName)
+			th.ExpectCondition(
+				linkName,
+				ConditionGetterFunc(MessagingLinkConditionGetter),
+				rabbitmqv1.MessagingLinkReadyCondition,
+				corev1.ConditionFalse,
+			)
+			th.DeleteInstance(infra.GetMessagingLink(linkName))
+		})
+
+		It("should configure the shovel on the source cluster", func() {
+			SimulateRabbitMQClusterReady(edgeClusterName)
+			SimulateRabbitMQClusterReady(centralClusterName)
+
+			th.ExpectCondition(
+				linkName,
+				ConditionGetterFunc(MessagingLinkConditionGetter),
+				rabbitmqv1.MessagingLinkReadyCondition,
+				corev1.ConditionTrue,
+			)
+			link := infra.GetMessagingLink(linkName)
+			Expect(link.Spec.Type).To(Equal(rabbitmqv1.MessagingLinkTypeShovel))
+			Expect(link.Status.RabbitmqClusterName).To(Equal(edgeClusterName.Name))
+			Expect(link.Status.UserRabbitmqClusterNames).To(ConsistOf(edgeClusterName.Name, centralClusterName.Name))
+
+			user := "link-" + linkName.Name
+			secret := th.GetSecret(types.NamespacedName{Name: "rabbitmq-link-user-" + linkName.Name, Namespace: namespace})
+			password := string(secret.Data["password"])
+			_, ok := mgmt.GetPermissions("nova", user)
+			Expect(ok).To(BeTrue())
+			_, ok = mgmt.GetPermissions("/", user)
+			Expect(ok).To(BeTrue())
+
+			value, ok := mgmt.GetParameter(rabbitmq.ShovelComponent, "nova", linkName.Name)
+			Expect(ok).To(BeTrue())
+			Expect(value).To(Equal(map[string]interface{}{
+				"src-protocol":  "amqp091",
+				"src-uri":       fmt.Sprintf("amqp://%s:%s@host.%s.svc:5672/nova", user, password, namespace),
+				"src-queue":     "notifications.info",
+				"dest-protocol": "amqp091",
+				"dest-uri":      fmt.Sprintf("amqp://%s:%s@host.%s.svc:5672/", user, password, namespace),
+				"dest-exchange": "nova",
+			}))
+
+			th.DeleteInstance(link)
+			infra.AssertMessagingLinkDoesNotExist(linkName)
+			_, ok = mgmt.GetParameter(rabbitmq.ShovelComponent, "nova", linkName.Name)
+			Expect(ok).To(BeFalse())
+			Expect(mgmt.HasUser(user)).To(BeFalse())
+		})
+	})
+
+	When("a federation MessagingLink gets created", func() {
+		BeforeEach(func() {
+			spec := map[string]interface{}{
+				"type": "federation",
+				"source": map[string]interface{}{
+					"rabbitmqClusterName": centralClusterName.Name,
+					"exchange":            "nova",
+				},
+				"destination": map[string]interface{}{
+					"rabbitmqClusterName": edgeClusterName.Name,
+					"exchange":            "nova",
+				},
+			}
+			DeferCleanup(th.DeleteInstance, CreateMessagingLink(linkName, spec))
+		})
+
+		It("should configure the upstream and policy on the destination cluster", func() {
+			SimulateRabbitMQClusterReady(edgeClusterName)
+			SimulateRabbitMQClusterReady(centralClusterName)
+
+			th.ExpectCondition(
+				linkName,
+				ConditionGetterFunc(MessagingLinkConditionGetter),
+				rabbitmqv1.MessagingLinkReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			_, ok := mgmt.GetParameter(rabbitmq.FederationUpstreamComponent, "/", linkName.Name)
+			Expect(ok).To(BeTrue())
+			policy, ok := mgmt.GetPolicy("/", "federation-"+linkName.Name)
+			Expect(ok).To(BeTrue())
+			Expect(policy.Pattern).To(Equal("^nova$"))
+			Expect(policy.Definition).To(HaveKeyWithValue("federation-upstream", linkName.Name))
+		})
+	})
+})
@@ -0,0 +1,135 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package functional_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/openstack-k8s-operators/lib-common/modules/common/test/helpers"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	rabbitmqv1 "github.com/openstack-k8s-operators/infra-operator/apis/rabbitmq/v1beta1"
+	"github.com/openstack-k8s-operators/infra-operator/pkg/rabbitmq"
+)
+
+var _ = Describe("MessagingPolicy controller", func() {
+	var policyName types.NamespacedName
+	var rabbitmqClusterName types.NamespacedName
+
+	BeforeEach(func() {
+		policyName = types.NamespacedName{
+			Name:      "notifications-ttl",
+			Namespace: namespace,
+		}
+		rabbitmqClusterName = types.NamespacedName{
+			Name:      "rabbitmq",
+			Namespace: namespace,
+		}
+	})
+
+	When("a MessagingPolicy gets created", func() {
+		BeforeEach(func() {
+			CreateRabbitMQCluster(rabbitmqClusterName, GetDefaultRabbitMQClusterSpec(false))
+			DeferCleanup(DeleteRabbitMQCluster, rabbitmqClusterName)
+
+			spec := map[string]interface{}{
+				"rabbitmqClusterName": rabbitmqClusterName.Name,
+				"vhost":               "nova",
+				"pattern":             "^notifications\\.",
+				"definition": map[string]interface{}{
+					"messageTTL": 3600000,
+					"maxLength":  10000,
+				},
+			}
+			DeferCleanup(th.DeleteInstance, CreateMessagingPolicy(policyName, spec))
+		})
+
+		It("should have the Spec defaults set", func() {
+			policy := infra.GetMessagingPolicy(policyName)
+			Expect(policy.Spec.ApplyTo).To(Equal("queues"))
+			Expect(policy.Spec.Priority).To(Equal(int32(0)))
+		})
+
+		It("should wait for the rabbitmq cluster to be ready", func() {
+			th.ExpectCondition(
+				policyName,
+				ConditionGetterFunc(MessagingPolicyConditionGetter),
+				rabbitmqv1.MessagingPolicyReadyCondition,
+				corev1.ConditionFalse,
+			)
+			_, ok := mgmt.GetPolicy("nova", policyName.Name)
+			Expect(ok).To(BeFalse())
+		})
+
+		It("should apply the policy on the vhost", func() {
+			SimulateRabbitMQClusterReady(rabbitmqClusterName)
+
+			th.ExpectCondition(
+				policyName,
+				ConditionGetterFunc(MessagingPolicyConditionGetter),
+				rabbitmqv1.MessagingPolicyReadyCondition,
+				corev1.ConditionTrue,
+			)
+			policy, ok := mgmt.GetPolicy("nova", policyName.Name)
+			Expect(ok).To(BeTrue())
+			Expect(policy).To(Equal(rabbitmq.Policy{
+				Pattern: "^notifications\\.",
+				ApplyTo: "queues",
+				Definition: map[string]interface{}{
+					"message-ttl": int64(3600000),
+					"max-length":  int64(10000),
+				},
+			}))
+		})
+
+		It("should move the policy to another vhost", func() {
+			SimulateRabbitMQClusterReady(rabbitmqClusterName)
+			th.ExpectCondition(
+				policyName,
+				ConditionGetterFunc(MessagingPolicyConditionGetter),
+				rabbitmqv1.MessagingPolicyReadyCondition,
+				corev1.ConditionTrue,
+			)
+
+			Eventually(func(g Gomega) {
+				policy := infra.GetMessagingPolicy(policyName)
+				policy.Spec.Vhost = "cinder"
+				g.Expect(th.K8sClient.Update(th.Ctx, policy)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				_, ok := mgmt.GetPolicy("cinder", policyName.Name)
+				g.Expect(ok).To(BeTrue())
+				_, ok = mgmt.GetPolicy("nova", policyName.Name)
+				g.Expect(ok).To(BeFalse())
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("should delete the policy with the MessagingPolicy", func() {
+			SimulateRabbitMQClusterReady(rabbitmqClusterName)
+			Eventually(func(g Gomega) {
+				_, ok := mgmt.GetPolicy("nova", policyName.Name)
+				g.Expect(ok).To(BeTrue())
+			}, timeout, interval).Should(Succeed())
+
+			th.DeleteInstance(infra.GetMessagingPolicy(policyName))
+			infra.AssertMessagingPolicyDoesNotExist(policyName)
+			_, ok := mgmt.GetPolicy("nova", policyName.Name)
+			Expect(ok).To(BeFalse())
+		})
+	})
+})
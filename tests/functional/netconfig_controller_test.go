@@ -0,0 +1,597 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package functional_test
+
+import (
+	"encoding/json"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/openstack-k8s-operators/lib-common/modules/common/test/helpers"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	networkv1 "github.com/openstack-k8s-operators/infra-operator/apis/network/v1beta1"
+	condition "github.com/openstack-k8s-operators/lib-common/modules/common/condition"
+)
+
+var _ = Describe("NetConfig controller", func() {
+	var netCfgName types.NamespacedName
+	var ipSetName types.NamespacedName
+
+	When("a NetConfig with an IPSet gets created", func() {
+		BeforeEach(func() {
+			netCfg := CreateNetConfig(namespace, GetDefaultNetConfigSpec())
+			netCfgName = types.NamespacedName{
+				Name:      netCfg.GetName(),
+				Namespace: namespace,
+			}
+
+			Eventually(func(g Gomega) {
+				res := GetNetConfig(netCfgName)
+				g.Expect(res).ToNot(BeNil())
+			}, timeout, interval).Should(Succeed())
+
+			ipset := CreateIPSet(namespace, GetDefaultIPSetSpec())
+			ipSetName = types.NamespacedName{
+				Name:      ipset.GetName(),
+				Namespace: namespace,
+			}
+
+			DeferCleanup(func(ctx SpecContext) {
+				th.DeleteInstance(ipset)
+				th.DeleteInstance(netCfg)
+			}, NodeTimeout(timeout))
+		})
+
+		It("reports the overall state is ready", func() {
+			th.ExpectCondition(
+				netCfgName,
+				ConditionGetterFunc(NetConfigConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+
+		It("should list the IPSet as owner of its IP", func() {
+			Eventually(func(g Gomega) {
+				cm := th.GetConfigMap(types.NamespacedName{
+					Name:      GetNetConfig(netCfgName).IPOwnerConfigMapName(),
+					Namespace: namespace,
+				})
+				owners := map[string]networkv1.IPOwner{}
+				g.Expect(json.Unmarshal([]byte(cm.Data["owners.json"]), &owners)).To(Succeed())
+				g.Expect(owners).To(HaveKeyWithValue("172.17.0.100", networkv1.IPOwner{
+					IPSet:   ipSetName.Name,
+					Network: net1,
+					Subnet:  subnet1,
+				}))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("exports the address utilization of the subnet", func() {
+			Eventually(func(g Gomega) {
+				for _, name := range []string{
+					"infra_ipam_subnet_addresses",
+					"infra_ipam_subnet_reserved_addresses",
+					"infra_ipam_subnet_free_addresses",
+				} {
+					g.Expect(testutil.GatherAndCount(metrics.Registry, name)).To(BeNumerically(">=", 1))
+				}
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("keeps a DNSData of the user with the name of the generated one", func() {
+			dnsData := &networkv1.DNSData{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      GetNetConfig(netCfgName).DNSDataName(),
+					Namespace: namespace,
+				},
+				Spec: networkv1.DNSDataSpec{
+					DNSDataLabelSelectorValue: "someselector",
+					Hosts: []networkv1.DNSHost{
+						{IP: "172.17.0.50", Hostnames: []string{"user-host"}},
+					},
+				},
+			}
+			Expect(th.K8sClient.Create(ctx, dnsData)).To(Succeed())
+			DeferCleanup(th.DeleteInstance, dnsData)
+
+			th.ExpectCondition(
+				netCfgName,
+				ConditionGetterFunc(NetConfigConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+			Consistently(func(g Gomega) {
+				g.Expect(th.K8sClient.Get(ctx, client.ObjectKeyFromObject(dnsData), &networkv1.DNSData{})).To(Succeed())
+			}, timeout/2, interval).Should(Succeed())
+		})
+
+		It("reports the address utilization of the subnet in the status", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(GetNetConfig(netCfgName).Status.Subnets).To(ContainElement(networkv1.SubnetUtilization{
+					Network:  net1,
+					Subnet:   subnet1,
+					Total:    101,
+					Reserved: 1,
+					Free:     100,
+				}))
+			}, timeout, interval).Should(Succeed())
+
+			th.ExpectCondition(
+				netCfgName,
+				ConditionGetterFunc(NetConfigConditionGetter),
+				networkv1.AddressPoolAvailableCondition,
+				corev1.ConditionTrue,
+			)
+		})
+	})
+
+	When("a NetConfig with a network publishing its DNS records gets created", func() {
+		var dnsDataName types.NamespacedName
+
+		BeforeEach(func() {
+			net := GetNetSpec(net1, GetSubnet1(subnet1))
+			net.PublishDNSRecords = true
+			netCfg := CreateNetConfig(namespace, GetNetConfigSpec(net))
+			netCfgName = types.NamespacedName{
+				Name:      netCfg.GetName(),
+				Namespace: namespace,
+			}
+			dnsDataName = types.NamespacedName{
+				Name:      GetNetConfig(netCfgName).DNSDataName(),
+				Namespace: namespace,
+			}
+
+			ipset := CreateIPSet(namespace, GetDefaultIPSetSpec())
+			ipSetName = types.NamespacedName{
+				Name:      ipset.GetName(),
+				Namespace: namespace,
+			}
+
+			DeferCleanup(func(ctx SpecContext) {
+				th.DeleteInstance(ipset)
+				// no garbage collection in envtest
+				th.DeleteInstance(GetDNSData(dnsDataName))
+				th.DeleteInstance(netCfg)
+			}, NodeTimeout(timeout))
+		})
+
+		It("publishes the address of the IPSet in the DNSData", func() {
+			Eventually(func(g Gomega) {
+				dnsData := GetDNSData(dnsDataName)
+				g.Expect(dnsData.Spec.PTRRecords).To(BeFalse())
+				g.Expect(dnsData.Spec.Hosts).To(ConsistOf(networkv1.DNSHost{
+					IP:        "172.17.0.100",
+					Hostnames: []string{ipSetName.Name + ".net-1.example.com"},
+					Owner: &networkv1.DNSHostOwner{
+						Kind: networkv1.DNSHostOwnerIPSet,
+						Name: ipSetName.Name,
+					},
+				}))
+			}, timeout, interval).Should(Succeed())
+
+			th.ExpectCondition(
+				netCfgName,
+				ConditionGetterFunc(NetConfigConditionGetter),
+				networkv1.DNSDataReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+
+		It("does not restart dnsmasq when an IPSet gets added", func() {
+			dnsMasq := CreateDNSMasq(namespace, GetDefaultDNSMasqSpec())
+			DeferCleanup(th.DeleteInstance, dnsMasq)
+			deploymentName := types.NamespacedName{
+				Name:      fmt.Sprintf("dnsmasq-%s", dnsMasq.GetName()),
+				Namespace: namespace,
+			}
+			hostsCMName := types.NamespacedName{
+				Name:      fmt.Sprintf("dnsmasq-%s-hosts", dnsMasq.GetName()),
+				Namespace: namespace,
+			}
+
+			configHash := ""
+			Eventually(func(g Gomega) {
+				hostsCM := th.GetConfigMap(hostsCMName)
+				g.Expect(hostsCM.Data).To(HaveKeyWithValue(
+					dnsDataName.Name, ContainSubstring(ipSetName.Name)))
+
+				depl := th.GetDeployment(deploymentName)
+				configHash = GetEnvVarValue(depl.Spec.Template.Spec.Containers[0].Env, "CONFIG_HASH", "")
+				g.Expect(configHash).ToNot(BeEmpty())
+			}, timeout, interval).Should(Succeed())
+
+			ipset := CreateIPSet(namespace, GetDefaultIPSetSpec())
+			DeferCleanup(th.DeleteInstance, ipset)
+
+			Eventually(func(g Gomega) {
+				hostsCM := th.GetConfigMap(hostsCMName)
+				g.Expect(hostsCM.Data).To(HaveKeyWithValue(
+					dnsDataName.Name, ContainSubstring(ipset.GetName())))
+			}, timeout, interval).Should(Succeed())
+
+			Consistently(func(g Gomega) {
+				depl := th.GetDeployment(deploymentName)
+				g.Expect(GetEnvVarValue(depl.Spec.Template.Spec.Containers[0].Env, "CONFIG_HASH", "")).To(Equal(configHash))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("a NetConfig with a BGPAnnouncement gets created without FRR-K8s installed", func() {
+		BeforeEach(func() {
+			defaults := networkv1.GetNetConfigDefaults()
+			networkv1.SetupNetConfigDefaults(networkv1.NetConfigDefaults{
+				FRRK8sNamespace: "metallb-system",
+				BGPASN:          64999,
+				BGPNeighbors:    []networkv1.BGPNeighbor{{Address: "172.30.0.1", ASN: 64998}},
+			})
+			DeferCleanup(networkv1.SetupNetConfigDefaults, defaults)
+
+			spec := GetDefaultNetConfigSpec()
+			spec["bgpAnnouncement"] = networkv1.BGPAnnouncement{
+				Neighbors: []string{"172.30.0.1"},
+			}
+			netCfg := CreateNetConfig(namespace, spec)
+			netCfgName = types.NamespacedName{
+				Name:      netCfg.GetName(),
+				Namespace: namespace,
+			}
+
+			DeferCleanup(th.DeleteInstance, netCfg)
+		})
+
+		It("reports the FRRConfiguration could not be created", func() {
+			th.ExpectCondition(
+				netCfgName,
+				ConditionGetterFunc(NetConfigConditionGetter),
+				networkv1.BGPAnnouncementReadyCondition,
+				corev1.ConditionFalse,
+			)
+			th.ExpectCondition(
+				netCfgName,
+				ConditionGetterFunc(NetConfigConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionFalse,
+			)
+		})
+
+		It("adds the finalizer to clean up the FRRConfiguration", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(GetNetConfig(netCfgName).Finalizers).ToNot(BeEmpty())
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("removes the finalizer when the BGPAnnouncement gets removed", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(GetNetConfig(netCfgName).Finalizers).ToNot(BeEmpty())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				netCfg := GetNetConfig(netCfgName)
+				netCfg.Spec.BGPAnnouncement = nil
+				g.Expect(k8sClient.Update(ctx, netCfg)).To(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				g.Expect(GetNetConfig(netCfgName).Finalizers).To(BeEmpty())
+			}, timeout, interval).Should(Succeed())
+			th.ExpectCondition(
+				netCfgName,
+				ConditionGetterFunc(NetConfigConditionGetter),
+				networkv1.BGPAnnouncementReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+	})
+
+	When("a NetConfig disables the FreeAddressesThreshold", func() {
+		BeforeEach(func() {
+			spec := GetDefaultNetConfigSpec()
+			spec["freeAddressesThreshold"] = 0
+			netCfg := CreateNetConfig(namespace, spec)
+			netCfgName = types.NamespacedName{
+				Name:      netCfg.GetName(),
+				Namespace: namespace,
+			}
+
+			DeferCleanup(th.DeleteInstance, netCfg)
+		})
+
+		It("keeps the threshold at 0 instead of the default", func() {
+			th.ExpectCondition(
+				netCfgName,
+				ConditionGetterFunc(NetConfigConditionGetter),
+				networkv1.AddressPoolAvailableCondition,
+				corev1.ConditionTrue,
+			)
+			threshold := GetNetConfig(netCfgName).Spec.FreeAddressesThreshold
+			Expect(threshold).NotTo(BeNil())
+			Expect(*threshold).To(BeZero())
+		})
+	})
+
+	When("a NetConfig has less free addresses than the FreeAddressesThreshold", func() {
+		BeforeEach(func() {
+			spec := GetDefaultNetConfigSpec()
+			spec["freeAddressesThreshold"] = 200
+			netCfg := CreateNetConfig(namespace, spec)
+			netCfgName = types.NamespacedName{
+				Name:      netCfg.GetName(),
+				Namespace: namespace,
+			}
+
+			DeferCleanup(th.DeleteInstance, netCfg)
+		})
+
+		It("reports a warning on the AddressPoolAvailable condition", func() {
+			th.ExpectConditionWithDetails(
+				netCfgName,
+				ConditionGetterFunc(NetConfigConditionGetter),
+				networkv1.AddressPoolAvailableCondition,
+				corev1.ConditionFalse,
+				networkv1.AddressPoolLowReason,
+				"Less than 200 free addresses in subnets: net-1/subnet1 (101 free)",
+			)
+		})
+
+		It("still reports the overall state is ready", func() {
+			th.ExpectCondition(
+				netCfgName,
+				ConditionGetterFunc(NetConfigConditionGetter),
+				condition.ReadyCondition,
+				corev1.ConditionTrue,
+			)
+		})
+	})
+
+	When("a NetConfig with ImportedReservations gets created", func() {
+		var reservationName types.NamespacedName
+
+		BeforeEach(func() {
+			reservationName = types.NamespacedName{
+				Name:      "adopted-ipset",
+				Namespace: namespace,
+			}
+
+			spec := GetDefaultNetConfigSpec()
+			spec["importedReservations"] = []networkv1.ImportedReservation{
+				{
+					IPSet: reservationName.Name,
+					Addresses: []networkv1.ImportedAddress{
+						{Network: net1, Subnet: subnet1, Address: "172.17.0.150"},
+					},
+				},
+			}
+			netCfg := CreateNetConfig(namespace, spec)
+			netCfgName = types.NamespacedName{
+				Name:      netCfg.GetName(),
+				Namespace: namespace,
+			}
+
+			DeferCleanup(func(ctx SpecContext) {
+				th.DeleteInstance(GetReservation(reservationName))
+				th.DeleteInstance(netCfg)
+			}, NodeTimeout(timeout))
+		})
+
+		It("seeds a Reservation for the IPSet", func() {
+			Eventually(func(g Gomega) {
+				res := GetReservation(reservationName)
+				g.Expect(res.Spec.IPSetRef.Name).To(Equal(reservationName.Name))
+				g.Expect(res.Spec.Reservation).To(HaveKeyWithValue(net1, networkv1.IPAddress{
+					Network: net1,
+					Subnet:  subnet1,
+					Address: "172.17.0.150",
+				}))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("keeps the imported address when the IPSet gets created", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(GetReservation(reservationName).Spec.Reservation).To(HaveKey(net1))
+			}, timeout, interval).Should(Succeed())
+
+			ipset := th.CreateUnstructured(map[string]interface{}{
+				"apiVersion": "network.openstack.org/v1beta1",
+				"kind":       "IPSet",
+				"metadata": map[string]interface{}{
+					"name":      reservationName.Name,
+					"namespace": namespace,
+				},
+				"spec": GetDefaultIPSetSpec(),
+			})
+			DeferCleanup(th.DeleteInstance, ipset)
+
+			Eventually(func(g Gomega) {
+				res := GetReservationFromNet(reservationName, "net-1")
+				g.Expect(res.Address).To(Equal("172.17.0.150"))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("a NetConfig with an ImportedReservation of an already reserved address gets created", func() {
+		var reservationName types.NamespacedName
+
+		BeforeEach(func() {
+			res := CreateStaleReservation(namespace, "172.17.0.150")
+			reservationName = types.NamespacedName{
+				Name:      "imported-ipset",
+				Namespace: namespace,
+			}
+
+			spec := GetDefaultNetConfigSpec()
+			spec["importedReservations"] = []networkv1.ImportedReservation{
+				{
+					IPSet: "conflicting-ipset",
+					Addresses: []networkv1.ImportedAddress{
+						{Network: net1, Subnet: subnet1, Address: "172.17.0.150"},
+					},
+				},
+				{
+					IPSet: reservationName.Name,
+					Addresses: []networkv1.ImportedAddress{
+						{Network: net1, Subnet: subnet1, Address: "172.17.0.151"},
+					},
+				},
+			}
+			netCfg := CreateNetConfig(namespace, spec)
+			netCfgName = types.NamespacedName{
+				Name:      netCfg.GetName(),
+				Namespace: namespace,
+			}
+
+			DeferCleanup(func(ctx SpecContext) {
+				th.DeleteInstance(GetReservation(reservationName))
+				th.DeleteInstance(res)
+				th.DeleteInstance(netCfg)
+			}, NodeTimeout(timeout))
+		})
+
+		It("skips the conflicting one and imports the others", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(GetReservation(reservationName).Spec.Reservation).To(HaveKey(net1))
+				g.Expect(GetNetConfig(netCfgName).Status.ConflictingImports).To(ConsistOf(
+					ContainSubstring("IPSet conflicting-ipset")))
+			}, timeout, interval).Should(Succeed())
+
+			th.ExpectCondition(
+				netCfgName,
+				ConditionGetterFunc(NetConfigConditionGetter),
+				networkv1.ReservationsValidCondition,
+				corev1.ConditionFalse,
+			)
+			err := th.K8sClient.Get(ctx, types.NamespacedName{Name: "conflicting-ipset", Namespace: namespace}, &networkv1.Reservation{})
+			Expect(k8s_errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	When("an address is reserved for more than one IPSet", func() {
+		var firstOwner string
+
+		BeforeEach(func() {
+			netCfg := CreateNetConfig(namespace, GetDefaultNetConfigSpec())
+			netCfgName = types.NamespacedName{
+				Name:      netCfg.GetName(),
+				Namespace: namespace,
+			}
+
+			res1 := CreateStaleReservation(namespace, "172.17.0.160")
+			res2 := CreateStaleReservation(namespace, "172.17.0.160")
+			firstOwner = res1.Spec.IPSetRef.Name
+			if res2.Name < res1.Name {
+				firstOwner = res2.Spec.IPSetRef.Name
+			}
+
+			DeferCleanup(func(ctx SpecContext) {
+				th.DeleteInstance(res1)
+				th.DeleteInstance(res2)
+				th.DeleteInstance(netCfg)
+			}, NodeTimeout(timeout))
+		})
+
+		It("keeps the first owner and reports the duplicate", func() {
+			Eventually(func(g Gomega) {
+				cond := GetNetConfig(netCfgName).Status.Conditions.Get(networkv1.IPOwnerReadyCondition)
+				g.Expect(cond).NotTo(BeNil())
+				g.Expect(cond.Status).To(Equal(corev1.ConditionFalse))
+				g.Expect(cond.Reason).To(Equal(networkv1.DuplicateIPOwnerReason))
+				g.Expect(cond.Message).To(ContainSubstring("172.17.0.160"))
+				g.Expect(cond.Message).To(ContainSubstring("owned by " + firstOwner))
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				cm := th.GetConfigMap(types.NamespacedName{
+					Name:      GetNetConfig(netCfgName).IPOwnerConfigMapName(),
+					Namespace: namespace,
+				})
+				owners := map[string]networkv1.IPOwner{}
+				g.Expect(json.Unmarshal([]byte(cm.Data["owners.json"]), &owners)).To(Succeed())
+				g.Expect(owners).To(HaveKeyWithValue("172.17.0.160", HaveField("IPSet", firstOwner)))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("a Reservation of a no longer existing IPSet is found", func() {
+		var reservationName types.NamespacedName
+
+		BeforeEach(func() {
+			netCfg := CreateNetConfig(namespace, GetDefaultNetConfigSpec())
+			netCfgName = types.NamespacedName{
+				Name:      netCfg.GetName(),
+				Namespace: namespace,
+			}
+
+			res := CreateStaleReservation(namespace, "172.17.0.150")
+			reservationName = types.NamespacedName{
+				Name:      res.GetName(),
+				Namespace: namespace,
+			}
+
+			DeferCleanup(func(ctx SpecContext) {
+				th.DeleteInstance(res)
+				th.DeleteInstance(netCfg)
+			}, NodeTimeout(timeout))
+		})
+
+		It("keeps the Reservation until the StaleReservationTTL expired", func() {
+			Eventually(func(g Gomega) {
+				g.Expect(GetNetConfig(netCfgName).Status.StaleReservations).To(ContainElement(reservationName.Name))
+				g.Expect(GetReservation(reservationName).Annotations).To(HaveKey("network.openstack.org/stale-since"))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	When("a Reservation of a no longer existing IPSet is found with StaleReservationTTL 0s", func() {
+		var reservationName types.NamespacedName
+
+		BeforeEach(func() {
+			spec := GetDefaultNetConfigSpec()
+			spec["staleReservationTTL"] = "0s"
+			netCfg := CreateNetConfig(namespace, spec)
+			netCfgName = types.NamespacedName{
+				Name:      netCfg.GetName(),
+				Namespace: namespace,
+			}
+
+			res := CreateStaleReservation(namespace, "172.17.0.150")
+			reservationName = types.NamespacedName{
+				Name:      res.GetName(),
+				Namespace: namespace,
+			}
+
+			DeferCleanup(func(ctx SpecContext) {
+				th.DeleteInstance(netCfg)
+			}, NodeTimeout(timeout))
+		})
+
+		It("releases the Reservation", func() {
+			Eventually(func(g Gomega) {
+				err := k8sClient.Get(ctx, reservationName, &networkv1.Reservation{})
+				g.Expect(k8s_errors.IsNotFound(err)).To(BeTrue())
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+})
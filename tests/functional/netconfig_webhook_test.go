@@ -91,5 +91,53 @@ var _ = Describe("NetConfig webhook", func() {
 				Expect(k8sClient.Delete(ctx, netcfg)).To(HaveOccurred())
 			})
 		})
+
+		When("a there is a Reservation in the subnet", func() {
+			BeforeEach(func() {
+				res := CreateStaleReservation(netConfigName.Namespace, "172.17.0.150")
+				DeferCleanup(th.DeleteInstance, res)
+			})
+
+			It("should not be possible to remove the reserved address from the AllocationRanges", func() {
+				netcfg := GetNetConfig(netConfigName)
+				netcfg.Spec.Networks[0].Subnets[0].AllocationRanges[0].End = "172.17.0.149"
+
+				Expect(k8sClient.Update(ctx, netcfg)).To(HaveOccurred())
+			})
+
+			It("should be possible to extend the AllocationRanges", func() {
+				Eventually(func(g Gomega) {
+					netcfg := GetNetConfig(netConfigName)
+					netcfg.Spec.Networks[0].Subnets[0].AllocationRanges[0].End = "172.17.0.250"
+
+					g.Expect(k8sClient.Update(ctx, netcfg)).To(Succeed())
+				}, timeout, interval).Should(Succeed())
+			})
+
+			It("should be possible to change the AllocationRanges with the override annotation", func() {
+				Eventually(func(g Gomega) {
+					netcfg := GetNetConfig(netConfigName)
+					netcfg.Annotations = map[string]string{networkv1.AnnotationAllowSubnetChanges: "true"}
+					netcfg.Spec.Networks[0].Subnets[0].AllocationRanges[0].End = "172.17.0.149"
+
+					g.Expect(k8sClient.Update(ctx, netcfg)).To(Succeed())
+				}, timeout, interval).Should(Succeed())
+			})
+
+			It("reports the Reservation as invalid once excluded with the override annotation", func() {
+				Eventually(func(g Gomega) {
+					netcfg := GetNetConfig(netConfigName)
+					netcfg.Annotations = map[string]string{networkv1.AnnotationAllowSubnetChanges: "true"}
+					netcfg.Spec.Networks[0].Subnets[0].ExcludeAddresses = append(
+						netcfg.Spec.Networks[0].Subnets[0].ExcludeAddresses, "172.17.0.150")
+
+					g.Expect(k8sClient.Update(ctx, netcfg)).To(Succeed())
+				}, timeout, interval).Should(Succeed())
+
+				Eventually(func(g Gomega) {
+					g.Expect(GetNetConfig(netConfigName).Status.InvalidReservations).To(HaveLen(1))
+				}, timeout, interval).Should(Succeed())
+			})
+		})
 	})
 })
@@ -0,0 +1,254 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package functional_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/openstack-k8s-operators/infra-operator/pkg/rabbitmq"
+)
+
+// fakeManagement - in memory RabbitMQ management API recording the vhosts,
+// users and permissions managed by the TransportURL controller
+type fakeManagement struct {
+	mu          sync.Mutex
+	vhosts      map[string]bool
+	users       map[string]string
+	permissions map[string]rabbitmq.Permissions
+	connections map[string]int
+	// refused - users whose AMQP connections get refused, with the broker error
+	refused    map[string]string
+	policies   map[string]rabbitmq.Policy
+	parameters map[string]interface{}
+	// externalLogins - users who connected with a client certificate and
+	// without password
+	externalLogins map[string]bool
+}
+
+func newFakeManagement() *fakeManagement {
+	return &fakeManagement{
+		vhosts:      map[string]bool{},
+		users:       map[string]string{},
+		permissions: map[string]rabbitmq.Permissions{},
+		connections: map[string]int{},
+		refused:     map[string]string{},
+		policies:    map[string]rabbitmq.Policy{},
+		parameters:  map[string]interface{}{},
+
+		externalLogins: map[string]bool{},
+	}
+}
+
+// factory - rabbitmq.ManagementFactory returning the fake
+func (m *fakeManagement) factory(string, string, string, *tls.Config) rabbitmq.Management {
+	return m
+}
+
+// connect - rabbitmq.ConnectFunc failing for the refused users
+func (m *fakeManagement) connect(_ context.Context, _ rabbitmq.Host, user string, password string, _ string, tlsConfig *tls.Config) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if reason, ok := m.refused[user]; ok {
+		return errors.New(reason)
+	}
+	if password == "" && tlsConfig != nil && len(tlsConfig.Certificates) > 0 {
+		m.externalLogins[user] = true
+	}
+	return nil
+}
+
+// Refuse - refuses the AMQP connections of user with the broker error reason
+func (m *fakeManagement) Refuse(user string, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.refused[user] = reason
+}
+
+func (m *fakeManagement) PutVhost(_ context.Context, vhost string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.vhosts[vhost] = true
+	return nil
+}
+
+func (m *fakeManagement) DeleteVhost(_ context.Context, vhost string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.vhosts, vhost)
+	for key := range m.permissions {
+		if key[:strings.LastIndex(key, "/")] == vhost {
+			delete(m.permissions, key)
+		}
+	}
+	return nil
+}
+
+func (m *fakeManagement) PutUser(_ context.Context, user string, password string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.users[user] = password
+	return nil
+}
+
+func (m *fakeManagement) PutPermissions(_ context.Context, vhost string, user string, permissions rabbitmq.Permissions) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.permissions[vhost+"/"+user] = permissions
+	return nil
+}
+
+func (m *fakeManagement) DeleteUser(_ context.Context, user string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.users, user)
+	for key := range m.permissions {
+		if strings.HasSuffix(key, "/"+user) {
+			delete(m.permissions, key)
+		}
+	}
+	return nil
+}
+
+func (m *fakeManagement) UserConnections(_ context.Context, user string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.connections[user], nil
+}
+
+func (m *fakeManagement) PutPolicy(_ context.Context, vhost string, name string, policy rabbitmq.Policy) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.policies[vhost+"/"+name] = policy
+	return nil
+}
+
+func (m *fakeManagement) DeletePolicy(_ context.Context, vhost string, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.policies, vhost+"/"+name)
+	return nil
+}
+
+func (m *fakeManagement) PutParameter(_ context.Context, component string, vhost string, name string, value interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.parameters[component+"/"+vhost+"/"+name] = value
+	return nil
+}
+
+func (m *fakeManagement) DeleteParameter(_ context.Context, component string, vhost string, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.parameters, component+"/"+vhost+"/"+name)
+	return nil
+}
+
+func (m *fakeManagement) HasExternalLogin(user string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.externalLogins[user]
+}
+
+func (m *fakeManagement) HasUser(user string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.users[user]
+	return ok
+}
+
+func (m *fakeManagement) HasVhost(vhost string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.vhosts[vhost]
+}
+
+func (m *fakeManagement) GetPermissions(vhost string, user string) (rabbitmq.Permissions, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.permissions[vhost+"/"+user]
+	return p, ok
+}
+
+func (m *fakeManagement) GetPolicy(vhost string, name string) (rabbitmq.Policy, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.policies[vhost+"/"+name]
+	return p, ok
+}
+
+func (m *fakeManagement) GetParameter(component string, vhost string, name string) (interface{}, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.parameters[component+"/"+vhost+"/"+name]
+	return v, ok
+}
+
+// generateCACert returns a PEM encoded self-signed CA certificate
+func generateCACert() []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "rootca-internal"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).ToNot(HaveOccurred())
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// generateClientCert returns a PEM encoded self-signed client certificate and
+// its key
+func generateClientCert(commonName string) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).ToNot(HaveOccurred())
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).ToNot(HaveOccurred())
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
@@ -0,0 +1,70 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package functional_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("TransportURL webhook", func() {
+	createTransportURL := func(spec map[string]interface{}) error {
+		raw := map[string]interface{}{
+			"apiVersion": "rabbitmq.openstack.org/v1beta1",
+			"kind":       "TransportURL",
+			"metadata": map[string]interface{}{
+				"name":      "invalid",
+				"namespace": namespace,
+			},
+			"spec": spec,
+		}
+
+		unstructuredObj := &unstructured.Unstructured{Object: raw}
+		_, err := controllerutil.CreateOrPatch(
+			th.Ctx, th.K8sClient, unstructuredObj, func() error { return nil })
+		return err
+	}
+
+	It("rejects a TransportURL referencing a rabbitmq cluster and an external endpoint", func() {
+		err := createTransportURL(map[string]interface{}{
+			"rabbitmqClusterName": "rabbitmq",
+			"external": map[string]interface{}{
+				"host":       "rabbitmq.example.com",
+				"secretName": "external-rabbitmq",
+			},
+		})
+		Expect(err).To(MatchError(ContainSubstring("external can not be set together with rabbitmqClusterName")))
+	})
+
+	It("rejects a TransportURL with an invalid rabbitmq cluster name", func() {
+		err := createTransportURL(map[string]interface{}{
+			"rabbitmqClusterName": "RabbitMQ_cell1",
+		})
+		Expect(err).To(MatchError(ContainSubstring("spec.rabbitmqClusterName")))
+	})
+
+	It("rejects quorum queues for the amqp1 driver", func() {
+		err := createTransportURL(map[string]interface{}{
+			"rabbitmqClusterName": "rabbitmq",
+			"driver":              "amqp1",
+			"quorumQueues":        true,
+		})
+		Expect(err).To(MatchError(ContainSubstring("quorumQueues is not supported by the amqp1 driver")))
+	})
+})